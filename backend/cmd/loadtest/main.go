@@ -0,0 +1,179 @@
+// Command loadtest drives a configurable request mix against a running sub2api
+// instance and reports throughput, TTFT, latency percentiles, queue wait, 429
+// rates and failover counts per concurrency stage.
+//
+// Point it at an instance whose accounts use base_url https://<profile>.mock.invalid
+// (with mock_upstream.enabled) to benchmark the scheduler, concurrency slots and
+// billing cache end-to-end without spending upstream quota. Enable
+// gateway.diagnostic_headers on the server to collect queue wait and failovers.
+//
+// Examples:
+//
+//	loadtest -key sk-xxx -models claude-sonnet-4-5=3,claude-haiku-4-5=1 -stream-ratio 0.7 \
+//	    -concurrency 64 -ramp-start 8 -ramp-step 8 -ramp-interval 30s -duration 5m
+//	loadtest -config plan.yaml -json > report.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/loadtest"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Plan file (YAML/JSON); flags below override its values when set")
+	baseURL := flag.String("url", "http://127.0.0.1:8080", "sub2api base URL")
+	apiKey := flag.String("key", os.Getenv("SUB2API_API_KEY"), "API key (defaults to $SUB2API_API_KEY)")
+	duration := flag.Duration("duration", time.Minute, "Total test duration")
+	timeout := flag.Duration("timeout", 5*time.Minute, "Per-request timeout")
+	concurrency := flag.Int("concurrency", 10, "Maximum concurrency")
+	rampStart := flag.Int("ramp-start", 0, "Initial concurrency (0 = start at -concurrency)")
+	rampStep := flag.Int("ramp-step", 0, "Concurrency added every -ramp-interval")
+	rampInterval := flag.Duration("ramp-interval", 0, "Duration of each ramp stage")
+	format := flag.String("format", loadtest.FormatMessages, "Request format: messages, responses or gemini")
+	models := flag.String("models", "claude-sonnet-4-5", "Comma-separated models with optional weights, e.g. a=3,b=1")
+	streamRatio := flag.Float64("stream-ratio", 0.5, "Fraction of streaming requests (0-1)")
+	promptTokens := flag.String("prompt-tokens", "200", "Comma-separated approximate prompt sizes in tokens, picked at random")
+	maxTokens := flag.Int("max-tokens", 256, "max_tokens for each request")
+	jsonOut := flag.Bool("json", false, "Print the report as JSON instead of tables")
+	quiet := flag.Bool("quiet", false, "Disable progress output")
+	flag.Parse()
+
+	plan := &loadtest.Plan{}
+	if *configPath != "" {
+		var err error
+		if plan, err = loadtest.LoadPlan(*configPath); err != nil {
+			log.Fatalf("failed to load plan: %v", err)
+		}
+	}
+
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	override := func(name string) bool { return *configPath == "" || set[name] }
+
+	if override("url") || plan.BaseURL == "" {
+		plan.BaseURL = *baseURL
+	}
+	if override("key") || plan.APIKey == "" {
+		plan.APIKey = *apiKey
+	}
+	if override("duration") {
+		plan.Duration = *duration
+	}
+	if override("timeout") {
+		plan.Timeout = *timeout
+	}
+	if override("concurrency") {
+		plan.Ramp.Max = *concurrency
+	}
+	if override("ramp-start") {
+		plan.Ramp.Start = *rampStart
+	}
+	if override("ramp-step") {
+		plan.Ramp.Step = *rampStep
+	}
+	if override("ramp-interval") {
+		plan.Ramp.StepDuration = *rampInterval
+	}
+	if len(plan.Scenarios) == 0 || set["models"] || set["format"] || set["stream-ratio"] || set["prompt-tokens"] || set["max-tokens"] {
+		scenarios, err := scenariosFromFlags(*format, *models, *streamRatio, *promptTokens, *maxTokens)
+		if err != nil {
+			log.Fatalf("invalid flags: %v", err)
+		}
+		plan.Scenarios = scenarios
+	}
+	if err := plan.Normalize(); err != nil {
+		log.Fatalf("invalid plan: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	runner := loadtest.NewRunner(plan, nil)
+	var done, failed atomic.Int64
+	runner.OnSample = func(s *loadtest.Sample) {
+		done.Add(1)
+		if !s.OK() {
+			failed.Add(1)
+		}
+	}
+	if !*quiet {
+		go func() {
+			ticker := time.NewTicker(5 * time.Second)
+			defer ticker.Stop()
+			start := time.Now()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					fmt.Fprintf(os.Stderr, "[%s] completed=%d failed=%d\n", time.Since(start).Round(time.Second), done.Load(), failed.Load())
+				}
+			}
+		}()
+	}
+
+	fmt.Fprintf(os.Stderr, "Load testing %s for %s (concurrency %d -> %d, %d scenarios)\n",
+		plan.BaseURL, plan.Duration, plan.Ramp.Start, plan.Ramp.Max, len(plan.Scenarios))
+	report := runner.Run(ctx)
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("failed to encode report: %v", err)
+		}
+		return
+	}
+	report.WriteText(os.Stdout)
+}
+
+// scenariosFromFlags builds one scenario per model from the command line flags.
+func scenariosFromFlags(format, models string, streamRatio float64, promptTokens string, maxTokens int) ([]loadtest.Scenario, error) {
+	var sizes []int
+	for _, part := range strings.Split(promptTokens, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("prompt-tokens: %w", err)
+		}
+		sizes = append(sizes, n)
+	}
+
+	var scenarios []loadtest.Scenario
+	for _, part := range strings.Split(models, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		model, weight := part, 1
+		if name, w, ok := strings.Cut(part, "="); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(w))
+			if err != nil {
+				return nil, fmt.Errorf("models: invalid weight in %q", part)
+			}
+			model, weight = strings.TrimSpace(name), n
+		}
+		scenarios = append(scenarios, loadtest.Scenario{
+			Format:       format,
+			Model:        model,
+			Weight:       weight,
+			StreamRatio:  streamRatio,
+			PromptTokens: sizes,
+			MaxTokens:    maxTokens,
+		})
+	}
+	return scenarios, nil
+}
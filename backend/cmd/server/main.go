@@ -8,7 +8,6 @@ import (
 	"errors"
 	"flag"
 	"log"
-	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -19,6 +18,8 @@ import (
 	_ "github.com/Wei-Shaw/sub2api/ent/runtime"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/handler"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
 	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/setup"
 	"github.com/Wei-Shaw/sub2api/internal/web"
@@ -45,19 +46,14 @@ func init() {
 	}
 }
 
-// initLogger configures the default slog handler based on gin.Mode().
-// In non-release mode, Debug level logs are enabled.
+// initLogger configures the default slog handler based on gin.Mode() until the
+// config is loaded. In non-release mode, Debug level logs are enabled.
 func initLogger() {
-	var level slog.Level
+	level := "debug"
 	if gin.Mode() == gin.ReleaseMode {
-		level = slog.LevelInfo
-	} else {
-		level = slog.LevelDebug
+		level = "info"
 	}
-	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: level,
-	})
-	slog.SetDefault(slog.New(handler))
+	_ = logger.Init(logger.Options{Level: level, Format: logger.FormatText})
 }
 
 func main() {
@@ -132,10 +128,32 @@ func runMainServer() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if err := logger.Init(logger.Options{Level: cfg.Log.Level, Format: cfg.Log.Format}); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
 	if cfg.RunMode == config.RunModeSimple {
 		log.Println("⚠️  WARNING: Running in SIMPLE mode - billing and quota checks are DISABLED")
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Enabled:        cfg.Tracing.Enabled,
+		ServiceName:    cfg.Tracing.ServiceName,
+		ServiceVersion: Version,
+		Endpoint:       cfg.Tracing.Endpoint,
+		Headers:        cfg.Tracing.Headers,
+		SampleRatio:    cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
+
 	buildInfo := handler.BuildInfo{
 		Version:   Version,
 		BuildType: BuildType,
@@ -125,6 +125,20 @@ func (_c *AccountCreate) SetNillableProxyID(v *int64) *AccountCreate {
 	return _c
 }
 
+// SetProxyPoolID sets the "proxy_pool_id" field.
+func (_c *AccountCreate) SetProxyPoolID(v int64) *AccountCreate {
+	_c.mutation.SetProxyPoolID(v)
+	return _c
+}
+
+// SetNillableProxyPoolID sets the "proxy_pool_id" field if the given value is not nil.
+func (_c *AccountCreate) SetNillableProxyPoolID(v *int64) *AccountCreate {
+	if v != nil {
+		_c.SetProxyPoolID(*v)
+	}
+	return _c
+}
+
 // SetConcurrency sets the "concurrency" field.
 func (_c *AccountCreate) SetConcurrency(v int) *AccountCreate {
 	_c.mutation.SetConcurrency(v)
@@ -591,6 +605,10 @@ func (_c *AccountCreate) createSpec() (*Account, *sqlgraph.CreateSpec) {
 		_spec.SetField(account.FieldExtra, field.TypeJSON, value)
 		_node.Extra = value
 	}
+	if value, ok := _c.mutation.ProxyPoolID(); ok {
+		_spec.SetField(account.FieldProxyPoolID, field.TypeInt64, value)
+		_node.ProxyPoolID = &value
+	}
 	if value, ok := _c.mutation.Concurrency(); ok {
 		_spec.SetField(account.FieldConcurrency, field.TypeInt, value)
 		_node.Concurrency = value
@@ -882,6 +900,30 @@ func (u *AccountUpsert) ClearProxyID() *AccountUpsert {
 	return u
 }
 
+// SetProxyPoolID sets the "proxy_pool_id" field.
+func (u *AccountUpsert) SetProxyPoolID(v int64) *AccountUpsert {
+	u.Set(account.FieldProxyPoolID, v)
+	return u
+}
+
+// UpdateProxyPoolID sets the "proxy_pool_id" field to the value that was provided on create.
+func (u *AccountUpsert) UpdateProxyPoolID() *AccountUpsert {
+	u.SetExcluded(account.FieldProxyPoolID)
+	return u
+}
+
+// AddProxyPoolID adds v to the "proxy_pool_id" field.
+func (u *AccountUpsert) AddProxyPoolID(v int64) *AccountUpsert {
+	u.Add(account.FieldProxyPoolID, v)
+	return u
+}
+
+// ClearProxyPoolID clears the value of the "proxy_pool_id" field.
+func (u *AccountUpsert) ClearProxyPoolID() *AccountUpsert {
+	u.SetNull(account.FieldProxyPoolID)
+	return u
+}
+
 // SetConcurrency sets the "concurrency" field.
 func (u *AccountUpsert) SetConcurrency(v int) *AccountUpsert {
 	u.Set(account.FieldConcurrency, v)
@@ -1326,6 +1368,34 @@ func (u *AccountUpsertOne) ClearProxyID() *AccountUpsertOne {
 	})
 }
 
+// SetProxyPoolID sets the "proxy_pool_id" field.
+func (u *AccountUpsertOne) SetProxyPoolID(v int64) *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.SetProxyPoolID(v)
+	})
+}
+
+// AddProxyPoolID adds v to the "proxy_pool_id" field.
+func (u *AccountUpsertOne) AddProxyPoolID(v int64) *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.AddProxyPoolID(v)
+	})
+}
+
+// UpdateProxyPoolID sets the "proxy_pool_id" field to the value that was provided on create.
+func (u *AccountUpsertOne) UpdateProxyPoolID() *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.UpdateProxyPoolID()
+	})
+}
+
+// ClearProxyPoolID clears the value of the "proxy_pool_id" field.
+func (u *AccountUpsertOne) ClearProxyPoolID() *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.ClearProxyPoolID()
+	})
+}
+
 // SetConcurrency sets the "concurrency" field.
 func (u *AccountUpsertOne) SetConcurrency(v int) *AccountUpsertOne {
 	return u.Update(func(s *AccountUpsert) {
@@ -1978,6 +2048,34 @@ func (u *AccountUpsertBulk) ClearProxyID() *AccountUpsertBulk {
 	})
 }
 
+// SetProxyPoolID sets the "proxy_pool_id" field.
+func (u *AccountUpsertBulk) SetProxyPoolID(v int64) *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.SetProxyPoolID(v)
+	})
+}
+
+// AddProxyPoolID adds v to the "proxy_pool_id" field.
+func (u *AccountUpsertBulk) AddProxyPoolID(v int64) *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.AddProxyPoolID(v)
+	})
+}
+
+// UpdateProxyPoolID sets the "proxy_pool_id" field to the value that was provided on create.
+func (u *AccountUpsertBulk) UpdateProxyPoolID() *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.UpdateProxyPoolID()
+	})
+}
+
+// ClearProxyPoolID clears the value of the "proxy_pool_id" field.
+func (u *AccountUpsertBulk) ClearProxyPoolID() *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.ClearProxyPoolID()
+	})
+}
+
 // SetConcurrency sets the "concurrency" field.
 func (u *AccountUpsertBulk) SetConcurrency(v int) *AccountUpsertBulk {
 	return u.Update(func(s *AccountUpsert) {
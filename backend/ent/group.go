@@ -46,6 +46,8 @@ type Group struct {
 	MonthlyLimitUsd *float64 `json:"monthly_limit_usd,omitempty"`
 	// DefaultValidityDays holds the value of the "default_validity_days" field.
 	DefaultValidityDays int `json:"default_validity_days,omitempty"`
+	// SubscriptionPrice holds the value of the "subscription_price" field.
+	SubscriptionPrice *float64 `json:"subscription_price,omitempty"`
 	// ImagePrice1k holds the value of the "image_price_1k" field.
 	ImagePrice1k *float64 `json:"image_price_1k,omitempty"`
 	// ImagePrice2k holds the value of the "image_price_2k" field.
@@ -60,6 +62,22 @@ type Group struct {
 	ModelRouting map[string][]int64 `json:"model_routing,omitempty"`
 	// 是否启用模型路由配置
 	ModelRoutingEnabled bool `json:"model_routing_enabled,omitempty"`
+	// 账号选择策略：空表示默认（优先级 + 最后使用时间/随机）
+	AccountSelectionStrategy string `json:"account_selection_strategy,omitempty"`
+	// 允许使用的图片生成模型（支持 * 通配符），为空表示不限制
+	ImageModels []string `json:"image_models,omitempty"`
+	// 内容策略命中动作：空表示不检查，block/log/strip
+	ContentPolicyAction string `json:"content_policy_action,omitempty"`
+	// 请求改写规则列表（按顺序应用于 Claude/Responses/Gemini 请求）
+	RequestRewriteRules json.RawMessage `json:"request_rewrite_rules,omitempty"`
+	// 模型别名：别名 -> 实际模型
+	ModelAliases map[string]string `json:"model_aliases,omitempty"`
+	// 允许使用的模型（支持 * 通配符），为空表示不限制
+	AllowedModels []string `json:"allowed_models,omitempty"`
+	// 禁止使用的模型（支持 * 通配符），优先于白名单
+	DeniedModels []string `json:"denied_models,omitempty"`
+	// 订阅 token/请求数限额列表（按窗口与模型族统计）
+	UsageLimits json.RawMessage `json:"usage_limits,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the GroupQuery when eager-loading is set.
 	Edges        GroupEdges `json:"edges"`
@@ -166,15 +184,15 @@ func (*Group) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case group.FieldModelRouting:
+		case group.FieldModelRouting, group.FieldImageModels, group.FieldRequestRewriteRules, group.FieldModelAliases, group.FieldAllowedModels, group.FieldDeniedModels, group.FieldUsageLimits:
 			values[i] = new([]byte)
 		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled:
 			values[i] = new(sql.NullBool)
-		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k:
+		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldSubscriptionPrice, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k:
 			values[i] = new(sql.NullFloat64)
 		case group.FieldID, group.FieldDefaultValidityDays, group.FieldFallbackGroupID:
 			values[i] = new(sql.NullInt64)
-		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType:
+		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType, group.FieldAccountSelectionStrategy, group.FieldContentPolicyAction:
 			values[i] = new(sql.NullString)
 		case group.FieldCreatedAt, group.FieldUpdatedAt, group.FieldDeletedAt:
 			values[i] = new(sql.NullTime)
@@ -288,6 +306,13 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.DefaultValidityDays = int(value.Int64)
 			}
+		case group.FieldSubscriptionPrice:
+			if value, ok := values[i].(*sql.NullFloat64); !ok {
+				return fmt.Errorf("unexpected type %T for field subscription_price", values[i])
+			} else if value.Valid {
+				_m.SubscriptionPrice = new(float64)
+				*_m.SubscriptionPrice = value.Float64
+			}
 		case group.FieldImagePrice1k:
 			if value, ok := values[i].(*sql.NullFloat64); !ok {
 				return fmt.Errorf("unexpected type %T for field image_price_1k", values[i])
@@ -336,6 +361,66 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.ModelRoutingEnabled = value.Bool
 			}
+		case group.FieldAccountSelectionStrategy:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field account_selection_strategy", values[i])
+			} else if value.Valid {
+				_m.AccountSelectionStrategy = value.String
+			}
+		case group.FieldImageModels:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field image_models", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ImageModels); err != nil {
+					return fmt.Errorf("unmarshal field image_models: %w", err)
+				}
+			}
+		case group.FieldContentPolicyAction:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field content_policy_action", values[i])
+			} else if value.Valid {
+				_m.ContentPolicyAction = value.String
+			}
+		case group.FieldRequestRewriteRules:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field request_rewrite_rules", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.RequestRewriteRules); err != nil {
+					return fmt.Errorf("unmarshal field request_rewrite_rules: %w", err)
+				}
+			}
+		case group.FieldModelAliases:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field model_aliases", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ModelAliases); err != nil {
+					return fmt.Errorf("unmarshal field model_aliases: %w", err)
+				}
+			}
+		case group.FieldAllowedModels:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field allowed_models", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.AllowedModels); err != nil {
+					return fmt.Errorf("unmarshal field allowed_models: %w", err)
+				}
+			}
+		case group.FieldDeniedModels:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field denied_models", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.DeniedModels); err != nil {
+					return fmt.Errorf("unmarshal field denied_models: %w", err)
+				}
+			}
+		case group.FieldUsageLimits:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field usage_limits", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.UsageLimits); err != nil {
+					return fmt.Errorf("unmarshal field usage_limits: %w", err)
+				}
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -464,6 +549,11 @@ func (_m *Group) String() string {
 	builder.WriteString("default_validity_days=")
 	builder.WriteString(fmt.Sprintf("%v", _m.DefaultValidityDays))
 	builder.WriteString(", ")
+	if v := _m.SubscriptionPrice; v != nil {
+		builder.WriteString("subscription_price=")
+		builder.WriteString(fmt.Sprintf("%v", *v))
+	}
+	builder.WriteString(", ")
 	if v := _m.ImagePrice1k; v != nil {
 		builder.WriteString("image_price_1k=")
 		builder.WriteString(fmt.Sprintf("%v", *v))
@@ -492,6 +582,30 @@ func (_m *Group) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("model_routing_enabled=")
 	builder.WriteString(fmt.Sprintf("%v", _m.ModelRoutingEnabled))
+	builder.WriteString(", ")
+	builder.WriteString("account_selection_strategy=")
+	builder.WriteString(_m.AccountSelectionStrategy)
+	builder.WriteString(", ")
+	builder.WriteString("image_models=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ImageModels))
+	builder.WriteString(", ")
+	builder.WriteString("content_policy_action=")
+	builder.WriteString(_m.ContentPolicyAction)
+	builder.WriteString(", ")
+	builder.WriteString("request_rewrite_rules=")
+	builder.WriteString(fmt.Sprintf("%v", _m.RequestRewriteRules))
+	builder.WriteString(", ")
+	builder.WriteString("model_aliases=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelAliases))
+	builder.WriteString(", ")
+	builder.WriteString("allowed_models=")
+	builder.WriteString(fmt.Sprintf("%v", _m.AllowedModels))
+	builder.WriteString(", ")
+	builder.WriteString("denied_models=")
+	builder.WriteString(fmt.Sprintf("%v", _m.DeniedModels))
+	builder.WriteString(", ")
+	builder.WriteString("usage_limits=")
+	builder.WriteString(fmt.Sprintf("%v", _m.UsageLimits))
 	builder.WriteByte(')')
 	return builder.String()
 }
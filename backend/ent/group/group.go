@@ -43,6 +43,8 @@ const (
 	FieldMonthlyLimitUsd = "monthly_limit_usd"
 	// FieldDefaultValidityDays holds the string denoting the default_validity_days field in the database.
 	FieldDefaultValidityDays = "default_validity_days"
+	// FieldSubscriptionPrice holds the string denoting the subscription_price field in the database.
+	FieldSubscriptionPrice = "subscription_price"
 	// FieldImagePrice1k holds the string denoting the image_price_1k field in the database.
 	FieldImagePrice1k = "image_price_1k"
 	// FieldImagePrice2k holds the string denoting the image_price_2k field in the database.
@@ -57,6 +59,22 @@ const (
 	FieldModelRouting = "model_routing"
 	// FieldModelRoutingEnabled holds the string denoting the model_routing_enabled field in the database.
 	FieldModelRoutingEnabled = "model_routing_enabled"
+	// FieldAccountSelectionStrategy holds the string denoting the account_selection_strategy field in the database.
+	FieldAccountSelectionStrategy = "account_selection_strategy"
+	// FieldImageModels holds the string denoting the image_models field in the database.
+	FieldImageModels = "image_models"
+	// FieldContentPolicyAction holds the string denoting the content_policy_action field in the database.
+	FieldContentPolicyAction = "content_policy_action"
+	// FieldRequestRewriteRules holds the string denoting the request_rewrite_rules field in the database.
+	FieldRequestRewriteRules = "request_rewrite_rules"
+	// FieldModelAliases holds the string denoting the model_aliases field in the database.
+	FieldModelAliases = "model_aliases"
+	// FieldAllowedModels holds the string denoting the allowed_models field in the database.
+	FieldAllowedModels = "allowed_models"
+	// FieldDeniedModels holds the string denoting the denied_models field in the database.
+	FieldDeniedModels = "denied_models"
+	// FieldUsageLimits holds the string denoting the usage_limits field in the database.
+	FieldUsageLimits = "usage_limits"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -146,6 +164,7 @@ var Columns = []string{
 	FieldWeeklyLimitUsd,
 	FieldMonthlyLimitUsd,
 	FieldDefaultValidityDays,
+	FieldSubscriptionPrice,
 	FieldImagePrice1k,
 	FieldImagePrice2k,
 	FieldImagePrice4k,
@@ -153,6 +172,14 @@ var Columns = []string{
 	FieldFallbackGroupID,
 	FieldModelRouting,
 	FieldModelRoutingEnabled,
+	FieldAccountSelectionStrategy,
+	FieldImageModels,
+	FieldContentPolicyAction,
+	FieldRequestRewriteRules,
+	FieldModelAliases,
+	FieldAllowedModels,
+	FieldDeniedModels,
+	FieldUsageLimits,
 }
 
 var (
@@ -212,6 +239,14 @@ var (
 	DefaultClaudeCodeOnly bool
 	// DefaultModelRoutingEnabled holds the default value on creation for the "model_routing_enabled" field.
 	DefaultModelRoutingEnabled bool
+	// DefaultAccountSelectionStrategy holds the default value on creation for the "account_selection_strategy" field.
+	DefaultAccountSelectionStrategy string
+	// AccountSelectionStrategyValidator is a validator for the "account_selection_strategy" field. It is called by the builders before save.
+	AccountSelectionStrategyValidator func(string) error
+	// DefaultContentPolicyAction holds the default value on creation for the "content_policy_action" field.
+	DefaultContentPolicyAction string
+	// ContentPolicyActionValidator is a validator for the "content_policy_action" field. It is called by the builders before save.
+	ContentPolicyActionValidator func(string) error
 )
 
 // OrderOption defines the ordering options for the Group queries.
@@ -292,6 +327,11 @@ func ByDefaultValidityDays(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldDefaultValidityDays, opts...).ToFunc()
 }
 
+// BySubscriptionPrice orders the results by the subscription_price field.
+func BySubscriptionPrice(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldSubscriptionPrice, opts...).ToFunc()
+}
+
 // ByImagePrice1k orders the results by the image_price_1k field.
 func ByImagePrice1k(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldImagePrice1k, opts...).ToFunc()
@@ -322,6 +362,16 @@ func ByModelRoutingEnabled(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldModelRoutingEnabled, opts...).ToFunc()
 }
 
+// ByAccountSelectionStrategy orders the results by the account_selection_strategy field.
+func ByAccountSelectionStrategy(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldAccountSelectionStrategy, opts...).ToFunc()
+}
+
+// ByContentPolicyAction orders the results by the content_policy_action field.
+func ByContentPolicyAction(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldContentPolicyAction, opts...).ToFunc()
+}
+
 // ByAPIKeysCount orders the results by api_keys count.
 func ByAPIKeysCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -125,6 +125,11 @@ func DefaultValidityDays(v int) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldDefaultValidityDays, v))
 }
 
+// SubscriptionPrice applies equality check predicate on the "subscription_price" field. It's identical to SubscriptionPriceEQ.
+func SubscriptionPrice(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldSubscriptionPrice, v))
+}
+
 // ImagePrice1k applies equality check predicate on the "image_price_1k" field. It's identical to ImagePrice1kEQ.
 func ImagePrice1k(v float64) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldImagePrice1k, v))
@@ -155,6 +160,16 @@ func ModelRoutingEnabled(v bool) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldModelRoutingEnabled, v))
 }
 
+// AccountSelectionStrategy applies equality check predicate on the "account_selection_strategy" field. It's identical to AccountSelectionStrategyEQ.
+func AccountSelectionStrategy(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldAccountSelectionStrategy, v))
+}
+
+// ContentPolicyAction applies equality check predicate on the "content_policy_action" field. It's identical to ContentPolicyActionEQ.
+func ContentPolicyAction(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldContentPolicyAction, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldCreatedAt, v))
@@ -860,6 +875,56 @@ func DefaultValidityDaysLTE(v int) predicate.Group {
 	return predicate.Group(sql.FieldLTE(FieldDefaultValidityDays, v))
 }
 
+// SubscriptionPriceEQ applies the EQ predicate on the "subscription_price" field.
+func SubscriptionPriceEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceNEQ applies the NEQ predicate on the "subscription_price" field.
+func SubscriptionPriceNEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceIn applies the In predicate on the "subscription_price" field.
+func SubscriptionPriceIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldSubscriptionPrice, vs...))
+}
+
+// SubscriptionPriceNotIn applies the NotIn predicate on the "subscription_price" field.
+func SubscriptionPriceNotIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldSubscriptionPrice, vs...))
+}
+
+// SubscriptionPriceGT applies the GT predicate on the "subscription_price" field.
+func SubscriptionPriceGT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceGTE applies the GTE predicate on the "subscription_price" field.
+func SubscriptionPriceGTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceLT applies the LT predicate on the "subscription_price" field.
+func SubscriptionPriceLT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceLTE applies the LTE predicate on the "subscription_price" field.
+func SubscriptionPriceLTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceIsNil applies the IsNil predicate on the "subscription_price" field.
+func SubscriptionPriceIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldSubscriptionPrice))
+}
+
+// SubscriptionPriceNotNil applies the NotNil predicate on the "subscription_price" field.
+func SubscriptionPriceNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldSubscriptionPrice))
+}
+
 // ImagePrice1kEQ applies the EQ predicate on the "image_price_1k" field.
 func ImagePrice1kEQ(v float64) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldImagePrice1k, v))
@@ -1090,6 +1155,196 @@ func ModelRoutingEnabledNEQ(v bool) predicate.Group {
 	return predicate.Group(sql.FieldNEQ(FieldModelRoutingEnabled, v))
 }
 
+// AccountSelectionStrategyEQ applies the EQ predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyNEQ applies the NEQ predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyIn applies the In predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldAccountSelectionStrategy, vs...))
+}
+
+// AccountSelectionStrategyNotIn applies the NotIn predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldAccountSelectionStrategy, vs...))
+}
+
+// AccountSelectionStrategyGT applies the GT predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyGTE applies the GTE predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyLT applies the LT predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyLTE applies the LTE predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyContains applies the Contains predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyHasPrefix applies the HasPrefix predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyHasSuffix applies the HasSuffix predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyEqualFold applies the EqualFold predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldAccountSelectionStrategy, v))
+}
+
+// AccountSelectionStrategyContainsFold applies the ContainsFold predicate on the "account_selection_strategy" field.
+func AccountSelectionStrategyContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldAccountSelectionStrategy, v))
+}
+
+// ImageModelsIsNil applies the IsNil predicate on the "image_models" field.
+func ImageModelsIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldImageModels))
+}
+
+// ImageModelsNotNil applies the NotNil predicate on the "image_models" field.
+func ImageModelsNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldImageModels))
+}
+
+// ContentPolicyActionEQ applies the EQ predicate on the "content_policy_action" field.
+func ContentPolicyActionEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionNEQ applies the NEQ predicate on the "content_policy_action" field.
+func ContentPolicyActionNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionIn applies the In predicate on the "content_policy_action" field.
+func ContentPolicyActionIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldContentPolicyAction, vs...))
+}
+
+// ContentPolicyActionNotIn applies the NotIn predicate on the "content_policy_action" field.
+func ContentPolicyActionNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldContentPolicyAction, vs...))
+}
+
+// ContentPolicyActionGT applies the GT predicate on the "content_policy_action" field.
+func ContentPolicyActionGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionGTE applies the GTE predicate on the "content_policy_action" field.
+func ContentPolicyActionGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionLT applies the LT predicate on the "content_policy_action" field.
+func ContentPolicyActionLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionLTE applies the LTE predicate on the "content_policy_action" field.
+func ContentPolicyActionLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionContains applies the Contains predicate on the "content_policy_action" field.
+func ContentPolicyActionContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionHasPrefix applies the HasPrefix predicate on the "content_policy_action" field.
+func ContentPolicyActionHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionHasSuffix applies the HasSuffix predicate on the "content_policy_action" field.
+func ContentPolicyActionHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionEqualFold applies the EqualFold predicate on the "content_policy_action" field.
+func ContentPolicyActionEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldContentPolicyAction, v))
+}
+
+// ContentPolicyActionContainsFold applies the ContainsFold predicate on the "content_policy_action" field.
+func ContentPolicyActionContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldContentPolicyAction, v))
+}
+
+// RequestRewriteRulesIsNil applies the IsNil predicate on the "request_rewrite_rules" field.
+func RequestRewriteRulesIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldRequestRewriteRules))
+}
+
+// RequestRewriteRulesNotNil applies the NotNil predicate on the "request_rewrite_rules" field.
+func RequestRewriteRulesNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldRequestRewriteRules))
+}
+
+// ModelAliasesIsNil applies the IsNil predicate on the "model_aliases" field.
+func ModelAliasesIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldModelAliases))
+}
+
+// ModelAliasesNotNil applies the NotNil predicate on the "model_aliases" field.
+func ModelAliasesNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldModelAliases))
+}
+
+// AllowedModelsIsNil applies the IsNil predicate on the "allowed_models" field.
+func AllowedModelsIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldAllowedModels))
+}
+
+// AllowedModelsNotNil applies the NotNil predicate on the "allowed_models" field.
+func AllowedModelsNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldAllowedModels))
+}
+
+// DeniedModelsIsNil applies the IsNil predicate on the "denied_models" field.
+func DeniedModelsIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldDeniedModels))
+}
+
+// DeniedModelsNotNil applies the NotNil predicate on the "denied_models" field.
+func DeniedModelsNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldDeniedModels))
+}
+
+// UsageLimitsIsNil applies the IsNil predicate on the "usage_limits" field.
+func UsageLimitsIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldUsageLimits))
+}
+
+// UsageLimitsNotNil applies the NotNil predicate on the "usage_limits" field.
+func UsageLimitsNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldUsageLimits))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.Group {
 	return predicate.Group(func(s *sql.Selector) {
@@ -4,6 +4,7 @@ package ent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -216,6 +217,20 @@ func (_c *GroupCreate) SetNillableDefaultValidityDays(v *int) *GroupCreate {
 	return _c
 }
 
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (_c *GroupCreate) SetSubscriptionPrice(v float64) *GroupCreate {
+	_c.mutation.SetSubscriptionPrice(v)
+	return _c
+}
+
+// SetNillableSubscriptionPrice sets the "subscription_price" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableSubscriptionPrice(v *float64) *GroupCreate {
+	if v != nil {
+		_c.SetSubscriptionPrice(*v)
+	}
+	return _c
+}
+
 // SetImagePrice1k sets the "image_price_1k" field.
 func (_c *GroupCreate) SetImagePrice1k(v float64) *GroupCreate {
 	_c.mutation.SetImagePrice1k(v)
@@ -306,6 +321,70 @@ func (_c *GroupCreate) SetNillableModelRoutingEnabled(v *bool) *GroupCreate {
 	return _c
 }
 
+// SetAccountSelectionStrategy sets the "account_selection_strategy" field.
+func (_c *GroupCreate) SetAccountSelectionStrategy(v string) *GroupCreate {
+	_c.mutation.SetAccountSelectionStrategy(v)
+	return _c
+}
+
+// SetNillableAccountSelectionStrategy sets the "account_selection_strategy" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableAccountSelectionStrategy(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetAccountSelectionStrategy(*v)
+	}
+	return _c
+}
+
+// SetImageModels sets the "image_models" field.
+func (_c *GroupCreate) SetImageModels(v []string) *GroupCreate {
+	_c.mutation.SetImageModels(v)
+	return _c
+}
+
+// SetContentPolicyAction sets the "content_policy_action" field.
+func (_c *GroupCreate) SetContentPolicyAction(v string) *GroupCreate {
+	_c.mutation.SetContentPolicyAction(v)
+	return _c
+}
+
+// SetNillableContentPolicyAction sets the "content_policy_action" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableContentPolicyAction(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetContentPolicyAction(*v)
+	}
+	return _c
+}
+
+// SetRequestRewriteRules sets the "request_rewrite_rules" field.
+func (_c *GroupCreate) SetRequestRewriteRules(v json.RawMessage) *GroupCreate {
+	_c.mutation.SetRequestRewriteRules(v)
+	return _c
+}
+
+// SetModelAliases sets the "model_aliases" field.
+func (_c *GroupCreate) SetModelAliases(v map[string]string) *GroupCreate {
+	_c.mutation.SetModelAliases(v)
+	return _c
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (_c *GroupCreate) SetAllowedModels(v []string) *GroupCreate {
+	_c.mutation.SetAllowedModels(v)
+	return _c
+}
+
+// SetDeniedModels sets the "denied_models" field.
+func (_c *GroupCreate) SetDeniedModels(v []string) *GroupCreate {
+	_c.mutation.SetDeniedModels(v)
+	return _c
+}
+
+// SetUsageLimits sets the "usage_limits" field.
+func (_c *GroupCreate) SetUsageLimits(v json.RawMessage) *GroupCreate {
+	_c.mutation.SetUsageLimits(v)
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *GroupCreate) AddAPIKeyIDs(ids ...int64) *GroupCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -479,6 +558,14 @@ func (_c *GroupCreate) defaults() error {
 		v := group.DefaultModelRoutingEnabled
 		_c.mutation.SetModelRoutingEnabled(v)
 	}
+	if _, ok := _c.mutation.AccountSelectionStrategy(); !ok {
+		v := group.DefaultAccountSelectionStrategy
+		_c.mutation.SetAccountSelectionStrategy(v)
+	}
+	if _, ok := _c.mutation.ContentPolicyAction(); !ok {
+		v := group.DefaultContentPolicyAction
+		_c.mutation.SetContentPolicyAction(v)
+	}
 	return nil
 }
 
@@ -537,6 +624,22 @@ func (_c *GroupCreate) check() error {
 	if _, ok := _c.mutation.ModelRoutingEnabled(); !ok {
 		return &ValidationError{Name: "model_routing_enabled", err: errors.New(`ent: missing required field "Group.model_routing_enabled"`)}
 	}
+	if _, ok := _c.mutation.AccountSelectionStrategy(); !ok {
+		return &ValidationError{Name: "account_selection_strategy", err: errors.New(`ent: missing required field "Group.account_selection_strategy"`)}
+	}
+	if v, ok := _c.mutation.AccountSelectionStrategy(); ok {
+		if err := group.AccountSelectionStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "account_selection_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.account_selection_strategy": %w`, err)}
+		}
+	}
+	if _, ok := _c.mutation.ContentPolicyAction(); !ok {
+		return &ValidationError{Name: "content_policy_action", err: errors.New(`ent: missing required field "Group.content_policy_action"`)}
+	}
+	if v, ok := _c.mutation.ContentPolicyAction(); ok {
+		if err := group.ContentPolicyActionValidator(v); err != nil {
+			return &ValidationError{Name: "content_policy_action", err: fmt.Errorf(`ent: validator failed for field "Group.content_policy_action": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -620,6 +723,10 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldDefaultValidityDays, field.TypeInt, value)
 		_node.DefaultValidityDays = value
 	}
+	if value, ok := _c.mutation.SubscriptionPrice(); ok {
+		_spec.SetField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+		_node.SubscriptionPrice = &value
+	}
 	if value, ok := _c.mutation.ImagePrice1k(); ok {
 		_spec.SetField(group.FieldImagePrice1k, field.TypeFloat64, value)
 		_node.ImagePrice1k = &value
@@ -648,6 +755,38 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 		_node.ModelRoutingEnabled = value
 	}
+	if value, ok := _c.mutation.AccountSelectionStrategy(); ok {
+		_spec.SetField(group.FieldAccountSelectionStrategy, field.TypeString, value)
+		_node.AccountSelectionStrategy = value
+	}
+	if value, ok := _c.mutation.ImageModels(); ok {
+		_spec.SetField(group.FieldImageModels, field.TypeJSON, value)
+		_node.ImageModels = value
+	}
+	if value, ok := _c.mutation.ContentPolicyAction(); ok {
+		_spec.SetField(group.FieldContentPolicyAction, field.TypeString, value)
+		_node.ContentPolicyAction = value
+	}
+	if value, ok := _c.mutation.RequestRewriteRules(); ok {
+		_spec.SetField(group.FieldRequestRewriteRules, field.TypeJSON, value)
+		_node.RequestRewriteRules = value
+	}
+	if value, ok := _c.mutation.ModelAliases(); ok {
+		_spec.SetField(group.FieldModelAliases, field.TypeJSON, value)
+		_node.ModelAliases = value
+	}
+	if value, ok := _c.mutation.AllowedModels(); ok {
+		_spec.SetField(group.FieldAllowedModels, field.TypeJSON, value)
+		_node.AllowedModels = value
+	}
+	if value, ok := _c.mutation.DeniedModels(); ok {
+		_spec.SetField(group.FieldDeniedModels, field.TypeJSON, value)
+		_node.DeniedModels = value
+	}
+	if value, ok := _c.mutation.UsageLimits(); ok {
+		_spec.SetField(group.FieldUsageLimits, field.TypeJSON, value)
+		_node.UsageLimits = value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1020,6 +1159,30 @@ func (u *GroupUpsert) AddDefaultValidityDays(v int) *GroupUpsert {
 	return u
 }
 
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (u *GroupUpsert) SetSubscriptionPrice(v float64) *GroupUpsert {
+	u.Set(group.FieldSubscriptionPrice, v)
+	return u
+}
+
+// UpdateSubscriptionPrice sets the "subscription_price" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateSubscriptionPrice() *GroupUpsert {
+	u.SetExcluded(group.FieldSubscriptionPrice)
+	return u
+}
+
+// AddSubscriptionPrice adds v to the "subscription_price" field.
+func (u *GroupUpsert) AddSubscriptionPrice(v float64) *GroupUpsert {
+	u.Add(group.FieldSubscriptionPrice, v)
+	return u
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (u *GroupUpsert) ClearSubscriptionPrice() *GroupUpsert {
+	u.SetNull(group.FieldSubscriptionPrice)
+	return u
+}
+
 // SetImagePrice1k sets the "image_price_1k" field.
 func (u *GroupUpsert) SetImagePrice1k(v float64) *GroupUpsert {
 	u.Set(group.FieldImagePrice1k, v)
@@ -1158,6 +1321,138 @@ func (u *GroupUpsert) UpdateModelRoutingEnabled() *GroupUpsert {
 	return u
 }
 
+// SetAccountSelectionStrategy sets the "account_selection_strategy" field.
+func (u *GroupUpsert) SetAccountSelectionStrategy(v string) *GroupUpsert {
+	u.Set(group.FieldAccountSelectionStrategy, v)
+	return u
+}
+
+// UpdateAccountSelectionStrategy sets the "account_selection_strategy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateAccountSelectionStrategy() *GroupUpsert {
+	u.SetExcluded(group.FieldAccountSelectionStrategy)
+	return u
+}
+
+// SetImageModels sets the "image_models" field.
+func (u *GroupUpsert) SetImageModels(v []string) *GroupUpsert {
+	u.Set(group.FieldImageModels, v)
+	return u
+}
+
+// UpdateImageModels sets the "image_models" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateImageModels() *GroupUpsert {
+	u.SetExcluded(group.FieldImageModels)
+	return u
+}
+
+// ClearImageModels clears the value of the "image_models" field.
+func (u *GroupUpsert) ClearImageModels() *GroupUpsert {
+	u.SetNull(group.FieldImageModels)
+	return u
+}
+
+// SetContentPolicyAction sets the "content_policy_action" field.
+func (u *GroupUpsert) SetContentPolicyAction(v string) *GroupUpsert {
+	u.Set(group.FieldContentPolicyAction, v)
+	return u
+}
+
+// UpdateContentPolicyAction sets the "content_policy_action" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateContentPolicyAction() *GroupUpsert {
+	u.SetExcluded(group.FieldContentPolicyAction)
+	return u
+}
+
+// SetRequestRewriteRules sets the "request_rewrite_rules" field.
+func (u *GroupUpsert) SetRequestRewriteRules(v json.RawMessage) *GroupUpsert {
+	u.Set(group.FieldRequestRewriteRules, v)
+	return u
+}
+
+// UpdateRequestRewriteRules sets the "request_rewrite_rules" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateRequestRewriteRules() *GroupUpsert {
+	u.SetExcluded(group.FieldRequestRewriteRules)
+	return u
+}
+
+// ClearRequestRewriteRules clears the value of the "request_rewrite_rules" field.
+func (u *GroupUpsert) ClearRequestRewriteRules() *GroupUpsert {
+	u.SetNull(group.FieldRequestRewriteRules)
+	return u
+}
+
+// SetModelAliases sets the "model_aliases" field.
+func (u *GroupUpsert) SetModelAliases(v map[string]string) *GroupUpsert {
+	u.Set(group.FieldModelAliases, v)
+	return u
+}
+
+// UpdateModelAliases sets the "model_aliases" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelAliases() *GroupUpsert {
+	u.SetExcluded(group.FieldModelAliases)
+	return u
+}
+
+// ClearModelAliases clears the value of the "model_aliases" field.
+func (u *GroupUpsert) ClearModelAliases() *GroupUpsert {
+	u.SetNull(group.FieldModelAliases)
+	return u
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (u *GroupUpsert) SetAllowedModels(v []string) *GroupUpsert {
+	u.Set(group.FieldAllowedModels, v)
+	return u
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateAllowedModels() *GroupUpsert {
+	u.SetExcluded(group.FieldAllowedModels)
+	return u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *GroupUpsert) ClearAllowedModels() *GroupUpsert {
+	u.SetNull(group.FieldAllowedModels)
+	return u
+}
+
+// SetDeniedModels sets the "denied_models" field.
+func (u *GroupUpsert) SetDeniedModels(v []string) *GroupUpsert {
+	u.Set(group.FieldDeniedModels, v)
+	return u
+}
+
+// UpdateDeniedModels sets the "denied_models" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateDeniedModels() *GroupUpsert {
+	u.SetExcluded(group.FieldDeniedModels)
+	return u
+}
+
+// ClearDeniedModels clears the value of the "denied_models" field.
+func (u *GroupUpsert) ClearDeniedModels() *GroupUpsert {
+	u.SetNull(group.FieldDeniedModels)
+	return u
+}
+
+// SetUsageLimits sets the "usage_limits" field.
+func (u *GroupUpsert) SetUsageLimits(v json.RawMessage) *GroupUpsert {
+	u.Set(group.FieldUsageLimits, v)
+	return u
+}
+
+// UpdateUsageLimits sets the "usage_limits" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateUsageLimits() *GroupUpsert {
+	u.SetExcluded(group.FieldUsageLimits)
+	return u
+}
+
+// ClearUsageLimits clears the value of the "usage_limits" field.
+func (u *GroupUpsert) ClearUsageLimits() *GroupUpsert {
+	u.SetNull(group.FieldUsageLimits)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1455,6 +1750,34 @@ func (u *GroupUpsertOne) UpdateDefaultValidityDays() *GroupUpsertOne {
 	})
 }
 
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (u *GroupUpsertOne) SetSubscriptionPrice(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSubscriptionPrice(v)
+	})
+}
+
+// AddSubscriptionPrice adds v to the "subscription_price" field.
+func (u *GroupUpsertOne) AddSubscriptionPrice(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddSubscriptionPrice(v)
+	})
+}
+
+// UpdateSubscriptionPrice sets the "subscription_price" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateSubscriptionPrice() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSubscriptionPrice()
+	})
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (u *GroupUpsertOne) ClearSubscriptionPrice() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearSubscriptionPrice()
+	})
+}
+
 // SetImagePrice1k sets the "image_price_1k" field.
 func (u *GroupUpsertOne) SetImagePrice1k(v float64) *GroupUpsertOne {
 	return u.Update(func(s *GroupUpsert) {
@@ -1616,6 +1939,160 @@ func (u *GroupUpsertOne) UpdateModelRoutingEnabled() *GroupUpsertOne {
 	})
 }
 
+// SetAccountSelectionStrategy sets the "account_selection_strategy" field.
+func (u *GroupUpsertOne) SetAccountSelectionStrategy(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetAccountSelectionStrategy(v)
+	})
+}
+
+// UpdateAccountSelectionStrategy sets the "account_selection_strategy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateAccountSelectionStrategy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateAccountSelectionStrategy()
+	})
+}
+
+// SetImageModels sets the "image_models" field.
+func (u *GroupUpsertOne) SetImageModels(v []string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetImageModels(v)
+	})
+}
+
+// UpdateImageModels sets the "image_models" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateImageModels() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateImageModels()
+	})
+}
+
+// ClearImageModels clears the value of the "image_models" field.
+func (u *GroupUpsertOne) ClearImageModels() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearImageModels()
+	})
+}
+
+// SetContentPolicyAction sets the "content_policy_action" field.
+func (u *GroupUpsertOne) SetContentPolicyAction(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetContentPolicyAction(v)
+	})
+}
+
+// UpdateContentPolicyAction sets the "content_policy_action" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateContentPolicyAction() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateContentPolicyAction()
+	})
+}
+
+// SetRequestRewriteRules sets the "request_rewrite_rules" field.
+func (u *GroupUpsertOne) SetRequestRewriteRules(v json.RawMessage) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRequestRewriteRules(v)
+	})
+}
+
+// UpdateRequestRewriteRules sets the "request_rewrite_rules" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateRequestRewriteRules() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRequestRewriteRules()
+	})
+}
+
+// ClearRequestRewriteRules clears the value of the "request_rewrite_rules" field.
+func (u *GroupUpsertOne) ClearRequestRewriteRules() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearRequestRewriteRules()
+	})
+}
+
+// SetModelAliases sets the "model_aliases" field.
+func (u *GroupUpsertOne) SetModelAliases(v map[string]string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelAliases(v)
+	})
+}
+
+// UpdateModelAliases sets the "model_aliases" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelAliases() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelAliases()
+	})
+}
+
+// ClearModelAliases clears the value of the "model_aliases" field.
+func (u *GroupUpsertOne) ClearModelAliases() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelAliases()
+	})
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (u *GroupUpsertOne) SetAllowedModels(v []string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetAllowedModels(v)
+	})
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateAllowedModels() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateAllowedModels()
+	})
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *GroupUpsertOne) ClearAllowedModels() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearAllowedModels()
+	})
+}
+
+// SetDeniedModels sets the "denied_models" field.
+func (u *GroupUpsertOne) SetDeniedModels(v []string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetDeniedModels(v)
+	})
+}
+
+// UpdateDeniedModels sets the "denied_models" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateDeniedModels() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateDeniedModels()
+	})
+}
+
+// ClearDeniedModels clears the value of the "denied_models" field.
+func (u *GroupUpsertOne) ClearDeniedModels() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearDeniedModels()
+	})
+}
+
+// SetUsageLimits sets the "usage_limits" field.
+func (u *GroupUpsertOne) SetUsageLimits(v json.RawMessage) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetUsageLimits(v)
+	})
+}
+
+// UpdateUsageLimits sets the "usage_limits" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateUsageLimits() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateUsageLimits()
+	})
+}
+
+// ClearUsageLimits clears the value of the "usage_limits" field.
+func (u *GroupUpsertOne) ClearUsageLimits() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearUsageLimits()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -2079,6 +2556,34 @@ func (u *GroupUpsertBulk) UpdateDefaultValidityDays() *GroupUpsertBulk {
 	})
 }
 
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (u *GroupUpsertBulk) SetSubscriptionPrice(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSubscriptionPrice(v)
+	})
+}
+
+// AddSubscriptionPrice adds v to the "subscription_price" field.
+func (u *GroupUpsertBulk) AddSubscriptionPrice(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddSubscriptionPrice(v)
+	})
+}
+
+// UpdateSubscriptionPrice sets the "subscription_price" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateSubscriptionPrice() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSubscriptionPrice()
+	})
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (u *GroupUpsertBulk) ClearSubscriptionPrice() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearSubscriptionPrice()
+	})
+}
+
 // SetImagePrice1k sets the "image_price_1k" field.
 func (u *GroupUpsertBulk) SetImagePrice1k(v float64) *GroupUpsertBulk {
 	return u.Update(func(s *GroupUpsert) {
@@ -2240,6 +2745,160 @@ func (u *GroupUpsertBulk) UpdateModelRoutingEnabled() *GroupUpsertBulk {
 	})
 }
 
+// SetAccountSelectionStrategy sets the "account_selection_strategy" field.
+func (u *GroupUpsertBulk) SetAccountSelectionStrategy(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetAccountSelectionStrategy(v)
+	})
+}
+
+// UpdateAccountSelectionStrategy sets the "account_selection_strategy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateAccountSelectionStrategy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateAccountSelectionStrategy()
+	})
+}
+
+// SetImageModels sets the "image_models" field.
+func (u *GroupUpsertBulk) SetImageModels(v []string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetImageModels(v)
+	})
+}
+
+// UpdateImageModels sets the "image_models" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateImageModels() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateImageModels()
+	})
+}
+
+// ClearImageModels clears the value of the "image_models" field.
+func (u *GroupUpsertBulk) ClearImageModels() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearImageModels()
+	})
+}
+
+// SetContentPolicyAction sets the "content_policy_action" field.
+func (u *GroupUpsertBulk) SetContentPolicyAction(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetContentPolicyAction(v)
+	})
+}
+
+// UpdateContentPolicyAction sets the "content_policy_action" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateContentPolicyAction() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateContentPolicyAction()
+	})
+}
+
+// SetRequestRewriteRules sets the "request_rewrite_rules" field.
+func (u *GroupUpsertBulk) SetRequestRewriteRules(v json.RawMessage) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetRequestRewriteRules(v)
+	})
+}
+
+// UpdateRequestRewriteRules sets the "request_rewrite_rules" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateRequestRewriteRules() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateRequestRewriteRules()
+	})
+}
+
+// ClearRequestRewriteRules clears the value of the "request_rewrite_rules" field.
+func (u *GroupUpsertBulk) ClearRequestRewriteRules() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearRequestRewriteRules()
+	})
+}
+
+// SetModelAliases sets the "model_aliases" field.
+func (u *GroupUpsertBulk) SetModelAliases(v map[string]string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelAliases(v)
+	})
+}
+
+// UpdateModelAliases sets the "model_aliases" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelAliases() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelAliases()
+	})
+}
+
+// ClearModelAliases clears the value of the "model_aliases" field.
+func (u *GroupUpsertBulk) ClearModelAliases() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearModelAliases()
+	})
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (u *GroupUpsertBulk) SetAllowedModels(v []string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetAllowedModels(v)
+	})
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateAllowedModels() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateAllowedModels()
+	})
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *GroupUpsertBulk) ClearAllowedModels() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearAllowedModels()
+	})
+}
+
+// SetDeniedModels sets the "denied_models" field.
+func (u *GroupUpsertBulk) SetDeniedModels(v []string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetDeniedModels(v)
+	})
+}
+
+// UpdateDeniedModels sets the "denied_models" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateDeniedModels() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateDeniedModels()
+	})
+}
+
+// ClearDeniedModels clears the value of the "denied_models" field.
+func (u *GroupUpsertBulk) ClearDeniedModels() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearDeniedModels()
+	})
+}
+
+// SetUsageLimits sets the "usage_limits" field.
+func (u *GroupUpsertBulk) SetUsageLimits(v json.RawMessage) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetUsageLimits(v)
+	})
+}
+
+// UpdateUsageLimits sets the "usage_limits" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateUsageLimits() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateUsageLimits()
+	})
+}
+
+// ClearUsageLimits clears the value of the "usage_limits" field.
+func (u *GroupUpsertBulk) ClearUsageLimits() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearUsageLimits()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -4,12 +4,14 @@ package ent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/dialect/sql/sqljson"
 	"entgo.io/ent/schema/field"
 	"github.com/Wei-Shaw/sub2api/ent/account"
 	"github.com/Wei-Shaw/sub2api/ent/apikey"
@@ -273,6 +275,33 @@ func (_u *GroupUpdate) AddDefaultValidityDays(v int) *GroupUpdate {
 	return _u
 }
 
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (_u *GroupUpdate) SetSubscriptionPrice(v float64) *GroupUpdate {
+	_u.mutation.ResetSubscriptionPrice()
+	_u.mutation.SetSubscriptionPrice(v)
+	return _u
+}
+
+// SetNillableSubscriptionPrice sets the "subscription_price" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableSubscriptionPrice(v *float64) *GroupUpdate {
+	if v != nil {
+		_u.SetSubscriptionPrice(*v)
+	}
+	return _u
+}
+
+// AddSubscriptionPrice adds value to the "subscription_price" field.
+func (_u *GroupUpdate) AddSubscriptionPrice(v float64) *GroupUpdate {
+	_u.mutation.AddSubscriptionPrice(v)
+	return _u
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (_u *GroupUpdate) ClearSubscriptionPrice() *GroupUpdate {
+	_u.mutation.ClearSubscriptionPrice()
+	return _u
+}
+
 // SetImagePrice1k sets the "image_price_1k" field.
 func (_u *GroupUpdate) SetImagePrice1k(v float64) *GroupUpdate {
 	_u.mutation.ResetImagePrice1k()
@@ -421,6 +450,136 @@ func (_u *GroupUpdate) SetNillableModelRoutingEnabled(v *bool) *GroupUpdate {
 	return _u
 }
 
+// SetAccountSelectionStrategy sets the "account_selection_strategy" field.
+func (_u *GroupUpdate) SetAccountSelectionStrategy(v string) *GroupUpdate {
+	_u.mutation.SetAccountSelectionStrategy(v)
+	return _u
+}
+
+// SetNillableAccountSelectionStrategy sets the "account_selection_strategy" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableAccountSelectionStrategy(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetAccountSelectionStrategy(*v)
+	}
+	return _u
+}
+
+// SetImageModels sets the "image_models" field.
+func (_u *GroupUpdate) SetImageModels(v []string) *GroupUpdate {
+	_u.mutation.SetImageModels(v)
+	return _u
+}
+
+// AppendImageModels appends value to the "image_models" field.
+func (_u *GroupUpdate) AppendImageModels(v []string) *GroupUpdate {
+	_u.mutation.AppendImageModels(v)
+	return _u
+}
+
+// ClearImageModels clears the value of the "image_models" field.
+func (_u *GroupUpdate) ClearImageModels() *GroupUpdate {
+	_u.mutation.ClearImageModels()
+	return _u
+}
+
+// SetContentPolicyAction sets the "content_policy_action" field.
+func (_u *GroupUpdate) SetContentPolicyAction(v string) *GroupUpdate {
+	_u.mutation.SetContentPolicyAction(v)
+	return _u
+}
+
+// SetNillableContentPolicyAction sets the "content_policy_action" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableContentPolicyAction(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetContentPolicyAction(*v)
+	}
+	return _u
+}
+
+// SetRequestRewriteRules sets the "request_rewrite_rules" field.
+func (_u *GroupUpdate) SetRequestRewriteRules(v json.RawMessage) *GroupUpdate {
+	_u.mutation.SetRequestRewriteRules(v)
+	return _u
+}
+
+// AppendRequestRewriteRules appends value to the "request_rewrite_rules" field.
+func (_u *GroupUpdate) AppendRequestRewriteRules(v json.RawMessage) *GroupUpdate {
+	_u.mutation.AppendRequestRewriteRules(v)
+	return _u
+}
+
+// ClearRequestRewriteRules clears the value of the "request_rewrite_rules" field.
+func (_u *GroupUpdate) ClearRequestRewriteRules() *GroupUpdate {
+	_u.mutation.ClearRequestRewriteRules()
+	return _u
+}
+
+// SetModelAliases sets the "model_aliases" field.
+func (_u *GroupUpdate) SetModelAliases(v map[string]string) *GroupUpdate {
+	_u.mutation.SetModelAliases(v)
+	return _u
+}
+
+// ClearModelAliases clears the value of the "model_aliases" field.
+func (_u *GroupUpdate) ClearModelAliases() *GroupUpdate {
+	_u.mutation.ClearModelAliases()
+	return _u
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (_u *GroupUpdate) SetAllowedModels(v []string) *GroupUpdate {
+	_u.mutation.SetAllowedModels(v)
+	return _u
+}
+
+// AppendAllowedModels appends value to the "allowed_models" field.
+func (_u *GroupUpdate) AppendAllowedModels(v []string) *GroupUpdate {
+	_u.mutation.AppendAllowedModels(v)
+	return _u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (_u *GroupUpdate) ClearAllowedModels() *GroupUpdate {
+	_u.mutation.ClearAllowedModels()
+	return _u
+}
+
+// SetDeniedModels sets the "denied_models" field.
+func (_u *GroupUpdate) SetDeniedModels(v []string) *GroupUpdate {
+	_u.mutation.SetDeniedModels(v)
+	return _u
+}
+
+// AppendDeniedModels appends value to the "denied_models" field.
+func (_u *GroupUpdate) AppendDeniedModels(v []string) *GroupUpdate {
+	_u.mutation.AppendDeniedModels(v)
+	return _u
+}
+
+// ClearDeniedModels clears the value of the "denied_models" field.
+func (_u *GroupUpdate) ClearDeniedModels() *GroupUpdate {
+	_u.mutation.ClearDeniedModels()
+	return _u
+}
+
+// SetUsageLimits sets the "usage_limits" field.
+func (_u *GroupUpdate) SetUsageLimits(v json.RawMessage) *GroupUpdate {
+	_u.mutation.SetUsageLimits(v)
+	return _u
+}
+
+// AppendUsageLimits appends value to the "usage_limits" field.
+func (_u *GroupUpdate) AppendUsageLimits(v json.RawMessage) *GroupUpdate {
+	_u.mutation.AppendUsageLimits(v)
+	return _u
+}
+
+// ClearUsageLimits clears the value of the "usage_limits" field.
+func (_u *GroupUpdate) ClearUsageLimits() *GroupUpdate {
+	_u.mutation.ClearUsageLimits()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdate) AddAPIKeyIDs(ids ...int64) *GroupUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -706,6 +865,16 @@ func (_u *GroupUpdate) check() error {
 			return &ValidationError{Name: "subscription_type", err: fmt.Errorf(`ent: validator failed for field "Group.subscription_type": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.AccountSelectionStrategy(); ok {
+		if err := group.AccountSelectionStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "account_selection_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.account_selection_strategy": %w`, err)}
+		}
+	}
+	if v, ok := _u.mutation.ContentPolicyAction(); ok {
+		if err := group.ContentPolicyActionValidator(v); err != nil {
+			return &ValidationError{Name: "content_policy_action", err: fmt.Errorf(`ent: validator failed for field "Group.content_policy_action": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -790,6 +959,15 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.AddedDefaultValidityDays(); ok {
 		_spec.AddField(group.FieldDefaultValidityDays, field.TypeInt, value)
 	}
+	if value, ok := _u.mutation.SubscriptionPrice(); ok {
+		_spec.SetField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedSubscriptionPrice(); ok {
+		_spec.AddField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+	}
+	if _u.mutation.SubscriptionPriceCleared() {
+		_spec.ClearField(group.FieldSubscriptionPrice, field.TypeFloat64)
+	}
 	if value, ok := _u.mutation.ImagePrice1k(); ok {
 		_spec.SetField(group.FieldImagePrice1k, field.TypeFloat64, value)
 	}
@@ -838,6 +1016,73 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
+	if value, ok := _u.mutation.AccountSelectionStrategy(); ok {
+		_spec.SetField(group.FieldAccountSelectionStrategy, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.ImageModels(); ok {
+		_spec.SetField(group.FieldImageModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedImageModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldImageModels, value)
+		})
+	}
+	if _u.mutation.ImageModelsCleared() {
+		_spec.ClearField(group.FieldImageModels, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.ContentPolicyAction(); ok {
+		_spec.SetField(group.FieldContentPolicyAction, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.RequestRewriteRules(); ok {
+		_spec.SetField(group.FieldRequestRewriteRules, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedRequestRewriteRules(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldRequestRewriteRules, value)
+		})
+	}
+	if _u.mutation.RequestRewriteRulesCleared() {
+		_spec.ClearField(group.FieldRequestRewriteRules, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.ModelAliases(); ok {
+		_spec.SetField(group.FieldModelAliases, field.TypeJSON, value)
+	}
+	if _u.mutation.ModelAliasesCleared() {
+		_spec.ClearField(group.FieldModelAliases, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.AllowedModels(); ok {
+		_spec.SetField(group.FieldAllowedModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldAllowedModels, value)
+		})
+	}
+	if _u.mutation.AllowedModelsCleared() {
+		_spec.ClearField(group.FieldAllowedModels, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.DeniedModels(); ok {
+		_spec.SetField(group.FieldDeniedModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedDeniedModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldDeniedModels, value)
+		})
+	}
+	if _u.mutation.DeniedModelsCleared() {
+		_spec.ClearField(group.FieldDeniedModels, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.UsageLimits(); ok {
+		_spec.SetField(group.FieldUsageLimits, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedUsageLimits(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldUsageLimits, value)
+		})
+	}
+	if _u.mutation.UsageLimitsCleared() {
+		_spec.ClearField(group.FieldUsageLimits, field.TypeJSON)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1391,6 +1636,33 @@ func (_u *GroupUpdateOne) AddDefaultValidityDays(v int) *GroupUpdateOne {
 	return _u
 }
 
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (_u *GroupUpdateOne) SetSubscriptionPrice(v float64) *GroupUpdateOne {
+	_u.mutation.ResetSubscriptionPrice()
+	_u.mutation.SetSubscriptionPrice(v)
+	return _u
+}
+
+// SetNillableSubscriptionPrice sets the "subscription_price" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableSubscriptionPrice(v *float64) *GroupUpdateOne {
+	if v != nil {
+		_u.SetSubscriptionPrice(*v)
+	}
+	return _u
+}
+
+// AddSubscriptionPrice adds value to the "subscription_price" field.
+func (_u *GroupUpdateOne) AddSubscriptionPrice(v float64) *GroupUpdateOne {
+	_u.mutation.AddSubscriptionPrice(v)
+	return _u
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (_u *GroupUpdateOne) ClearSubscriptionPrice() *GroupUpdateOne {
+	_u.mutation.ClearSubscriptionPrice()
+	return _u
+}
+
 // SetImagePrice1k sets the "image_price_1k" field.
 func (_u *GroupUpdateOne) SetImagePrice1k(v float64) *GroupUpdateOne {
 	_u.mutation.ResetImagePrice1k()
@@ -1539,6 +1811,136 @@ func (_u *GroupUpdateOne) SetNillableModelRoutingEnabled(v *bool) *GroupUpdateOn
 	return _u
 }
 
+// SetAccountSelectionStrategy sets the "account_selection_strategy" field.
+func (_u *GroupUpdateOne) SetAccountSelectionStrategy(v string) *GroupUpdateOne {
+	_u.mutation.SetAccountSelectionStrategy(v)
+	return _u
+}
+
+// SetNillableAccountSelectionStrategy sets the "account_selection_strategy" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableAccountSelectionStrategy(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetAccountSelectionStrategy(*v)
+	}
+	return _u
+}
+
+// SetImageModels sets the "image_models" field.
+func (_u *GroupUpdateOne) SetImageModels(v []string) *GroupUpdateOne {
+	_u.mutation.SetImageModels(v)
+	return _u
+}
+
+// AppendImageModels appends value to the "image_models" field.
+func (_u *GroupUpdateOne) AppendImageModels(v []string) *GroupUpdateOne {
+	_u.mutation.AppendImageModels(v)
+	return _u
+}
+
+// ClearImageModels clears the value of the "image_models" field.
+func (_u *GroupUpdateOne) ClearImageModels() *GroupUpdateOne {
+	_u.mutation.ClearImageModels()
+	return _u
+}
+
+// SetContentPolicyAction sets the "content_policy_action" field.
+func (_u *GroupUpdateOne) SetContentPolicyAction(v string) *GroupUpdateOne {
+	_u.mutation.SetContentPolicyAction(v)
+	return _u
+}
+
+// SetNillableContentPolicyAction sets the "content_policy_action" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableContentPolicyAction(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetContentPolicyAction(*v)
+	}
+	return _u
+}
+
+// SetRequestRewriteRules sets the "request_rewrite_rules" field.
+func (_u *GroupUpdateOne) SetRequestRewriteRules(v json.RawMessage) *GroupUpdateOne {
+	_u.mutation.SetRequestRewriteRules(v)
+	return _u
+}
+
+// AppendRequestRewriteRules appends value to the "request_rewrite_rules" field.
+func (_u *GroupUpdateOne) AppendRequestRewriteRules(v json.RawMessage) *GroupUpdateOne {
+	_u.mutation.AppendRequestRewriteRules(v)
+	return _u
+}
+
+// ClearRequestRewriteRules clears the value of the "request_rewrite_rules" field.
+func (_u *GroupUpdateOne) ClearRequestRewriteRules() *GroupUpdateOne {
+	_u.mutation.ClearRequestRewriteRules()
+	return _u
+}
+
+// SetModelAliases sets the "model_aliases" field.
+func (_u *GroupUpdateOne) SetModelAliases(v map[string]string) *GroupUpdateOne {
+	_u.mutation.SetModelAliases(v)
+	return _u
+}
+
+// ClearModelAliases clears the value of the "model_aliases" field.
+func (_u *GroupUpdateOne) ClearModelAliases() *GroupUpdateOne {
+	_u.mutation.ClearModelAliases()
+	return _u
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (_u *GroupUpdateOne) SetAllowedModels(v []string) *GroupUpdateOne {
+	_u.mutation.SetAllowedModels(v)
+	return _u
+}
+
+// AppendAllowedModels appends value to the "allowed_models" field.
+func (_u *GroupUpdateOne) AppendAllowedModels(v []string) *GroupUpdateOne {
+	_u.mutation.AppendAllowedModels(v)
+	return _u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (_u *GroupUpdateOne) ClearAllowedModels() *GroupUpdateOne {
+	_u.mutation.ClearAllowedModels()
+	return _u
+}
+
+// SetDeniedModels sets the "denied_models" field.
+func (_u *GroupUpdateOne) SetDeniedModels(v []string) *GroupUpdateOne {
+	_u.mutation.SetDeniedModels(v)
+	return _u
+}
+
+// AppendDeniedModels appends value to the "denied_models" field.
+func (_u *GroupUpdateOne) AppendDeniedModels(v []string) *GroupUpdateOne {
+	_u.mutation.AppendDeniedModels(v)
+	return _u
+}
+
+// ClearDeniedModels clears the value of the "denied_models" field.
+func (_u *GroupUpdateOne) ClearDeniedModels() *GroupUpdateOne {
+	_u.mutation.ClearDeniedModels()
+	return _u
+}
+
+// SetUsageLimits sets the "usage_limits" field.
+func (_u *GroupUpdateOne) SetUsageLimits(v json.RawMessage) *GroupUpdateOne {
+	_u.mutation.SetUsageLimits(v)
+	return _u
+}
+
+// AppendUsageLimits appends value to the "usage_limits" field.
+func (_u *GroupUpdateOne) AppendUsageLimits(v json.RawMessage) *GroupUpdateOne {
+	_u.mutation.AppendUsageLimits(v)
+	return _u
+}
+
+// ClearUsageLimits clears the value of the "usage_limits" field.
+func (_u *GroupUpdateOne) ClearUsageLimits() *GroupUpdateOne {
+	_u.mutation.ClearUsageLimits()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdateOne) AddAPIKeyIDs(ids ...int64) *GroupUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -1837,6 +2239,16 @@ func (_u *GroupUpdateOne) check() error {
 			return &ValidationError{Name: "subscription_type", err: fmt.Errorf(`ent: validator failed for field "Group.subscription_type": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.AccountSelectionStrategy(); ok {
+		if err := group.AccountSelectionStrategyValidator(v); err != nil {
+			return &ValidationError{Name: "account_selection_strategy", err: fmt.Errorf(`ent: validator failed for field "Group.account_selection_strategy": %w`, err)}
+		}
+	}
+	if v, ok := _u.mutation.ContentPolicyAction(); ok {
+		if err := group.ContentPolicyActionValidator(v); err != nil {
+			return &ValidationError{Name: "content_policy_action", err: fmt.Errorf(`ent: validator failed for field "Group.content_policy_action": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -1938,6 +2350,15 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.AddedDefaultValidityDays(); ok {
 		_spec.AddField(group.FieldDefaultValidityDays, field.TypeInt, value)
 	}
+	if value, ok := _u.mutation.SubscriptionPrice(); ok {
+		_spec.SetField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedSubscriptionPrice(); ok {
+		_spec.AddField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+	}
+	if _u.mutation.SubscriptionPriceCleared() {
+		_spec.ClearField(group.FieldSubscriptionPrice, field.TypeFloat64)
+	}
 	if value, ok := _u.mutation.ImagePrice1k(); ok {
 		_spec.SetField(group.FieldImagePrice1k, field.TypeFloat64, value)
 	}
@@ -1986,6 +2407,73 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
+	if value, ok := _u.mutation.AccountSelectionStrategy(); ok {
+		_spec.SetField(group.FieldAccountSelectionStrategy, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.ImageModels(); ok {
+		_spec.SetField(group.FieldImageModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedImageModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldImageModels, value)
+		})
+	}
+	if _u.mutation.ImageModelsCleared() {
+		_spec.ClearField(group.FieldImageModels, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.ContentPolicyAction(); ok {
+		_spec.SetField(group.FieldContentPolicyAction, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.RequestRewriteRules(); ok {
+		_spec.SetField(group.FieldRequestRewriteRules, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedRequestRewriteRules(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldRequestRewriteRules, value)
+		})
+	}
+	if _u.mutation.RequestRewriteRulesCleared() {
+		_spec.ClearField(group.FieldRequestRewriteRules, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.ModelAliases(); ok {
+		_spec.SetField(group.FieldModelAliases, field.TypeJSON, value)
+	}
+	if _u.mutation.ModelAliasesCleared() {
+		_spec.ClearField(group.FieldModelAliases, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.AllowedModels(); ok {
+		_spec.SetField(group.FieldAllowedModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldAllowedModels, value)
+		})
+	}
+	if _u.mutation.AllowedModelsCleared() {
+		_spec.ClearField(group.FieldAllowedModels, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.DeniedModels(); ok {
+		_spec.SetField(group.FieldDeniedModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedDeniedModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldDeniedModels, value)
+		})
+	}
+	if _u.mutation.DeniedModelsCleared() {
+		_spec.ClearField(group.FieldDeniedModels, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.UsageLimits(); ok {
+		_spec.SetField(group.FieldUsageLimits, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedUsageLimits(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, group.FieldUsageLimits, value)
+		})
+	}
+	if _u.mutation.UsageLimitsCleared() {
+		_spec.ClearField(group.FieldUsageLimits, field.TypeJSON)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
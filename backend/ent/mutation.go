@@ -1186,6 +1186,8 @@ type AccountMutation struct {
 	_type                 *string
 	credentials           *map[string]interface{}
 	extra                 *map[string]interface{}
+	proxy_pool_id         *int64
+	addproxy_pool_id      *int64
 	concurrency           *int
 	addconcurrency        *int
 	priority              *int
@@ -1715,6 +1717,76 @@ func (m *AccountMutation) ResetProxyID() {
 	delete(m.clearedFields, account.FieldProxyID)
 }
 
+// SetProxyPoolID sets the "proxy_pool_id" field.
+func (m *AccountMutation) SetProxyPoolID(i int64) {
+	m.proxy_pool_id = &i
+	m.addproxy_pool_id = nil
+}
+
+// ProxyPoolID returns the value of the "proxy_pool_id" field in the mutation.
+func (m *AccountMutation) ProxyPoolID() (r int64, exists bool) {
+	v := m.proxy_pool_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldProxyPoolID returns the old "proxy_pool_id" field's value of the Account entity.
+// If the Account object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *AccountMutation) OldProxyPoolID(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldProxyPoolID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldProxyPoolID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldProxyPoolID: %w", err)
+	}
+	return oldValue.ProxyPoolID, nil
+}
+
+// AddProxyPoolID adds i to the "proxy_pool_id" field.
+func (m *AccountMutation) AddProxyPoolID(i int64) {
+	if m.addproxy_pool_id != nil {
+		*m.addproxy_pool_id += i
+	} else {
+		m.addproxy_pool_id = &i
+	}
+}
+
+// AddedProxyPoolID returns the value that was added to the "proxy_pool_id" field in this mutation.
+func (m *AccountMutation) AddedProxyPoolID() (r int64, exists bool) {
+	v := m.addproxy_pool_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearProxyPoolID clears the value of the "proxy_pool_id" field.
+func (m *AccountMutation) ClearProxyPoolID() {
+	m.proxy_pool_id = nil
+	m.addproxy_pool_id = nil
+	m.clearedFields[account.FieldProxyPoolID] = struct{}{}
+}
+
+// ProxyPoolIDCleared returns if the "proxy_pool_id" field was cleared in this mutation.
+func (m *AccountMutation) ProxyPoolIDCleared() bool {
+	_, ok := m.clearedFields[account.FieldProxyPoolID]
+	return ok
+}
+
+// ResetProxyPoolID resets all changes to the "proxy_pool_id" field.
+func (m *AccountMutation) ResetProxyPoolID() {
+	m.proxy_pool_id = nil
+	m.addproxy_pool_id = nil
+	delete(m.clearedFields, account.FieldProxyPoolID)
+}
+
 // SetConcurrency sets the "concurrency" field.
 func (m *AccountMutation) SetConcurrency(i int) {
 	m.concurrency = &i
@@ -2601,7 +2673,7 @@ func (m *AccountMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *AccountMutation) Fields() []string {
-	fields := make([]string, 0, 25)
+	fields := make([]string, 0, 26)
 	if m.created_at != nil {
 		fields = append(fields, account.FieldCreatedAt)
 	}
@@ -2632,6 +2704,9 @@ func (m *AccountMutation) Fields() []string {
 	if m.proxy != nil {
 		fields = append(fields, account.FieldProxyID)
 	}
+	if m.proxy_pool_id != nil {
+		fields = append(fields, account.FieldProxyPoolID)
+	}
 	if m.concurrency != nil {
 		fields = append(fields, account.FieldConcurrency)
 	}
@@ -2705,6 +2780,8 @@ func (m *AccountMutation) Field(name string) (ent.Value, bool) {
 		return m.Extra()
 	case account.FieldProxyID:
 		return m.ProxyID()
+	case account.FieldProxyPoolID:
+		return m.ProxyPoolID()
 	case account.FieldConcurrency:
 		return m.Concurrency()
 	case account.FieldPriority:
@@ -2764,6 +2841,8 @@ func (m *AccountMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldExtra(ctx)
 	case account.FieldProxyID:
 		return m.OldProxyID(ctx)
+	case account.FieldProxyPoolID:
+		return m.OldProxyPoolID(ctx)
 	case account.FieldConcurrency:
 		return m.OldConcurrency(ctx)
 	case account.FieldPriority:
@@ -2873,6 +2952,13 @@ func (m *AccountMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetProxyID(v)
 		return nil
+	case account.FieldProxyPoolID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetProxyPoolID(v)
+		return nil
 	case account.FieldConcurrency:
 		v, ok := value.(int)
 		if !ok {
@@ -2986,6 +3072,9 @@ func (m *AccountMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *AccountMutation) AddedFields() []string {
 	var fields []string
+	if m.addproxy_pool_id != nil {
+		fields = append(fields, account.FieldProxyPoolID)
+	}
 	if m.addconcurrency != nil {
 		fields = append(fields, account.FieldConcurrency)
 	}
@@ -3003,6 +3092,8 @@ func (m *AccountMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *AccountMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case account.FieldProxyPoolID:
+		return m.AddedProxyPoolID()
 	case account.FieldConcurrency:
 		return m.AddedConcurrency()
 	case account.FieldPriority:
@@ -3018,6 +3109,13 @@ func (m *AccountMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *AccountMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case account.FieldProxyPoolID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddProxyPoolID(v)
+		return nil
 	case account.FieldConcurrency:
 		v, ok := value.(int)
 		if !ok {
@@ -3056,6 +3154,9 @@ func (m *AccountMutation) ClearedFields() []string {
 	if m.FieldCleared(account.FieldProxyID) {
 		fields = append(fields, account.FieldProxyID)
 	}
+	if m.FieldCleared(account.FieldProxyPoolID) {
+		fields = append(fields, account.FieldProxyPoolID)
+	}
 	if m.FieldCleared(account.FieldErrorMessage) {
 		fields = append(fields, account.FieldErrorMessage)
 	}
@@ -3106,6 +3207,9 @@ func (m *AccountMutation) ClearField(name string) error {
 	case account.FieldProxyID:
 		m.ClearProxyID()
 		return nil
+	case account.FieldProxyPoolID:
+		m.ClearProxyPoolID()
+		return nil
 	case account.FieldErrorMessage:
 		m.ClearErrorMessage()
 		return nil
@@ -3171,6 +3275,9 @@ func (m *AccountMutation) ResetField(name string) error {
 	case account.FieldProxyID:
 		m.ResetProxyID()
 		return nil
+	case account.FieldProxyPoolID:
+		m.ResetProxyPoolID()
+		return nil
 	case account.FieldConcurrency:
 		m.ResetConcurrency()
 		return nil
@@ -3836,61 +3943,76 @@ func (m *AccountGroupMutation) ResetEdge(name string) error {
 // GroupMutation represents an operation that mutates the Group nodes in the graph.
 type GroupMutation struct {
 	config
-	op                       Op
-	typ                      string
-	id                       *int64
-	created_at               *time.Time
-	updated_at               *time.Time
-	deleted_at               *time.Time
-	name                     *string
-	description              *string
-	rate_multiplier          *float64
-	addrate_multiplier       *float64
-	is_exclusive             *bool
-	status                   *string
-	platform                 *string
-	subscription_type        *string
-	daily_limit_usd          *float64
-	adddaily_limit_usd       *float64
-	weekly_limit_usd         *float64
-	addweekly_limit_usd      *float64
-	monthly_limit_usd        *float64
-	addmonthly_limit_usd     *float64
-	default_validity_days    *int
-	adddefault_validity_days *int
-	image_price_1k           *float64
-	addimage_price_1k        *float64
-	image_price_2k           *float64
-	addimage_price_2k        *float64
-	image_price_4k           *float64
-	addimage_price_4k        *float64
-	claude_code_only         *bool
-	fallback_group_id        *int64
-	addfallback_group_id     *int64
-	model_routing            *map[string][]int64
-	model_routing_enabled    *bool
-	clearedFields            map[string]struct{}
-	api_keys                 map[int64]struct{}
-	removedapi_keys          map[int64]struct{}
-	clearedapi_keys          bool
-	redeem_codes             map[int64]struct{}
-	removedredeem_codes      map[int64]struct{}
-	clearedredeem_codes      bool
-	subscriptions            map[int64]struct{}
-	removedsubscriptions     map[int64]struct{}
-	clearedsubscriptions     bool
-	usage_logs               map[int64]struct{}
-	removedusage_logs        map[int64]struct{}
-	clearedusage_logs        bool
-	accounts                 map[int64]struct{}
-	removedaccounts          map[int64]struct{}
-	clearedaccounts          bool
-	allowed_users            map[int64]struct{}
-	removedallowed_users     map[int64]struct{}
-	clearedallowed_users     bool
-	done                     bool
-	oldValue                 func(context.Context) (*Group, error)
-	predicates               []predicate.Group
+	op                          Op
+	typ                         string
+	id                          *int64
+	created_at                  *time.Time
+	updated_at                  *time.Time
+	deleted_at                  *time.Time
+	name                        *string
+	description                 *string
+	rate_multiplier             *float64
+	addrate_multiplier          *float64
+	is_exclusive                *bool
+	status                      *string
+	platform                    *string
+	subscription_type           *string
+	daily_limit_usd             *float64
+	adddaily_limit_usd          *float64
+	weekly_limit_usd            *float64
+	addweekly_limit_usd         *float64
+	monthly_limit_usd           *float64
+	addmonthly_limit_usd        *float64
+	default_validity_days       *int
+	adddefault_validity_days    *int
+	subscription_price          *float64
+	addsubscription_price       *float64
+	image_price_1k              *float64
+	addimage_price_1k           *float64
+	image_price_2k              *float64
+	addimage_price_2k           *float64
+	image_price_4k              *float64
+	addimage_price_4k           *float64
+	claude_code_only            *bool
+	fallback_group_id           *int64
+	addfallback_group_id        *int64
+	model_routing               *map[string][]int64
+	model_routing_enabled       *bool
+	account_selection_strategy  *string
+	image_models                *[]string
+	appendimage_models          []string
+	content_policy_action       *string
+	request_rewrite_rules       *json.RawMessage
+	appendrequest_rewrite_rules json.RawMessage
+	model_aliases               *map[string]string
+	allowed_models              *[]string
+	appendallowed_models        []string
+	denied_models               *[]string
+	appenddenied_models         []string
+	usage_limits                *json.RawMessage
+	appendusage_limits          json.RawMessage
+	clearedFields               map[string]struct{}
+	api_keys                    map[int64]struct{}
+	removedapi_keys             map[int64]struct{}
+	clearedapi_keys             bool
+	redeem_codes                map[int64]struct{}
+	removedredeem_codes         map[int64]struct{}
+	clearedredeem_codes         bool
+	subscriptions               map[int64]struct{}
+	removedsubscriptions        map[int64]struct{}
+	clearedsubscriptions        bool
+	usage_logs                  map[int64]struct{}
+	removedusage_logs           map[int64]struct{}
+	clearedusage_logs           bool
+	accounts                    map[int64]struct{}
+	removedaccounts             map[int64]struct{}
+	clearedaccounts             bool
+	allowed_users               map[int64]struct{}
+	removedallowed_users        map[int64]struct{}
+	clearedallowed_users        bool
+	done                        bool
+	oldValue                    func(context.Context) (*Group, error)
+	predicates                  []predicate.Group
 }
 
 var _ ent.Mutation = (*GroupMutation)(nil)
@@ -4663,6 +4785,76 @@ func (m *GroupMutation) ResetDefaultValidityDays() {
 	m.adddefault_validity_days = nil
 }
 
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (m *GroupMutation) SetSubscriptionPrice(f float64) {
+	m.subscription_price = &f
+	m.addsubscription_price = nil
+}
+
+// SubscriptionPrice returns the value of the "subscription_price" field in the mutation.
+func (m *GroupMutation) SubscriptionPrice() (r float64, exists bool) {
+	v := m.subscription_price
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldSubscriptionPrice returns the old "subscription_price" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldSubscriptionPrice(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldSubscriptionPrice is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldSubscriptionPrice requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldSubscriptionPrice: %w", err)
+	}
+	return oldValue.SubscriptionPrice, nil
+}
+
+// AddSubscriptionPrice adds f to the "subscription_price" field.
+func (m *GroupMutation) AddSubscriptionPrice(f float64) {
+	if m.addsubscription_price != nil {
+		*m.addsubscription_price += f
+	} else {
+		m.addsubscription_price = &f
+	}
+}
+
+// AddedSubscriptionPrice returns the value that was added to the "subscription_price" field in this mutation.
+func (m *GroupMutation) AddedSubscriptionPrice() (r float64, exists bool) {
+	v := m.addsubscription_price
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (m *GroupMutation) ClearSubscriptionPrice() {
+	m.subscription_price = nil
+	m.addsubscription_price = nil
+	m.clearedFields[group.FieldSubscriptionPrice] = struct{}{}
+}
+
+// SubscriptionPriceCleared returns if the "subscription_price" field was cleared in this mutation.
+func (m *GroupMutation) SubscriptionPriceCleared() bool {
+	_, ok := m.clearedFields[group.FieldSubscriptionPrice]
+	return ok
+}
+
+// ResetSubscriptionPrice resets all changes to the "subscription_price" field.
+func (m *GroupMutation) ResetSubscriptionPrice() {
+	m.subscription_price = nil
+	m.addsubscription_price = nil
+	delete(m.clearedFields, group.FieldSubscriptionPrice)
+}
+
 // SetImagePrice1k sets the "image_price_1k" field.
 func (m *GroupMutation) SetImagePrice1k(f float64) {
 	m.image_price_1k = &f
@@ -5064,6 +5256,452 @@ func (m *GroupMutation) ResetModelRoutingEnabled() {
 	m.model_routing_enabled = nil
 }
 
+// SetAccountSelectionStrategy sets the "account_selection_strategy" field.
+func (m *GroupMutation) SetAccountSelectionStrategy(s string) {
+	m.account_selection_strategy = &s
+}
+
+// AccountSelectionStrategy returns the value of the "account_selection_strategy" field in the mutation.
+func (m *GroupMutation) AccountSelectionStrategy() (r string, exists bool) {
+	v := m.account_selection_strategy
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAccountSelectionStrategy returns the old "account_selection_strategy" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldAccountSelectionStrategy(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAccountSelectionStrategy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAccountSelectionStrategy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAccountSelectionStrategy: %w", err)
+	}
+	return oldValue.AccountSelectionStrategy, nil
+}
+
+// ResetAccountSelectionStrategy resets all changes to the "account_selection_strategy" field.
+func (m *GroupMutation) ResetAccountSelectionStrategy() {
+	m.account_selection_strategy = nil
+}
+
+// SetImageModels sets the "image_models" field.
+func (m *GroupMutation) SetImageModels(s []string) {
+	m.image_models = &s
+	m.appendimage_models = nil
+}
+
+// ImageModels returns the value of the "image_models" field in the mutation.
+func (m *GroupMutation) ImageModels() (r []string, exists bool) {
+	v := m.image_models
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldImageModels returns the old "image_models" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldImageModels(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldImageModels is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldImageModels requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldImageModels: %w", err)
+	}
+	return oldValue.ImageModels, nil
+}
+
+// AppendImageModels adds s to the "image_models" field.
+func (m *GroupMutation) AppendImageModels(s []string) {
+	m.appendimage_models = append(m.appendimage_models, s...)
+}
+
+// AppendedImageModels returns the list of values that were appended to the "image_models" field in this mutation.
+func (m *GroupMutation) AppendedImageModels() ([]string, bool) {
+	if len(m.appendimage_models) == 0 {
+		return nil, false
+	}
+	return m.appendimage_models, true
+}
+
+// ClearImageModels clears the value of the "image_models" field.
+func (m *GroupMutation) ClearImageModels() {
+	m.image_models = nil
+	m.appendimage_models = nil
+	m.clearedFields[group.FieldImageModels] = struct{}{}
+}
+
+// ImageModelsCleared returns if the "image_models" field was cleared in this mutation.
+func (m *GroupMutation) ImageModelsCleared() bool {
+	_, ok := m.clearedFields[group.FieldImageModels]
+	return ok
+}
+
+// ResetImageModels resets all changes to the "image_models" field.
+func (m *GroupMutation) ResetImageModels() {
+	m.image_models = nil
+	m.appendimage_models = nil
+	delete(m.clearedFields, group.FieldImageModels)
+}
+
+// SetContentPolicyAction sets the "content_policy_action" field.
+func (m *GroupMutation) SetContentPolicyAction(s string) {
+	m.content_policy_action = &s
+}
+
+// ContentPolicyAction returns the value of the "content_policy_action" field in the mutation.
+func (m *GroupMutation) ContentPolicyAction() (r string, exists bool) {
+	v := m.content_policy_action
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldContentPolicyAction returns the old "content_policy_action" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldContentPolicyAction(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldContentPolicyAction is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldContentPolicyAction requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldContentPolicyAction: %w", err)
+	}
+	return oldValue.ContentPolicyAction, nil
+}
+
+// ResetContentPolicyAction resets all changes to the "content_policy_action" field.
+func (m *GroupMutation) ResetContentPolicyAction() {
+	m.content_policy_action = nil
+}
+
+// SetRequestRewriteRules sets the "request_rewrite_rules" field.
+func (m *GroupMutation) SetRequestRewriteRules(jm json.RawMessage) {
+	m.request_rewrite_rules = &jm
+	m.appendrequest_rewrite_rules = nil
+}
+
+// RequestRewriteRules returns the value of the "request_rewrite_rules" field in the mutation.
+func (m *GroupMutation) RequestRewriteRules() (r json.RawMessage, exists bool) {
+	v := m.request_rewrite_rules
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRequestRewriteRules returns the old "request_rewrite_rules" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldRequestRewriteRules(ctx context.Context) (v json.RawMessage, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRequestRewriteRules is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRequestRewriteRules requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRequestRewriteRules: %w", err)
+	}
+	return oldValue.RequestRewriteRules, nil
+}
+
+// AppendRequestRewriteRules adds jm to the "request_rewrite_rules" field.
+func (m *GroupMutation) AppendRequestRewriteRules(jm json.RawMessage) {
+	m.appendrequest_rewrite_rules = append(m.appendrequest_rewrite_rules, jm...)
+}
+
+// AppendedRequestRewriteRules returns the list of values that were appended to the "request_rewrite_rules" field in this mutation.
+func (m *GroupMutation) AppendedRequestRewriteRules() (json.RawMessage, bool) {
+	if len(m.appendrequest_rewrite_rules) == 0 {
+		return nil, false
+	}
+	return m.appendrequest_rewrite_rules, true
+}
+
+// ClearRequestRewriteRules clears the value of the "request_rewrite_rules" field.
+func (m *GroupMutation) ClearRequestRewriteRules() {
+	m.request_rewrite_rules = nil
+	m.appendrequest_rewrite_rules = nil
+	m.clearedFields[group.FieldRequestRewriteRules] = struct{}{}
+}
+
+// RequestRewriteRulesCleared returns if the "request_rewrite_rules" field was cleared in this mutation.
+func (m *GroupMutation) RequestRewriteRulesCleared() bool {
+	_, ok := m.clearedFields[group.FieldRequestRewriteRules]
+	return ok
+}
+
+// ResetRequestRewriteRules resets all changes to the "request_rewrite_rules" field.
+func (m *GroupMutation) ResetRequestRewriteRules() {
+	m.request_rewrite_rules = nil
+	m.appendrequest_rewrite_rules = nil
+	delete(m.clearedFields, group.FieldRequestRewriteRules)
+}
+
+// SetModelAliases sets the "model_aliases" field.
+func (m *GroupMutation) SetModelAliases(value map[string]string) {
+	m.model_aliases = &value
+}
+
+// ModelAliases returns the value of the "model_aliases" field in the mutation.
+func (m *GroupMutation) ModelAliases() (r map[string]string, exists bool) {
+	v := m.model_aliases
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelAliases returns the old "model_aliases" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelAliases(ctx context.Context) (v map[string]string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelAliases is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelAliases requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelAliases: %w", err)
+	}
+	return oldValue.ModelAliases, nil
+}
+
+// ClearModelAliases clears the value of the "model_aliases" field.
+func (m *GroupMutation) ClearModelAliases() {
+	m.model_aliases = nil
+	m.clearedFields[group.FieldModelAliases] = struct{}{}
+}
+
+// ModelAliasesCleared returns if the "model_aliases" field was cleared in this mutation.
+func (m *GroupMutation) ModelAliasesCleared() bool {
+	_, ok := m.clearedFields[group.FieldModelAliases]
+	return ok
+}
+
+// ResetModelAliases resets all changes to the "model_aliases" field.
+func (m *GroupMutation) ResetModelAliases() {
+	m.model_aliases = nil
+	delete(m.clearedFields, group.FieldModelAliases)
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (m *GroupMutation) SetAllowedModels(s []string) {
+	m.allowed_models = &s
+	m.appendallowed_models = nil
+}
+
+// AllowedModels returns the value of the "allowed_models" field in the mutation.
+func (m *GroupMutation) AllowedModels() (r []string, exists bool) {
+	v := m.allowed_models
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAllowedModels returns the old "allowed_models" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldAllowedModels(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAllowedModels is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAllowedModels requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAllowedModels: %w", err)
+	}
+	return oldValue.AllowedModels, nil
+}
+
+// AppendAllowedModels adds s to the "allowed_models" field.
+func (m *GroupMutation) AppendAllowedModels(s []string) {
+	m.appendallowed_models = append(m.appendallowed_models, s...)
+}
+
+// AppendedAllowedModels returns the list of values that were appended to the "allowed_models" field in this mutation.
+func (m *GroupMutation) AppendedAllowedModels() ([]string, bool) {
+	if len(m.appendallowed_models) == 0 {
+		return nil, false
+	}
+	return m.appendallowed_models, true
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (m *GroupMutation) ClearAllowedModels() {
+	m.allowed_models = nil
+	m.appendallowed_models = nil
+	m.clearedFields[group.FieldAllowedModels] = struct{}{}
+}
+
+// AllowedModelsCleared returns if the "allowed_models" field was cleared in this mutation.
+func (m *GroupMutation) AllowedModelsCleared() bool {
+	_, ok := m.clearedFields[group.FieldAllowedModels]
+	return ok
+}
+
+// ResetAllowedModels resets all changes to the "allowed_models" field.
+func (m *GroupMutation) ResetAllowedModels() {
+	m.allowed_models = nil
+	m.appendallowed_models = nil
+	delete(m.clearedFields, group.FieldAllowedModels)
+}
+
+// SetDeniedModels sets the "denied_models" field.
+func (m *GroupMutation) SetDeniedModels(s []string) {
+	m.denied_models = &s
+	m.appenddenied_models = nil
+}
+
+// DeniedModels returns the value of the "denied_models" field in the mutation.
+func (m *GroupMutation) DeniedModels() (r []string, exists bool) {
+	v := m.denied_models
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDeniedModels returns the old "denied_models" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldDeniedModels(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDeniedModels is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDeniedModels requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDeniedModels: %w", err)
+	}
+	return oldValue.DeniedModels, nil
+}
+
+// AppendDeniedModels adds s to the "denied_models" field.
+func (m *GroupMutation) AppendDeniedModels(s []string) {
+	m.appenddenied_models = append(m.appenddenied_models, s...)
+}
+
+// AppendedDeniedModels returns the list of values that were appended to the "denied_models" field in this mutation.
+func (m *GroupMutation) AppendedDeniedModels() ([]string, bool) {
+	if len(m.appenddenied_models) == 0 {
+		return nil, false
+	}
+	return m.appenddenied_models, true
+}
+
+// ClearDeniedModels clears the value of the "denied_models" field.
+func (m *GroupMutation) ClearDeniedModels() {
+	m.denied_models = nil
+	m.appenddenied_models = nil
+	m.clearedFields[group.FieldDeniedModels] = struct{}{}
+}
+
+// DeniedModelsCleared returns if the "denied_models" field was cleared in this mutation.
+func (m *GroupMutation) DeniedModelsCleared() bool {
+	_, ok := m.clearedFields[group.FieldDeniedModels]
+	return ok
+}
+
+// ResetDeniedModels resets all changes to the "denied_models" field.
+func (m *GroupMutation) ResetDeniedModels() {
+	m.denied_models = nil
+	m.appenddenied_models = nil
+	delete(m.clearedFields, group.FieldDeniedModels)
+}
+
+// SetUsageLimits sets the "usage_limits" field.
+func (m *GroupMutation) SetUsageLimits(jm json.RawMessage) {
+	m.usage_limits = &jm
+	m.appendusage_limits = nil
+}
+
+// UsageLimits returns the value of the "usage_limits" field in the mutation.
+func (m *GroupMutation) UsageLimits() (r json.RawMessage, exists bool) {
+	v := m.usage_limits
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldUsageLimits returns the old "usage_limits" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldUsageLimits(ctx context.Context) (v json.RawMessage, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldUsageLimits is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldUsageLimits requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldUsageLimits: %w", err)
+	}
+	return oldValue.UsageLimits, nil
+}
+
+// AppendUsageLimits adds jm to the "usage_limits" field.
+func (m *GroupMutation) AppendUsageLimits(jm json.RawMessage) {
+	m.appendusage_limits = append(m.appendusage_limits, jm...)
+}
+
+// AppendedUsageLimits returns the list of values that were appended to the "usage_limits" field in this mutation.
+func (m *GroupMutation) AppendedUsageLimits() (json.RawMessage, bool) {
+	if len(m.appendusage_limits) == 0 {
+		return nil, false
+	}
+	return m.appendusage_limits, true
+}
+
+// ClearUsageLimits clears the value of the "usage_limits" field.
+func (m *GroupMutation) ClearUsageLimits() {
+	m.usage_limits = nil
+	m.appendusage_limits = nil
+	m.clearedFields[group.FieldUsageLimits] = struct{}{}
+}
+
+// UsageLimitsCleared returns if the "usage_limits" field was cleared in this mutation.
+func (m *GroupMutation) UsageLimitsCleared() bool {
+	_, ok := m.clearedFields[group.FieldUsageLimits]
+	return ok
+}
+
+// ResetUsageLimits resets all changes to the "usage_limits" field.
+func (m *GroupMutation) ResetUsageLimits() {
+	m.usage_limits = nil
+	m.appendusage_limits = nil
+	delete(m.clearedFields, group.FieldUsageLimits)
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *GroupMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -5422,7 +6060,7 @@ func (m *GroupMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *GroupMutation) Fields() []string {
-	fields := make([]string, 0, 21)
+	fields := make([]string, 0, 30)
 	if m.created_at != nil {
 		fields = append(fields, group.FieldCreatedAt)
 	}
@@ -5465,6 +6103,9 @@ func (m *GroupMutation) Fields() []string {
 	if m.default_validity_days != nil {
 		fields = append(fields, group.FieldDefaultValidityDays)
 	}
+	if m.subscription_price != nil {
+		fields = append(fields, group.FieldSubscriptionPrice)
+	}
 	if m.image_price_1k != nil {
 		fields = append(fields, group.FieldImagePrice1k)
 	}
@@ -5486,6 +6127,30 @@ func (m *GroupMutation) Fields() []string {
 	if m.model_routing_enabled != nil {
 		fields = append(fields, group.FieldModelRoutingEnabled)
 	}
+	if m.account_selection_strategy != nil {
+		fields = append(fields, group.FieldAccountSelectionStrategy)
+	}
+	if m.image_models != nil {
+		fields = append(fields, group.FieldImageModels)
+	}
+	if m.content_policy_action != nil {
+		fields = append(fields, group.FieldContentPolicyAction)
+	}
+	if m.request_rewrite_rules != nil {
+		fields = append(fields, group.FieldRequestRewriteRules)
+	}
+	if m.model_aliases != nil {
+		fields = append(fields, group.FieldModelAliases)
+	}
+	if m.allowed_models != nil {
+		fields = append(fields, group.FieldAllowedModels)
+	}
+	if m.denied_models != nil {
+		fields = append(fields, group.FieldDeniedModels)
+	}
+	if m.usage_limits != nil {
+		fields = append(fields, group.FieldUsageLimits)
+	}
 	return fields
 }
 
@@ -5522,6 +6187,8 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.MonthlyLimitUsd()
 	case group.FieldDefaultValidityDays:
 		return m.DefaultValidityDays()
+	case group.FieldSubscriptionPrice:
+		return m.SubscriptionPrice()
 	case group.FieldImagePrice1k:
 		return m.ImagePrice1k()
 	case group.FieldImagePrice2k:
@@ -5536,6 +6203,22 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.ModelRouting()
 	case group.FieldModelRoutingEnabled:
 		return m.ModelRoutingEnabled()
+	case group.FieldAccountSelectionStrategy:
+		return m.AccountSelectionStrategy()
+	case group.FieldImageModels:
+		return m.ImageModels()
+	case group.FieldContentPolicyAction:
+		return m.ContentPolicyAction()
+	case group.FieldRequestRewriteRules:
+		return m.RequestRewriteRules()
+	case group.FieldModelAliases:
+		return m.ModelAliases()
+	case group.FieldAllowedModels:
+		return m.AllowedModels()
+	case group.FieldDeniedModels:
+		return m.DeniedModels()
+	case group.FieldUsageLimits:
+		return m.UsageLimits()
 	}
 	return nil, false
 }
@@ -5573,6 +6256,8 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldMonthlyLimitUsd(ctx)
 	case group.FieldDefaultValidityDays:
 		return m.OldDefaultValidityDays(ctx)
+	case group.FieldSubscriptionPrice:
+		return m.OldSubscriptionPrice(ctx)
 	case group.FieldImagePrice1k:
 		return m.OldImagePrice1k(ctx)
 	case group.FieldImagePrice2k:
@@ -5587,6 +6272,22 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldModelRouting(ctx)
 	case group.FieldModelRoutingEnabled:
 		return m.OldModelRoutingEnabled(ctx)
+	case group.FieldAccountSelectionStrategy:
+		return m.OldAccountSelectionStrategy(ctx)
+	case group.FieldImageModels:
+		return m.OldImageModels(ctx)
+	case group.FieldContentPolicyAction:
+		return m.OldContentPolicyAction(ctx)
+	case group.FieldRequestRewriteRules:
+		return m.OldRequestRewriteRules(ctx)
+	case group.FieldModelAliases:
+		return m.OldModelAliases(ctx)
+	case group.FieldAllowedModels:
+		return m.OldAllowedModels(ctx)
+	case group.FieldDeniedModels:
+		return m.OldDeniedModels(ctx)
+	case group.FieldUsageLimits:
+		return m.OldUsageLimits(ctx)
 	}
 	return nil, fmt.Errorf("unknown Group field %s", name)
 }
@@ -5694,54 +6395,117 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetDefaultValidityDays(v)
 		return nil
-	case group.FieldImagePrice1k:
-		v, ok := value.(float64)
+	case group.FieldSubscriptionPrice:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetSubscriptionPrice(v)
+		return nil
+	case group.FieldImagePrice1k:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetImagePrice1k(v)
+		return nil
+	case group.FieldImagePrice2k:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetImagePrice2k(v)
+		return nil
+	case group.FieldImagePrice4k:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetImagePrice4k(v)
+		return nil
+	case group.FieldClaudeCodeOnly:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetClaudeCodeOnly(v)
+		return nil
+	case group.FieldFallbackGroupID:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetFallbackGroupID(v)
+		return nil
+	case group.FieldModelRouting:
+		v, ok := value.(map[string][]int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelRouting(v)
+		return nil
+	case group.FieldModelRoutingEnabled:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelRoutingEnabled(v)
+		return nil
+	case group.FieldAccountSelectionStrategy:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAccountSelectionStrategy(v)
+		return nil
+	case group.FieldImageModels:
+		v, ok := value.([]string)
 		if !ok {
 			return fmt.Errorf("unexpected type %T for field %s", value, name)
 		}
-		m.SetImagePrice1k(v)
+		m.SetImageModels(v)
 		return nil
-	case group.FieldImagePrice2k:
-		v, ok := value.(float64)
+	case group.FieldContentPolicyAction:
+		v, ok := value.(string)
 		if !ok {
 			return fmt.Errorf("unexpected type %T for field %s", value, name)
 		}
-		m.SetImagePrice2k(v)
+		m.SetContentPolicyAction(v)
 		return nil
-	case group.FieldImagePrice4k:
-		v, ok := value.(float64)
+	case group.FieldRequestRewriteRules:
+		v, ok := value.(json.RawMessage)
 		if !ok {
 			return fmt.Errorf("unexpected type %T for field %s", value, name)
 		}
-		m.SetImagePrice4k(v)
+		m.SetRequestRewriteRules(v)
 		return nil
-	case group.FieldClaudeCodeOnly:
-		v, ok := value.(bool)
+	case group.FieldModelAliases:
+		v, ok := value.(map[string]string)
 		if !ok {
 			return fmt.Errorf("unexpected type %T for field %s", value, name)
 		}
-		m.SetClaudeCodeOnly(v)
+		m.SetModelAliases(v)
 		return nil
-	case group.FieldFallbackGroupID:
-		v, ok := value.(int64)
+	case group.FieldAllowedModels:
+		v, ok := value.([]string)
 		if !ok {
 			return fmt.Errorf("unexpected type %T for field %s", value, name)
 		}
-		m.SetFallbackGroupID(v)
+		m.SetAllowedModels(v)
 		return nil
-	case group.FieldModelRouting:
-		v, ok := value.(map[string][]int64)
+	case group.FieldDeniedModels:
+		v, ok := value.([]string)
 		if !ok {
 			return fmt.Errorf("unexpected type %T for field %s", value, name)
 		}
-		m.SetModelRouting(v)
+		m.SetDeniedModels(v)
 		return nil
-	case group.FieldModelRoutingEnabled:
-		v, ok := value.(bool)
+	case group.FieldUsageLimits:
+		v, ok := value.(json.RawMessage)
 		if !ok {
 			return fmt.Errorf("unexpected type %T for field %s", value, name)
 		}
-		m.SetModelRoutingEnabled(v)
+		m.SetUsageLimits(v)
 		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
@@ -5766,6 +6530,9 @@ func (m *GroupMutation) AddedFields() []string {
 	if m.adddefault_validity_days != nil {
 		fields = append(fields, group.FieldDefaultValidityDays)
 	}
+	if m.addsubscription_price != nil {
+		fields = append(fields, group.FieldSubscriptionPrice)
+	}
 	if m.addimage_price_1k != nil {
 		fields = append(fields, group.FieldImagePrice1k)
 	}
@@ -5796,6 +6563,8 @@ func (m *GroupMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedMonthlyLimitUsd()
 	case group.FieldDefaultValidityDays:
 		return m.AddedDefaultValidityDays()
+	case group.FieldSubscriptionPrice:
+		return m.AddedSubscriptionPrice()
 	case group.FieldImagePrice1k:
 		return m.AddedImagePrice1k()
 	case group.FieldImagePrice2k:
@@ -5848,6 +6617,13 @@ func (m *GroupMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddDefaultValidityDays(v)
 		return nil
+	case group.FieldSubscriptionPrice:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddSubscriptionPrice(v)
+		return nil
 	case group.FieldImagePrice1k:
 		v, ok := value.(float64)
 		if !ok {
@@ -5899,6 +6675,9 @@ func (m *GroupMutation) ClearedFields() []string {
 	if m.FieldCleared(group.FieldMonthlyLimitUsd) {
 		fields = append(fields, group.FieldMonthlyLimitUsd)
 	}
+	if m.FieldCleared(group.FieldSubscriptionPrice) {
+		fields = append(fields, group.FieldSubscriptionPrice)
+	}
 	if m.FieldCleared(group.FieldImagePrice1k) {
 		fields = append(fields, group.FieldImagePrice1k)
 	}
@@ -5914,6 +6693,24 @@ func (m *GroupMutation) ClearedFields() []string {
 	if m.FieldCleared(group.FieldModelRouting) {
 		fields = append(fields, group.FieldModelRouting)
 	}
+	if m.FieldCleared(group.FieldImageModels) {
+		fields = append(fields, group.FieldImageModels)
+	}
+	if m.FieldCleared(group.FieldRequestRewriteRules) {
+		fields = append(fields, group.FieldRequestRewriteRules)
+	}
+	if m.FieldCleared(group.FieldModelAliases) {
+		fields = append(fields, group.FieldModelAliases)
+	}
+	if m.FieldCleared(group.FieldAllowedModels) {
+		fields = append(fields, group.FieldAllowedModels)
+	}
+	if m.FieldCleared(group.FieldDeniedModels) {
+		fields = append(fields, group.FieldDeniedModels)
+	}
+	if m.FieldCleared(group.FieldUsageLimits) {
+		fields = append(fields, group.FieldUsageLimits)
+	}
 	return fields
 }
 
@@ -5943,6 +6740,9 @@ func (m *GroupMutation) ClearField(name string) error {
 	case group.FieldMonthlyLimitUsd:
 		m.ClearMonthlyLimitUsd()
 		return nil
+	case group.FieldSubscriptionPrice:
+		m.ClearSubscriptionPrice()
+		return nil
 	case group.FieldImagePrice1k:
 		m.ClearImagePrice1k()
 		return nil
@@ -5958,6 +6758,24 @@ func (m *GroupMutation) ClearField(name string) error {
 	case group.FieldModelRouting:
 		m.ClearModelRouting()
 		return nil
+	case group.FieldImageModels:
+		m.ClearImageModels()
+		return nil
+	case group.FieldRequestRewriteRules:
+		m.ClearRequestRewriteRules()
+		return nil
+	case group.FieldModelAliases:
+		m.ClearModelAliases()
+		return nil
+	case group.FieldAllowedModels:
+		m.ClearAllowedModels()
+		return nil
+	case group.FieldDeniedModels:
+		m.ClearDeniedModels()
+		return nil
+	case group.FieldUsageLimits:
+		m.ClearUsageLimits()
+		return nil
 	}
 	return fmt.Errorf("unknown Group nullable field %s", name)
 }
@@ -6008,6 +6826,9 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldDefaultValidityDays:
 		m.ResetDefaultValidityDays()
 		return nil
+	case group.FieldSubscriptionPrice:
+		m.ResetSubscriptionPrice()
+		return nil
 	case group.FieldImagePrice1k:
 		m.ResetImagePrice1k()
 		return nil
@@ -6029,6 +6850,30 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldModelRoutingEnabled:
 		m.ResetModelRoutingEnabled()
 		return nil
+	case group.FieldAccountSelectionStrategy:
+		m.ResetAccountSelectionStrategy()
+		return nil
+	case group.FieldImageModels:
+		m.ResetImageModels()
+		return nil
+	case group.FieldContentPolicyAction:
+		m.ResetContentPolicyAction()
+		return nil
+	case group.FieldRequestRewriteRules:
+		m.ResetRequestRewriteRules()
+		return nil
+	case group.FieldModelAliases:
+		m.ResetModelAliases()
+		return nil
+	case group.FieldAllowedModels:
+		m.ResetAllowedModels()
+		return nil
+	case group.FieldDeniedModels:
+		m.ResetDeniedModels()
+		return nil
+	case group.FieldUsageLimits:
+		m.ResetUsageLimits()
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -11504,6 +12349,7 @@ type UsageLogMutation struct {
 	image_count                 *int
 	addimage_count              *int
 	image_size                  *string
+	trace_id                    *string
 	created_at                  *time.Time
 	clearedFields               map[string]struct{}
 	user                        *int64
@@ -13130,6 +13976,55 @@ func (m *UsageLogMutation) ResetImageSize() {
 	delete(m.clearedFields, usagelog.FieldImageSize)
 }
 
+// SetTraceID sets the "trace_id" field.
+func (m *UsageLogMutation) SetTraceID(s string) {
+	m.trace_id = &s
+}
+
+// TraceID returns the value of the "trace_id" field in the mutation.
+func (m *UsageLogMutation) TraceID() (r string, exists bool) {
+	v := m.trace_id
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTraceID returns the old "trace_id" field's value of the UsageLog entity.
+// If the UsageLog object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UsageLogMutation) OldTraceID(ctx context.Context) (v *string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTraceID is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTraceID requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTraceID: %w", err)
+	}
+	return oldValue.TraceID, nil
+}
+
+// ClearTraceID clears the value of the "trace_id" field.
+func (m *UsageLogMutation) ClearTraceID() {
+	m.trace_id = nil
+	m.clearedFields[usagelog.FieldTraceID] = struct{}{}
+}
+
+// TraceIDCleared returns if the "trace_id" field was cleared in this mutation.
+func (m *UsageLogMutation) TraceIDCleared() bool {
+	_, ok := m.clearedFields[usagelog.FieldTraceID]
+	return ok
+}
+
+// ResetTraceID resets all changes to the "trace_id" field.
+func (m *UsageLogMutation) ResetTraceID() {
+	m.trace_id = nil
+	delete(m.clearedFields, usagelog.FieldTraceID)
+}
+
 // SetCreatedAt sets the "created_at" field.
 func (m *UsageLogMutation) SetCreatedAt(t time.Time) {
 	m.created_at = &t
@@ -13423,6 +14318,9 @@ func (m *UsageLogMutation) Fields() []string {
 	if m.image_size != nil {
 		fields = append(fields, usagelog.FieldImageSize)
 	}
+	if m.trace_id != nil {
+		fields = append(fields, usagelog.FieldTraceID)
+	}
 	if m.created_at != nil {
 		fields = append(fields, usagelog.FieldCreatedAt)
 	}
@@ -13492,6 +14390,8 @@ func (m *UsageLogMutation) Field(name string) (ent.Value, bool) {
 		return m.ImageCount()
 	case usagelog.FieldImageSize:
 		return m.ImageSize()
+	case usagelog.FieldTraceID:
+		return m.TraceID()
 	case usagelog.FieldCreatedAt:
 		return m.CreatedAt()
 	}
@@ -13561,6 +14461,8 @@ func (m *UsageLogMutation) OldField(ctx context.Context, name string) (ent.Value
 		return m.OldImageCount(ctx)
 	case usagelog.FieldImageSize:
 		return m.OldImageSize(ctx)
+	case usagelog.FieldTraceID:
+		return m.OldTraceID(ctx)
 	case usagelog.FieldCreatedAt:
 		return m.OldCreatedAt(ctx)
 	}
@@ -13775,6 +14677,13 @@ func (m *UsageLogMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetImageSize(v)
 		return nil
+	case usagelog.FieldTraceID:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTraceID(v)
+		return nil
 	case usagelog.FieldCreatedAt:
 		v, ok := value.(time.Time)
 		if !ok {
@@ -14055,6 +14964,9 @@ func (m *UsageLogMutation) ClearedFields() []string {
 	if m.FieldCleared(usagelog.FieldImageSize) {
 		fields = append(fields, usagelog.FieldImageSize)
 	}
+	if m.FieldCleared(usagelog.FieldTraceID) {
+		fields = append(fields, usagelog.FieldTraceID)
+	}
 	return fields
 }
 
@@ -14093,6 +15005,9 @@ func (m *UsageLogMutation) ClearField(name string) error {
 	case usagelog.FieldImageSize:
 		m.ClearImageSize()
 		return nil
+	case usagelog.FieldTraceID:
+		m.ClearTraceID()
+		return nil
 	}
 	return fmt.Errorf("unknown UsageLog nullable field %s", name)
 }
@@ -14188,6 +15103,9 @@ func (m *UsageLogMutation) ResetField(name string) error {
 	case usagelog.FieldImageSize:
 		m.ResetImageSize()
 		return nil
+	case usagelog.FieldTraceID:
+		m.ResetTraceID()
+		return nil
 	case usagelog.FieldCreatedAt:
 		m.ResetCreatedAt()
 		return nil
@@ -18411,6 +19329,11 @@ type UserSubscriptionMutation struct {
 	addmonthly_usage_usd    *float64
 	assigned_at             *time.Time
 	notes                   *string
+	daily_usage_counters    *map[string]float64
+	weekly_usage_counters   *map[string]float64
+	monthly_usage_counters  *map[string]float64
+	auto_renew              *bool
+	paused_at               *time.Time
 	clearedFields           map[string]struct{}
 	user                    *int64
 	cleareduser             bool
@@ -19274,6 +20197,238 @@ func (m *UserSubscriptionMutation) ResetNotes() {
 	delete(m.clearedFields, usersubscription.FieldNotes)
 }
 
+// SetDailyUsageCounters sets the "daily_usage_counters" field.
+func (m *UserSubscriptionMutation) SetDailyUsageCounters(value map[string]float64) {
+	m.daily_usage_counters = &value
+}
+
+// DailyUsageCounters returns the value of the "daily_usage_counters" field in the mutation.
+func (m *UserSubscriptionMutation) DailyUsageCounters() (r map[string]float64, exists bool) {
+	v := m.daily_usage_counters
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDailyUsageCounters returns the old "daily_usage_counters" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldDailyUsageCounters(ctx context.Context) (v map[string]float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDailyUsageCounters is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDailyUsageCounters requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDailyUsageCounters: %w", err)
+	}
+	return oldValue.DailyUsageCounters, nil
+}
+
+// ClearDailyUsageCounters clears the value of the "daily_usage_counters" field.
+func (m *UserSubscriptionMutation) ClearDailyUsageCounters() {
+	m.daily_usage_counters = nil
+	m.clearedFields[usersubscription.FieldDailyUsageCounters] = struct{}{}
+}
+
+// DailyUsageCountersCleared returns if the "daily_usage_counters" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) DailyUsageCountersCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldDailyUsageCounters]
+	return ok
+}
+
+// ResetDailyUsageCounters resets all changes to the "daily_usage_counters" field.
+func (m *UserSubscriptionMutation) ResetDailyUsageCounters() {
+	m.daily_usage_counters = nil
+	delete(m.clearedFields, usersubscription.FieldDailyUsageCounters)
+}
+
+// SetWeeklyUsageCounters sets the "weekly_usage_counters" field.
+func (m *UserSubscriptionMutation) SetWeeklyUsageCounters(value map[string]float64) {
+	m.weekly_usage_counters = &value
+}
+
+// WeeklyUsageCounters returns the value of the "weekly_usage_counters" field in the mutation.
+func (m *UserSubscriptionMutation) WeeklyUsageCounters() (r map[string]float64, exists bool) {
+	v := m.weekly_usage_counters
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldWeeklyUsageCounters returns the old "weekly_usage_counters" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldWeeklyUsageCounters(ctx context.Context) (v map[string]float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldWeeklyUsageCounters is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldWeeklyUsageCounters requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldWeeklyUsageCounters: %w", err)
+	}
+	return oldValue.WeeklyUsageCounters, nil
+}
+
+// ClearWeeklyUsageCounters clears the value of the "weekly_usage_counters" field.
+func (m *UserSubscriptionMutation) ClearWeeklyUsageCounters() {
+	m.weekly_usage_counters = nil
+	m.clearedFields[usersubscription.FieldWeeklyUsageCounters] = struct{}{}
+}
+
+// WeeklyUsageCountersCleared returns if the "weekly_usage_counters" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) WeeklyUsageCountersCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldWeeklyUsageCounters]
+	return ok
+}
+
+// ResetWeeklyUsageCounters resets all changes to the "weekly_usage_counters" field.
+func (m *UserSubscriptionMutation) ResetWeeklyUsageCounters() {
+	m.weekly_usage_counters = nil
+	delete(m.clearedFields, usersubscription.FieldWeeklyUsageCounters)
+}
+
+// SetMonthlyUsageCounters sets the "monthly_usage_counters" field.
+func (m *UserSubscriptionMutation) SetMonthlyUsageCounters(value map[string]float64) {
+	m.monthly_usage_counters = &value
+}
+
+// MonthlyUsageCounters returns the value of the "monthly_usage_counters" field in the mutation.
+func (m *UserSubscriptionMutation) MonthlyUsageCounters() (r map[string]float64, exists bool) {
+	v := m.monthly_usage_counters
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldMonthlyUsageCounters returns the old "monthly_usage_counters" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldMonthlyUsageCounters(ctx context.Context) (v map[string]float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldMonthlyUsageCounters is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldMonthlyUsageCounters requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldMonthlyUsageCounters: %w", err)
+	}
+	return oldValue.MonthlyUsageCounters, nil
+}
+
+// ClearMonthlyUsageCounters clears the value of the "monthly_usage_counters" field.
+func (m *UserSubscriptionMutation) ClearMonthlyUsageCounters() {
+	m.monthly_usage_counters = nil
+	m.clearedFields[usersubscription.FieldMonthlyUsageCounters] = struct{}{}
+}
+
+// MonthlyUsageCountersCleared returns if the "monthly_usage_counters" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) MonthlyUsageCountersCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldMonthlyUsageCounters]
+	return ok
+}
+
+// ResetMonthlyUsageCounters resets all changes to the "monthly_usage_counters" field.
+func (m *UserSubscriptionMutation) ResetMonthlyUsageCounters() {
+	m.monthly_usage_counters = nil
+	delete(m.clearedFields, usersubscription.FieldMonthlyUsageCounters)
+}
+
+// SetAutoRenew sets the "auto_renew" field.
+func (m *UserSubscriptionMutation) SetAutoRenew(b bool) {
+	m.auto_renew = &b
+}
+
+// AutoRenew returns the value of the "auto_renew" field in the mutation.
+func (m *UserSubscriptionMutation) AutoRenew() (r bool, exists bool) {
+	v := m.auto_renew
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAutoRenew returns the old "auto_renew" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldAutoRenew(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAutoRenew is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAutoRenew requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAutoRenew: %w", err)
+	}
+	return oldValue.AutoRenew, nil
+}
+
+// ResetAutoRenew resets all changes to the "auto_renew" field.
+func (m *UserSubscriptionMutation) ResetAutoRenew() {
+	m.auto_renew = nil
+}
+
+// SetPausedAt sets the "paused_at" field.
+func (m *UserSubscriptionMutation) SetPausedAt(t time.Time) {
+	m.paused_at = &t
+}
+
+// PausedAt returns the value of the "paused_at" field in the mutation.
+func (m *UserSubscriptionMutation) PausedAt() (r time.Time, exists bool) {
+	v := m.paused_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldPausedAt returns the old "paused_at" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldPausedAt(ctx context.Context) (v *time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldPausedAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldPausedAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldPausedAt: %w", err)
+	}
+	return oldValue.PausedAt, nil
+}
+
+// ClearPausedAt clears the value of the "paused_at" field.
+func (m *UserSubscriptionMutation) ClearPausedAt() {
+	m.paused_at = nil
+	m.clearedFields[usersubscription.FieldPausedAt] = struct{}{}
+}
+
+// PausedAtCleared returns if the "paused_at" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) PausedAtCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldPausedAt]
+	return ok
+}
+
+// ResetPausedAt resets all changes to the "paused_at" field.
+func (m *UserSubscriptionMutation) ResetPausedAt() {
+	m.paused_at = nil
+	delete(m.clearedFields, usersubscription.FieldPausedAt)
+}
+
 // ClearUser clears the "user" edge to the User entity.
 func (m *UserSubscriptionMutation) ClearUser() {
 	m.cleareduser = true
@@ -19456,7 +20611,7 @@ func (m *UserSubscriptionMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *UserSubscriptionMutation) Fields() []string {
-	fields := make([]string, 0, 17)
+	fields := make([]string, 0, 22)
 	if m.created_at != nil {
 		fields = append(fields, usersubscription.FieldCreatedAt)
 	}
@@ -19508,6 +20663,21 @@ func (m *UserSubscriptionMutation) Fields() []string {
 	if m.notes != nil {
 		fields = append(fields, usersubscription.FieldNotes)
 	}
+	if m.daily_usage_counters != nil {
+		fields = append(fields, usersubscription.FieldDailyUsageCounters)
+	}
+	if m.weekly_usage_counters != nil {
+		fields = append(fields, usersubscription.FieldWeeklyUsageCounters)
+	}
+	if m.monthly_usage_counters != nil {
+		fields = append(fields, usersubscription.FieldMonthlyUsageCounters)
+	}
+	if m.auto_renew != nil {
+		fields = append(fields, usersubscription.FieldAutoRenew)
+	}
+	if m.paused_at != nil {
+		fields = append(fields, usersubscription.FieldPausedAt)
+	}
 	return fields
 }
 
@@ -19550,6 +20720,16 @@ func (m *UserSubscriptionMutation) Field(name string) (ent.Value, bool) {
 		return m.AssignedAt()
 	case usersubscription.FieldNotes:
 		return m.Notes()
+	case usersubscription.FieldDailyUsageCounters:
+		return m.DailyUsageCounters()
+	case usersubscription.FieldWeeklyUsageCounters:
+		return m.WeeklyUsageCounters()
+	case usersubscription.FieldMonthlyUsageCounters:
+		return m.MonthlyUsageCounters()
+	case usersubscription.FieldAutoRenew:
+		return m.AutoRenew()
+	case usersubscription.FieldPausedAt:
+		return m.PausedAt()
 	}
 	return nil, false
 }
@@ -19593,6 +20773,16 @@ func (m *UserSubscriptionMutation) OldField(ctx context.Context, name string) (e
 		return m.OldAssignedAt(ctx)
 	case usersubscription.FieldNotes:
 		return m.OldNotes(ctx)
+	case usersubscription.FieldDailyUsageCounters:
+		return m.OldDailyUsageCounters(ctx)
+	case usersubscription.FieldWeeklyUsageCounters:
+		return m.OldWeeklyUsageCounters(ctx)
+	case usersubscription.FieldMonthlyUsageCounters:
+		return m.OldMonthlyUsageCounters(ctx)
+	case usersubscription.FieldAutoRenew:
+		return m.OldAutoRenew(ctx)
+	case usersubscription.FieldPausedAt:
+		return m.OldPausedAt(ctx)
 	}
 	return nil, fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -19721,6 +20911,41 @@ func (m *UserSubscriptionMutation) SetField(name string, value ent.Value) error
 		}
 		m.SetNotes(v)
 		return nil
+	case usersubscription.FieldDailyUsageCounters:
+		v, ok := value.(map[string]float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetDailyUsageCounters(v)
+		return nil
+	case usersubscription.FieldWeeklyUsageCounters:
+		v, ok := value.(map[string]float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetWeeklyUsageCounters(v)
+		return nil
+	case usersubscription.FieldMonthlyUsageCounters:
+		v, ok := value.(map[string]float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetMonthlyUsageCounters(v)
+		return nil
+	case usersubscription.FieldAutoRenew:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAutoRenew(v)
+		return nil
+	case usersubscription.FieldPausedAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPausedAt(v)
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -19808,6 +21033,18 @@ func (m *UserSubscriptionMutation) ClearedFields() []string {
 	if m.FieldCleared(usersubscription.FieldNotes) {
 		fields = append(fields, usersubscription.FieldNotes)
 	}
+	if m.FieldCleared(usersubscription.FieldDailyUsageCounters) {
+		fields = append(fields, usersubscription.FieldDailyUsageCounters)
+	}
+	if m.FieldCleared(usersubscription.FieldWeeklyUsageCounters) {
+		fields = append(fields, usersubscription.FieldWeeklyUsageCounters)
+	}
+	if m.FieldCleared(usersubscription.FieldMonthlyUsageCounters) {
+		fields = append(fields, usersubscription.FieldMonthlyUsageCounters)
+	}
+	if m.FieldCleared(usersubscription.FieldPausedAt) {
+		fields = append(fields, usersubscription.FieldPausedAt)
+	}
 	return fields
 }
 
@@ -19840,6 +21077,18 @@ func (m *UserSubscriptionMutation) ClearField(name string) error {
 	case usersubscription.FieldNotes:
 		m.ClearNotes()
 		return nil
+	case usersubscription.FieldDailyUsageCounters:
+		m.ClearDailyUsageCounters()
+		return nil
+	case usersubscription.FieldWeeklyUsageCounters:
+		m.ClearWeeklyUsageCounters()
+		return nil
+	case usersubscription.FieldMonthlyUsageCounters:
+		m.ClearMonthlyUsageCounters()
+		return nil
+	case usersubscription.FieldPausedAt:
+		m.ClearPausedAt()
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription nullable field %s", name)
 }
@@ -19899,6 +21148,21 @@ func (m *UserSubscriptionMutation) ResetField(name string) error {
 	case usersubscription.FieldNotes:
 		m.ResetNotes()
 		return nil
+	case usersubscription.FieldDailyUsageCounters:
+		m.ResetDailyUsageCounters()
+		return nil
+	case usersubscription.FieldWeeklyUsageCounters:
+		m.ResetWeeklyUsageCounters()
+		return nil
+	case usersubscription.FieldMonthlyUsageCounters:
+		m.ResetMonthlyUsageCounters()
+		return nil
+	case usersubscription.FieldAutoRenew:
+		m.ResetAutoRenew()
+		return nil
+	case usersubscription.FieldPausedAt:
+		m.ResetPausedAt()
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"encoding/json"
+
 	"github.com/Wei-Shaw/sub2api/ent/schema/mixins"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
@@ -72,6 +74,11 @@ func (Group) Fields() []ent.Field {
 			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}),
 		field.Int("default_validity_days").
 			Default(30),
+		// 订阅续期价格（每个 default_validity_days 周期，从余额扣除；为空表示不支持自助续期/变更）
+		field.Float("subscription_price").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}),
 
 		// 图片生成计费配置（antigravity 和 gemini 平台使用）
 		field.Float("image_price_1k").
@@ -106,6 +113,50 @@ func (Group) Fields() []ent.Field {
 		field.Bool("model_routing_enabled").
 			Default(false).
 			Comment("是否启用模型路由配置"),
+
+		// 账号选择策略 (added by migration 047)
+		field.String("account_selection_strategy").
+			MaxLen(50).
+			Default("").
+			Comment("账号选择策略：空表示默认（优先级 + 最后使用时间/随机）"),
+
+		// 图片生成模型白名单 (added by migration 050)
+		field.JSON("image_models", []string{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("允许使用的图片生成模型（支持 * 通配符），为空表示不限制"),
+
+		// 内容策略命中动作 (added by migration 051)
+		field.String("content_policy_action").
+			MaxLen(20).
+			Default("").
+			Comment("内容策略命中动作：空表示不检查，block/log/strip"),
+
+		// 请求改写规则 (added by migration 052)
+		field.JSON("request_rewrite_rules", json.RawMessage{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("请求改写规则列表（按顺序应用于 Claude/Responses/Gemini 请求）"),
+
+		// 模型别名与访问策略 (added by migration 053)
+		field.JSON("model_aliases", map[string]string{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("模型别名：别名 -> 实际模型"),
+		field.JSON("allowed_models", []string{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("允许使用的模型（支持 * 通配符），为空表示不限制"),
+		field.JSON("denied_models", []string{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("禁止使用的模型（支持 * 通配符），优先于白名单"),
+
+		// 订阅 token/请求数限额 (added by migration 055)
+		field.JSON("usage_limits", json.RawMessage{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("订阅 token/请求数限额列表（按窗口与模型族统计）"),
 	}
 }
 
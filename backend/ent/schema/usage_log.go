@@ -119,6 +119,12 @@ func (UsageLog) Fields() []ent.Field {
 			Optional().
 			Nillable(),
 
+		// 分布式追踪 trace id（W3C，32 位十六进制）
+		field.String("trace_id").
+			MaxLen(32).
+			Optional().
+			Nillable(),
+
 		// 时间戳（只有 created_at，日志不可修改）
 		field.Time("created_at").
 			Default(time.Now).
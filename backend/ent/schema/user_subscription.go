@@ -79,6 +79,26 @@ func (UserSubscription) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			SchemaType(map[string]string{dialect.Postgres: "text"}),
+
+		// token/请求数限额计数：限额名称 -> 当前窗口累计值，随对应窗口一起重置
+		field.JSON("daily_usage_counters", map[string]float64{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
+		field.JSON("weekly_usage_counters", map[string]float64{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
+		field.JSON("monthly_usage_counters", map[string]float64{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
+
+		// 自动续期：到期时按分组 subscription_price 从用户余额扣费并延长一个周期
+		field.Bool("auto_renew").
+			Default(false),
+		// 暂停时间：暂停期间订阅不可用，恢复时按暂停时长顺延到期时间
+		field.Time("paused_at").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "timestamptz"}),
 	}
 }
 
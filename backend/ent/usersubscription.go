@@ -3,6 +3,7 @@
 package ent
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -53,6 +54,16 @@ type UserSubscription struct {
 	AssignedAt time.Time `json:"assigned_at,omitempty"`
 	// Notes holds the value of the "notes" field.
 	Notes *string `json:"notes,omitempty"`
+	// DailyUsageCounters holds the value of the "daily_usage_counters" field.
+	DailyUsageCounters map[string]float64 `json:"daily_usage_counters,omitempty"`
+	// WeeklyUsageCounters holds the value of the "weekly_usage_counters" field.
+	WeeklyUsageCounters map[string]float64 `json:"weekly_usage_counters,omitempty"`
+	// MonthlyUsageCounters holds the value of the "monthly_usage_counters" field.
+	MonthlyUsageCounters map[string]float64 `json:"monthly_usage_counters,omitempty"`
+	// AutoRenew holds the value of the "auto_renew" field.
+	AutoRenew bool `json:"auto_renew,omitempty"`
+	// PausedAt holds the value of the "paused_at" field.
+	PausedAt *time.Time `json:"paused_at,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the UserSubscriptionQuery when eager-loading is set.
 	Edges        UserSubscriptionEdges `json:"edges"`
@@ -121,13 +132,17 @@ func (*UserSubscription) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
+		case usersubscription.FieldDailyUsageCounters, usersubscription.FieldWeeklyUsageCounters, usersubscription.FieldMonthlyUsageCounters:
+			values[i] = new([]byte)
+		case usersubscription.FieldAutoRenew:
+			values[i] = new(sql.NullBool)
 		case usersubscription.FieldDailyUsageUsd, usersubscription.FieldWeeklyUsageUsd, usersubscription.FieldMonthlyUsageUsd:
 			values[i] = new(sql.NullFloat64)
 		case usersubscription.FieldID, usersubscription.FieldUserID, usersubscription.FieldGroupID, usersubscription.FieldAssignedBy:
 			values[i] = new(sql.NullInt64)
 		case usersubscription.FieldStatus, usersubscription.FieldNotes:
 			values[i] = new(sql.NullString)
-		case usersubscription.FieldCreatedAt, usersubscription.FieldUpdatedAt, usersubscription.FieldDeletedAt, usersubscription.FieldStartsAt, usersubscription.FieldExpiresAt, usersubscription.FieldDailyWindowStart, usersubscription.FieldWeeklyWindowStart, usersubscription.FieldMonthlyWindowStart, usersubscription.FieldAssignedAt:
+		case usersubscription.FieldCreatedAt, usersubscription.FieldUpdatedAt, usersubscription.FieldDeletedAt, usersubscription.FieldStartsAt, usersubscription.FieldExpiresAt, usersubscription.FieldDailyWindowStart, usersubscription.FieldWeeklyWindowStart, usersubscription.FieldMonthlyWindowStart, usersubscription.FieldAssignedAt, usersubscription.FieldPausedAt:
 			values[i] = new(sql.NullTime)
 		default:
 			values[i] = new(sql.UnknownType)
@@ -258,6 +273,43 @@ func (_m *UserSubscription) assignValues(columns []string, values []any) error {
 				_m.Notes = new(string)
 				*_m.Notes = value.String
 			}
+		case usersubscription.FieldDailyUsageCounters:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field daily_usage_counters", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.DailyUsageCounters); err != nil {
+					return fmt.Errorf("unmarshal field daily_usage_counters: %w", err)
+				}
+			}
+		case usersubscription.FieldWeeklyUsageCounters:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field weekly_usage_counters", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.WeeklyUsageCounters); err != nil {
+					return fmt.Errorf("unmarshal field weekly_usage_counters: %w", err)
+				}
+			}
+		case usersubscription.FieldMonthlyUsageCounters:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field monthly_usage_counters", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.MonthlyUsageCounters); err != nil {
+					return fmt.Errorf("unmarshal field monthly_usage_counters: %w", err)
+				}
+			}
+		case usersubscription.FieldAutoRenew:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field auto_renew", values[i])
+			} else if value.Valid {
+				_m.AutoRenew = value.Bool
+			}
+		case usersubscription.FieldPausedAt:
+			if value, ok := values[i].(*sql.NullTime); !ok {
+				return fmt.Errorf("unexpected type %T for field paused_at", values[i])
+			} else if value.Valid {
+				_m.PausedAt = new(time.Time)
+				*_m.PausedAt = value.Time
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -376,6 +428,23 @@ func (_m *UserSubscription) String() string {
 		builder.WriteString("notes=")
 		builder.WriteString(*v)
 	}
+	builder.WriteString(", ")
+	builder.WriteString("daily_usage_counters=")
+	builder.WriteString(fmt.Sprintf("%v", _m.DailyUsageCounters))
+	builder.WriteString(", ")
+	builder.WriteString("weekly_usage_counters=")
+	builder.WriteString(fmt.Sprintf("%v", _m.WeeklyUsageCounters))
+	builder.WriteString(", ")
+	builder.WriteString("monthly_usage_counters=")
+	builder.WriteString(fmt.Sprintf("%v", _m.MonthlyUsageCounters))
+	builder.WriteString(", ")
+	builder.WriteString("auto_renew=")
+	builder.WriteString(fmt.Sprintf("%v", _m.AutoRenew))
+	builder.WriteString(", ")
+	if v := _m.PausedAt; v != nil {
+		builder.WriteString("paused_at=")
+		builder.WriteString(v.Format(time.ANSIC))
+	}
 	builder.WriteByte(')')
 	return builder.String()
 }
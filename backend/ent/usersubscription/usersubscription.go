@@ -49,6 +49,16 @@ const (
 	FieldAssignedAt = "assigned_at"
 	// FieldNotes holds the string denoting the notes field in the database.
 	FieldNotes = "notes"
+	// FieldDailyUsageCounters holds the string denoting the daily_usage_counters field in the database.
+	FieldDailyUsageCounters = "daily_usage_counters"
+	// FieldWeeklyUsageCounters holds the string denoting the weekly_usage_counters field in the database.
+	FieldWeeklyUsageCounters = "weekly_usage_counters"
+	// FieldMonthlyUsageCounters holds the string denoting the monthly_usage_counters field in the database.
+	FieldMonthlyUsageCounters = "monthly_usage_counters"
+	// FieldAutoRenew holds the string denoting the auto_renew field in the database.
+	FieldAutoRenew = "auto_renew"
+	// FieldPausedAt holds the string denoting the paused_at field in the database.
+	FieldPausedAt = "paused_at"
 	// EdgeUser holds the string denoting the user edge name in mutations.
 	EdgeUser = "user"
 	// EdgeGroup holds the string denoting the group edge name in mutations.
@@ -109,6 +119,11 @@ var Columns = []string{
 	FieldAssignedBy,
 	FieldAssignedAt,
 	FieldNotes,
+	FieldDailyUsageCounters,
+	FieldWeeklyUsageCounters,
+	FieldMonthlyUsageCounters,
+	FieldAutoRenew,
+	FieldPausedAt,
 }
 
 // ValidColumn reports if the column name is valid (part of the table columns).
@@ -147,6 +162,8 @@ var (
 	DefaultMonthlyUsageUsd float64
 	// DefaultAssignedAt holds the default value on creation for the "assigned_at" field.
 	DefaultAssignedAt func() time.Time
+	// DefaultAutoRenew holds the default value on creation for the "auto_renew" field.
+	DefaultAutoRenew bool
 )
 
 // OrderOption defines the ordering options for the UserSubscription queries.
@@ -242,6 +259,16 @@ func ByNotes(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldNotes, opts...).ToFunc()
 }
 
+// ByAutoRenew orders the results by the auto_renew field.
+func ByAutoRenew(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldAutoRenew, opts...).ToFunc()
+}
+
+// ByPausedAt orders the results by the paused_at field.
+func ByPausedAt(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldPausedAt, opts...).ToFunc()
+}
+
 // ByUserField orders the results by user field.
 func ByUserField(field string, opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
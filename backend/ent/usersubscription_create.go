@@ -231,6 +231,52 @@ func (_c *UserSubscriptionCreate) SetNillableNotes(v *string) *UserSubscriptionC
 	return _c
 }
 
+// SetDailyUsageCounters sets the "daily_usage_counters" field.
+func (_c *UserSubscriptionCreate) SetDailyUsageCounters(v map[string]float64) *UserSubscriptionCreate {
+	_c.mutation.SetDailyUsageCounters(v)
+	return _c
+}
+
+// SetWeeklyUsageCounters sets the "weekly_usage_counters" field.
+func (_c *UserSubscriptionCreate) SetWeeklyUsageCounters(v map[string]float64) *UserSubscriptionCreate {
+	_c.mutation.SetWeeklyUsageCounters(v)
+	return _c
+}
+
+// SetMonthlyUsageCounters sets the "monthly_usage_counters" field.
+func (_c *UserSubscriptionCreate) SetMonthlyUsageCounters(v map[string]float64) *UserSubscriptionCreate {
+	_c.mutation.SetMonthlyUsageCounters(v)
+	return _c
+}
+
+// SetAutoRenew sets the "auto_renew" field.
+func (_c *UserSubscriptionCreate) SetAutoRenew(v bool) *UserSubscriptionCreate {
+	_c.mutation.SetAutoRenew(v)
+	return _c
+}
+
+// SetNillableAutoRenew sets the "auto_renew" field if the given value is not nil.
+func (_c *UserSubscriptionCreate) SetNillableAutoRenew(v *bool) *UserSubscriptionCreate {
+	if v != nil {
+		_c.SetAutoRenew(*v)
+	}
+	return _c
+}
+
+// SetPausedAt sets the "paused_at" field.
+func (_c *UserSubscriptionCreate) SetPausedAt(v time.Time) *UserSubscriptionCreate {
+	_c.mutation.SetPausedAt(v)
+	return _c
+}
+
+// SetNillablePausedAt sets the "paused_at" field if the given value is not nil.
+func (_c *UserSubscriptionCreate) SetNillablePausedAt(v *time.Time) *UserSubscriptionCreate {
+	if v != nil {
+		_c.SetPausedAt(*v)
+	}
+	return _c
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_c *UserSubscriptionCreate) SetUser(v *User) *UserSubscriptionCreate {
 	return _c.SetUserID(v.ID)
@@ -349,6 +395,10 @@ func (_c *UserSubscriptionCreate) defaults() error {
 		v := usersubscription.DefaultAssignedAt()
 		_c.mutation.SetAssignedAt(v)
 	}
+	if _, ok := _c.mutation.AutoRenew(); !ok {
+		v := usersubscription.DefaultAutoRenew
+		_c.mutation.SetAutoRenew(v)
+	}
 	return nil
 }
 
@@ -392,6 +442,9 @@ func (_c *UserSubscriptionCreate) check() error {
 	if _, ok := _c.mutation.AssignedAt(); !ok {
 		return &ValidationError{Name: "assigned_at", err: errors.New(`ent: missing required field "UserSubscription.assigned_at"`)}
 	}
+	if _, ok := _c.mutation.AutoRenew(); !ok {
+		return &ValidationError{Name: "auto_renew", err: errors.New(`ent: missing required field "UserSubscription.auto_renew"`)}
+	}
 	if len(_c.mutation.UserIDs()) == 0 {
 		return &ValidationError{Name: "user", err: errors.New(`ent: missing required edge "UserSubscription.user"`)}
 	}
@@ -481,6 +534,26 @@ func (_c *UserSubscriptionCreate) createSpec() (*UserSubscription, *sqlgraph.Cre
 		_spec.SetField(usersubscription.FieldNotes, field.TypeString, value)
 		_node.Notes = &value
 	}
+	if value, ok := _c.mutation.DailyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldDailyUsageCounters, field.TypeJSON, value)
+		_node.DailyUsageCounters = value
+	}
+	if value, ok := _c.mutation.WeeklyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldWeeklyUsageCounters, field.TypeJSON, value)
+		_node.WeeklyUsageCounters = value
+	}
+	if value, ok := _c.mutation.MonthlyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldMonthlyUsageCounters, field.TypeJSON, value)
+		_node.MonthlyUsageCounters = value
+	}
+	if value, ok := _c.mutation.AutoRenew(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenew, field.TypeBool, value)
+		_node.AutoRenew = value
+	}
+	if value, ok := _c.mutation.PausedAt(); ok {
+		_spec.SetField(usersubscription.FieldPausedAt, field.TypeTime, value)
+		_node.PausedAt = &value
+	}
 	if nodes := _c.mutation.UserIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -846,6 +919,90 @@ func (u *UserSubscriptionUpsert) ClearNotes() *UserSubscriptionUpsert {
 	return u
 }
 
+// SetDailyUsageCounters sets the "daily_usage_counters" field.
+func (u *UserSubscriptionUpsert) SetDailyUsageCounters(v map[string]float64) *UserSubscriptionUpsert {
+	u.Set(usersubscription.FieldDailyUsageCounters, v)
+	return u
+}
+
+// UpdateDailyUsageCounters sets the "daily_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsert) UpdateDailyUsageCounters() *UserSubscriptionUpsert {
+	u.SetExcluded(usersubscription.FieldDailyUsageCounters)
+	return u
+}
+
+// ClearDailyUsageCounters clears the value of the "daily_usage_counters" field.
+func (u *UserSubscriptionUpsert) ClearDailyUsageCounters() *UserSubscriptionUpsert {
+	u.SetNull(usersubscription.FieldDailyUsageCounters)
+	return u
+}
+
+// SetWeeklyUsageCounters sets the "weekly_usage_counters" field.
+func (u *UserSubscriptionUpsert) SetWeeklyUsageCounters(v map[string]float64) *UserSubscriptionUpsert {
+	u.Set(usersubscription.FieldWeeklyUsageCounters, v)
+	return u
+}
+
+// UpdateWeeklyUsageCounters sets the "weekly_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsert) UpdateWeeklyUsageCounters() *UserSubscriptionUpsert {
+	u.SetExcluded(usersubscription.FieldWeeklyUsageCounters)
+	return u
+}
+
+// ClearWeeklyUsageCounters clears the value of the "weekly_usage_counters" field.
+func (u *UserSubscriptionUpsert) ClearWeeklyUsageCounters() *UserSubscriptionUpsert {
+	u.SetNull(usersubscription.FieldWeeklyUsageCounters)
+	return u
+}
+
+// SetMonthlyUsageCounters sets the "monthly_usage_counters" field.
+func (u *UserSubscriptionUpsert) SetMonthlyUsageCounters(v map[string]float64) *UserSubscriptionUpsert {
+	u.Set(usersubscription.FieldMonthlyUsageCounters, v)
+	return u
+}
+
+// UpdateMonthlyUsageCounters sets the "monthly_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsert) UpdateMonthlyUsageCounters() *UserSubscriptionUpsert {
+	u.SetExcluded(usersubscription.FieldMonthlyUsageCounters)
+	return u
+}
+
+// ClearMonthlyUsageCounters clears the value of the "monthly_usage_counters" field.
+func (u *UserSubscriptionUpsert) ClearMonthlyUsageCounters() *UserSubscriptionUpsert {
+	u.SetNull(usersubscription.FieldMonthlyUsageCounters)
+	return u
+}
+
+// SetAutoRenew sets the "auto_renew" field.
+func (u *UserSubscriptionUpsert) SetAutoRenew(v bool) *UserSubscriptionUpsert {
+	u.Set(usersubscription.FieldAutoRenew, v)
+	return u
+}
+
+// UpdateAutoRenew sets the "auto_renew" field to the value that was provided on create.
+func (u *UserSubscriptionUpsert) UpdateAutoRenew() *UserSubscriptionUpsert {
+	u.SetExcluded(usersubscription.FieldAutoRenew)
+	return u
+}
+
+// SetPausedAt sets the "paused_at" field.
+func (u *UserSubscriptionUpsert) SetPausedAt(v time.Time) *UserSubscriptionUpsert {
+	u.Set(usersubscription.FieldPausedAt, v)
+	return u
+}
+
+// UpdatePausedAt sets the "paused_at" field to the value that was provided on create.
+func (u *UserSubscriptionUpsert) UpdatePausedAt() *UserSubscriptionUpsert {
+	u.SetExcluded(usersubscription.FieldPausedAt)
+	return u
+}
+
+// ClearPausedAt clears the value of the "paused_at" field.
+func (u *UserSubscriptionUpsert) ClearPausedAt() *UserSubscriptionUpsert {
+	u.SetNull(usersubscription.FieldPausedAt)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1178,6 +1335,104 @@ func (u *UserSubscriptionUpsertOne) ClearNotes() *UserSubscriptionUpsertOne {
 	})
 }
 
+// SetDailyUsageCounters sets the "daily_usage_counters" field.
+func (u *UserSubscriptionUpsertOne) SetDailyUsageCounters(v map[string]float64) *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetDailyUsageCounters(v)
+	})
+}
+
+// UpdateDailyUsageCounters sets the "daily_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertOne) UpdateDailyUsageCounters() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateDailyUsageCounters()
+	})
+}
+
+// ClearDailyUsageCounters clears the value of the "daily_usage_counters" field.
+func (u *UserSubscriptionUpsertOne) ClearDailyUsageCounters() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearDailyUsageCounters()
+	})
+}
+
+// SetWeeklyUsageCounters sets the "weekly_usage_counters" field.
+func (u *UserSubscriptionUpsertOne) SetWeeklyUsageCounters(v map[string]float64) *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetWeeklyUsageCounters(v)
+	})
+}
+
+// UpdateWeeklyUsageCounters sets the "weekly_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertOne) UpdateWeeklyUsageCounters() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateWeeklyUsageCounters()
+	})
+}
+
+// ClearWeeklyUsageCounters clears the value of the "weekly_usage_counters" field.
+func (u *UserSubscriptionUpsertOne) ClearWeeklyUsageCounters() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearWeeklyUsageCounters()
+	})
+}
+
+// SetMonthlyUsageCounters sets the "monthly_usage_counters" field.
+func (u *UserSubscriptionUpsertOne) SetMonthlyUsageCounters(v map[string]float64) *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetMonthlyUsageCounters(v)
+	})
+}
+
+// UpdateMonthlyUsageCounters sets the "monthly_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertOne) UpdateMonthlyUsageCounters() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateMonthlyUsageCounters()
+	})
+}
+
+// ClearMonthlyUsageCounters clears the value of the "monthly_usage_counters" field.
+func (u *UserSubscriptionUpsertOne) ClearMonthlyUsageCounters() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearMonthlyUsageCounters()
+	})
+}
+
+// SetAutoRenew sets the "auto_renew" field.
+func (u *UserSubscriptionUpsertOne) SetAutoRenew(v bool) *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetAutoRenew(v)
+	})
+}
+
+// UpdateAutoRenew sets the "auto_renew" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertOne) UpdateAutoRenew() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateAutoRenew()
+	})
+}
+
+// SetPausedAt sets the "paused_at" field.
+func (u *UserSubscriptionUpsertOne) SetPausedAt(v time.Time) *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetPausedAt(v)
+	})
+}
+
+// UpdatePausedAt sets the "paused_at" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertOne) UpdatePausedAt() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdatePausedAt()
+	})
+}
+
+// ClearPausedAt clears the value of the "paused_at" field.
+func (u *UserSubscriptionUpsertOne) ClearPausedAt() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearPausedAt()
+	})
+}
+
 // Exec executes the query.
 func (u *UserSubscriptionUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1676,6 +1931,104 @@ func (u *UserSubscriptionUpsertBulk) ClearNotes() *UserSubscriptionUpsertBulk {
 	})
 }
 
+// SetDailyUsageCounters sets the "daily_usage_counters" field.
+func (u *UserSubscriptionUpsertBulk) SetDailyUsageCounters(v map[string]float64) *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetDailyUsageCounters(v)
+	})
+}
+
+// UpdateDailyUsageCounters sets the "daily_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertBulk) UpdateDailyUsageCounters() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateDailyUsageCounters()
+	})
+}
+
+// ClearDailyUsageCounters clears the value of the "daily_usage_counters" field.
+func (u *UserSubscriptionUpsertBulk) ClearDailyUsageCounters() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearDailyUsageCounters()
+	})
+}
+
+// SetWeeklyUsageCounters sets the "weekly_usage_counters" field.
+func (u *UserSubscriptionUpsertBulk) SetWeeklyUsageCounters(v map[string]float64) *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetWeeklyUsageCounters(v)
+	})
+}
+
+// UpdateWeeklyUsageCounters sets the "weekly_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertBulk) UpdateWeeklyUsageCounters() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateWeeklyUsageCounters()
+	})
+}
+
+// ClearWeeklyUsageCounters clears the value of the "weekly_usage_counters" field.
+func (u *UserSubscriptionUpsertBulk) ClearWeeklyUsageCounters() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearWeeklyUsageCounters()
+	})
+}
+
+// SetMonthlyUsageCounters sets the "monthly_usage_counters" field.
+func (u *UserSubscriptionUpsertBulk) SetMonthlyUsageCounters(v map[string]float64) *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetMonthlyUsageCounters(v)
+	})
+}
+
+// UpdateMonthlyUsageCounters sets the "monthly_usage_counters" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertBulk) UpdateMonthlyUsageCounters() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateMonthlyUsageCounters()
+	})
+}
+
+// ClearMonthlyUsageCounters clears the value of the "monthly_usage_counters" field.
+func (u *UserSubscriptionUpsertBulk) ClearMonthlyUsageCounters() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearMonthlyUsageCounters()
+	})
+}
+
+// SetAutoRenew sets the "auto_renew" field.
+func (u *UserSubscriptionUpsertBulk) SetAutoRenew(v bool) *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetAutoRenew(v)
+	})
+}
+
+// UpdateAutoRenew sets the "auto_renew" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertBulk) UpdateAutoRenew() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateAutoRenew()
+	})
+}
+
+// SetPausedAt sets the "paused_at" field.
+func (u *UserSubscriptionUpsertBulk) SetPausedAt(v time.Time) *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetPausedAt(v)
+	})
+}
+
+// UpdatePausedAt sets the "paused_at" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertBulk) UpdatePausedAt() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdatePausedAt()
+	})
+}
+
+// ClearPausedAt clears the value of the "paused_at" field.
+func (u *UserSubscriptionUpsertBulk) ClearPausedAt() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearPausedAt()
+	})
+}
+
 // Exec executes the query.
 func (u *UserSubscriptionUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -304,6 +304,76 @@ func (_u *UserSubscriptionUpdate) ClearNotes() *UserSubscriptionUpdate {
 	return _u
 }
 
+// SetDailyUsageCounters sets the "daily_usage_counters" field.
+func (_u *UserSubscriptionUpdate) SetDailyUsageCounters(v map[string]float64) *UserSubscriptionUpdate {
+	_u.mutation.SetDailyUsageCounters(v)
+	return _u
+}
+
+// ClearDailyUsageCounters clears the value of the "daily_usage_counters" field.
+func (_u *UserSubscriptionUpdate) ClearDailyUsageCounters() *UserSubscriptionUpdate {
+	_u.mutation.ClearDailyUsageCounters()
+	return _u
+}
+
+// SetWeeklyUsageCounters sets the "weekly_usage_counters" field.
+func (_u *UserSubscriptionUpdate) SetWeeklyUsageCounters(v map[string]float64) *UserSubscriptionUpdate {
+	_u.mutation.SetWeeklyUsageCounters(v)
+	return _u
+}
+
+// ClearWeeklyUsageCounters clears the value of the "weekly_usage_counters" field.
+func (_u *UserSubscriptionUpdate) ClearWeeklyUsageCounters() *UserSubscriptionUpdate {
+	_u.mutation.ClearWeeklyUsageCounters()
+	return _u
+}
+
+// SetMonthlyUsageCounters sets the "monthly_usage_counters" field.
+func (_u *UserSubscriptionUpdate) SetMonthlyUsageCounters(v map[string]float64) *UserSubscriptionUpdate {
+	_u.mutation.SetMonthlyUsageCounters(v)
+	return _u
+}
+
+// ClearMonthlyUsageCounters clears the value of the "monthly_usage_counters" field.
+func (_u *UserSubscriptionUpdate) ClearMonthlyUsageCounters() *UserSubscriptionUpdate {
+	_u.mutation.ClearMonthlyUsageCounters()
+	return _u
+}
+
+// SetAutoRenew sets the "auto_renew" field.
+func (_u *UserSubscriptionUpdate) SetAutoRenew(v bool) *UserSubscriptionUpdate {
+	_u.mutation.SetAutoRenew(v)
+	return _u
+}
+
+// SetNillableAutoRenew sets the "auto_renew" field if the given value is not nil.
+func (_u *UserSubscriptionUpdate) SetNillableAutoRenew(v *bool) *UserSubscriptionUpdate {
+	if v != nil {
+		_u.SetAutoRenew(*v)
+	}
+	return _u
+}
+
+// SetPausedAt sets the "paused_at" field.
+func (_u *UserSubscriptionUpdate) SetPausedAt(v time.Time) *UserSubscriptionUpdate {
+	_u.mutation.SetPausedAt(v)
+	return _u
+}
+
+// SetNillablePausedAt sets the "paused_at" field if the given value is not nil.
+func (_u *UserSubscriptionUpdate) SetNillablePausedAt(v *time.Time) *UserSubscriptionUpdate {
+	if v != nil {
+		_u.SetPausedAt(*v)
+	}
+	return _u
+}
+
+// ClearPausedAt clears the value of the "paused_at" field.
+func (_u *UserSubscriptionUpdate) ClearPausedAt() *UserSubscriptionUpdate {
+	_u.mutation.ClearPausedAt()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *UserSubscriptionUpdate) SetUser(v *User) *UserSubscriptionUpdate {
 	return _u.SetUserID(v.ID)
@@ -525,6 +595,33 @@ func (_u *UserSubscriptionUpdate) sqlSave(ctx context.Context) (_node int, err e
 	if _u.mutation.NotesCleared() {
 		_spec.ClearField(usersubscription.FieldNotes, field.TypeString)
 	}
+	if value, ok := _u.mutation.DailyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldDailyUsageCounters, field.TypeJSON, value)
+	}
+	if _u.mutation.DailyUsageCountersCleared() {
+		_spec.ClearField(usersubscription.FieldDailyUsageCounters, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.WeeklyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldWeeklyUsageCounters, field.TypeJSON, value)
+	}
+	if _u.mutation.WeeklyUsageCountersCleared() {
+		_spec.ClearField(usersubscription.FieldWeeklyUsageCounters, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.MonthlyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldMonthlyUsageCounters, field.TypeJSON, value)
+	}
+	if _u.mutation.MonthlyUsageCountersCleared() {
+		_spec.ClearField(usersubscription.FieldMonthlyUsageCounters, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.AutoRenew(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenew, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.PausedAt(); ok {
+		_spec.SetField(usersubscription.FieldPausedAt, field.TypeTime, value)
+	}
+	if _u.mutation.PausedAtCleared() {
+		_spec.ClearField(usersubscription.FieldPausedAt, field.TypeTime)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -950,6 +1047,76 @@ func (_u *UserSubscriptionUpdateOne) ClearNotes() *UserSubscriptionUpdateOne {
 	return _u
 }
 
+// SetDailyUsageCounters sets the "daily_usage_counters" field.
+func (_u *UserSubscriptionUpdateOne) SetDailyUsageCounters(v map[string]float64) *UserSubscriptionUpdateOne {
+	_u.mutation.SetDailyUsageCounters(v)
+	return _u
+}
+
+// ClearDailyUsageCounters clears the value of the "daily_usage_counters" field.
+func (_u *UserSubscriptionUpdateOne) ClearDailyUsageCounters() *UserSubscriptionUpdateOne {
+	_u.mutation.ClearDailyUsageCounters()
+	return _u
+}
+
+// SetWeeklyUsageCounters sets the "weekly_usage_counters" field.
+func (_u *UserSubscriptionUpdateOne) SetWeeklyUsageCounters(v map[string]float64) *UserSubscriptionUpdateOne {
+	_u.mutation.SetWeeklyUsageCounters(v)
+	return _u
+}
+
+// ClearWeeklyUsageCounters clears the value of the "weekly_usage_counters" field.
+func (_u *UserSubscriptionUpdateOne) ClearWeeklyUsageCounters() *UserSubscriptionUpdateOne {
+	_u.mutation.ClearWeeklyUsageCounters()
+	return _u
+}
+
+// SetMonthlyUsageCounters sets the "monthly_usage_counters" field.
+func (_u *UserSubscriptionUpdateOne) SetMonthlyUsageCounters(v map[string]float64) *UserSubscriptionUpdateOne {
+	_u.mutation.SetMonthlyUsageCounters(v)
+	return _u
+}
+
+// ClearMonthlyUsageCounters clears the value of the "monthly_usage_counters" field.
+func (_u *UserSubscriptionUpdateOne) ClearMonthlyUsageCounters() *UserSubscriptionUpdateOne {
+	_u.mutation.ClearMonthlyUsageCounters()
+	return _u
+}
+
+// SetAutoRenew sets the "auto_renew" field.
+func (_u *UserSubscriptionUpdateOne) SetAutoRenew(v bool) *UserSubscriptionUpdateOne {
+	_u.mutation.SetAutoRenew(v)
+	return _u
+}
+
+// SetNillableAutoRenew sets the "auto_renew" field if the given value is not nil.
+func (_u *UserSubscriptionUpdateOne) SetNillableAutoRenew(v *bool) *UserSubscriptionUpdateOne {
+	if v != nil {
+		_u.SetAutoRenew(*v)
+	}
+	return _u
+}
+
+// SetPausedAt sets the "paused_at" field.
+func (_u *UserSubscriptionUpdateOne) SetPausedAt(v time.Time) *UserSubscriptionUpdateOne {
+	_u.mutation.SetPausedAt(v)
+	return _u
+}
+
+// SetNillablePausedAt sets the "paused_at" field if the given value is not nil.
+func (_u *UserSubscriptionUpdateOne) SetNillablePausedAt(v *time.Time) *UserSubscriptionUpdateOne {
+	if v != nil {
+		_u.SetPausedAt(*v)
+	}
+	return _u
+}
+
+// ClearPausedAt clears the value of the "paused_at" field.
+func (_u *UserSubscriptionUpdateOne) ClearPausedAt() *UserSubscriptionUpdateOne {
+	_u.mutation.ClearPausedAt()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *UserSubscriptionUpdateOne) SetUser(v *User) *UserSubscriptionUpdateOne {
 	return _u.SetUserID(v.ID)
@@ -1201,6 +1368,33 @@ func (_u *UserSubscriptionUpdateOne) sqlSave(ctx context.Context) (_node *UserSu
 	if _u.mutation.NotesCleared() {
 		_spec.ClearField(usersubscription.FieldNotes, field.TypeString)
 	}
+	if value, ok := _u.mutation.DailyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldDailyUsageCounters, field.TypeJSON, value)
+	}
+	if _u.mutation.DailyUsageCountersCleared() {
+		_spec.ClearField(usersubscription.FieldDailyUsageCounters, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.WeeklyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldWeeklyUsageCounters, field.TypeJSON, value)
+	}
+	if _u.mutation.WeeklyUsageCountersCleared() {
+		_spec.ClearField(usersubscription.FieldWeeklyUsageCounters, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.MonthlyUsageCounters(); ok {
+		_spec.SetField(usersubscription.FieldMonthlyUsageCounters, field.TypeJSON, value)
+	}
+	if _u.mutation.MonthlyUsageCountersCleared() {
+		_spec.ClearField(usersubscription.FieldMonthlyUsageCounters, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.AutoRenew(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenew, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.PausedAt(); ok {
+		_spec.SetField(usersubscription.FieldPausedAt, field.TypeTime, value)
+	}
+	if _u.mutation.PausedAtCleared() {
+		_spec.ClearField(usersubscription.FieldPausedAt, field.TypeTime)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -48,6 +48,7 @@ type Config struct {
 	Ops          OpsConfig                  `mapstructure:"ops"`
 	JWT          JWTConfig                  `mapstructure:"jwt"`
 	Totp         TotpConfig                 `mapstructure:"totp"`
+	WebAuthn     WebAuthnConfig             `mapstructure:"webauthn"`
 	LinuxDo      LinuxDoConnectConfig       `mapstructure:"linuxdo_connect"`
 	Default      DefaultConfig              `mapstructure:"default"`
 	RateLimit    RateLimitConfig            `mapstructure:"rate_limit"`
@@ -59,10 +60,19 @@ type Config struct {
 	UsageCleanup UsageCleanupConfig         `mapstructure:"usage_cleanup"`
 	Concurrency  ConcurrencyConfig          `mapstructure:"concurrency"`
 	TokenRefresh TokenRefreshConfig         `mapstructure:"token_refresh"`
-	RunMode      string                     `mapstructure:"run_mode" yaml:"run_mode"`
-	Timezone     string                     `mapstructure:"timezone"` // e.g. "Asia/Shanghai", "UTC"
-	Gemini       GeminiConfig               `mapstructure:"gemini"`
-	Update       UpdateConfig               `mapstructure:"update"`
+	ProxyPool    ProxyPoolConfig            `mapstructure:"proxy_pool"`
+	// ContentPolicy 内容策略：外部审核服务与违规自动封禁
+	ContentPolicy    ContentPolicyConfig    `mapstructure:"content_policy"`
+	SpendAnomaly     SpendAnomalyConfig     `mapstructure:"spend_anomaly"`     // 消费异常检测与自动处置
+	CapacityForecast CapacityForecastConfig `mapstructure:"capacity_forecast"` // 账号池容量预测
+	Tracing          TracingConfig          `mapstructure:"tracing"`           // OpenTelemetry 分布式追踪
+	Log              LogConfig              `mapstructure:"log"`               // 结构化日志
+	Cluster          ClusterConfig          `mapstructure:"cluster"`           // 多实例集群感知
+	MockUpstream     MockUpstreamConfig     `mapstructure:"mock_upstream"`     // 内置模拟上游与上游录制（离线端到端测试）
+	RunMode          string                 `mapstructure:"run_mode" yaml:"run_mode"`
+	Timezone         string                 `mapstructure:"timezone"` // e.g. "Asia/Shanghai", "UTC"
+	Gemini           GeminiConfig           `mapstructure:"gemini"`
+	Update           UpdateConfig           `mapstructure:"update"`
 }
 
 type GeminiConfig struct {
@@ -128,6 +138,169 @@ type TokenRefreshConfig struct {
 	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"`
 }
 
+// ProxyPoolConfig 代理池健康检查与故障切换配置
+type ProxyPoolConfig struct {
+	// 是否启用代理池成员后台健康检查
+	HealthCheckEnabled bool `mapstructure:"health_check_enabled"`
+	// 健康检查间隔（秒）；探测使用 ip-api.com，间隔过短可能触发其频率限制
+	HealthCheckIntervalSeconds int `mapstructure:"health_check_interval_seconds"`
+	// 连续失败多少次后判定代理失效（探测失败与请求网络错误均计入）
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// 单次请求最多尝试的代理池成员数量（含首选成员）
+	MaxFailoverAttempts int `mapstructure:"max_failover_attempts"`
+}
+
+// ContentPolicyConfig 内容策略配置（规则由管理员在后台维护，命中动作按分组配置）
+type ContentPolicyConfig struct {
+	// 本地审核服务地址（OpenAI moderation 兼容：POST {"input": "..."}，返回 results[].flagged/categories），为空表示不调用
+	ModerationURL string `mapstructure:"moderation_url"`
+	// 审核服务超时（秒）
+	ModerationTimeoutSeconds int `mapstructure:"moderation_timeout_seconds"`
+	// 审核服务不可用时是否放行请求
+	ModerationFailOpen bool `mapstructure:"moderation_fail_open"`
+	// 窗口内违规次数达到该值后自动停用用户，0 表示不自动停用
+	SuspendAfterViolations int `mapstructure:"suspend_after_violations"`
+	// 违规计数窗口（小时）
+	ViolationWindowHours int `mapstructure:"violation_window_hours"`
+	// 规则快照刷新间隔（秒），多实例部署时用于同步其他实例的规则修改
+	RuleRefreshIntervalSeconds int `mapstructure:"rule_refresh_interval_seconds"`
+}
+
+// MockUpstreamConfig 内置模拟上游
+//
+// 开启后，base_url 为 https://mock.invalid（default profile）或 https://<profile>.mock.invalid 的
+// Anthropic / OpenAI / Gemini API Key 账号由网关内置的模拟上游响应，不访问网络，
+// 用于在 CI 中离线验证调度、failover 与计费的完整链路。生产环境请保持关闭。
+type MockUpstreamConfig struct {
+	Enabled  bool                           `mapstructure:"enabled"`
+	Profiles map[string]MockUpstreamProfile `mapstructure:"profiles"`
+	Record   UpstreamRecordConfig           `mapstructure:"record"`
+}
+
+// MockUpstreamProfile 模拟上游的行为
+type MockUpstreamProfile struct {
+	// 首包延迟（毫秒）
+	LatencyMs int `mapstructure:"latency_ms"`
+	// 流式响应相邻 SSE 事件的间隔（毫秒）
+	ChunkIntervalMs int `mapstructure:"chunk_interval_ms"`
+	// 流式响应文本拆分的 delta 数量
+	Chunks int `mapstructure:"chunks"`
+	// 响应文本与用量
+	Text            string `mapstructure:"text"`
+	InputTokens     int    `mapstructure:"input_tokens"`
+	OutputTokens    int    `mapstructure:"output_tokens"`
+	CacheReadTokens int    `mapstructure:"cache_read_tokens"`
+	// 故障注入概率（0-1）：429 / 529（Gemini、OpenAI 为 503）/ 500
+	RateLimitRate   float64 `mapstructure:"rate_limit_rate"`
+	OverloadedRate  float64 `mapstructure:"overloaded_rate"`
+	ServerErrorRate float64 `mapstructure:"server_error_rate"`
+	// 429 响应携带的重置时间（秒）
+	RetryAfterSeconds int `mapstructure:"retry_after_seconds"`
+	// 成功响应也携带限流响应头（anthropic-ratelimit-unified-* / x-codex-*）
+	RateLimitHeaders bool `mapstructure:"rate_limit_headers"`
+	// 回放目录：其中的录制文件按（协议、流式、模型）匹配后优先于生成的响应
+	FixturesDir string `mapstructure:"fixtures_dir"`
+}
+
+// UpstreamRecordConfig 真实上游交互录制，生成的文件可作为 profile 的 fixtures_dir 回放
+type UpstreamRecordConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Dir     string `mapstructure:"dir"`
+	// 单个请求/响应体超过该大小时不录制
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+}
+
+// SpendAnomalyConfig 消费异常检测配置
+//
+// 当前窗口为最近 1 小时，基线为此前 baseline_days 天的小时均值。
+// 各检测项的处置动作：alert（仅进入审查队列）/ disable_key（停用 Key）/ lower_concurrency（降低用户并发）。
+type SpendAnomalyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// 检测间隔（秒）
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// 基线天数
+	BaselineDays int `mapstructure:"baseline_days"`
+	// 当前窗口消费低于该值（美元）的 Key/用户不参与检测；同时作为基线小时均值的下限
+	MinHourlySpendUSD float64 `mapstructure:"min_hourly_spend_usd"`
+	// 当前窗口消费达到基线小时均值的多少倍判定为消费突增
+	SpikeMultiplier float64 `mapstructure:"spike_multiplier"`
+	// 当前窗口出现的陌生 IP 数达到该值时命中，0 表示不检测
+	NewIPThreshold int `mapstructure:"new_ip_threshold"`
+	// 当前窗口出现的陌生客户端（User-Agent 产品名，忽略版本号）数达到该值时命中，0 表示不检测
+	NewUserAgentThreshold int `mapstructure:"new_user_agent_threshold"`
+	// 模型消费分布与基线的偏移度（总变差距离，0-1）达到该值时命中，0 表示不检测
+	ModelMixThreshold float64 `mapstructure:"model_mix_threshold"`
+	// 各检测项的处置动作
+	SpikeAction     string `mapstructure:"spike_action"`
+	NewClientAction string `mapstructure:"new_client_action"`
+	ModelMixAction  string `mapstructure:"model_mix_action"`
+	// lower_concurrency 动作将用户并发降至该值
+	LoweredConcurrency int `mapstructure:"lowered_concurrency"`
+	// 驳回后同一对象同一类型的静默时间（分钟），避免反复命中
+	CooldownMinutes int `mapstructure:"cooldown_minutes"`
+}
+
+// CapacityForecastConfig 账号池容量预测配置
+//
+// 以请求数为单位：按账号配额窗口（Claude 5h/7d、Codex 5h/7d、Gemini 日配额、Antigravity 配额域）的使用率
+// 与窗口内请求数估算单账号容量，结合分组近期需求推算耗尽时间与建议扩容数。
+type CapacityForecastConfig struct {
+	// 预测时长（小时），超过该时长仍未耗尽视为容量充足
+	HorizonHours int `mapstructure:"horizon_hours"`
+	// 需求估算回看时长（小时），需求取最近 1 小时与回看期小时均值中的较大者
+	DemandLookbackHours int `mapstructure:"demand_lookback_hours"`
+	// 建议扩容时预留的余量比例（0.2 表示按需求的 120% 计算）
+	Headroom float64 `mapstructure:"headroom"`
+	// 耗尽时间低于该值（小时）时状态为 warning / critical
+	WarningHours  float64 `mapstructure:"warning_hours"`
+	CriticalHours float64 `mapstructure:"critical_hours"`
+	// 预测结果缓存时间（秒），告警指标与管理接口共用
+	CacheTTLSeconds int `mapstructure:"cache_ttl_seconds"`
+	// 查询账号上游用量（Claude OAuth / Antigravity）的并发数
+	UsageFetchConcurrency int `mapstructure:"usage_fetch_concurrency"`
+}
+
+// TracingConfig OpenTelemetry 分布式追踪配置
+//
+// 网关请求会生成认证、并发槽位、账号调度、获取令牌、上游 HTTP、流式转发与使用量记录等 span，
+// 客户端携带的 W3C traceparent 会被继承；trace id 写入 ops 错误日志与使用记录。
+type TracingConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	ServiceName string `mapstructure:"service_name"`
+	// OTLP/HTTP 地址，如 http://otel-collector:4318；为空时使用 OTEL_EXPORTER_OTLP_* 环境变量
+	Endpoint string `mapstructure:"endpoint"`
+	// 导出请求附带的头（如鉴权）
+	Headers map[string]string `mapstructure:"headers"`
+	// 根 span 采样比例（0-1）；客户端传入已采样的 traceparent 时始终采样
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// LogConfig 结构化日志配置
+//
+// 网关日志带有 client_request_id、api_key_id、user_id、account_id、platform、model 与 trace_id 字段；
+// 日志级别可通过管理接口在运行时调整（仅影响当前节点，重启后恢复为配置值）。
+type LogConfig struct {
+	// 日志级别 debug/info/warn/error；为空时 server.mode=release 使用 info，否则使用 debug
+	Level string `mapstructure:"level"`
+	// 输出格式 text/json
+	Format string `mapstructure:"format"`
+}
+
+// ClusterConfig 多实例集群感知
+//
+// 每个节点定期把版本、启动时间、进行中请求数、配置摘要等写入 Redis 作为心跳，
+// 管理端据此展示集群节点与 leader 锁持有情况，并可逐个节点滚动更新/重启。
+type ClusterConfig struct {
+	// 节点 ID；为空时使用 hostname:port。需在重启后保持不变，滚动更新据此识别同一节点
+	NodeID string `mapstructure:"node_id"`
+	// 心跳间隔（秒）
+	HeartbeatIntervalSeconds int `mapstructure:"heartbeat_interval_seconds"`
+	// 超过该时间未收到心跳视为节点离线（秒）
+	NodeTTLSeconds int `mapstructure:"node_ttl_seconds"`
+	// 滚动更新/重启时等待单个节点恢复的超时（秒），超时后中止滚动
+	RolloutNodeTimeoutSeconds int `mapstructure:"rollout_node_timeout_seconds"`
+}
+
 type PricingConfig struct {
 	// 价格数据远程URL（默认使用LiteLLM镜像）
 	RemoteURL string `mapstructure:"remote_url"`
@@ -191,6 +364,18 @@ type ProxyProbeConfig struct {
 
 type BillingConfig struct {
 	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	Hold           BillingHoldConfig    `mapstructure:"hold"`
+}
+
+// BillingHoldConfig 请求预授权配置：转发前按预估费用上限冻结余额/订阅额度，计费时按实际费用结算
+type BillingHoldConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TTLSeconds: 冻结记录过期时间（秒），用于回收崩溃/中断请求的冻结，应大于最长请求耗时
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// InputBytesPerToken: 按请求体字节数估算输入 token 的换算比例
+	InputBytesPerToken int `mapstructure:"input_bytes_per_token"`
+	// DefaultMaxOutputTokens: 请求未指定 max_tokens 时使用的输出 token 上限
+	DefaultMaxOutputTokens int `mapstructure:"default_max_output_tokens"`
 }
 
 type CircuitBreakerConfig struct {
@@ -259,10 +444,15 @@ type GatewayConfig struct {
 	// 是否允许对部分 400 错误触发 failover（默认关闭以避免改变语义）
 	FailoverOn400 bool `mapstructure:"failover_on_400"`
 
+	// 是否在网关响应中返回排队等待时间与账号切换次数诊断头（供压测使用，默认关闭）
+	DiagnosticHeaders bool `mapstructure:"diagnostic_headers"`
+
 	// 账户切换最大次数（遇到上游错误时切换到其他账户的次数上限）
 	MaxAccountSwitches int `mapstructure:"max_account_switches"`
 	// Gemini 账户切换最大次数（Gemini 平台单独配置，因 API 限制更严格）
 	MaxAccountSwitchesGemini int `mapstructure:"max_account_switches_gemini"`
+	// Gemini 分组兜底到 Anthropic 账号时使用的 Claude 模型（账号 model_mapping 未映射到 claude-* 时生效）
+	GeminiClaudeFallbackModel string `mapstructure:"gemini_claude_fallback_model"`
 
 	// Antigravity 429 fallback 限流时间（分钟），解析重置时间失败时使用
 	AntigravityFallbackCooldownMinutes int `mapstructure:"antigravity_fallback_cooldown_minutes"`
@@ -339,6 +529,47 @@ type GatewaySchedulingConfig struct {
 	// 全量重建周期配置
 	// 全量重建周期（秒），0 表示禁用
 	FullRebuildIntervalSeconds int `mapstructure:"full_rebuild_interval_seconds"`
+
+	// Prompt 缓存亲和调度配置
+	CacheAffinity GatewayCacheAffinityConfig `mapstructure:"cache_affinity"`
+
+	// 账号熔断配置
+	CircuitBreaker GatewayCircuitBreakerConfig `mapstructure:"circuit_breaker"`
+}
+
+// GatewayCacheAffinityConfig Prompt 缓存亲和调度配置
+// 记录各账号近期写入/命中的 prompt 缓存前缀，调度时优先选择持有热缓存的账号（依赖 load_batch_enabled）
+type GatewayCacheAffinityConfig struct {
+	// Enabled: 是否启用缓存亲和调度
+	Enabled bool `mapstructure:"enabled"`
+	// MinPrefixTokens: 参与亲和调度的最小前缀长度（估算 token 数），过短的前缀不值得为其排队
+	MinPrefixTokens int `mapstructure:"min_prefix_tokens"`
+	// TokensPerWaitingRequest: 热账号每多一个排队请求所需的前缀 token 数，
+	// 用于权衡缓存未命中的成本与排队等待（0 表示热账号满载时不排队，直接回退到负载感知选择）
+	TokensPerWaitingRequest int `mapstructure:"tokens_per_waiting_request"`
+}
+
+// GatewayCircuitBreakerConfig 账号熔断配置
+// 按滚动窗口统计账号的上游错误率与慢请求率，超过阈值后熔断（open），
+// 熔断时间结束后进入半开（half_open）放行单个探测请求，探测成功则恢复（closed），失败则重新熔断。
+// 状态保存在 Redis 中，多实例共享。
+type GatewayCircuitBreakerConfig struct {
+	// Enabled: 是否启用账号熔断
+	Enabled bool `mapstructure:"enabled"`
+	// Window: 滚动统计窗口
+	Window time.Duration `mapstructure:"window"`
+	// MinRequests: 窗口内最少请求数，不足时不触发熔断
+	MinRequests int `mapstructure:"min_requests"`
+	// ErrorRateThreshold: 错误率阈值（0~1），5xx / 529 / 网络错误计为失败
+	ErrorRateThreshold float64 `mapstructure:"error_rate_threshold"`
+	// SlowCallDuration: 慢请求阈值（流式请求按首 token 耗时，非流式按总耗时），0 表示不统计慢请求
+	SlowCallDuration time.Duration `mapstructure:"slow_call_duration"`
+	// SlowCallRateThreshold: 慢请求率阈值（0~1）
+	SlowCallRateThreshold float64 `mapstructure:"slow_call_rate_threshold"`
+	// OpenDuration: 熔断持续时间，结束后进入半开状态
+	OpenDuration time.Duration `mapstructure:"open_duration"`
+	// ProbeTimeout: 半开状态下单个探测请求的占用时长，超时未回报结果时允许下一个探测
+	ProbeTimeout time.Duration `mapstructure:"probe_timeout"`
 }
 
 func (s *ServerConfig) Address() string {
@@ -465,6 +696,22 @@ type OpsMetricsCollectorCacheConfig struct {
 type JWTConfig struct {
 	Secret     string `mapstructure:"secret"`
 	ExpireHour int    `mapstructure:"expire_hour"`
+	// RefreshExpireHour 登录会话的有效期（小时），在此期间可刷新 access token，每次刷新滑动延长
+	RefreshExpireHour int `mapstructure:"refresh_expire_hour"`
+}
+
+// WebAuthnConfig WebAuthn/Passkey 认证配置
+type WebAuthnConfig struct {
+	// Enabled 是否启用 WebAuthn（作为第二因素）
+	Enabled bool `mapstructure:"enabled"`
+	// RPID 依赖方 ID，通常为站点域名（不含协议与端口），如 "example.com"
+	RPID string `mapstructure:"rp_id"`
+	// RPDisplayName 依赖方显示名称
+	RPDisplayName string `mapstructure:"rp_display_name"`
+	// RPOrigins 允许的来源列表，如 ["https://example.com"]
+	RPOrigins []string `mapstructure:"rp_origins"`
+	// AllowPasswordless 是否允许使用 Passkey 无密码登录
+	AllowPasswordless bool `mapstructure:"allow_passwordless"`
 }
 
 // TotpConfig TOTP 双因素认证配置
@@ -609,6 +856,15 @@ func Load() (*Config, error) {
 	if cfg.Server.Mode == "" {
 		cfg.Server.Mode = "debug"
 	}
+	cfg.Log.Level = strings.ToLower(strings.TrimSpace(cfg.Log.Level))
+	if cfg.Log.Level == "" {
+		cfg.Log.Level = "debug"
+		if cfg.Server.Mode == "release" {
+			cfg.Log.Level = "info"
+		}
+	}
+	cfg.Log.Format = strings.ToLower(strings.TrimSpace(cfg.Log.Format))
+	cfg.Cluster.NodeID = strings.TrimSpace(cfg.Cluster.NodeID)
 	cfg.JWT.Secret = strings.TrimSpace(cfg.JWT.Secret)
 	cfg.LinuxDo.ClientID = strings.TrimSpace(cfg.LinuxDo.ClientID)
 	cfg.LinuxDo.ClientSecret = strings.TrimSpace(cfg.LinuxDo.ClientSecret)
@@ -720,6 +976,10 @@ func setDefaults() {
 	viper.SetDefault("billing.circuit_breaker.failure_threshold", 5)
 	viper.SetDefault("billing.circuit_breaker.reset_timeout_seconds", 30)
 	viper.SetDefault("billing.circuit_breaker.half_open_requests", 3)
+	viper.SetDefault("billing.hold.enabled", true)
+	viper.SetDefault("billing.hold.ttl_seconds", 1800)
+	viper.SetDefault("billing.hold.input_bytes_per_token", 4)
+	viper.SetDefault("billing.hold.default_max_output_tokens", 8192)
 
 	// Turnstile
 	viper.SetDefault("turnstile.required", false)
@@ -780,10 +1040,18 @@ func setDefaults() {
 	// JWT
 	viper.SetDefault("jwt.secret", "")
 	viper.SetDefault("jwt.expire_hour", 24)
+	viper.SetDefault("jwt.refresh_expire_hour", 168)
 
 	// TOTP
 	viper.SetDefault("totp.encryption_key", "")
 
+	// WebAuthn
+	viper.SetDefault("webauthn.enabled", false)
+	viper.SetDefault("webauthn.rp_id", "")
+	viper.SetDefault("webauthn.rp_display_name", "Sub2API")
+	viper.SetDefault("webauthn.rp_origins", []string{})
+	viper.SetDefault("webauthn.allow_passwordless", false)
+
 	// Default
 	// Admin credentials are created via the setup flow (web wizard / CLI / AUTO_SETUP).
 	// Do not ship fixed defaults here to avoid insecure "known credentials" in production.
@@ -846,9 +1114,11 @@ func setDefaults() {
 	viper.SetDefault("gateway.log_upstream_error_body", true)
 	viper.SetDefault("gateway.log_upstream_error_body_max_bytes", 2048)
 	viper.SetDefault("gateway.inject_beta_for_apikey", false)
+	viper.SetDefault("gateway.diagnostic_headers", false)
 	viper.SetDefault("gateway.failover_on_400", false)
 	viper.SetDefault("gateway.max_account_switches", 10)
 	viper.SetDefault("gateway.max_account_switches_gemini", 3)
+	viper.SetDefault("gateway.gemini_claude_fallback_model", "claude-sonnet-4-5")
 	viper.SetDefault("gateway.antigravity_fallback_cooldown_minutes", 1)
 	viper.SetDefault("gateway.max_body_size", int64(100*1024*1024))
 	viper.SetDefault("gateway.connection_pool_isolation", ConnectionPoolIsolationAccountProxy)
@@ -879,11 +1149,82 @@ func setDefaults() {
 	viper.SetDefault("gateway.scheduling.outbox_lag_rebuild_failures", 3)
 	viper.SetDefault("gateway.scheduling.outbox_backlog_rebuild_rows", 10000)
 	viper.SetDefault("gateway.scheduling.full_rebuild_interval_seconds", 300)
+	viper.SetDefault("gateway.scheduling.cache_affinity.enabled", false)
+	viper.SetDefault("gateway.scheduling.cache_affinity.min_prefix_tokens", 1024)
+	viper.SetDefault("gateway.scheduling.cache_affinity.tokens_per_waiting_request", 20000)
+	viper.SetDefault("gateway.scheduling.circuit_breaker.enabled", false)
+	viper.SetDefault("gateway.scheduling.circuit_breaker.window", 60*time.Second)
+	viper.SetDefault("gateway.scheduling.circuit_breaker.min_requests", 20)
+	viper.SetDefault("gateway.scheduling.circuit_breaker.error_rate_threshold", 0.5)
+	viper.SetDefault("gateway.scheduling.circuit_breaker.slow_call_duration", 0)
+	viper.SetDefault("gateway.scheduling.circuit_breaker.slow_call_rate_threshold", 0.8)
+	viper.SetDefault("gateway.scheduling.circuit_breaker.open_duration", 30*time.Second)
+	viper.SetDefault("gateway.scheduling.circuit_breaker.probe_timeout", 60*time.Second)
 	// TLS指纹伪装配置（默认关闭，需要账号级别单独启用）
 	viper.SetDefault("gateway.tls_fingerprint.enabled", true)
 	viper.SetDefault("concurrency.ping_interval", 10)
 
 	// TokenRefresh
+	// Proxy pool
+	viper.SetDefault("proxy_pool.health_check_enabled", true)
+	viper.SetDefault("proxy_pool.health_check_interval_seconds", 120)
+	viper.SetDefault("proxy_pool.failure_threshold", 3)
+	viper.SetDefault("proxy_pool.max_failover_attempts", 3)
+
+	// Content policy
+	viper.SetDefault("content_policy.moderation_url", "")
+	viper.SetDefault("content_policy.moderation_timeout_seconds", 3)
+	viper.SetDefault("content_policy.moderation_fail_open", true)
+	viper.SetDefault("content_policy.suspend_after_violations", 0)
+	viper.SetDefault("content_policy.violation_window_hours", 24)
+	viper.SetDefault("content_policy.rule_refresh_interval_seconds", 30)
+
+	// Mock upstream / upstream recording
+	viper.SetDefault("mock_upstream.enabled", false)
+	viper.SetDefault("mock_upstream.record.enabled", false)
+	viper.SetDefault("mock_upstream.record.dir", "./data/upstream-fixtures")
+	viper.SetDefault("mock_upstream.record.max_body_bytes", 4<<20)
+
+	// Spend anomaly
+	viper.SetDefault("spend_anomaly.enabled", true)
+	viper.SetDefault("spend_anomaly.interval_seconds", 300)
+	viper.SetDefault("spend_anomaly.baseline_days", 7)
+	viper.SetDefault("spend_anomaly.min_hourly_spend_usd", 5.0)
+	viper.SetDefault("spend_anomaly.spike_multiplier", 5.0)
+	viper.SetDefault("spend_anomaly.new_ip_threshold", 3)
+	viper.SetDefault("spend_anomaly.new_user_agent_threshold", 1)
+	viper.SetDefault("spend_anomaly.model_mix_threshold", 0.8)
+	viper.SetDefault("spend_anomaly.spike_action", "alert")
+	viper.SetDefault("spend_anomaly.new_client_action", "alert")
+	viper.SetDefault("spend_anomaly.model_mix_action", "alert")
+	viper.SetDefault("spend_anomaly.lowered_concurrency", 1)
+	viper.SetDefault("spend_anomaly.cooldown_minutes", 360)
+
+	// Log
+	viper.SetDefault("log.level", "")
+	viper.SetDefault("log.format", "text")
+
+	// Cluster
+	viper.SetDefault("cluster.node_id", "")
+	viper.SetDefault("cluster.heartbeat_interval_seconds", 10)
+	viper.SetDefault("cluster.node_ttl_seconds", 35)
+	viper.SetDefault("cluster.rollout_node_timeout_seconds", 600)
+
+	// Tracing
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.service_name", "sub2api")
+	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("tracing.sample_ratio", 0.1)
+
+	// Capacity forecast
+	viper.SetDefault("capacity_forecast.horizon_hours", 168)
+	viper.SetDefault("capacity_forecast.demand_lookback_hours", 24)
+	viper.SetDefault("capacity_forecast.headroom", 0.2)
+	viper.SetDefault("capacity_forecast.warning_hours", 24.0)
+	viper.SetDefault("capacity_forecast.critical_hours", 5.0)
+	viper.SetDefault("capacity_forecast.cache_ttl_seconds", 300)
+	viper.SetDefault("capacity_forecast.usage_fetch_concurrency", 4)
+
 	viper.SetDefault("token_refresh.enabled", true)
 	viper.SetDefault("token_refresh.check_interval_minutes", 5)        // 每5分钟检查一次
 	viper.SetDefault("token_refresh.refresh_before_expiry_hours", 0.5) // 提前30分钟刷新（适配Google 1小时token）
@@ -909,6 +1250,20 @@ func (c *Config) Validate() error {
 	if c.JWT.ExpireHour > 24 {
 		log.Printf("Warning: jwt.expire_hour is %d hours (> 24). Consider shorter expiration for security.", c.JWT.ExpireHour)
 	}
+	if c.JWT.RefreshExpireHour < 0 {
+		return fmt.Errorf("jwt.refresh_expire_hour must be non-negative")
+	}
+	if c.JWT.RefreshExpireHour > 0 && c.JWT.RefreshExpireHour < c.JWT.ExpireHour {
+		return fmt.Errorf("jwt.refresh_expire_hour must be >= jwt.expire_hour")
+	}
+	if c.WebAuthn.Enabled {
+		if strings.TrimSpace(c.WebAuthn.RPID) == "" {
+			return fmt.Errorf("webauthn.rp_id is required when webauthn is enabled")
+		}
+		if len(c.WebAuthn.RPOrigins) == 0 {
+			return fmt.Errorf("webauthn.rp_origins is required when webauthn is enabled")
+		}
+	}
 	if c.Security.CSP.Enabled && strings.TrimSpace(c.Security.CSP.Policy) == "" {
 		return fmt.Errorf("security.csp.policy is required when CSP is enabled")
 	}
@@ -978,6 +1333,148 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("billing.circuit_breaker.half_open_requests must be positive")
 		}
 	}
+	if c.Billing.Hold.Enabled {
+		if c.Billing.Hold.TTLSeconds <= 0 {
+			return fmt.Errorf("billing.hold.ttl_seconds must be positive")
+		}
+		if c.Billing.Hold.InputBytesPerToken <= 0 {
+			return fmt.Errorf("billing.hold.input_bytes_per_token must be positive")
+		}
+		if c.Billing.Hold.DefaultMaxOutputTokens <= 0 {
+			return fmt.Errorf("billing.hold.default_max_output_tokens must be positive")
+		}
+	}
+	if c.ProxyPool.HealthCheckEnabled && c.ProxyPool.HealthCheckIntervalSeconds <= 0 {
+		return fmt.Errorf("proxy_pool.health_check_interval_seconds must be positive")
+	}
+	if c.ProxyPool.FailureThreshold <= 0 {
+		return fmt.Errorf("proxy_pool.failure_threshold must be positive")
+	}
+	if c.ProxyPool.MaxFailoverAttempts <= 0 {
+		return fmt.Errorf("proxy_pool.max_failover_attempts must be positive")
+	}
+	if c.ContentPolicy.ModerationURL != "" && c.ContentPolicy.ModerationTimeoutSeconds <= 0 {
+		return fmt.Errorf("content_policy.moderation_timeout_seconds must be positive")
+	}
+	if c.ContentPolicy.SuspendAfterViolations < 0 {
+		return fmt.Errorf("content_policy.suspend_after_violations must be non-negative")
+	}
+	if c.ContentPolicy.SuspendAfterViolations > 0 && c.ContentPolicy.ViolationWindowHours <= 0 {
+		return fmt.Errorf("content_policy.violation_window_hours must be positive")
+	}
+	if c.ContentPolicy.RuleRefreshIntervalSeconds <= 0 {
+		return fmt.Errorf("content_policy.rule_refresh_interval_seconds must be positive")
+	}
+	if c.SpendAnomaly.Enabled {
+		if c.SpendAnomaly.IntervalSeconds <= 0 {
+			return fmt.Errorf("spend_anomaly.interval_seconds must be positive")
+		}
+		if c.SpendAnomaly.BaselineDays <= 0 {
+			return fmt.Errorf("spend_anomaly.baseline_days must be positive")
+		}
+		if c.SpendAnomaly.MinHourlySpendUSD < 0 {
+			return fmt.Errorf("spend_anomaly.min_hourly_spend_usd must be non-negative")
+		}
+		if c.SpendAnomaly.SpikeMultiplier <= 1 {
+			return fmt.Errorf("spend_anomaly.spike_multiplier must be greater than 1")
+		}
+		if c.SpendAnomaly.NewIPThreshold < 0 || c.SpendAnomaly.NewUserAgentThreshold < 0 {
+			return fmt.Errorf("spend_anomaly new client thresholds must be non-negative")
+		}
+		if c.SpendAnomaly.ModelMixThreshold < 0 || c.SpendAnomaly.ModelMixThreshold > 1 {
+			return fmt.Errorf("spend_anomaly.model_mix_threshold must be between 0 and 1")
+		}
+		actions := []struct{ name, value string }{
+			{"spike_action", c.SpendAnomaly.SpikeAction},
+			{"new_client_action", c.SpendAnomaly.NewClientAction},
+			{"model_mix_action", c.SpendAnomaly.ModelMixAction},
+		}
+		for _, action := range actions {
+			switch action.value {
+			case "alert", "disable_key", "lower_concurrency":
+			default:
+				return fmt.Errorf("spend_anomaly.%s must be one of alert, disable_key, lower_concurrency", action.name)
+			}
+		}
+		if c.SpendAnomaly.LoweredConcurrency <= 0 {
+			return fmt.Errorf("spend_anomaly.lowered_concurrency must be positive")
+		}
+		if c.SpendAnomaly.CooldownMinutes < 0 {
+			return fmt.Errorf("spend_anomaly.cooldown_minutes must be non-negative")
+		}
+	}
+	if c.CapacityForecast.HorizonHours <= 0 {
+		return fmt.Errorf("capacity_forecast.horizon_hours must be positive")
+	}
+	if c.CapacityForecast.DemandLookbackHours <= 0 {
+		return fmt.Errorf("capacity_forecast.demand_lookback_hours must be positive")
+	}
+	if c.CapacityForecast.Headroom < 0 {
+		return fmt.Errorf("capacity_forecast.headroom must be non-negative")
+	}
+	if c.CapacityForecast.CriticalHours < 0 || c.CapacityForecast.WarningHours < c.CapacityForecast.CriticalHours {
+		return fmt.Errorf("capacity_forecast.warning_hours must be >= critical_hours >= 0")
+	}
+	if c.CapacityForecast.CacheTTLSeconds < 0 {
+		return fmt.Errorf("capacity_forecast.cache_ttl_seconds must be non-negative")
+	}
+	if c.CapacityForecast.UsageFetchConcurrency <= 0 {
+		return fmt.Errorf("capacity_forecast.usage_fetch_concurrency must be positive")
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("log.level must be one of debug/info/warn/error")
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("log.format must be one of text/json")
+	}
+	if c.Cluster.HeartbeatIntervalSeconds <= 0 {
+		return fmt.Errorf("cluster.heartbeat_interval_seconds must be positive")
+	}
+	if c.Cluster.NodeTTLSeconds <= c.Cluster.HeartbeatIntervalSeconds {
+		return fmt.Errorf("cluster.node_ttl_seconds must be greater than cluster.heartbeat_interval_seconds")
+	}
+	if c.Cluster.RolloutNodeTimeoutSeconds <= 0 {
+		return fmt.Errorf("cluster.rollout_node_timeout_seconds must be positive")
+	}
+	if c.Tracing.Enabled {
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			return fmt.Errorf("tracing.sample_ratio must be within [0,1]")
+		}
+		if strings.TrimSpace(c.Tracing.ServiceName) == "" {
+			return fmt.Errorf("tracing.service_name is required when tracing is enabled")
+		}
+	}
+	for name, p := range c.MockUpstream.Profiles {
+		if p.LatencyMs < 0 || p.ChunkIntervalMs < 0 || p.Chunks < 0 || p.RetryAfterSeconds < 0 {
+			return fmt.Errorf("mock_upstream.profiles.%s: durations and chunks must be non-negative", name)
+		}
+		if p.InputTokens < 0 || p.OutputTokens < 0 || p.CacheReadTokens < 0 {
+			return fmt.Errorf("mock_upstream.profiles.%s: token counts must be non-negative", name)
+		}
+		rates := []float64{p.RateLimitRate, p.OverloadedRate, p.ServerErrorRate}
+		total := 0.0
+		for _, r := range rates {
+			if r < 0 || r > 1 {
+				return fmt.Errorf("mock_upstream.profiles.%s: error rates must be between 0 and 1", name)
+			}
+			total += r
+		}
+		if total > 1 {
+			return fmt.Errorf("mock_upstream.profiles.%s: error rates must sum to at most 1", name)
+		}
+	}
+	if c.MockUpstream.Record.Enabled {
+		if strings.TrimSpace(c.MockUpstream.Record.Dir) == "" {
+			return fmt.Errorf("mock_upstream.record.dir is required when recording is enabled")
+		}
+		if c.MockUpstream.Record.MaxBodyBytes <= 0 {
+			return fmt.Errorf("mock_upstream.record.max_body_bytes must be positive")
+		}
+	}
 	if c.Database.MaxOpenConns <= 0 {
 		return fmt.Errorf("database.max_open_conns must be positive")
 	}
@@ -1209,6 +1706,35 @@ func (c *Config) Validate() error {
 		c.Gateway.Scheduling.OutboxLagRebuildSeconds < c.Gateway.Scheduling.OutboxLagWarnSeconds {
 		return fmt.Errorf("gateway.scheduling.outbox_lag_rebuild_seconds must be >= outbox_lag_warn_seconds")
 	}
+	if c.Gateway.Scheduling.CacheAffinity.MinPrefixTokens < 0 {
+		return fmt.Errorf("gateway.scheduling.cache_affinity.min_prefix_tokens must be non-negative")
+	}
+	if c.Gateway.Scheduling.CacheAffinity.TokensPerWaitingRequest < 0 {
+		return fmt.Errorf("gateway.scheduling.cache_affinity.tokens_per_waiting_request must be non-negative")
+	}
+	if cb := c.Gateway.Scheduling.CircuitBreaker; cb.Enabled {
+		if cb.Window <= 0 {
+			return fmt.Errorf("gateway.scheduling.circuit_breaker.window must be positive")
+		}
+		if cb.MinRequests <= 0 {
+			return fmt.Errorf("gateway.scheduling.circuit_breaker.min_requests must be positive")
+		}
+		if cb.ErrorRateThreshold <= 0 || cb.ErrorRateThreshold > 1 {
+			return fmt.Errorf("gateway.scheduling.circuit_breaker.error_rate_threshold must be within (0, 1]")
+		}
+		if cb.SlowCallDuration < 0 {
+			return fmt.Errorf("gateway.scheduling.circuit_breaker.slow_call_duration must be non-negative")
+		}
+		if cb.SlowCallDuration > 0 && (cb.SlowCallRateThreshold <= 0 || cb.SlowCallRateThreshold > 1) {
+			return fmt.Errorf("gateway.scheduling.circuit_breaker.slow_call_rate_threshold must be within (0, 1]")
+		}
+		if cb.OpenDuration <= 0 {
+			return fmt.Errorf("gateway.scheduling.circuit_breaker.open_duration must be positive")
+		}
+		if cb.ProbeTimeout <= 0 {
+			return fmt.Errorf("gateway.scheduling.circuit_breaker.probe_timeout must be positive")
+		}
+	}
 	if c.Ops.MetricsCollectorCache.TTL < 0 {
 		return fmt.Errorf("ops.metrics_collector_cache.ttl must be non-negative")
 	}
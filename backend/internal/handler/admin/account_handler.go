@@ -46,6 +46,7 @@ type AccountHandler struct {
 	crsSyncService          *service.CRSSyncService
 	sessionLimitCache       service.SessionLimitCache
 	tokenCacheInvalidator   service.TokenCacheInvalidator
+	circuitBreaker          *service.CircuitBreakerService
 }
 
 // NewAccountHandler creates a new admin account handler
@@ -62,6 +63,7 @@ func NewAccountHandler(
 	crsSyncService *service.CRSSyncService,
 	sessionLimitCache service.SessionLimitCache,
 	tokenCacheInvalidator service.TokenCacheInvalidator,
+	circuitBreaker *service.CircuitBreakerService,
 ) *AccountHandler {
 	return &AccountHandler{
 		adminService:            adminService,
@@ -76,6 +78,7 @@ func NewAccountHandler(
 		crsSyncService:          crsSyncService,
 		sessionLimitCache:       sessionLimitCache,
 		tokenCacheInvalidator:   tokenCacheInvalidator,
+		circuitBreaker:          circuitBreaker,
 	}
 }
 
@@ -88,6 +91,7 @@ type CreateAccountRequest struct {
 	Credentials             map[string]any `json:"credentials" binding:"required"`
 	Extra                   map[string]any `json:"extra"`
 	ProxyID                 *int64         `json:"proxy_id"`
+	ProxyPoolID             *int64         `json:"proxy_pool_id"`
 	Concurrency             int            `json:"concurrency"`
 	Priority                int            `json:"priority"`
 	RateMultiplier          *float64       `json:"rate_multiplier"`
@@ -106,6 +110,7 @@ type UpdateAccountRequest struct {
 	Credentials             map[string]any `json:"credentials"`
 	Extra                   map[string]any `json:"extra"`
 	ProxyID                 *int64         `json:"proxy_id"`
+	ProxyPoolID             *int64         `json:"proxy_pool_id"`
 	Concurrency             *int           `json:"concurrency"`
 	Priority                *int           `json:"priority"`
 	RateMultiplier          *float64       `json:"rate_multiplier"`
@@ -121,6 +126,7 @@ type BulkUpdateAccountsRequest struct {
 	AccountIDs              []int64        `json:"account_ids" binding:"required,min=1"`
 	Name                    string         `json:"name"`
 	ProxyID                 *int64         `json:"proxy_id"`
+	ProxyPoolID             *int64         `json:"proxy_pool_id"`
 	Concurrency             *int           `json:"concurrency"`
 	Priority                *int           `json:"priority"`
 	RateMultiplier          *float64       `json:"rate_multiplier"`
@@ -301,6 +307,7 @@ func (h *AccountHandler) Create(c *gin.Context) {
 		Credentials:           req.Credentials,
 		Extra:                 req.Extra,
 		ProxyID:               req.ProxyID,
+		ProxyPoolID:           req.ProxyPoolID,
 		Concurrency:           req.Concurrency,
 		Priority:              req.Priority,
 		RateMultiplier:        req.RateMultiplier,
@@ -364,6 +371,7 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		Credentials:           req.Credentials,
 		Extra:                 req.Extra,
 		ProxyID:               req.ProxyID,
+		ProxyPoolID:           req.ProxyPoolID,
 		Concurrency:           req.Concurrency, // 指针类型，nil 表示未提供
 		Priority:              req.Priority,    // 指针类型，nil 表示未提供
 		RateMultiplier:        req.RateMultiplier,
@@ -810,6 +818,7 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 
 	hasUpdates := req.Name != "" ||
 		req.ProxyID != nil ||
+		req.ProxyPoolID != nil ||
 		req.Concurrency != nil ||
 		req.Priority != nil ||
 		req.RateMultiplier != nil ||
@@ -828,6 +837,7 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 		AccountIDs:            req.AccountIDs,
 		Name:                  req.Name,
 		ProxyID:               req.ProxyID,
+		ProxyPoolID:           req.ProxyPoolID,
 		Concurrency:           req.Concurrency,
 		Priority:              req.Priority,
 		RateMultiplier:        req.RateMultiplier,
@@ -1069,6 +1079,74 @@ func (h *AccountHandler) ClearTempUnschedulable(c *gin.Context) {
 	response.Success(c, gin.H{"message": "Temp unschedulable cleared successfully"})
 }
 
+// GetCircuitBreaker handles getting account circuit breaker state
+// GET /api/v1/admin/accounts/:id/circuit-breaker
+func (h *AccountHandler) GetCircuitBreaker(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	states, err := h.circuitBreaker.GetStates(c.Request.Context(), []int64{accountID})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	state := states[accountID]
+	if state == nil {
+		state = &service.CircuitBreakerState{State: service.CircuitStateClosed}
+	}
+	response.Success(c, gin.H{
+		"enabled": h.circuitBreaker.Enabled(),
+		"state":   state,
+	})
+}
+
+// ResetCircuitBreaker handles manually closing an account circuit breaker
+// DELETE /api/v1/admin/accounts/:id/circuit-breaker
+func (h *AccountHandler) ResetCircuitBreaker(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	if err := h.circuitBreaker.Reset(c.Request.Context(), accountID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Circuit breaker reset successfully"})
+}
+
+// BatchResetCircuitBreakerRequest represents batch circuit breaker reset request
+type BatchResetCircuitBreakerRequest struct {
+	AccountIDs []int64 `json:"account_ids" binding:"required,min=1"`
+}
+
+// BatchResetCircuitBreaker handles resetting circuit breakers for multiple accounts
+// POST /api/v1/admin/accounts/circuit-breaker/reset
+func (h *AccountHandler) BatchResetCircuitBreaker(c *gin.Context) {
+	var req BatchResetCircuitBreakerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	reset := 0
+	for _, accountID := range req.AccountIDs {
+		if err := h.circuitBreaker.Reset(c.Request.Context(), accountID); err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+		reset++
+	}
+
+	response.Success(c, gin.H{"reset": reset})
+}
+
 // GetTodayStats handles getting account today statistics
 // GET /api/v1/admin/accounts/:id/today-stats
 func (h *AccountHandler) GetTodayStats(c *gin.Context) {
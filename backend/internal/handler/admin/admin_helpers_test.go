@@ -116,6 +116,22 @@ func TestOpsAlertRuleValidation(t *testing.T) {
 	_, err = validateOpsAlertRulePayload(map[string]json.RawMessage{})
 	require.Error(t, err)
 
+	validated, err = validateOpsAlertRulePayload(map[string]json.RawMessage{
+		"name":       json.RawMessage(`"Slow TTFT"`),
+		"expression": json.RawMessage(`"p95(ttft_ms{platform=\"anthropic\"}) by (account) > 8000"`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "expression", validated.MetricType)
+	require.Equal(t, ">", validated.Operator)
+	require.Equal(t, 8000.0, validated.Threshold)
+	require.Equal(t, `p95(ttft_ms{platform="anthropic"}) by (account_id) > 8000`, validated.Expression)
+
+	_, err = validateOpsAlertRulePayload(map[string]json.RawMessage{
+		"name":       json.RawMessage(`"Bad"`),
+		"expression": json.RawMessage(`"p95(requests) > 1"`),
+	})
+	require.Error(t, err)
+
 	require.True(t, isPercentOrRateMetric("error_rate"))
 	require.False(t, isPercentOrRateMetric("concurrency_queue_depth"))
 }
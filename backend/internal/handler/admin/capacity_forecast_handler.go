@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CapacityForecastHandler exposes account pool capacity forecasts per group
+type CapacityForecastHandler struct {
+	capacityForecastService *service.CapacityForecastService
+}
+
+// NewCapacityForecastHandler creates a new admin capacity forecast handler
+func NewCapacityForecastHandler(capacityForecastService *service.CapacityForecastService) *CapacityForecastHandler {
+	return &CapacityForecastHandler{
+		capacityForecastService: capacityForecastService,
+	}
+}
+
+// List handles forecasting every active group, most urgent first
+// GET /api/v1/admin/capacity-forecast?refresh=true
+func (h *CapacityForecastHandler) List(c *gin.Context) {
+	refresh, _ := strconv.ParseBool(c.Query("refresh"))
+	report, err := h.capacityForecastService.ForecastGroups(c.Request.Context(), refresh)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, report)
+}
+
+// GetByGroup handles forecasting a single group with per-account quota windows
+// GET /api/v1/admin/capacity-forecast/:group_id
+func (h *CapacityForecastHandler) GetByGroup(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Param("group_id"), 10, 64)
+	if err != nil || groupID <= 0 {
+		response.BadRequest(c, "Invalid group ID")
+		return
+	}
+	forecast, err := h.capacityForecastService.ForecastGroup(c.Request.Context(), groupID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, forecast)
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClusterHandler exposes the multi-instance cluster view and rolling operations
+type ClusterHandler struct {
+	clusterService *service.ClusterService
+}
+
+// NewClusterHandler creates a new admin cluster handler
+func NewClusterHandler(clusterService *service.ClusterService) *ClusterHandler {
+	return &ClusterHandler{
+		clusterService: clusterService,
+	}
+}
+
+// GetOverview returns all registered nodes, leader lock holders and the latest rollout
+// GET /api/v1/admin/cluster
+func (h *ClusterHandler) GetOverview(c *gin.Context) {
+	overview, err := h.clusterService.Overview(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, overview)
+}
+
+// StartRolloutRequest is the body of StartRollout
+type StartRolloutRequest struct {
+	Action string `json:"action" binding:"required,oneof=update restart"`
+}
+
+// StartRollout starts a cluster-wide update/restart that processes one node at a time
+// POST /api/v1/admin/cluster/rollout
+func (h *ClusterHandler) StartRollout(c *gin.Context) {
+	var req StartRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rollout, err := h.clusterService.StartRollout(c.Request.Context(), req.Action)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, rollout)
+}
+
+// GetRollout returns the progress of the latest rollout
+// GET /api/v1/admin/cluster/rollout
+func (h *ClusterHandler) GetRollout(c *gin.Context) {
+	rollout, err := h.clusterService.GetRollout(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, rollout)
+}
@@ -0,0 +1,226 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentPolicyHandler handles admin content policy rules and hit review
+type ContentPolicyHandler struct {
+	contentPolicyService *service.ContentPolicyService
+}
+
+// NewContentPolicyHandler creates a new admin content policy handler
+func NewContentPolicyHandler(contentPolicyService *service.ContentPolicyService) *ContentPolicyHandler {
+	return &ContentPolicyHandler{
+		contentPolicyService: contentPolicyService,
+	}
+}
+
+// CreateContentPolicyRuleRequest represents create content policy rule request
+type CreateContentPolicyRuleRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	MatchType   string `json:"match_type" binding:"required,oneof=keyword regex"`
+	Pattern     string `json:"pattern" binding:"required"`
+	Description string `json:"description"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+// UpdateContentPolicyRuleRequest represents update content policy rule request
+type UpdateContentPolicyRuleRequest struct {
+	Name        *string `json:"name" binding:"omitempty,max=100"`
+	MatchType   *string `json:"match_type" binding:"omitempty,oneof=keyword regex"`
+	Pattern     *string `json:"pattern"`
+	Description *string `json:"description"`
+	Enabled     *bool   `json:"enabled"`
+}
+
+// TestContentPolicyRequest represents a dry-run request against the enabled rules
+type TestContentPolicyRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// ListRules handles listing all content policy rules
+// GET /api/v1/admin/content-policy/rules
+func (h *ContentPolicyHandler) ListRules(c *gin.Context) {
+	rules, err := h.contentPolicyService.ListRules(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.ContentPolicyRule, 0, len(rules))
+	for i := range rules {
+		out = append(out, *dto.ContentPolicyRuleFromService(&rules[i]))
+	}
+	response.Success(c, out)
+}
+
+// CreateRule handles creating a content policy rule
+// POST /api/v1/admin/content-policy/rules
+func (h *ContentPolicyHandler) CreateRule(c *gin.Context) {
+	var req CreateContentPolicyRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	rule, err := h.contentPolicyService.CreateRule(c.Request.Context(), &service.CreateContentPolicyRuleInput{
+		Name:        req.Name,
+		MatchType:   req.MatchType,
+		Pattern:     req.Pattern,
+		Description: req.Description,
+		Enabled:     enabled,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.ContentPolicyRuleFromService(rule))
+}
+
+// UpdateRule handles updating a content policy rule
+// PUT /api/v1/admin/content-policy/rules/:id
+func (h *ContentPolicyHandler) UpdateRule(c *gin.Context) {
+	ruleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid rule ID")
+		return
+	}
+
+	var req UpdateContentPolicyRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	rule, err := h.contentPolicyService.UpdateRule(c.Request.Context(), ruleID, &service.UpdateContentPolicyRuleInput{
+		Name:        req.Name,
+		MatchType:   req.MatchType,
+		Pattern:     req.Pattern,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.ContentPolicyRuleFromService(rule))
+}
+
+// DeleteRule handles deleting a content policy rule
+// DELETE /api/v1/admin/content-policy/rules/:id
+func (h *ContentPolicyHandler) DeleteRule(c *gin.Context) {
+	ruleID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid rule ID")
+		return
+	}
+
+	if err := h.contentPolicyService.DeleteRule(c.Request.Context(), ruleID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Content policy rule deleted successfully"})
+}
+
+// Test handles a dry run of the enabled rules against sample text
+// POST /api/v1/admin/content-policy/test
+func (h *ContentPolicyHandler) Test(c *gin.Context) {
+	var req TestContentPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	matches := h.contentPolicyService.TestRules(c.Request.Context(), req.Text)
+	out := make([]dto.ContentPolicyMatch, 0, len(matches))
+	for _, m := range matches {
+		out = append(out, dto.ContentPolicyMatch{RuleID: m.RuleID, RuleName: m.RuleName, Excerpt: m.Excerpt})
+	}
+	response.Success(c, gin.H{"matched": len(out) > 0, "matches": out})
+}
+
+// ListHits handles listing content policy hits for review
+// GET /api/v1/admin/content-policy/hits
+func (h *ContentPolicyHandler) ListHits(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	var filter service.ContentPolicyHitFilter
+	if v := strings.TrimSpace(c.Query("user_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filter.UserID = &id
+	}
+	if v := strings.TrimSpace(c.Query("group_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid group_id")
+			return
+		}
+		filter.GroupID = &id
+	}
+	if v := strings.TrimSpace(c.Query("reviewed")); v != "" {
+		reviewed, err := strconv.ParseBool(v)
+		if err != nil {
+			response.BadRequest(c, "Invalid reviewed")
+			return
+		}
+		filter.Reviewed = &reviewed
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	hits, result, err := h.contentPolicyService.ListHits(c.Request.Context(), params, filter)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.ContentPolicyHit, 0, len(hits))
+	for i := range hits {
+		out = append(out, *dto.ContentPolicyHitFromService(&hits[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// ReviewHit handles marking a content policy hit as reviewed
+// POST /api/v1/admin/content-policy/hits/:id/review
+func (h *ContentPolicyHandler) ReviewHit(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	hitID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid hit ID")
+		return
+	}
+
+	if err := h.contentPolicyService.ReviewHit(c.Request.Context(), hitID, subject.UserID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Content policy hit reviewed"})
+}
@@ -360,6 +360,32 @@ func (h *DashboardHandler) GetUserUsageTrend(c *gin.Context) {
 	})
 }
 
+// GetAccountCacheStats handles getting prompt cache hit rates per account
+// GET /api/v1/admin/dashboard/account-cache-stats
+// Query params: start_date, end_date (YYYY-MM-DD), group_id (optional)
+func (h *DashboardHandler) GetAccountCacheStats(c *gin.Context) {
+	startTime, endTime := parseTimeRange(c)
+
+	var groupID int64
+	if groupIDStr := c.Query("group_id"); groupIDStr != "" {
+		if id, err := strconv.ParseInt(groupIDStr, 10, 64); err == nil {
+			groupID = id
+		}
+	}
+
+	stats, err := h.dashboardService.GetAccountCacheStats(c.Request.Context(), startTime, endTime, groupID)
+	if err != nil {
+		response.Error(c, 500, "Failed to get account cache statistics")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"accounts":   stats,
+		"start_date": startTime.Format("2006-01-02"),
+		"end_date":   endTime.Add(-24 * time.Hour).Format("2006-01-02"),
+	})
+}
+
 // BatchUsersUsageRequest represents the request body for batch user usage stats
 type BatchUsersUsageRequest struct {
 	UserIDs []int64 `json:"user_ids" binding:"required"`
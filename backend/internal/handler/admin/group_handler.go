@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
 
@@ -9,17 +10,20 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
 )
 
 // GroupHandler handles admin group management
 type GroupHandler struct {
-	adminService service.AdminService
+	adminService     service.AdminService
+	accountSelection *service.AccountSelectionService
 }
 
 // NewGroupHandler creates a new admin group handler
-func NewGroupHandler(adminService service.AdminService) *GroupHandler {
+func NewGroupHandler(adminService service.AdminService, accountSelection *service.AccountSelectionService) *GroupHandler {
 	return &GroupHandler{
-		adminService: adminService,
+		adminService:     adminService,
+		accountSelection: accountSelection,
 	}
 }
 
@@ -34,6 +38,8 @@ type CreateGroupRequest struct {
 	DailyLimitUSD    *float64 `json:"daily_limit_usd"`
 	WeeklyLimitUSD   *float64 `json:"weekly_limit_usd"`
 	MonthlyLimitUSD  *float64 `json:"monthly_limit_usd"`
+	// 订阅续期价格（负数表示清除）
+	SubscriptionPrice *float64 `json:"subscription_price"`
 	// 图片生成计费配置（antigravity 和 gemini 平台使用，负数表示清除配置）
 	ImagePrice1K    *float64 `json:"image_price_1k"`
 	ImagePrice2K    *float64 `json:"image_price_2k"`
@@ -43,6 +49,20 @@ type CreateGroupRequest struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
+	// 账号选择策略（空表示默认）
+	AccountSelectionStrategy string `json:"account_selection_strategy"`
+	// 图片生成模型白名单（为空表示不限制）
+	ImageModels []string `json:"image_models"`
+	// 内容策略命中动作（空表示不检查）
+	ContentPolicyAction string `json:"content_policy_action" binding:"omitempty,oneof=block log strip"`
+	// 请求改写规则（按顺序应用）
+	RequestRewriteRules []dto.RequestRewriteRule `json:"request_rewrite_rules" binding:"omitempty,dive"`
+	// 模型别名（别名 -> 实际模型）与访问策略（为空表示不限制）
+	ModelAliases  map[string]string `json:"model_aliases"`
+	AllowedModels []string          `json:"allowed_models"`
+	DeniedModels  []string          `json:"denied_models"`
+	// 订阅 token/请求数限额（为空表示不限制）
+	UsageLimits []dto.SubscriptionUsageLimit `json:"usage_limits" binding:"omitempty,dive"`
 }
 
 // UpdateGroupRequest represents update group request
@@ -57,6 +77,8 @@ type UpdateGroupRequest struct {
 	DailyLimitUSD    *float64 `json:"daily_limit_usd"`
 	WeeklyLimitUSD   *float64 `json:"weekly_limit_usd"`
 	MonthlyLimitUSD  *float64 `json:"monthly_limit_usd"`
+	// 订阅续期价格（负数表示清除）
+	SubscriptionPrice *float64 `json:"subscription_price"`
 	// 图片生成计费配置（antigravity 和 gemini 平台使用，负数表示清除配置）
 	ImagePrice1K    *float64 `json:"image_price_1k"`
 	ImagePrice2K    *float64 `json:"image_price_2k"`
@@ -66,6 +88,20 @@ type UpdateGroupRequest struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled *bool              `json:"model_routing_enabled"`
+	// 账号选择策略（传入空字符串恢复默认）
+	AccountSelectionStrategy *string `json:"account_selection_strategy"`
+	// 图片生成模型白名单（传入空数组清除限制）
+	ImageModels *[]string `json:"image_models"`
+	// 内容策略命中动作（传入空字符串关闭检查）
+	ContentPolicyAction *string `json:"content_policy_action"`
+	// 请求改写规则（传入空数组清除）
+	RequestRewriteRules *[]dto.RequestRewriteRule `json:"request_rewrite_rules"`
+	// 模型别名与访问策略（传入空值清除）
+	ModelAliases  *map[string]string `json:"model_aliases"`
+	AllowedModels *[]string          `json:"allowed_models"`
+	DeniedModels  *[]string          `json:"denied_models"`
+	// 订阅 token/请求数限额（传入空数组清除）
+	UsageLimits *[]dto.SubscriptionUsageLimit `json:"usage_limits"`
 }
 
 // List handles listing all groups with pagination
@@ -155,22 +191,31 @@ func (h *GroupHandler) Create(c *gin.Context) {
 	}
 
 	group, err := h.adminService.CreateGroup(c.Request.Context(), &service.CreateGroupInput{
-		Name:                req.Name,
-		Description:         req.Description,
-		Platform:            req.Platform,
-		RateMultiplier:      req.RateMultiplier,
-		IsExclusive:         req.IsExclusive,
-		SubscriptionType:    req.SubscriptionType,
-		DailyLimitUSD:       req.DailyLimitUSD,
-		WeeklyLimitUSD:      req.WeeklyLimitUSD,
-		MonthlyLimitUSD:     req.MonthlyLimitUSD,
-		ImagePrice1K:        req.ImagePrice1K,
-		ImagePrice2K:        req.ImagePrice2K,
-		ImagePrice4K:        req.ImagePrice4K,
-		ClaudeCodeOnly:      req.ClaudeCodeOnly,
-		FallbackGroupID:     req.FallbackGroupID,
-		ModelRouting:        req.ModelRouting,
-		ModelRoutingEnabled: req.ModelRoutingEnabled,
+		Name:                     req.Name,
+		Description:              req.Description,
+		Platform:                 req.Platform,
+		RateMultiplier:           req.RateMultiplier,
+		IsExclusive:              req.IsExclusive,
+		SubscriptionType:         req.SubscriptionType,
+		DailyLimitUSD:            req.DailyLimitUSD,
+		WeeklyLimitUSD:           req.WeeklyLimitUSD,
+		MonthlyLimitUSD:          req.MonthlyLimitUSD,
+		SubscriptionPrice:        req.SubscriptionPrice,
+		ImagePrice1K:             req.ImagePrice1K,
+		ImagePrice2K:             req.ImagePrice2K,
+		ImagePrice4K:             req.ImagePrice4K,
+		ClaudeCodeOnly:           req.ClaudeCodeOnly,
+		FallbackGroupID:          req.FallbackGroupID,
+		ModelRouting:             req.ModelRouting,
+		ModelRoutingEnabled:      req.ModelRoutingEnabled,
+		AccountSelectionStrategy: req.AccountSelectionStrategy,
+		ImageModels:              req.ImageModels,
+		ContentPolicyAction:      req.ContentPolicyAction,
+		RequestRewriteRules:      dto.RequestRewriteRulesToService(req.RequestRewriteRules),
+		ModelAliases:             req.ModelAliases,
+		AllowedModels:            req.AllowedModels,
+		DeniedModels:             req.DeniedModels,
+		UsageLimits:              dto.SubscriptionUsageLimitsToService(req.UsageLimits),
 	})
 	if err != nil {
 		response.ErrorFrom(c, err)
@@ -195,24 +240,45 @@ func (h *GroupHandler) Update(c *gin.Context) {
 		return
 	}
 
+	var rewriteRules *[]service.RequestRewriteRule
+	if req.RequestRewriteRules != nil {
+		rules := dto.RequestRewriteRulesToService(*req.RequestRewriteRules)
+		rewriteRules = &rules
+	}
+
+	var usageLimits *[]service.SubscriptionUsageLimit
+	if req.UsageLimits != nil {
+		limits := dto.SubscriptionUsageLimitsToService(*req.UsageLimits)
+		usageLimits = &limits
+	}
+
 	group, err := h.adminService.UpdateGroup(c.Request.Context(), groupID, &service.UpdateGroupInput{
-		Name:                req.Name,
-		Description:         req.Description,
-		Platform:            req.Platform,
-		RateMultiplier:      req.RateMultiplier,
-		IsExclusive:         req.IsExclusive,
-		Status:              req.Status,
-		SubscriptionType:    req.SubscriptionType,
-		DailyLimitUSD:       req.DailyLimitUSD,
-		WeeklyLimitUSD:      req.WeeklyLimitUSD,
-		MonthlyLimitUSD:     req.MonthlyLimitUSD,
-		ImagePrice1K:        req.ImagePrice1K,
-		ImagePrice2K:        req.ImagePrice2K,
-		ImagePrice4K:        req.ImagePrice4K,
-		ClaudeCodeOnly:      req.ClaudeCodeOnly,
-		FallbackGroupID:     req.FallbackGroupID,
-		ModelRouting:        req.ModelRouting,
-		ModelRoutingEnabled: req.ModelRoutingEnabled,
+		Name:                     req.Name,
+		Description:              req.Description,
+		Platform:                 req.Platform,
+		RateMultiplier:           req.RateMultiplier,
+		IsExclusive:              req.IsExclusive,
+		Status:                   req.Status,
+		SubscriptionType:         req.SubscriptionType,
+		DailyLimitUSD:            req.DailyLimitUSD,
+		WeeklyLimitUSD:           req.WeeklyLimitUSD,
+		MonthlyLimitUSD:          req.MonthlyLimitUSD,
+		SubscriptionPrice:        req.SubscriptionPrice,
+		ImagePrice1K:             req.ImagePrice1K,
+		ImagePrice2K:             req.ImagePrice2K,
+		ImagePrice4K:             req.ImagePrice4K,
+		ClaudeCodeOnly:           req.ClaudeCodeOnly,
+		FallbackGroupID:          req.FallbackGroupID,
+		ModelRouting:             req.ModelRouting,
+		ModelRoutingEnabled:      req.ModelRoutingEnabled,
+		AccountSelectionStrategy: req.AccountSelectionStrategy,
+		ImageModels:              req.ImageModels,
+		ContentPolicyAction:      req.ContentPolicyAction,
+		RequestRewriteRules:      rewriteRules,
+		ModelAliases:             req.ModelAliases,
+		AllowedModels:            req.AllowedModels,
+		DeniedModels:             req.DeniedModels,
+		UsageLimits:              usageLimits,
 	})
 	if err != nil {
 		response.ErrorFrom(c, err)
@@ -259,6 +325,96 @@ func (h *GroupHandler) GetStats(c *gin.Context) {
 	_ = groupID // TODO: implement actual stats
 }
 
+// AccountSelectionDryRun shows which account each selection strategy would pick
+// GET /api/v1/admin/groups/:id/account-selection/dry-run?model=xxx
+func (h *GroupHandler) AccountSelectionDryRun(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid group ID")
+		return
+	}
+
+	result, err := h.accountSelection.DryRun(c.Request.Context(), groupID, strings.TrimSpace(c.Query("model")))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// RequestRewriteTestRequest represents a request rewrite preview request
+type RequestRewriteTestRequest struct {
+	// Format defaults to the group platform (openai → responses, gemini → gemini, otherwise anthropic)
+	Format string          `json:"format" binding:"omitempty,oneof=anthropic responses gemini"`
+	Model  string          `json:"model"`
+	Body   json.RawMessage `json:"body" binding:"required"`
+	// Rules previews unsaved rules instead of the group's current rules
+	Rules *[]dto.RequestRewriteRule `json:"rules" binding:"omitempty,dive"`
+}
+
+// TestRequestRewrite shows the transformed body for a sample request
+// POST /api/v1/admin/groups/:id/request-rewrite/test
+func (h *GroupHandler) TestRequestRewrite(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid group ID")
+		return
+	}
+
+	var req RequestRewriteTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if !json.Valid(req.Body) {
+		response.BadRequest(c, "body must be a JSON object")
+		return
+	}
+
+	group, err := h.adminService.GetGroup(c.Request.Context(), groupID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	rules := group.RequestRewriteRules
+	if req.Rules != nil {
+		rules = dto.RequestRewriteRulesToService(*req.Rules)
+		if err := service.ValidateRequestRewriteRules(group.Platform, rules); err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+	}
+
+	format := req.Format
+	if format == "" {
+		switch group.Platform {
+		case service.PlatformOpenAI:
+			format = service.RewriteFormatResponses
+		case service.PlatformGemini:
+			format = service.RewriteFormatGemini
+		default:
+			format = service.RewriteFormatAnthropic
+		}
+	}
+	model := strings.TrimSpace(req.Model)
+	if model == "" {
+		model = gjson.GetBytes(req.Body, "model").String()
+	}
+
+	out, changed, err := service.ApplyRequestRewriteRules(req.Body, format, model, rules)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Success(c, gin.H{
+		"format":  format,
+		"model":   model,
+		"changed": changed,
+		"body":    json.RawMessage(out),
+	})
+}
+
 // GetGroupAPIKeys handles getting API keys in a group
 // GET /api/v1/admin/groups/:id/api-keys
 func (h *GroupHandler) GetGroupAPIKeys(c *gin.Context) {
@@ -23,6 +23,12 @@ var validOpsAlertMetricTypes = []string{
 	"cpu_usage_percent",
 	"memory_usage_percent",
 	"concurrency_queue_depth",
+	service.OpsMetricSpendUSD,
+	service.OpsMetricMaxUserSpendUSD,
+	service.OpsMetricMaxAPIKeySpendUSD,
+	service.OpsMetricSpendAnomalyPendingCount,
+	service.OpsMetricGroupHoursToExhaustion,
+	service.OpsMetricGroupRecommendedAccounts,
 }
 
 var validOpsAlertMetricTypeSet = func() map[string]struct{} {
@@ -58,6 +64,7 @@ type opsAlertRuleValidatedInput struct {
 	MetricType string
 	Operator   string
 	Threshold  float64
+	Expression string
 
 	Severity string
 
@@ -94,7 +101,19 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 		return nil, fmt.Errorf("invalid request body")
 	}
 
+	var expression string
+	if v, ok := raw["expression"]; ok {
+		if err := json.Unmarshal(v, &expression); err != nil {
+			return nil, fmt.Errorf("expression must be a string")
+		}
+		expression = strings.TrimSpace(expression)
+	}
+
 	requiredFields := []string{"name", "metric_type", "operator", "threshold"}
+	if expression != "" {
+		// metric_type/operator/threshold come from the expression.
+		requiredFields = []string{"name"}
+	}
 	for _, field := range requiredFields {
 		if _, ok := raw[field]; !ok {
 			return nil, fmt.Errorf("%s is required", field)
@@ -107,6 +126,24 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 	}
 	name = strings.TrimSpace(name)
 
+	if expression != "" {
+		expr, err := service.ParseOpsAlertExpression(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression: %v", err)
+		}
+		validated := &opsAlertRuleValidatedInput{
+			Name:       name,
+			MetricType: service.OpsAlertMetricTypeExpression,
+			Operator:   expr.Operator,
+			Threshold:  expr.Threshold,
+			Expression: expr.String(),
+		}
+		if err := validateOpsAlertRuleOptions(raw, validated); err != nil {
+			return nil, err
+		}
+		return validated, nil
+	}
+
 	var metricType string
 	if err := json.Unmarshal(raw["metric_type"], &metricType); err != nil || strings.TrimSpace(metricType) == "" {
 		return nil, fmt.Errorf("metric_type is required")
@@ -146,17 +183,24 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 		Operator:   operator,
 		Threshold:  threshold,
 	}
+	if err := validateOpsAlertRuleOptions(raw, validated); err != nil {
+		return nil, err
+	}
+	return validated, nil
+}
 
+// validateOpsAlertRuleOptions validates the optional fields shared by metric and expression rules.
+func validateOpsAlertRuleOptions(raw map[string]json.RawMessage, validated *opsAlertRuleValidatedInput) error {
 	if v, ok := raw["severity"]; ok {
 		validated.SeverityProvided = true
 		var sev string
 		if err := json.Unmarshal(v, &sev); err != nil {
-			return nil, fmt.Errorf("severity must be a string")
+			return fmt.Errorf("severity must be a string")
 		}
 		sev = strings.ToUpper(strings.TrimSpace(sev))
 		if sev != "" {
 			if _, ok := validOpsAlertSeveritySet[sev]; !ok {
-				return nil, fmt.Errorf("severity must be one of: %s", strings.Join(validOpsAlertSeverities, ", "))
+				return fmt.Errorf("severity must be one of: %s", strings.Join(validOpsAlertSeverities, ", "))
 			}
 			validated.Severity = sev
 		}
@@ -168,7 +212,7 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 	if v, ok := raw["enabled"]; ok {
 		validated.EnabledProvided = true
 		if err := json.Unmarshal(v, &validated.Enabled); err != nil {
-			return nil, fmt.Errorf("enabled must be a boolean")
+			return fmt.Errorf("enabled must be a boolean")
 		}
 	} else {
 		validated.Enabled = true
@@ -177,7 +221,7 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 	if v, ok := raw["notify_email"]; ok {
 		validated.NotifyProvided = true
 		if err := json.Unmarshal(v, &validated.NotifyEmail); err != nil {
-			return nil, fmt.Errorf("notify_email must be a boolean")
+			return fmt.Errorf("notify_email must be a boolean")
 		}
 	} else {
 		validated.NotifyEmail = true
@@ -186,12 +230,12 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 	if v, ok := raw["window_minutes"]; ok {
 		validated.WindowProvided = true
 		if err := json.Unmarshal(v, &validated.WindowMinutes); err != nil {
-			return nil, fmt.Errorf("window_minutes must be an integer")
+			return fmt.Errorf("window_minutes must be an integer")
 		}
 		switch validated.WindowMinutes {
 		case 1, 5, 60:
 		default:
-			return nil, fmt.Errorf("window_minutes must be one of: 1, 5, 60")
+			return fmt.Errorf("window_minutes must be one of: 1, 5, 60")
 		}
 	} else {
 		validated.WindowMinutes = 1
@@ -200,10 +244,10 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 	if v, ok := raw["sustained_minutes"]; ok {
 		validated.SustainedProvided = true
 		if err := json.Unmarshal(v, &validated.SustainedMinutes); err != nil {
-			return nil, fmt.Errorf("sustained_minutes must be an integer")
+			return fmt.Errorf("sustained_minutes must be an integer")
 		}
 		if validated.SustainedMinutes < 1 || validated.SustainedMinutes > 1440 {
-			return nil, fmt.Errorf("sustained_minutes must be between 1 and 1440")
+			return fmt.Errorf("sustained_minutes must be between 1 and 1440")
 		}
 	} else {
 		validated.SustainedMinutes = 1
@@ -212,16 +256,16 @@ func validateOpsAlertRulePayload(raw map[string]json.RawMessage) (*opsAlertRuleV
 	if v, ok := raw["cooldown_minutes"]; ok {
 		validated.CooldownProvided = true
 		if err := json.Unmarshal(v, &validated.CooldownMinutes); err != nil {
-			return nil, fmt.Errorf("cooldown_minutes must be an integer")
+			return fmt.Errorf("cooldown_minutes must be an integer")
 		}
 		if validated.CooldownMinutes < 0 || validated.CooldownMinutes > 1440 {
-			return nil, fmt.Errorf("cooldown_minutes must be between 0 and 1440")
+			return fmt.Errorf("cooldown_minutes must be between 0 and 1440")
 		}
 	} else {
 		validated.CooldownMinutes = 0
 	}
 
-	return validated, nil
+	return nil
 }
 
 // ListAlertRules returns all ops alert rules.
@@ -283,6 +327,7 @@ func (h *OpsHandler) CreateAlertRule(c *gin.Context) {
 	rule.Severity = validated.Severity
 	rule.Enabled = validated.Enabled
 	rule.NotifyEmail = validated.NotifyEmail
+	rule.Expression = validated.Expression
 
 	created, err := h.opsService.CreateAlertRule(c.Request.Context(), &rule)
 	if err != nil {
@@ -338,6 +383,7 @@ func (h *OpsHandler) UpdateAlertRule(c *gin.Context) {
 	rule.Severity = validated.Severity
 	rule.Enabled = validated.Enabled
 	rule.NotifyEmail = validated.NotifyEmail
+	rule.Expression = validated.Expression
 
 	updated, err := h.opsService.UpdateAlertRule(c.Request.Context(), &rule)
 	if err != nil {
@@ -372,6 +418,74 @@ func (h *OpsHandler) DeleteAlertRule(c *gin.Context) {
 	response.Success(c, gin.H{"deleted": true})
 }
 
+// OpsAlertExpressionRequest is the body of the expression validate/preview endpoints.
+type OpsAlertExpressionRequest struct {
+	Expression       string `json:"expression" binding:"required"`
+	WindowMinutes    int    `json:"window_minutes"`
+	SustainedMinutes int    `json:"sustained_minutes"`
+	CooldownMinutes  int    `json:"cooldown_minutes"`
+}
+
+// ValidateAlertExpression parses an alert rule expression and returns its canonical form.
+// POST /api/v1/admin/ops/alert-rules/validate
+func (h *OpsHandler) ValidateAlertExpression(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	var req OpsAlertExpressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	expr, err := h.opsService.ValidateAlertExpression(req.Expression)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"expression": expr.String(), "parsed": expr})
+}
+
+// PreviewAlertExpression shows what an expression would have fired over the last 24h.
+// POST /api/v1/admin/ops/alert-rules/preview
+func (h *OpsHandler) PreviewAlertExpression(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	var req OpsAlertExpressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	switch req.WindowMinutes {
+	case 0:
+		req.WindowMinutes = 5
+	case 1, 5, 60:
+	default:
+		response.BadRequest(c, "window_minutes must be one of: 1, 5, 60")
+		return
+	}
+	if req.SustainedMinutes < 0 || req.SustainedMinutes > 1440 {
+		response.BadRequest(c, "sustained_minutes must be between 0 and 1440")
+		return
+	}
+	if req.CooldownMinutes < 0 || req.CooldownMinutes > 1440 {
+		response.BadRequest(c, "cooldown_minutes must be between 0 and 1440")
+		return
+	}
+
+	preview, err := h.opsService.PreviewAlertExpression(c.Request.Context(), req.Expression, req.WindowMinutes, req.SustainedMinutes, req.CooldownMinutes)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, preview)
+}
+
 // GetAlertEvent returns a single ops alert event.
 // GET /api/v1/admin/ops/alert-events/:id
 func (h *OpsHandler) GetAlertEvent(c *gin.Context) {
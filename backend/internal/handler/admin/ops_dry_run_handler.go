@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// opsDryRunRequest is the admin playground payload. Body is the inbound request body
+// exactly as a client would send it in the given format.
+type opsDryRunRequest struct {
+	AccountIDs []int64           `json:"account_ids" binding:"required"`
+	Format     string            `json:"format" binding:"required"`
+	Model      string            `json:"model"`
+	Stream     bool              `json:"stream"`
+	Headers    map[string]string `json:"headers"`
+	Body       json.RawMessage   `json:"body" binding:"required"`
+}
+
+// DryRunGatewayRequest shows the exact upstream request each selected account would receive
+// for an inbound request, without contacting any upstream.
+// POST /api/v1/admin/ops/dry-run
+func (h *OpsHandler) DryRunGatewayRequest(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+
+	var req opsDryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	results, err := h.opsService.DryRunGatewayRequest(c.Request.Context(), &service.OpsDryRunRequest{
+		AccountIDs: req.AccountIDs,
+		Format:     req.Format,
+		Model:      req.Model,
+		Stream:     req.Stream,
+		Headers:    req.Headers,
+		Body:       req.Body,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"results": results})
+}
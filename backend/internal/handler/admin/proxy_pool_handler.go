@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProxyPoolHandler handles admin proxy pool management
+type ProxyPoolHandler struct {
+	proxyPoolService *service.ProxyPoolService
+}
+
+// NewProxyPoolHandler creates a new admin proxy pool handler
+func NewProxyPoolHandler(proxyPoolService *service.ProxyPoolService) *ProxyPoolHandler {
+	return &ProxyPoolHandler{
+		proxyPoolService: proxyPoolService,
+	}
+}
+
+// CreateProxyPoolRequest represents create proxy pool request
+type CreateProxyPoolRequest struct {
+	Name        string  `json:"name" binding:"required,max=100"`
+	Description string  `json:"description"`
+	ProxyIDs    []int64 `json:"proxy_ids" binding:"required,min=1"`
+}
+
+// UpdateProxyPoolRequest represents update proxy pool request
+type UpdateProxyPoolRequest struct {
+	Name        *string  `json:"name" binding:"omitempty,max=100"`
+	Description *string  `json:"description"`
+	Status      *string  `json:"status" binding:"omitempty,oneof=active inactive"`
+	ProxyIDs    *[]int64 `json:"proxy_ids"`
+}
+
+// List handles listing all proxy pools with member health
+// GET /api/v1/admin/proxy-pools
+func (h *ProxyPoolHandler) List(c *gin.Context) {
+	pools, err := h.proxyPoolService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.ProxyPool, 0, len(pools))
+	for i := range pools {
+		out = append(out, *dto.ProxyPoolDetailFromService(&pools[i]))
+	}
+	response.Success(c, out)
+}
+
+// GetByID handles getting a proxy pool by ID
+// GET /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) GetByID(c *gin.Context) {
+	poolID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid proxy pool ID")
+		return
+	}
+
+	pool, err := h.proxyPoolService.Get(c.Request.Context(), poolID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.ProxyPoolDetailFromService(pool))
+}
+
+// Create handles creating a new proxy pool
+// POST /api/v1/admin/proxy-pools
+func (h *ProxyPoolHandler) Create(c *gin.Context) {
+	var req CreateProxyPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	pool, err := h.proxyPoolService.Create(c.Request.Context(), &service.CreateProxyPoolInput{
+		Name:        req.Name,
+		Description: req.Description,
+		ProxyIDs:    req.ProxyIDs,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.ProxyPoolFromService(pool))
+}
+
+// Update handles updating a proxy pool
+// PUT /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) Update(c *gin.Context) {
+	poolID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid proxy pool ID")
+		return
+	}
+
+	var req UpdateProxyPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	pool, err := h.proxyPoolService.Update(c.Request.Context(), poolID, &service.UpdateProxyPoolInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      req.Status,
+		ProxyIDs:    req.ProxyIDs,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.ProxyPoolFromService(pool))
+}
+
+// Delete handles deleting a proxy pool
+// DELETE /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) Delete(c *gin.Context) {
+	poolID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid proxy pool ID")
+		return
+	}
+
+	if err := h.proxyPoolService.Delete(c.Request.Context(), poolID); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Proxy pool deleted successfully"})
+}
+
+// Probe handles probing all members of a proxy pool immediately
+// POST /api/v1/admin/proxy-pools/:id/probe
+func (h *ProxyPoolHandler) Probe(c *gin.Context) {
+	poolID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid proxy pool ID")
+		return
+	}
+
+	pool, err := h.proxyPoolService.ProbeNow(c.Request.Context(), poolID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.ProxyPoolDetailFromService(pool))
+}
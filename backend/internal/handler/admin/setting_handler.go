@@ -20,15 +20,17 @@ type SettingHandler struct {
 	emailService     *service.EmailService
 	turnstileService *service.TurnstileService
 	opsService       *service.OpsService
+	twoFactorService *service.TwoFactorService
 }
 
 // NewSettingHandler 创建系统设置处理器
-func NewSettingHandler(settingService *service.SettingService, emailService *service.EmailService, turnstileService *service.TurnstileService, opsService *service.OpsService) *SettingHandler {
+func NewSettingHandler(settingService *service.SettingService, emailService *service.EmailService, turnstileService *service.TurnstileService, opsService *service.OpsService, twoFactorService *service.TwoFactorService) *SettingHandler {
 	return &SettingHandler{
 		settingService:   settingService,
 		emailService:     emailService,
 		turnstileService: turnstileService,
 		opsService:       opsService,
+		twoFactorService: twoFactorService,
 	}
 }
 
@@ -51,6 +53,7 @@ func (h *SettingHandler) GetSettings(c *gin.Context) {
 		PasswordResetEnabled:                 settings.PasswordResetEnabled,
 		TotpEnabled:                          settings.TotpEnabled,
 		TotpEncryptionKeyConfigured:          h.settingService.IsTotpEncryptionKeyConfigured(),
+		RequireAdmin2FA:                      settings.RequireAdmin2FA,
 		SMTPHost:                             settings.SMTPHost,
 		SMTPPort:                             settings.SMTPPort,
 		SMTPUsername:                         settings.SMTPUsername,
@@ -94,11 +97,12 @@ func (h *SettingHandler) GetSettings(c *gin.Context) {
 // UpdateSettingsRequest 更新设置请求
 type UpdateSettingsRequest struct {
 	// 注册设置
-	RegistrationEnabled  bool `json:"registration_enabled"`
-	EmailVerifyEnabled   bool `json:"email_verify_enabled"`
-	PromoCodeEnabled     bool `json:"promo_code_enabled"`
-	PasswordResetEnabled bool `json:"password_reset_enabled"`
-	TotpEnabled          bool `json:"totp_enabled"` // TOTP 双因素认证
+	RegistrationEnabled  bool  `json:"registration_enabled"`
+	EmailVerifyEnabled   bool  `json:"email_verify_enabled"`
+	PromoCodeEnabled     bool  `json:"promo_code_enabled"`
+	PasswordResetEnabled bool  `json:"password_reset_enabled"`
+	TotpEnabled          bool  `json:"totp_enabled"`      // TOTP 双因素认证
+	RequireAdmin2FA      *bool `json:"require_admin_2fa"` // 管理员强制 2FA（未传时保持不变）
 
 	// 邮件服务设置
 	SMTPHost     string `json:"smtp_host"`
@@ -217,6 +221,23 @@ func (h *SettingHandler) UpdateSettings(c *gin.Context) {
 		}
 	}
 
+	// 开启管理员强制 2FA 前，要求当前管理员自身已配置第二因素
+	requireAdmin2FA := previousSettings.RequireAdmin2FA
+	if req.RequireAdmin2FA != nil {
+		requireAdmin2FA = *req.RequireAdmin2FA
+	}
+	if requireAdmin2FA && !previousSettings.RequireAdmin2FA && h.twoFactorService != nil {
+		subject, ok := middleware.GetAuthSubjectFromContext(c)
+		if !ok {
+			response.Unauthorized(c, "User not authenticated")
+			return
+		}
+		if err := h.twoFactorService.EnsureCanRequireAdmin2FA(c.Request.Context(), subject.UserID); err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+	}
+
 	// LinuxDo Connect 参数验证
 	if req.LinuxDoConnectEnabled {
 		req.LinuxDoConnectClientID = strings.TrimSpace(req.LinuxDoConnectClientID)
@@ -292,6 +313,7 @@ func (h *SettingHandler) UpdateSettings(c *gin.Context) {
 		PromoCodeEnabled:            req.PromoCodeEnabled,
 		PasswordResetEnabled:        req.PasswordResetEnabled,
 		TotpEnabled:                 req.TotpEnabled,
+		RequireAdmin2FA:             requireAdmin2FA,
 		SMTPHost:                    req.SMTPHost,
 		SMTPPort:                    req.SMTPPort,
 		SMTPUsername:                req.SMTPUsername,
@@ -372,6 +394,7 @@ func (h *SettingHandler) UpdateSettings(c *gin.Context) {
 		PasswordResetEnabled:                 updatedSettings.PasswordResetEnabled,
 		TotpEnabled:                          updatedSettings.TotpEnabled,
 		TotpEncryptionKeyConfigured:          h.settingService.IsTotpEncryptionKeyConfigured(),
+		RequireAdmin2FA:                      updatedSettings.RequireAdmin2FA,
 		SMTPHost:                             updatedSettings.SMTPHost,
 		SMTPPort:                             updatedSettings.SMTPPort,
 		SMTPUsername:                         updatedSettings.SMTPUsername,
@@ -446,6 +469,9 @@ func diffSettings(before *service.SystemSettings, after *service.SystemSettings,
 	if before.TotpEnabled != after.TotpEnabled {
 		changed = append(changed, "totp_enabled")
 	}
+	if before.RequireAdmin2FA != after.RequireAdmin2FA {
+		changed = append(changed, "require_admin_2fa")
+	}
 	if before.SMTPHost != after.SMTPHost {
 		changed = append(changed, "smtp_host")
 	}
@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SpendAnomalyHandler handles the admin review queue for spend anomaly flags
+type SpendAnomalyHandler struct {
+	spendAnomalyService *service.SpendAnomalyService
+}
+
+// NewSpendAnomalyHandler creates a new admin spend anomaly handler
+func NewSpendAnomalyHandler(spendAnomalyService *service.SpendAnomalyService) *SpendAnomalyHandler {
+	return &SpendAnomalyHandler{
+		spendAnomalyService: spendAnomalyService,
+	}
+}
+
+// ReviewSpendAnomalyRequest represents a review decision for a flagged key/user
+type ReviewSpendAnomalyRequest struct {
+	Decision string `json:"decision" binding:"required,oneof=confirm dismiss"`
+	Note     string `json:"note" binding:"max=1000"`
+}
+
+// List handles listing spend anomaly flags
+// GET /api/v1/admin/spend-anomalies
+func (h *SpendAnomalyHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+
+	filter := service.SpendAnomalyFlagFilter{
+		Status: strings.TrimSpace(c.Query("status")),
+		Kind:   strings.TrimSpace(c.Query("kind")),
+	}
+	if v := strings.TrimSpace(c.Query("user_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filter.UserID = &id
+	}
+	if v := strings.TrimSpace(c.Query("api_key_id")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid api_key_id")
+			return
+		}
+		filter.APIKeyID = &id
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	flags, result, err := h.spendAnomalyService.ListFlags(c.Request.Context(), params, filter)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SpendAnomalyFlag, 0, len(flags))
+	for i := range flags {
+		out = append(out, *dto.SpendAnomalyFlagFromService(&flags[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetByID handles getting a single spend anomaly flag
+// GET /api/v1/admin/spend-anomalies/:id
+func (h *SpendAnomalyHandler) GetByID(c *gin.Context) {
+	flagID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid flag ID")
+		return
+	}
+
+	flag, err := h.spendAnomalyService.GetFlag(c.Request.Context(), flagID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.SpendAnomalyFlagFromService(flag))
+}
+
+// Review handles confirming or dismissing a spend anomaly flag
+// POST /api/v1/admin/spend-anomalies/:id/review
+func (h *SpendAnomalyHandler) Review(c *gin.Context) {
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok || subject.UserID <= 0 {
+		response.Error(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	flagID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid flag ID")
+		return
+	}
+
+	var req ReviewSpendAnomalyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	flag, err := h.spendAnomalyService.ReviewFlag(c.Request.Context(), flagID, req.Decision, subject.UserID, req.Note)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, dto.SpendAnomalyFlagFromService(flag))
+}
+
+// Detect handles running one detection pass immediately
+// POST /api/v1/admin/spend-anomalies/detect
+func (h *SpendAnomalyHandler) Detect(c *gin.Context) {
+	created, err := h.spendAnomalyService.DetectOnce(c.Request.Context(), time.Now())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"created": created})
+}
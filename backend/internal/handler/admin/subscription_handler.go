@@ -25,6 +25,17 @@ func toResponsePagination(p *pagination.PaginationResult) *response.PaginationRe
 	}
 }
 
+// SetAutoRenewRequest represents an auto-renew toggle request
+type SetAutoRenewRequest struct {
+	AutoRenew *bool `json:"auto_renew" binding:"required"`
+}
+
+// ChangePlanRequest represents a subscription plan change request
+type ChangePlanRequest struct {
+	GroupID int64 `json:"group_id" binding:"required"`
+	DryRun  bool  `json:"dry_run"`
+}
+
 // SubscriptionHandler handles admin subscription management
 type SubscriptionHandler struct {
 	subscriptionService *service.SubscriptionService
@@ -280,3 +291,119 @@ func getAdminIDFromContext(c *gin.Context) int64 {
 	}
 	return subject.UserID
 }
+
+// SetAutoRenew handles toggling auto-renewal of a subscription
+// PUT /api/v1/admin/subscriptions/:id/auto-renew
+func (h *SubscriptionHandler) SetAutoRenew(c *gin.Context) {
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	var req SetAutoRenewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	subscription, err := h.subscriptionService.SetAutoRenew(c.Request.Context(), subscriptionID, 0, *req.AutoRenew)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserSubscriptionFromServiceAdmin(subscription))
+}
+
+// ChangePlan handles moving a subscription to another group with prorated billing
+// POST /api/v1/admin/subscriptions/:id/change
+func (h *SubscriptionHandler) ChangePlan(c *gin.Context) {
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	var req ChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if req.DryRun {
+		quote, err := h.subscriptionService.QuotePlanChange(c.Request.Context(), subscriptionID, 0, req.GroupID)
+		if err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+		response.Success(c, gin.H{"quote": quote})
+		return
+	}
+
+	subscription, quote, err := h.subscriptionService.ChangePlan(c.Request.Context(), subscriptionID, 0, req.GroupID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"subscription": dto.UserSubscriptionFromServiceAdmin(subscription),
+		"quote":        quote,
+	})
+}
+
+// Pause handles pausing a subscription
+// POST /api/v1/admin/subscriptions/:id/pause
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	subscription, err := h.subscriptionService.PauseSubscription(c.Request.Context(), subscriptionID, 0)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserSubscriptionFromServiceAdmin(subscription))
+}
+
+// Resume handles resuming a paused subscription
+// POST /api/v1/admin/subscriptions/:id/resume
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	subscription, err := h.subscriptionService.ResumeSubscription(c.Request.Context(), subscriptionID, 0)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserSubscriptionFromServiceAdmin(subscription))
+}
+
+// History handles listing renewal/plan change history of a subscription
+// GET /api/v1/admin/subscriptions/:id/history
+func (h *SubscriptionHandler) History(c *gin.Context) {
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	entries, pagination, err := h.subscriptionService.ListSubscriptionHistory(c.Request.Context(), subscriptionID, 0, page, pageSize)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SubscriptionHistoryEntry, 0, len(entries))
+	for i := range entries {
+		out = append(out, *dto.SubscriptionHistoryEntryFromService(&entries[i]))
+	}
+	response.PaginatedWithResult(c, out, toResponsePagination(pagination))
+}
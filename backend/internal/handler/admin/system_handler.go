@@ -1,9 +1,11 @@
 package admin
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/sysutil"
 	"github.com/Wei-Shaw/sub2api/internal/service"
@@ -85,3 +87,38 @@ func (h *SystemHandler) RestartService(c *gin.Context) {
 		"message": "Service restart initiated",
 	})
 }
+
+// GetLogLevel returns the current log level and output format of this node
+// GET /api/v1/admin/system/log-level
+func (h *SystemHandler) GetLogLevel(c *gin.Context) {
+	response.Success(c, gin.H{
+		"level":  logger.Level(),
+		"format": logger.Format(),
+	})
+}
+
+// SetLogLevelRequest is the body of SetLogLevel
+type SetLogLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// SetLogLevel changes the log level of this node at runtime.
+// The change is not persisted and is lost on restart; in a multi-node
+// deployment it only applies to the node that served the request.
+// PUT /api/v1/admin/system/log-level
+func (h *SystemHandler) SetLogLevel(c *gin.Context) {
+	var req SetLogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	if err := logger.SetLevel(req.Level); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	slog.InfoContext(c.Request.Context(), "log_level_changed", "level", logger.Level())
+	response.Success(c, gin.H{
+		"level":  logger.Level(),
+		"format": logger.Format(),
+	})
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserSessionHandler handles admin management of user login sessions
+type UserSessionHandler struct {
+	sessionService *service.SessionService
+}
+
+// NewUserSessionHandler creates a new admin user session handler
+func NewUserSessionHandler(sessionService *service.SessionService) *UserSessionHandler {
+	return &UserSessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// List returns the active login sessions of a user
+// GET /api/v1/admin/users/:id/sessions
+func (h *UserSessionHandler) List(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	sessions, err := h.sessionService.ListActive(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.UserSession, 0, len(sessions))
+	for i := range sessions {
+		out = append(out, *dto.UserSessionFromService(&sessions[i], ""))
+	}
+	response.Success(c, out)
+}
+
+// Revoke revokes a single session of a user
+// DELETE /api/v1/admin/users/:id/sessions/:session_id
+func (h *UserSessionHandler) Revoke(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.sessionService.Revoke(c.Request.Context(), userID, c.Param("session_id"), service.SessionRevokeReasonAdminForce); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Session revoked successfully"})
+}
+
+// ForceLogout revokes all sessions of a user
+// POST /api/v1/admin/users/:id/sessions/revoke
+func (h *UserSessionHandler) ForceLogout(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeAllForUser(c.Request.Context(), userID, "", service.SessionRevokeReasonAdminForce)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"revoked": revoked})
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
 	"log/slog"
+	"strings"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
@@ -21,10 +24,12 @@ type AuthHandler struct {
 	settingSvc   *service.SettingService
 	promoService *service.PromoService
 	totpService  *service.TotpService
+	twoFactorSvc *service.TwoFactorService
+	webAuthnSvc  *service.WebAuthnService
 }
 
 // NewAuthHandler creates a new AuthHandler
-func NewAuthHandler(cfg *config.Config, authService *service.AuthService, userService *service.UserService, settingService *service.SettingService, promoService *service.PromoService, totpService *service.TotpService) *AuthHandler {
+func NewAuthHandler(cfg *config.Config, authService *service.AuthService, userService *service.UserService, settingService *service.SettingService, promoService *service.PromoService, totpService *service.TotpService, twoFactorService *service.TwoFactorService, webAuthnService *service.WebAuthnService) *AuthHandler {
 	return &AuthHandler{
 		cfg:          cfg,
 		authService:  authService,
@@ -32,6 +37,8 @@ func NewAuthHandler(cfg *config.Config, authService *service.AuthService, userSe
 		settingSvc:   settingService,
 		promoService: promoService,
 		totpService:  totpService,
+		twoFactorSvc: twoFactorService,
+		webAuthnSvc:  webAuthnService,
 	}
 }
 
@@ -87,7 +94,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		}
 	}
 
-	token, user, err := h.authService.RegisterWithVerification(c.Request.Context(), req.Email, req.Password, req.VerifyCode, req.PromoCode)
+	token, user, err := h.authService.RegisterWithVerification(sessionClientContext(c), req.Email, req.Password, req.VerifyCode, req.PromoCode)
 	if err != nil {
 		response.ErrorFrom(c, err)
 		return
@@ -142,47 +149,54 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	user, err := h.authService.Authenticate(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
 		response.ErrorFrom(c, err)
 		return
 	}
 
-	// Check if TOTP 2FA is enabled for this user
-	if h.totpService != nil && h.settingSvc.IsTotpEnabled(c.Request.Context()) && user.TotpEnabled {
-		// Create a temporary login session for 2FA
-		tempToken, err := h.totpService.CreateLoginSession(c.Request.Context(), user.ID, user.Email)
+	// Check if 2FA (TOTP / WebAuthn) is enabled for this user
+	if h.totpService != nil && h.twoFactorSvc != nil {
+		methods, err := h.twoFactorSvc.LoginMethods(c.Request.Context(), user)
 		if err != nil {
-			response.InternalError(c, "Failed to create 2FA session")
+			response.ErrorFrom(c, err)
+			return
+		}
+		if len(methods) > 0 {
+			// Create a temporary login session for 2FA
+			tempToken, err := h.totpService.CreateLoginSession(c.Request.Context(), user.ID, user.Email)
+			if err != nil {
+				response.InternalError(c, "Failed to create 2FA session")
+				return
+			}
+
+			response.Success(c, TotpLoginResponse{
+				Requires2FA:     true,
+				TempToken:       tempToken,
+				UserEmailMasked: service.MaskEmail(user.Email),
+				Methods:         methods,
+			})
 			return
 		}
-
-		response.Success(c, TotpLoginResponse{
-			Requires2FA:     true,
-			TempToken:       tempToken,
-			UserEmailMasked: service.MaskEmail(user.Email),
-		})
-		return
 	}
 
-	response.Success(c, AuthResponse{
-		AccessToken: token,
-		TokenType:   "Bearer",
-		User:        dto.UserFromService(user),
-	})
+	h.respondWithToken(c, user)
 }
 
 // TotpLoginResponse represents the response when 2FA is required
 type TotpLoginResponse struct {
-	Requires2FA     bool   `json:"requires_2fa"`
-	TempToken       string `json:"temp_token,omitempty"`
-	UserEmailMasked string `json:"user_email_masked,omitempty"`
+	Requires2FA     bool     `json:"requires_2fa"`
+	TempToken       string   `json:"temp_token,omitempty"`
+	UserEmailMasked string   `json:"user_email_masked,omitempty"`
+	Methods         []string `json:"methods,omitempty"` // 可用的第二因素：totp / webauthn / recovery_code
 }
 
 // Login2FARequest represents the 2FA login request
+// Exactly one of totp_code and recovery_code is required
 type Login2FARequest struct {
-	TempToken string `json:"temp_token" binding:"required"`
-	TotpCode  string `json:"totp_code" binding:"required,len=6"`
+	TempToken    string `json:"temp_token" binding:"required"`
+	TotpCode     string `json:"totp_code" binding:"omitempty,len=6"`
+	RecoveryCode string `json:"recovery_code"`
 }
 
 // Login2FA completes the login with 2FA verification
@@ -193,10 +207,15 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 		response.BadRequest(c, "Invalid request: "+err.Error())
 		return
 	}
+	if req.TotpCode == "" && strings.TrimSpace(req.RecoveryCode) == "" {
+		response.BadRequest(c, "Either totp_code or recovery_code is required")
+		return
+	}
 
 	slog.Debug("login_2fa_request",
 		"temp_token_len", len(req.TempToken),
-		"totp_code_len", len(req.TotpCode))
+		"totp_code_len", len(req.TotpCode),
+		"use_recovery_code", req.TotpCode == "")
 
 	// Get the login session
 	session, err := h.totpService.GetLoginSession(c.Request.Context(), req.TempToken)
@@ -216,8 +235,14 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 		"user_id", session.UserID,
 		"email", session.Email)
 
-	// Verify the TOTP code
-	if err := h.totpService.VerifyCode(c.Request.Context(), session.UserID, req.TotpCode); err != nil {
+	// Verify the TOTP code, or consume a one-time recovery code
+	var verifyErr error
+	if req.TotpCode != "" {
+		verifyErr = h.totpService.VerifyCode(c.Request.Context(), session.UserID, req.TotpCode)
+	} else {
+		verifyErr = h.twoFactorSvc.VerifyRecoveryCode(c.Request.Context(), session.UserID, req.RecoveryCode)
+	}
+	if err := verifyErr; err != nil {
 		slog.Debug("login_2fa_verify_failed",
 			"user_id", session.UserID,
 			"error", err)
@@ -235,8 +260,137 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 		return
 	}
 
-	// Generate the JWT token
-	token, err := h.authService.GenerateToken(user)
+	h.respondWithToken(c, user)
+}
+
+// Login2FAWebAuthnBeginRequest represents the request to start a security key assertion during 2FA login
+type Login2FAWebAuthnBeginRequest struct {
+	TempToken string `json:"temp_token" binding:"required"`
+}
+
+// Login2FAWebAuthnBegin returns the assertion options for navigator.credentials.get()
+// POST /api/v1/auth/login/2fa/webauthn/begin
+func (h *AuthHandler) Login2FAWebAuthnBegin(c *gin.Context) {
+	var req Login2FAWebAuthnBeginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	session, err := h.totpService.GetLoginSession(c.Request.Context(), req.TempToken)
+	if err != nil || session == nil {
+		response.BadRequest(c, "Invalid or expired 2FA session")
+		return
+	}
+
+	assertion, err := h.webAuthnSvc.BeginLogin(c.Request.Context(), session.UserID, req.TempToken)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, assertion)
+}
+
+// Login2FAWebAuthnFinishRequest represents the assertion response from the browser during 2FA login
+type Login2FAWebAuthnFinishRequest struct {
+	TempToken  string          `json:"temp_token" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// Login2FAWebAuthnFinish verifies the security key assertion and completes the login
+// POST /api/v1/auth/login/2fa/webauthn/finish
+func (h *AuthHandler) Login2FAWebAuthnFinish(c *gin.Context) {
+	var req Login2FAWebAuthnFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	session, err := h.totpService.GetLoginSession(c.Request.Context(), req.TempToken)
+	if err != nil || session == nil {
+		response.BadRequest(c, "Invalid or expired 2FA session")
+		return
+	}
+
+	if err := h.webAuthnSvc.FinishLogin(c.Request.Context(), session.UserID, req.TempToken, req.Credential); err != nil {
+		slog.Debug("login_2fa_webauthn_failed",
+			"user_id", session.UserID,
+			"error", err)
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	_ = h.totpService.DeleteLoginSession(c.Request.Context(), req.TempToken)
+
+	user, err := h.userService.GetByID(c.Request.Context(), session.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	h.respondWithToken(c, user)
+}
+
+// PasskeyLoginBeginRequest represents the request to start a passwordless passkey login
+type PasskeyLoginBeginRequest struct {
+	TurnstileToken string `json:"turnstile_token"`
+}
+
+// PasskeyLoginBegin returns the assertion options for a discoverable credential login
+// POST /api/v1/auth/passkey/begin
+func (h *AuthHandler) PasskeyLoginBegin(c *gin.Context) {
+	var req PasskeyLoginBeginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// Allow empty body (optional params)
+		req = PasskeyLoginBeginRequest{}
+	}
+
+	// Turnstile 验证
+	if err := h.authService.VerifyTurnstile(c.Request.Context(), req.TurnstileToken, ip.GetClientIP(c)); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	ceremonyID, assertion, err := h.webAuthnSvc.BeginPasskeyLogin(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"ceremony_id": ceremonyID,
+		"options":     assertion,
+	})
+}
+
+// PasskeyLoginFinishRequest represents the assertion response for a passwordless passkey login
+type PasskeyLoginFinishRequest struct {
+	CeremonyID string          `json:"ceremony_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// PasskeyLoginFinish verifies the passkey assertion and issues a token
+// POST /api/v1/auth/passkey/finish
+func (h *AuthHandler) PasskeyLoginFinish(c *gin.Context) {
+	var req PasskeyLoginFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	user, err := h.webAuthnSvc.FinishPasskeyLogin(c.Request.Context(), req.CeremonyID, req.Credential)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	h.respondWithToken(c, user)
+}
+
+// respondWithToken 为完成认证的用户签发 JWT 并返回登录响应
+func (h *AuthHandler) respondWithToken(c *gin.Context, user *service.User) {
+	token, err := h.authService.IssueToken(sessionClientContext(c), user)
 	if err != nil {
 		response.InternalError(c, "Failed to generate token")
 		return
@@ -249,6 +403,52 @@ func (h *AuthHandler) Login2FA(c *gin.Context) {
 	})
 }
 
+// RefreshToken exchanges the current (possibly expired) token for a new one while its session is still valid
+// POST /api/v1/auth/refresh
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" || strings.TrimSpace(parts[1]) == "" {
+		response.Unauthorized(c, "Authorization header format must be 'Bearer {token}'")
+		return
+	}
+
+	token, err := h.authService.RefreshToken(sessionClientContext(c), strings.TrimSpace(parts[1]))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+	})
+}
+
+// Logout revokes the session bound to the current token
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), subject.UserID, middleware2.GetSessionIDFromContext(c)); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Logged out successfully"})
+}
+
+// sessionClientContext 携带客户端 IP/User-Agent 的 context，用于签发 token 时记录登录会话
+func sessionClientContext(c *gin.Context) context.Context {
+	return service.WithSessionClientInfo(c.Request.Context(), service.SessionClientInfo{
+		IPAddress: ip.GetClientIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+}
+
 // GetCurrentUser handles getting current authenticated user
 // GET /api/v1/auth/me
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
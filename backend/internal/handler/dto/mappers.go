@@ -105,10 +105,19 @@ func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
 		return nil
 	}
 	out := &AdminGroup{
-		Group:               groupFromServiceBase(g),
-		ModelRouting:        g.ModelRouting,
-		ModelRoutingEnabled: g.ModelRoutingEnabled,
-		AccountCount:        g.AccountCount,
+		Group:                    groupFromServiceBase(g),
+		SubscriptionPrice:        g.SubscriptionPrice,
+		ModelRouting:             g.ModelRouting,
+		ModelRoutingEnabled:      g.ModelRoutingEnabled,
+		AccountCount:             g.AccountCount,
+		AccountSelectionStrategy: g.AccountSelectionStrategy,
+		ImageModels:              g.ImageModels,
+		ContentPolicyAction:      g.ContentPolicyAction,
+		RequestRewriteRules:      RequestRewriteRulesFromService(g.RequestRewriteRules),
+		ModelAliases:             g.ModelAliases,
+		AllowedModels:            g.AllowedModels,
+		DeniedModels:             g.DeniedModels,
+		UsageLimits:              SubscriptionUsageLimitsFromService(g.UsageLimits),
 	}
 	if len(g.AccountGroups) > 0 {
 		out.AccountGroups = make([]AccountGroup, 0, len(g.AccountGroups))
@@ -156,6 +165,7 @@ func AccountFromServiceShallow(a *service.Account) *Account {
 		Credentials:             a.Credentials,
 		Extra:                   a.Extra,
 		ProxyID:                 a.ProxyID,
+		ProxyPoolID:             a.ProxyPoolID,
 		Concurrency:             a.Concurrency,
 		Priority:                a.Priority,
 		RateMultiplier:          a.BillingRateMultiplier(),
@@ -284,7 +294,171 @@ func ProxyWithAccountCountFromService(p *service.ProxyWithAccountCount) *ProxyWi
 		CountryCode:    p.CountryCode,
 		Region:         p.Region,
 		City:           p.City,
+
+		ConsecutiveFailures: p.ConsecutiveFailures,
+	}
+}
+
+func ProxyPoolFromService(p *service.ProxyPool) *ProxyPool {
+	if p == nil {
+		return nil
+	}
+	proxyIDs := p.ProxyIDs
+	if proxyIDs == nil {
+		proxyIDs = []int64{}
+	}
+	return &ProxyPool{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Status:      p.Status,
+		ProxyIDs:    proxyIDs,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+func RequestRewriteRulesFromService(rules []service.RequestRewriteRule) []RequestRewriteRule {
+	out := make([]RequestRewriteRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, RequestRewriteRule(r))
+	}
+	return out
+}
+
+// RequestRewriteRulesToService converts request rewrite rules from API input; nil stays nil.
+func RequestRewriteRulesToService(rules []RequestRewriteRule) []service.RequestRewriteRule {
+	if rules == nil {
+		return nil
+	}
+	out := make([]service.RequestRewriteRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, service.RequestRewriteRule(r))
+	}
+	return out
+}
+
+func SubscriptionUsageLimitsFromService(limits []service.SubscriptionUsageLimit) []SubscriptionUsageLimit {
+	out := make([]SubscriptionUsageLimit, 0, len(limits))
+	for _, l := range limits {
+		out = append(out, SubscriptionUsageLimit(l))
+	}
+	return out
+}
+
+// SubscriptionUsageLimitsToService converts usage limits from API input; nil stays nil.
+func SubscriptionUsageLimitsToService(limits []SubscriptionUsageLimit) []service.SubscriptionUsageLimit {
+	if limits == nil {
+		return nil
+	}
+	out := make([]service.SubscriptionUsageLimit, 0, len(limits))
+	for _, l := range limits {
+		out = append(out, service.SubscriptionUsageLimit(l))
+	}
+	return out
+}
+
+func ContentPolicyRuleFromService(r *service.ContentPolicyRule) *ContentPolicyRule {
+	if r == nil {
+		return nil
+	}
+	return &ContentPolicyRule{
+		ID:          r.ID,
+		Name:        r.Name,
+		MatchType:   r.MatchType,
+		Pattern:     r.Pattern,
+		Description: r.Description,
+		Enabled:     r.Enabled,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+}
+
+func ContentPolicyHitFromService(h *service.ContentPolicyHit) *ContentPolicyHit {
+	if h == nil {
+		return nil
+	}
+	return &ContentPolicyHit{
+		ID:         h.ID,
+		UserID:     h.UserID,
+		APIKeyID:   h.APIKeyID,
+		GroupID:    h.GroupID,
+		RuleID:     h.RuleID,
+		RuleName:   h.RuleName,
+		Source:     h.Source,
+		Action:     h.Action,
+		Platform:   h.Platform,
+		Model:      h.Model,
+		Excerpt:    h.Excerpt,
+		Categories: h.Categories,
+		ReviewedAt: h.ReviewedAt,
+		ReviewedBy: h.ReviewedBy,
+		CreatedAt:  h.CreatedAt,
+	}
+}
+
+func SpendAnomalyFlagFromService(f *service.SpendAnomalyFlag) *SpendAnomalyFlag {
+	if f == nil {
+		return nil
+	}
+	out := &SpendAnomalyFlag{
+		ID:            f.ID,
+		UserID:        f.UserID,
+		APIKeyID:      f.APIKeyID,
+		Kind:          f.Kind,
+		Action:        f.Action,
+		Status:        f.Status,
+		CurrentValue:  f.CurrentValue,
+		BaselineValue: f.BaselineValue,
+		WindowStart:   f.WindowStart,
+		WindowEnd:     f.WindowEnd,
+		Details:       f.Details,
+		ReviewNote:    f.ReviewNote,
+		ReviewedAt:    f.ReviewedAt,
+		ReviewedBy:    f.ReviewedBy,
+		CreatedAt:     f.CreatedAt,
+	}
+	if f.ActionState != nil {
+		out.ActionState = &SpendAnomalyActionState{
+			DisabledAPIKeyIDs:   f.ActionState.DisabledAPIKeyIDs,
+			PreviousConcurrency: f.ActionState.PreviousConcurrency,
+			LoweredConcurrency:  f.ActionState.LoweredConcurrency,
+		}
 	}
+	return out
+}
+
+func ProxyPoolDetailFromService(d *service.ProxyPoolDetail) *ProxyPool {
+	if d == nil {
+		return nil
+	}
+	out := ProxyPoolFromService(&d.ProxyPool)
+	out.AccountCount = d.AccountCount
+	out.Members = make([]ProxyPoolMember, 0, len(d.Members))
+	for i := range d.Members {
+		m := &d.Members[i]
+		member := ProxyPoolMember{
+			Proxy:   *ProxyFromService(&m.Proxy),
+			Healthy: m.Healthy,
+		}
+		if info := m.Health; info != nil {
+			if info.Success {
+				member.LatencyStatus = "success"
+				member.LatencyMs = info.LatencyMs
+			} else {
+				member.LatencyStatus = "failed"
+			}
+			member.LatencyMessage = info.Message
+			member.IPAddress = info.IPAddress
+			member.Country = info.Country
+			member.CountryCode = info.CountryCode
+			member.ConsecutiveFailures = info.ConsecutiveFailures
+			checkedAt := info.UpdatedAt
+			member.CheckedAt = &checkedAt
+		}
+		out.Members = append(out.Members, member)
+	}
+	return out
 }
 
 func ProxyAccountSummaryFromService(a *service.ProxyAccountSummary) *ProxyAccountSummary {
@@ -408,6 +582,7 @@ func UsageLogFromServiceAdmin(l *service.UsageLog) *AdminUsageLog {
 		UsageLog:              usageLogFromServiceUser(l),
 		AccountRateMultiplier: l.AccountRateMultiplier,
 		IPAddress:             l.IPAddress,
+		TraceID:               l.TraceID,
 		Account:               AccountSummaryFromService(l.Account),
 	}
 }
@@ -491,6 +666,8 @@ func userSubscriptionFromServiceBase(sub *service.UserSubscription) UserSubscrip
 		DailyUsageUSD:      sub.DailyUsageUSD,
 		WeeklyUsageUSD:     sub.WeeklyUsageUSD,
 		MonthlyUsageUSD:    sub.MonthlyUsageUSD,
+		AutoRenew:          sub.AutoRenew,
+		PausedAt:           sub.PausedAt,
 		CreatedAt:          sub.CreatedAt,
 		UpdatedAt:          sub.UpdatedAt,
 		User:               UserFromServiceShallow(sub.User),
@@ -498,6 +675,25 @@ func userSubscriptionFromServiceBase(sub *service.UserSubscription) UserSubscrip
 	}
 }
 
+func SubscriptionHistoryEntryFromService(e *service.SubscriptionHistoryEntry) *SubscriptionHistoryEntry {
+	if e == nil {
+		return nil
+	}
+	return &SubscriptionHistoryEntry{
+		ID:                e.ID,
+		SubscriptionID:    e.SubscriptionID,
+		Action:            e.Action,
+		FromGroupID:       e.FromGroupID,
+		ToGroupID:         e.ToGroupID,
+		Amount:            e.Amount,
+		Credit:            e.Credit,
+		PreviousExpiresAt: e.PreviousExpiresAt,
+		NewExpiresAt:      e.NewExpiresAt,
+		Note:              e.Note,
+		CreatedAt:         e.CreatedAt,
+	}
+}
+
 func BulkAssignResultFromService(r *service.BulkAssignResult) *BulkAssignResult {
 	if r == nil {
 		return nil
@@ -545,3 +741,32 @@ func PromoCodeUsageFromService(u *service.PromoCodeUsage) *PromoCodeUsage {
 		User:        UserFromServiceShallow(u.User),
 	}
 }
+
+func UserSessionFromService(s *service.UserSession, currentSessionID string) *UserSession {
+	if s == nil {
+		return nil
+	}
+	return &UserSession{
+		SessionID:    s.SessionID,
+		IPAddress:    s.IPAddress,
+		UserAgent:    s.UserAgent,
+		DeviceName:   s.DeviceName,
+		CreatedAt:    s.CreatedAt,
+		LastActiveAt: s.LastActiveAt,
+		ExpiresAt:    s.ExpiresAt,
+		Current:      currentSessionID != "" && s.SessionID == currentSessionID,
+	}
+}
+
+func WebAuthnCredentialFromService(c *service.WebAuthnCredential) *WebAuthnCredential {
+	if c == nil {
+		return nil
+	}
+	return &WebAuthnCredential{
+		ID:           c.ID,
+		Name:         c.Name,
+		Discoverable: c.Discoverable,
+		CreatedAt:    c.CreatedAt,
+		LastUsedAt:   c.LastUsedAt,
+	}
+}
@@ -8,6 +8,7 @@ type SystemSettings struct {
 	PasswordResetEnabled        bool `json:"password_reset_enabled"`
 	TotpEnabled                 bool `json:"totp_enabled"`                   // TOTP 双因素认证
 	TotpEncryptionKeyConfigured bool `json:"totp_encryption_key_configured"` // TOTP 加密密钥是否已配置
+	RequireAdmin2FA             bool `json:"require_admin_2fa"`              // 管理员强制 2FA
 
 	SMTPHost               string `json:"smtp_host"`
 	SMTPPort               int    `json:"smtp_port"`
@@ -64,6 +65,8 @@ type PublicSettings struct {
 	PromoCodeEnabled            bool   `json:"promo_code_enabled"`
 	PasswordResetEnabled        bool   `json:"password_reset_enabled"`
 	TotpEnabled                 bool   `json:"totp_enabled"` // TOTP 双因素认证
+	WebAuthnEnabled             bool   `json:"webauthn_enabled"`
+	PasskeyLoginEnabled         bool   `json:"passkey_login_enabled"`
 	TurnstileEnabled            bool   `json:"turnstile_enabled"`
 	TurnstileSiteKey            string `json:"turnstile_site_key"`
 	SiteName                    string `json:"site_name"`
@@ -74,10 +74,33 @@ type Group struct {
 type AdminGroup struct {
 	Group
 
+	// 订阅续期价格（每个有效期周期，nil 表示不支持自助续期/变更）
+	SubscriptionPrice *float64 `json:"subscription_price"`
+
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
 
+	// 账号选择策略
+	AccountSelectionStrategy string `json:"account_selection_strategy"`
+
+	// 图片生成模型白名单（为空表示不限制）
+	ImageModels []string `json:"image_models"`
+
+	// 内容策略命中动作（空表示不检查）
+	ContentPolicyAction string `json:"content_policy_action"`
+
+	// 请求改写规则（按顺序应用）
+	RequestRewriteRules []RequestRewriteRule `json:"request_rewrite_rules"`
+
+	// 模型别名与访问策略
+	ModelAliases  map[string]string `json:"model_aliases"`
+	AllowedModels []string          `json:"allowed_models"`
+	DeniedModels  []string          `json:"denied_models"`
+
+	// 订阅 token/请求数限额
+	UsageLimits []SubscriptionUsageLimit `json:"usage_limits"`
+
 	AccountGroups []AccountGroup `json:"account_groups,omitempty"`
 	AccountCount  int64          `json:"account_count,omitempty"`
 }
@@ -91,6 +114,7 @@ type Account struct {
 	Credentials        map[string]any `json:"credentials"`
 	Extra              map[string]any `json:"extra"`
 	ProxyID            *int64         `json:"proxy_id"`
+	ProxyPoolID        *int64         `json:"proxy_pool_id"`
 	Concurrency        int            `json:"concurrency"`
 	Priority           int            `json:"priority"`
 	RateMultiplier     float64        `json:"rate_multiplier"`
@@ -175,6 +199,114 @@ type ProxyWithAccountCount struct {
 	CountryCode    string `json:"country_code,omitempty"`
 	Region         string `json:"region,omitempty"`
 	City           string `json:"city,omitempty"`
+
+	ConsecutiveFailures int `json:"consecutive_failures,omitempty"`
+}
+
+type RequestRewriteRule struct {
+	Action   string            `json:"action" binding:"required"`
+	Models   []string          `json:"models,omitempty"`
+	Text     string            `json:"text,omitempty"`
+	Value    *float64          `json:"value,omitempty"`
+	Tools    []string          `json:"tools,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type SubscriptionUsageLimit struct {
+	Name                string   `json:"name" binding:"required"`
+	Window              string   `json:"window" binding:"required"`
+	Metric              string   `json:"metric" binding:"required"`
+	Limit               float64  `json:"limit"`
+	Models              []string `json:"models,omitempty"`
+	InputWeight         float64  `json:"input_weight,omitempty"`
+	OutputWeight        float64  `json:"output_weight,omitempty"`
+	CacheCreationWeight float64  `json:"cache_creation_weight,omitempty"`
+	CacheReadWeight     float64  `json:"cache_read_weight,omitempty"`
+}
+
+type ProxyPoolMember struct {
+	Proxy
+	Healthy             bool       `json:"healthy"`
+	LatencyMs           *int64     `json:"latency_ms,omitempty"`
+	LatencyStatus       string     `json:"latency_status,omitempty"`
+	LatencyMessage      string     `json:"latency_message,omitempty"`
+	IPAddress           string     `json:"ip_address,omitempty"`
+	Country             string     `json:"country,omitempty"`
+	CountryCode         string     `json:"country_code,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+	CheckedAt           *time.Time `json:"checked_at,omitempty"`
+}
+
+type ProxyPool struct {
+	ID           int64             `json:"id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Status       string            `json:"status"`
+	ProxyIDs     []int64           `json:"proxy_ids"`
+	Members      []ProxyPoolMember `json:"members,omitempty"`
+	AccountCount int64             `json:"account_count"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+type ContentPolicyRule struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	MatchType   string    `json:"match_type"`
+	Pattern     string    `json:"pattern"`
+	Description string    `json:"description"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ContentPolicyHit struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	APIKeyID   int64      `json:"api_key_id"`
+	GroupID    *int64     `json:"group_id"`
+	RuleID     *int64     `json:"rule_id"`
+	RuleName   string     `json:"rule_name"`
+	Source     string     `json:"source"`
+	Action     string     `json:"action"`
+	Platform   string     `json:"platform"`
+	Model      string     `json:"model"`
+	Excerpt    string     `json:"excerpt"`
+	Categories []string   `json:"categories,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	ReviewedBy *int64     `json:"reviewed_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ContentPolicyMatch struct {
+	RuleID   int64  `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	Excerpt  string `json:"excerpt"`
+}
+
+type SpendAnomalyFlag struct {
+	ID            int64                    `json:"id"`
+	UserID        int64                    `json:"user_id"`
+	APIKeyID      int64                    `json:"api_key_id"`
+	Kind          string                   `json:"kind"`
+	Action        string                   `json:"action"`
+	Status        string                   `json:"status"`
+	CurrentValue  float64                  `json:"current_value"`
+	BaselineValue float64                  `json:"baseline_value"`
+	WindowStart   time.Time                `json:"window_start"`
+	WindowEnd     time.Time                `json:"window_end"`
+	Details       map[string]any           `json:"details,omitempty"`
+	ActionState   *SpendAnomalyActionState `json:"action_state,omitempty"`
+	ReviewNote    string                   `json:"review_note"`
+	ReviewedAt    *time.Time               `json:"reviewed_at"`
+	ReviewedBy    *int64                   `json:"reviewed_by"`
+	CreatedAt     time.Time                `json:"created_at"`
+}
+
+type SpendAnomalyActionState struct {
+	DisabledAPIKeyIDs   []int64 `json:"disabled_api_key_ids,omitempty"`
+	PreviousConcurrency *int    `json:"previous_concurrency,omitempty"`
+	LoweredConcurrency  *int    `json:"lowered_concurrency,omitempty"`
 }
 
 type ProxyAccountSummary struct {
@@ -268,6 +400,9 @@ type AdminUsageLog struct {
 	// IPAddress 用户请求 IP（仅管理员可见）
 	IPAddress *string `json:"ip_address,omitempty"`
 
+	// TraceID 分布式追踪 trace id（仅管理员可见，用于关联追踪后端）
+	TraceID *string `json:"trace_id,omitempty"`
+
 	// Account 最小账号信息（避免泄露敏感字段）
 	Account *AccountSummary `json:"account,omitempty"`
 }
@@ -330,6 +465,9 @@ type UserSubscription struct {
 	WeeklyUsageUSD  float64 `json:"weekly_usage_usd"`
 	MonthlyUsageUSD float64 `json:"monthly_usage_usd"`
 
+	AutoRenew bool       `json:"auto_renew"`
+	PausedAt  *time.Time `json:"paused_at"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -337,6 +475,21 @@ type UserSubscription struct {
 	Group *Group `json:"group,omitempty"`
 }
 
+// SubscriptionHistoryEntry 订阅历史（续期、升降级、暂停/恢复）
+type SubscriptionHistoryEntry struct {
+	ID                int64      `json:"id"`
+	SubscriptionID    int64      `json:"subscription_id"`
+	Action            string     `json:"action"`
+	FromGroupID       *int64     `json:"from_group_id"`
+	ToGroupID         *int64     `json:"to_group_id"`
+	Amount            float64    `json:"amount"`
+	Credit            float64    `json:"credit"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at"`
+	NewExpiresAt      *time.Time `json:"new_expires_at"`
+	Note              string     `json:"note"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
 // AdminUserSubscription 是管理员接口使用的订阅 DTO（包含分配信息/备注等字段）。
 // 注意：普通用户接口不得返回 assigned_by/assigned_at/notes/assigned_by_user 等管理员字段。
 type AdminUserSubscription struct {
@@ -380,3 +533,24 @@ type PromoCodeUsage struct {
 
 	User *User `json:"user,omitempty"`
 }
+
+// UserSession 用户登录会话
+type UserSession struct {
+	SessionID    string    `json:"session_id"`
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent"`
+	DeviceName   string    `json:"device_name"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Current      bool      `json:"current"`
+}
+
+// WebAuthnCredential 用户注册的安全密钥 / Passkey
+type WebAuthnCredential struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Discoverable bool       `json:"discoverable"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
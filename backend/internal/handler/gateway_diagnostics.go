@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Diagnostic response headers exposed when gateway.diagnostic_headers is enabled.
+// They let load-testing clients (cmd/loadtest) observe server-side scheduling cost.
+const (
+	// HeaderQueueWaitMs is the total time (ms) spent waiting for user/account concurrency slots.
+	HeaderQueueWaitMs = "X-Sub2api-Queue-Wait-Ms"
+	// HeaderAccountSwitches is the number of account failovers before the response started.
+	HeaderAccountSwitches = "X-Sub2api-Account-Switches"
+)
+
+const (
+	gatewayQueueWaitKey         = "gateway_queue_wait"
+	gatewayAccountSelectionsKey = "gateway_account_selections"
+)
+
+// addGatewayQueueWait accumulates the time spent waiting for a concurrency slot.
+func addGatewayQueueWait(c *gin.Context, d time.Duration) {
+	if c == nil || d <= 0 {
+		return
+	}
+	c.Set(gatewayQueueWaitKey, gatewayQueueWait(c)+d)
+}
+
+func gatewayQueueWait(c *gin.Context) time.Duration {
+	if v, ok := c.Get(gatewayQueueWaitKey); ok {
+		if d, ok := v.(time.Duration); ok {
+			return d
+		}
+	}
+	return 0
+}
+
+// countGatewayAccountSelection records one account selection; every selection after the first is a failover.
+func countGatewayAccountSelection(c *gin.Context) {
+	if c == nil {
+		return
+	}
+	c.Set(gatewayAccountSelectionsKey, c.GetInt(gatewayAccountSelectionsKey)+1)
+}
+
+func gatewayAccountSwitches(c *gin.Context) int {
+	if n := c.GetInt(gatewayAccountSelectionsKey); n > 1 {
+		return n - 1
+	}
+	return 0
+}
+
+// diagnosticsWriter stamps the diagnostic headers right before the response header is written.
+//
+// Note: for streaming requests that receive keepalive pings while queued, headers are sent
+// with the first ping, so the reported values only cover the wait up to that point.
+type diagnosticsWriter struct {
+	gin.ResponseWriter
+	c       *gin.Context
+	stamped bool
+}
+
+func (w *diagnosticsWriter) stamp() {
+	if w.stamped || w.ResponseWriter.Written() {
+		return
+	}
+	w.stamped = true
+	h := w.ResponseWriter.Header()
+	h.Set(HeaderQueueWaitMs, strconv.FormatInt(gatewayQueueWait(w.c).Milliseconds(), 10))
+	h.Set(HeaderAccountSwitches, strconv.Itoa(gatewayAccountSwitches(w.c)))
+}
+
+func (w *diagnosticsWriter) WriteHeaderNow() {
+	w.stamp()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *diagnosticsWriter) Write(b []byte) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *diagnosticsWriter) WriteString(s string) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *diagnosticsWriter) Flush() {
+	w.stamp()
+	w.ResponseWriter.Flush()
+}
+
+// GatewayDiagnosticsMiddleware adds queue-wait and failover headers to gateway responses.
+// It is a no-op unless enabled, since the values reveal scheduling details to API key holders.
+func GatewayDiagnosticsMiddleware(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+		c.Writer = &diagnosticsWriter{ResponseWriter: c.Writer, c: c}
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TestGatewayDiagnosticsMiddleware 验证诊断头在响应写出前带上排队时间与账号切换次数
+func TestGatewayDiagnosticsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(enabled bool) *gin.Engine {
+		r := gin.New()
+		r.Use(GatewayDiagnosticsMiddleware(enabled))
+		r.POST("/v1/messages", func(c *gin.Context) {
+			addGatewayQueueWait(c, 120*time.Millisecond)
+			addGatewayQueueWait(c, 30*time.Millisecond)
+			setOpsSelectedAccount(c, &service.Account{ID: 1}, "")
+			setOpsSelectedAccount(c, &service.Account{ID: 2}, "")
+			setOpsSelectedAccount(c, &service.Account{ID: 3}, "")
+			c.Writer.Header().Set("Content-Type", "text/event-stream")
+			c.Writer.WriteHeader(http.StatusOK)
+			_, _ = c.Writer.WriteString("data: {}\n\n")
+			c.Writer.Flush()
+		})
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	newRouter(true).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", nil))
+	if got := w.Header().Get(HeaderQueueWaitMs); got != "150" {
+		t.Errorf("queue wait header = %q, want 150", got)
+	}
+	if got := w.Header().Get(HeaderAccountSwitches); got != "2" {
+		t.Errorf("account switches header = %q, want 2", got)
+	}
+
+	w = httptest.NewRecorder()
+	newRouter(false).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", nil))
+	if w.Header().Get(HeaderQueueWaitMs) != "" || w.Header().Get(HeaderAccountSwitches) != "" {
+		t.Errorf("diagnostic headers must not be sent when disabled: %v", w.Header())
+	}
+}
+
+// TestSetOpsSelectedAccountLogFields 验证账号切换后请求日志上下文携带最后选中的账号、平台与模型
+func TestSetOpsSelectedAccountLogFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+	setOpsSelectedAccount(c, &service.Account{ID: 1, Platform: service.PlatformAnthropic}, "claude-sonnet-4-5")
+	setOpsSelectedAccount(c, &service.Account{ID: 2, Platform: service.PlatformAntigravity}, "claude-sonnet-4-5")
+
+	got := map[string]string{}
+	for _, attr := range logger.Fields(c.Request.Context()) {
+		got[attr.Key] = attr.Value.String()
+	}
+	if got["account_id"] != "2" || got["platform"] != service.PlatformAntigravity || got["model"] != "claude-sonnet-4-5" {
+		t.Errorf("log fields = %v, want account_id=2 platform=antigravity model=claude-sonnet-4-5", got)
+	}
+}
@@ -1,12 +1,13 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -16,11 +17,13 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
 	pkgerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/sjson"
 )
 
 // GatewayHandler handles API gateway requests
@@ -30,6 +33,7 @@ type GatewayHandler struct {
 	antigravityGatewayService *service.AntigravityGatewayService
 	userService               *service.UserService
 	billingCacheService       *service.BillingCacheService
+	contentPolicyService      *service.ContentPolicyService
 	concurrencyHelper         *ConcurrencyHelper
 	maxAccountSwitches        int
 	maxAccountSwitchesGemini  int
@@ -43,6 +47,7 @@ func NewGatewayHandler(
 	userService *service.UserService,
 	concurrencyService *service.ConcurrencyService,
 	billingCacheService *service.BillingCacheService,
+	contentPolicyService *service.ContentPolicyService,
 	cfg *config.Config,
 ) *GatewayHandler {
 	pingInterval := time.Duration(0)
@@ -63,6 +68,7 @@ func NewGatewayHandler(
 		antigravityGatewayService: antigravityGatewayService,
 		userService:               userService,
 		billingCacheService:       billingCacheService,
+		contentPolicyService:      contentPolicyService,
 		concurrencyHelper:         NewConcurrencyHelper(concurrencyService, SSEPingFormatClaude, pingInterval),
 		maxAccountSwitches:        maxAccountSwitches,
 		maxAccountSwitchesGemini:  maxAccountSwitchesGemini,
@@ -122,6 +128,32 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		return
 	}
 
+	// 分组模型别名与访问策略（别名改写请求体中的 model）
+	resolvedModel, rewrittenBody, err := applyGroupModelPolicy(apiKey, reqModel, body)
+	if err != nil {
+		status, code, message := modelPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	reqModel = resolvedModel
+
+	// 内容策略检查与分组请求改写（请求体变化后需重新解析）
+	rewrittenBody, err = enforceContentPolicy(c, h.contentPolicyService, apiKey, reqModel, rewrittenBody)
+	if err != nil {
+		status, code, message := contentPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	rewrittenBody = applyGroupRequestRewrite(apiKey, service.RewriteFormatAnthropic, reqModel, rewrittenBody)
+	if !bytes.Equal(rewrittenBody, body) {
+		body = rewrittenBody
+		if parsedReq, err = service.ParseGatewayRequest(body); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+			return
+		}
+		setOpsRequestContext(c, reqModel, reqStream, body)
+	}
+
 	// Track if we've started streaming (for error handling)
 	streamStarted := false
 
@@ -133,7 +165,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	canWait, err := h.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
 	waitCounted := false
 	if err != nil {
-		log.Printf("Increment wait count failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "increment_wait_count_failed", "error", err)
 		// On error, allow request to proceed
 	} else if !canWait {
 		h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
@@ -152,7 +184,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	// 1. 首先获取用户并发槽位
 	userReleaseFunc, err := h.concurrencyHelper.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, reqStream, &streamStarted)
 	if err != nil {
-		log.Printf("User concurrency acquire failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "user_concurrency_acquire_failed", "error", err)
 		h.handleConcurrencyError(c, err, "user", streamStarted)
 		return
 	}
@@ -168,13 +200,23 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	}
 
 	// 2. 【新增】Wait后二次检查余额/订阅
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
-		log.Printf("Billing eligibility check failed after wait: %v", err)
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, reqModel); err != nil {
+		slog.WarnContext(c.Request.Context(), "billing_eligibility_check_failed", "error", err)
 		status, code, message := billingErrorDetails(err)
 		h.handleStreamingAwareError(c, status, code, message, streamStarted)
 		return
 	}
 
+	// 2.1 按预估费用上限冻结余额/订阅额度，防止并发请求透支；未结算的冻结在返回时释放
+	billingHold, err := h.billingCacheService.ReserveBillingHold(c.Request.Context(), apiKey.User, apiKey.Group, subscription, reqModel, body)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "billing_hold_rejected", "error", err)
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, streamStarted)
+		return
+	}
+	defer billingHold.Release()
+
 	// 计算粘性会话hash
 	sessionHash := h.gatewayService.GenerateSessionHash(parsedReq)
 
@@ -207,7 +249,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				return
 			}
 			account := selection.Account
-			setOpsSelectedAccount(c, account.ID)
+			setOpsSelectedAccount(c, account, reqModel)
 
 			// 检查请求拦截（预热请求、SUGGESTION MODE等）
 			if account.IsInterceptWarmupEnabled() {
@@ -235,9 +277,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				accountWaitCounted := false
 				canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
 				if err != nil {
-					log.Printf("Increment account wait count failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "increment_account_wait_count_failed", "account_id", account.ID, "error", err)
 				} else if !canWait {
-					log.Printf("Account wait queue full: account=%d", account.ID)
+					slog.WarnContext(c.Request.Context(), "account_wait_queue_full", "account_id", account.ID)
 					h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
 					return
 				}
@@ -260,7 +302,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					&streamStarted,
 				)
 				if err != nil {
-					log.Printf("Account concurrency acquire failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "account_concurrency_acquire_failed", "account_id", account.ID, "error", err)
 					h.handleConcurrencyError(c, err, "account", streamStarted)
 					return
 				}
@@ -270,7 +312,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					accountWaitCounted = false
 				}
 				if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
-					log.Printf("Bind sticky session failed: %v", err)
+					slog.WarnContext(c.Request.Context(), "bind_sticky_session_failed", "account_id", account.ID, "error", err)
 				}
 			}
 			// 账号槽位/等待计数需要在超时或断开时安全回收
@@ -296,21 +338,22 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 						return
 					}
 					switchCount++
-					log.Printf("Account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
+					slog.WarnContext(c.Request.Context(), "upstream_failover", "account_id", account.ID, "status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
 					continue
 				}
 				// 错误响应已在Forward中处理，这里只记录日志
-				log.Printf("Forward request failed: %v", err)
+				slog.ErrorContext(c.Request.Context(), "forward_failed", "account_id", account.ID, "error", err)
 				return
 			}
 
 			// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 			userAgent := c.GetHeader("User-Agent")
 			clientIP := ip.GetClientIP(c)
+			usageCtx := logger.Detach(c.Request.Context())
 
 			// 异步记录使用量（subscription已在函数开头获取）
-			go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string) {
-				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string, hold *service.BillingHold) {
+				ctx, cancel := context.WithTimeout(usageCtx, 10*time.Second)
 				defer cancel()
 				if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 					Result:       result,
@@ -320,14 +363,18 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					Subscription: subscription,
 					UserAgent:    ua,
 					IPAddress:    clientIP,
+					BillingHold:  hold,
 				}); err != nil {
-					log.Printf("Record usage failed: %v", err)
+					slog.ErrorContext(ctx, "record_usage_failed", "account_id", usedAccount.ID, "error", err)
 				}
-			}(result, account, userAgent, clientIP)
+			}(result, account, userAgent, clientIP, billingHold.Detach())
 			return
 		}
 	}
 
+	// 缓存亲和调度：携带 prompt 缓存前缀，供账号选择与转发后记录热缓存使用
+	c.Request = c.Request.WithContext(h.gatewayService.WithPromptCacheAffinity(c.Request.Context(), parsedReq))
+
 	maxAccountSwitches := h.maxAccountSwitches
 	switchCount := 0
 	failedAccountIDs := make(map[int64]struct{})
@@ -345,7 +392,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			return
 		}
 		account := selection.Account
-		setOpsSelectedAccount(c, account.ID)
+		setOpsSelectedAccount(c, account, reqModel)
 
 		// 检查请求拦截（预热请求、SUGGESTION MODE等）
 		if account.IsInterceptWarmupEnabled() {
@@ -373,9 +420,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			accountWaitCounted := false
 			canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
 			if err != nil {
-				log.Printf("Increment account wait count failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "increment_account_wait_count_failed", "account_id", account.ID, "error", err)
 			} else if !canWait {
-				log.Printf("Account wait queue full: account=%d", account.ID)
+				slog.WarnContext(c.Request.Context(), "account_wait_queue_full", "account_id", account.ID)
 				h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
 				return
 			}
@@ -397,7 +444,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				&streamStarted,
 			)
 			if err != nil {
-				log.Printf("Account concurrency acquire failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "account_concurrency_acquire_failed", "account_id", account.ID, "error", err)
 				h.handleConcurrencyError(c, err, "account", streamStarted)
 				return
 			}
@@ -406,7 +453,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				accountWaitCounted = false
 			}
 			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
-				log.Printf("Bind sticky session failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "bind_sticky_session_failed", "account_id", account.ID, "error", err)
 			}
 		}
 		// 账号槽位/等待计数需要在超时或断开时安全回收
@@ -432,21 +479,22 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					return
 				}
 				switchCount++
-				log.Printf("Account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
+				slog.WarnContext(c.Request.Context(), "upstream_failover", "account_id", account.ID, "status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
 				continue
 			}
 			// 错误响应已在Forward中处理，这里只记录日志
-			log.Printf("Account %d: Forward request failed: %v", account.ID, err)
+			slog.ErrorContext(c.Request.Context(), "forward_failed", "account_id", account.ID, "error", err)
 			return
 		}
 
 		// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
+		usageCtx := logger.Detach(c.Request.Context())
 
 		// 异步记录使用量（subscription已在函数开头获取）
-		go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string) {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string, hold *service.BillingHold) {
+			ctx, cancel := context.WithTimeout(usageCtx, 10*time.Second)
 			defer cancel()
 			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 				Result:       result,
@@ -456,10 +504,11 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				Subscription: subscription,
 				UserAgent:    ua,
 				IPAddress:    clientIP,
+				BillingHold:  hold,
 			}); err != nil {
-				log.Printf("Record usage failed: %v", err)
+				slog.ErrorContext(ctx, "record_usage_failed", "account_id", usedAccount.ID, "error", err)
 			}
-		}(result, account, userAgent, clientIP)
+		}(result, account, userAgent, clientIP, billingHold.Detach())
 		return
 	}
 }
@@ -493,7 +542,7 @@ func (h *GatewayHandler) Models(c *gin.Context) {
 				CreatedAt:   "2024-01-01T00:00:00Z",
 			})
 		}
-		c.JSON(http.StatusOK, gin.H{
+		writeModelList(c, apiKey, gin.H{
 			"object": "list",
 			"data":   models,
 		})
@@ -502,14 +551,14 @@ func (h *GatewayHandler) Models(c *gin.Context) {
 
 	// Fallback to default models
 	if platform == "openai" {
-		c.JSON(http.StatusOK, gin.H{
+		writeModelList(c, apiKey, gin.H{
 			"object": "list",
 			"data":   openai.DefaultModels,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	writeModelList(c, apiKey, gin.H{
 		"object": "list",
 		"data":   claude.DefaultModels,
 	})
@@ -518,12 +567,28 @@ func (h *GatewayHandler) Models(c *gin.Context) {
 // AntigravityModels 返回 Antigravity 支持的全部模型
 // GET /antigravity/models
 func (h *GatewayHandler) AntigravityModels(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+	writeModelList(c, apiKey, gin.H{
 		"object": "list",
 		"data":   antigravity.DefaultModels(),
 	})
 }
 
+// writeModelList writes a {"data":[...]} model list filtered by the group's model policy
+// (denied models removed, aliases listed alongside their targets).
+func writeModelList(c *gin.Context, apiKey *service.APIKey, list any) {
+	if apiKey == nil || !apiKey.Group.HasModelPolicy() {
+		c.JSON(http.StatusOK, list)
+		return
+	}
+	body, err := json.Marshal(list)
+	if err != nil {
+		c.JSON(http.StatusOK, list)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", apiKey.Group.ApplyModelPolicyToList(body, service.ModelListFormatData))
+}
+
 // Usage handles getting account balance for CC Switch integration
 // GET /v1/usage
 func (h *GatewayHandler) Usage(c *gin.Context) {
@@ -548,12 +613,22 @@ func (h *GatewayHandler) Usage(c *gin.Context) {
 		}
 
 		remaining := h.calculateSubscriptionRemaining(apiKey.Group, subscription)
-		c.JSON(http.StatusOK, gin.H{
+		resp := gin.H{
 			"isValid":   true,
 			"planName":  apiKey.Group.Name,
 			"remaining": remaining,
 			"unit":      "USD",
-		})
+		}
+		// Token/request-count limits: an exhausted limit covering all models blocks every request.
+		if limits := service.BuildUsageLimitProgress(apiKey.Group, subscription); len(limits) > 0 {
+			for _, l := range limits {
+				if len(l.Models) == 0 && l.Remaining <= 0 {
+					resp["remaining"] = 0
+				}
+			}
+			resp["limits"] = limits
+		}
+		c.JSON(http.StatusOK, resp)
 		return
 	}
 
@@ -739,6 +814,21 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		return
 	}
 
+	// 分组模型别名与访问策略
+	resolvedModel, resolvedBody, err := applyGroupModelPolicy(apiKey, parsedReq.Model, body)
+	if err != nil {
+		status, code, message := modelPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	if resolvedModel != parsedReq.Model {
+		body = resolvedBody
+		if parsedReq, err = service.ParseGatewayRequest(body); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+			return
+		}
+	}
+
 	setOpsRequestContext(c, parsedReq.Model, parsedReq.Stream, body)
 
 	// 获取订阅信息（可能为nil）
@@ -746,7 +836,7 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 
 	// 校验 billing eligibility（订阅/余额）
 	// 【注意】不计算并发，但需要校验订阅/余额
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, parsedReq.Model); err != nil {
 		status, code, message := billingErrorDetails(err)
 		h.errorResponse(c, status, code, message)
 		return
@@ -761,11 +851,11 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error())
 		return
 	}
-	setOpsSelectedAccount(c, account.ID)
+	setOpsSelectedAccount(c, account, parsedReq.Model)
 
 	// 转发请求（不记录使用量）
 	if err := h.gatewayService.ForwardCountTokens(c.Request.Context(), c, account, parsedReq); err != nil {
-		log.Printf("Forward count_tokens request failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "count_tokens_forward_failed", "account_id", account.ID, "error", err)
 		// 错误响应已在 ForwardCountTokens 中处理
 		return
 	}
@@ -975,3 +1065,73 @@ func billingErrorDetails(err error) (status int, code, message string) {
 	}
 	return http.StatusForbidden, "billing_error", msg
 }
+
+// applyGroupModelPolicy resolves the group's model aliases and enforces its model access policy
+// before account selection. When an alias is used, the body's "model" field is rewritten to the target.
+func applyGroupModelPolicy(apiKey *service.APIKey, model string, body []byte) (string, []byte, error) {
+	if apiKey == nil || apiKey.Group == nil {
+		return model, body, nil
+	}
+	resolved, err := apiKey.Group.ResolveRequestModel(model)
+	if err != nil {
+		return "", nil, err
+	}
+	if resolved == model || body == nil {
+		return resolved, body, nil
+	}
+	body, err = sjson.SetBytes(body, "model", resolved)
+	if err != nil {
+		return "", nil, err
+	}
+	return resolved, body, nil
+}
+
+// modelPolicyErrorDetails maps model policy errors to HTTP status/code/message
+func modelPolicyErrorDetails(err error) (status int, code, message string) {
+	if errors.Is(err, service.ErrModelNotAllowed) {
+		return http.StatusForbidden, "permission_error", pkgerrors.Message(err)
+	}
+	return http.StatusInternalServerError, "api_error", "Failed to process request"
+}
+
+// applyGroupRequestRewrite applies the group's request rewrite rules.
+// Rewrite failures are logged and the original body is forwarded unchanged.
+func applyGroupRequestRewrite(apiKey *service.APIKey, format, model string, body []byte) []byte {
+	if apiKey == nil || apiKey.Group == nil || len(apiKey.Group.RequestRewriteRules) == 0 {
+		return body
+	}
+	rewritten, _, err := service.ApplyRequestRewriteRules(body, format, model, apiKey.Group.RequestRewriteRules)
+	if err != nil {
+		slog.Warn("request_rewrite_failed", "group_id", apiKey.Group.ID, "error", err)
+		return body
+	}
+	return rewritten
+}
+
+// contentPolicyErrorDetails maps content policy errors to HTTP status/code/message
+func contentPolicyErrorDetails(err error) (status int, code, message string) {
+	msg := pkgerrors.Message(err)
+	if msg == "" {
+		msg = err.Error()
+	}
+	if errors.Is(err, service.ErrContentPolicyUnavailable) {
+		return http.StatusServiceUnavailable, "api_error", msg
+	}
+	return http.StatusBadRequest, "invalid_request_error", msg
+}
+
+// enforceContentPolicy runs the group's content policy against the request body
+// before it is forwarded and returns the (possibly stripped) body.
+func enforceContentPolicy(c *gin.Context, svc *service.ContentPolicyService, apiKey *service.APIKey, model string, body []byte) ([]byte, error) {
+	if svc == nil || apiKey == nil || apiKey.Group == nil {
+		return body, nil
+	}
+	return svc.Enforce(c.Request.Context(), &service.ContentPolicyCheckInput{
+		User:     apiKey.User,
+		APIKey:   apiKey,
+		Group:    apiKey.Group,
+		Platform: apiKey.Group.Platform,
+		Model:    model,
+		Body:     body,
+	})
+}
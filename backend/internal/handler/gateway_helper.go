@@ -9,9 +9,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // claudeCodeValidator is a singleton validator for Claude Code client detection
@@ -156,8 +158,9 @@ func (h *ConcurrencyHelper) DecrementAccountWaitCount(ctx context.Context, accou
 // AcquireUserSlotWithWait acquires a user concurrency slot, waiting if necessary.
 // For streaming requests, sends ping events during the wait.
 // streamStarted is updated if streaming response has begun.
-func (h *ConcurrencyHelper) AcquireUserSlotWithWait(c *gin.Context, userID int64, maxConcurrency int, isStream bool, streamStarted *bool) (func(), error) {
-	ctx := c.Request.Context()
+func (h *ConcurrencyHelper) AcquireUserSlotWithWait(c *gin.Context, userID int64, maxConcurrency int, isStream bool, streamStarted *bool) (release func(), err error) {
+	ctx, span := tracing.Start(c.Request.Context(), "concurrency.acquire_user_slot", attribute.Int64("sub2api.user_id", userID))
+	defer func() { tracing.End(span, err) }()
 
 	// Try to acquire immediately
 	result, err := h.concurrencyService.AcquireUserSlot(ctx, userID, maxConcurrency)
@@ -170,14 +173,16 @@ func (h *ConcurrencyHelper) AcquireUserSlotWithWait(c *gin.Context, userID int64
 	}
 
 	// Need to wait - handle streaming ping if needed
+	span.SetAttributes(attribute.Bool("sub2api.queued", true))
 	return h.waitForSlotWithPing(c, "user", userID, maxConcurrency, isStream, streamStarted)
 }
 
 // AcquireAccountSlotWithWait acquires an account concurrency slot, waiting if necessary.
 // For streaming requests, sends ping events during the wait.
 // streamStarted is updated if streaming response has begun.
-func (h *ConcurrencyHelper) AcquireAccountSlotWithWait(c *gin.Context, accountID int64, maxConcurrency int, isStream bool, streamStarted *bool) (func(), error) {
-	ctx := c.Request.Context()
+func (h *ConcurrencyHelper) AcquireAccountSlotWithWait(c *gin.Context, accountID int64, maxConcurrency int, isStream bool, streamStarted *bool) (release func(), err error) {
+	ctx, span := tracing.Start(c.Request.Context(), "concurrency.acquire_account_slot", attribute.Int64("sub2api.account_id", accountID))
+	defer func() { tracing.End(span, err) }()
 
 	// Try to acquire immediately
 	result, err := h.concurrencyService.AcquireAccountSlot(ctx, accountID, maxConcurrency)
@@ -190,6 +195,7 @@ func (h *ConcurrencyHelper) AcquireAccountSlotWithWait(c *gin.Context, accountID
 	}
 
 	// Need to wait - handle streaming ping if needed
+	span.SetAttributes(attribute.Bool("sub2api.queued", true))
 	return h.waitForSlotWithPing(c, "account", accountID, maxConcurrency, isStream, streamStarted)
 }
 
@@ -201,6 +207,9 @@ func (h *ConcurrencyHelper) waitForSlotWithPing(c *gin.Context, slotType string,
 
 // waitForSlotWithPingTimeout waits for a concurrency slot with a custom timeout.
 func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType string, id int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool) (func(), error) {
+	waitStart := time.Now()
+	defer func() { addGatewayQueueWait(c, time.Since(waitStart)) }()
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 	defer cancel()
 
@@ -291,7 +300,10 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 }
 
 // AcquireAccountSlotWithWaitTimeout acquires an account slot with a custom timeout (keeps SSE ping).
-func (h *ConcurrencyHelper) AcquireAccountSlotWithWaitTimeout(c *gin.Context, accountID int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool) (func(), error) {
+func (h *ConcurrencyHelper) AcquireAccountSlotWithWaitTimeout(c *gin.Context, accountID int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool) (release func(), err error) {
+	_, span := tracing.Start(c.Request.Context(), "concurrency.acquire_account_slot",
+		attribute.Int64("sub2api.account_id", accountID), attribute.Bool("sub2api.queued", true))
+	defer func() { tracing.End(span, err) }()
 	return h.waitForSlotWithPingTimeout(c, "account", accountID, maxConcurrency, timeout, isStream, streamStarted)
 }
 
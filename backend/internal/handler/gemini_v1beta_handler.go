@@ -5,9 +5,10 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/pkg/gemini"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/googleapi"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
@@ -51,7 +53,7 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 
 	// 强制 antigravity 模式：返回 antigravity 支持的模型列表
 	if forcePlatform == service.PlatformAntigravity {
-		c.JSON(http.StatusOK, antigravity.FallbackGeminiModelsList())
+		writeGeminiModelList(c, apiKey, antigravity.FallbackGeminiModelsList())
 		return
 	}
 
@@ -61,7 +63,7 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 		hasAntigravity, _ := h.geminiCompatService.HasAntigravityAccounts(c.Request.Context(), apiKey.GroupID)
 		if hasAntigravity {
 			// antigravity 账户使用静态模型列表
-			c.JSON(http.StatusOK, gemini.FallbackModelsList())
+			writeGeminiModelList(c, apiKey, gemini.FallbackModelsList())
 			return
 		}
 		googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
@@ -74,12 +76,29 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 		return
 	}
 	if shouldFallbackGeminiModels(res) {
-		c.JSON(http.StatusOK, gemini.FallbackModelsList())
+		writeGeminiModelList(c, apiKey, gemini.FallbackModelsList())
 		return
 	}
+	if res.StatusCode == http.StatusOK && apiKey.Group.HasModelPolicy() {
+		res.Body = apiKey.Group.ApplyModelPolicyToList(res.Body, service.ModelListFormatGemini)
+	}
 	writeUpstreamResponse(c, res)
 }
 
+// writeGeminiModelList writes a v1beta {"models":[...]} list filtered by the group's model policy
+func writeGeminiModelList(c *gin.Context, apiKey *service.APIKey, list any) {
+	if !apiKey.Group.HasModelPolicy() {
+		c.JSON(http.StatusOK, list)
+		return
+	}
+	body, err := json.Marshal(list)
+	if err != nil {
+		c.JSON(http.StatusOK, list)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", apiKey.Group.ApplyModelPolicyToList(body, service.ModelListFormatGemini))
+}
+
 // GeminiV1BetaGetModel proxies:
 // GET /v1beta/models/{model}
 func (h *GatewayHandler) GeminiV1BetaGetModel(c *gin.Context) {
@@ -100,6 +119,12 @@ func (h *GatewayHandler) GeminiV1BetaGetModel(c *gin.Context) {
 		googleError(c, http.StatusBadRequest, "Missing model in URL")
 		return
 	}
+	modelName, _, err := applyGroupModelPolicy(apiKey, modelName, nil)
+	if err != nil {
+		status, _, message := modelPolicyErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
 
 	// 强制 antigravity 模式：返回 antigravity 模型信息
 	if forcePlatform == service.PlatformAntigravity {
@@ -135,6 +160,8 @@ func (h *GatewayHandler) GeminiV1BetaGetModel(c *gin.Context) {
 // GeminiV1BetaModels proxies Gemini native REST endpoints like:
 // POST /v1beta/models/{model}:generateContent
 // POST /v1beta/models/{model}:streamGenerateContent?alt=sse
+// POST /v1beta/models/{model}:embedContent
+// POST /v1beta/models/{model}:batchEmbedContents
 func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	apiKey, ok := middleware.GetAPIKeyFromContext(c)
 	if !ok || apiKey == nil {
@@ -161,6 +188,14 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		return
 	}
 
+	// group model aliases and access policy (the model lives in the URL, not the body)
+	modelName, _, err = applyGroupModelPolicy(apiKey, modelName, nil)
+	if err != nil {
+		status, _, message := modelPolicyErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+
 	stream := action == "streamGenerateContent"
 
 	body, err := io.ReadAll(c.Request.Body)
@@ -179,6 +214,21 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 
 	setOpsRequestContext(c, modelName, stream, body)
 
+	// content policy applies to generation and embeddings (same as /v1/embeddings); countTokens is untouched.
+	// Group request rewrite only targets generation requests.
+	isGeneration := action == "generateContent" || action == "streamGenerateContent"
+	if isGeneration || service.IsGeminiEmbeddingAction(action) {
+		body, err = enforceContentPolicy(c, h.contentPolicyService, apiKey, modelName, body)
+		if err != nil {
+			status, _, message := contentPolicyErrorDetails(err)
+			googleError(c, status, message)
+			return
+		}
+	}
+	if isGeneration {
+		body = applyGroupRequestRewrite(apiKey, service.RewriteFormatGemini, modelName, body)
+	}
+
 	// Get subscription (may be nil)
 	subscription, _ := middleware.GetSubscriptionFromContext(c)
 
@@ -190,7 +240,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	canWait, err := geminiConcurrency.IncrementWaitCount(c.Request.Context(), authSubject.UserID, maxWait)
 	waitCounted := false
 	if err != nil {
-		log.Printf("Increment wait count failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "increment_wait_count_failed", "error", err)
 	} else if !canWait {
 		googleError(c, http.StatusTooManyRequests, "Too many pending requests, please retry later")
 		return
@@ -222,12 +272,27 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	}
 
 	// 2) billing eligibility check (after wait)
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, modelName); err != nil {
 		status, _, message := billingErrorDetails(err)
 		googleError(c, status, message)
 		return
 	}
 
+	// 2.1) reserve the estimated upper-bound cost; unsettled holds are released on return
+	embedding := service.IsGeminiEmbeddingAction(action)
+	var billingHold *service.BillingHold
+	if embedding {
+		billingHold, err = h.billingCacheService.ReserveEmbeddingBillingHold(c.Request.Context(), apiKey.User, apiKey.Group, subscription, modelName, body)
+	} else {
+		billingHold, err = h.billingCacheService.ReserveBillingHold(c.Request.Context(), apiKey.User, apiKey.Group, subscription, modelName, body)
+	}
+	if err != nil {
+		status, _, message := billingErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+	defer billingHold.Release()
+
 	// 3) select account (sticky session based on request body)
 	// 优先使用 Gemini CLI 的会话标识（privileged-user-id + tmp 目录哈希）
 	sessionHash := extractGeminiCLISessionHash(c, body)
@@ -254,10 +319,34 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	failedAccountIDs := make(map[int64]struct{})
 	lastFailoverStatus := 0
 
+	// Gemini 账号耗尽后，生成类请求可兜底到分组内的 Anthropic 账号（请求/响应经协议翻译）
+	claudeFallbackAllowed := !middleware.HasForcePlatform(c) && (action == "generateContent" || action == "streamGenerateContent")
+	claudeFallback := false
+	switchToClaudeFallback := func() bool {
+		if !claudeFallbackAllowed || claudeFallback {
+			return false
+		}
+		claudeFallback = true
+		switchCount = 0
+		if sessionHash != "" {
+			sessionKey = "gemini-claude:" + sessionHash
+		}
+		slog.InfoContext(c.Request.Context(), "gemini_fallback_to_anthropic_accounts", "model", modelName)
+		return true
+	}
+
 	for {
-		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, failedAccountIDs, "") // Gemini 不使用会话限制
+		var selection *service.AccountSelectionResult
+		if claudeFallback {
+			selection, err = h.gatewayService.SelectGeminiClaudeFallbackAccount(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, failedAccountIDs)
+		} else {
+			selection, err = h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, failedAccountIDs, "") // Gemini 不使用会话限制
+		}
 		if err != nil {
-			if len(failedAccountIDs) == 0 {
+			if switchToClaudeFallback() {
+				continue
+			}
+			if lastFailoverStatus == 0 {
 				googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
 				return
 			}
@@ -265,18 +354,26 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			return
 		}
 		account := selection.Account
-		setOpsSelectedAccount(c, account.ID)
+		// Antigravity 账号不支持嵌入接口，跳过且不计入切换次数
+		if embedding && account.Platform == service.PlatformAntigravity {
+			if selection.Acquired && selection.ReleaseFunc != nil {
+				selection.ReleaseFunc()
+			}
+			failedAccountIDs[account.ID] = struct{}{}
+			continue
+		}
+		setOpsSelectedAccount(c, account, modelName)
 
 		// 检测账号切换：如果粘性会话绑定的账号与当前选择的账号不同，清除 thoughtSignature
 		// 注意：Gemini 原生 API 的 thoughtSignature 与具体上游账号强相关；跨账号透传会导致 400。
 		if sessionBoundAccountID > 0 && sessionBoundAccountID != account.ID {
-			log.Printf("[Gemini] Sticky session account switched: %d -> %d, cleaning thoughtSignature", sessionBoundAccountID, account.ID)
+			slog.InfoContext(c.Request.Context(), "gemini_sticky_account_switched", "from_account_id", sessionBoundAccountID, "account_id", account.ID)
 			body = service.CleanGeminiNativeThoughtSignatures(body)
 			sessionBoundAccountID = account.ID
 		} else if sessionKey != "" && sessionBoundAccountID == 0 && isCLI && !cleanedForUnknownBinding && bytes.Contains(body, []byte(`"thoughtSignature"`)) {
 			// 无缓存绑定但请求里已有 thoughtSignature：常见于缓存丢失/TTL 过期后，CLI 继续携带旧签名。
 			// 为避免第一次转发就 400，这里做一次确定性清理，让新账号重新生成签名链路。
-			log.Printf("[Gemini] Sticky session binding missing for CLI request, cleaning thoughtSignature proactively")
+			slog.InfoContext(c.Request.Context(), "gemini_sticky_binding_missing")
 			body = service.CleanGeminiNativeThoughtSignatures(body)
 			cleanedForUnknownBinding = true
 			sessionBoundAccountID = account.ID
@@ -295,9 +392,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			accountWaitCounted := false
 			canWait, err := geminiConcurrency.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
 			if err != nil {
-				log.Printf("Increment account wait count failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "increment_account_wait_count_failed", "account_id", account.ID, "error", err)
 			} else if !canWait {
-				log.Printf("Account wait queue full: account=%d", account.ID)
+				slog.WarnContext(c.Request.Context(), "account_wait_queue_full", "account_id", account.ID)
 				googleError(c, http.StatusTooManyRequests, "Too many pending requests, please retry later")
 				return
 			}
@@ -327,7 +424,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				accountWaitCounted = false
 			}
 			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
-				log.Printf("Bind sticky session failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "bind_sticky_session_failed", "account_id", account.ID, "error", err)
 			}
 		}
 		// 账号槽位/等待计数需要在超时或断开时安全回收
@@ -335,9 +432,12 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 
 		// 5) forward (根据平台分流)
 		var result *service.ForwardResult
-		if account.Platform == service.PlatformAntigravity {
+		switch account.Platform {
+		case service.PlatformAntigravity:
 			result, err = h.antigravityGatewayService.ForwardGemini(c.Request.Context(), c, account, modelName, action, stream, body)
-		} else {
+		case service.PlatformAnthropic:
+			result, err = h.gatewayService.ForwardGeminiAsClaude(c.Request.Context(), c, account, modelName, stream, body)
+		default:
 			result, err = h.geminiCompatService.ForwardNative(c.Request.Context(), c, account, modelName, action, stream, body)
 		}
 		if accountReleaseFunc != nil {
@@ -349,26 +449,30 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				failedAccountIDs[account.ID] = struct{}{}
 				if switchCount >= maxAccountSwitches {
 					lastFailoverStatus = failoverErr.StatusCode
+					if switchToClaudeFallback() {
+						continue
+					}
 					handleGeminiFailoverExhausted(c, lastFailoverStatus)
 					return
 				}
 				lastFailoverStatus = failoverErr.StatusCode
 				switchCount++
-				log.Printf("Gemini account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
+				slog.WarnContext(c.Request.Context(), "upstream_failover", "account_id", account.ID, "status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
 				continue
 			}
 			// ForwardNative already wrote the response
-			log.Printf("Gemini native forward failed: %v", err)
+			slog.ErrorContext(c.Request.Context(), "forward_failed", "account_id", account.ID, "error", err)
 			return
 		}
 
 		// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
+		usageCtx := logger.Detach(c.Request.Context())
 
 		// 6) record usage async
-		go func(result *service.ForwardResult, usedAccount *service.Account, ua, ip string) {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		go func(result *service.ForwardResult, usedAccount *service.Account, ua, ip string, hold *service.BillingHold) {
+			ctx, cancel := context.WithTimeout(usageCtx, 10*time.Second)
 			defer cancel()
 			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
 				Result:       result,
@@ -378,10 +482,11 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				Subscription: subscription,
 				UserAgent:    ua,
 				IPAddress:    ip,
+				BillingHold:  hold,
 			}); err != nil {
-				log.Printf("Record usage failed: %v", err)
+				slog.ErrorContext(ctx, "record_usage_failed", "account_id", usedAccount.ID, "error", err)
 			}
-		}(result, account, userAgent, clientIP)
+		}(result, account, userAgent, clientIP, billingHold.Detach())
 		return
 	}
 }
@@ -15,14 +15,20 @@ type AdminHandlers struct {
 	GeminiOAuth      *admin.GeminiOAuthHandler
 	AntigravityOAuth *admin.AntigravityOAuthHandler
 	Proxy            *admin.ProxyHandler
+	ProxyPool        *admin.ProxyPoolHandler
+	ContentPolicy    *admin.ContentPolicyHandler
+	SpendAnomaly     *admin.SpendAnomalyHandler
+	CapacityForecast *admin.CapacityForecastHandler
 	Redeem           *admin.RedeemHandler
 	Promo            *admin.PromoHandler
 	Setting          *admin.SettingHandler
 	Ops              *admin.OpsHandler
 	System           *admin.SystemHandler
+	Cluster          *admin.ClusterHandler
 	Subscription     *admin.SubscriptionHandler
 	Usage            *admin.UsageHandler
 	UserAttribute    *admin.UserAttributeHandler
+	UserSession      *admin.UserSessionHandler
 }
 
 // Handlers contains all HTTP handlers
@@ -36,8 +42,12 @@ type Handlers struct {
 	Admin         *AdminHandlers
 	Gateway       *GatewayHandler
 	OpenAIGateway *OpenAIGatewayHandler
+	MessageBatch  *MessageBatchHandler
 	Setting       *SettingHandler
 	Totp          *TotpHandler
+	TwoFactor     *TwoFactorHandler
+	WebAuthn      *WebAuthnHandler
+	Session       *SessionHandler
 }
 
 // BuildInfo contains build-time information
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pkgerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// MessageBatchHandler handles the Anthropic Message Batches API
+type MessageBatchHandler struct {
+	batchService         *service.MessageBatchService
+	billingCacheService  *service.BillingCacheService
+	contentPolicyService *service.ContentPolicyService
+}
+
+// NewMessageBatchHandler creates a new MessageBatchHandler
+func NewMessageBatchHandler(batchService *service.MessageBatchService, billingCacheService *service.BillingCacheService, contentPolicyService *service.ContentPolicyService) *MessageBatchHandler {
+	return &MessageBatchHandler{
+		batchService:         batchService,
+		billingCacheService:  billingCacheService,
+		contentPolicyService: contentPolicyService,
+	}
+}
+
+// Create creates a message batch
+// POST /v1/messages/batches
+func (h *MessageBatchHandler) Create(c *gin.Context) {
+	apiKey, ok := h.requireAnthropicKey(c)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			h.errorResponse(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if len(body) == 0 || !gjson.ValidBytes(body) {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return
+	}
+	requests := gjson.GetBytes(body, "requests")
+	if !requests.IsArray() || len(requests.Array()) == 0 {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "requests must be a non-empty array")
+		return
+	}
+
+	// Model policy and content policy apply to every request in the batch;
+	// aliased models and stripped params are written back before forwarding.
+	for i, item := range requests.Array() {
+		params := []byte(item.Get("params").Raw)
+		model, checked, err := applyGroupModelPolicy(apiKey, item.Get("params.model").String(), params)
+		if err != nil {
+			status, code, message := modelPolicyErrorDetails(err)
+			h.errorResponse(c, status, code, message)
+			return
+		}
+		checked, err = enforceContentPolicy(c, h.contentPolicyService, apiKey, model, checked)
+		if err != nil {
+			status, code, message := contentPolicyErrorDetails(err)
+			h.errorResponse(c, status, code, message)
+			return
+		}
+		if bytes.Equal(checked, params) {
+			continue
+		}
+		if body, err = sjson.SetRawBytes(body, fmt.Sprintf("requests.%d.params", i), checked); err != nil {
+			h.errorResponse(c, http.StatusInternalServerError, "api_error", "Failed to process request body")
+			return
+		}
+	}
+
+	// Check eligibility once per distinct model so per-model-family usage limits apply to batches too.
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+	checked := make(map[string]struct{})
+	for _, item := range gjson.GetBytes(body, "requests").Array() {
+		model := item.Get("params.model").String()
+		if _, ok := checked[model]; ok {
+			continue
+		}
+		checked[model] = struct{}{}
+		if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, model); err != nil {
+			status, code, message := billingErrorDetails(err)
+			h.errorResponse(c, status, code, message)
+			return
+		}
+	}
+
+	resp, err := h.batchService.Create(c.Request.Context(), h.requestContext(c, apiKey), body)
+	h.writeResponse(c, resp, err)
+}
+
+// List lists the message batches created with the current API key
+// GET /v1/messages/batches
+func (h *MessageBatchHandler) List(c *gin.Context) {
+	apiKey, ok := h.requireAnthropicKey(c)
+	if !ok {
+		return
+	}
+
+	params := service.MessageBatchListParams{
+		BeforeID: strings.TrimSpace(c.Query("before_id")),
+		AfterID:  strings.TrimSpace(c.Query("after_id")),
+	}
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > service.MessageBatchMaxListLimit {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error",
+				fmt.Sprintf("limit must be between 1 and %d", service.MessageBatchMaxListLimit))
+			return
+		}
+		params.Limit = limit
+	}
+	if params.BeforeID != "" && params.AfterID != "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "before_id and after_id are mutually exclusive")
+		return
+	}
+
+	body, err := h.batchService.List(c.Request.Context(), h.requestContext(c, apiKey), params)
+	if err != nil {
+		h.writeResponse(c, nil, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+// Retrieve gets a message batch
+// GET /v1/messages/batches/:id
+func (h *MessageBatchHandler) Retrieve(c *gin.Context) {
+	apiKey, ok := h.requireAnthropicKey(c)
+	if !ok {
+		return
+	}
+	resp, err := h.batchService.Retrieve(c.Request.Context(), h.requestContext(c, apiKey), c.Param("id"))
+	h.writeResponse(c, resp, err)
+}
+
+// Cancel cancels a message batch
+// POST /v1/messages/batches/:id/cancel
+func (h *MessageBatchHandler) Cancel(c *gin.Context) {
+	apiKey, ok := h.requireAnthropicKey(c)
+	if !ok {
+		return
+	}
+	resp, err := h.batchService.Cancel(c.Request.Context(), h.requestContext(c, apiKey), c.Param("id"))
+	h.writeResponse(c, resp, err)
+}
+
+// Results streams the results of a message batch (JSONL)
+// GET /v1/messages/batches/:id/results
+func (h *MessageBatchHandler) Results(c *gin.Context) {
+	apiKey, ok := h.requireAnthropicKey(c)
+	if !ok {
+		return
+	}
+
+	start := func(contentType string) {
+		c.Header("Content-Type", contentType)
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+	}
+	resp, err := h.batchService.Results(c.Request.Context(), h.requestContext(c, apiKey), c.Param("id"), start, c.Writer)
+	if err != nil || resp != nil {
+		h.writeResponse(c, resp, err)
+	}
+}
+
+func (h *MessageBatchHandler) requireAnthropicKey(c *gin.Context) (*service.APIKey, bool) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return nil, false
+	}
+	if apiKey.Group != nil && apiKey.Group.Platform != service.PlatformAnthropic {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Message batches are only available for Anthropic groups")
+		return nil, false
+	}
+	return apiKey, true
+}
+
+func (h *MessageBatchHandler) requestContext(c *gin.Context, apiKey *service.APIKey) *service.MessageBatchRequestContext {
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+	return &service.MessageBatchRequestContext{
+		APIKey:        apiKey,
+		Subscription:  subscription,
+		Header:        c.Request.Header,
+		PublicBaseURL: derivePublicBaseURL(c),
+	}
+}
+
+// writeResponse relays the upstream response, or maps a service error to an Anthropic-style error
+func (h *MessageBatchHandler) writeResponse(c *gin.Context, resp *service.MessageBatchUpstreamResponse, err error) {
+	if err != nil {
+		if c.Writer.Written() {
+			return
+		}
+		code := pkgerrors.Code(err)
+		switch {
+		case errors.Is(err, service.ErrMessageBatchNotFound):
+			h.errorResponse(c, http.StatusNotFound, "not_found_error", pkgerrors.Message(err))
+		case code == http.StatusServiceUnavailable:
+			h.errorResponse(c, http.StatusServiceUnavailable, "api_error", pkgerrors.Message(err))
+		default:
+			log.Printf("Message batch request failed: %v", err)
+			h.errorResponse(c, http.StatusBadGateway, "api_error", "Upstream request failed")
+		}
+		return
+	}
+	contentType := resp.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(resp.StatusCode, contentType, resp.Body)
+}
+
+func (h *MessageBatchHandler) errorResponse(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"type": "error",
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
+
+// derivePublicBaseURL returns the externally visible base URL of the gateway (honors reverse proxy headers)
+func derivePublicBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if xfProto := strings.TrimSpace(c.GetHeader("X-Forwarded-Proto")); xfProto != "" {
+		scheme = strings.TrimSpace(strings.Split(xfProto, ",")[0])
+	}
+
+	host := strings.TrimSpace(c.Request.Host)
+	if xfHost := strings.TrimSpace(c.GetHeader("X-Forwarded-Host")); xfHost != "" {
+		host = strings.TrimSpace(strings.Split(xfHost, ",")[0])
+	}
+	return fmt.Sprintf("%s://%s", scheme, host)
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// Embeddings handles OpenAI Embeddings API endpoint (API key accounts only)
+// POST /v1/embeddings
+func (h *OpenAIGatewayHandler) Embeddings(c *gin.Context) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+
+	if apiKey.Group != nil && apiKey.Group.Platform != service.PlatformOpenAI {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Embeddings are only available for OpenAI groups")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			h.errorResponse(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if len(body) == 0 {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
+		return
+	}
+	if !gjson.ValidBytes(body) {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return
+	}
+
+	reqModel := gjson.GetBytes(body, "model").String()
+	if reqModel == "" {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return
+	}
+	if !gjson.GetBytes(body, "input").Exists() {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "input is required")
+		return
+	}
+
+	// Group model aliases and access policy (enforced before account selection)
+	reqModel, body, err = applyGroupModelPolicy(apiKey, reqModel, body)
+	if err != nil {
+		status, code, message := modelPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	body, err = enforceContentPolicy(c, h.contentPolicyService, apiKey, reqModel, body)
+	if err != nil {
+		status, code, message := contentPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	setOpsRequestContext(c, reqModel, false, body)
+
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+
+	// 0. Check if wait queue is full
+	maxWait := service.CalculateMaxWait(subject.Concurrency)
+	canWait, err := h.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
+	waitCounted := false
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "increment_wait_count_failed", "error", err)
+	} else if !canWait {
+		h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
+		return
+	}
+	if err == nil && canWait {
+		waitCounted = true
+	}
+	defer func() {
+		if waitCounted {
+			h.concurrencyHelper.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		}
+	}()
+
+	// 1. Acquire user concurrency slot
+	streamStarted := false
+	userReleaseFunc, err := h.concurrencyHelper.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, false, &streamStarted)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "user_concurrency_acquire_failed", "error", err)
+		h.handleConcurrencyError(c, err, "user", false)
+		return
+	}
+	if waitCounted {
+		h.concurrencyHelper.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		waitCounted = false
+	}
+	userReleaseFunc = wrapReleaseOnDone(c.Request.Context(), userReleaseFunc)
+	if userReleaseFunc != nil {
+		defer userReleaseFunc()
+	}
+
+	// 2. Re-check billing eligibility after wait
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, reqModel); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	// 2.1 Reserve the estimated cost (input tokens at embedding prices)
+	billingHold, err := h.billingCacheService.ReserveEmbeddingBillingHold(c.Request.Context(), apiKey.User, apiKey.Group, subscription, reqModel, body)
+	if err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	defer billingHold.Release()
+
+	// OAuth (ChatGPT) accounts cannot serve embeddings; exclude them up front
+	failedAccountIDs := h.gatewayService.APIKeyOnlyExcludedAccountIDs(c.Request.Context(), apiKey.GroupID)
+	maxAccountSwitches := h.maxAccountSwitches
+	switchCount := 0
+	lastFailoverStatus := 0
+
+	for {
+		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, "", reqModel, failedAccountIDs)
+		if err != nil {
+			if lastFailoverStatus == 0 {
+				h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error())
+				return
+			}
+			h.handleFailoverExhausted(c, lastFailoverStatus, false)
+			return
+		}
+		account := selection.Account
+		setOpsSelectedAccount(c, account, reqModel)
+
+		// 3. Acquire account concurrency slot
+		accountReleaseFunc := selection.ReleaseFunc
+		if !selection.Acquired {
+			if selection.WaitPlan == nil {
+				h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts")
+				return
+			}
+			accountWaitCounted := false
+			canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
+			if err != nil {
+				slog.WarnContext(c.Request.Context(), "increment_account_wait_count_failed", "account_id", account.ID, "error", err)
+			} else if !canWait {
+				h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
+				return
+			}
+			if err == nil && canWait {
+				accountWaitCounted = true
+			}
+			defer func() {
+				if accountWaitCounted {
+					h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+				}
+			}()
+
+			accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
+				c,
+				account.ID,
+				selection.WaitPlan.MaxConcurrency,
+				selection.WaitPlan.Timeout,
+				false,
+				&streamStarted,
+			)
+			if err != nil {
+				slog.WarnContext(c.Request.Context(), "account_concurrency_acquire_failed", "account_id", account.ID, "error", err)
+				h.handleConcurrencyError(c, err, "account", false)
+				return
+			}
+			if accountWaitCounted {
+				h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+				accountWaitCounted = false
+			}
+		}
+		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
+
+		result, err := h.gatewayService.ForwardEmbeddings(c.Request.Context(), c, account, body)
+		if accountReleaseFunc != nil {
+			accountReleaseFunc()
+		}
+		if err != nil {
+			var failoverErr *service.UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				failedAccountIDs[account.ID] = struct{}{}
+				lastFailoverStatus = failoverErr.StatusCode
+				if switchCount >= maxAccountSwitches {
+					h.handleFailoverExhausted(c, lastFailoverStatus, false)
+					return
+				}
+				switchCount++
+				slog.WarnContext(c.Request.Context(), "upstream_failover", "account_id", account.ID, "status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
+				continue
+			}
+			slog.ErrorContext(c.Request.Context(), "embeddings_forward_failed", "account_id", account.ID, "error", err)
+			return
+		}
+
+		userAgent := c.GetHeader("User-Agent")
+		clientIP := ip.GetClientIP(c)
+		usageCtx := logger.Detach(c.Request.Context())
+
+		go func(result *service.OpenAIForwardResult, usedAccount *service.Account, ua, ip string, hold *service.BillingHold) {
+			ctx, cancel := context.WithTimeout(usageCtx, 10*time.Second)
+			defer cancel()
+			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
+				Result:       result,
+				APIKey:       apiKey,
+				User:         apiKey.User,
+				Account:      usedAccount,
+				Subscription: subscription,
+				UserAgent:    ua,
+				IPAddress:    ip,
+				BillingHold:  hold,
+			}); err != nil {
+				slog.ErrorContext(ctx, "record_usage_failed", "account_id", usedAccount.ID, "error", err)
+			}
+		}(result, account, userAgent, clientIP, billingHold.Detach())
+		return
+	}
+}
@@ -1,18 +1,20 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
@@ -22,32 +24,49 @@ import (
 
 // OpenAIGatewayHandler handles OpenAI API gateway requests
 type OpenAIGatewayHandler struct {
-	gatewayService      *service.OpenAIGatewayService
-	billingCacheService *service.BillingCacheService
-	concurrencyHelper   *ConcurrencyHelper
-	maxAccountSwitches  int
+	gatewayService       *service.OpenAIGatewayService
+	billingCacheService  *service.BillingCacheService
+	contentPolicyService *service.ContentPolicyService
+	concurrencyHelper    *ConcurrencyHelper
+	maxAccountSwitches   int
+
+	// 图片接口在 gemini/antigravity 分组下经翻译转发，复用通用网关的账号调度与用量记录
+	geminiGatewayService     *service.GatewayService
+	imageService             *service.ImageGenerationService
+	maxAccountSwitchesGemini int
 }
 
 // NewOpenAIGatewayHandler creates a new OpenAIGatewayHandler
 func NewOpenAIGatewayHandler(
 	gatewayService *service.OpenAIGatewayService,
+	geminiGatewayService *service.GatewayService,
+	imageService *service.ImageGenerationService,
 	concurrencyService *service.ConcurrencyService,
 	billingCacheService *service.BillingCacheService,
+	contentPolicyService *service.ContentPolicyService,
 	cfg *config.Config,
 ) *OpenAIGatewayHandler {
 	pingInterval := time.Duration(0)
 	maxAccountSwitches := 3
+	maxAccountSwitchesGemini := 3
 	if cfg != nil {
 		pingInterval = time.Duration(cfg.Concurrency.PingInterval) * time.Second
 		if cfg.Gateway.MaxAccountSwitches > 0 {
 			maxAccountSwitches = cfg.Gateway.MaxAccountSwitches
 		}
+		if cfg.Gateway.MaxAccountSwitchesGemini > 0 {
+			maxAccountSwitchesGemini = cfg.Gateway.MaxAccountSwitchesGemini
+		}
 	}
 	return &OpenAIGatewayHandler{
-		gatewayService:      gatewayService,
-		billingCacheService: billingCacheService,
-		concurrencyHelper:   NewConcurrencyHelper(concurrencyService, SSEPingFormatComment, pingInterval),
-		maxAccountSwitches:  maxAccountSwitches,
+		gatewayService:           gatewayService,
+		billingCacheService:      billingCacheService,
+		contentPolicyService:     contentPolicyService,
+		concurrencyHelper:        NewConcurrencyHelper(concurrencyService, SSEPingFormatComment, pingInterval),
+		maxAccountSwitches:       maxAccountSwitches,
+		geminiGatewayService:     geminiGatewayService,
+		imageService:             imageService,
+		maxAccountSwitchesGemini: maxAccountSwitchesGemini,
 	}
 }
 
@@ -102,6 +121,19 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		return
 	}
 
+	// Group model aliases and access policy (enforced before account selection)
+	resolvedModel, resolvedBody, err := applyGroupModelPolicy(apiKey, reqModel, body)
+	if err != nil {
+		status, code, message := modelPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	if resolvedModel != reqModel {
+		reqModel = resolvedModel
+		reqBody["model"] = resolvedModel
+		body = resolvedBody
+	}
+
 	userAgent := c.GetHeader("User-Agent")
 	if !openai.IsCodexCLIRequest(userAgent) {
 		existingInstructions, _ := reqBody["instructions"].(string)
@@ -127,19 +159,37 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		previousResponseID, _ := reqBody["previous_response_id"].(string)
 		if strings.TrimSpace(previousResponseID) == "" && !service.HasToolCallContext(reqBody) {
 			if service.HasFunctionCallOutputMissingCallID(reqBody) {
-				log.Printf("[OpenAI Handler] function_call_output 缺少 call_id: model=%s", reqModel)
+				slog.WarnContext(c.Request.Context(), "function_call_output_missing_call_id", "model", reqModel)
 				h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "function_call_output requires call_id or previous_response_id; if relying on history, ensure store=true and reuse previous_response_id")
 				return
 			}
 			callIDs := service.FunctionCallOutputCallIDs(reqBody)
 			if !service.HasItemReferenceForCallIDs(reqBody, callIDs) {
-				log.Printf("[OpenAI Handler] function_call_output 缺少匹配的 item_reference: model=%s", reqModel)
+				slog.WarnContext(c.Request.Context(), "function_call_output_missing_item_reference", "model", reqModel)
 				h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "function_call_output requires item_reference ids matching each call_id, or previous_response_id/tool_call context; if relying on history, ensure store=true and reuse previous_response_id")
 				return
 			}
 		}
 	}
 
+	// Content policy check and group request rewrite (either may change the body)
+	rewrittenBody, err := enforceContentPolicy(c, h.contentPolicyService, apiKey, reqModel, body)
+	if err != nil {
+		status, code, message := contentPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	rewrittenBody = applyGroupRequestRewrite(apiKey, service.RewriteFormatResponses, reqModel, rewrittenBody)
+	if !bytes.Equal(rewrittenBody, body) {
+		body = rewrittenBody
+		reqBody = nil
+		if err := json.Unmarshal(body, &reqBody); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+			return
+		}
+		setOpsRequestContext(c, reqModel, reqStream, body)
+	}
+
 	// Track if we've started streaming (for error handling)
 	streamStarted := false
 
@@ -151,7 +201,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	canWait, err := h.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
 	waitCounted := false
 	if err != nil {
-		log.Printf("Increment wait count failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "increment_wait_count_failed", "error", err)
 		// On error, allow request to proceed
 	} else if !canWait {
 		h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
@@ -169,7 +219,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	// 1. First acquire user concurrency slot
 	userReleaseFunc, err := h.concurrencyHelper.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, reqStream, &streamStarted)
 	if err != nil {
-		log.Printf("User concurrency acquire failed: %v", err)
+		slog.WarnContext(c.Request.Context(), "user_concurrency_acquire_failed", "error", err)
 		h.handleConcurrencyError(c, err, "user", streamStarted)
 		return
 	}
@@ -185,12 +235,22 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	}
 
 	// 2. Re-check billing eligibility after wait
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
-		log.Printf("Billing eligibility check failed after wait: %v", err)
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, reqModel); err != nil {
+		slog.WarnContext(c.Request.Context(), "billing_eligibility_check_failed", "error", err)
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, streamStarted)
+		return
+	}
+
+	// 2.1 Reserve the estimated upper-bound cost; unsettled holds are released on return
+	billingHold, err := h.billingCacheService.ReserveBillingHold(c.Request.Context(), apiKey.User, apiKey.Group, subscription, reqModel, body)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "billing_hold_rejected", "error", err)
 		status, code, message := billingErrorDetails(err)
 		h.handleStreamingAwareError(c, status, code, message, streamStarted)
 		return
 	}
+	defer billingHold.Release()
 
 	// Generate session hash (header first; fallback to prompt_cache_key)
 	sessionHash := h.gatewayService.GenerateSessionHash(c, reqBody)
@@ -202,10 +262,10 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 
 	for {
 		// Select account supporting the requested model
-		log.Printf("[OpenAI Handler] Selecting account: groupID=%v model=%s", apiKey.GroupID, reqModel)
+		slog.DebugContext(c.Request.Context(), "openai_selecting_account", "group_id", apiKey.GroupID, "model", reqModel)
 		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionHash, reqModel, failedAccountIDs)
 		if err != nil {
-			log.Printf("[OpenAI Handler] SelectAccount failed: %v", err)
+			slog.WarnContext(c.Request.Context(), "openai_select_account_failed", "error", err)
 			if len(failedAccountIDs) == 0 {
 				h.handleStreamingAwareError(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error(), streamStarted)
 				return
@@ -214,8 +274,8 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 			return
 		}
 		account := selection.Account
-		log.Printf("[OpenAI Handler] Selected account: id=%d name=%s", account.ID, account.Name)
-		setOpsSelectedAccount(c, account.ID)
+		slog.DebugContext(c.Request.Context(), "openai_selected_account", "account_id", account.ID, "account_name", account.Name)
+		setOpsSelectedAccount(c, account, reqModel)
 
 		// 3. Acquire account concurrency slot
 		accountReleaseFunc := selection.ReleaseFunc
@@ -227,9 +287,9 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 			accountWaitCounted := false
 			canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
 			if err != nil {
-				log.Printf("Increment account wait count failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "increment_account_wait_count_failed", "account_id", account.ID, "error", err)
 			} else if !canWait {
-				log.Printf("Account wait queue full: account=%d", account.ID)
+				slog.WarnContext(c.Request.Context(), "account_wait_queue_full", "account_id", account.ID)
 				h.handleStreamingAwareError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later", streamStarted)
 				return
 			}
@@ -251,7 +311,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				&streamStarted,
 			)
 			if err != nil {
-				log.Printf("Account concurrency acquire failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "account_concurrency_acquire_failed", "account_id", account.ID, "error", err)
 				h.handleConcurrencyError(c, err, "account", streamStarted)
 				return
 			}
@@ -260,7 +320,7 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				accountWaitCounted = false
 			}
 			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionHash, account.ID); err != nil {
-				log.Printf("Bind sticky session failed: %v", err)
+				slog.WarnContext(c.Request.Context(), "bind_sticky_session_failed", "account_id", account.ID, "error", err)
 			}
 		}
 		// 账号槽位/等待计数需要在超时或断开时安全回收
@@ -282,21 +342,22 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				}
 				lastFailoverStatus = failoverErr.StatusCode
 				switchCount++
-				log.Printf("Account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, maxAccountSwitches)
+				slog.WarnContext(c.Request.Context(), "upstream_failover", "account_id", account.ID, "status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
 				continue
 			}
 			// Error response already handled in Forward, just log
-			log.Printf("Account %d: Forward request failed: %v", account.ID, err)
+			slog.ErrorContext(c.Request.Context(), "forward_failed", "account_id", account.ID, "error", err)
 			return
 		}
 
 		// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
+		usageCtx := logger.Detach(c.Request.Context())
 
 		// Async record usage
-		go func(result *service.OpenAIForwardResult, usedAccount *service.Account, ua, ip string) {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		go func(result *service.OpenAIForwardResult, usedAccount *service.Account, ua, ip string, hold *service.BillingHold) {
+			ctx, cancel := context.WithTimeout(usageCtx, 10*time.Second)
 			defer cancel()
 			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
 				Result:       result,
@@ -306,10 +367,11 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				Subscription: subscription,
 				UserAgent:    ua,
 				IPAddress:    ip,
+				BillingHold:  hold,
 			}); err != nil {
-				log.Printf("Record usage failed: %v", err)
+				slog.ErrorContext(ctx, "record_usage_failed", "account_id", usedAccount.ID, "error", err)
 			}
-		}(result, account, userAgent, clientIP)
+		}(result, account, userAgent, clientIP, billingHold.Detach())
 		return
 	}
 }
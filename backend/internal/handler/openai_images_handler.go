@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// imageUsageRecorder records usage for a completed image request (called asynchronously)
+type imageUsageRecorder func(ctx context.Context, account *service.Account, ua, ip string, hold *service.BillingHold) error
+
+// ImageGenerations handles OpenAI Images API generation endpoint
+// POST /v1/images/generations
+func (h *OpenAIGatewayHandler) ImageGenerations(c *gin.Context) {
+	h.handleImages(c, false)
+}
+
+// ImageEdits handles OpenAI Images API edit endpoint
+// POST /v1/images/edits
+func (h *OpenAIGatewayHandler) ImageEdits(c *gin.Context) {
+	h.handleImages(c, true)
+}
+
+// handleImages serves image requests from OpenAI API key accounts (passthrough)
+// or from Gemini/Antigravity image models (translated to generateContent).
+func (h *OpenAIGatewayHandler) handleImages(c *gin.Context, edit bool) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+
+	platform := service.PlatformOpenAI
+	if apiKey.Group != nil {
+		platform = apiKey.Group.Platform
+	}
+	switch platform {
+	case service.PlatformOpenAI, service.PlatformGemini, service.PlatformAntigravity:
+	default:
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Image generation is not available for this group")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			h.errorResponse(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if len(body) == 0 {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
+		return
+	}
+
+	req, err := service.ParseImageGenerationRequest(body, c.GetHeader("Content-Type"), edit)
+	if err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", infraerrors.Message(err))
+		return
+	}
+	resolvedModel, err := apiKey.Group.ResolveRequestModel(req.Model)
+	if err != nil {
+		status, code, message := modelPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	if err := req.SetModel(resolvedModel); err != nil {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to process request body")
+		return
+	}
+	if !apiKey.Group.IsImageModelAllowed(req.Model) {
+		h.errorResponse(c, http.StatusForbidden, "permission_error", fmt.Sprintf("Image model %s is not allowed for this group", req.Model))
+		return
+	}
+	if err := enforceImageContentPolicy(c, h.contentPolicyService, apiKey, req); err != nil {
+		status, code, message := contentPolicyErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	if platform != service.PlatformOpenAI {
+		if err := h.imageService.ValidateGeminiRequest(req); err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", infraerrors.Message(err))
+			return
+		}
+	}
+
+	// multipart bodies carry raw image bytes; keep them out of ops error logs
+	var opsBody []byte
+	if !req.Multipart {
+		opsBody = body
+	}
+	setOpsRequestContext(c, req.Model, false, opsBody)
+
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+
+	// 0. Check if wait queue is full
+	maxWait := service.CalculateMaxWait(subject.Concurrency)
+	canWait, err := h.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
+	waitCounted := false
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "increment_wait_count_failed", "error", err)
+	} else if !canWait {
+		h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
+		return
+	}
+	if err == nil && canWait {
+		waitCounted = true
+	}
+	defer func() {
+		if waitCounted {
+			h.concurrencyHelper.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		}
+	}()
+
+	// 1. Acquire user concurrency slot
+	streamStarted := false
+	userReleaseFunc, err := h.concurrencyHelper.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, false, &streamStarted)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "user_concurrency_acquire_failed", "error", err)
+		h.handleConcurrencyError(c, err, "user", false)
+		return
+	}
+	if waitCounted {
+		h.concurrencyHelper.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		waitCounted = false
+	}
+	userReleaseFunc = wrapReleaseOnDone(c.Request.Context(), userReleaseFunc)
+	if userReleaseFunc != nil {
+		defer userReleaseFunc()
+	}
+
+	// 2. Re-check billing eligibility after wait
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, req.Model); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+
+	// 2.1 Reserve the estimated cost (requested image count at the size tier price)
+	billingHold, err := h.billingCacheService.ReserveImageBillingHold(c.Request.Context(), apiKey.User, apiKey.Group, subscription, req.Model, req.SizeTier(), req.N)
+	if err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.errorResponse(c, status, code, message)
+		return
+	}
+	defer billingHold.Release()
+
+	var (
+		failedAccountIDs   map[int64]struct{}
+		maxAccountSwitches int
+		selectAccount      func(excluded map[int64]struct{}) (*service.AccountSelectionResult, error)
+		forward            func(account *service.Account) (imageUsageRecorder, error)
+	)
+	if platform == service.PlatformOpenAI {
+		// OAuth (ChatGPT) accounts cannot serve the Images API; exclude them up front
+		failedAccountIDs = h.gatewayService.APIKeyOnlyExcludedAccountIDs(c.Request.Context(), apiKey.GroupID)
+		maxAccountSwitches = h.maxAccountSwitches
+		selectAccount = func(excluded map[int64]struct{}) (*service.AccountSelectionResult, error) {
+			return h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, "", req.Model, excluded)
+		}
+		forward = func(account *service.Account) (imageUsageRecorder, error) {
+			result, err := h.gatewayService.ForwardImages(c.Request.Context(), c, account, req)
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, usedAccount *service.Account, ua, ip string, hold *service.BillingHold) error {
+				return h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
+					Result:       result,
+					APIKey:       apiKey,
+					User:         apiKey.User,
+					Account:      usedAccount,
+					Subscription: subscription,
+					UserAgent:    ua,
+					IPAddress:    ip,
+					BillingHold:  hold,
+				})
+			}, nil
+		}
+	} else {
+		failedAccountIDs = make(map[int64]struct{})
+		maxAccountSwitches = h.maxAccountSwitchesGemini
+		selectAccount = func(excluded map[int64]struct{}) (*service.AccountSelectionResult, error) {
+			return h.geminiGatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, "", req.Model, excluded, "")
+		}
+		forward = func(account *service.Account) (imageUsageRecorder, error) {
+			result, err := h.imageService.ForwardGemini(c.Request.Context(), c, account, req)
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, usedAccount *service.Account, ua, ip string, hold *service.BillingHold) error {
+				return h.geminiGatewayService.RecordUsage(ctx, &service.RecordUsageInput{
+					Result:       result,
+					APIKey:       apiKey,
+					User:         apiKey.User,
+					Account:      usedAccount,
+					Subscription: subscription,
+					UserAgent:    ua,
+					IPAddress:    ip,
+					BillingHold:  hold,
+				})
+			}, nil
+		}
+	}
+
+	switchCount := 0
+	lastFailoverStatus := 0
+
+	for {
+		selection, err := selectAccount(failedAccountIDs)
+		if err != nil {
+			if lastFailoverStatus == 0 {
+				h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error())
+				return
+			}
+			h.handleFailoverExhausted(c, lastFailoverStatus, false)
+			return
+		}
+		account := selection.Account
+		setOpsSelectedAccount(c, account, req.Model)
+
+		// 3. Acquire account concurrency slot
+		accountReleaseFunc := selection.ReleaseFunc
+		if !selection.Acquired {
+			if selection.WaitPlan == nil {
+				h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts")
+				return
+			}
+			accountWaitCounted := false
+			canWait, err := h.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
+			if err != nil {
+				slog.WarnContext(c.Request.Context(), "increment_account_wait_count_failed", "account_id", account.ID, "error", err)
+			} else if !canWait {
+				h.errorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
+				return
+			}
+			if err == nil && canWait {
+				accountWaitCounted = true
+			}
+			defer func() {
+				if accountWaitCounted {
+					h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+				}
+			}()
+
+			accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
+				c,
+				account.ID,
+				selection.WaitPlan.MaxConcurrency,
+				selection.WaitPlan.Timeout,
+				false,
+				&streamStarted,
+			)
+			if err != nil {
+				slog.WarnContext(c.Request.Context(), "account_concurrency_acquire_failed", "account_id", account.ID, "error", err)
+				h.handleConcurrencyError(c, err, "account", false)
+				return
+			}
+			if accountWaitCounted {
+				h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+				accountWaitCounted = false
+			}
+		}
+		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
+
+		recordUsage, err := forward(account)
+		if accountReleaseFunc != nil {
+			accountReleaseFunc()
+		}
+		if err != nil {
+			var failoverErr *service.UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				failedAccountIDs[account.ID] = struct{}{}
+				lastFailoverStatus = failoverErr.StatusCode
+				if switchCount >= maxAccountSwitches {
+					h.handleFailoverExhausted(c, lastFailoverStatus, false)
+					return
+				}
+				switchCount++
+				slog.WarnContext(c.Request.Context(), "upstream_failover", "account_id", account.ID, "status", failoverErr.StatusCode, "switch_count", switchCount, "max_switches", maxAccountSwitches)
+				continue
+			}
+			slog.ErrorContext(c.Request.Context(), "images_forward_failed", "account_id", account.ID, "error", err)
+			return
+		}
+
+		userAgent := c.GetHeader("User-Agent")
+		clientIP := ip.GetClientIP(c)
+		usageCtx := logger.Detach(c.Request.Context())
+
+		go func(usedAccount *service.Account, ua, ip string, hold *service.BillingHold) {
+			ctx, cancel := context.WithTimeout(usageCtx, 10*time.Second)
+			defer cancel()
+			if err := recordUsage(ctx, usedAccount, ua, ip, hold); err != nil {
+				slog.ErrorContext(ctx, "record_usage_failed", "account_id", usedAccount.ID, "error", err)
+			}
+		}(account, userAgent, clientIP, billingHold.Detach())
+		return
+	}
+}
+
+// enforceImageContentPolicy runs the group's content policy against the image prompt
+// and writes a stripped prompt back into the request (JSON or multipart).
+func enforceImageContentPolicy(c *gin.Context, svc *service.ContentPolicyService, apiKey *service.APIKey, req *service.ImageGenerationRequest) error {
+	checkBody, err := json.Marshal(map[string]string{"prompt": req.Prompt})
+	if err != nil {
+		return err
+	}
+	out, err := enforceContentPolicy(c, svc, apiKey, req.Model, checkBody)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(out, checkBody) {
+		return nil
+	}
+	return req.SetPrompt(gjson.GetBytes(out, "prompt").String())
+}
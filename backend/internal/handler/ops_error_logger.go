@@ -16,6 +16,8 @@ import (
 
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/gin-gonic/gin"
@@ -260,13 +262,33 @@ func setOpsRequestContext(c *gin.Context, model string, stream bool, requestBody
 	if len(requestBody) > 0 {
 		c.Set(opsRequestBodyKey, requestBody)
 	}
+	if model != "" {
+		setRequestLogFields(c, "model", model, "stream", stream)
+	}
+}
+
+// setOpsSelectedAccount records the selected account for ops logging and attaches
+// account_id/platform/model to the request log context before forwarding.
+func setOpsSelectedAccount(c *gin.Context, account *service.Account, model string) {
+	if c == nil || account == nil || account.ID <= 0 {
+		return
+	}
+	c.Set(opsAccountIDKey, account.ID)
+	countGatewayAccountSelection(c)
+	if model != "" {
+		setRequestLogFields(c, "account_id", account.ID, "platform", account.Platform, "model", model)
+		return
+	}
+	setRequestLogFields(c, "account_id", account.ID, "platform", account.Platform)
 }
 
-func setOpsSelectedAccount(c *gin.Context, accountID int64) {
-	if c == nil || accountID <= 0 {
+// setRequestLogFields attaches fields to the request log context; gateway log lines written
+// with c.Request.Context() afterwards (including those in services) carry them.
+func setRequestLogFields(c *gin.Context, args ...any) {
+	if c.Request == nil {
 		return
 	}
-	c.Set(opsAccountIDKey, accountID)
+	c.Request = c.Request.WithContext(logger.With(c.Request.Context(), args...))
 }
 
 type opsCaptureWriter struct {
@@ -471,6 +493,7 @@ func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 			entry := &service.OpsInsertErrorLogInput{
 				RequestID:       requestID,
 				ClientRequestID: clientRequestID,
+				TraceID:         tracing.TraceID(c.Request.Context()),
 
 				AccountID: accountID,
 				Platform:  platform,
@@ -587,6 +610,7 @@ func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 		entry := &service.OpsInsertErrorLogInput{
 			RequestID:       requestID,
 			ClientRequestID: clientRequestID,
+			TraceID:         tracing.TraceID(c.Request.Context()),
 
 			AccountID: accountID,
 			Platform:  platform,
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler handles login session management for the current user
+type SessionHandler struct {
+	sessionService *service.SessionService
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(sessionService *service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// List returns the active login sessions of the current user
+// GET /api/v1/user/sessions
+func (h *SessionHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sessions, err := h.sessionService.ListActive(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	currentSessionID := middleware2.GetSessionIDFromContext(c)
+	out := make([]dto.UserSession, 0, len(sessions))
+	for i := range sessions {
+		out = append(out, *dto.UserSessionFromService(&sessions[i], currentSessionID))
+	}
+	response.Success(c, out)
+}
+
+// Revoke revokes one of the current user's sessions
+// DELETE /api/v1/user/sessions/:session_id
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	if sessionID == "" {
+		response.BadRequest(c, "Invalid session ID")
+		return
+	}
+
+	if err := h.sessionService.Revoke(c.Request.Context(), subject.UserID, sessionID, service.SessionRevokeReasonUserRevoked); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeOthers revokes all sessions of the current user except the current one
+// POST /api/v1/user/sessions/revoke-others
+func (h *SessionHandler) RevokeOthers(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeAllForUser(c.Request.Context(), subject.UserID, middleware2.GetSessionIDFromContext(c), service.SessionRevokeReasonUserRevoked)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"revoked": revoked})
+}
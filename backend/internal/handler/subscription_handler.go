@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
@@ -30,6 +33,24 @@ type SubscriptionProgressInfo struct {
 	Progress     *service.SubscriptionProgress `json:"progress"`
 }
 
+// SetAutoRenewRequest represents an auto-renew toggle request
+type SetAutoRenewRequest struct {
+	AutoRenew *bool `json:"auto_renew" binding:"required"`
+}
+
+// ChangePlanRequest represents a subscription upgrade/downgrade request
+type ChangePlanRequest struct {
+	GroupID int64 `json:"group_id" binding:"required"`
+	// DryRun only returns the prorated quote without changing anything
+	DryRun bool `json:"dry_run"`
+}
+
+// ChangePlanResponse represents the result of a subscription plan change
+type ChangePlanResponse struct {
+	Subscription *dto.UserSubscription                `json:"subscription,omitempty"`
+	Quote        *service.SubscriptionPlanChangeQuote `json:"quote"`
+}
+
 // SubscriptionHandler handles user subscription operations
 type SubscriptionHandler struct {
 	subscriptionService *service.SubscriptionService
@@ -186,3 +207,132 @@ func (h *SubscriptionHandler) GetSummary(c *gin.Context) {
 
 	response.Success(c, summary)
 }
+
+// SetAutoRenew handles toggling auto-renewal of current user's subscription
+// PUT /api/v1/subscriptions/:id/auto-renew
+func (h *SubscriptionHandler) SetAutoRenew(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	var req SetAutoRenewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	subscription, err := h.subscriptionService.SetAutoRenew(c.Request.Context(), subscriptionID, subject.UserID, *req.AutoRenew)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserSubscriptionFromService(subscription))
+}
+
+// ChangePlan handles upgrading/downgrading current user's subscription to another group
+// POST /api/v1/subscriptions/:id/change
+func (h *SubscriptionHandler) ChangePlan(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	var req ChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if req.DryRun {
+		quote, err := h.subscriptionService.QuotePlanChange(c.Request.Context(), subscriptionID, subject.UserID, req.GroupID)
+		if err != nil {
+			response.ErrorFrom(c, err)
+			return
+		}
+		response.Success(c, ChangePlanResponse{Quote: quote})
+		return
+	}
+
+	subscription, quote, err := h.subscriptionService.ChangePlan(c.Request.Context(), subscriptionID, subject.UserID, req.GroupID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, ChangePlanResponse{
+		Subscription: dto.UserSubscriptionFromService(subscription),
+		Quote:        quote,
+	})
+}
+
+// Pause handles pausing current user's subscription
+// POST /api/v1/subscriptions/:id/pause
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	h.updatePauseState(c, h.subscriptionService.PauseSubscription)
+}
+
+// Resume handles resuming current user's paused subscription
+// POST /api/v1/subscriptions/:id/resume
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	h.updatePauseState(c, h.subscriptionService.ResumeSubscription)
+}
+
+func (h *SubscriptionHandler) updatePauseState(c *gin.Context, fn func(ctx context.Context, subscriptionID, userID int64) (*service.UserSubscription, error)) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	subscription, err := fn(c.Request.Context(), subscriptionID, subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserSubscriptionFromService(subscription))
+}
+
+// History handles listing renewal/plan change history of current user's subscription
+// GET /api/v1/subscriptions/:id/history
+func (h *SubscriptionHandler) History(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	entries, result, err := h.subscriptionService.ListSubscriptionHistory(c.Request.Context(), subscriptionID, subject.UserID, page, pageSize)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SubscriptionHistoryEntry, 0, len(entries))
+	for i := range entries {
+		out = append(out, *dto.SubscriptionHistoryEntryFromService(&entries[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
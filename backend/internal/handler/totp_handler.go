@@ -119,12 +119,18 @@ func (h *TotpHandler) Enable(c *gin.Context) {
 		return
 	}
 
-	if err := h.totpService.CompleteSetup(c.Request.Context(), subject.UserID, req.TotpCode, req.SetupToken); err != nil {
+	recoveryCodes, err := h.totpService.CompleteSetup(c.Request.Context(), subject.UserID, req.TotpCode, req.SetupToken)
+	if err != nil {
 		response.ErrorFrom(c, err)
 		return
 	}
 
-	response.Success(c, gin.H{"success": true})
+	resp := gin.H{"success": true}
+	if len(recoveryCodes) > 0 {
+		// 恢复码仅在此处返回一次
+		resp["recovery_codes"] = recoveryCodes
+	}
+	response.Success(c, resp)
 }
 
 // TotpDisableRequest represents the request to disable TOTP
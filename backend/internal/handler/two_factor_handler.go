@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorHandler handles the combined 2FA status and recovery codes
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// GetStatus returns the 2FA status of the current user
+// GET /api/v1/user/2fa/status
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	status, err := h.twoFactorService.GetStatus(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// RegenerateRecoveryCodesRequest represents the identity verification for regenerating recovery codes
+type RegenerateRecoveryCodesRequest struct {
+	EmailCode string `json:"email_code"`
+	Password  string `json:"password"`
+}
+
+// RegenerateRecoveryCodes invalidates all existing recovery codes and returns a new set
+// POST /api/v1/user/2fa/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), subject.UserID, req.EmailCode, req.Password)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"recovery_codes": codes})
+}
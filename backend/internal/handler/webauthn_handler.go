@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebAuthnHandler handles security key / passkey management for the current user
+type WebAuthnHandler struct {
+	webAuthnService *service.WebAuthnService
+}
+
+// NewWebAuthnHandler creates a new WebAuthnHandler
+func NewWebAuthnHandler(webAuthnService *service.WebAuthnService) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		webAuthnService: webAuthnService,
+	}
+}
+
+// ListCredentials returns the registered security keys of the current user
+// GET /api/v1/user/webauthn/credentials
+func (h *WebAuthnHandler) ListCredentials(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	credentials, err := h.webAuthnService.ListCredentials(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.WebAuthnCredential, 0, len(credentials))
+	for i := range credentials {
+		out = append(out, *dto.WebAuthnCredentialFromService(&credentials[i]))
+	}
+	response.Success(c, gin.H{
+		"feature_enabled": h.webAuthnService.IsEnabled(),
+		"credentials":     out,
+	})
+}
+
+// WebAuthnRegisterBeginRequest represents the request to start security key registration
+type WebAuthnRegisterBeginRequest struct {
+	EmailCode string `json:"email_code"`
+	Password  string `json:"password"`
+}
+
+// BeginRegistration returns the credential creation options for navigator.credentials.create()
+// POST /api/v1/user/webauthn/register/begin
+func (h *WebAuthnHandler) BeginRegistration(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req WebAuthnRegisterBeginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	creation, err := h.webAuthnService.BeginRegistration(c.Request.Context(), subject.UserID, req.EmailCode, req.Password)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, creation)
+}
+
+// WebAuthnRegisterFinishRequest represents the attestation response from the browser
+type WebAuthnRegisterFinishRequest struct {
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// FinishRegistration verifies the attestation and stores the new security key
+// POST /api/v1/user/webauthn/register/finish
+func (h *WebAuthnHandler) FinishRegistration(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req WebAuthnRegisterFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	credential, recoveryCodes, err := h.webAuthnService.FinishRegistration(c.Request.Context(), subject.UserID, req.Name, req.Credential)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	resp := gin.H{"credential": dto.WebAuthnCredentialFromService(credential)}
+	if len(recoveryCodes) > 0 {
+		// 恢复码仅在此处返回一次
+		resp["recovery_codes"] = recoveryCodes
+	}
+	response.Success(c, resp)
+}
+
+// WebAuthnDeleteRequest represents the identity verification for removing a security key
+type WebAuthnDeleteRequest struct {
+	EmailCode string `json:"email_code"`
+	Password  string `json:"password"`
+}
+
+// DeleteCredential removes a registered security key
+// DELETE /api/v1/user/webauthn/credentials/:id
+func (h *WebAuthnHandler) DeleteCredential(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	credentialID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid credential ID")
+		return
+	}
+
+	var req WebAuthnDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	if err := h.webAuthnService.DeleteCredential(c.Request.Context(), subject.UserID, credentialID, req.EmailCode, req.Password); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"success": true})
+}
@@ -18,14 +18,20 @@ func ProvideAdminHandlers(
 	geminiOAuthHandler *admin.GeminiOAuthHandler,
 	antigravityOAuthHandler *admin.AntigravityOAuthHandler,
 	proxyHandler *admin.ProxyHandler,
+	proxyPoolHandler *admin.ProxyPoolHandler,
+	contentPolicyHandler *admin.ContentPolicyHandler,
+	spendAnomalyHandler *admin.SpendAnomalyHandler,
+	capacityForecastHandler *admin.CapacityForecastHandler,
 	redeemHandler *admin.RedeemHandler,
 	promoHandler *admin.PromoHandler,
 	settingHandler *admin.SettingHandler,
 	opsHandler *admin.OpsHandler,
 	systemHandler *admin.SystemHandler,
+	clusterHandler *admin.ClusterHandler,
 	subscriptionHandler *admin.SubscriptionHandler,
 	usageHandler *admin.UsageHandler,
 	userAttributeHandler *admin.UserAttributeHandler,
+	userSessionHandler *admin.UserSessionHandler,
 ) *AdminHandlers {
 	return &AdminHandlers{
 		Dashboard:        dashboardHandler,
@@ -37,14 +43,20 @@ func ProvideAdminHandlers(
 		GeminiOAuth:      geminiOAuthHandler,
 		AntigravityOAuth: antigravityOAuthHandler,
 		Proxy:            proxyHandler,
+		ProxyPool:        proxyPoolHandler,
+		ContentPolicy:    contentPolicyHandler,
+		SpendAnomaly:     spendAnomalyHandler,
+		CapacityForecast: capacityForecastHandler,
 		Redeem:           redeemHandler,
 		Promo:            promoHandler,
 		Setting:          settingHandler,
 		Ops:              opsHandler,
 		System:           systemHandler,
+		Cluster:          clusterHandler,
 		Subscription:     subscriptionHandler,
 		Usage:            usageHandler,
 		UserAttribute:    userAttributeHandler,
+		UserSession:      userSessionHandler,
 	}
 }
 
@@ -69,8 +81,12 @@ func ProvideHandlers(
 	adminHandlers *AdminHandlers,
 	gatewayHandler *GatewayHandler,
 	openaiGatewayHandler *OpenAIGatewayHandler,
+	messageBatchHandler *MessageBatchHandler,
 	settingHandler *SettingHandler,
 	totpHandler *TotpHandler,
+	twoFactorHandler *TwoFactorHandler,
+	webAuthnHandler *WebAuthnHandler,
+	sessionHandler *SessionHandler,
 ) *Handlers {
 	return &Handlers{
 		Auth:          authHandler,
@@ -82,8 +98,12 @@ func ProvideHandlers(
 		Admin:         adminHandlers,
 		Gateway:       gatewayHandler,
 		OpenAIGateway: openaiGatewayHandler,
+		MessageBatch:  messageBatchHandler,
 		Setting:       settingHandler,
 		Totp:          totpHandler,
+		TwoFactor:     twoFactorHandler,
+		WebAuthn:      webAuthnHandler,
+		Session:       sessionHandler,
 	}
 }
 
@@ -98,7 +118,11 @@ var ProviderSet = wire.NewSet(
 	NewSubscriptionHandler,
 	NewGatewayHandler,
 	NewOpenAIGatewayHandler,
+	NewMessageBatchHandler,
 	NewTotpHandler,
+	NewTwoFactorHandler,
+	NewWebAuthnHandler,
+	NewSessionHandler,
 	ProvideSettingHandler,
 
 	// Admin handlers
@@ -111,6 +135,11 @@ var ProviderSet = wire.NewSet(
 	admin.NewGeminiOAuthHandler,
 	admin.NewAntigravityOAuthHandler,
 	admin.NewProxyHandler,
+	admin.NewProxyPoolHandler,
+	admin.NewContentPolicyHandler,
+	admin.NewSpendAnomalyHandler,
+	admin.NewCapacityForecastHandler,
+	admin.NewClusterHandler,
 	admin.NewRedeemHandler,
 	admin.NewPromoHandler,
 	admin.NewSettingHandler,
@@ -119,6 +148,7 @@ var ProviderSet = wire.NewSet(
 	admin.NewSubscriptionHandler,
 	admin.NewUsageHandler,
 	admin.NewUserAttributeHandler,
+	admin.NewUserSessionHandler,
 
 	// AdminHandlers and Handlers constructors
 	ProvideAdminHandlers,
@@ -0,0 +1,235 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTransformGeminiRequestToClaude(t *testing.T) {
+	body := `{
+		"systemInstruction": {"parts": [{"text": "You are helpful."}]},
+		"contents": [
+			{"role": "user", "parts": [
+				{"text": "What is in this image?"},
+				{"inlineData": {"mimeType": "image/png", "data": "iVBORw0KGgo="}}
+			]},
+			{"role": "model", "parts": [
+				{"text": "thinking...", "thought": true, "thoughtSignature": "sig_1"},
+				{"text": "unsigned thought", "thought": true},
+				{"functionCall": {"name": "lookup", "args": {"q": "cat"}}}
+			]},
+			{"role": "user", "parts": [{"text": "continue"}]},
+			{"role": "function", "parts": [{"functionResponse": {"name": "lookup", "response": {"result": "a cat"}}}]}
+		],
+		"tools": [{"functionDeclarations": [{
+			"name": "lookup",
+			"description": "Look things up",
+			"parameters": {"type": "OBJECT", "properties": {"q": {"type": "STRING"}, "type": {"type": "STRING"}}}
+		}]}],
+		"toolConfig": {"functionCallingConfig": {"mode": "ANY", "allowedFunctionNames": ["lookup"]}},
+		"generationConfig": {"maxOutputTokens": 2048, "temperature": 0.3, "thinkingConfig": {"thinkingBudget": 4096}}
+	}`
+
+	out, err := TransformGeminiRequestToClaude([]byte(body), "claude-sonnet-4-5", true)
+	if err != nil {
+		t.Fatalf("TransformGeminiRequestToClaude() error = %v", err)
+	}
+
+	var req claudeRequestFromGemini
+	if err := json.Unmarshal(out, &req); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+
+	if req.Model != "claude-sonnet-4-5" || !req.Stream {
+		t.Errorf("model/stream = %s/%v", req.Model, req.Stream)
+	}
+	if req.System != "You are helpful." {
+		t.Errorf("system = %q", req.System)
+	}
+	// 工具调用循环中思考块已丢弃，开启 thinking 会被 Claude 拒绝
+	if req.Thinking != nil {
+		t.Errorf("thinking must be disabled mid tool loop, got %+v", req.Thinking)
+	}
+
+	// user, assistant, user(continue + tool_result merged)
+	if len(req.Messages) != 3 {
+		t.Fatalf("messages = %d, want 3", len(req.Messages))
+	}
+	if got := req.Messages[0].Content[1]; got.Type != "image" || got.Source == nil || got.Source.MediaType != "image/png" {
+		t.Errorf("image block = %+v", got)
+	}
+
+	assistant := req.Messages[1]
+	if assistant.Role != "assistant" || len(assistant.Content) != 1 {
+		t.Fatalf("assistant message = %+v", assistant)
+	}
+	toolUse := assistant.Content[0]
+	if toolUse.Type != "tool_use" || toolUse.Name != "lookup" || toolUse.ID == "" {
+		t.Errorf("tool_use block = %+v", toolUse)
+	}
+
+	last := req.Messages[2]
+	if last.Content[0].Type != "tool_result" || last.Content[0].ToolUseID != toolUse.ID {
+		t.Errorf("tool_result must come first and reference %s, got %+v", toolUse.ID, last.Content[0])
+	}
+
+	if len(req.Tools) != 1 {
+		t.Fatalf("tools = %d", len(req.Tools))
+	}
+	schema := req.Tools[0].InputSchema
+	props := schema["properties"].(map[string]any)
+	if schema["type"] != "object" || props["q"].(map[string]any)["type"] != "string" || props["type"].(map[string]any)["type"] != "string" {
+		t.Errorf("schema types not normalized: %v", schema)
+	}
+	if req.ToolChoice["type"] != "tool" || req.ToolChoice["name"] != "lookup" {
+		t.Errorf("tool_choice = %v", req.ToolChoice)
+	}
+}
+
+func TestTransformGeminiRequestToClaude_DropsGeminiThoughtSignatures(t *testing.T) {
+	body := `{
+		"contents": [
+			{"role": "user", "parts": [{"text": "hi"}]},
+			{"role": "model", "parts": [
+				{"text": "gemini reasoning", "thought": true, "thoughtSignature": "CiQBjz1rX-gemini-signature"},
+				{"text": "Hello!"}
+			]},
+			{"role": "user", "parts": [{"text": "and now?"}]}
+		],
+		"generationConfig": {"maxOutputTokens": 2048, "temperature": 0.3, "thinkingConfig": {"thinkingBudget": 4096}}
+	}`
+
+	out, err := TransformGeminiRequestToClaude([]byte(body), "claude-sonnet-4-5", false)
+	if err != nil {
+		t.Fatalf("TransformGeminiRequestToClaude() error = %v", err)
+	}
+	if strings.Contains(string(out), "signature") || strings.Contains(string(out), "gemini reasoning") {
+		t.Fatalf("gemini thoughts must not be forwarded to Claude: %s", out)
+	}
+
+	var req claudeRequestFromGemini
+	if err := json.Unmarshal(out, &req); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	assistant := req.Messages[1]
+	if len(assistant.Content) != 1 || assistant.Content[0].Type != "text" || assistant.Content[0].Text != "Hello!" {
+		t.Errorf("assistant message = %+v", assistant)
+	}
+
+	// 最后一条是普通用户输入，可以开启 thinking
+	if req.Thinking == nil || req.Thinking.BudgetTokens != 4096 {
+		t.Fatalf("thinking = %+v, want budget 4096", req.Thinking)
+	}
+	if req.MaxTokens <= req.Thinking.BudgetTokens {
+		t.Errorf("max_tokens %d must exceed thinking budget", req.MaxTokens)
+	}
+	if req.Temperature != nil {
+		t.Errorf("temperature must be dropped when thinking is enabled")
+	}
+}
+
+func TestTransformGeminiRequestToClaude_EmptyContents(t *testing.T) {
+	if _, err := TransformGeminiRequestToClaude([]byte(`{"contents": []}`), "claude-sonnet-4-5", false); err == nil {
+		t.Fatal("expected error for empty contents")
+	}
+}
+
+func TestTransformClaudeResponseToGemini(t *testing.T) {
+	body := `{
+		"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-sonnet-4-5",
+		"content": [
+			{"type": "thinking", "thinking": "hmm", "signature": "sig"},
+			{"type": "text", "text": "Hello"},
+			{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"q": "cat"}}
+		],
+		"stop_reason": "max_tokens",
+		"usage": {"input_tokens": 10, "output_tokens": 5, "cache_read_input_tokens": 4}
+	}`
+
+	out, err := TransformClaudeResponseToGemini([]byte(body))
+	if err != nil {
+		t.Fatalf("TransformClaudeResponseToGemini() error = %v", err)
+	}
+	var resp GeminiResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	cand := resp.Candidates[0]
+	if cand.FinishReason != "MAX_TOKENS" {
+		t.Errorf("finishReason = %s", cand.FinishReason)
+	}
+	parts := cand.Content.Parts
+	if len(parts) != 3 || !parts[0].Thought || parts[0].ThoughtSignature != "sig" || parts[1].Text != "Hello" || parts[2].FunctionCall == nil {
+		t.Errorf("parts = %+v", parts)
+	}
+	if u := resp.UsageMetadata; u.PromptTokenCount != 14 || u.CachedContentTokenCount != 4 || u.TotalTokenCount != 19 {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestClaudeToGeminiStreamProcessor(t *testing.T) {
+	lines := []string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":12,"output_tokens":1}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"cat\"}"}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+		`data: {"type":"message_stop"}`,
+	}
+
+	p := NewClaudeToGeminiStreamProcessor()
+	var chunks []GeminiResponse
+	for _, line := range lines {
+		out := p.ProcessLine(line)
+		if len(out) == 0 {
+			continue
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(string(out), "data: "))
+		var resp GeminiResponse
+		if err := json.Unmarshal([]byte(payload), &resp); err != nil {
+			t.Fatalf("invalid chunk %q: %v", out, err)
+		}
+		chunks = append(chunks, resp)
+	}
+	if extra := p.Finish(); extra != nil {
+		t.Errorf("Finish() after message_stop should be empty, got %s", extra)
+	}
+
+	if len(chunks) != 3 {
+		t.Fatalf("chunks = %d, want 3 (text, functionCall, final)", len(chunks))
+	}
+	if chunks[0].Candidates[0].Content.Parts[0].Text != "Hi" || chunks[0].ResponseID != "msg_1" {
+		t.Errorf("text chunk = %+v", chunks[0])
+	}
+	fc := chunks[1].Candidates[0].Content.Parts[0].FunctionCall
+	if fc == nil || fc.Name != "lookup" || fc.Args.(map[string]any)["q"] != "cat" {
+		t.Errorf("functionCall chunk = %+v", fc)
+	}
+	final := chunks[2]
+	if final.Candidates[0].FinishReason != "STOP" || final.UsageMetadata == nil || final.UsageMetadata.CandidatesTokenCount != 7 {
+		t.Errorf("final chunk = %+v", final)
+	}
+}
+
+func TestClaudeToGeminiStreamProcessor_Error(t *testing.T) {
+	p := NewClaudeToGeminiStreamProcessor()
+	out := p.ProcessLine(`data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+	if !strings.Contains(string(out), `"code":503`) {
+		t.Errorf("error chunk = %s", out)
+	}
+	if p.Finish() != nil {
+		t.Error("no final chunk expected after an error")
+	}
+
+	p = NewClaudeToGeminiStreamProcessor()
+	out = p.ProcessLine(`data: {"error":"stream_timeout"}`)
+	if !strings.Contains(string(out), "stream_timeout") {
+		t.Errorf("gateway stream error not converted: %s", out)
+	}
+}
@@ -0,0 +1,409 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Gemini generateContent → Claude Messages 请求转换
+// 用于 Gemini 分组通过 Anthropic 账号兜底时，将 Gemini SDK 的请求翻译为 Claude 请求。
+
+const (
+	// geminiClaudeDefaultMaxTokens 未指定 maxOutputTokens 时使用的 max_tokens（Claude 必填）
+	geminiClaudeDefaultMaxTokens = 8192
+	// geminiClaudeMinThinkingBudget Claude thinking 的最小 budget_tokens
+	geminiClaudeMinThinkingBudget = 1024
+	// geminiClaudeDynamicThinkingBudget 动态思考（thinkingBudget=-1 或仅 includeThoughts）时的预算
+	geminiClaudeDynamicThinkingBudget = 8192
+)
+
+// geminiSourceRequest Gemini 原生请求（比 GeminiRequest 更宽松，兼容 SDK 的各种写法）
+type geminiSourceRequest struct {
+	Contents               []GeminiContent               `json:"contents"`
+	SystemInstruction      *GeminiContent                `json:"systemInstruction,omitempty"`
+	SystemInstructionSnake *GeminiContent                `json:"system_instruction,omitempty"`
+	GenerationConfig       *geminiSourceGenerationConfig `json:"generationConfig,omitempty"`
+	Tools                  []geminiSourceTool            `json:"tools,omitempty"`
+	ToolConfig             *geminiSourceToolConfig       `json:"toolConfig,omitempty"`
+}
+
+type geminiSourceGenerationConfig struct {
+	MaxOutputTokens int                         `json:"maxOutputTokens,omitempty"`
+	Temperature     *float64                    `json:"temperature,omitempty"`
+	TopP            *float64                    `json:"topP,omitempty"`
+	TopK            *float64                    `json:"topK,omitempty"`
+	StopSequences   []string                    `json:"stopSequences,omitempty"`
+	ThinkingConfig  *geminiSourceThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type geminiSourceThinkingConfig struct {
+	IncludeThoughts *bool  `json:"includeThoughts,omitempty"`
+	ThinkingBudget  *int   `json:"thinkingBudget,omitempty"`
+	ThinkingLevel   string `json:"thinkingLevel,omitempty"`
+}
+
+type geminiSourceTool struct {
+	FunctionDeclarations []geminiSourceFunctionDecl `json:"functionDeclarations,omitempty"`
+}
+
+type geminiSourceFunctionDecl struct {
+	Name                 string         `json:"name"`
+	Description          string         `json:"description,omitempty"`
+	Parameters           map[string]any `json:"parameters,omitempty"`
+	ParametersJSONSchema map[string]any `json:"parametersJsonSchema,omitempty"`
+}
+
+type geminiSourceToolConfig struct {
+	FunctionCallingConfig *struct {
+		Mode                 string   `json:"mode,omitempty"`
+		AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+	} `json:"functionCallingConfig,omitempty"`
+}
+
+// claudeRequestFromGemini 转换后的 Claude 请求
+type claudeRequestFromGemini struct {
+	Model         string                    `json:"model"`
+	Messages      []claudeMessageFromGemini `json:"messages"`
+	System        string                    `json:"system,omitempty"`
+	MaxTokens     int                       `json:"max_tokens"`
+	Stream        bool                      `json:"stream,omitempty"`
+	Temperature   *float64                  `json:"temperature,omitempty"`
+	TopP          *float64                  `json:"top_p,omitempty"`
+	TopK          *int                      `json:"top_k,omitempty"`
+	StopSequences []string                  `json:"stop_sequences,omitempty"`
+	Tools         []ClaudeTool              `json:"tools,omitempty"`
+	ToolChoice    map[string]any            `json:"tool_choice,omitempty"`
+	Thinking      *ThinkingConfig           `json:"thinking,omitempty"`
+}
+
+type claudeMessageFromGemini struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// TransformGeminiRequestToClaude 将 Gemini generateContent 请求体转换为 Claude Messages 请求体
+// 支持 contents / systemInstruction / functionDeclarations / inlineData / thinkingConfig。
+func TransformGeminiRequestToClaude(body []byte, claudeModel string, stream bool) ([]byte, error) {
+	var src geminiSourceRequest
+	if err := json.Unmarshal(body, &src); err != nil {
+		return nil, fmt.Errorf("parse gemini request: %w", err)
+	}
+
+	messages := buildClaudeMessagesFromGemini(src.Contents)
+	if len(messages) == 0 {
+		return nil, errors.New("contents is required")
+	}
+
+	out := claudeRequestFromGemini{
+		Model:     claudeModel,
+		Messages:  messages,
+		MaxTokens: geminiClaudeDefaultMaxTokens,
+		Stream:    stream,
+	}
+
+	system := src.SystemInstruction
+	if system == nil {
+		system = src.SystemInstructionSnake
+	}
+	if system != nil {
+		texts := make([]string, 0, len(system.Parts))
+		for _, part := range system.Parts {
+			if strings.TrimSpace(part.Text) != "" {
+				texts = append(texts, part.Text)
+			}
+		}
+		out.System = strings.Join(texts, "\n")
+	}
+
+	if cfg := src.GenerationConfig; cfg != nil {
+		if cfg.MaxOutputTokens > 0 {
+			out.MaxTokens = cfg.MaxOutputTokens
+		}
+		out.Temperature = cfg.Temperature
+		out.TopP = cfg.TopP
+		if cfg.TopK != nil {
+			topK := int(*cfg.TopK)
+			out.TopK = &topK
+		}
+		out.StopSequences = cfg.StopSequences
+
+		// 历史中的思考块已被丢弃：处于工具调用循环或以 assistant 预填充结尾时，
+		// Claude 要求最后一条 assistant 消息以 thinking 开头，此时不开启 thinking
+		if budget := resolveGeminiThinkingBudget(cfg.ThinkingConfig); budget > 0 && !claudeHistoryRequiresThinkingBlock(messages) {
+			out.Thinking = &ThinkingConfig{Type: "enabled", BudgetTokens: budget}
+			// Claude 要求 max_tokens > budget_tokens，且开启 thinking 时不允许修改采样参数
+			if out.MaxTokens <= budget {
+				out.MaxTokens = budget + geminiClaudeDefaultMaxTokens
+			}
+			out.Temperature = nil
+			out.TopP = nil
+			out.TopK = nil
+		}
+	}
+
+	out.Tools = buildClaudeToolsFromGemini(src.Tools)
+	if len(out.Tools) > 0 {
+		out.ToolChoice = buildClaudeToolChoiceFromGemini(src.ToolConfig)
+	}
+
+	return json.Marshal(out)
+}
+
+// resolveGeminiThinkingBudget 将 Gemini thinkingConfig 折算为 Claude budget_tokens，0 表示不开启
+func resolveGeminiThinkingBudget(cfg *geminiSourceThinkingConfig) int {
+	if cfg == nil {
+		return 0
+	}
+	if cfg.ThinkingBudget != nil {
+		budget := *cfg.ThinkingBudget
+		switch {
+		case budget == 0:
+			return 0
+		case budget < 0:
+			return geminiClaudeDynamicThinkingBudget
+		case budget < geminiClaudeMinThinkingBudget:
+			return geminiClaudeMinThinkingBudget
+		default:
+			return budget
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.ThinkingLevel)) {
+	case "minimal":
+		return 0
+	case "low":
+		return 4096
+	case "medium":
+		return 8192
+	case "high":
+		return 16384
+	}
+	if cfg.IncludeThoughts != nil && *cfg.IncludeThoughts {
+		return geminiClaudeDynamicThinkingBudget
+	}
+	return 0
+}
+
+// buildClaudeMessagesFromGemini 将 Gemini contents 转换为 Claude messages
+// - model 角色映射为 assistant，其余映射为 user
+// - 连续同角色消息会被合并，user 消息中的 tool_result 会被前置（Claude 要求紧跟 tool_use）
+// - functionResponse 缺少 id 时按函数名匹配最近一次未响应的 functionCall
+func buildClaudeMessagesFromGemini(contents []GeminiContent) []claudeMessageFromGemini {
+	messages := make([]claudeMessageFromGemini, 0, len(contents))
+	pendingToolIDs := make(map[string][]string)
+	toolSeq := 0
+
+	for _, content := range contents {
+		role := "user"
+		if content.Role == "model" || content.Role == "assistant" {
+			role = "assistant"
+		}
+
+		blocks := make([]ContentBlock, 0, len(content.Parts))
+		for _, part := range content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				id := part.FunctionCall.ID
+				if id == "" {
+					toolSeq++
+					id = fmt.Sprintf("toolu_gemini_%d", toolSeq)
+				}
+				pendingToolIDs[part.FunctionCall.Name] = append(pendingToolIDs[part.FunctionCall.Name], id)
+				input := part.FunctionCall.Args
+				if input == nil {
+					input = map[string]any{}
+				}
+				blocks = append(blocks, ContentBlock{Type: "tool_use", ID: id, Name: part.FunctionCall.Name, Input: input})
+
+			case part.FunctionResponse != nil:
+				fr := part.FunctionResponse
+				payload, _ := json.Marshal(fr.Response)
+				id := fr.ID
+				if queue := pendingToolIDs[fr.Name]; len(queue) > 0 {
+					if id == "" {
+						id = queue[0]
+					}
+					pendingToolIDs[fr.Name] = removeToolID(queue, id)
+				}
+				if id == "" {
+					// 找不到对应的 tool_use，降级为文本，避免 Claude 拒绝孤立的 tool_result
+					blocks = append(blocks, ContentBlock{Type: "text", Text: fmt.Sprintf("[%s result]: %s", fr.Name, payload)})
+					continue
+				}
+				resultContent, _ := json.Marshal(string(payload))
+				blocks = append(blocks, ContentBlock{Type: "tool_result", ToolUseID: id, Content: resultContent})
+
+			case part.InlineData != nil:
+				blockType := "image"
+				if part.InlineData.MimeType == "application/pdf" {
+					blockType = "document"
+				}
+				blocks = append(blocks, ContentBlock{
+					Type: blockType,
+					Source: &ImageSource{
+						Type:      "base64",
+						MediaType: part.InlineData.MimeType,
+						Data:      part.InlineData.Data,
+					},
+				})
+
+			case part.Thought:
+				// 兜底发生在会话中途，历史中的 thoughtSignature 通常由 Gemini 生成，Claude 会判定签名无效并返回 400；
+				// 无法区分签名来源，因此思考内容一律不回传
+
+			case part.Text != "":
+				blocks = append(blocks, ContentBlock{Type: "text", Text: part.Text})
+			}
+		}
+
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			continue
+		}
+		messages = append(messages, claudeMessageFromGemini{Role: role, Content: blocks})
+	}
+
+	for i := range messages {
+		if messages[i].Role == "user" {
+			messages[i].Content = moveToolResultsFirst(messages[i].Content)
+		}
+	}
+	return messages
+}
+
+// claudeHistoryRequiresThinkingBlock 开启 thinking 时最后一条 assistant 消息是否必须以 thinking 块开头：
+// 以 assistant 预填充结尾，或最后一条 user 消息携带 tool_result（工具调用循环进行中）。
+func claudeHistoryRequiresThinkingBlock(messages []claudeMessageFromGemini) bool {
+	if len(messages) == 0 {
+		return false
+	}
+	last := messages[len(messages)-1]
+	if last.Role == "assistant" {
+		return true
+	}
+	for _, block := range last.Content {
+		if block.Type == "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+func removeToolID(queue []string, id string) []string {
+	for i, v := range queue {
+		if v == id {
+			return append(queue[:i:i], queue[i+1:]...)
+		}
+	}
+	return queue
+}
+
+func moveToolResultsFirst(blocks []ContentBlock) []ContentBlock {
+	ordered := make([]ContentBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Type == "tool_result" {
+			ordered = append(ordered, b)
+		}
+	}
+	for _, b := range blocks {
+		if b.Type != "tool_result" {
+			ordered = append(ordered, b)
+		}
+	}
+	return ordered
+}
+
+// buildClaudeToolsFromGemini 将 functionDeclarations 转换为 Claude tools（googleSearch 等内置工具忽略）
+func buildClaudeToolsFromGemini(tools []geminiSourceTool) []ClaudeTool {
+	var out []ClaudeTool
+	for _, tool := range tools {
+		for _, decl := range tool.FunctionDeclarations {
+			if strings.TrimSpace(decl.Name) == "" {
+				continue
+			}
+			schema := decl.ParametersJSONSchema
+			if schema == nil {
+				schema = decl.Parameters
+			}
+			out = append(out, ClaudeTool{
+				Name:        decl.Name,
+				Description: decl.Description,
+				InputSchema: normalizeGeminiSchema(schema),
+			})
+		}
+	}
+	return out
+}
+
+// normalizeGeminiSchema 将 Gemini OpenAPI 风格 schema（如 "OBJECT"）转为 JSON Schema，并保证顶层为 object
+func normalizeGeminiSchema(schema map[string]any) map[string]any {
+	normalized, _ := lowercaseSchemaTypes(deepCopy(schema)).(map[string]any)
+	if normalized == nil {
+		normalized = map[string]any{}
+	}
+	if _, ok := normalized["type"]; !ok {
+		normalized["type"] = "object"
+	}
+	if normalized["type"] == "object" {
+		if _, ok := normalized["properties"]; !ok {
+			normalized["properties"] = map[string]any{}
+		}
+	}
+	return normalized
+}
+
+func lowercaseSchemaTypes(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if key == "type" {
+				switch t := child.(type) {
+				case string:
+					v[key] = strings.ToLower(t)
+					continue
+				case []any:
+					for i, item := range t {
+						if s, ok := item.(string); ok {
+							t[i] = strings.ToLower(s)
+						}
+					}
+					continue
+				}
+			}
+			// 属性名也可能叫 "type"，此时值为子 schema，需要继续递归
+			v[key] = lowercaseSchemaTypes(child)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = lowercaseSchemaTypes(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// buildClaudeToolChoiceFromGemini 将 functionCallingConfig.mode 转换为 Claude tool_choice
+func buildClaudeToolChoiceFromGemini(cfg *geminiSourceToolConfig) map[string]any {
+	if cfg == nil || cfg.FunctionCallingConfig == nil {
+		return nil
+	}
+	switch strings.ToUpper(cfg.FunctionCallingConfig.Mode) {
+	case "AUTO":
+		return map[string]any{"type": "auto"}
+	case "ANY", "VALIDATED":
+		if names := cfg.FunctionCallingConfig.AllowedFunctionNames; len(names) == 1 {
+			return map[string]any{"type": "tool", "name": names[0]}
+		}
+		return map[string]any{"type": "any"}
+	case "NONE":
+		return map[string]any{"type": "none"}
+	default:
+		return nil
+	}
+}
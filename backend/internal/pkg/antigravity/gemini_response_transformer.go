@@ -0,0 +1,317 @@
+package antigravity
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/googleapi"
+)
+
+// Claude Messages 响应 → Gemini generateContent 响应转换
+// 与 gemini_request_transformer.go 配合，用于 Gemini 分组的 Anthropic 兜底链路。
+
+// TransformClaudeResponseToGemini 将 Claude 非流式响应转换为 Gemini 响应
+func TransformClaudeResponseToGemini(body []byte) ([]byte, error) {
+	var claudeResp ClaudeResponse
+	if err := json.Unmarshal(body, &claudeResp); err != nil {
+		return nil, fmt.Errorf("parse claude response: %w", err)
+	}
+
+	parts := make([]GeminiPart, 0, len(claudeResp.Content))
+	for _, item := range claudeResp.Content {
+		switch item.Type {
+		case "text":
+			parts = append(parts, GeminiPart{Text: item.Text})
+		case "thinking":
+			// 签名随 thoughtSignature 回传，客户端带回历史时可还原为 Claude thinking 块
+			parts = append(parts, GeminiPart{Text: item.Thinking, Thought: true, ThoughtSignature: item.Signature})
+		case "tool_use":
+			args := item.Input
+			if args == nil {
+				args = map[string]any{}
+			}
+			parts = append(parts, GeminiPart{FunctionCall: &GeminiFunctionCall{Name: item.Name, Args: args, ID: item.ID}})
+		}
+	}
+
+	resp := GeminiResponse{
+		Candidates: []GeminiCandidate{{
+			Content:      &GeminiContent{Role: "model", Parts: parts},
+			FinishReason: claudeStopReasonToGemini(claudeResp.StopReason),
+		}},
+		UsageMetadata: claudeUsageToGemini(&claudeResp.Usage),
+		ResponseID:    claudeResp.ID,
+		ModelVersion:  claudeResp.Model,
+	}
+	return json.Marshal(resp)
+}
+
+// TransformClaudeErrorToGemini 将 Claude 错误响应转换为 Google 风格错误体
+func TransformClaudeErrorToGemini(status int, body []byte) []byte {
+	message := strings.TrimSpace(string(body))
+	var claudeErr ClaudeError
+	if err := json.Unmarshal(body, &claudeErr); err == nil && claudeErr.Error.Message != "" {
+		message = claudeErr.Error.Message
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	out, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"message": message,
+			"status":  googleapi.HTTPStatusToGoogleStatus(status),
+		},
+	})
+	return out
+}
+
+// claudeStopReasonToGemini 将 Claude stop_reason 映射为 Gemini finishReason
+func claudeStopReasonToGemini(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "MAX_TOKENS"
+	case "refusal":
+		return "SAFETY"
+	default:
+		// end_turn / stop_sequence / tool_use / pause_turn 在 Gemini 侧均为 STOP
+		return "STOP"
+	}
+}
+
+// claudeErrorTypeToStatus 将 Claude 流内 error 事件的类型映射为 HTTP 状态码
+func claudeErrorTypeToStatus(errType string) int {
+	switch errType {
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// claudeUsageToGemini Gemini 的 promptTokenCount 包含缓存命中部分，Claude 的 input_tokens 不包含
+func claudeUsageToGemini(usage *ClaudeUsage) *GeminiUsageMetadata {
+	if usage == nil {
+		return nil
+	}
+	prompt := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+	return &GeminiUsageMetadata{
+		PromptTokenCount:        prompt,
+		CandidatesTokenCount:    usage.OutputTokens,
+		CachedContentTokenCount: usage.CacheReadInputTokens,
+		TotalTokenCount:         prompt + usage.OutputTokens,
+	}
+}
+
+// ClaudeToGeminiStreamProcessor 将 Claude SSE 事件转换为 Gemini streamGenerateContent 的 SSE chunk
+type ClaudeToGeminiStreamProcessor struct {
+	responseID string
+	model      string
+	usage      ClaudeUsage
+	stopReason string
+	finished   bool
+
+	// tool_use 块的参数以 input_json_delta 分片到达，需在 content_block_stop 时一次性输出
+	toolBlocks map[int]*claudeStreamToolBlock
+}
+
+type claudeStreamToolBlock struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+type claudeStreamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message *struct {
+		ID    string       `json:"id"`
+		Model string       `json:"model"`
+		Usage *ClaudeUsage `json:"usage"`
+	} `json:"message,omitempty"`
+	ContentBlock *ClaudeContentItem `json:"content_block,omitempty"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		Signature   string `json:"signature"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta,omitempty"`
+	Usage *ClaudeUsage    `json:"usage,omitempty"`
+	Error json.RawMessage `json:"error,omitempty"`
+}
+
+// NewClaudeToGeminiStreamProcessor 创建 Claude → Gemini 流式转换器
+func NewClaudeToGeminiStreamProcessor() *ClaudeToGeminiStreamProcessor {
+	return &ClaudeToGeminiStreamProcessor{toolBlocks: make(map[int]*claudeStreamToolBlock)}
+}
+
+// ProcessLine 处理一行 Claude SSE，返回需要写给客户端的 Gemini SSE 数据（可能为空）
+func (p *ClaudeToGeminiStreamProcessor) ProcessLine(line string) []byte {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data:") {
+		// event: 行与空行在 Gemini 流中没有对应物
+		return nil
+	}
+	data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+	if data == "" || data == "[DONE]" {
+		return nil
+	}
+
+	var event claudeStreamEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			p.responseID = event.Message.ID
+			p.model = event.Message.Model
+			if event.Message.Usage != nil {
+				p.usage = *event.Message.Usage
+			}
+		}
+
+	case "content_block_start":
+		if block := event.ContentBlock; block != nil {
+			switch block.Type {
+			case "tool_use":
+				p.toolBlocks[event.Index] = &claudeStreamToolBlock{id: block.ID, name: block.Name}
+			case "text":
+				if block.Text != "" {
+					return p.emitParts([]GeminiPart{{Text: block.Text}}, "")
+				}
+			}
+		}
+
+	case "content_block_delta":
+		if event.Delta == nil {
+			return nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			if event.Delta.Text != "" {
+				return p.emitParts([]GeminiPart{{Text: event.Delta.Text}}, "")
+			}
+		case "thinking_delta":
+			if event.Delta.Thinking != "" {
+				return p.emitParts([]GeminiPart{{Text: event.Delta.Thinking, Thought: true}}, "")
+			}
+		case "signature_delta":
+			if event.Delta.Signature != "" {
+				return p.emitParts([]GeminiPart{{Thought: true, ThoughtSignature: event.Delta.Signature}}, "")
+			}
+		case "input_json_delta":
+			if block := p.toolBlocks[event.Index]; block != nil {
+				block.args.WriteString(event.Delta.PartialJSON)
+			}
+		}
+
+	case "content_block_stop":
+		block := p.toolBlocks[event.Index]
+		if block == nil {
+			return nil
+		}
+		delete(p.toolBlocks, event.Index)
+		args := map[string]any{}
+		if raw := strings.TrimSpace(block.args.String()); raw != "" {
+			_ = json.Unmarshal([]byte(raw), &args)
+		}
+		return p.emitParts([]GeminiPart{{FunctionCall: &GeminiFunctionCall{Name: block.name, Args: args, ID: block.id}}}, "")
+
+	case "message_delta":
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			p.stopReason = event.Delta.StopReason
+		}
+		if event.Usage != nil {
+			if event.Usage.InputTokens > 0 {
+				p.usage.InputTokens = event.Usage.InputTokens
+			}
+			if event.Usage.CacheReadInputTokens > 0 {
+				p.usage.CacheReadInputTokens = event.Usage.CacheReadInputTokens
+			}
+			if event.Usage.CacheCreationInputTokens > 0 {
+				p.usage.CacheCreationInputTokens = event.Usage.CacheCreationInputTokens
+			}
+			p.usage.OutputTokens = event.Usage.OutputTokens
+		}
+
+	case "message_stop":
+		return p.Finish()
+
+	case "error", "":
+		// 网关自身的流错误形如 {"error":"stream_timeout"}，上游错误为 {"type":"error","error":{...}}
+		if len(event.Error) == 0 {
+			return nil
+		}
+		var detail ErrorDetail
+		if err := json.Unmarshal(event.Error, &detail); err != nil {
+			_ = json.Unmarshal(event.Error, &detail.Message)
+		}
+		p.finished = true
+		status := claudeErrorTypeToStatus(detail.Type)
+		payload, _ := json.Marshal(map[string]any{
+			"error": map[string]any{
+				"code":    status,
+				"message": detail.Message,
+				"status":  googleapi.HTTPStatusToGoogleStatus(status),
+			},
+		})
+		return formatGeminiSSE(payload)
+	}
+	return nil
+}
+
+// Finish 输出携带 finishReason 与 usageMetadata 的最后一个 chunk（已输出过则返回 nil）
+func (p *ClaudeToGeminiStreamProcessor) Finish() []byte {
+	if p.finished {
+		return nil
+	}
+	p.finished = true
+	return p.emitParts(nil, claudeStopReasonToGemini(p.stopReason))
+}
+
+func (p *ClaudeToGeminiStreamProcessor) emitParts(parts []GeminiPart, finishReason string) []byte {
+	if parts == nil {
+		parts = []GeminiPart{}
+	}
+	resp := GeminiResponse{
+		Candidates: []GeminiCandidate{{
+			Content:      &GeminiContent{Role: "model", Parts: parts},
+			FinishReason: finishReason,
+		}},
+		ResponseID:   p.responseID,
+		ModelVersion: p.model,
+	}
+	if finishReason != "" {
+		resp.UsageMetadata = claudeUsageToGemini(&p.usage)
+	}
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		return nil
+	}
+	return formatGeminiSSE(payload)
+}
+
+func formatGeminiSSE(payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("data: ")
+	buf.Write(payload)
+	buf.WriteString("\r\n\r\n")
+	return buf.Bytes()
+}
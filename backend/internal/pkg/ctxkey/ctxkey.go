@@ -18,4 +18,15 @@ const (
 	IsClaudeCodeClient Key = "ctx_is_claude_code_client"
 	// Group 认证后的分组信息，由 API Key 认证中间件设置
 	Group Key = "ctx_group"
+
+	// SessionID 当前 JWT 对应的登录会话 ID，由 JWT 认证中间件设置
+	SessionID Key = "ctx_session_id"
+	// SessionClient 登录请求的客户端信息（IP/User-Agent），由认证 Handler 设置，用于创建登录会话
+	SessionClient Key = "ctx_session_client"
+
+	// PromptCachePrefixes 请求中 cache_control 断点对应的 prompt 缓存前缀，供缓存亲和调度使用
+	PromptCachePrefixes Key = "ctx_prompt_cache_prefixes"
+
+	// UpstreamDryRun 上游 dry run 记录器；存在时 HTTPUpstream 只记录请求并返回模拟响应，不访问真实上游
+	UpstreamDryRun Key = "ctx_upstream_dry_run"
 )
@@ -0,0 +1,303 @@
+// Package fakeupstream 提供模拟上游（Anthropic / OpenAI Responses / Gemini）的响应构造，
+// 用于在不访问真实上游的情况下走通网关的完整转发链路（dry run、离线测试等）。
+package fakeupstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Kind 上游协议类型
+type Kind string
+
+const (
+	KindUnknown         Kind = ""
+	KindAnthropic       Kind = "anthropic"
+	KindOpenAIResponses Kind = "openai_responses"
+	// KindGemini AI Studio / Vertex 风格的 generateContent
+	KindGemini Kind = "gemini"
+	// KindGeminiInternal Code Assist / Antigravity 的 v1internal 接口，响应包裹在 response 字段中
+	KindGeminiInternal Kind = "gemini_v1internal"
+)
+
+// Options 模拟响应的内容与用量
+type Options struct {
+	Text         string
+	InputTokens  int
+	OutputTokens int
+	// CacheReadTokens 缓存命中的输入 token（Anthropic 不计入 input_tokens，OpenAI/Gemini 计入）
+	CacheReadTokens int
+	// Chunks 流式响应中文本拆分的 delta 数量（<=1 表示一次性输出）
+	Chunks int
+}
+
+// DefaultOptions 默认模拟响应
+func DefaultOptions() Options {
+	return Options{Text: "This is a simulated upstream response.", InputTokens: 10, OutputTokens: 8}
+}
+
+// Detect 根据请求 URL 判断上游协议类型
+func Detect(req *http.Request) Kind {
+	if req == nil || req.URL == nil {
+		return KindUnknown
+	}
+	path := req.URL.Path
+	switch {
+	case strings.Contains(path, "/v1internal:"):
+		return KindGeminiInternal
+	case strings.Contains(path, ":generateContent") || strings.Contains(path, ":streamGenerateContent"):
+		return KindGemini
+	case strings.HasSuffix(path, "/responses"):
+		return KindOpenAIResponses
+	case strings.HasSuffix(path, "/v1/messages"):
+		return KindAnthropic
+	default:
+		return KindUnknown
+	}
+}
+
+// IsStream 判断请求是否期望流式响应（Gemini 看 action，其余看请求体 stream 字段）
+func IsStream(req *http.Request, body []byte) bool {
+	if req != nil && req.URL != nil && strings.Contains(req.URL.Path, "streamGenerateContent") {
+		return true
+	}
+	return gjson.GetBytes(body, "stream").Bool()
+}
+
+// RequestModel 提取请求的模型名（请求体 model 字段优先，其次为 Gemini URL 路径中的模型）
+func RequestModel(req *http.Request, body []byte) string {
+	if model := gjson.GetBytes(body, "model").String(); model != "" {
+		return model
+	}
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	path := req.URL.Path
+	if i := strings.Index(path, "/models/"); i >= 0 {
+		rest := path[i+len("/models/"):]
+		if j := strings.Index(rest, ":"); j >= 0 {
+			return rest[:j]
+		}
+		return rest
+	}
+	return ""
+}
+
+// Respond 为请求构造成功的模拟响应（请求体需由调用方提前读出）
+func Respond(req *http.Request, body []byte, opts Options) *http.Response {
+	kind := Detect(req)
+	stream := IsStream(req, body)
+	contentType, payload := Build(kind, RequestModel(req, body), stream, opts)
+	return NewResponse(http.StatusOK, http.Header{"Content-Type": []string{contentType}}, payload)
+}
+
+// NewResponse 构造 http.Response
+func NewResponse(status int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
+
+// Build 构造指定协议的模拟响应体，返回 Content-Type 与响应体
+func Build(kind Kind, model string, stream bool, opts Options) (string, []byte) {
+	switch kind {
+	case KindAnthropic:
+		if stream {
+			return "text/event-stream", anthropicStream(model, opts)
+		}
+		return "application/json", anthropicMessage(model, opts)
+	case KindOpenAIResponses:
+		if stream {
+			return "text/event-stream", openAIStream(model, opts)
+		}
+		return "application/json", mustJSON(openAIResponse(model, opts))
+	case KindGemini, KindGeminiInternal:
+		wrap := func(resp map[string]any) []byte {
+			if kind == KindGeminiInternal {
+				resp = map[string]any{"response": resp, "traceId": "fake-trace"}
+			}
+			return mustJSON(resp)
+		}
+		if !stream {
+			return "application/json", wrap(geminiResponse(model, opts.Text, "STOP", opts))
+		}
+		var buf bytes.Buffer
+		chunks := splitText(opts.Text, opts.Chunks)
+		for i, chunk := range chunks {
+			finishReason := ""
+			if i == len(chunks)-1 {
+				finishReason = "STOP"
+			}
+			buf.Write(sseData(wrap(geminiResponse(model, chunk, finishReason, opts))))
+		}
+		return "text/event-stream", buf.Bytes()
+	default:
+		return "application/json", []byte(`{}`)
+	}
+}
+
+func anthropicMessage(model string, opts Options) []byte {
+	return mustJSON(map[string]any{
+		"id":            "msg_fake",
+		"type":          "message",
+		"role":          "assistant",
+		"model":         model,
+		"content":       []any{map[string]any{"type": "text", "text": opts.Text}},
+		"stop_reason":   "end_turn",
+		"stop_sequence": nil,
+		"usage":         anthropicUsage(opts, opts.OutputTokens),
+	})
+}
+
+func anthropicUsage(opts Options, outputTokens int) map[string]any {
+	return map[string]any{
+		"input_tokens":                opts.InputTokens,
+		"output_tokens":               outputTokens,
+		"cache_read_input_tokens":     opts.CacheReadTokens,
+		"cache_creation_input_tokens": 0,
+	}
+}
+
+func anthropicStream(model string, opts Options) []byte {
+	var buf bytes.Buffer
+	event := func(name string, data any) {
+		fmt.Fprintf(&buf, "event: %s\ndata: %s\n\n", name, mustJSON(data))
+	}
+	event("message_start", map[string]any{
+		"type": "message_start",
+		"message": map[string]any{
+			"id": "msg_fake", "type": "message", "role": "assistant", "model": model,
+			"content": []any{}, "stop_reason": nil, "stop_sequence": nil,
+			"usage": anthropicUsage(opts, 1),
+		},
+	})
+	event("content_block_start", map[string]any{"type": "content_block_start", "index": 0, "content_block": map[string]any{"type": "text", "text": ""}})
+	for _, chunk := range splitText(opts.Text, opts.Chunks) {
+		event("content_block_delta", map[string]any{"type": "content_block_delta", "index": 0, "delta": map[string]any{"type": "text_delta", "text": chunk}})
+	}
+	event("content_block_stop", map[string]any{"type": "content_block_stop", "index": 0})
+	event("message_delta", map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": "end_turn", "stop_sequence": nil},
+		"usage": map[string]any{"output_tokens": opts.OutputTokens},
+	})
+	event("message_stop", map[string]any{"type": "message_stop"})
+	return buf.Bytes()
+}
+
+func openAIResponse(model string, opts Options) map[string]any {
+	return map[string]any{
+		"id":     "resp_fake",
+		"object": "response",
+		"status": "completed",
+		"model":  model,
+		"output": []any{map[string]any{
+			"id": "msg_fake", "type": "message", "role": "assistant", "status": "completed",
+			"content": []any{map[string]any{"type": "output_text", "text": opts.Text, "annotations": []any{}}},
+		}},
+		"usage": map[string]any{
+			"input_tokens":          opts.InputTokens,
+			"output_tokens":         opts.OutputTokens,
+			"total_tokens":          opts.InputTokens + opts.OutputTokens,
+			"input_tokens_details":  map[string]any{"cached_tokens": opts.CacheReadTokens},
+			"output_tokens_details": map[string]any{"reasoning_tokens": 0},
+		},
+	}
+}
+
+func openAIStream(model string, opts Options) []byte {
+	var buf bytes.Buffer
+	event := func(name string, data map[string]any) {
+		data["type"] = name
+		fmt.Fprintf(&buf, "event: %s\ndata: %s\n\n", name, mustJSON(data))
+	}
+	created := openAIResponse(model, opts)
+	created["status"] = "in_progress"
+	created["output"] = []any{}
+	delete(created, "usage")
+	event("response.created", map[string]any{"response": created})
+	for _, chunk := range splitText(opts.Text, opts.Chunks) {
+		event("response.output_text.delta", map[string]any{"item_id": "msg_fake", "output_index": 0, "content_index": 0, "delta": chunk})
+	}
+	event("response.completed", map[string]any{"response": openAIResponse(model, opts)})
+	return buf.Bytes()
+}
+
+// geminiResponse 构造 Gemini 响应；finishReason 为空表示流式中间 chunk（不携带用量）
+func geminiResponse(model, text, finishReason string, opts Options) map[string]any {
+	candidate := map[string]any{
+		"content": map[string]any{"role": "model", "parts": []any{map[string]any{"text": text}}},
+		"index":   0,
+	}
+	resp := map[string]any{
+		"candidates":   []any{candidate},
+		"modelVersion": model,
+		"responseId":   "fake-response",
+	}
+	if finishReason != "" {
+		candidate["finishReason"] = finishReason
+		usage := map[string]any{
+			"promptTokenCount":     opts.InputTokens,
+			"candidatesTokenCount": opts.OutputTokens,
+			"totalTokenCount":      opts.InputTokens + opts.OutputTokens,
+		}
+		if opts.CacheReadTokens > 0 {
+			usage["cachedContentTokenCount"] = opts.CacheReadTokens
+		}
+		resp["usageMetadata"] = usage
+	}
+	return resp
+}
+
+// splitText 将文本按字符拆分为 n 段（n<=1 或文本过短时整体返回）
+func splitText(text string, n int) []string {
+	runes := []rune(text)
+	if n <= 1 || len(runes) <= 1 {
+		return []string{text}
+	}
+	if n > len(runes) {
+		n = len(runes)
+	}
+	size := (len(runes) + n - 1) / n
+	parts := make([]string, 0, n)
+	for start := 0; start < len(runes); start += size {
+		end := start + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		parts = append(parts, string(runes[start:end]))
+	}
+	return parts
+}
+
+func sseData(payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("data: ")
+	buf.Write(payload)
+	buf.WriteString("\r\n\r\n")
+	return buf.Bytes()
+}
+
+func mustJSON(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return []byte(`{}`)
+	}
+	return b
+}
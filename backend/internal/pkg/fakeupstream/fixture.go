@@ -0,0 +1,213 @@
+package fakeupstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fixture 一次录制的上游交互，可由 Mock 回放
+type Fixture struct {
+	Kind       Kind            `json:"kind"`
+	Model      string          `json:"model,omitempty"`
+	Stream     bool            `json:"stream"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Request    FixtureRequest  `json:"request"`
+	Response   FixtureResponse `json:"response"`
+}
+
+// FixtureRequest 录制的上游请求（凭证相关请求头不落盘）
+type FixtureRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// FixtureResponse 录制的上游响应；JSON 响应存入 Body，SSE 等非 JSON 响应原样存入 BodyText
+type FixtureResponse struct {
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+	BodyText string            `json:"body_text,omitempty"`
+}
+
+// sensitiveHeaders 携带凭证的头，录制与展示时不应出现明文
+var sensitiveHeaders = map[string]bool{
+	"authorization":  true,
+	"x-api-key":      true,
+	"x-goog-api-key": true,
+	"cookie":         true,
+	"set-cookie":     true,
+}
+
+// IsSensitiveHeader 判断请求/响应头是否包含凭证
+func IsSensitiveHeader(name string) bool {
+	return sensitiveHeaders[strings.ToLower(name)]
+}
+
+// HTTPResponse 将录制的响应还原为 http.Response
+func (f *Fixture) HTTPResponse() *http.Response {
+	header := http.Header{}
+	for k, v := range f.Response.Headers {
+		header.Set(k, v)
+	}
+	body := []byte(f.Response.BodyText)
+	if len(f.Response.Body) > 0 {
+		body = f.Response.Body
+	}
+	status := f.Response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return NewResponse(status, header, body)
+}
+
+// LoadFixtures 读取目录下所有 *.json 录制文件（按文件名排序）
+func LoadFixtures(dir string) ([]*Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	fixtures := make([]*Fixture, 0, len(paths))
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var fx Fixture
+		if err := json.Unmarshal(raw, &fx); err != nil {
+			return nil, fmt.Errorf("parse fixture %s: %w", filepath.Base(path), err)
+		}
+		if fx.Kind == KindUnknown {
+			continue
+		}
+		fixtures = append(fixtures, &fx)
+	}
+	return fixtures, nil
+}
+
+var fixtureNameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// WriteFixture 将录制写入目录，返回文件路径
+func WriteFixture(dir string, fx *Fixture) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	mode := "json"
+	if fx.Stream {
+		mode = "stream"
+	}
+	model := fixtureNameSanitizer.ReplaceAllString(fx.Model, "_")
+	if model == "" {
+		model = "unknown"
+	}
+	name := fmt.Sprintf("%s-%s-%s-%d-%d.json", fx.Kind, model, mode, fx.Response.Status, fx.RecordedAt.UnixNano())
+	data, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// Record 包装真实上游响应：响应体被完整读取并关闭后写入录制文件。
+// 协议无法识别或响应体超过 maxBytes 时不录制；onSaved 可为空，用于上报写入结果。
+func Record(dir string, req *http.Request, reqBody []byte, resp *http.Response, maxBytes int, onSaved func(path string, err error)) *http.Response {
+	kind := Detect(req)
+	if kind == KindUnknown || resp == nil || resp.Body == nil {
+		return resp
+	}
+	fx := &Fixture{
+		Kind:       kind,
+		Model:      RequestModel(req, reqBody),
+		Stream:     IsStream(req, reqBody),
+		RecordedAt: time.Now(),
+		Request: FixtureRequest{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Headers: fixtureHeaders(req.Header),
+		},
+		Response: FixtureResponse{
+			Status:  resp.StatusCode,
+			Headers: fixtureHeaders(resp.Header),
+		},
+	}
+	if len(reqBody) <= maxBytes && json.Valid(reqBody) {
+		fx.Request.Body = append(json.RawMessage(nil), reqBody...)
+	}
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		maxBytes:   maxBytes,
+		save: func(body []byte) {
+			if json.Valid(body) {
+				fx.Response.Body = body
+			} else {
+				fx.Response.BodyText = string(body)
+			}
+			path, err := WriteFixture(dir, fx)
+			if onSaved != nil {
+				onSaved(path, err)
+			}
+		},
+	}
+	return resp
+}
+
+func fixtureHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if IsSensitiveHeader(k) {
+			continue
+		}
+		out[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// recordingBody 在读取响应体的同时缓存内容；仅当完整读到 EOF 时才保存，避免录下被截断的响应
+type recordingBody struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	maxBytes int
+	overflow bool
+	complete bool
+	once     sync.Once
+	save     func(body []byte)
+}
+
+func (r *recordingBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && !r.overflow {
+		if r.buf.Len()+n > r.maxBytes {
+			r.overflow = true
+			r.buf.Reset()
+		} else {
+			r.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		r.complete = true
+	}
+	return n, err
+}
+
+func (r *recordingBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(func() {
+		if r.complete && !r.overflow {
+			r.save(r.buf.Bytes())
+		}
+	})
+	return err
+}
@@ -0,0 +1,344 @@
+package fakeupstream
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MockHostSuffix 模拟上游的保留域名（.invalid 为 RFC 2606 保留 TLD，不会解析到真实主机）。
+// 账号 base_url 设置为 https://mock.invalid 或 https://<profile>.mock.invalid 即使用对应 profile。
+const MockHostSuffix = "mock.invalid"
+
+// DefaultMockProfile 未指定 profile 时使用的名称
+const DefaultMockProfile = "default"
+
+// MockProfileName 判断主机名（不含端口）是否为模拟上游并返回 profile 名
+func MockProfileName(host string) (string, bool) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == MockHostSuffix {
+		return DefaultMockProfile, true
+	}
+	if name, ok := strings.CutSuffix(host, "."+MockHostSuffix); ok && name != "" {
+		return name, true
+	}
+	return "", false
+}
+
+// Profile 模拟上游的行为配置
+type Profile struct {
+	Options
+	// Latency 首包延迟（返回响应头之前）
+	Latency time.Duration
+	// ChunkInterval 流式响应相邻 SSE 事件之间的间隔
+	ChunkInterval time.Duration
+	// 故障注入概率（0-1，按顺序累加判定）：429 限流、529 过载（Gemini/OpenAI 为 503）、500 服务端错误
+	RateLimitRate   float64
+	OverloadedRate  float64
+	ServerErrorRate float64
+	// RetryAfter 429 响应中携带的重置时间
+	RetryAfter time.Duration
+	// RateLimitHeaders 成功响应也携带限流相关响应头（用于验证会话窗口/用量快照解析）
+	RateLimitHeaders bool
+	// Fixtures 回放的录制响应；匹配（协议、流式、模型）时优先于生成的响应
+	Fixtures []*Fixture
+}
+
+// Mock 按 profile 模拟 Anthropic / OpenAI Responses / Gemini 上游
+type Mock struct {
+	profiles map[string]Profile
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	cursors map[string]int
+	seq     atomic.Int64
+}
+
+// NewMock 创建模拟上游；profiles 中没有 default 时使用 DefaultOptions
+func NewMock(profiles map[string]Profile) *Mock {
+	m := &Mock{
+		profiles: make(map[string]Profile, len(profiles)+1),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		cursors:  make(map[string]int),
+	}
+	for name, p := range profiles {
+		m.profiles[strings.ToLower(name)] = p
+	}
+	if _, ok := m.profiles[DefaultMockProfile]; !ok {
+		m.profiles[DefaultMockProfile] = Profile{Options: DefaultOptions()}
+	}
+	return m
+}
+
+// SetSeed 固定故障注入的随机序列（测试用）
+func (m *Mock) SetSeed(seed int64) {
+	m.mu.Lock()
+	m.rng = rand.New(rand.NewSource(seed))
+	m.mu.Unlock()
+}
+
+// Do 模拟一次上游调用（请求体需由调用方提前读出）
+func (m *Mock) Do(req *http.Request, body []byte) (*http.Response, error) {
+	name, _ := MockProfileName(req.URL.Hostname())
+	profile, ok := m.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("mock upstream profile %q not configured", name)
+	}
+	ctx := req.Context()
+	if err := sleepContext(ctx, profile.Latency); err != nil {
+		return nil, err
+	}
+
+	kind := Detect(req)
+	if kind == KindUnknown {
+		return NewResponse(http.StatusNotFound, http.Header{"Content-Type": []string{"application/json"}},
+			[]byte(`{"error":{"type":"not_found_error","message":"mock upstream: unsupported endpoint"}}`)), nil
+	}
+
+	requestID := fmt.Sprintf("req_mock_%d", m.seq.Add(1))
+	if status := m.injectStatus(profile); status != 0 {
+		resp := mockErrorResponse(kind, status, profile.RetryAfter)
+		resp.Header.Set(requestIDHeader(kind), requestID)
+		return resp, nil
+	}
+
+	stream := IsStream(req, body)
+	model := RequestModel(req, body)
+	var resp *http.Response
+	if fx := m.pickFixture(name, profile.Fixtures, kind, model, stream); fx != nil {
+		resp = fx.HTTPResponse()
+	} else {
+		contentType, payload := Build(kind, model, stream, profile.Options)
+		resp = NewResponse(http.StatusOK, http.Header{"Content-Type": []string{contentType}}, payload)
+	}
+	resp.Header.Set(requestIDHeader(kind), requestID)
+	if profile.RateLimitHeaders {
+		setRateLimitHeaders(resp.Header, kind, false, profile.RetryAfter)
+	}
+	if profile.ChunkInterval > 0 && strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		payload, _ := io.ReadAll(resp.Body)
+		resp.Body = newPacedBody(ctx, payload, profile.ChunkInterval)
+		resp.ContentLength = -1
+	}
+	return resp, nil
+}
+
+func (m *Mock) injectStatus(p Profile) int {
+	if p.RateLimitRate <= 0 && p.OverloadedRate <= 0 && p.ServerErrorRate <= 0 {
+		return 0
+	}
+	m.mu.Lock()
+	r := m.rng.Float64()
+	m.mu.Unlock()
+	switch {
+	case r < p.RateLimitRate:
+		return http.StatusTooManyRequests
+	case r < p.RateLimitRate+p.OverloadedRate:
+		return 529
+	case r < p.RateLimitRate+p.OverloadedRate+p.ServerErrorRate:
+		return http.StatusInternalServerError
+	default:
+		return 0
+	}
+}
+
+// pickFixture 在匹配协议与流式的录制中轮询选择，同模型的录制优先
+func (m *Mock) pickFixture(profile string, fixtures []*Fixture, kind Kind, model string, stream bool) *Fixture {
+	var sameModel, sameKind []*Fixture
+	for _, fx := range fixtures {
+		if fx.Kind != kind || fx.Stream != stream {
+			continue
+		}
+		sameKind = append(sameKind, fx)
+		if model != "" && fx.Model == model {
+			sameModel = append(sameModel, fx)
+		}
+	}
+	candidates := sameModel
+	if len(candidates) == 0 {
+		candidates = sameKind
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	key := fmt.Sprintf("%s|%s|%s|%t", profile, kind, model, stream)
+	m.mu.Lock()
+	idx := m.cursors[key] % len(candidates)
+	m.cursors[key]++
+	m.mu.Unlock()
+	return candidates[idx]
+}
+
+func requestIDHeader(kind Kind) string {
+	if kind == KindAnthropic {
+		return "request-id"
+	}
+	return "x-request-id"
+}
+
+// mockErrorResponse 构造各协议格式的错误响应（429 附带可被网关解析的重置时间）
+func mockErrorResponse(kind Kind, status int, retryAfter time.Duration) *http.Response {
+	if retryAfter <= 0 {
+		retryAfter = time.Minute
+	}
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	var body any
+	switch kind {
+	case KindAnthropic:
+		errType, message := "api_error", "Internal server error"
+		switch status {
+		case http.StatusTooManyRequests:
+			errType, message = "rate_limit_error", "This request would exceed your account's rate limit. Please try again later."
+		case 529:
+			errType, message = "overloaded_error", "Overloaded"
+		}
+		body = map[string]any{"type": "error", "error": map[string]any{"type": errType, "message": message}}
+	case KindOpenAIResponses:
+		if status == 529 {
+			status = http.StatusServiceUnavailable
+		}
+		errObj := map[string]any{"type": "server_error", "message": "The server had an error while processing your request."}
+		if status == http.StatusTooManyRequests {
+			errObj = map[string]any{
+				"type":      "rate_limit_exceeded",
+				"message":   "Rate limit reached.",
+				"resets_at": time.Now().Add(retryAfter).Unix(),
+			}
+		} else if status == http.StatusServiceUnavailable {
+			errObj["message"] = "The engine is currently overloaded, please try again later."
+		}
+		body = map[string]any{"error": errObj}
+	default:
+		if status == 529 {
+			status = http.StatusServiceUnavailable
+		}
+		errObj := map[string]any{"code": status, "message": "Internal error encountered.", "status": "INTERNAL"}
+		switch status {
+		case http.StatusTooManyRequests:
+			delay := fmt.Sprintf("%ds", int(retryAfter.Seconds()))
+			errObj = map[string]any{
+				"code":    status,
+				"message": "Resource has been exhausted (e.g. check quota).",
+				"status":  "RESOURCE_EXHAUSTED",
+				"details": []any{
+					map[string]any{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": delay},
+					map[string]any{"@type": "type.googleapis.com/google.rpc.ErrorInfo", "reason": "RATE_LIMIT_EXCEEDED", "metadata": map[string]any{"quotaResetDelay": delay}},
+				},
+			}
+		case http.StatusServiceUnavailable:
+			errObj = map[string]any{"code": status, "message": "The model is overloaded. Please try again later.", "status": "UNAVAILABLE"}
+		}
+		body = map[string]any{"error": errObj}
+	}
+	if status == http.StatusTooManyRequests {
+		setRateLimitHeaders(header, kind, true, retryAfter)
+	}
+	return NewResponse(status, header, mustJSON(body))
+}
+
+// setRateLimitHeaders 写入网关会解析的限流响应头
+func setRateLimitHeaders(h http.Header, kind Kind, limited bool, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = time.Minute
+	}
+	resetAt := time.Now().Add(retryAfter)
+	seconds := strconv.Itoa(int(retryAfter.Seconds()))
+	if limited {
+		h.Set("retry-after", seconds)
+	}
+	switch kind {
+	case KindAnthropic:
+		status := "allowed"
+		if limited {
+			status = "rejected"
+		}
+		h.Set("anthropic-ratelimit-unified-status", status)
+		h.Set("anthropic-ratelimit-unified-5h-status", status)
+		h.Set("anthropic-ratelimit-unified-reset", strconv.FormatInt(resetAt.Unix(), 10))
+	case KindOpenAIResponses:
+		used := "10"
+		remaining := "99"
+		if limited {
+			used, remaining = "100", "0"
+		}
+		h.Set("x-codex-primary-used-percent", used)
+		h.Set("x-codex-primary-reset-after-seconds", seconds)
+		h.Set("x-codex-primary-window-minutes", "300")
+		h.Set("x-ratelimit-limit-requests", "100")
+		h.Set("x-ratelimit-remaining-requests", remaining)
+		h.Set("x-ratelimit-reset-requests", seconds+"s")
+	}
+}
+
+// pacedBody 按 SSE 事件逐个输出，相邻事件之间等待 interval
+type pacedBody struct {
+	ctx      context.Context
+	events   [][]byte
+	interval time.Duration
+	current  []byte
+	started  bool
+}
+
+func newPacedBody(ctx context.Context, payload []byte, interval time.Duration) io.ReadCloser {
+	return &pacedBody{ctx: ctx, events: splitSSEEvents(payload), interval: interval}
+}
+
+func (b *pacedBody) Read(p []byte) (int, error) {
+	for len(b.current) == 0 {
+		if len(b.events) == 0 {
+			return 0, io.EOF
+		}
+		if b.started {
+			if err := sleepContext(b.ctx, b.interval); err != nil {
+				return 0, err
+			}
+		}
+		b.started = true
+		b.current, b.events = b.events[0], b.events[1:]
+	}
+	n := copy(p, b.current)
+	b.current = b.current[n:]
+	return n, nil
+}
+
+func (b *pacedBody) Close() error { return nil }
+
+// splitSSEEvents 按空行切分 SSE 事件（保留分隔符，拼接后与原文一致）
+func splitSSEEvents(payload []byte) [][]byte {
+	var events [][]byte
+	for len(payload) > 0 {
+		idx, sepLen := bytes.Index(payload, []byte("\n\n")), 2
+		if crlf := bytes.Index(payload, []byte("\r\n\r\n")); crlf >= 0 && (idx < 0 || crlf < idx) {
+			idx, sepLen = crlf, 4
+		}
+		if idx < 0 {
+			events = append(events, payload)
+			break
+		}
+		events = append(events, payload[:idx+sepLen])
+		payload = payload[idx+sepLen:]
+	}
+	return events
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
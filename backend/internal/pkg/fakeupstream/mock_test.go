@@ -0,0 +1,176 @@
+package fakeupstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newMockRequest(t *testing.T, url, body string) (*http.Request, []byte) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	return req, []byte(body)
+}
+
+func TestMockProfileName(t *testing.T) {
+	cases := map[string]string{
+		"mock.invalid":       DefaultMockProfile,
+		"Flaky.Mock.Invalid": "flaky",
+		"api.anthropic.com":  "",
+		"mock.invalid.com":   "",
+		".mock.invalid":      "",
+	}
+	for host, want := range cases {
+		got, ok := MockProfileName(host)
+		if got != want || ok != (want != "") {
+			t.Errorf("MockProfileName(%q) = %q, %v; want %q", host, got, ok, want)
+		}
+	}
+}
+
+func TestMock_StreamPacingAndUsage(t *testing.T) {
+	m := NewMock(map[string]Profile{
+		"slow": {
+			Options:       Options{Text: "abcdef", InputTokens: 20, OutputTokens: 6, CacheReadTokens: 5, Chunks: 3},
+			ChunkInterval: 5 * time.Millisecond,
+		},
+	})
+	req, body := newMockRequest(t, "https://slow.mock.invalid/v1/messages", `{"model":"claude-sonnet-4-5","stream":true}`)
+
+	start := time.Now()
+	resp, err := m.Do(req, body)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	payload, _ := io.ReadAll(resp.Body)
+	elapsed := time.Since(start)
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("request-id") == "" {
+		t.Fatalf("status = %d, headers = %v", resp.StatusCode, resp.Header)
+	}
+	if got := strings.Count(string(payload), "text_delta"); got != 3 {
+		t.Errorf("text deltas = %d, want 3", got)
+	}
+	if !strings.Contains(string(payload), `"cache_read_input_tokens":5`) {
+		t.Errorf("usage missing cache tokens: %s", payload)
+	}
+	// 8 个事件之间有 7 个间隔
+	if elapsed < 35*time.Millisecond {
+		t.Errorf("stream was not paced, elapsed %v", elapsed)
+	}
+}
+
+func TestMock_ErrorInjection(t *testing.T) {
+	m := NewMock(map[string]Profile{
+		"limited":    {Options: DefaultOptions(), RateLimitRate: 1, RetryAfter: 30 * time.Second},
+		"overloaded": {Options: DefaultOptions(), OverloadedRate: 1},
+	})
+
+	req, body := newMockRequest(t, "https://limited.mock.invalid/v1/messages", `{"model":"claude-sonnet-4-5"}`)
+	resp, _ := m.Do(req, body)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("anthropic-ratelimit-unified-reset") == "" || resp.Header.Get("retry-after") != "30" {
+		t.Errorf("anthropic 429 = %d %v", resp.StatusCode, resp.Header)
+	}
+
+	req, body = newMockRequest(t, "https://limited.mock.invalid/v1beta/models/gemini-2.5-flash:generateContent", `{}`)
+	resp, _ = m.Do(req, body)
+	payload, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusTooManyRequests || !strings.Contains(string(payload), `"quotaResetDelay":"30s"`) {
+		t.Errorf("gemini 429 = %d %s", resp.StatusCode, payload)
+	}
+
+	req, body = newMockRequest(t, "https://limited.mock.invalid/v1/responses", `{"model":"gpt-5"}`)
+	resp, _ = m.Do(req, body)
+	payload, _ = io.ReadAll(resp.Body)
+	if resp.Header.Get("x-codex-primary-used-percent") != "100" || !strings.Contains(string(payload), "resets_at") {
+		t.Errorf("openai 429 = %v %s", resp.Header, payload)
+	}
+
+	req, body = newMockRequest(t, "https://overloaded.mock.invalid/v1/messages", `{"model":"claude-sonnet-4-5"}`)
+	resp, _ = m.Do(req, body)
+	if resp.StatusCode != 529 {
+		t.Errorf("anthropic overloaded = %d, want 529", resp.StatusCode)
+	}
+	req, body = newMockRequest(t, "https://overloaded.mock.invalid/v1/responses", `{"model":"gpt-5"}`)
+	resp, _ = m.Do(req, body)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("openai overloaded = %d, want 503", resp.StatusCode)
+	}
+
+	req, body = newMockRequest(t, "https://unknown.mock.invalid/v1/messages", `{}`)
+	if _, err := m.Do(req, body); err == nil {
+		t.Error("unknown profile should fail")
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	upstreamBody := "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"
+	req, body := newMockRequest(t, "https://api.anthropic.com/v1/messages", `{"model":"claude-opus-4-5","stream":true}`)
+	req.Header.Set("x-api-key", "sk-secret")
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	var saved string
+	resp := Record(dir, req, body, NewResponse(http.StatusOK, http.Header{"Content-Type": []string{"text/event-stream"}}, []byte(upstreamBody)), 1<<20, func(path string, err error) {
+		if err != nil {
+			t.Errorf("save fixture: %v", err)
+		}
+		saved = path
+	})
+	got, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(got) != upstreamBody || saved == "" {
+		t.Fatalf("recorded body = %q, saved = %q", got, saved)
+	}
+
+	fixtures, err := LoadFixtures(dir)
+	if err != nil || len(fixtures) != 1 {
+		t.Fatalf("LoadFixtures() = %d, %v", len(fixtures), err)
+	}
+	fx := fixtures[0]
+	if fx.Kind != KindAnthropic || fx.Model != "claude-opus-4-5" || !fx.Stream {
+		t.Errorf("fixture = %+v", fx)
+	}
+	raw, _ := json.Marshal(fx.Request.Headers)
+	if bytes.Contains(raw, []byte("sk-secret")) {
+		t.Errorf("credentials leaked into fixture: %s", raw)
+	}
+
+	m := NewMock(map[string]Profile{DefaultMockProfile: {Options: DefaultOptions(), Fixtures: fixtures}})
+	req, body = newMockRequest(t, "https://mock.invalid/v1/messages", `{"model":"claude-opus-4-5","stream":true}`)
+	replayed, _ := m.Do(req, body)
+	got, _ = io.ReadAll(replayed.Body)
+	if string(got) != upstreamBody {
+		t.Errorf("replayed body = %q", got)
+	}
+
+	// 非流式请求没有匹配的录制时回退到生成的响应
+	req, body = newMockRequest(t, "https://mock.invalid/v1/messages", `{"model":"claude-opus-4-5"}`)
+	generated, _ := m.Do(req, body)
+	got, _ = io.ReadAll(generated.Body)
+	if !strings.Contains(string(got), `"type":"message"`) {
+		t.Errorf("generated body = %s", got)
+	}
+}
+
+func TestRecord_SkipsTruncatedResponse(t *testing.T) {
+	dir := t.TempDir()
+	req, body := newMockRequest(t, "https://api.openai.com/v1/responses", `{"model":"gpt-5"}`)
+	resp := Record(dir, req, body, NewResponse(http.StatusOK, nil, []byte(`{"id":"resp_1"}`)), 1<<20, nil)
+	buf := make([]byte, 4)
+	_, _ = resp.Body.Read(buf)
+	_ = resp.Body.Close()
+
+	fixtures, err := LoadFixtures(dir)
+	if err != nil || len(fixtures) != 0 {
+		t.Errorf("partially read response must not be recorded, got %d fixtures (%v)", len(fixtures), err)
+	}
+}
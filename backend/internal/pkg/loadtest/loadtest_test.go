@@ -0,0 +1,188 @@
+package loadtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRampConcurrencyAt(t *testing.T) {
+	r := Ramp{Start: 2, Step: 3, Max: 10, StepDuration: time.Second}
+	cases := map[time.Duration]int{0: 2, 999 * time.Millisecond: 2, time.Second: 5, 2 * time.Second: 8, 10 * time.Second: 10}
+	for elapsed, want := range cases {
+		if got := r.ConcurrencyAt(elapsed); got != want {
+			t.Errorf("ConcurrencyAt(%v) = %d, want %d", elapsed, got, want)
+		}
+	}
+	if got := (Ramp{Max: 4}).ConcurrencyAt(time.Hour); got != 4 {
+		t.Errorf("no ramp: got %d, want 4", got)
+	}
+}
+
+func TestLoadPlanAndNormalize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.yaml")
+	plan := `
+base_url: http://127.0.0.1:8080/
+api_key: sk-test
+duration: 2m
+ramp: {start: 4, step: 4, max: 16, step_duration: 30s}
+scenarios:
+  - model: claude-sonnet-4-5
+    weight: 3
+    stream_ratio: 0.8
+    prompt_tokens: [200, 8000]
+  - format: gemini
+    model: gemini-2.5-flash
+`
+	if err := os.WriteFile(path, []byte(plan), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan() error = %v", err)
+	}
+	if err := p.Normalize(); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if p.BaseURL != "http://127.0.0.1:8080" || p.Duration != 2*time.Minute || p.Ramp.StepDuration != 30*time.Second {
+		t.Errorf("plan = %+v", p)
+	}
+	if sc := p.Scenarios[1]; sc.Format != FormatGemini || sc.Weight != 1 || sc.MaxTokens != 256 || sc.Name != "gemini:gemini-2.5-flash" {
+		t.Errorf("defaults not applied: %+v", sc)
+	}
+
+	p.Scenarios[0].Format = "chat"
+	if err := p.Normalize(); err == nil {
+		t.Error("unsupported format should be rejected")
+	}
+}
+
+func TestNewRequestFormats(t *testing.T) {
+	p := &Plan{BaseURL: "http://x", APIKey: "k", Duration: time.Second, Ramp: Ramp{Max: 1}, Scenarios: []Scenario{
+		{Format: FormatGemini, Model: "gemini-2.5-pro", StreamRatio: 1, PromptTokens: []int{100}},
+	}}
+	if err := p.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := p.newRequest(newTestRand())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.path != "/v1beta/models/gemini-2.5-pro:streamGenerateContent?alt=sse" || !req.stream {
+		t.Errorf("gemini stream request = %s", req.path)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(req.body, &body); err != nil {
+		t.Fatal(err)
+	}
+	text := body["contents"].([]any)[0].(map[string]any)["parts"].([]any)[0].(map[string]any)["text"].(string)
+	if len(text) < 400 {
+		t.Errorf("prompt too short for 100 tokens: %d bytes", len(text))
+	}
+}
+
+// TestRunnerAgainstFakeGateway 用模拟网关验证吞吐、TTFT、429、诊断头统计
+func TestRunnerAgainstFakeGateway(t *testing.T) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := calls.Add(1)
+		w.Header().Set("X-Sub2api-Queue-Wait-Ms", "7")
+		if n%5 == 0 {
+			w.Header().Set("X-Sub2api-Account-Switches", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error"}}`))
+			return
+		}
+		w.Header().Set("X-Sub2api-Account-Switches", "0")
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			_, _ = w.Write([]byte(`{"type":"message"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		_, _ = fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n")
+		flusher.Flush()
+		time.Sleep(5 * time.Millisecond)
+		_, _ = fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\"}\n\n")
+		_, _ = fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer srv.Close()
+
+	plan := &Plan{
+		BaseURL:   srv.URL,
+		APIKey:    "sk-test",
+		Duration:  400 * time.Millisecond,
+		Ramp:      Ramp{Start: 1, Step: 1, Max: 3, StepDuration: 150 * time.Millisecond},
+		Scenarios: []Scenario{{Model: "claude-sonnet-4-5", StreamRatio: 0.5}},
+	}
+	if err := plan.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	report := NewRunner(plan, srv.Client()).Run(t.Context())
+
+	total := report.Total
+	if total.Requests == 0 || total.Requests != int(calls.Load()) {
+		t.Fatalf("requests = %d, server calls = %d", total.Requests, calls.Load())
+	}
+	if total.RateLimited != total.Requests/5 || total.StatusCounts["429"] != total.RateLimited {
+		t.Errorf("rate limited = %d of %d (%v)", total.RateLimited, total.Requests, total.StatusCounts)
+	}
+	if total.Failovers != total.RateLimited || total.FailoverRequests != total.RateLimited {
+		t.Errorf("failovers = %d, want %d", total.Failovers, total.RateLimited)
+	}
+	if !report.HasDiagnostics || total.QueueWait == nil || total.QueueWait.P50 != 7 {
+		t.Errorf("queue wait = %+v", total.QueueWait)
+	}
+	if total.TTFT == nil || total.TTFT.P50 < 5 {
+		t.Errorf("ttft = %+v", total.TTFT)
+	}
+	if total.Throughput <= 0 {
+		t.Errorf("throughput = %v", total.Throughput)
+	}
+	if len(report.Stages) != 3 || report.Stages[2].Concurrency != 3 {
+		t.Errorf("stages = %d", len(report.Stages))
+	}
+
+	var out bytes.Buffer
+	report.WriteText(&out)
+	if !strings.Contains(out.String(), "TOTAL") || !strings.Contains(out.String(), "claude-sonnet-4-5") {
+		t.Errorf("text report:\n%s", out.String())
+	}
+}
+
+func TestReadStreamError(t *testing.T) {
+	stream := "data: {\"type\":\"message_start\"}\n\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\"}}\n\n"
+	if _, err := readStream(strings.NewReader(stream), time.Now()); err == nil {
+		t.Error("stream error event should fail the request")
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	var values []time.Duration
+	for i := 1; i <= 100; i++ {
+		values = append(values, time.Duration(i)*time.Millisecond)
+	}
+	p := percentiles(values)
+	if p.P50 != 50 || p.P95 != 95 || p.P99 != 99 || p.Max != 100 {
+		t.Errorf("percentiles = %+v", p)
+	}
+}
+
+func newTestRand() *rand.Rand {
+	return rand.New(rand.NewSource(1))
+}
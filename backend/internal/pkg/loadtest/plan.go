@@ -0,0 +1,234 @@
+// Package loadtest 网关压测：按配置的请求组合（模型、流式比例、prompt 大小、并发爬坡）驱动 sub2api 实例，
+// 统计吞吐、TTFT、延迟分位数、排队等待、429 比例与账号切换次数，用于账号池容量规划。
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的请求协议
+const (
+	FormatMessages  = "messages"  // POST /v1/messages（Anthropic）
+	FormatResponses = "responses" // POST /v1/responses（OpenAI Responses）
+	FormatGemini    = "gemini"    // POST /v1beta/models/{model}:generateContent（Gemini 原生）
+)
+
+// Scenario 一类请求；按 Weight 在所有场景中加权随机抽取
+type Scenario struct {
+	Name   string `yaml:"name" json:"name"`
+	Weight int    `yaml:"weight" json:"weight"`
+	Format string `yaml:"format" json:"format"`
+	Model  string `yaml:"model" json:"model"`
+	// StreamRatio 流式请求比例（0-1）
+	StreamRatio float64 `yaml:"stream_ratio" json:"stream_ratio"`
+	// PromptTokens 近似 prompt token 数，多个值时每次随机取一个
+	PromptTokens []int `yaml:"prompt_tokens" json:"prompt_tokens"`
+	MaxTokens    int   `yaml:"max_tokens" json:"max_tokens"`
+}
+
+// Ramp 并发爬坡：从 Start 开始，每 StepDuration 增加 Step，直到 Max 后保持到压测结束
+type Ramp struct {
+	Start        int           `yaml:"start" json:"start"`
+	Step         int           `yaml:"step" json:"step"`
+	Max          int           `yaml:"max" json:"max"`
+	StepDuration time.Duration `yaml:"step_duration" json:"step_duration"`
+}
+
+// Plan 一次压测的完整配置
+type Plan struct {
+	BaseURL   string        `yaml:"base_url" json:"base_url"`
+	APIKey    string        `yaml:"api_key" json:"-"`
+	Duration  time.Duration `yaml:"duration" json:"duration"`
+	Timeout   time.Duration `yaml:"timeout" json:"timeout"`
+	Ramp      Ramp          `yaml:"ramp" json:"ramp"`
+	Scenarios []Scenario    `yaml:"scenarios" json:"scenarios"`
+}
+
+// LoadPlan 从 YAML 文件读取压测配置（JSON 是 YAML 子集，同样可用；时长写作 "30s"、"5m"）
+func LoadPlan(path string) (*Plan, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+	if err := yaml.Unmarshal(raw, plan); err != nil {
+		return nil, fmt.Errorf("parse plan %s: %w", path, err)
+	}
+	return plan, nil
+}
+
+// Normalize 补齐默认值并校验配置
+func (p *Plan) Normalize() error {
+	p.BaseURL = strings.TrimRight(strings.TrimSpace(p.BaseURL), "/")
+	if p.BaseURL == "" {
+		return fmt.Errorf("base_url is required")
+	}
+	if strings.TrimSpace(p.APIKey) == "" {
+		return fmt.Errorf("api_key is required")
+	}
+	if p.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = 5 * time.Minute
+	}
+	if p.Ramp.Max <= 0 {
+		return fmt.Errorf("ramp.max must be positive")
+	}
+	if p.Ramp.Start <= 0 || p.Ramp.Start > p.Ramp.Max {
+		p.Ramp.Start = p.Ramp.Max
+	}
+	if p.Ramp.Step <= 0 || p.Ramp.StepDuration <= 0 {
+		// 未配置爬坡时直接使用最大并发
+		p.Ramp.Start = p.Ramp.Max
+	}
+	if len(p.Scenarios) == 0 {
+		return fmt.Errorf("at least one scenario is required")
+	}
+	for i := range p.Scenarios {
+		sc := &p.Scenarios[i]
+		switch sc.Format {
+		case "":
+			sc.Format = FormatMessages
+		case FormatMessages, FormatResponses, FormatGemini:
+		default:
+			return fmt.Errorf("scenarios[%d]: unsupported format %q", i, sc.Format)
+		}
+		if strings.TrimSpace(sc.Model) == "" {
+			return fmt.Errorf("scenarios[%d]: model is required", i)
+		}
+		if sc.Weight < 0 {
+			return fmt.Errorf("scenarios[%d]: weight must be non-negative", i)
+		}
+		if sc.Weight == 0 {
+			sc.Weight = 1
+		}
+		if sc.StreamRatio < 0 || sc.StreamRatio > 1 {
+			return fmt.Errorf("scenarios[%d]: stream_ratio must be within [0,1]", i)
+		}
+		if len(sc.PromptTokens) == 0 {
+			sc.PromptTokens = []int{200}
+		}
+		for _, n := range sc.PromptTokens {
+			if n <= 0 {
+				return fmt.Errorf("scenarios[%d]: prompt_tokens must be positive", i)
+			}
+		}
+		if sc.MaxTokens <= 0 {
+			sc.MaxTokens = 256
+		}
+		if sc.Name == "" {
+			sc.Name = sc.Format + ":" + sc.Model
+		}
+	}
+	return nil
+}
+
+// ConcurrencyAt 返回压测开始 elapsed 后的目标并发
+func (r Ramp) ConcurrencyAt(elapsed time.Duration) int {
+	if r.Start >= r.Max || r.StepDuration <= 0 || r.Step <= 0 {
+		return r.Max
+	}
+	n := r.Start + int(elapsed/r.StepDuration)*r.Step
+	if n > r.Max {
+		return r.Max
+	}
+	return n
+}
+
+// pick 按权重随机选择场景
+func (p *Plan) pick(rng *rand.Rand) *Scenario {
+	total := 0
+	for i := range p.Scenarios {
+		total += p.Scenarios[i].Weight
+	}
+	n := rng.Intn(total)
+	for i := range p.Scenarios {
+		n -= p.Scenarios[i].Weight
+		if n < 0 {
+			return &p.Scenarios[i]
+		}
+	}
+	return &p.Scenarios[len(p.Scenarios)-1]
+}
+
+// request 一次待发送的请求
+type request struct {
+	scenario     *Scenario
+	stream       bool
+	promptTokens int
+	path         string
+	body         []byte
+}
+
+// promptFiller 用于拼接 prompt；按约 4 字节/token 估算长度
+const promptFiller = "The quick brown fox jumps over the lazy dog. "
+
+func buildPrompt(tokens int, rng *rand.Rand) string {
+	// 随机前缀避免命中 prompt 缓存导致测量失真
+	prefix := fmt.Sprintf("[loadtest %08x] ", rng.Uint32())
+	n := tokens*4 - len(prefix)
+	if n <= 0 {
+		return prefix
+	}
+	var b strings.Builder
+	b.Grow(tokens*4 + len(promptFiller))
+	b.WriteString(prefix)
+	for b.Len() < tokens*4 {
+		b.WriteString(promptFiller)
+	}
+	return b.String()
+}
+
+func (p *Plan) newRequest(rng *rand.Rand) (*request, error) {
+	sc := p.pick(rng)
+	req := &request{
+		scenario:     sc,
+		stream:       rng.Float64() < sc.StreamRatio,
+		promptTokens: sc.PromptTokens[rng.Intn(len(sc.PromptTokens))],
+	}
+	prompt := buildPrompt(req.promptTokens, rng)
+
+	var payload any
+	switch sc.Format {
+	case FormatResponses:
+		req.path = "/v1/responses"
+		payload = map[string]any{
+			"model":             sc.Model,
+			"stream":            req.stream,
+			"max_output_tokens": sc.MaxTokens,
+			"input":             []any{map[string]any{"role": "user", "content": prompt}},
+		}
+	case FormatGemini:
+		action := "generateContent"
+		if req.stream {
+			action = "streamGenerateContent?alt=sse"
+		}
+		req.path = "/v1beta/models/" + sc.Model + ":" + action
+		payload = map[string]any{
+			"contents":         []any{map[string]any{"role": "user", "parts": []any{map[string]any{"text": prompt}}}},
+			"generationConfig": map[string]any{"maxOutputTokens": sc.MaxTokens},
+		}
+	default:
+		req.path = "/v1/messages"
+		payload = map[string]any{
+			"model":      sc.Model,
+			"stream":     req.stream,
+			"max_tokens": sc.MaxTokens,
+			"messages":   []any{map[string]any{"role": "user", "content": prompt}},
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req.body = body
+	return req, nil
+}
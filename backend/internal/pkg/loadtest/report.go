@@ -0,0 +1,255 @@
+package loadtest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// StageWindow 一个爬坡阶段（目标并发不变的时间段）
+type StageWindow struct {
+	Concurrency int       `json:"concurrency"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+}
+
+// Percentiles 延迟分位数（毫秒）
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// Stats 一组请求的汇总统计
+type Stats struct {
+	Requests    int `json:"requests"`
+	Succeeded   int `json:"succeeded"`
+	Failed      int `json:"failed"`
+	RateLimited int `json:"rate_limited"`
+	// Throughput 成功请求数 / 时长（req/s）
+	Throughput    float64        `json:"throughput"`
+	RateLimitRate float64        `json:"rate_limit_rate"`
+	ErrorRate     float64        `json:"error_rate"`
+	StatusCounts  map[string]int `json:"status_counts"`
+	// Latency 仅统计成功请求
+	Latency Percentiles  `json:"latency_ms"`
+	TTFT    *Percentiles `json:"ttft_ms,omitempty"`
+	// 以下字段来自服务端诊断头，服务端未开启 gateway.diagnostic_headers 时为空
+	QueueWait        *Percentiles `json:"queue_wait_ms,omitempty"`
+	Failovers        int          `json:"failovers"`
+	FailoverRequests int          `json:"failover_requests"`
+}
+
+// StageReport 某个并发阶段的统计，用于观察吞吐与延迟随并发的变化
+type StageReport struct {
+	Concurrency int           `json:"concurrency"`
+	Duration    time.Duration `json:"duration"`
+	Stats
+}
+
+// ScenarioReport 某个场景的统计
+type ScenarioReport struct {
+	Name string `json:"name"`
+	Stats
+}
+
+// Report 压测报告
+type Report struct {
+	Duration       time.Duration     `json:"duration"`
+	HasDiagnostics bool              `json:"has_diagnostics"`
+	Total          Stats             `json:"total"`
+	Stages         []*StageReport    `json:"stages"`
+	Scenarios      []*ScenarioReport `json:"scenarios"`
+	// Errors 传输错误与流内错误按消息计数
+	Errors map[string]int `json:"errors,omitempty"`
+	// SaturationConcurrency 首个 p95 延迟超过上一阶段 2 倍或 429 比例超过 5% 的并发，0 表示未观察到饱和
+	SaturationConcurrency int `json:"saturation_concurrency"`
+}
+
+func buildReport(plan *Plan, samples []*Sample, stages []*StageWindow, duration time.Duration) *Report {
+	report := &Report{Duration: duration}
+	for _, s := range samples {
+		if s.HasDiagnostics {
+			report.HasDiagnostics = true
+			break
+		}
+	}
+	report.Total = computeStats(samples, duration)
+	for _, s := range samples {
+		if s.Err != "" {
+			if report.Errors == nil {
+				report.Errors = make(map[string]int)
+			}
+			report.Errors[truncate(s.Err, 120)]++
+		}
+	}
+
+	byStage := make(map[int][]*Sample)
+	for _, s := range samples {
+		byStage[s.Concurrency] = append(byStage[s.Concurrency], s)
+	}
+	var prevP95 float64
+	for _, st := range stages {
+		sr := &StageReport{Concurrency: st.Concurrency, Duration: st.End.Sub(st.Start)}
+		sr.Stats = computeStats(byStage[st.Concurrency], sr.Duration)
+		report.Stages = append(report.Stages, sr)
+		if report.SaturationConcurrency == 0 && sr.Requests > 0 {
+			if sr.RateLimitRate > 0.05 || (prevP95 > 0 && sr.Latency.P95 > 2*prevP95) {
+				report.SaturationConcurrency = sr.Concurrency
+			}
+			prevP95 = sr.Latency.P95
+		}
+	}
+
+	byScenario := make(map[string][]*Sample)
+	for _, s := range samples {
+		byScenario[s.Scenario] = append(byScenario[s.Scenario], s)
+	}
+	for _, sc := range plan.Scenarios {
+		if list, ok := byScenario[sc.Name]; ok {
+			report.Scenarios = append(report.Scenarios, &ScenarioReport{Name: sc.Name, Stats: computeStats(list, duration)})
+			delete(byScenario, sc.Name)
+		}
+	}
+	return report
+}
+
+func computeStats(samples []*Sample, duration time.Duration) Stats {
+	st := Stats{Requests: len(samples), StatusCounts: map[string]int{}}
+	var latency, ttft, queueWait []time.Duration
+	for _, s := range samples {
+		if s.OK() {
+			st.Succeeded++
+			latency = append(latency, s.Latency)
+			if s.Stream && s.TTFT > 0 {
+				ttft = append(ttft, s.TTFT)
+			}
+		} else {
+			st.Failed++
+		}
+		if s.Status == 429 {
+			st.RateLimited++
+		}
+		switch {
+		case s.Status > 0:
+			st.StatusCounts[strconv.Itoa(s.Status)]++
+		default:
+			st.StatusCounts["transport_error"]++
+		}
+		if s.HasDiagnostics {
+			queueWait = append(queueWait, s.QueueWait)
+			st.Failovers += s.Switches
+			if s.Switches > 0 {
+				st.FailoverRequests++
+			}
+		}
+	}
+	if st.Requests > 0 {
+		st.RateLimitRate = float64(st.RateLimited) / float64(st.Requests)
+		st.ErrorRate = float64(st.Failed) / float64(st.Requests)
+	}
+	if duration > 0 {
+		st.Throughput = float64(st.Succeeded) / duration.Seconds()
+	}
+	st.Latency = percentiles(latency)
+	if len(ttft) > 0 {
+		p := percentiles(ttft)
+		st.TTFT = &p
+	}
+	if len(queueWait) > 0 {
+		p := percentiles(queueWait)
+		st.QueueWait = &p
+	}
+	return st
+}
+
+// percentiles 使用 nearest-rank 计算分位数
+func percentiles(values []time.Duration) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p float64) float64 {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return ms(sorted[idx])
+	}
+	return Percentiles{P50: rank(0.50), P90: rank(0.90), P95: rank(0.95), P99: rank(0.99), Max: ms(sorted[len(sorted)-1])}
+}
+
+func ms(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*10) / 10
+}
+
+// WriteText 以表格形式输出报告
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Duration: %s   Requests: %d   Succeeded: %d   Throughput: %.2f req/s\n",
+		r.Duration.Round(time.Millisecond), r.Total.Requests, r.Total.Succeeded, r.Total.Throughput)
+	fmt.Fprintf(w, "Errors: %.2f%%   429: %.2f%%   Status: %v\n", r.Total.ErrorRate*100, r.Total.RateLimitRate*100, r.Total.StatusCounts)
+	if !r.HasDiagnostics {
+		fmt.Fprintln(w, "Queue wait / failover: n/a (enable gateway.diagnostic_headers on the server)")
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "concurrency\treq/s\trequests\terr%\t429%\tp50 ms\tp95 ms\tp99 ms\tttft p50\tttft p95\tqueue p95\tfailovers\t")
+	for _, st := range r.Stages {
+		writeStatsRow(tw, strconv.Itoa(st.Concurrency), &st.Stats)
+	}
+	_ = tw.Flush()
+	fmt.Fprintln(w)
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "scenario\treq/s\trequests\terr%\t429%\tp50 ms\tp95 ms\tp99 ms\tttft p50\tttft p95\tqueue p95\tfailovers\t")
+	for _, sc := range r.Scenarios {
+		writeStatsRow(tw, sc.Name, &sc.Stats)
+	}
+	writeStatsRow(tw, "TOTAL", &r.Total)
+	_ = tw.Flush()
+
+	if len(r.Errors) > 0 {
+		msgs := make([]string, 0, len(r.Errors))
+		for msg := range r.Errors {
+			msgs = append(msgs, msg)
+		}
+		sort.Slice(msgs, func(i, j int) bool { return r.Errors[msgs[i]] > r.Errors[msgs[j]] })
+		fmt.Fprintln(w, "\nTop errors:")
+		for i, msg := range msgs {
+			if i == 5 {
+				break
+			}
+			fmt.Fprintf(w, "  %6d  %s\n", r.Errors[msg], msg)
+		}
+	}
+
+	if r.SaturationConcurrency > 0 {
+		fmt.Fprintf(w, "\nSaturation observed at concurrency %d (p95 latency doubled or 429 rate > 5%%)\n", r.SaturationConcurrency)
+	}
+}
+
+func writeStatsRow(w io.Writer, label string, st *Stats) {
+	ttft50, ttft95, queue95 := "-", "-", "-"
+	if st.TTFT != nil {
+		ttft50, ttft95 = fmtMs(st.TTFT.P50), fmtMs(st.TTFT.P95)
+	}
+	if st.QueueWait != nil {
+		queue95 = fmtMs(st.QueueWait.P95)
+	}
+	fmt.Fprintf(w, "%s\t%.2f\t%d\t%.1f\t%.1f\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t\n",
+		label, st.Throughput, st.Requests, st.ErrorRate*100, st.RateLimitRate*100,
+		fmtMs(st.Latency.P50), fmtMs(st.Latency.P95), fmtMs(st.Latency.P99),
+		ttft50, ttft95, queue95, st.Failovers)
+}
+
+func fmtMs(v float64) string {
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
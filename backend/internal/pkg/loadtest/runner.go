@@ -0,0 +1,237 @@
+package loadtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 服务端诊断响应头（需开启 gateway.diagnostic_headers）
+const (
+	headerQueueWaitMs     = "X-Sub2api-Queue-Wait-Ms"
+	headerAccountSwitches = "X-Sub2api-Account-Switches"
+)
+
+// Sample 单个请求的测量结果
+type Sample struct {
+	Scenario     string
+	Stream       bool
+	PromptTokens int
+	// Concurrency 请求发出时的目标并发（所属爬坡阶段）
+	Concurrency int
+	Status      int
+	Err         string
+	Latency     time.Duration
+	// TTFT 流式请求收到首个内容事件的时间；非流式请求为 0
+	TTFT time.Duration
+	// QueueWait / Switches 来自服务端诊断头，HasDiagnostics 为 false 时无效
+	QueueWait      time.Duration
+	Switches       int
+	HasDiagnostics bool
+}
+
+// OK 请求是否成功（2xx 且没有传输错误）
+func (s *Sample) OK() bool {
+	return s.Err == "" && s.Status >= 200 && s.Status < 300
+}
+
+// Runner 压测执行器
+type Runner struct {
+	plan   *Plan
+	client *http.Client
+	// OnSample 每个请求完成后回调（可为空，用于进度输出），需并发安全
+	OnSample func(*Sample)
+}
+
+// NewRunner 创建执行器；client 为空时使用按并发上限调整过连接池的默认客户端
+func NewRunner(plan *Plan, client *http.Client) *Runner {
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = plan.Ramp.Max * 2
+		transport.MaxIdleConnsPerHost = plan.Ramp.Max * 2
+		client = &http.Client{Transport: transport}
+	}
+	return &Runner{plan: plan, client: client}
+}
+
+// Run 按爬坡计划执行压测直到 plan.Duration 结束或 ctx 取消；进行中的请求会等待完成
+func (r *Runner) Run(ctx context.Context) *Report {
+	ctx, cancel := context.WithTimeout(ctx, r.plan.Duration)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		samples []*Sample
+		wg      sync.WaitGroup
+		target  atomic.Int64
+		stages  []*StageWindow
+	)
+	start := time.Now()
+	workers := 0
+	setTarget := func(n int, at time.Time) {
+		target.Store(int64(n))
+		mu.Lock()
+		if len(stages) > 0 {
+			stages[len(stages)-1].End = at
+		}
+		stages = append(stages, &StageWindow{Concurrency: n, Start: at})
+		mu.Unlock()
+		for ; workers < n; workers++ {
+			wg.Add(1)
+			go func(id int) {
+				defer wg.Done()
+				rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(id)))
+				for ctx.Err() == nil {
+					s := r.do(ctx, rng, int(target.Load()))
+					if s == nil {
+						continue
+					}
+					mu.Lock()
+					samples = append(samples, s)
+					mu.Unlock()
+					if r.OnSample != nil {
+						r.OnSample(s)
+					}
+				}
+			}(workers)
+		}
+	}
+
+	setTarget(r.plan.Ramp.ConcurrencyAt(0), start)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case now := <-ticker.C:
+			if n := r.plan.Ramp.ConcurrencyAt(now.Sub(start)); n > int(target.Load()) {
+				setTarget(n, now)
+			}
+		}
+	}
+	end := time.Now()
+	wg.Wait()
+
+	stages[len(stages)-1].End = end
+	return buildReport(r.plan, samples, stages, end.Sub(start))
+}
+
+// do 发送一个请求；压测结束导致的取消不计入结果
+func (r *Runner) do(ctx context.Context, rng *rand.Rand, concurrency int) *Sample {
+	req, err := r.plan.newRequest(rng)
+	if err != nil {
+		return &Sample{Err: err.Error(), Concurrency: concurrency}
+	}
+	s := &Sample{
+		Scenario:     req.scenario.Name,
+		Stream:       req.stream,
+		PromptTokens: req.promptTokens,
+		Concurrency:  concurrency,
+	}
+
+	// 单请求超时独立于压测时长：已发出的请求允许完成，避免长尾请求被截断后低估延迟
+	reqCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.plan.Timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(reqCtx, http.MethodPost, r.plan.BaseURL+req.path, bytes.NewReader(req.body))
+	if err != nil {
+		s.Err = err.Error()
+		return s
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+r.plan.APIKey)
+	if req.scenario.Format == FormatMessages {
+		httpReq.Header.Set("anthropic-version", "2023-06-01")
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	start := time.Now()
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		s.Latency = time.Since(start)
+		s.Err = err.Error()
+		return s
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	s.Status = resp.StatusCode
+	if v := resp.Header.Get(headerQueueWaitMs); v != "" {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			s.QueueWait = time.Duration(ms) * time.Millisecond
+			s.HasDiagnostics = true
+		}
+	}
+	if v := resp.Header.Get(headerAccountSwitches); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			s.Switches = n
+			s.HasDiagnostics = true
+		}
+	}
+
+	if req.stream && s.Status == http.StatusOK {
+		s.TTFT, err = readStream(resp.Body, start)
+	} else {
+		_, err = io.Copy(io.Discard, resp.Body)
+	}
+	s.Latency = time.Since(start)
+	if err != nil {
+		s.Err = err.Error()
+	}
+	return s
+}
+
+// readStream 读完 SSE 响应，返回首个内容事件的到达时间；流内错误事件视为请求失败
+func readStream(body io.Reader, start time.Time) (time.Duration, error) {
+	var ttft time.Duration
+	reader := bufio.NewReaderSize(body, 64*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:"); ok {
+			data = strings.TrimSpace(data)
+			if isStreamError(data) {
+				return ttft, errors.New("stream error: " + truncate(data, 200))
+			}
+			if ttft == 0 && isContentEvent(data) {
+				ttft = time.Since(start)
+			}
+		}
+		if err == io.EOF {
+			return ttft, nil
+		}
+		if err != nil {
+			return ttft, err
+		}
+	}
+}
+
+// isContentEvent 识别携带模型输出的事件：Anthropic content_block_delta、OpenAI *.delta、Gemini candidates
+func isContentEvent(data string) bool {
+	return strings.Contains(data, `"content_block_delta"`) ||
+		strings.Contains(data, `.delta"`) ||
+		strings.Contains(data, `"candidates"`)
+}
+
+func isStreamError(data string) bool {
+	return strings.HasPrefix(data, `{"type":"error"`) ||
+		strings.HasPrefix(data, `{"error"`) ||
+		strings.Contains(data, `"type":"response.failed"`)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,197 @@
+// Package logger 结构化日志：JSON/文本输出、运行时可调整的日志级别，以及随 context 传递的请求关联字段。
+//
+// Init 会替换 slog 默认 Logger，标准库 log.Printf 的输出也会经由同一 Handler 以 INFO 级别输出。
+// 网关请求链路上通过 With 把 client_request_id、api_key_id、user_id、account_id、platform、model
+// 等字段写入 context，之后使用 slog.*Context(ctx, ...) 记录的日志都会自动带上这些字段。
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
+)
+
+// 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options 日志初始化参数
+type Options struct {
+	// Level debug/info/warn/error
+	Level string
+	// Format text/json
+	Format string
+	// Output 默认 os.Stderr
+	Output io.Writer
+}
+
+var (
+	level  = new(slog.LevelVar)
+	format = FormatText
+)
+
+// Init 按配置安装默认 Logger
+func Init(opts Options) error {
+	lvl, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	f := strings.ToLower(strings.TrimSpace(opts.Format))
+	switch f {
+	case "", FormatText:
+		f = FormatText
+		h = slog.NewTextHandler(out, handlerOpts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		return fmt.Errorf("unknown log format %q", opts.Format)
+	}
+	level.Set(lvl)
+	format = f
+	slog.SetDefault(slog.New(&contextHandler{Handler: h}))
+	return nil
+}
+
+// ParseLevel 解析日志级别名称（warning 视为 warn）
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// SetLevel 运行时调整日志级别（仅影响当前进程）
+func SetLevel(s string) error {
+	lvl, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	return nil
+}
+
+// Level 返回当前日志级别名称（小写）
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// Format 返回当前输出格式
+func Format() string {
+	return format
+}
+
+type fieldsKey struct{}
+
+// With 返回附加了日志字段的 context；同名字段会被覆盖（如故障转移后更换的 account_id）
+func With(ctx context.Context, args ...any) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	added := argsToAttrs(args)
+	if len(added) == 0 {
+		return ctx
+	}
+	existing := Fields(ctx)
+	merged := make([]slog.Attr, 0, len(existing)+len(added))
+	for _, a := range existing {
+		if !hasKey(added, a.Key) {
+			merged = append(merged, a)
+		}
+	}
+	merged = append(merged, added...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// Fields 返回 context 中的日志字段
+func Fields(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// Detach 返回不随请求取消的新 context，保留日志字段与 trace，用于请求结束后的异步任务（如记录使用量）
+func Detach(ctx context.Context) context.Context {
+	detached := tracing.Detach(ctx)
+	if fields := Fields(ctx); len(fields) > 0 {
+		detached = context.WithValue(detached, fieldsKey{}, fields)
+	}
+	return detached
+}
+
+func argsToAttrs(args []any) []slog.Attr {
+	var attrs []slog.Attr
+	for len(args) > 0 {
+		switch v := args[0].(type) {
+		case slog.Attr:
+			attrs = append(attrs, v)
+			args = args[1:]
+		case string:
+			if len(args) < 2 {
+				attrs = append(attrs, slog.String("!BADKEY", v))
+				args = nil
+				continue
+			}
+			attrs = append(attrs, slog.Any(v, args[1]))
+			args = args[2:]
+		default:
+			attrs = append(attrs, slog.Any("!BADKEY", v))
+			args = args[1:]
+		}
+	}
+	return attrs
+}
+
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// contextHandler 在输出前追加 context 中的请求字段与 trace_id
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(Fields(ctx)...)
+		if id := tracing.TraceID(ctx); id != "" {
+			r.AddAttrs(slog.String("trace_id", id))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
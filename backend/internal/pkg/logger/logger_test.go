@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func initJSON(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	var buf bytes.Buffer
+	if err := Init(Options{Level: level, Format: FormatJSON, Output: &buf}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	return &buf
+}
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	return m
+}
+
+func TestWithOverridesSameKey(t *testing.T) {
+	ctx := With(context.Background(), "account_id", 1, "model", "claude")
+	ctx = With(ctx, "account_id", 2)
+
+	fields := Fields(ctx)
+	if len(fields) != 2 {
+		t.Fatalf("len(Fields) = %d, want 2", len(fields))
+	}
+	if fields[0].Key != "model" || fields[1].Key != "account_id" || fields[1].Value.Int64() != 2 {
+		t.Fatalf("Fields = %v, want model then account_id=2", fields)
+	}
+}
+
+func TestHandlerAddsContextFieldsAndTraceID(t *testing.T) {
+	buf := initJSON(t, "info")
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, span := tracing.StartServer(context.Background(), propagation.HeaderCarrier(header), "POST /v1/messages")
+	defer span.End()
+	ctx = With(ctx, "client_request_id", "req-1", "account_id", 7)
+
+	slog.WarnContext(ctx, "upstream_error", "status", 502)
+
+	m := decodeLine(t, buf)
+	if m["msg"] != "upstream_error" || m["client_request_id"] != "req-1" || m["account_id"] != float64(7) {
+		t.Fatalf("log line = %v, want context fields", m)
+	}
+	if m["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace_id = %v", m["trace_id"])
+	}
+}
+
+func TestDetachKeepsFields(t *testing.T) {
+	parent, cancel := context.WithCancel(With(context.Background(), "user_id", 3))
+	ctx := Detach(parent)
+	cancel()
+
+	if ctx.Err() != nil {
+		t.Fatalf("detached ctx canceled: %v", ctx.Err())
+	}
+	if fields := Fields(ctx); len(fields) != 1 || fields[0].Key != "user_id" {
+		t.Fatalf("Fields = %v, want user_id", fields)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	buf := initJSON(t, "warn")
+
+	slog.Info("hidden")
+	if buf.Len() != 0 {
+		t.Fatalf("info logged at warn level: %s", buf.String())
+	}
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	if Level() != "debug" {
+		t.Fatalf("Level() = %q, want debug", Level())
+	}
+	slog.Debug("shown")
+	if decodeLine(t, buf)["msg"] != "shown" {
+		t.Fatalf("debug not logged after SetLevel")
+	}
+	if err := SetLevel("verbose"); err == nil {
+		t.Fatalf("SetLevel(verbose) error = nil")
+	}
+}
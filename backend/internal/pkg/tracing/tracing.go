@@ -0,0 +1,121 @@
+// Package tracing 基于 OpenTelemetry 的分布式追踪：OTLP/HTTP 导出、按比例采样、W3C trace context 传播。
+//
+// 未启用时全局 TracerProvider 保持为 noop，Start 返回的 span 不产生任何开销；
+// 但仍会解析客户端传入的 traceparent，使 trace id 能写入错误日志与使用记录，便于与上游调用方关联。
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Wei-Shaw/sub2api"
+
+// Options 追踪初始化参数
+type Options struct {
+	Enabled        bool
+	ServiceName    string
+	ServiceVersion string
+	// Endpoint OTLP/HTTP 地址，如 http://otel-collector:4318（http 表示不使用 TLS）；为空时读取 OTEL_EXPORTER_OTLP_* 环境变量
+	Endpoint string
+	Headers  map[string]string
+	// SampleRatio 根 span 采样比例（0-1）；客户端传入的 traceparent 已采样时始终跟随
+	SampleRatio float64
+}
+
+func init() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Init 安装全局 TracerProvider，返回用于刷新并关闭导出器的函数；未启用时返回空操作
+func Init(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if !opts.Enabled {
+		return noop, nil
+	}
+
+	var exporterOpts []otlptracehttp.Option
+	if endpoint := strings.TrimSpace(opts.Endpoint); endpoint != "" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(endpoint))
+	}
+	if len(opts.Headers) > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(opts.Headers))
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return noop, fmt.Errorf("create otlp exporter: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("service.name", opts.ServiceName)}
+	if opts.ServiceVersion != "" {
+		attrs = append(attrs, attribute.String("service.version", opts.ServiceVersion))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		return noop, fmt.Errorf("build tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(5*time.Second)),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start 以 ctx 中的 span 为父创建内部 span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer 从请求头提取 W3C trace context 并创建服务端 span
+func StartServer(ctx context.Context, carrier propagation.TextMapCarrier, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// StartClient 创建出站调用 span（不向上游注入 trace 头，避免暴露内部链路信息）
+func StartClient(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 非空时记录错误并标记状态
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID 返回 ctx 中的 trace id（32 位十六进制），没有有效 trace 时返回空
+func TraceID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Detach 返回只携带 ctx 中 span 的新上下文，用于请求结束后仍需归属同一 trace 的异步任务（如记录使用量）
+func Detach(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
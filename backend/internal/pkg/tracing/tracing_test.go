@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestStartServerContinuesIncomingTraceWhenDisabled(t *testing.T) {
+	header := http.Header{}
+	header.Set("traceparent", testTraceparent)
+
+	ctx, span := StartServer(context.Background(), propagation.HeaderCarrier(header), "GET /v1/messages")
+	defer span.End()
+
+	if got := TraceID(ctx); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("TraceID() = %q, want incoming trace id", got)
+	}
+}
+
+func TestTraceIDEmptyWithoutTrace(t *testing.T) {
+	if got := TraceID(context.Background()); got != "" {
+		t.Fatalf("TraceID() = %q, want empty", got)
+	}
+	ctx, span := Start(context.Background(), "noop")
+	defer span.End()
+	if got := TraceID(ctx); got != "" {
+		t.Fatalf("TraceID() without provider or parent = %q, want empty", got)
+	}
+}
+
+func TestDetachKeepsTraceAfterCancel(t *testing.T) {
+	header := http.Header{}
+	header.Set("traceparent", testTraceparent)
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, span := StartServer(parent, propagation.HeaderCarrier(header), "POST /v1/messages")
+	span.End()
+	cancel()
+
+	detached := Detach(ctx)
+	if detached.Err() != nil {
+		t.Fatalf("Detach() inherited cancellation: %v", detached.Err())
+	}
+	if got := TraceID(detached); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("TraceID(Detach()) = %q, want incoming trace id", got)
+	}
+}
+
+func TestInitDisabledIsNoop(t *testing.T) {
+	shutdown, err := Init(context.Background(), Options{Enabled: false})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+}
@@ -172,6 +172,19 @@ type BatchAPIKeyUsageStats struct {
 	TotalActualCost float64 `json:"total_actual_cost"`
 }
 
+// AccountCacheStat represents prompt cache hit statistics for a single account
+type AccountCacheStat struct {
+	AccountID           int64   `json:"account_id"`
+	AccountName         string  `json:"account_name"`
+	Requests            int64   `json:"requests"`
+	CacheHitRequests    int64   `json:"cache_hit_requests"` // cache_read_tokens > 0 的请求数
+	InputTokens         int64   `json:"input_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	RequestHitRate      float64 `json:"request_hit_rate"` // cache_hit_requests / requests
+	TokenHitRate        float64 `json:"token_hit_rate"`   // cache_read / (input + cache_creation + cache_read)
+}
+
 // AccountUsageHistory represents daily usage history for an account
 type AccountUsageHistory struct {
 	Date       string  `json:"date"`
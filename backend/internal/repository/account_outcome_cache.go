@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 账号上游请求结果缓存
+//
+// 设计说明：
+// 每个账号使用一个 hash 按分钟桶记录成功/失败次数：
+// - Key: account_outcome:{accountID}
+// - Field: {unixMinute}:s / {unixMinute}:f
+// - Value: 次数
+//
+// key 按统计窗口的 3 倍过期，读取时只累加 since 之后的分钟桶。
+const (
+	accountOutcomeKeyPrefix = "account_outcome:"
+	accountOutcomeKeyTTL    = 30 * time.Minute
+)
+
+type accountOutcomeCache struct {
+	rdb *redis.Client
+}
+
+func NewAccountOutcomeCache(rdb *redis.Client) service.AccountOutcomeCache {
+	return &accountOutcomeCache{rdb: rdb}
+}
+
+func accountOutcomeKey(accountID int64) string {
+	return accountOutcomeKeyPrefix + strconv.FormatInt(accountID, 10)
+}
+
+func (c *accountOutcomeCache) RecordOutcome(ctx context.Context, accountID int64, success bool, now time.Time) error {
+	suffix := ":f"
+	if success {
+		suffix = ":s"
+	}
+	key := accountOutcomeKey(accountID)
+	pipe := c.rdb.Pipeline()
+	pipe.HIncrBy(ctx, key, strconv.FormatInt(now.Unix()/60, 10)+suffix, 1)
+	pipe.Expire(ctx, key, accountOutcomeKeyTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *accountOutcomeCache) GetOutcomes(ctx context.Context, accountIDs []int64, since time.Time) (map[int64]service.AccountOutcomeStats, error) {
+	result := make(map[int64]service.AccountOutcomeStats, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return result, nil
+	}
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(accountIDs))
+	for i, id := range accountIDs {
+		cmds[i] = pipe.HGetAll(ctx, accountOutcomeKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	sinceMinute := since.Unix() / 60
+	for i, id := range accountIDs {
+		fields, err := cmds[i].Result()
+		if err != nil {
+			continue
+		}
+		var stats service.AccountOutcomeStats
+		for field, value := range fields {
+			minuteStr, kind, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			minute, err := strconv.ParseInt(minuteStr, 10, 64)
+			if err != nil || minute < sinceMinute {
+				continue
+			}
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch kind {
+			case "s":
+				stats.Success += n
+			case "f":
+				stats.Failure += n
+			}
+		}
+		result[id] = stats
+	}
+	return result, nil
+}
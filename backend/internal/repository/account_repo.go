@@ -93,6 +93,9 @@ func (r *accountRepository) Create(ctx context.Context, account *service.Account
 	if account.ProxyID != nil {
 		builder.SetProxyID(*account.ProxyID)
 	}
+	if account.ProxyPoolID != nil {
+		builder.SetProxyPoolID(*account.ProxyPoolID)
+	}
 	if account.LastUsedAt != nil {
 		builder.SetLastUsedAt(*account.LastUsedAt)
 	}
@@ -310,6 +313,11 @@ func (r *accountRepository) Update(ctx context.Context, account *service.Account
 	} else {
 		builder.ClearProxyID()
 	}
+	if account.ProxyPoolID != nil {
+		builder.SetProxyPoolID(*account.ProxyPoolID)
+	} else {
+		builder.ClearProxyPoolID()
+	}
 	if account.LastUsedAt != nil {
 		builder.SetLastUsedAt(*account.LastUsedAt)
 	} else {
@@ -1115,6 +1123,16 @@ func (r *accountRepository) BulkUpdate(ctx context.Context, ids []int64, updates
 			idx++
 		}
 	}
+	if updates.ProxyPoolID != nil {
+		// 与 proxy_id 相同，0 表示清除代理池绑定
+		if *updates.ProxyPoolID == 0 {
+			setClauses = append(setClauses, "proxy_pool_id = NULL")
+		} else {
+			setClauses = append(setClauses, "proxy_pool_id = $"+itoa(idx))
+			args = append(args, *updates.ProxyPoolID)
+			idx++
+		}
+	}
 	if updates.Concurrency != nil {
 		setClauses = append(setClauses, "concurrency = $"+itoa(idx))
 		args = append(args, *updates.Concurrency)
@@ -1501,6 +1519,7 @@ func accountEntityToService(m *dbent.Account) *service.Account {
 		Credentials:         copyJSONMap(m.Credentials),
 		Extra:               copyJSONMap(m.Extra),
 		ProxyID:             m.ProxyID,
+		ProxyPoolID:         m.ProxyPoolID,
 		Concurrency:         m.Concurrency,
 		Priority:            m.Priority,
 		RateMultiplier:      &rateMultiplier,
@@ -138,6 +138,14 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 				group.FieldFallbackGroupID,
 				group.FieldModelRoutingEnabled,
 				group.FieldModelRouting,
+				group.FieldAccountSelectionStrategy,
+				group.FieldImageModels,
+				group.FieldContentPolicyAction,
+				group.FieldRequestRewriteRules,
+				group.FieldModelAliases,
+				group.FieldAllowedModels,
+				group.FieldDeniedModels,
+				group.FieldUsageLimits,
 			)
 		}).
 		Only(ctx)
@@ -409,28 +417,37 @@ func groupEntityToService(g *dbent.Group) *service.Group {
 		return nil
 	}
 	return &service.Group{
-		ID:                  g.ID,
-		Name:                g.Name,
-		Description:         derefString(g.Description),
-		Platform:            g.Platform,
-		RateMultiplier:      g.RateMultiplier,
-		IsExclusive:         g.IsExclusive,
-		Status:              g.Status,
-		Hydrated:            true,
-		SubscriptionType:    g.SubscriptionType,
-		DailyLimitUSD:       g.DailyLimitUsd,
-		WeeklyLimitUSD:      g.WeeklyLimitUsd,
-		MonthlyLimitUSD:     g.MonthlyLimitUsd,
-		ImagePrice1K:        g.ImagePrice1k,
-		ImagePrice2K:        g.ImagePrice2k,
-		ImagePrice4K:        g.ImagePrice4k,
-		DefaultValidityDays: g.DefaultValidityDays,
-		ClaudeCodeOnly:      g.ClaudeCodeOnly,
-		FallbackGroupID:     g.FallbackGroupID,
-		ModelRouting:        g.ModelRouting,
-		ModelRoutingEnabled: g.ModelRoutingEnabled,
-		CreatedAt:           g.CreatedAt,
-		UpdatedAt:           g.UpdatedAt,
+		ID:                       g.ID,
+		Name:                     g.Name,
+		Description:              derefString(g.Description),
+		Platform:                 g.Platform,
+		RateMultiplier:           g.RateMultiplier,
+		IsExclusive:              g.IsExclusive,
+		Status:                   g.Status,
+		Hydrated:                 true,
+		SubscriptionType:         g.SubscriptionType,
+		DailyLimitUSD:            g.DailyLimitUsd,
+		WeeklyLimitUSD:           g.WeeklyLimitUsd,
+		MonthlyLimitUSD:          g.MonthlyLimitUsd,
+		ImagePrice1K:             g.ImagePrice1k,
+		ImagePrice2K:             g.ImagePrice2k,
+		ImagePrice4K:             g.ImagePrice4k,
+		DefaultValidityDays:      g.DefaultValidityDays,
+		SubscriptionPrice:        g.SubscriptionPrice,
+		ClaudeCodeOnly:           g.ClaudeCodeOnly,
+		FallbackGroupID:          g.FallbackGroupID,
+		ModelRouting:             g.ModelRouting,
+		ModelRoutingEnabled:      g.ModelRoutingEnabled,
+		AccountSelectionStrategy: g.AccountSelectionStrategy,
+		ImageModels:              g.ImageModels,
+		ContentPolicyAction:      g.ContentPolicyAction,
+		RequestRewriteRules:      unmarshalRequestRewriteRules(g.RequestRewriteRules),
+		ModelAliases:             g.ModelAliases,
+		AllowedModels:            g.AllowedModels,
+		DeniedModels:             g.DeniedModels,
+		UsageLimits:              unmarshalSubscriptionUsageLimits(g.UsageLimits),
+		CreatedAt:                g.CreatedAt,
+		UpdatedAt:                g.UpdatedAt,
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/service"
@@ -13,9 +14,11 @@ import (
 )
 
 const (
-	billingBalanceKeyPrefix = "billing:balance:"
-	billingSubKeyPrefix     = "billing:sub:"
-	billingCacheTTL         = 5 * time.Minute
+	billingBalanceKeyPrefix     = "billing:balance:"
+	billingSubKeyPrefix         = "billing:sub:"
+	billingBalanceHoldKeyPrefix = "billing:hold:balance:"
+	billingSubHoldKeyPrefix     = "billing:hold:sub:"
+	billingCacheTTL             = 5 * time.Minute
 )
 
 // billingBalanceKey generates the Redis key for user balance cache.
@@ -28,6 +31,16 @@ func billingSubKey(userID, groupID int64) string {
 	return fmt.Sprintf("%s%d:%d", billingSubKeyPrefix, userID, groupID)
 }
 
+// billingBalanceHoldKey generates the Redis key for balance holds (hash: holdID -> "amount|expiresAt").
+func billingBalanceHoldKey(userID int64) string {
+	return fmt.Sprintf("%s%d", billingBalanceHoldKeyPrefix, userID)
+}
+
+// billingSubHoldKey generates the Redis key for subscription holds.
+func billingSubHoldKey(userID, groupID int64) string {
+	return fmt.Sprintf("%s%d:%d", billingSubHoldKeyPrefix, userID, groupID)
+}
+
 const (
 	subFieldStatus       = "status"
 	subFieldExpiresAt    = "expires_at"
@@ -37,6 +50,13 @@ const (
 	subFieldVersion      = "version"
 )
 
+// token/请求数限额计数字段前缀：<window>_counter:<限额名称>
+const (
+	subFieldDailyCounterPrefix   = "daily_counter:"
+	subFieldWeeklyCounterPrefix  = "weekly_counter:"
+	subFieldMonthlyCounterPrefix = "monthly_counter:"
+)
+
 var (
 	deductBalanceScript = redis.NewScript(`
 		local current = redis.call('GET', KEYS[1])
@@ -61,6 +81,97 @@ var (
 		redis.call('EXPIRE', KEYS[1], ARGV[2])
 		return 1
 	`)
+
+	// ARGV[1] 为 TTL，其后为 字段/增量 对
+	updateSubCountersScript = redis.NewScript(`
+		if redis.call('EXISTS', KEYS[1]) == 0 then
+			return 0
+		end
+		for i = 2, #ARGV, 2 do
+			redis.call('HINCRBYFLOAT', KEYS[1], ARGV[i], ARGV[i + 1])
+		end
+		redis.call('EXPIRE', KEYS[1], ARGV[1])
+		return 1
+	`)
+)
+
+// holdSumLua 汇总未过期冻结金额，同时清理已过期（崩溃/中断请求遗留）的冻结
+const holdSumLua = `
+	local function sum_holds(key, now)
+		local held = 0
+		local entries = redis.call('HGETALL', key)
+		for i = 1, #entries, 2 do
+			local amount, expires = string.match(entries[i + 1], '^([^|]+)|(%d+)$')
+			if amount == nil or tonumber(expires) <= now then
+				redis.call('HDEL', key, entries[i])
+			else
+				held = held + tonumber(amount)
+			end
+		end
+		return held
+	end
+`
+
+// 冻结脚本返回值与 service.BillingHoldStatus 一致：
+// 0 缓存不存在，1 冻结成功，2 余额不足，3/4/5 超出日/周/月限额
+var (
+	reserveBalanceHoldScript = redis.NewScript(holdSumLua + `
+		local balance = redis.call('GET', KEYS[1])
+		if balance == false then
+			return 0
+		end
+		local held = sum_holds(KEYS[2], tonumber(ARGV[3]))
+		local amount = tonumber(ARGV[2])
+		if tonumber(balance) - held < amount then
+			return 2
+		end
+		redis.call('HSET', KEYS[2], ARGV[1], ARGV[2] .. '|' .. ARGV[4])
+		redis.call('EXPIRE', KEYS[2], ARGV[5])
+		return 1
+	`)
+
+	settleBalanceHoldScript = redis.NewScript(`
+		redis.call('HDEL', KEYS[2], ARGV[1])
+		local cost = tonumber(ARGV[2])
+		if cost > 0 then
+			local current = redis.call('GET', KEYS[1])
+			if current ~= false then
+				redis.call('SET', KEYS[1], tonumber(current) - cost)
+				redis.call('EXPIRE', KEYS[1], ARGV[3])
+			end
+		end
+		return 1
+	`)
+
+	reserveSubHoldScript = redis.NewScript(holdSumLua + `
+		if redis.call('EXISTS', KEYS[1]) == 0 then
+			return 0
+		end
+		local held = sum_holds(KEYS[2], tonumber(ARGV[3]))
+		local amount = tonumber(ARGV[2])
+		local usage = redis.call('HMGET', KEYS[1], 'daily_usage', 'weekly_usage', 'monthly_usage')
+		for i = 1, 3 do
+			local limit = tonumber(ARGV[5 + i])
+			if limit > 0 and (tonumber(usage[i]) or 0) + held + amount > limit then
+				return 2 + i
+			end
+		end
+		redis.call('HSET', KEYS[2], ARGV[1], ARGV[2] .. '|' .. ARGV[4])
+		redis.call('EXPIRE', KEYS[2], ARGV[5])
+		return 1
+	`)
+
+	settleSubHoldScript = redis.NewScript(`
+		redis.call('HDEL', KEYS[2], ARGV[1])
+		local cost = tonumber(ARGV[2])
+		if cost > 0 and redis.call('EXISTS', KEYS[1]) == 1 then
+			redis.call('HINCRBYFLOAT', KEYS[1], 'daily_usage', cost)
+			redis.call('HINCRBYFLOAT', KEYS[1], 'weekly_usage', cost)
+			redis.call('HINCRBYFLOAT', KEYS[1], 'monthly_usage', cost)
+			redis.call('EXPIRE', KEYS[1], ARGV[3])
+		end
+		return 1
+	`)
 )
 
 type billingCache struct {
@@ -142,6 +253,21 @@ func (c *billingCache) parseSubscriptionCache(data map[string]string) (*service.
 		result.Version, _ = strconv.ParseInt(versionStr, 10, 64)
 	}
 
+	for field, value := range data {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(field, subFieldDailyCounterPrefix):
+			result.Counters.Daily = setCounter(result.Counters.Daily, strings.TrimPrefix(field, subFieldDailyCounterPrefix), v)
+		case strings.HasPrefix(field, subFieldWeeklyCounterPrefix):
+			result.Counters.Weekly = setCounter(result.Counters.Weekly, strings.TrimPrefix(field, subFieldWeeklyCounterPrefix), v)
+		case strings.HasPrefix(field, subFieldMonthlyCounterPrefix):
+			result.Counters.Monthly = setCounter(result.Counters.Monthly, strings.TrimPrefix(field, subFieldMonthlyCounterPrefix), v)
+		}
+	}
+
 	return result, nil
 }
 
@@ -160,6 +286,7 @@ func (c *billingCache) SetSubscriptionCache(ctx context.Context, userID, groupID
 		subFieldMonthlyUsage: data.MonthlyUsage,
 		subFieldVersion:      data.Version,
 	}
+	appendSubscriptionCounterFields(fields, data.Counters)
 
 	pipe := c.rdb.Pipeline()
 	pipe.HSet(ctx, key, fields)
@@ -177,7 +304,96 @@ func (c *billingCache) UpdateSubscriptionUsage(ctx context.Context, userID, grou
 	return nil
 }
 
+func (c *billingCache) UpdateSubscriptionCounters(ctx context.Context, userID, groupID int64, delta service.SubscriptionUsageCounters) error {
+	if delta.IsEmpty() {
+		return nil
+	}
+	fields := make(map[string]any)
+	appendSubscriptionCounterFields(fields, delta)
+	args := make([]any, 0, 1+2*len(fields))
+	args = append(args, int(billingCacheTTL.Seconds()))
+	for field, value := range fields {
+		args = append(args, field, value)
+	}
+	key := billingSubKey(userID, groupID)
+	_, err := updateSubCountersScript.Run(ctx, c.rdb, []string{key}, args...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Warning: update subscription counters cache failed for user %d group %d: %v", userID, groupID, err)
+	}
+	return nil
+}
+
+func appendSubscriptionCounterFields(fields map[string]any, counters service.SubscriptionUsageCounters) {
+	for name, v := range counters.Daily {
+		fields[subFieldDailyCounterPrefix+name] = v
+	}
+	for name, v := range counters.Weekly {
+		fields[subFieldWeeklyCounterPrefix+name] = v
+	}
+	for name, v := range counters.Monthly {
+		fields[subFieldMonthlyCounterPrefix+name] = v
+	}
+}
+
+func setCounter(m map[string]float64, name string, v float64) map[string]float64 {
+	if m == nil {
+		m = make(map[string]float64)
+	}
+	m[name] = v
+	return m
+}
+
 func (c *billingCache) InvalidateSubscriptionCache(ctx context.Context, userID, groupID int64) error {
 	key := billingSubKey(userID, groupID)
 	return c.rdb.Del(ctx, key).Err()
 }
+
+func (c *billingCache) ReserveBalanceHold(ctx context.Context, userID int64, holdID string, amount float64, expiresAt time.Time) (service.BillingHoldStatus, error) {
+	keys := []string{billingBalanceKey(userID), billingBalanceHoldKey(userID)}
+	status, err := reserveBalanceHoldScript.Run(ctx, c.rdb, keys,
+		holdID, amount, time.Now().Unix(), expiresAt.Unix(), holdKeyTTLSeconds(expiresAt),
+	).Int()
+	if err != nil {
+		return service.BillingHoldCacheMiss, err
+	}
+	return service.BillingHoldStatus(status), nil
+}
+
+func (c *billingCache) SettleBalanceHold(ctx context.Context, userID int64, holdID string, cost float64) error {
+	keys := []string{billingBalanceKey(userID), billingBalanceHoldKey(userID)}
+	return settleBalanceHoldScript.Run(ctx, c.rdb, keys, holdID, cost, int(billingCacheTTL.Seconds())).Err()
+}
+
+func (c *billingCache) ReserveSubscriptionHold(ctx context.Context, userID, groupID int64, holdID string, amount float64, expiresAt time.Time, limits service.SubscriptionHoldLimits) (service.BillingHoldStatus, error) {
+	keys := []string{billingSubKey(userID, groupID), billingSubHoldKey(userID, groupID)}
+	status, err := reserveSubHoldScript.Run(ctx, c.rdb, keys,
+		holdID, amount, time.Now().Unix(), expiresAt.Unix(), holdKeyTTLSeconds(expiresAt),
+		holdLimitArg(limits.DailyUSD), holdLimitArg(limits.WeeklyUSD), holdLimitArg(limits.MonthlyUSD),
+	).Int()
+	if err != nil {
+		return service.BillingHoldCacheMiss, err
+	}
+	return service.BillingHoldStatus(status), nil
+}
+
+func (c *billingCache) SettleSubscriptionHold(ctx context.Context, userID, groupID int64, holdID string, cost float64) error {
+	keys := []string{billingSubKey(userID, groupID), billingSubHoldKey(userID, groupID)}
+	return settleSubHoldScript.Run(ctx, c.rdb, keys, holdID, cost, int(billingCacheTTL.Seconds())).Err()
+}
+
+// holdKeyTTLSeconds 冻结 key 的过期时间覆盖最晚的冻结，所有请求结束后自动回收
+func holdKeyTTLSeconds(expiresAt time.Time) int {
+	ttl := int(time.Until(expiresAt).Seconds()) + 1
+	if ttl < 1 {
+		ttl = 1
+	}
+	return ttl
+}
+
+// holdLimitArg 限额参数，0 表示不限制
+func holdLimitArg(limit *float64) float64 {
+	if limit == nil || *limit <= 0 {
+		return 0
+	}
+	return *limit
+}
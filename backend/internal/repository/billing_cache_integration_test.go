@@ -212,6 +212,35 @@ func (s *BillingCacheSuite) TestSubscriptionCache() {
 				require.Equal(s.T(), 3.5, gotSub.MonthlyUsage)
 			},
 		},
+		{
+			name: "update_counters_increments_window_fields",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				userID := int64(16)
+				groupID := int64(26)
+
+				data := &service.SubscriptionCacheData{
+					Status:    "active",
+					ExpiresAt: time.Now().Add(1 * time.Hour),
+					Counters: service.SubscriptionUsageCounters{
+						Daily: map[string]float64{"opus": 10},
+					},
+					Version: 1,
+				}
+				require.NoError(s.T(), cache.SetSubscriptionCache(ctx, userID, groupID, data), "SetSubscriptionCache")
+
+				delta := service.SubscriptionUsageCounters{
+					Daily:   map[string]float64{"opus": 5},
+					Monthly: map[string]float64{"requests": 1},
+				}
+				require.NoError(s.T(), cache.UpdateSubscriptionCounters(ctx, userID, groupID, delta), "UpdateSubscriptionCounters")
+
+				gotSub, err := cache.GetSubscriptionCache(ctx, userID, groupID)
+				require.NoError(s.T(), err, "GetSubscriptionCache after update")
+				require.Equal(s.T(), 15.0, gotSub.Counters.Daily["opus"])
+				require.Equal(s.T(), 1.0, gotSub.Counters.Monthly["requests"])
+				require.Empty(s.T(), gotSub.Counters.Weekly)
+			},
+		},
 		{
 			name: "invalidate_removes_key",
 			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
@@ -278,6 +307,84 @@ func (s *BillingCacheSuite) TestSubscriptionCache() {
 	}
 }
 
+func (s *BillingCacheSuite) TestBalanceHold() {
+	rdb := testRedis(s.T())
+	cache := NewBillingCache(rdb)
+	ctx := context.Background()
+	userID := int64(71)
+	expiresAt := time.Now().Add(time.Minute)
+
+	status, err := cache.ReserveBalanceHold(ctx, userID, "h0", 1, expiresAt)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldCacheMiss, status, "missing balance cache must be reported")
+
+	require.NoError(s.T(), cache.SetUserBalance(ctx, userID, 10))
+
+	status, err = cache.ReserveBalanceHold(ctx, userID, "h1", 6, expiresAt)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldReserved, status)
+
+	status, err = cache.ReserveBalanceHold(ctx, userID, "h2", 6, expiresAt)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldInsufficientBalance, status, "in-flight holds must reduce available balance")
+
+	require.NoError(s.T(), cache.SettleBalanceHold(ctx, userID, "h1", 2.5))
+	balance, err := cache.GetUserBalance(ctx, userID)
+	require.NoError(s.T(), err)
+	require.InDelta(s.T(), 7.5, balance, 1e-9, "settle deducts the actual cost")
+
+	status, err = cache.ReserveBalanceHold(ctx, userID, "h3", 6, expiresAt)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldReserved, status, "settled hold must be released")
+
+	// 过期冻结在下一次预留时被回收
+	require.NoError(s.T(), rdb.HSet(ctx, billingBalanceHoldKey(userID), "h3", fmt.Sprintf("6|%d", time.Now().Add(-time.Second).Unix())).Err())
+	status, err = cache.ReserveBalanceHold(ctx, userID, "h4", 7, expiresAt)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldReserved, status, "expired hold must not count")
+	exists, err := rdb.HExists(ctx, billingBalanceHoldKey(userID), "h3").Result()
+	require.NoError(s.T(), err)
+	require.False(s.T(), exists, "expired hold must be removed")
+}
+
+func (s *BillingCacheSuite) TestSubscriptionHold() {
+	rdb := testRedis(s.T())
+	cache := NewBillingCache(rdb)
+	ctx := context.Background()
+	userID, groupID := int64(72), int64(8)
+	expiresAt := time.Now().Add(time.Minute)
+	daily, monthly := 5.0, 100.0
+	limits := service.SubscriptionHoldLimits{DailyUSD: &daily, MonthlyUSD: &monthly}
+
+	status, err := cache.ReserveSubscriptionHold(ctx, userID, groupID, "h0", 1, expiresAt, limits)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldCacheMiss, status)
+
+	require.NoError(s.T(), cache.SetSubscriptionCache(ctx, userID, groupID, &service.SubscriptionCacheData{
+		Status:     service.SubscriptionStatusActive,
+		ExpiresAt:  time.Now().Add(time.Hour),
+		DailyUsage: 2,
+	}))
+
+	status, err = cache.ReserveSubscriptionHold(ctx, userID, groupID, "h1", 2, expiresAt, limits)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldReserved, status)
+
+	status, err = cache.ReserveSubscriptionHold(ctx, userID, groupID, "h2", 2, expiresAt, limits)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldDailyLimitExceeded, status)
+
+	require.NoError(s.T(), cache.SettleSubscriptionHold(ctx, userID, groupID, "h1", 0.5))
+	data, err := cache.GetSubscriptionCache(ctx, userID, groupID)
+	require.NoError(s.T(), err)
+	require.InDelta(s.T(), 2.5, data.DailyUsage, 1e-9)
+	require.InDelta(s.T(), 0.5, data.MonthlyUsage, 1e-9)
+
+	status, err = cache.ReserveSubscriptionHold(ctx, userID, groupID, "h2", 2, expiresAt, limits)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.BillingHoldReserved, status)
+}
+
 func TestBillingCacheSuite(t *testing.T) {
 	suite.Run(t, new(BillingCacheSuite))
 }
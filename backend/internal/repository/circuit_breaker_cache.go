@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 账号熔断缓存
+//
+// 设计说明：
+// - 状态 Key: circuit_breaker:state:{accountID}（hash：state / opened_at / open_until / probe_until / reason / error_rate）
+// - 统计 Key: circuit_breaker:stats:{accountID}（hash：{bucket}:t / {bucket}:f / {bucket}:s，分别为总数、失败数、慢请求数）
+//
+// 状态转换全部在 Lua 脚本中完成，保证多实例并发时状态一致。
+// 关闭状态不保存状态 key；状态 key 最长保留 24h，作为异常情况下的自动恢复兜底。
+const (
+	circuitBreakerStateKeyPrefix = "circuit_breaker:state:"
+	circuitBreakerStatsKeyPrefix = "circuit_breaker:stats:"
+	circuitBreakerStateTTL       = 24 * time.Hour
+	// 滚动窗口划分的桶数
+	circuitBreakerBuckets = 10
+)
+
+var (
+	// recordCircuitResultScript 记录请求结果并推进状态机
+	// KEYS[1] = 状态 key, KEYS[2] = 统计 key
+	// ARGV: now_ms, failed, slow, window_ms, bucket_ms, min_requests, error_threshold, slow_threshold, open_ms, state_ttl_ms
+	// 返回 {state, transitioned}
+	recordCircuitResultScript = redis.NewScript(`
+		local now = tonumber(ARGV[1])
+		local failed = ARGV[2] == '1'
+		local slow = ARGV[3] == '1'
+		local windowMs = tonumber(ARGV[4])
+		local bucketMs = tonumber(ARGV[5])
+		local minRequests = tonumber(ARGV[6])
+		local errorThreshold = tonumber(ARGV[7])
+		local slowThreshold = tonumber(ARGV[8])
+		local openMs = tonumber(ARGV[9])
+		local stateTTL = tonumber(ARGV[10])
+
+		local state = redis.call('HGET', KEYS[1], 'state')
+		if state == 'half_open' then
+			if failed or slow then
+				redis.call('HSET', KEYS[1], 'state', 'open', 'opened_at', now, 'open_until', now + openMs, 'reason', 'probe_failed')
+				redis.call('HDEL', KEYS[1], 'probe_until')
+				redis.call('PEXPIRE', KEYS[1], stateTTL)
+				return {'open', 1}
+			end
+			redis.call('DEL', KEYS[1], KEYS[2])
+			return {'closed', 1}
+		end
+		if state == 'open' then
+			-- 熔断前发出的请求结果不再计入
+			return {'open', 0}
+		end
+
+		local bucket = math.floor(now / bucketMs)
+		redis.call('HINCRBY', KEYS[2], bucket .. ':t', 1)
+		if failed then
+			redis.call('HINCRBY', KEYS[2], bucket .. ':f', 1)
+		end
+		if slow then
+			redis.call('HINCRBY', KEYS[2], bucket .. ':s', 1)
+		end
+		redis.call('PEXPIRE', KEYS[2], windowMs * 2)
+
+		local minBucket = math.floor((now - windowMs) / bucketMs) + 1
+		local fields = redis.call('HGETALL', KEYS[2])
+		local total, failures, slows = 0, 0, 0
+		for i = 1, #fields, 2 do
+			local field = fields[i]
+			local sep = string.find(field, ':', 1, true)
+			local b = tonumber(string.sub(field, 1, sep - 1))
+			if b == nil or b < minBucket then
+				redis.call('HDEL', KEYS[2], field)
+			else
+				local kind = string.sub(field, sep + 1)
+				local n = tonumber(fields[i + 1]) or 0
+				if kind == 't' then
+					total = total + n
+				elseif kind == 'f' then
+					failures = failures + n
+				elseif kind == 's' then
+					slows = slows + n
+				end
+			end
+		end
+
+		if total >= minRequests then
+			local reason = nil
+			local errorRate = failures / total
+			if errorRate >= errorThreshold then
+				reason = 'error_rate'
+			elseif slowThreshold > 0 and slows / total >= slowThreshold then
+				reason = 'slow_call_rate'
+			end
+			if reason then
+				redis.call('HSET', KEYS[1], 'state', 'open', 'opened_at', now, 'open_until', now + openMs, 'reason', reason, 'error_rate', tostring(errorRate))
+				redis.call('PEXPIRE', KEYS[1], stateTTL)
+				redis.call('DEL', KEYS[2])
+				return {'open', 1}
+			end
+		end
+		return {'closed', 0}
+	`)
+
+	// acquireCircuitProbeScript 熔断到期后转入半开并占用探测名额
+	// KEYS[1] = 状态 key
+	// ARGV: now_ms, probe_ms
+	// 返回 1 表示放行，0 表示拒绝
+	acquireCircuitProbeScript = redis.NewScript(`
+		local now = tonumber(ARGV[1])
+		local probeMs = tonumber(ARGV[2])
+		local data = redis.call('HMGET', KEYS[1], 'state', 'open_until', 'probe_until')
+		local state = data[1]
+		if not state or state == 'closed' then
+			return 1
+		end
+		if state == 'open' then
+			if now < tonumber(data[2] or '0') then
+				return 0
+			end
+			redis.call('HSET', KEYS[1], 'state', 'half_open', 'probe_until', now + probeMs)
+			return 1
+		end
+		if data[3] and now < tonumber(data[3]) then
+			return 0
+		end
+		redis.call('HSET', KEYS[1], 'probe_until', now + probeMs)
+		return 1
+	`)
+
+	// releaseCircuitProbeScript 归还半开探测名额（仅当名额仍由本次占用）
+	// KEYS[1] = 状态 key
+	// ARGV: probe_until_ms
+	releaseCircuitProbeScript = redis.NewScript(`
+		local data = redis.call('HMGET', KEYS[1], 'state', 'probe_until')
+		if data[1] == 'half_open' and data[2] and tonumber(data[2]) == tonumber(ARGV[1]) then
+			redis.call('HDEL', KEYS[1], 'probe_until')
+			return 1
+		end
+		return 0
+	`)
+)
+
+type circuitBreakerCache struct {
+	rdb *redis.Client
+}
+
+func NewCircuitBreakerCache(rdb *redis.Client) service.CircuitBreakerCache {
+	return &circuitBreakerCache{rdb: rdb}
+}
+
+func circuitBreakerStateKey(accountID int64) string {
+	return circuitBreakerStateKeyPrefix + strconv.FormatInt(accountID, 10)
+}
+
+func circuitBreakerStatsKey(accountID int64) string {
+	return circuitBreakerStatsKeyPrefix + strconv.FormatInt(accountID, 10)
+}
+
+func boolArg(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+func (c *circuitBreakerCache) RecordResult(ctx context.Context, accountID int64, failed, slow bool, now time.Time, policy service.CircuitBreakerPolicy) (string, bool, error) {
+	bucketMs := policy.Window.Milliseconds() / circuitBreakerBuckets
+	if bucketMs < 1000 {
+		bucketMs = 1000
+	}
+	res, err := recordCircuitResultScript.Run(ctx, c.rdb,
+		[]string{circuitBreakerStateKey(accountID), circuitBreakerStatsKey(accountID)},
+		now.UnixMilli(),
+		boolArg(failed),
+		boolArg(slow),
+		policy.Window.Milliseconds(),
+		bucketMs,
+		policy.MinRequests,
+		policy.ErrorRateThreshold,
+		policy.SlowCallRateThreshold,
+		policy.OpenDuration.Milliseconds(),
+		circuitBreakerStateTTL.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return "", false, err
+	}
+	if len(res) != 2 {
+		return "", false, nil
+	}
+	state, _ := res[0].(string)
+	transitioned, _ := res[1].(int64)
+	return state, transitioned == 1, nil
+}
+
+func (c *circuitBreakerCache) AcquireProbe(ctx context.Context, accountID int64, now time.Time, policy service.CircuitBreakerPolicy) (bool, error) {
+	res, err := acquireCircuitProbeScript.Run(ctx, c.rdb,
+		[]string{circuitBreakerStateKey(accountID)},
+		now.UnixMilli(),
+		policy.ProbeTimeout.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (c *circuitBreakerCache) ReleaseProbe(ctx context.Context, accountID int64, probeUntil time.Time) error {
+	return releaseCircuitProbeScript.Run(ctx, c.rdb,
+		[]string{circuitBreakerStateKey(accountID)},
+		probeUntil.UnixMilli(),
+	).Err()
+}
+
+func (c *circuitBreakerCache) GetStates(ctx context.Context, accountIDs []int64) (map[int64]*service.CircuitBreakerState, error) {
+	result := make(map[int64]*service.CircuitBreakerState)
+	if len(accountIDs) == 0 {
+		return result, nil
+	}
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(accountIDs))
+	for i, id := range accountIDs {
+		cmds[i] = pipe.HGetAll(ctx, circuitBreakerStateKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for i, id := range accountIDs {
+		fields, err := cmds[i].Result()
+		if err != nil || fields["state"] == "" || fields["state"] == service.CircuitStateClosed {
+			continue
+		}
+		state := &service.CircuitBreakerState{
+			State:      fields["state"],
+			Reason:     fields["reason"],
+			OpenedAt:   parseUnixMilli(fields["opened_at"]),
+			OpenUntil:  parseUnixMilli(fields["open_until"]),
+			ProbeUntil: parseUnixMilli(fields["probe_until"]),
+		}
+		if v, err := strconv.ParseFloat(fields["error_rate"], 64); err == nil {
+			state.ErrorRate = v
+		}
+		result[id] = state
+	}
+	return result, nil
+}
+
+func (c *circuitBreakerCache) Reset(ctx context.Context, accountID int64) error {
+	return c.rdb.Del(ctx, circuitBreakerStateKey(accountID), circuitBreakerStatsKey(accountID)).Err()
+}
+
+func parseUnixMilli(v string) *time.Time {
+	if v == "" {
+		return nil
+	}
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil
+	}
+	t := time.UnixMilli(ms)
+	return &t
+}
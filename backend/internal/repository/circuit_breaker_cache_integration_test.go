@@ -0,0 +1,135 @@
+//go:build integration
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CircuitBreakerCacheSuite struct {
+	IntegrationRedisSuite
+	cache  service.CircuitBreakerCache
+	policy service.CircuitBreakerPolicy
+}
+
+func (s *CircuitBreakerCacheSuite) SetupTest() {
+	s.IntegrationRedisSuite.SetupTest()
+	s.cache = NewCircuitBreakerCache(s.rdb)
+	s.policy = service.CircuitBreakerPolicy{
+		Window:             time.Minute,
+		MinRequests:        4,
+		ErrorRateThreshold: 0.5,
+		OpenDuration:       30 * time.Second,
+		ProbeTimeout:       time.Minute,
+	}
+}
+
+func (s *CircuitBreakerCacheSuite) TestOpensOnErrorRate() {
+	now := time.Now()
+	accountID := int64(1)
+
+	for _, failed := range []bool{false, true, false} {
+		state, transitioned, err := s.cache.RecordResult(s.ctx, accountID, failed, false, now, s.policy)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), service.CircuitStateClosed, state)
+		require.False(s.T(), transitioned)
+	}
+
+	state, transitioned, err := s.cache.RecordResult(s.ctx, accountID, true, false, now, s.policy)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.CircuitStateOpen, state)
+	require.True(s.T(), transitioned)
+
+	states, err := s.cache.GetStates(s.ctx, []int64{accountID, 2})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), states, 1)
+	require.Equal(s.T(), "error_rate", states[accountID].Reason)
+	require.InDelta(s.T(), 0.5, states[accountID].ErrorRate, 1e-9)
+}
+
+func (s *CircuitBreakerCacheSuite) TestOldBucketsExpire() {
+	accountID := int64(3)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, _, err := s.cache.RecordResult(s.ctx, accountID, true, false, start, s.policy)
+		require.NoError(s.T(), err)
+	}
+	// 窗口外的失败不再计入
+	state, _, err := s.cache.RecordResult(s.ctx, accountID, true, false, start.Add(2*time.Minute), s.policy)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.CircuitStateClosed, state)
+}
+
+func (s *CircuitBreakerCacheSuite) TestHalfOpenProbe() {
+	now := time.Now()
+	accountID := int64(4)
+	for i := 0; i < 4; i++ {
+		_, _, err := s.cache.RecordResult(s.ctx, accountID, true, false, now, s.policy)
+		require.NoError(s.T(), err)
+	}
+
+	ok, err := s.cache.AcquireProbe(s.ctx, accountID, now, s.policy)
+	require.NoError(s.T(), err)
+	require.False(s.T(), ok, "open circuit must reject before open_until")
+
+	later := now.Add(s.policy.OpenDuration)
+	ok, err = s.cache.AcquireProbe(s.ctx, accountID, later, s.policy)
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+	ok, err = s.cache.AcquireProbe(s.ctx, accountID, later, s.policy)
+	require.NoError(s.T(), err)
+	require.False(s.T(), ok, "only one probe at a time")
+
+	// 归还名额后可再次探测；过期的占用记录不影响当前名额
+	require.NoError(s.T(), s.cache.ReleaseProbe(s.ctx, accountID, later.Add(time.Hour)))
+	ok, err = s.cache.AcquireProbe(s.ctx, accountID, later, s.policy)
+	require.NoError(s.T(), err)
+	require.False(s.T(), ok, "release with a stale probe_until is a no-op")
+	require.NoError(s.T(), s.cache.ReleaseProbe(s.ctx, accountID, later.Add(s.policy.ProbeTimeout)))
+	ok, err = s.cache.AcquireProbe(s.ctx, accountID, later, s.policy)
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+
+	// 探测失败：重新熔断
+	state, transitioned, err := s.cache.RecordResult(s.ctx, accountID, true, false, later, s.policy)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.CircuitStateOpen, state)
+	require.True(s.T(), transitioned)
+
+	// 再次探测成功：恢复
+	again := later.Add(s.policy.OpenDuration)
+	ok, err = s.cache.AcquireProbe(s.ctx, accountID, again, s.policy)
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+	state, transitioned, err = s.cache.RecordResult(s.ctx, accountID, false, false, again, s.policy)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), service.CircuitStateClosed, state)
+	require.True(s.T(), transitioned)
+
+	states, err := s.cache.GetStates(s.ctx, []int64{accountID})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), states)
+}
+
+func (s *CircuitBreakerCacheSuite) TestReset() {
+	now := time.Now()
+	accountID := int64(5)
+	for i := 0; i < 4; i++ {
+		_, _, err := s.cache.RecordResult(s.ctx, accountID, true, false, now, s.policy)
+		require.NoError(s.T(), err)
+	}
+	require.NoError(s.T(), s.cache.Reset(s.ctx, accountID))
+
+	ok, err := s.cache.AcquireProbe(s.ctx, accountID, now, s.policy)
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+}
+
+func TestCircuitBreakerCacheSuite(t *testing.T) {
+	suite.Run(t, new(CircuitBreakerCacheSuite))
+}
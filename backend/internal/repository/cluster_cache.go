@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 集群节点缓存
+//
+// 设计说明：
+// - 节点 Key: cluster:nodes（hash：{nodeID} -> 节点心跳 JSON），离线判断与清理由服务层按 last_heartbeat 完成
+// - 命令 Key: cluster:commands:{nodeID}（list，RPUSH/LPOP），过期时间为单节点滚动超时
+// - 滚动 Key: cluster:rollout（最近一次滚动进度 JSON），cluster:rollout:lock（滚动互斥锁，值为发起进程 ID）
+const (
+	clusterNodesKey            = "cluster:nodes"
+	clusterCommandKeyPrefix    = "cluster:commands:"
+	clusterRolloutKey          = "cluster:rollout"
+	clusterRolloutLockKey      = "cluster:rollout:lock"
+	clusterRolloutRetentionTTL = 7 * 24 * time.Hour
+)
+
+var clusterReleaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type clusterCache struct {
+	rdb *redis.Client
+}
+
+func NewClusterCache(rdb *redis.Client) service.ClusterCache {
+	return &clusterCache{rdb: rdb}
+}
+
+func (c *clusterCache) SaveNode(ctx context.Context, node *service.ClusterNode) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return c.rdb.HSet(ctx, clusterNodesKey, node.NodeID, data).Err()
+}
+
+func (c *clusterCache) ListNodes(ctx context.Context) ([]*service.ClusterNode, error) {
+	values, err := c.rdb.HGetAll(ctx, clusterNodesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*service.ClusterNode, 0, len(values))
+	for _, raw := range values {
+		var node service.ClusterNode
+		if err := json.Unmarshal([]byte(raw), &node); err != nil {
+			continue
+		}
+		nodes = append(nodes, &node)
+	}
+	return nodes, nil
+}
+
+func (c *clusterCache) DeleteNode(ctx context.Context, nodeID string) error {
+	return c.rdb.HDel(ctx, clusterNodesKey, nodeID).Err()
+}
+
+func (c *clusterCache) GetLockHolders(ctx context.Context, keys []string) (map[string]string, error) {
+	holders := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return holders, nil
+	}
+	values, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if s, ok := v.(string); ok && s != "" {
+			holders[keys[i]] = s
+		}
+	}
+	return holders, nil
+}
+
+func (c *clusterCache) PushCommand(ctx context.Context, nodeID string, cmd *service.ClusterCommand, ttl time.Duration) error {
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	key := clusterCommandKeyPrefix + nodeID
+	pipe := c.rdb.TxPipeline()
+	pipe.RPush(ctx, key, data)
+	pipe.Expire(ctx, key, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (c *clusterCache) PopCommand(ctx context.Context, nodeID string) (*service.ClusterCommand, error) {
+	raw, err := c.rdb.LPop(ctx, clusterCommandKeyPrefix+nodeID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cmd service.ClusterCommand
+	if err := json.Unmarshal([]byte(raw), &cmd); err != nil {
+		return nil, err
+	}
+	return &cmd, nil
+}
+
+func (c *clusterCache) AcquireRolloutLock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(ctx, clusterRolloutLockKey, owner, ttl).Result()
+}
+
+func (c *clusterCache) ReleaseRolloutLock(ctx context.Context, owner string) error {
+	return clusterReleaseLockScript.Run(ctx, c.rdb, []string{clusterRolloutLockKey}, owner).Err()
+}
+
+func (c *clusterCache) SaveRollout(ctx context.Context, rollout *service.ClusterRollout) error {
+	data, err := json.Marshal(rollout)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, clusterRolloutKey, data, clusterRolloutRetentionTTL).Err()
+}
+
+func (c *clusterCache) GetRollout(ctx context.Context) (*service.ClusterRollout, error) {
+	raw, err := c.rdb.Get(ctx, clusterRolloutKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rollout service.ClusterRollout
+	if err := json.Unmarshal([]byte(raw), &rollout); err != nil {
+		return nil, err
+	}
+	return &rollout, nil
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type contentPolicyRepository struct {
+	sql sqlExecutor
+}
+
+func NewContentPolicyRepository(db *sql.DB) service.ContentPolicyRepository {
+	return &contentPolicyRepository{sql: db}
+}
+
+const contentPolicyRuleSelect = `
+	SELECT id, name, match_type, pattern, description, enabled, created_at, updated_at
+	FROM content_policy_rules
+`
+
+const contentPolicyHitSelect = `
+	SELECT id, user_id, api_key_id, group_id, rule_id, rule_name, source, action,
+		platform, model, excerpt, categories, reviewed_at, reviewed_by, created_at
+	FROM content_policy_hits
+`
+
+func (r *contentPolicyRepository) CreateRule(ctx context.Context, rule *service.ContentPolicyRule) error {
+	if rule == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO content_policy_rules (name, match_type, pattern, description, enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`, []any{rule.Name, rule.MatchType, rule.Pattern, rule.Description, rule.Enabled},
+		&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+func (r *contentPolicyRepository) GetRule(ctx context.Context, id int64) (*service.ContentPolicyRule, error) {
+	rule := &service.ContentPolicyRule{}
+	err := scanSingleRow(ctx, r.sql, contentPolicyRuleSelect+" WHERE id = $1", []any{id},
+		&rule.ID, &rule.Name, &rule.MatchType, &rule.Pattern, &rule.Description, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrContentPolicyRuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *contentPolicyRepository) UpdateRule(ctx context.Context, rule *service.ContentPolicyRule) error {
+	if rule == nil {
+		return nil
+	}
+	err := scanSingleRow(ctx, r.sql, `
+		UPDATE content_policy_rules
+		SET name = $2, match_type = $3, pattern = $4, description = $5, enabled = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, []any{rule.ID, rule.Name, rule.MatchType, rule.Pattern, rule.Description, rule.Enabled}, &rule.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.ErrContentPolicyRuleNotFound
+	}
+	return err
+}
+
+func (r *contentPolicyRepository) DeleteRule(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx, "DELETE FROM content_policy_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrContentPolicyRuleNotFound
+	}
+	return nil
+}
+
+func (r *contentPolicyRepository) ListRules(ctx context.Context) ([]service.ContentPolicyRule, error) {
+	rows, err := r.sql.QueryContext(ctx, contentPolicyRuleSelect+" ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	rules := make([]service.ContentPolicyRule, 0)
+	for rows.Next() {
+		var rule service.ContentPolicyRule
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.MatchType, &rule.Pattern, &rule.Description, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func (r *contentPolicyRepository) CreateHit(ctx context.Context, hit *service.ContentPolicyHit) error {
+	if hit == nil {
+		return nil
+	}
+	var categories any
+	if len(hit.Categories) > 0 {
+		raw, err := json.Marshal(hit.Categories)
+		if err != nil {
+			return err
+		}
+		categories = string(raw)
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO content_policy_hits
+			(user_id, api_key_id, group_id, rule_id, rule_name, source, action, platform, model, excerpt, categories)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11::jsonb)
+		RETURNING id, created_at
+	`, []any{
+		hit.UserID, hit.APIKeyID, hit.GroupID, hit.RuleID, hit.RuleName, hit.Source, hit.Action,
+		hit.Platform, hit.Model, hit.Excerpt, categories,
+	}, &hit.ID, &hit.CreatedAt)
+}
+
+func (r *contentPolicyRepository) ListHits(ctx context.Context, params pagination.PaginationParams, filter service.ContentPolicyHitFilter) ([]service.ContentPolicyHit, *pagination.PaginationResult, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conds = append(conds, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.GroupID != nil {
+		args = append(args, *filter.GroupID)
+		conds = append(conds, fmt.Sprintf("group_id = $%d", len(args)))
+	}
+	if filter.Reviewed != nil {
+		if *filter.Reviewed {
+			conds = append(conds, "reviewed_at IS NOT NULL")
+		} else {
+			conds = append(conds, "reviewed_at IS NULL")
+		}
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM content_policy_hits"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+
+	query := fmt.Sprintf("%s%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		contentPolicyHitSelect, where, len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	hits := make([]service.ContentPolicyHit, 0)
+	for rows.Next() {
+		hit, err := scanContentPolicyHit(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		hits = append(hits, *hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return hits, paginationResultFromTotal(total, params), nil
+}
+
+func (r *contentPolicyRepository) MarkHitReviewed(ctx context.Context, id int64, reviewerID int64) error {
+	res, err := r.sql.ExecContext(ctx,
+		"UPDATE content_policy_hits SET reviewed_at = NOW(), reviewed_by = $2 WHERE id = $1", id, reviewerID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrContentPolicyHitNotFound
+	}
+	return nil
+}
+
+func (r *contentPolicyRepository) CountViolationsSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	var count int64
+	if err := scanSingleRow(ctx, r.sql,
+		"SELECT COUNT(*) FROM content_policy_hits WHERE user_id = $1 AND created_at >= $2 AND action <> $3",
+		[]any{userID, since, service.ContentPolicyActionLog}, &count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func scanContentPolicyHit(rows *sql.Rows) (*service.ContentPolicyHit, error) {
+	var (
+		hit        service.ContentPolicyHit
+		groupID    sql.NullInt64
+		ruleID     sql.NullInt64
+		categories []byte
+		reviewedAt sql.NullTime
+		reviewedBy sql.NullInt64
+	)
+	if err := rows.Scan(
+		&hit.ID, &hit.UserID, &hit.APIKeyID, &groupID, &ruleID, &hit.RuleName, &hit.Source, &hit.Action,
+		&hit.Platform, &hit.Model, &hit.Excerpt, &categories, &reviewedAt, &reviewedBy, &hit.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if groupID.Valid {
+		hit.GroupID = &groupID.Int64
+	}
+	if ruleID.Valid {
+		hit.RuleID = &ruleID.Int64
+	}
+	if len(categories) > 0 {
+		_ = json.Unmarshal(categories, &hit.Categories)
+	}
+	if reviewedAt.Valid {
+		hit.ReviewedAt = &reviewedAt.Time
+	}
+	if reviewedBy.Valid {
+		hit.ReviewedBy = &reviewedBy.Int64
+	}
+	return &hit, nil
+}
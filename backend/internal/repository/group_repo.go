@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"log"
 
@@ -47,10 +48,40 @@ func (r *groupRepository) Create(ctx context.Context, groupIn *service.Group) er
 		SetNillableImagePrice1k(groupIn.ImagePrice1K).
 		SetNillableImagePrice2k(groupIn.ImagePrice2K).
 		SetNillableImagePrice4k(groupIn.ImagePrice4K).
+		SetNillableSubscriptionPrice(groupIn.SubscriptionPrice).
 		SetDefaultValidityDays(groupIn.DefaultValidityDays).
 		SetClaudeCodeOnly(groupIn.ClaudeCodeOnly).
 		SetNillableFallbackGroupID(groupIn.FallbackGroupID).
-		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled)
+		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled).
+		SetAccountSelectionStrategy(groupIn.AccountSelectionStrategy).
+		SetContentPolicyAction(groupIn.ContentPolicyAction)
+
+	if len(groupIn.ImageModels) > 0 {
+		builder = builder.SetImageModels(groupIn.ImageModels)
+	}
+	if len(groupIn.RequestRewriteRules) > 0 {
+		raw, err := marshalRequestRewriteRules(groupIn.RequestRewriteRules)
+		if err != nil {
+			return err
+		}
+		builder = builder.SetRequestRewriteRules(raw)
+	}
+	if len(groupIn.ModelAliases) > 0 {
+		builder = builder.SetModelAliases(groupIn.ModelAliases)
+	}
+	if len(groupIn.AllowedModels) > 0 {
+		builder = builder.SetAllowedModels(groupIn.AllowedModels)
+	}
+	if len(groupIn.DeniedModels) > 0 {
+		builder = builder.SetDeniedModels(groupIn.DeniedModels)
+	}
+	if len(groupIn.UsageLimits) > 0 {
+		raw, err := json.Marshal(groupIn.UsageLimits)
+		if err != nil {
+			return err
+		}
+		builder = builder.SetUsageLimits(raw)
+	}
 
 	// 设置模型路由配置
 	if groupIn.ModelRouting != nil {
@@ -108,7 +139,9 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		SetNillableImagePrice4k(groupIn.ImagePrice4K).
 		SetDefaultValidityDays(groupIn.DefaultValidityDays).
 		SetClaudeCodeOnly(groupIn.ClaudeCodeOnly).
-		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled)
+		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled).
+		SetAccountSelectionStrategy(groupIn.AccountSelectionStrategy).
+		SetContentPolicyAction(groupIn.ContentPolicyAction)
 
 	// 处理 FallbackGroupID：nil 时清除，否则设置
 	if groupIn.FallbackGroupID != nil {
@@ -117,6 +150,59 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		builder = builder.ClearFallbackGroupID()
 	}
 
+	// 处理 SubscriptionPrice：nil 时清除（不支持自助续期/变更）
+	if groupIn.SubscriptionPrice != nil {
+		builder = builder.SetSubscriptionPrice(*groupIn.SubscriptionPrice)
+	} else {
+		builder = builder.ClearSubscriptionPrice()
+	}
+
+	// 处理 ImageModels：为空时清除（不限制）
+	if len(groupIn.ImageModels) > 0 {
+		builder = builder.SetImageModels(groupIn.ImageModels)
+	} else {
+		builder = builder.ClearImageModels()
+	}
+
+	// 处理 RequestRewriteRules：为空时清除
+	if len(groupIn.RequestRewriteRules) > 0 {
+		raw, err := marshalRequestRewriteRules(groupIn.RequestRewriteRules)
+		if err != nil {
+			return err
+		}
+		builder = builder.SetRequestRewriteRules(raw)
+	} else {
+		builder = builder.ClearRequestRewriteRules()
+	}
+
+	// 处理模型别名与访问策略：为空时清除
+	if len(groupIn.ModelAliases) > 0 {
+		builder = builder.SetModelAliases(groupIn.ModelAliases)
+	} else {
+		builder = builder.ClearModelAliases()
+	}
+	if len(groupIn.AllowedModels) > 0 {
+		builder = builder.SetAllowedModels(groupIn.AllowedModels)
+	} else {
+		builder = builder.ClearAllowedModels()
+	}
+	if len(groupIn.DeniedModels) > 0 {
+		builder = builder.SetDeniedModels(groupIn.DeniedModels)
+	} else {
+		builder = builder.ClearDeniedModels()
+	}
+
+	// 处理 token/请求数限额：为空时清除
+	if len(groupIn.UsageLimits) > 0 {
+		raw, err := json.Marshal(groupIn.UsageLimits)
+		if err != nil {
+			return err
+		}
+		builder = builder.SetUsageLimits(raw)
+	} else {
+		builder = builder.ClearUsageLimits()
+	}
+
 	// 处理 ModelRouting：nil 时清除，否则设置
 	if groupIn.ModelRouting != nil {
 		builder = builder.SetModelRouting(groupIn.ModelRouting)
@@ -425,3 +511,34 @@ func (r *groupRepository) loadAccountCounts(ctx context.Context, groupIDs []int6
 
 	return counts, nil
 }
+
+// marshalRequestRewriteRules 请求改写规则以 JSON 数组存储
+func marshalRequestRewriteRules(rules []service.RequestRewriteRule) (json.RawMessage, error) {
+	return json.Marshal(rules)
+}
+
+// unmarshalSubscriptionUsageLimits 解析失败时视为无限额，避免脏数据阻断鉴权
+func unmarshalSubscriptionUsageLimits(raw json.RawMessage) []service.SubscriptionUsageLimit {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var limits []service.SubscriptionUsageLimit
+	if err := json.Unmarshal(raw, &limits); err != nil {
+		log.Printf("[GroupRepo] invalid usage_limits: %v", err)
+		return nil
+	}
+	return limits
+}
+
+// unmarshalRequestRewriteRules 解析失败时视为无规则，避免脏数据阻断鉴权
+func unmarshalRequestRewriteRules(raw json.RawMessage) []service.RequestRewriteRule {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var rules []service.RequestRewriteRule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		log.Printf("[GroupRepo] invalid request_rewrite_rules: %v", err)
+		return nil
+	}
+	return rules
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// doTraced 执行上游请求并记录 client span（DNS、建连、TLS 握手、首字节作为事件）。
+// span 在收到响应头或请求失败时结束，流式响应体的读取耗时由 stream.relay span 记录；
+// 不向上游注入 traceparent。
+func doTraced(client *http.Client, req *http.Request, accountID int64, proxyURL string, tlsFingerprint bool) (*http.Response, error) {
+	host := ""
+	if req.URL != nil {
+		host = req.URL.Host
+	}
+	ctx, span := tracing.StartClient(req.Context(), "upstream.http",
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", host),
+		attribute.Int64("sub2api.account_id", accountID),
+		attribute.Bool("sub2api.proxied", proxyURL != ""),
+		attribute.Bool("sub2api.tls_fingerprint", tlsFingerprint),
+	)
+	if !span.IsRecording() {
+		span.End()
+		return client.Do(req)
+	}
+
+	req = req.WithContext(httptrace.WithClientTrace(ctx, upstreamClientTrace(span)))
+	resp, err := client.Do(req)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	span.End()
+	return resp, nil
+}
+
+func upstreamClientTrace(span trace.Span) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			span.AddEvent("got_conn", trace.WithAttributes(attribute.Bool("reused", info.Reused)))
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			span.AddEvent("dns_done", trace.WithAttributes(attribute.Bool("error", info.Err != nil)))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			span.AddEvent("connect_done", trace.WithAttributes(attribute.Bool("error", err != nil)))
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			span.AddEvent("tls_handshake_done", trace.WithAttributes(attribute.Bool("error", err != nil)))
+		},
+		GotFirstResponseByte: func() {
+			span.AddEvent("first_response_byte")
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type messageBatchRepository struct {
+	sql sqlExecutor
+}
+
+func NewMessageBatchRepository(db *sql.DB) service.MessageBatchRepository {
+	return &messageBatchRepository{sql: db}
+}
+
+const messageBatchColumns = `
+	id, batch_id, api_key_id, user_id, group_id, account_id, processing_status,
+	payload, results_billed_at, created_at, updated_at
+`
+
+func (r *messageBatchRepository) Create(ctx context.Context, batch *service.MessageBatch) error {
+	if batch == nil {
+		return nil
+	}
+	payload := batch.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage("{}")
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO message_batches (batch_id, api_key_id, user_id, group_id, account_id, processing_status, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`, []any{batch.BatchID, batch.APIKeyID, batch.UserID, batch.GroupID, batch.AccountID, batch.ProcessingStatus, []byte(payload)},
+		&batch.ID, &batch.CreatedAt, &batch.UpdatedAt)
+}
+
+func (r *messageBatchRepository) GetByBatchID(ctx context.Context, apiKeyID int64, batchID string) (*service.MessageBatch, error) {
+	rows, err := r.sql.QueryContext(ctx,
+		"SELECT "+messageBatchColumns+" FROM message_batches WHERE batch_id = $1 AND api_key_id = $2",
+		batchID, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrMessageBatchNotFound
+	}
+	batch, err := scanMessageBatch(rows)
+	if err != nil {
+		return nil, err
+	}
+	return batch, rows.Err()
+}
+
+func (r *messageBatchRepository) UpdatePayload(ctx context.Context, id int64, status string, payload json.RawMessage) error {
+	if len(payload) == 0 {
+		payload = json.RawMessage("{}")
+	}
+	_, err := r.sql.ExecContext(ctx,
+		"UPDATE message_batches SET processing_status = $2, payload = $3, updated_at = NOW() WHERE id = $1",
+		id, status, []byte(payload))
+	return err
+}
+
+func (r *messageBatchRepository) ListByAPIKey(ctx context.Context, apiKeyID int64, params service.MessageBatchListParams) ([]service.MessageBatch, bool, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = service.MessageBatchDefaultListLimit
+	}
+
+	// 游标为批次 ID，先解析为本地自增 ID；列表按 id 倒序（最新在前）
+	query := "SELECT " + messageBatchColumns + " FROM message_batches WHERE api_key_id = $1"
+	args := []any{apiKeyID}
+	reverse := false
+	switch {
+	case params.AfterID != "":
+		query += " AND id < (SELECT id FROM message_batches WHERE batch_id = $2 AND api_key_id = $1) ORDER BY id DESC"
+		args = append(args, params.AfterID)
+	case params.BeforeID != "":
+		query += " AND id > (SELECT id FROM message_batches WHERE batch_id = $2 AND api_key_id = $1) ORDER BY id ASC"
+		args = append(args, params.BeforeID)
+		reverse = true
+	default:
+		query += " ORDER BY id DESC"
+	}
+	args = append(args, limit+1)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	batches := make([]service.MessageBatch, 0, limit)
+	for rows.Next() {
+		batch, err := scanMessageBatch(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		batches = append(batches, *batch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(batches) > limit
+	if hasMore {
+		batches = batches[:limit]
+	}
+	if reverse {
+		for i, j := 0, len(batches)-1; i < j; i, j = i+1, j-1 {
+			batches[i], batches[j] = batches[j], batches[i]
+		}
+	}
+	return batches, hasMore, nil
+}
+
+func (r *messageBatchRepository) MarkResultsBilled(ctx context.Context, id int64) error {
+	_, err := r.sql.ExecContext(ctx,
+		"UPDATE message_batches SET results_billed_at = COALESCE(results_billed_at, NOW()), updated_at = NOW() WHERE id = $1",
+		id)
+	return err
+}
+
+func scanMessageBatch(rows *sql.Rows) (*service.MessageBatch, error) {
+	var (
+		batch    service.MessageBatch
+		groupID  sql.NullInt64
+		payload  []byte
+		billedAt sql.NullTime
+	)
+	if err := rows.Scan(
+		&batch.ID,
+		&batch.BatchID,
+		&batch.APIKeyID,
+		&batch.UserID,
+		&groupID,
+		&batch.AccountID,
+		&batch.ProcessingStatus,
+		&payload,
+		&billedAt,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service.ErrMessageBatchNotFound
+		}
+		return nil, err
+	}
+	if groupID.Valid {
+		v := groupID.Int64
+		batch.GroupID = &v
+	}
+	if billedAt.Valid {
+		t := billedAt.Time
+		batch.ResultsBilledAt = &t
+	}
+	batch.Payload = json.RawMessage(payload)
+	return &batch, nil
+}
@@ -31,6 +31,7 @@ func (r *opsRepository) InsertErrorLog(ctx context.Context, input *service.OpsIn
 INSERT INTO ops_error_logs (
   request_id,
   client_request_id,
+  trace_id,
   user_id,
   api_key_id,
   account_id,
@@ -64,7 +65,7 @@ INSERT INTO ops_error_logs (
   retry_count,
   created_at
 ) VALUES (
-  $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32,$33,$34
+  $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32,$33,$34,$35
 ) RETURNING id`
 
 	var id int64
@@ -73,6 +74,7 @@ INSERT INTO ops_error_logs (
 		q,
 		opsNullString(input.RequestID),
 		opsNullString(input.ClientRequestID),
+		opsNullString(input.TraceID),
 		opsNullInt64(input.UserID),
 		opsNullInt64(input.APIKeyID),
 		opsNullInt64(input.AccountID),
@@ -163,6 +165,7 @@ SELECT
   e.resolved_retry_id,
   COALESCE(e.client_request_id, ''),
   COALESCE(e.request_id, ''),
+  COALESCE(e.trace_id, ''),
   COALESCE(e.error_message, ''),
   e.user_id,
   COALESCE(u.email, ''),
@@ -225,6 +228,7 @@ LIMIT $` + itoa(len(args)+1) + ` OFFSET $` + itoa(len(args)+2)
 			&resolvedRetryID,
 			&item.ClientRequestID,
 			&item.RequestID,
+			&item.TraceID,
 			&item.Message,
 			&userID,
 			&userEmail,
@@ -318,6 +322,7 @@ SELECT
   e.resolved_retry_id,
   COALESCE(e.client_request_id, ''),
   COALESCE(e.request_id, ''),
+  COALESCE(e.trace_id, ''),
   COALESCE(e.error_message, ''),
   COALESCE(e.error_body, ''),
   e.upstream_status_code,
@@ -389,6 +394,7 @@ LIMIT 1`
 		&resolvedRetryID,
 		&out.ClientRequestID,
 		&out.RequestID,
+		&out.TraceID,
 		&out.Message,
 		&out.ErrorBody,
 		&upstreamStatusCode,
@@ -1027,6 +1033,10 @@ func buildOpsErrorLogsWhere(filter *service.OpsErrorLogFilter) (string, []any) {
 		args = append(args, crid)
 		clauses = append(clauses, "COALESCE(client_request_id,'') = $"+itoa(len(args)))
 	}
+	if tid := strings.TrimSpace(filter.TraceID); tid != "" {
+		args = append(args, tid)
+		clauses = append(clauses, "trace_id = $"+itoa(len(args)))
+	}
 
 	if q := strings.TrimSpace(filter.Query); q != "" {
 		like := "%" + q + "%"
@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// Label columns exposed by opsAlertExprRequestsCTE (all TEXT, empty when absent).
+var opsAlertExprLabelColumns = map[string]string{
+	"platform":   "platform",
+	"group_id":   "group_id",
+	"account_id": "account_id",
+	"model":      "model",
+	"proxy_id":   "proxy_id",
+}
+
+// Value columns exposed by opsAlertExprRequestsCTE.
+var opsAlertExprValueColumns = map[string]string{
+	"duration_ms": "duration_ms",
+	"ttft_ms":     "ttft_ms",
+	"tokens":      "tokens",
+	"cost_usd":    "cost_usd",
+}
+
+// Value columns of ops_system_metrics (overall snapshots only).
+var opsAlertExprSystemColumns = map[string]string{
+	"cpu_usage_percent":       "cpu_usage_percent",
+	"memory_usage_percent":    "memory_usage_percent",
+	"concurrency_queue_depth": "concurrency_queue_depth",
+	"goroutine_count":         "goroutine_count",
+	"db_conn_waiting":         "db_conn_waiting",
+}
+
+// Success rows from usage_logs and SLA-scope error rows from ops_error_logs,
+// aligned with the dashboard error-rate definition. $1/$2 are the time window.
+const opsAlertExprRequestsCTE = `
+WITH src AS (
+  SELECT
+    ul.created_at AS created_at,
+    COALESCE(NULLIF(g.platform, ''), NULLIF(a.platform, ''), '') AS platform,
+    COALESCE(ul.group_id::TEXT, '') AS group_id,
+    COALESCE(ul.account_id::TEXT, '') AS account_id,
+    COALESCE(ul.model, '') AS model,
+    COALESCE(a.proxy_id::TEXT, '') AS proxy_id,
+    ul.duration_ms::DOUBLE PRECISION AS duration_ms,
+    ul.first_token_ms::DOUBLE PRECISION AS ttft_ms,
+    (ul.input_tokens + ul.output_tokens + ul.cache_creation_tokens + ul.cache_read_tokens)::DOUBLE PRECISION AS tokens,
+    ul.actual_cost::DOUBLE PRECISION AS cost_usd,
+    0 AS is_error
+  FROM usage_logs ul
+  LEFT JOIN groups g ON g.id = ul.group_id
+  LEFT JOIN accounts a ON a.id = ul.account_id
+  WHERE ul.created_at >= $1 AND ul.created_at < $2
+
+  UNION ALL
+
+  SELECT
+    o.created_at AS created_at,
+    COALESCE(NULLIF(o.platform, ''), NULLIF(g.platform, ''), NULLIF(a.platform, ''), '') AS platform,
+    COALESCE(o.group_id::TEXT, '') AS group_id,
+    COALESCE(o.account_id::TEXT, '') AS account_id,
+    COALESCE(o.model, '') AS model,
+    COALESCE(a.proxy_id::TEXT, '') AS proxy_id,
+    o.duration_ms::DOUBLE PRECISION AS duration_ms,
+    o.time_to_first_token_ms::DOUBLE PRECISION AS ttft_ms,
+    NULL::DOUBLE PRECISION AS tokens,
+    NULL::DOUBLE PRECISION AS cost_usd,
+    1 AS is_error
+  FROM ops_error_logs o
+  LEFT JOIN groups g ON g.id = o.group_id
+  LEFT JOIN accounts a ON a.id = o.account_id
+  WHERE o.created_at >= $1 AND o.created_at < $2
+    AND COALESCE(o.status_code, 0) >= 400
+    AND NOT o.is_business_limited
+)
+`
+
+// QueryAlertExpressionSeries evaluates expr over [start, end) in buckets of step
+// (step <= 0 means a single bucket) and returns one point per (bucket, label set).
+func (r *opsRepository) QueryAlertExpressionSeries(ctx context.Context, expr *service.OpsAlertExpression, start, end time.Time, step time.Duration) ([]*service.OpsAlertSeriesPoint, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("nil ops repository")
+	}
+	if expr == nil {
+		return nil, fmt.Errorf("nil expression")
+	}
+	start = start.UTC()
+	end = end.UTC()
+	if !end.After(start) {
+		return nil, fmt.Errorf("invalid time range")
+	}
+	if step <= 0 || step > end.Sub(start) {
+		step = end.Sub(start)
+	}
+	stepSeconds := step.Seconds()
+
+	var (
+		q    string
+		args []any
+		err  error
+	)
+	switch expr.Source() {
+	case service.OpsAlertExprSourceRequests:
+		q, args, err = buildOpsAlertExprRequestsQuery(expr, start, end, stepSeconds)
+	case service.OpsAlertExprSourceSystem:
+		q, args, err = buildOpsAlertExprSystemQuery(expr, start, end, stepSeconds)
+	default:
+		err = fmt.Errorf("unsupported metric %q", expr.Metric)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []*service.OpsAlertSeriesPoint{}
+	for rows.Next() {
+		var bucket int64
+		var value float64
+		labelValues := make([]string, len(expr.GroupBy))
+		dest := make([]any, 0, 2+len(labelValues))
+		dest = append(dest, &bucket)
+		for i := range labelValues {
+			dest = append(dest, &labelValues[i])
+		}
+		dest = append(dest, &value)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		point := &service.OpsAlertSeriesPoint{
+			BucketStart: start.Add(time.Duration(bucket) * step),
+			Value:       value,
+		}
+		if len(expr.GroupBy) > 0 {
+			point.Labels = make(map[string]string, len(expr.GroupBy))
+			for i, label := range expr.GroupBy {
+				point.Labels[label] = labelValues[i]
+			}
+		}
+		out = append(out, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func buildOpsAlertExprRequestsQuery(expr *service.OpsAlertExpression, start, end time.Time, stepSeconds float64) (string, []any, error) {
+	args := []any{start, end, stepSeconds}
+	conditions := []string{}
+
+	for _, m := range expr.Matchers {
+		col, ok := opsAlertExprLabelColumns[m.Label]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported label %q", m.Label)
+		}
+		var op string
+		switch m.Op {
+		case service.OpsAlertMatchEqual:
+			op = "="
+		case service.OpsAlertMatchNotEqual:
+			op = "<>"
+		case service.OpsAlertMatchRegex:
+			op = "~"
+		case service.OpsAlertMatchNotRegex:
+			op = "!~"
+		default:
+			return "", nil, fmt.Errorf("unsupported matcher %q", m.Op)
+		}
+		args = append(args, m.Value)
+		conditions = append(conditions, fmt.Sprintf("%s %s $%d", col, op, len(args)))
+	}
+
+	var valueSQL string
+	switch expr.Metric {
+	case "requests", "errors":
+		countSQL := "COUNT(*)"
+		if expr.Metric == "errors" {
+			countSQL = "COUNT(*) FILTER (WHERE is_error = 1)"
+		}
+		valueSQL = countSQL + "::DOUBLE PRECISION"
+		if expr.Aggregation == service.OpsAlertAggRate {
+			valueSQL = countSQL + "::DOUBLE PRECISION / $3::DOUBLE PRECISION"
+		}
+	case "error_rate":
+		valueSQL = "100.0 * AVG(is_error)::DOUBLE PRECISION"
+	default:
+		col, ok := opsAlertExprValueColumns[expr.Metric]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported metric %q", expr.Metric)
+		}
+		aggSQL, err := opsAlertExprAggregateSQL(expr.Aggregation, col)
+		if err != nil {
+			return "", nil, err
+		}
+		valueSQL = aggSQL
+		conditions = append(conditions, col+" IS NOT NULL")
+	}
+
+	groupCols := make([]string, 0, len(expr.GroupBy))
+	for _, label := range expr.GroupBy {
+		col, ok := opsAlertExprLabelColumns[label]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported label %q", label)
+		}
+		groupCols = append(groupCols, col)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	selectCols := append([]string{"bucket"}, groupCols...)
+
+	q := opsAlertExprRequestsCTE + `
+SELECT ` + strings.Join(selectCols, ", ") + `, COALESCE(` + valueSQL + `, 0)::DOUBLE PRECISION AS value
+FROM (
+  SELECT *, FLOOR(EXTRACT(EPOCH FROM (created_at - $1))::DOUBLE PRECISION / $3::DOUBLE PRECISION)::BIGINT AS bucket
+  FROM src
+) s
+` + where + `
+GROUP BY ` + strings.Join(selectCols, ", ") + `
+ORDER BY bucket`
+	return q, args, nil
+}
+
+func buildOpsAlertExprSystemQuery(expr *service.OpsAlertExpression, start, end time.Time, stepSeconds float64) (string, []any, error) {
+	col, ok := opsAlertExprSystemColumns[expr.Metric]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported metric %q", expr.Metric)
+	}
+	aggSQL, err := opsAlertExprAggregateSQL(expr.Aggregation, col)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q := `
+SELECT
+  FLOOR(EXTRACT(EPOCH FROM (created_at - $1))::DOUBLE PRECISION / $3::DOUBLE PRECISION)::BIGINT AS bucket,
+  ` + aggSQL + `::DOUBLE PRECISION AS value
+FROM ops_system_metrics
+WHERE created_at >= $1 AND created_at < $2
+  AND window_minutes = 1
+  AND platform IS NULL
+  AND group_id IS NULL
+  AND ` + col + ` IS NOT NULL
+GROUP BY bucket
+ORDER BY bucket`
+	return q, []any{start, end, stepSeconds}, nil
+}
+
+func opsAlertExprAggregateSQL(aggregation, col string) (string, error) {
+	switch aggregation {
+	case service.OpsAlertAggSum:
+		return "SUM(" + col + ")", nil
+	case service.OpsAlertAggAvg:
+		return "AVG(" + col + ")", nil
+	case service.OpsAlertAggMin:
+		return "MIN(" + col + ")", nil
+	case service.OpsAlertAggMax:
+		return "MAX(" + col + ")", nil
+	case service.OpsAlertAggP50:
+		return "percentile_cont(0.50) WITHIN GROUP (ORDER BY " + col + ")", nil
+	case service.OpsAlertAggP90:
+		return "percentile_cont(0.90) WITHIN GROUP (ORDER BY " + col + ")", nil
+	case service.OpsAlertAggP95:
+		return "percentile_cont(0.95) WITHIN GROUP (ORDER BY " + col + ")", nil
+	case service.OpsAlertAggP99:
+		return "percentile_cont(0.99) WITHIN GROUP (ORDER BY " + col + ")", nil
+	default:
+		return "", fmt.Errorf("unsupported aggregation %q", aggregation)
+	}
+}
+
+// ListActiveAlertEvents returns all firing events of a rule (one per series for expression rules).
+func (r *opsRepository) ListActiveAlertEvents(ctx context.Context, ruleID int64) ([]*service.OpsAlertEvent, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("nil ops repository")
+	}
+	if ruleID <= 0 {
+		return nil, fmt.Errorf("invalid rule id")
+	}
+
+	q := `
+SELECT
+  id,
+  COALESCE(rule_id, 0),
+  COALESCE(severity, ''),
+  COALESCE(status, ''),
+  COALESCE(title, ''),
+  COALESCE(description, ''),
+  metric_value,
+  threshold_value,
+  dimensions,
+  fired_at,
+  resolved_at,
+  email_sent,
+  created_at
+FROM ops_alert_events
+WHERE rule_id = $1 AND status = $2
+ORDER BY fired_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, q, ruleID, service.OpsAlertStatusFiring)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []*service.OpsAlertEvent{}
+	for rows.Next() {
+		ev, err := scanOpsAlertEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetLatestAlertEventForSeries returns the most recent event of one series of a rule.
+func (r *opsRepository) GetLatestAlertEventForSeries(ctx context.Context, ruleID int64, seriesKey string) (*service.OpsAlertEvent, error) {
+	if r == nil || r.db == nil {
+		return nil, fmt.Errorf("nil ops repository")
+	}
+	if ruleID <= 0 {
+		return nil, fmt.Errorf("invalid rule id")
+	}
+
+	q := `
+SELECT
+  id,
+  COALESCE(rule_id, 0),
+  COALESCE(severity, ''),
+  COALESCE(status, ''),
+  COALESCE(title, ''),
+  COALESCE(description, ''),
+  metric_value,
+  threshold_value,
+  dimensions,
+  fired_at,
+  resolved_at,
+  email_sent,
+  created_at
+FROM ops_alert_events
+WHERE rule_id = $1
+  AND COALESCE(dimensions->>'series_key', '') = $2
+ORDER BY fired_at DESC
+LIMIT 1`
+
+	ev, err := scanOpsAlertEvent(r.db.QueryRowContext(ctx, q, ruleID, seriesKey))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ev, nil
+}
@@ -31,6 +31,7 @@ SELECT
   cooldown_minutes,
   COALESCE(notify_email, true),
   filters,
+  COALESCE(expression, ''),
   last_triggered_at,
   created_at,
   updated_at
@@ -62,6 +63,7 @@ ORDER BY id DESC`
 			&rule.CooldownMinutes,
 			&rule.NotifyEmail,
 			&filtersRaw,
+			&rule.Expression,
 			&lastTriggeredAt,
 			&rule.CreatedAt,
 			&rule.UpdatedAt,
@@ -113,10 +115,11 @@ INSERT INTO ops_alert_rules (
   cooldown_minutes,
   notify_email,
   filters,
+  expression,
   created_at,
   updated_at
 ) VALUES (
-  $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,NOW(),NOW()
+  $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,NOW(),NOW()
 )
 RETURNING
   id,
@@ -132,6 +135,7 @@ RETURNING
   cooldown_minutes,
   COALESCE(notify_email, true),
   filters,
+  COALESCE(expression, ''),
   last_triggered_at,
   created_at,
   updated_at`
@@ -155,6 +159,7 @@ RETURNING
 		input.CooldownMinutes,
 		input.NotifyEmail,
 		filtersArg,
+		opsNullString(input.Expression),
 	).Scan(
 		&out.ID,
 		&out.Name,
@@ -169,6 +174,7 @@ RETURNING
 		&out.CooldownMinutes,
 		&out.NotifyEmail,
 		&filtersRaw,
+		&out.Expression,
 		&lastTriggeredAt,
 		&out.CreatedAt,
 		&out.UpdatedAt,
@@ -220,6 +226,7 @@ SET
   cooldown_minutes = $11,
   notify_email = $12,
   filters = $13,
+  expression = $14,
   updated_at = NOW()
 WHERE id = $1
 RETURNING
@@ -236,6 +243,7 @@ RETURNING
   cooldown_minutes,
   COALESCE(notify_email, true),
   filters,
+  COALESCE(expression, ''),
   last_triggered_at,
   created_at,
   updated_at`
@@ -260,6 +268,7 @@ RETURNING
 		input.CooldownMinutes,
 		input.NotifyEmail,
 		filtersArg,
+		opsNullString(input.Expression),
 	).Scan(
 		&out.ID,
 		&out.Name,
@@ -274,6 +283,7 @@ RETURNING
 		&out.CooldownMinutes,
 		&out.NotifyEmail,
 		&filtersRaw,
+		&out.Expression,
 		&lastTriggeredAt,
 		&out.CreatedAt,
 		&out.UpdatedAt,
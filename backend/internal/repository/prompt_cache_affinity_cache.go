@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// Prompt 缓存亲和缓存
+//
+// 设计说明：
+// 每个 prompt 前缀使用一个有序集合记录持有该前缀热缓存的账号：
+// - Key: prompt_cache:prefix:{prefixHash}
+// - Member: accountID
+// - Score: 缓存过期时间（Unix 毫秒）
+//
+// 写入时清理已过期成员，key 本身按最长 TTL（1h）过期。
+const (
+	promptCachePrefixKeyPrefix = "prompt_cache:prefix:"
+	promptCacheKeyTTL          = time.Hour
+)
+
+type promptCacheAffinityCache struct {
+	rdb *redis.Client
+}
+
+func NewPromptCacheAffinityCache(rdb *redis.Client) service.PromptCacheAffinityCache {
+	return &promptCacheAffinityCache{rdb: rdb}
+}
+
+func promptCachePrefixKey(prefixHash string) string {
+	return promptCachePrefixKeyPrefix + prefixHash
+}
+
+func (c *promptCacheAffinityCache) MarkWarm(ctx context.Context, accountID int64, prefixes []service.PromptCachePrefix, now time.Time) error {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	member := strconv.FormatInt(accountID, 10)
+	pipe := c.rdb.Pipeline()
+	for _, p := range prefixes {
+		key := promptCachePrefixKey(p.Hash)
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.Add(p.TTL).UnixMilli()), Member: member})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+		// 同一前缀可能被不同 TTL 写入，key 统一按最长 TTL 过期，成员有效期以 score 为准
+		pipe.Expire(ctx, key, promptCacheKeyTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *promptCacheAffinityCache) GetWarmAccounts(ctx context.Context, prefixHashes []string, now time.Time) (map[string][]int64, error) {
+	if len(prefixHashes) == 0 {
+		return map[string][]int64{}, nil
+	}
+	minScore := "(" + strconv.FormatInt(now.UnixMilli(), 10)
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(prefixHashes))
+	for i, hash := range prefixHashes {
+		cmds[i] = pipe.ZRangeByScore(ctx, promptCachePrefixKey(hash), &redis.ZRangeBy{Min: minScore, Max: "+inf"})
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	result := make(map[string][]int64, len(prefixHashes))
+	for i, hash := range prefixHashes {
+		members, err := cmds[i].Result()
+		if err != nil {
+			continue
+		}
+		for _, m := range members {
+			if id, err := strconv.ParseInt(m, 10, 64); err == nil {
+				result[hash] = append(result[hash], id)
+			}
+		}
+	}
+	return result, nil
+}
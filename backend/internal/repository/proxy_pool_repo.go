@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+type proxyPoolRepository struct {
+	sql sqlExecutor
+}
+
+func NewProxyPoolRepository(db *sql.DB) service.ProxyPoolRepository {
+	return &proxyPoolRepository{sql: db}
+}
+
+// 成员按 position 顺序返回；已软删除的代理不计入成员
+const proxyPoolSelect = `
+	SELECT p.id, p.name, p.description, p.status, p.created_at, p.updated_at,
+		COALESCE(
+			(SELECT array_agg(m.proxy_id ORDER BY m.position, m.proxy_id)
+			 FROM proxy_pool_members m
+			 JOIN proxies x ON x.id = m.proxy_id AND x.deleted_at IS NULL
+			 WHERE m.pool_id = p.id),
+			'{}'
+		) AS proxy_ids
+	FROM proxy_pools p
+`
+
+func (r *proxyPoolRepository) Create(ctx context.Context, pool *service.ProxyPool) error {
+	if pool == nil {
+		return nil
+	}
+	return r.withTx(ctx, func(txRepo *proxyPoolRepository) error {
+		if err := scanSingleRow(ctx, txRepo.sql, `
+			INSERT INTO proxy_pools (name, description, status)
+			VALUES ($1, $2, $3)
+			RETURNING id, created_at, updated_at
+		`, []any{pool.Name, pool.Description, pool.Status}, &pool.ID, &pool.CreatedAt, &pool.UpdatedAt); err != nil {
+			return err
+		}
+		return txRepo.replaceMembers(ctx, pool.ID, pool.ProxyIDs)
+	})
+}
+
+func (r *proxyPoolRepository) GetByID(ctx context.Context, id int64) (*service.ProxyPool, error) {
+	rows, err := r.sql.QueryContext(ctx, proxyPoolSelect+" WHERE p.id = $1 AND p.deleted_at IS NULL", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrProxyPoolNotFound
+	}
+	pool, err := scanProxyPool(rows)
+	if err != nil {
+		return nil, err
+	}
+	return pool, rows.Err()
+}
+
+func (r *proxyPoolRepository) Update(ctx context.Context, pool *service.ProxyPool) error {
+	if pool == nil {
+		return nil
+	}
+	return r.withTx(ctx, func(txRepo *proxyPoolRepository) error {
+		err := scanSingleRow(ctx, txRepo.sql, `
+			UPDATE proxy_pools
+			SET name = $2, description = $3, status = $4, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING updated_at
+		`, []any{pool.ID, pool.Name, pool.Description, pool.Status}, &pool.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return service.ErrProxyPoolNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return txRepo.replaceMembers(ctx, pool.ID, pool.ProxyIDs)
+	})
+}
+
+func (r *proxyPoolRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.sql.ExecContext(ctx,
+		"UPDATE proxy_pools SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrProxyPoolNotFound
+	}
+	return nil
+}
+
+func (r *proxyPoolRepository) List(ctx context.Context) ([]service.ProxyPool, error) {
+	rows, err := r.sql.QueryContext(ctx, proxyPoolSelect+" WHERE p.deleted_at IS NULL ORDER BY p.id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	pools := make([]service.ProxyPool, 0)
+	for rows.Next() {
+		pool, err := scanProxyPool(rows)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, *pool)
+	}
+	return pools, rows.Err()
+}
+
+func (r *proxyPoolRepository) CountAccountsByPoolID(ctx context.Context, poolID int64) (int64, error) {
+	var count int64
+	if err := scanSingleRow(ctx, r.sql,
+		"SELECT COUNT(*) FROM accounts WHERE proxy_pool_id = $1 AND deleted_at IS NULL",
+		[]any{poolID}, &count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// withTx 代理池字段与成员需在同一事务内写入
+func (r *proxyPoolRepository) withTx(ctx context.Context, fn func(txRepo *proxyPoolRepository) error) error {
+	db, ok := r.sql.(*sql.DB)
+	if !ok {
+		return fn(r)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(&proxyPoolRepository{sql: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *proxyPoolRepository) replaceMembers(ctx context.Context, poolID int64, proxyIDs []int64) error {
+	if _, err := r.sql.ExecContext(ctx, "DELETE FROM proxy_pool_members WHERE pool_id = $1", poolID); err != nil {
+		return err
+	}
+	for i, proxyID := range proxyIDs {
+		if _, err := r.sql.ExecContext(ctx, `
+			INSERT INTO proxy_pool_members (pool_id, proxy_id, position)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, poolID, proxyID, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanProxyPool(rows *sql.Rows) (*service.ProxyPool, error) {
+	var (
+		pool     service.ProxyPool
+		proxyIDs pq.Int64Array
+	)
+	if err := rows.Scan(
+		&pool.ID,
+		&pool.Name,
+		&pool.Description,
+		&pool.Status,
+		&pool.CreatedAt,
+		&pool.UpdatedAt,
+		&proxyIDs,
+	); err != nil {
+		return nil, err
+	}
+	pool.ProxyIDs = []int64(proxyIDs)
+	return &pool, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type recoveryCodeRepository struct {
+	sql sqlExecutor
+}
+
+func NewRecoveryCodeRepository(db *sql.DB) service.RecoveryCodeRepository {
+	return &recoveryCodeRepository{sql: db}
+}
+
+func (r *recoveryCodeRepository) ReplaceAll(ctx context.Context, userID int64, codeHashes []string) error {
+	// 删除旧码与写入新码需在同一事务内完成，避免出现用户短暂没有可用恢复码的窗口
+	if db, ok := r.sql.(*sql.DB); ok {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		txRepo := &recoveryCodeRepository{sql: tx}
+		if err := txRepo.replaceAllInTx(ctx, userID, codeHashes); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		return tx.Commit()
+	}
+	return r.replaceAllInTx(ctx, userID, codeHashes)
+}
+
+func (r *recoveryCodeRepository) replaceAllInTx(ctx context.Context, userID int64, codeHashes []string) error {
+	if _, err := r.sql.ExecContext(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := r.sql.ExecContext(ctx,
+			"INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)",
+			userID, hash,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID int64, codeHash string, at time.Time) (bool, error) {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE user_recovery_codes SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash, at)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *recoveryCodeRepository) CountRemaining(ctx context.Context, userID int64) (int, error) {
+	var count int
+	if err := scanSingleRow(ctx, r.sql,
+		"SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL",
+		[]any{userID}, &count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *recoveryCodeRepository) DeleteAll(ctx context.Context, userID int64) error {
+	_, err := r.sql.ExecContext(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userID)
+	return err
+}
@@ -0,0 +1,397 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// 基线期单个 Key 最多读取的去重 IP / User-Agent 数
+const spendAnomalyFingerprintLimit = 5000
+
+type spendAnomalyRepository struct {
+	sql sqlExecutor
+}
+
+func NewSpendAnomalyRepository(db *sql.DB) service.SpendAnomalyRepository {
+	return &spendAnomalyRepository{sql: db}
+}
+
+const spendAnomalyFlagSelect = `
+	SELECT id, user_id, api_key_id, kind, action, status, current_value, baseline_value,
+		window_start, window_end, details, action_state, review_note, reviewed_at, reviewed_by, created_at
+	FROM spend_anomaly_flags
+`
+
+func (r *spendAnomalyRepository) ListAPIKeySpendStats(ctx context.Context, windowStart, windowEnd, baselineStart time.Time, minCurrent float64) ([]service.SpendWindowStat, error) {
+	// 先用时间索引筛出当前窗口的候选 Key，再按 api_key_id 统计基线期消费，避免每轮扫描整个基线期
+	return r.listSpendStats(ctx, `
+		WITH cur AS (
+			SELECT api_key_id, MAX(user_id) AS user_id, SUM(actual_cost) AS cost
+			FROM usage_logs
+			WHERE created_at >= $1 AND created_at < $2
+			GROUP BY api_key_id
+			HAVING SUM(actual_cost) >= $4
+		)
+		SELECT cur.user_id, cur.api_key_id, cur.cost,
+			COALESCE(SUM(ul.actual_cost), 0), MIN(ul.created_at)
+		FROM cur
+		LEFT JOIN usage_logs ul
+			ON ul.api_key_id = cur.api_key_id AND ul.created_at >= $3 AND ul.created_at < $1
+		GROUP BY cur.user_id, cur.api_key_id, cur.cost
+	`, windowStart, windowEnd, baselineStart, minCurrent)
+}
+
+func (r *spendAnomalyRepository) ListUserSpendStats(ctx context.Context, windowStart, windowEnd, baselineStart time.Time, minCurrent float64) ([]service.SpendWindowStat, error) {
+	return r.listSpendStats(ctx, `
+		WITH cur AS (
+			SELECT user_id, SUM(actual_cost) AS cost
+			FROM usage_logs
+			WHERE created_at >= $1 AND created_at < $2
+			GROUP BY user_id
+			HAVING SUM(actual_cost) >= $4
+		)
+		SELECT cur.user_id, 0, cur.cost,
+			COALESCE(SUM(ul.actual_cost), 0), MIN(ul.created_at)
+		FROM cur
+		LEFT JOIN usage_logs ul
+			ON ul.user_id = cur.user_id AND ul.created_at >= $3 AND ul.created_at < $1
+		GROUP BY cur.user_id, cur.cost
+	`, windowStart, windowEnd, baselineStart, minCurrent)
+}
+
+func (r *spendAnomalyRepository) listSpendStats(ctx context.Context, query string, args ...any) ([]service.SpendWindowStat, error) {
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := make([]service.SpendWindowStat, 0)
+	for rows.Next() {
+		var (
+			stat    service.SpendWindowStat
+			firstAt sql.NullTime
+		)
+		if err := rows.Scan(&stat.UserID, &stat.APIKeyID, &stat.CurrentCost, &stat.BaselineCost, &firstAt); err != nil {
+			return nil, err
+		}
+		if firstAt.Valid {
+			stat.BaselineFirstAt = &firstAt.Time
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+func (r *spendAnomalyRepository) ListClientFingerprints(ctx context.Context, apiKeyID int64, start, end time.Time) ([]string, []string, error) {
+	ips, err := r.listDistinct(ctx, "ip_address", apiKeyID, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	userAgents, err := r.listDistinct(ctx, "user_agent", apiKeyID, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ips, userAgents, nil
+}
+
+// listDistinct column 仅接受内部常量
+func (r *spendAnomalyRepository) listDistinct(ctx context.Context, column string, apiKeyID int64, start, end time.Time) ([]string, error) {
+	query := fmt.Sprintf(`
+		SELECT DISTINCT %[1]s FROM usage_logs
+		WHERE api_key_id = $1 AND created_at >= $2 AND created_at < $3 AND %[1]s IS NOT NULL AND %[1]s <> ''
+		LIMIT %[2]d
+	`, column, spendAnomalyFingerprintLimit)
+	rows, err := r.sql.QueryContext(ctx, query, apiKeyID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	values := make([]string, 0)
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+func (r *spendAnomalyRepository) GetModelSpend(ctx context.Context, apiKeyID int64, start, end time.Time) (map[string]float64, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT model, SUM(actual_cost) FROM usage_logs
+		WHERE api_key_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY model
+	`, apiKeyID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	spend := make(map[string]float64)
+	for rows.Next() {
+		var (
+			model string
+			cost  float64
+		)
+		if err := rows.Scan(&model, &cost); err != nil {
+			return nil, err
+		}
+		spend[model] = cost
+	}
+	return spend, rows.Err()
+}
+
+func (r *spendAnomalyRepository) ListActiveAPIKeyIDs(ctx context.Context, userID int64, start, end time.Time) ([]int64, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT DISTINCT api_key_id FROM usage_logs
+		WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY api_key_id
+	`, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *spendAnomalyRepository) GetSpendMetric(ctx context.Context, metricType string, start, end time.Time, groupID *int64) (float64, error) {
+	args := []any{start, end}
+	where := "created_at >= $1 AND created_at < $2"
+	if groupID != nil && *groupID > 0 {
+		args = append(args, *groupID)
+		where += " AND group_id = $3"
+	}
+
+	var query string
+	switch metricType {
+	case service.OpsMetricSpendUSD:
+		query = "SELECT COALESCE(SUM(actual_cost), 0) FROM usage_logs WHERE " + where
+	case service.OpsMetricMaxUserSpendUSD:
+		query = "SELECT COALESCE(MAX(cost), 0) FROM (SELECT SUM(actual_cost) AS cost FROM usage_logs WHERE " + where + " GROUP BY user_id) t"
+	case service.OpsMetricMaxAPIKeySpendUSD:
+		query = "SELECT COALESCE(MAX(cost), 0) FROM (SELECT SUM(actual_cost) AS cost FROM usage_logs WHERE " + where + " GROUP BY api_key_id) t"
+	default:
+		return 0, fmt.Errorf("unsupported spend metric %q", metricType)
+	}
+
+	var value float64
+	if err := scanSingleRow(ctx, r.sql, query, args, &value); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+func (r *spendAnomalyRepository) CreateFlag(ctx context.Context, flag *service.SpendAnomalyFlag) (bool, error) {
+	if flag == nil {
+		return false, nil
+	}
+	details, err := marshalNullableJSON(flag.Details, len(flag.Details) > 0)
+	if err != nil {
+		return false, err
+	}
+	actionState, err := marshalNullableJSON(flag.ActionState, flag.ActionState != nil)
+	if err != nil {
+		return false, err
+	}
+	err = scanSingleRow(ctx, r.sql, `
+		INSERT INTO spend_anomaly_flags
+			(user_id, api_key_id, kind, action, status, current_value, baseline_value, window_start, window_end, details, action_state)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::jsonb, $11::jsonb)
+		ON CONFLICT (user_id, api_key_id, kind) WHERE status = 'pending' DO NOTHING
+		RETURNING id, created_at
+	`, []any{
+		flag.UserID, flag.APIKeyID, flag.Kind, flag.Action, flag.Status, flag.CurrentValue, flag.BaselineValue,
+		flag.WindowStart, flag.WindowEnd, details, actionState,
+	}, &flag.ID, &flag.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *spendAnomalyRepository) HasRecentDismissal(ctx context.Context, userID, apiKeyID int64, kind string, since time.Time) (bool, error) {
+	var exists bool
+	if err := scanSingleRow(ctx, r.sql, `
+		SELECT EXISTS (
+			SELECT 1 FROM spend_anomaly_flags
+			WHERE user_id = $1 AND api_key_id = $2 AND kind = $3 AND status = $4 AND reviewed_at >= $5
+		)
+	`, []any{userID, apiKeyID, kind, service.SpendAnomalyStatusDismissed, since}, &exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (r *spendAnomalyRepository) UpdateFlagActionState(ctx context.Context, id int64, state *service.SpendAnomalyActionState) error {
+	raw, err := marshalNullableJSON(state, state != nil)
+	if err != nil {
+		return err
+	}
+	_, err = r.sql.ExecContext(ctx, "UPDATE spend_anomaly_flags SET action_state = $2::jsonb WHERE id = $1", id, raw)
+	return err
+}
+
+func (r *spendAnomalyRepository) GetFlag(ctx context.Context, id int64) (*service.SpendAnomalyFlag, error) {
+	rows, err := r.sql.QueryContext(ctx, spendAnomalyFlagSelect+" WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrSpendAnomalyFlagNotFound
+	}
+	return scanSpendAnomalyFlag(rows)
+}
+
+func (r *spendAnomalyRepository) ListFlags(ctx context.Context, params pagination.PaginationParams, filter service.SpendAnomalyFlagFilter) ([]service.SpendAnomalyFlag, *pagination.PaginationResult, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conds = append(conds, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Kind != "" {
+		args = append(args, filter.Kind)
+		conds = append(conds, fmt.Sprintf("kind = $%d", len(args)))
+	}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conds = append(conds, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.APIKeyID != nil {
+		args = append(args, *filter.APIKeyID)
+		conds = append(conds, fmt.Sprintf("api_key_id = $%d", len(args)))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM spend_anomaly_flags"+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+
+	query := fmt.Sprintf("%s%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d",
+		spendAnomalyFlagSelect, where, len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	flags := make([]service.SpendAnomalyFlag, 0)
+	for rows.Next() {
+		flag, err := scanSpendAnomalyFlag(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		flags = append(flags, *flag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return flags, paginationResultFromTotal(total, params), nil
+}
+
+func (r *spendAnomalyRepository) ReviewFlag(ctx context.Context, id int64, status string, reviewerID int64, note string) error {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE spend_anomaly_flags
+		SET status = $2, reviewed_at = NOW(), reviewed_by = $3, review_note = $4
+		WHERE id = $1 AND status = $5
+	`, id, status, reviewerID, note, service.SpendAnomalyStatusPending)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrSpendAnomalyAlreadyReviewed
+	}
+	return nil
+}
+
+func (r *spendAnomalyRepository) CountPendingFlags(ctx context.Context) (int64, error) {
+	var count int64
+	if err := scanSingleRow(ctx, r.sql,
+		"SELECT COUNT(*) FROM spend_anomaly_flags WHERE status = $1",
+		[]any{service.SpendAnomalyStatusPending}, &count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func marshalNullableJSON(v any, present bool) (any, error) {
+	if !present {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func scanSpendAnomalyFlag(rows *sql.Rows) (*service.SpendAnomalyFlag, error) {
+	var (
+		flag        service.SpendAnomalyFlag
+		details     []byte
+		actionState []byte
+		reviewedAt  sql.NullTime
+		reviewedBy  sql.NullInt64
+	)
+	if err := rows.Scan(
+		&flag.ID, &flag.UserID, &flag.APIKeyID, &flag.Kind, &flag.Action, &flag.Status, &flag.CurrentValue, &flag.BaselineValue,
+		&flag.WindowStart, &flag.WindowEnd, &details, &actionState, &flag.ReviewNote, &reviewedAt, &reviewedBy, &flag.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(details) > 0 {
+		_ = json.Unmarshal(details, &flag.Details)
+	}
+	if len(actionState) > 0 {
+		var state service.SpendAnomalyActionState
+		if err := json.Unmarshal(actionState, &state); err == nil {
+			flag.ActionState = &state
+		}
+	}
+	if reviewedAt.Valid {
+		flag.ReviewedAt = &reviewedAt.Time
+	}
+	if reviewedBy.Valid {
+		flag.ReviewedBy = &reviewedBy.Int64
+	}
+	return &flag, nil
+}
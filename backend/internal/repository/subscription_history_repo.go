@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type subscriptionHistoryRepository struct {
+	sql sqlExecutor
+}
+
+func NewSubscriptionHistoryRepository(db *sql.DB) service.SubscriptionHistoryRepository {
+	return &subscriptionHistoryRepository{sql: db}
+}
+
+const subscriptionHistorySelect = `
+	SELECT id, subscription_id, user_id, action, from_group_id, to_group_id, amount, credit,
+		previous_expires_at, new_expires_at, note, created_at
+	FROM subscription_history
+`
+
+// Create 写入一条订阅历史；在事务上下文中与余额扣费、订阅更新同事务提交。
+func (r *subscriptionHistoryRepository) Create(ctx context.Context, entry *service.SubscriptionHistoryEntry) error {
+	if entry == nil {
+		return nil
+	}
+	sqlq := r.sql
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		sqlq = tx.Client()
+	}
+	return scanSingleRow(ctx, sqlq, `
+		INSERT INTO subscription_history (
+			subscription_id, user_id, action, from_group_id, to_group_id, amount, credit,
+			previous_expires_at, new_expires_at, note
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`, []any{
+		entry.SubscriptionID, entry.UserID, entry.Action, entry.FromGroupID, entry.ToGroupID,
+		entry.Amount, entry.Credit, entry.PreviousExpiresAt, entry.NewExpiresAt, entry.Note,
+	}, &entry.ID, &entry.CreatedAt)
+}
+
+func (r *subscriptionHistoryRepository) ListBySubscriptionID(ctx context.Context, subscriptionID int64, params pagination.PaginationParams) ([]service.SubscriptionHistoryEntry, *pagination.PaginationResult, error) {
+	var total int64
+	if err := scanSingleRow(ctx, r.sql,
+		"SELECT COUNT(*) FROM subscription_history WHERE subscription_id = $1", []any{subscriptionID}, &total); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := r.sql.QueryContext(ctx,
+		subscriptionHistorySelect+" WHERE subscription_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3",
+		subscriptionID, params.Limit(), params.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := make([]service.SubscriptionHistoryEntry, 0)
+	for rows.Next() {
+		entry, err := scanSubscriptionHistoryEntry(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return entries, paginationResultFromTotal(total, params), nil
+}
+
+func scanSubscriptionHistoryEntry(rows *sql.Rows) (*service.SubscriptionHistoryEntry, error) {
+	var (
+		entry             service.SubscriptionHistoryEntry
+		fromGroupID       sql.NullInt64
+		toGroupID         sql.NullInt64
+		previousExpiresAt sql.NullTime
+		newExpiresAt      sql.NullTime
+	)
+	if err := rows.Scan(
+		&entry.ID, &entry.SubscriptionID, &entry.UserID, &entry.Action, &fromGroupID, &toGroupID,
+		&entry.Amount, &entry.Credit, &previousExpiresAt, &newExpiresAt, &entry.Note, &entry.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if fromGroupID.Valid {
+		entry.FromGroupID = &fromGroupID.Int64
+	}
+	if toGroupID.Valid {
+		entry.ToGroupID = &toGroupID.Int64
+	}
+	entry.PreviousExpiresAt = nullTimePtr(previousExpiresAt)
+	entry.NewExpiresAt = nullTimePtr(newExpiresAt)
+	return &entry, nil
+}
+
+func nullTimePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time
+	return &t
+}
@@ -22,7 +22,7 @@ import (
 	"github.com/lib/pq"
 )
 
-const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, created_at"
+const usageLogSelectColumns = "id, user_id, api_key_id, account_id, request_id, model, group_id, subscription_id, input_tokens, output_tokens, cache_creation_tokens, cache_read_tokens, cache_creation_5m_tokens, cache_creation_1h_tokens, input_cost, output_cost, cache_creation_cost, cache_read_cost, total_cost, actual_cost, rate_multiplier, account_rate_multiplier, billing_type, stream, duration_ms, first_token_ms, user_agent, ip_address, image_count, image_size, trace_id, created_at"
 
 type usageLogRepository struct {
 	client *dbent.Client
@@ -114,6 +114,7 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 			ip_address,
 			image_count,
 			image_size,
+			trace_id,
 			created_at
 		) VALUES (
 			$1, $2, $3, $4, $5,
@@ -121,7 +122,7 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 			$8, $9, $10, $11,
 			$12, $13,
 			$14, $15, $16, $17, $18, $19,
-			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
+			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31
 		)
 		ON CONFLICT (request_id, api_key_id) DO NOTHING
 		RETURNING id, created_at
@@ -134,6 +135,7 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 	userAgent := nullString(log.UserAgent)
 	ipAddress := nullString(log.IPAddress)
 	imageSize := nullString(log.ImageSize)
+	traceID := nullString(log.TraceID)
 
 	var requestIDArg any
 	if requestID != "" {
@@ -170,6 +172,7 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 		ipAddress,
 		log.ImageCount,
 		imageSize,
+		traceID,
 		createdAt,
 	}
 	if err := scanSingleRow(ctx, sqlq, query, args, &log.ID, &log.CreatedAt); err != nil {
@@ -1410,6 +1413,70 @@ func (r *usageLogRepository) GetBatchAPIKeyUsageStats(ctx context.Context, apiKe
 	return result, nil
 }
 
+// AccountCacheStat represents prompt cache hit statistics for a single account
+type AccountCacheStat = usagestats.AccountCacheStat
+
+// GetAccountCacheStats aggregates prompt cache hit rates per account from cache_read_tokens
+func (r *usageLogRepository) GetAccountCacheStats(ctx context.Context, startTime, endTime time.Time, groupID int64) (results []AccountCacheStat, err error) {
+	query := `
+		SELECT
+			ul.account_id,
+			COALESCE(MAX(a.name), '') as account_name,
+			COUNT(*) as requests,
+			COUNT(*) FILTER (WHERE ul.cache_read_tokens > 0) as cache_hit_requests,
+			COALESCE(SUM(ul.input_tokens), 0) as input_tokens,
+			COALESCE(SUM(ul.cache_creation_tokens), 0) as cache_creation_tokens,
+			COALESCE(SUM(ul.cache_read_tokens), 0) as cache_read_tokens
+		FROM usage_logs ul
+		LEFT JOIN accounts a ON a.id = ul.account_id
+		WHERE ul.created_at >= $1 AND ul.created_at < $2
+	`
+	args := []any{startTime, endTime}
+	if groupID > 0 {
+		query += " AND ul.group_id = $3"
+		args = append(args, groupID)
+	}
+	query += " GROUP BY ul.account_id ORDER BY cache_read_tokens DESC, requests DESC"
+
+	rows, err := r.sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			results = nil
+		}
+	}()
+
+	results = make([]AccountCacheStat, 0)
+	for rows.Next() {
+		var row AccountCacheStat
+		if err = rows.Scan(
+			&row.AccountID,
+			&row.AccountName,
+			&row.Requests,
+			&row.CacheHitRequests,
+			&row.InputTokens,
+			&row.CacheCreationTokens,
+			&row.CacheReadTokens,
+		); err != nil {
+			return nil, err
+		}
+		if row.Requests > 0 {
+			row.RequestHitRate = float64(row.CacheHitRequests) / float64(row.Requests)
+		}
+		if promptTokens := row.InputTokens + row.CacheCreationTokens + row.CacheReadTokens; promptTokens > 0 {
+			row.TokenHitRate = float64(row.CacheReadTokens) / float64(promptTokens)
+		}
+		results = append(results, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // GetUsageTrendWithFilters returns usage trend data with optional filters
 func (r *usageLogRepository) GetUsageTrendWithFilters(ctx context.Context, startTime, endTime time.Time, granularity string, userID, apiKeyID, accountID, groupID int64, model string, stream *bool, billingType *int8) (results []TrendDataPoint, err error) {
 	dateFormat := "YYYY-MM-DD"
@@ -2090,6 +2157,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		ipAddress             sql.NullString
 		imageCount            int
 		imageSize             sql.NullString
+		traceID               sql.NullString
 		createdAt             time.Time
 	)
 
@@ -2124,6 +2192,7 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 		&ipAddress,
 		&imageCount,
 		&imageSize,
+		&traceID,
 		&createdAt,
 	); err != nil {
 		return nil, err
@@ -2183,6 +2252,9 @@ func scanUsageLog(scanner interface{ Scan(...any) error }) (*service.UsageLog, e
 	if imageSize.Valid {
 		log.ImageSize = &imageSize.String
 	}
+	if traceID.Valid {
+		log.TraceID = &traceID.String
+	}
 
 	return log, nil
 }
@@ -311,6 +311,21 @@ func (s *UserRepoSuite) TestDeductBalance_ExactAmount() {
 	s.Require().InDelta(0.0, got.Balance, 1e-6)
 }
 
+func (s *UserRepoSuite) TestDeductBalanceIfSufficient() {
+	user := s.mustCreateUser(&service.User{Email: "sufficient@test.com", Balance: 10})
+
+	s.Require().NoError(s.repo.DeductBalanceIfSufficient(s.ctx, user.ID, 10), "exact amount")
+	err := s.repo.DeductBalanceIfSufficient(s.ctx, user.ID, 0.01)
+	s.Require().ErrorIs(err, service.ErrInsufficientBalance)
+
+	got, err := s.repo.GetByID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Require().InDelta(0.0, got.Balance, 1e-6, "balance must not go negative")
+
+	err = s.repo.DeductBalanceIfSufficient(s.ctx, 999999, 1)
+	s.Require().ErrorIs(err, service.ErrUserNotFound)
+}
+
 func (s *UserRepoSuite) TestDeductBalance_AllowsOverdraft() {
 	user := s.mustCreateUser(&service.User{Email: "overdraft@test.com", Balance: 5.0})
 
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	userSessionKeyPrefix       = "auth:session:"
+	legacyTokenCutoffKeyPrefix = "auth:legacy_token_cutoff:"
+)
+
+type userSessionCache struct {
+	rdb *redis.Client
+}
+
+// NewUserSessionCache 创建登录会话缓存
+func NewUserSessionCache(rdb *redis.Client) service.UserSessionCache {
+	return &userSessionCache{rdb: rdb}
+}
+
+func userSessionKey(sessionID string) string {
+	return userSessionKeyPrefix + sessionID
+}
+
+func (c *userSessionCache) GetSession(ctx context.Context, sessionID string) (*service.UserSession, error) {
+	data, err := c.rdb.Get(ctx, userSessionKey(sessionID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	var session service.UserSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("unmarshal session: %w", err)
+	}
+	return &session, nil
+}
+
+func (c *userSessionCache) SetSession(ctx context.Context, session *service.UserSession, ttl time.Duration) error {
+	if session == nil {
+		return nil
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+	return c.rdb.Set(ctx, userSessionKey(session.SessionID), data, ttl).Err()
+}
+
+func (c *userSessionCache) DeleteSessions(ctx context.Context, sessionIDs ...string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(sessionIDs))
+	for _, sid := range sessionIDs {
+		keys = append(keys, userSessionKey(sid))
+	}
+	return c.rdb.Del(ctx, keys...).Err()
+}
+
+func legacyTokenCutoffKey(userID int64) string {
+	return legacyTokenCutoffKeyPrefix + strconv.FormatInt(userID, 10)
+}
+
+func (c *userSessionCache) GetLegacyTokenCutoff(ctx context.Context, userID int64) (time.Time, error) {
+	unix, err := c.rdb.Get(ctx, legacyTokenCutoffKey(userID)).Int64()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("get legacy token cutoff: %w", err)
+	}
+	return time.Unix(unix, 0), nil
+}
+
+func (c *userSessionCache) SetLegacyTokenCutoff(ctx context.Context, userID int64, at time.Time, ttl time.Duration) error {
+	return c.rdb.Set(ctx, legacyTokenCutoffKey(userID), at.Unix(), ttl).Err()
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type userSessionRepository struct {
+	sql sqlExecutor
+}
+
+func NewUserSessionRepository(db *sql.DB) service.UserSessionRepository {
+	return &userSessionRepository{sql: db}
+}
+
+const userSessionColumns = `
+	id, session_id, user_id, ip_address, user_agent, device_name,
+	created_at, last_active_at, expires_at, revoked_at, revoke_reason
+`
+
+func (r *userSessionRepository) Create(ctx context.Context, session *service.UserSession) error {
+	if session == nil {
+		return nil
+	}
+	return scanSingleRow(ctx, r.sql, `
+		INSERT INTO user_sessions (
+			session_id, user_id, ip_address, user_agent, device_name,
+			created_at, last_active_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, []any{
+		session.SessionID,
+		session.UserID,
+		session.IPAddress,
+		session.UserAgent,
+		session.DeviceName,
+		session.CreatedAt,
+		session.LastActiveAt,
+		session.ExpiresAt,
+	}, &session.ID)
+}
+
+func (r *userSessionRepository) GetBySessionID(ctx context.Context, sessionID string) (*service.UserSession, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+userSessionColumns+" FROM user_sessions WHERE session_id = $1", sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrSessionNotFound
+	}
+	session, err := scanUserSession(rows)
+	if err != nil {
+		return nil, err
+	}
+	return session, rows.Err()
+}
+
+func (r *userSessionRepository) ListActiveByUser(ctx context.Context, userID int64, now time.Time) ([]service.UserSession, error) {
+	rows, err := r.sql.QueryContext(ctx, "SELECT "+userSessionColumns+`
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_active_at DESC, id DESC
+	`, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	sessions := make([]service.UserSession, 0)
+	for rows.Next() {
+		session, err := scanUserSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *userSessionRepository) TouchLastActive(ctx context.Context, sessionID string, at time.Time) error {
+	_, err := r.sql.ExecContext(ctx, `
+		UPDATE user_sessions SET last_active_at = $2
+		WHERE session_id = $1 AND revoked_at IS NULL AND last_active_at < $2
+	`, sessionID, at)
+	return err
+}
+
+func (r *userSessionRepository) ExtendExpiry(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE user_sessions SET expires_at = $2
+		WHERE session_id = $1 AND revoked_at IS NULL
+	`, sessionID, expiresAt)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrSessionRevoked
+	}
+	return nil
+}
+
+func (r *userSessionRepository) Revoke(ctx context.Context, sessionID, reason string, at time.Time) (bool, error) {
+	res, err := r.sql.ExecContext(ctx, `
+		UPDATE user_sessions SET revoked_at = $2, revoke_reason = $3
+		WHERE session_id = $1 AND revoked_at IS NULL
+	`, sessionID, at, reason)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *userSessionRepository) RevokeAllByUser(ctx context.Context, userID int64, exceptSessionID, reason string, at time.Time) ([]string, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		UPDATE user_sessions SET revoked_at = $2, revoke_reason = $3
+		WHERE user_id = $1 AND revoked_at IS NULL AND session_id <> $4
+		RETURNING session_id
+	`, userID, at, reason, exceptSessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	revoked := make([]string, 0)
+	for rows.Next() {
+		var sid string
+		if err := rows.Scan(&sid); err != nil {
+			return nil, err
+		}
+		revoked = append(revoked, sid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+func (r *userSessionRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.sql.ExecContext(ctx, `
+		DELETE FROM user_sessions
+		WHERE expires_at < $1 OR (revoked_at IS NOT NULL AND revoked_at < $1)
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanUserSession(rows *sql.Rows) (*service.UserSession, error) {
+	var (
+		session   service.UserSession
+		revokedAt sql.NullTime
+	)
+	if err := rows.Scan(
+		&session.ID,
+		&session.SessionID,
+		&session.UserID,
+		&session.IPAddress,
+		&session.UserAgent,
+		&session.DeviceName,
+		&session.CreatedAt,
+		&session.LastActiveAt,
+		&session.ExpiresAt,
+		&revokedAt,
+		&session.RevokeReason,
+	); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		t := revokedAt.Time
+		session.RevokedAt = &t
+	}
+	return &session, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	dbent "github.com/Wei-Shaw/sub2api/ent"
@@ -34,7 +35,9 @@ func (r *userSubscriptionRepository) Create(ctx context.Context, sub *service.Us
 		SetDailyUsageUsd(sub.DailyUsageUSD).
 		SetWeeklyUsageUsd(sub.WeeklyUsageUSD).
 		SetMonthlyUsageUsd(sub.MonthlyUsageUSD).
-		SetNillableAssignedBy(sub.AssignedBy)
+		SetNillableAssignedBy(sub.AssignedBy).
+		SetAutoRenew(sub.AutoRenew).
+		SetNillablePausedAt(sub.PausedAt)
 
 	if sub.StartsAt.IsZero() {
 		builder.SetStartsAt(time.Now())
@@ -120,7 +123,28 @@ func (r *userSubscriptionRepository) Update(ctx context.Context, sub *service.Us
 		SetMonthlyUsageUsd(sub.MonthlyUsageUSD).
 		SetNillableAssignedBy(sub.AssignedBy).
 		SetAssignedAt(sub.AssignedAt).
-		SetNotes(sub.Notes)
+		SetNotes(sub.Notes).
+		SetAutoRenew(sub.AutoRenew)
+	if sub.PausedAt != nil {
+		builder = builder.SetPausedAt(*sub.PausedAt)
+	} else {
+		builder = builder.ClearPausedAt()
+	}
+	if len(sub.DailyUsageCounters) > 0 {
+		builder = builder.SetDailyUsageCounters(sub.DailyUsageCounters)
+	} else {
+		builder = builder.ClearDailyUsageCounters()
+	}
+	if len(sub.WeeklyUsageCounters) > 0 {
+		builder = builder.SetWeeklyUsageCounters(sub.WeeklyUsageCounters)
+	} else {
+		builder = builder.ClearWeeklyUsageCounters()
+	}
+	if len(sub.MonthlyUsageCounters) > 0 {
+		builder = builder.SetMonthlyUsageCounters(sub.MonthlyUsageCounters)
+	} else {
+		builder = builder.ClearMonthlyUsageCounters()
+	}
 
 	updated, err := builder.Save(ctx)
 	if err == nil {
@@ -309,6 +333,7 @@ func (r *userSubscriptionRepository) ResetDailyUsage(ctx context.Context, id int
 	client := clientFromContext(ctx, r.client)
 	_, err := client.UserSubscription.UpdateOneID(id).
 		SetDailyUsageUsd(0).
+		ClearDailyUsageCounters().
 		SetDailyWindowStart(newWindowStart).
 		Save(ctx)
 	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
@@ -318,6 +343,7 @@ func (r *userSubscriptionRepository) ResetWeeklyUsage(ctx context.Context, id in
 	client := clientFromContext(ctx, r.client)
 	_, err := client.UserSubscription.UpdateOneID(id).
 		SetWeeklyUsageUsd(0).
+		ClearWeeklyUsageCounters().
 		SetWeeklyWindowStart(newWindowStart).
 		Save(ctx)
 	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
@@ -327,6 +353,7 @@ func (r *userSubscriptionRepository) ResetMonthlyUsage(ctx context.Context, id i
 	client := clientFromContext(ctx, r.client)
 	_, err := client.UserSubscription.UpdateOneID(id).
 		SetMonthlyUsageUsd(0).
+		ClearMonthlyUsageCounters().
 		SetMonthlyWindowStart(newWindowStart).
 		Save(ctx)
 	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
@@ -369,6 +396,69 @@ func (r *userSubscriptionRepository) IncrementUsage(ctx context.Context, id int6
 	return service.ErrSubscriptionNotFound
 }
 
+// IncrementUsageCounters 原子性地累加 token/请求数限额计数。
+// 在 UPDATE 内基于当前行值合并 JSONB，避免并发请求互相覆盖计数。
+func (r *userSubscriptionRepository) IncrementUsageCounters(ctx context.Context, id int64, delta service.SubscriptionUsageCounters) error {
+	if delta.IsEmpty() {
+		return nil
+	}
+	daily, err := marshalUsageCounters(delta.Daily)
+	if err != nil {
+		return err
+	}
+	weekly, err := marshalUsageCounters(delta.Weekly)
+	if err != nil {
+		return err
+	}
+	monthly, err := marshalUsageCounters(delta.Monthly)
+	if err != nil {
+		return err
+	}
+
+	updateSQL := `
+		UPDATE user_subscriptions us
+		SET
+			daily_usage_counters = ` + mergeUsageCountersSQL("daily_usage_counters", "$1") + `,
+			weekly_usage_counters = ` + mergeUsageCountersSQL("weekly_usage_counters", "$2") + `,
+			monthly_usage_counters = ` + mergeUsageCountersSQL("monthly_usage_counters", "$3") + `,
+			updated_at = NOW()
+		WHERE us.id = $4 AND us.deleted_at IS NULL
+	`
+
+	client := clientFromContext(ctx, r.client)
+	result, err := client.ExecContext(ctx, updateSQL, daily, weekly, monthly, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// mergeUsageCountersSQL 生成将增量 JSON 对象逐键累加到计数列的表达式
+func mergeUsageCountersSQL(column, param string) string {
+	return `COALESCE(us.` + column + `, '{}'::jsonb) || COALESCE((
+				SELECT jsonb_object_agg(d.key, COALESCE((us.` + column + ` ->> d.key)::numeric, 0) + d.value::numeric)
+				FROM jsonb_each_text(` + param + `::jsonb) d
+			), '{}'::jsonb)`
+}
+
+func marshalUsageCounters(counters map[string]float64) (string, error) {
+	if len(counters) == 0 {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(counters)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
 func (r *userSubscriptionRepository) BatchUpdateExpiredStatus(ctx context.Context) (int64, error) {
 	client := clientFromContext(ctx, r.client)
 	n, err := client.UserSubscription.Update().
@@ -381,6 +471,148 @@ func (r *userSubscriptionRepository) BatchUpdateExpiredStatus(ctx context.Contex
 	return int64(n), err
 }
 
+func (r *userSubscriptionRepository) SetAutoRenew(ctx context.Context, id int64, autoRenew bool) error {
+	client := clientFromContext(ctx, r.client)
+	_, err := client.UserSubscription.UpdateOneID(id).
+		SetAutoRenew(autoRenew).
+		Save(ctx)
+	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
+}
+
+// Pause 仅暂停仍有效的 active 订阅，避免与过期任务或并发恢复竞争。
+func (r *userSubscriptionRepository) Pause(ctx context.Context, id int64, pausedAt time.Time) error {
+	client := clientFromContext(ctx, r.client)
+	n, err := client.UserSubscription.Update().
+		Where(
+			usersubscription.IDEQ(id),
+			usersubscription.StatusEQ(service.SubscriptionStatusActive),
+			usersubscription.ExpiresAtGT(pausedAt),
+		).
+		SetStatus(service.SubscriptionStatusPaused).
+		SetPausedAt(pausedAt).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrSubscriptionNotPausable
+	}
+	return nil
+}
+
+func (r *userSubscriptionRepository) Resume(ctx context.Context, id int64, newExpiresAt time.Time) error {
+	client := clientFromContext(ctx, r.client)
+	n, err := client.UserSubscription.Update().
+		Where(
+			usersubscription.IDEQ(id),
+			usersubscription.StatusEQ(service.SubscriptionStatusPaused),
+		).
+		SetStatus(service.SubscriptionStatusActive).
+		SetExpiresAt(newExpiresAt).
+		ClearPausedAt().
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrSubscriptionNotPaused
+	}
+	return nil
+}
+
+// RenewExpiry 续期：仅当订阅仍为 active 且到期时间未被修改时才更新，避免并发续期重复顺延。
+func (r *userSubscriptionRepository) RenewExpiry(ctx context.Context, id int64, prevExpiresAt, newExpiresAt time.Time) error {
+	client := clientFromContext(ctx, r.client)
+	n, err := client.UserSubscription.Update().
+		Where(
+			usersubscription.IDEQ(id),
+			usersubscription.StatusEQ(service.SubscriptionStatusActive),
+			usersubscription.ExpiresAtEQ(prevExpiresAt),
+		).
+		SetExpiresAt(newExpiresAt).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrSubscriptionChanged
+	}
+	return nil
+}
+
+func (r *userSubscriptionRepository) MarkExpired(ctx context.Context, id int64, expiresAt time.Time) error {
+	client := clientFromContext(ctx, r.client)
+	n, err := client.UserSubscription.Update().
+		Where(
+			usersubscription.IDEQ(id),
+			usersubscription.StatusEQ(service.SubscriptionStatusActive),
+			usersubscription.ExpiresAtEQ(expiresAt),
+		).
+		SetStatus(service.SubscriptionStatusExpired).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return service.ErrSubscriptionChanged
+	}
+	return nil
+}
+
+// ChangeGroup 将订阅从 fromGroupID 迁移到新分组并开始新周期（用量窗口清零）；
+// 订阅已不在原分组或不再 active 时返回 ErrSubscriptionChanged。
+func (r *userSubscriptionRepository) ChangeGroup(ctx context.Context, id, fromGroupID, groupID int64, startsAt, expiresAt time.Time) error {
+	client := clientFromContext(ctx, r.client)
+	n, err := client.UserSubscription.Update().
+		Where(
+			usersubscription.IDEQ(id),
+			usersubscription.GroupIDEQ(fromGroupID),
+			usersubscription.StatusEQ(service.SubscriptionStatusActive),
+		).
+		SetGroupID(groupID).
+		SetStartsAt(startsAt).
+		SetExpiresAt(expiresAt).
+		SetStatus(service.SubscriptionStatusActive).
+		ClearPausedAt().
+		ClearDailyWindowStart().
+		ClearWeeklyWindowStart().
+		ClearMonthlyWindowStart().
+		SetDailyUsageUsd(0).
+		SetWeeklyUsageUsd(0).
+		SetMonthlyUsageUsd(0).
+		ClearDailyUsageCounters().
+		ClearWeeklyUsageCounters().
+		ClearMonthlyUsageCounters().
+		Save(ctx)
+	if err != nil {
+		return translatePersistenceError(err, service.ErrSubscriptionNotFound, service.ErrSubscriptionAlreadyExists)
+	}
+	if n == 0 {
+		return service.ErrSubscriptionChanged
+	}
+	return nil
+}
+
+func (r *userSubscriptionRepository) ListAutoRenewDue(ctx context.Context, before time.Time, limit int) ([]service.UserSubscription, error) {
+	client := clientFromContext(ctx, r.client)
+	q := client.UserSubscription.Query().
+		Where(
+			usersubscription.AutoRenewEQ(true),
+			usersubscription.StatusEQ(service.SubscriptionStatusActive),
+			usersubscription.ExpiresAtLTE(before),
+		).
+		WithGroup().
+		Order(dbent.Asc(usersubscription.FieldExpiresAt))
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	subs, err := q.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return userSubscriptionEntitiesToService(subs), nil
+}
+
 // Extra repository helpers (currently used only by integration tests).
 
 func (r *userSubscriptionRepository) ListExpired(ctx context.Context) ([]service.UserSubscription, error) {
@@ -426,23 +658,28 @@ func userSubscriptionEntityToService(m *dbent.UserSubscription) *service.UserSub
 		return nil
 	}
 	out := &service.UserSubscription{
-		ID:                 m.ID,
-		UserID:             m.UserID,
-		GroupID:            m.GroupID,
-		StartsAt:           m.StartsAt,
-		ExpiresAt:          m.ExpiresAt,
-		Status:             m.Status,
-		DailyWindowStart:   m.DailyWindowStart,
-		WeeklyWindowStart:  m.WeeklyWindowStart,
-		MonthlyWindowStart: m.MonthlyWindowStart,
-		DailyUsageUSD:      m.DailyUsageUsd,
-		WeeklyUsageUSD:     m.WeeklyUsageUsd,
-		MonthlyUsageUSD:    m.MonthlyUsageUsd,
-		AssignedBy:         m.AssignedBy,
-		AssignedAt:         m.AssignedAt,
-		Notes:              derefString(m.Notes),
-		CreatedAt:          m.CreatedAt,
-		UpdatedAt:          m.UpdatedAt,
+		ID:                   m.ID,
+		UserID:               m.UserID,
+		GroupID:              m.GroupID,
+		StartsAt:             m.StartsAt,
+		ExpiresAt:            m.ExpiresAt,
+		Status:               m.Status,
+		DailyWindowStart:     m.DailyWindowStart,
+		WeeklyWindowStart:    m.WeeklyWindowStart,
+		MonthlyWindowStart:   m.MonthlyWindowStart,
+		DailyUsageUSD:        m.DailyUsageUsd,
+		WeeklyUsageUSD:       m.WeeklyUsageUsd,
+		MonthlyUsageUSD:      m.MonthlyUsageUsd,
+		DailyUsageCounters:   m.DailyUsageCounters,
+		WeeklyUsageCounters:  m.WeeklyUsageCounters,
+		MonthlyUsageCounters: m.MonthlyUsageCounters,
+		AssignedBy:           m.AssignedBy,
+		AssignedAt:           m.AssignedAt,
+		Notes:                derefString(m.Notes),
+		AutoRenew:            m.AutoRenew,
+		PausedAt:             m.PausedAt,
+		CreatedAt:            m.CreatedAt,
+		UpdatedAt:            m.UpdatedAt,
 	}
 	if m.Edges.User != nil {
 		out.User = userEntityToService(m.Edges.User)
@@ -431,7 +431,7 @@ func (s *UserSubscriptionRepoSuite) TestResetMonthlyUsage() {
 	s.Require().WithinDuration(resetAt, *got.MonthlyWindowStart, time.Microsecond)
 }
 
-// --- UpdateStatus / ExtendExpiry / UpdateNotes ---
+// --- UpdateStatus / ExtendExpiry / RenewExpiry / ChangeGroup / UpdateNotes ---
 
 func (s *UserSubscriptionRepoSuite) TestUpdateStatus() {
 	user := s.mustCreateUser("status@test.com", service.RoleUser)
@@ -460,6 +460,60 @@ func (s *UserSubscriptionRepoSuite) TestExtendExpiry() {
 	s.Require().WithinDuration(newExpiry, got.ExpiresAt, time.Microsecond)
 }
 
+func (s *UserSubscriptionRepoSuite) TestRenewExpiry_OnlyOnce() {
+	user := s.mustCreateUser("renew@test.com", service.RoleUser)
+	group := s.mustCreateGroup("g-renew")
+	created := s.mustCreateSubscription(user.ID, group.ID, nil)
+	sub, err := s.repo.GetByID(s.ctx, created.ID)
+	s.Require().NoError(err)
+
+	newExpiry := sub.ExpiresAt.AddDate(0, 0, 30)
+	s.Require().NoError(s.repo.RenewExpiry(s.ctx, sub.ID, sub.ExpiresAt, newExpiry), "RenewExpiry")
+
+	// 并发续期的另一方基于旧到期时间更新，应被拒绝
+	err = s.repo.RenewExpiry(s.ctx, sub.ID, sub.ExpiresAt, newExpiry.AddDate(0, 0, 30))
+	s.Require().ErrorIs(err, service.ErrSubscriptionChanged)
+
+	got, err := s.repo.GetByID(s.ctx, sub.ID)
+	s.Require().NoError(err)
+	s.Require().WithinDuration(newExpiry, got.ExpiresAt, time.Microsecond)
+}
+
+func (s *UserSubscriptionRepoSuite) TestMarkExpired_RequiresUnchangedExpiry() {
+	user := s.mustCreateUser("markexpired@test.com", service.RoleUser)
+	group := s.mustCreateGroup("g-markexpired")
+	created := s.mustCreateSubscription(user.ID, group.ID, nil)
+	sub, err := s.repo.GetByID(s.ctx, created.ID)
+	s.Require().NoError(err)
+
+	// 已被其他流程续期的订阅不应被标记过期
+	err = s.repo.MarkExpired(s.ctx, sub.ID, sub.ExpiresAt.Add(-time.Hour))
+	s.Require().ErrorIs(err, service.ErrSubscriptionChanged)
+
+	s.Require().NoError(s.repo.MarkExpired(s.ctx, sub.ID, sub.ExpiresAt), "MarkExpired")
+	got, err := s.repo.GetByID(s.ctx, sub.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(service.SubscriptionStatusExpired, got.Status)
+}
+
+func (s *UserSubscriptionRepoSuite) TestChangeGroup_RequiresSourceGroup() {
+	user := s.mustCreateUser("changegroup@test.com", service.RoleUser)
+	from := s.mustCreateGroup("g-change-from")
+	to := s.mustCreateGroup("g-change-to")
+	other := s.mustCreateGroup("g-change-other")
+	sub := s.mustCreateSubscription(user.ID, from.ID, nil)
+
+	now := time.Now()
+	s.Require().NoError(s.repo.ChangeGroup(s.ctx, sub.ID, from.ID, to.ID, now, now.AddDate(0, 0, 30)), "ChangeGroup")
+
+	err := s.repo.ChangeGroup(s.ctx, sub.ID, from.ID, other.ID, now, now.AddDate(0, 0, 30))
+	s.Require().ErrorIs(err, service.ErrSubscriptionChanged)
+
+	got, err := s.repo.GetByID(s.ctx, sub.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(to.ID, got.GroupID)
+}
+
 func (s *UserSubscriptionRepoSuite) TestUpdateNotes() {
 	user := s.mustCreateUser("notes@test.com", service.RoleUser)
 	group := s.mustCreateGroup("g-notes")
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/redis/go-redis/v9"
+)
+
+const webAuthnCeremonyKeyPrefix = "webauthn:ceremony:"
+
+type webAuthnCache struct {
+	rdb *redis.Client
+}
+
+// NewWebAuthnCache 创建 WebAuthn 仪式数据缓存
+func NewWebAuthnCache(rdb *redis.Client) service.WebAuthnCache {
+	return &webAuthnCache{rdb: rdb}
+}
+
+func (c *webAuthnCache) SetCeremony(ctx context.Context, key string, data *webauthn.SessionData, ttl time.Duration) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal webauthn session: %w", err)
+	}
+	return c.rdb.Set(ctx, webAuthnCeremonyKeyPrefix+key, raw, ttl).Err()
+}
+
+func (c *webAuthnCache) TakeCeremony(ctx context.Context, key string) (*webauthn.SessionData, error) {
+	// GETDEL 保证挑战只能被使用一次
+	raw, err := c.rdb.GetDel(ctx, webAuthnCeremonyKeyPrefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get webauthn session: %w", err)
+	}
+	var data webauthn.SessionData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("unmarshal webauthn session: %w", err)
+	}
+	return &data, nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+type webAuthnCredentialRepository struct {
+	sql sqlExecutor
+}
+
+func NewWebAuthnCredentialRepository(db *sql.DB) service.WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{sql: db}
+}
+
+func (r *webAuthnCredentialRepository) Create(ctx context.Context, credential *service.WebAuthnCredential) error {
+	if credential == nil {
+		return nil
+	}
+	data, err := json.Marshal(credential.Credential)
+	if err != nil {
+		return fmt.Errorf("marshal webauthn credential: %w", err)
+	}
+	err = scanSingleRow(ctx, r.sql, `
+		INSERT INTO user_webauthn_credentials (user_id, credential_id, name, credential, discoverable, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, []any{
+		credential.UserID,
+		credential.CredentialID,
+		credential.Name,
+		data,
+		credential.Discoverable,
+		credential.CreatedAt,
+	}, &credential.ID)
+	if isUniqueConstraintViolation(err) {
+		return service.ErrWebAuthnCredentialExists
+	}
+	return err
+}
+
+func (r *webAuthnCredentialRepository) ListByUser(ctx context.Context, userID int64) ([]service.WebAuthnCredential, error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT id, user_id, credential_id, name, credential, discoverable, created_at, last_used_at
+		FROM user_webauthn_credentials
+		WHERE user_id = $1
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]service.WebAuthnCredential, 0)
+	for rows.Next() {
+		var (
+			item       service.WebAuthnCredential
+			data       []byte
+			lastUsedAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&item.ID,
+			&item.UserID,
+			&item.CredentialID,
+			&item.Name,
+			&data,
+			&item.Discoverable,
+			&item.CreatedAt,
+			&lastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &item.Credential); err != nil {
+			return nil, fmt.Errorf("unmarshal webauthn credential %d: %w", item.ID, err)
+		}
+		if lastUsedAt.Valid {
+			t := lastUsedAt.Time
+			item.LastUsedAt = &t
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (r *webAuthnCredentialRepository) CountByUser(ctx context.Context, userID int64) (int, error) {
+	var count int
+	if err := scanSingleRow(ctx, r.sql,
+		"SELECT COUNT(*) FROM user_webauthn_credentials WHERE user_id = $1",
+		[]any{userID}, &count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *webAuthnCredentialRepository) UpdateAfterLogin(ctx context.Context, credentialID string, credential webauthn.Credential, usedAt time.Time) error {
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return fmt.Errorf("marshal webauthn credential: %w", err)
+	}
+	_, err = r.sql.ExecContext(ctx, `
+		UPDATE user_webauthn_credentials SET credential = $2, last_used_at = $3
+		WHERE credential_id = $1
+	`, credentialID, data, usedAt)
+	return err
+}
+
+func (r *webAuthnCredentialRepository) Delete(ctx context.Context, userID, id int64) error {
+	res, err := r.sql.ExecContext(ctx,
+		"DELETE FROM user_webauthn_credentials WHERE id = $1 AND user_id = $2",
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrWebAuthnCredentialMissing
+	}
+	return nil
+}
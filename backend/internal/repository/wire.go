@@ -47,6 +47,12 @@ func ProvideSessionLimitCache(rdb *redis.Client, cfg *config.Config) service.Ses
 	return NewSessionLimitCache(rdb, defaultIdleTimeoutMinutes)
 }
 
+// ProvideHTTPUpstream 创建上游 HTTP 客户端，并为绑定代理池的账户提供成员解析与故障切换；
+// 中间层按配置接入内置模拟上游与上游录制；最外层的 dry run 装饰器用于管理端预览上游请求（不访问真实上游）
+func ProvideHTTPUpstream(cfg *config.Config, proxyPools *service.ProxyPoolService) service.HTTPUpstream {
+	return service.NewDryRunHTTPUpstream(service.NewMockHTTPUpstream(service.NewProxyPoolHTTPUpstream(NewHTTPUpstream(cfg), proxyPools), cfg))
+}
+
 // ProviderSet is the Wire provider set for all repositories
 var ProviderSet = wire.NewSet(
 	NewUserRepository,
@@ -54,6 +60,11 @@ var ProviderSet = wire.NewSet(
 	NewGroupRepository,
 	NewAccountRepository,
 	NewProxyRepository,
+	NewProxyPoolRepository,
+	NewContentPolicyRepository,
+	NewSpendAnomalyRepository,
+	NewSubscriptionHistoryRepository,
+	NewMessageBatchRepository,
 	NewRedeemCodeRepository,
 	NewPromoCodeRepository,
 	NewUsageLogRepository,
@@ -64,6 +75,9 @@ var ProviderSet = wire.NewSet(
 	NewUserSubscriptionRepository,
 	NewUserAttributeDefinitionRepository,
 	NewUserAttributeValueRepository,
+	NewUserSessionRepository,
+	NewRecoveryCodeRepository,
+	NewWebAuthnCredentialRepository,
 
 	// Cache implementations
 	NewGatewayCache,
@@ -73,16 +87,22 @@ var ProviderSet = wire.NewSet(
 	NewTimeoutCounterCache,
 	ProvideConcurrencyCache,
 	ProvideSessionLimitCache,
+	NewPromptCacheAffinityCache,
+	NewAccountOutcomeCache,
+	NewCircuitBreakerCache,
 	NewDashboardCache,
 	NewEmailCache,
 	NewIdentityCache,
 	NewRedeemCache,
 	NewUpdateCache,
+	NewClusterCache,
 	NewGeminiTokenCache,
 	NewSchedulerCache,
 	NewSchedulerOutboxRepository,
 	NewProxyLatencyCache,
 	NewTotpCache,
+	NewUserSessionCache,
+	NewWebAuthnCache,
 
 	// Encryptors
 	NewAESEncryptor,
@@ -94,7 +114,7 @@ var ProviderSet = wire.NewSet(
 	NewProxyExitInfoProber,
 	NewClaudeUsageFetcher,
 	NewClaudeOAuthClient,
-	NewHTTPUpstream,
+	ProvideHTTPUpstream,
 	NewOpenAIOAuthClient,
 	NewGeminiOAuthClient,
 	NewGeminiCliCodeAssistClient,
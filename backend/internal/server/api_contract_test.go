@@ -230,6 +230,8 @@ func TestAPIContracts(t *testing.T) {
 						"daily_usage_usd": 1.23,
 						"weekly_usage_usd": 2.34,
 						"monthly_usage_usd": 3.45,
+						"auto_renew": false,
+						"paused_at": null,
 						"created_at": "2025-01-02T03:04:05Z",
 						"updated_at": "2025-01-02T03:04:05Z"
 					}
@@ -455,6 +457,7 @@ func TestAPIContracts(t *testing.T) {
 					"password_reset_enabled": false,
 					"totp_enabled": false,
 					"totp_encryption_key_configured": false,
+					"require_admin_2fa": false,
 					"smtp_host": "smtp.example.com",
 					"smtp_port": 587,
 					"smtp_username": "user",
@@ -584,7 +587,7 @@ func newContractDeps(t *testing.T) *contractDeps {
 		RunMode: config.RunModeStandard,
 	}
 
-	userService := service.NewUserService(userRepo, nil)
+	userService := service.NewUserService(userRepo, nil, nil)
 	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, groupRepo, userSubRepo, apiKeyCache, cfg)
 
 	usageRepo := newStubUsageLogRepo()
@@ -599,12 +602,12 @@ func newContractDeps(t *testing.T) *contractDeps {
 	settingRepo := newStubSettingRepo()
 	settingService := service.NewSettingService(settingRepo, cfg)
 
-	adminService := service.NewAdminService(userRepo, groupRepo, &accountRepo, proxyRepo, apiKeyRepo, redeemRepo, nil, nil, nil, nil)
-	authHandler := handler.NewAuthHandler(cfg, nil, userService, settingService, nil, nil)
+	adminService := service.NewAdminService(userRepo, groupRepo, &accountRepo, proxyRepo, apiKeyRepo, redeemRepo, nil, nil, nil, nil, nil)
+	authHandler := handler.NewAuthHandler(cfg, nil, userService, settingService, nil, nil, nil, nil)
 	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
 	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
-	adminSettingHandler := adminhandler.NewSettingHandler(settingService, nil, nil, nil)
-	adminAccountHandler := adminhandler.NewAccountHandler(adminService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	adminSettingHandler := adminhandler.NewSettingHandler(settingService, nil, nil, nil, nil)
+	adminAccountHandler := adminhandler.NewAccountHandler(adminService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 
 	jwtAuth := func(c *gin.Context) {
 		c.Set(string(middleware.ContextKeyUser), middleware.AuthSubject{
@@ -747,6 +750,10 @@ func (r *stubUserRepo) DeductBalance(ctx context.Context, id int64, amount float
 	return errors.New("not implemented")
 }
 
+func (r *stubUserRepo) DeductBalanceIfSufficient(ctx context.Context, id int64, amount float64) error {
+	return errors.New("not implemented")
+}
+
 func (r *stubUserRepo) UpdateConcurrency(ctx context.Context, id int64, amount int) error {
 	return errors.New("not implemented")
 }
@@ -1223,9 +1230,34 @@ func (stubUserSubscriptionRepo) ResetMonthlyUsage(ctx context.Context, id int64,
 func (stubUserSubscriptionRepo) IncrementUsage(ctx context.Context, id int64, costUSD float64) error {
 	return errors.New("not implemented")
 }
+func (stubUserSubscriptionRepo) IncrementUsageCounters(ctx context.Context, id int64, delta service.SubscriptionUsageCounters) error {
+	return errors.New("not implemented")
+}
 func (stubUserSubscriptionRepo) BatchUpdateExpiredStatus(ctx context.Context) (int64, error) {
 	return 0, errors.New("not implemented")
 }
+func (stubUserSubscriptionRepo) SetAutoRenew(ctx context.Context, id int64, autoRenew bool) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) Pause(ctx context.Context, id int64, pausedAt time.Time) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) Resume(ctx context.Context, id int64, newExpiresAt time.Time) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) RenewExpiry(ctx context.Context, id int64, prevExpiresAt, newExpiresAt time.Time) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) MarkExpired(ctx context.Context, id int64, expiresAt time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (stubUserSubscriptionRepo) ChangeGroup(ctx context.Context, id, fromGroupID, groupID int64, startsAt, expiresAt time.Time) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) ListAutoRenewDue(ctx context.Context, before time.Time, limit int) ([]service.UserSubscription, error) {
+	return nil, errors.New("not implemented")
+}
 
 type stubApiKeyRepo struct {
 	now time.Time
@@ -1578,6 +1610,10 @@ func (r *stubUsageLogRepo) GetBatchAPIKeyUsageStats(ctx context.Context, apiKeyI
 	return nil, errors.New("not implemented")
 }
 
+func (r *stubUsageLogRepo) GetAccountCacheStats(ctx context.Context, startTime, endTime time.Time, groupID int64) ([]usagestats.AccountCacheStat, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (r *stubUsageLogRepo) GetUserDashboardStats(ctx context.Context, userID int64) (*usagestats.UserDashboardStats, error) {
 	return nil, errors.New("not implemented")
 }
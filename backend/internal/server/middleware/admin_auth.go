@@ -16,8 +16,9 @@ func NewAdminAuthMiddleware(
 	authService *service.AuthService,
 	userService *service.UserService,
 	settingService *service.SettingService,
+	twoFactorService *service.TwoFactorService,
 ) AdminAuthMiddleware {
-	return AdminAuthMiddleware(adminAuth(authService, userService, settingService))
+	return AdminAuthMiddleware(adminAuth(authService, userService, settingService, twoFactorService))
 }
 
 // adminAuth 管理员认证中间件实现
@@ -28,6 +29,7 @@ func adminAuth(
 	authService *service.AuthService,
 	userService *service.UserService,
 	settingService *service.SettingService,
+	twoFactorService *service.TwoFactorService,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// WebSocket upgrade requests cannot set Authorization headers in browsers.
@@ -36,7 +38,7 @@ func adminAuth(
 		//   Sec-WebSocket-Protocol: sub2api-admin, jwt.<token>
 		if isWebSocketUpgradeRequest(c) {
 			if token := extractJWTFromWebSocketSubprotocol(c); token != "" {
-				if !validateJWTForAdmin(c, token, authService, userService) {
+				if !validateJWTForAdmin(c, token, authService, userService, twoFactorService) {
 					return
 				}
 				c.Next()
@@ -59,7 +61,7 @@ func adminAuth(
 		if authHeader != "" {
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) == 2 && parts[0] == "Bearer" {
-				if !validateJWTForAdmin(c, parts[1], authService, userService) {
+				if !validateJWTForAdmin(c, parts[1], authService, userService, twoFactorService) {
 					return
 				}
 				c.Next()
@@ -151,6 +153,7 @@ func validateJWTForAdmin(
 	token string,
 	authService *service.AuthService,
 	userService *service.UserService,
+	twoFactorService *service.TwoFactorService,
 ) bool {
 	// 验证 JWT token
 	claims, err := authService.ValidateToken(token)
@@ -182,6 +185,25 @@ func validateJWTForAdmin(
 		return false
 	}
 
+	if err := authService.ValidateSession(c.Request.Context(), claims); err != nil {
+		abortSessionError(c, err)
+		return false
+	}
+	setSessionContext(c, claims.SessionID)
+
+	// 管理员强制 2FA 策略：未配置第二因素的管理员只能访问用户侧接口（用于完成 2FA 配置）
+	if twoFactorService != nil {
+		satisfied, err := twoFactorService.CheckAdminPolicy(c.Request.Context(), user)
+		if err != nil {
+			AbortWithError(c, 500, "INTERNAL_ERROR", "Internal server error")
+			return false
+		}
+		if !satisfied {
+			AbortWithError(c, 403, "ADMIN_2FA_REQUIRED", "Two-factor authentication must be enabled to access admin features")
+			return false
+		}
+	}
+
 	c.Set(string(ContextKeyUser), AuthSubject{
 		UserID:      user.ID,
 		Concurrency: user.Concurrency,
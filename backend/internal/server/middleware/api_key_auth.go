@@ -9,6 +9,8 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -22,6 +24,9 @@ func NewAPIKeyAuthMiddleware(apiKeyService *service.APIKeyService, subscriptionS
 // apiKeyAuthWithSubscription API Key认证中间件（支持订阅验证）
 func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscriptionService *service.SubscriptionService, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		_, span := tracing.Start(c.Request.Context(), "auth.api_key")
+		defer endAuthSpan(c, span)
+
 		queryKey := strings.TrimSpace(c.Query("key"))
 		queryApiKey := strings.TrimSpace(c.Query("api_key"))
 		if queryKey != "" || queryApiKey != "" {
@@ -106,6 +111,8 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 			})
 			c.Set(string(ContextKeyUserRole), apiKey.User.Role)
 			setGroupContext(c, apiKey.Group)
+			setAuthLogFields(c, apiKey)
+			endAuthSpan(c, span)
 			c.Next()
 			return
 		}
@@ -165,7 +172,9 @@ func apiKeyAuthWithSubscription(apiKeyService *service.APIKeyService, subscripti
 		})
 		c.Set(string(ContextKeyUserRole), apiKey.User.Role)
 		setGroupContext(c, apiKey.Group)
+		setAuthLogFields(c, apiKey)
 
+		endAuthSpan(c, span)
 		c.Next()
 	}
 }
@@ -190,6 +199,16 @@ func GetSubscriptionFromContext(c *gin.Context) (*service.UserSubscription, bool
 	return subscription, ok
 }
 
+// setAuthLogFields attaches the authenticated API key, user and group to the request
+// log fields so every later gateway log line can be filtered by them.
+func setAuthLogFields(c *gin.Context, apiKey *service.APIKey) {
+	args := []any{"api_key_id", apiKey.ID, "user_id", apiKey.User.ID}
+	if apiKey.GroupID != nil {
+		args = append(args, "group_id", *apiKey.GroupID)
+	}
+	c.Request = c.Request.WithContext(logger.With(c.Request.Context(), args...))
+}
+
 func setGroupContext(c *gin.Context, group *service.Group) {
 	if !service.IsGroupContextValid(group) {
 		return
@@ -422,6 +422,38 @@ func (r *stubUserSubscriptionRepo) IncrementUsage(ctx context.Context, id int64,
 	return errors.New("not implemented")
 }
 
+func (r *stubUserSubscriptionRepo) IncrementUsageCounters(ctx context.Context, id int64, delta service.SubscriptionUsageCounters) error {
+	return errors.New("not implemented")
+}
+
 func (r *stubUserSubscriptionRepo) BatchUpdateExpiredStatus(ctx context.Context) (int64, error) {
 	return 0, errors.New("not implemented")
 }
+
+func (r *stubUserSubscriptionRepo) SetAutoRenew(ctx context.Context, id int64, autoRenew bool) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) Pause(ctx context.Context, id int64, pausedAt time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) Resume(ctx context.Context, id int64, newExpiresAt time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) RenewExpiry(ctx context.Context, id int64, prevExpiresAt, newExpiresAt time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) MarkExpired(ctx context.Context, id int64, expiresAt time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) ChangeGroup(ctx context.Context, id, fromGroupID, groupID int64, startsAt, expiresAt time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) ListAutoRenewDue(ctx context.Context, before time.Time, limit int) ([]service.UserSubscription, error) {
+	return nil, errors.New("not implemented")
+}
@@ -1,6 +1,11 @@
 package middleware
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/gin-gonic/gin"
+)
 
 // AuthSubject is the minimal authenticated identity stored in gin context.
 // Decision: {UserID int64, Concurrency int}
@@ -26,3 +31,22 @@ func GetUserRoleFromContext(c *gin.Context) (string, bool) {
 	role, ok := value.(string)
 	return role, ok
 }
+
+// setSessionContext 将当前会话 ID 写入 gin.Context 与 request.Context（供 Service 层排除当前会话）
+func setSessionContext(c *gin.Context, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	c.Set(string(ContextKeySessionID), sessionID)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxkey.SessionID, sessionID))
+}
+
+// GetSessionIDFromContext 返回当前 JWT 绑定的会话 ID
+func GetSessionIDFromContext(c *gin.Context) string {
+	value, exists := c.Get(string(ContextKeySessionID))
+	if !exists {
+		return ""
+	}
+	sid, _ := value.(string)
+	return sid
+}
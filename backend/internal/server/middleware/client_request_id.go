@@ -4,13 +4,15 @@ import (
 	"context"
 
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 // ClientRequestID ensures every request has a unique client_request_id in request.Context().
 //
-// This is used by the Ops monitoring module for end-to-end request correlation.
+// This is used by the Ops monitoring module for end-to-end request correlation, and is
+// attached to the request log fields.
 func ClientRequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request == nil {
@@ -24,7 +26,8 @@ func ClientRequestID() gin.HandlerFunc {
 		}
 
 		id := uuid.New().String()
-		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ctxkey.ClientRequestID, id))
+		ctx := context.WithValue(c.Request.Context(), ctxkey.ClientRequestID, id)
+		c.Request = c.Request.WithContext(logger.With(ctx, "client_request_id", id))
 		c.Next()
 	}
 }
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// InFlightRequests counts gateway requests in progress on this node; the count is
+// reported in the cluster heartbeat. While the node drains before a cluster restart,
+// new requests are rejected with 503 so clients retry against another node.
+func InFlightRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Count before checking the drain flag so the drain loop never misses a request
+		// that was admitted concurrently.
+		done := service.BeginGatewayRequest()
+		defer done()
+		if service.GatewayDraining() {
+			c.Header("Connection", "close")
+			c.Header("Retry-After", "5")
+			AbortWithError(c, http.StatusServiceUnavailable, "SERVICE_DRAINING", "Node is restarting, please retry")
+			return
+		}
+		c.Next()
+	}
+}
@@ -68,6 +68,13 @@ func jwtAuth(authService *service.AuthService, userService *service.UserService)
 			return
 		}
 
+		// 校验 token 绑定的登录会话（可被用户/管理员吊销）
+		if err := authService.ValidateSession(c.Request.Context(), claims); err != nil {
+			abortSessionError(c, err)
+			return
+		}
+		setSessionContext(c, claims.SessionID)
+
 		c.Set(string(ContextKeyUser), AuthSubject{
 			UserID:      user.ID,
 			Concurrency: user.Concurrency,
@@ -78,4 +85,13 @@ func jwtAuth(authService *service.AuthService, userService *service.UserService)
 	}
 }
 
+// abortSessionError 会话失效返回 401；会话存储异常返回 503，避免基础设施故障导致用户被登出
+func abortSessionError(c *gin.Context, err error) {
+	if service.IsSessionInvalid(err) {
+		AbortWithError(c, 401, "SESSION_REVOKED", "Session has been revoked or expired")
+		return
+	}
+	AbortWithError(c, 503, "SERVICE_UNAVAILABLE", "Service temporarily unavailable, please retry later")
+}
+
 // Deprecated: prefer GetAuthSubjectFromContext in auth_subject.go.
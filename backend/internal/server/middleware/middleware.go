@@ -21,6 +21,8 @@ const (
 	ContextKeySubscription ContextKey = "subscription"
 	// ContextKeyForcePlatform 强制平台（用于 /antigravity 路由）
 	ContextKeyForcePlatform ContextKey = "force_platform"
+	// ContextKeySessionID 当前 JWT 绑定的登录会话 ID
+	ContextKeySessionID ContextKey = "session_id"
 )
 
 // ForcePlatform 返回设置强制平台的中间件
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing creates the server span for a gateway request, continuing the W3C trace
+// context (traceparent) sent by the client when present.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request == nil {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		ctx, span := tracing.StartServer(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header), c.Request.Method+" "+route,
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if id, ok := c.Request.Context().Value(ctxkey.ClientRequestID).(string); ok && id != "" {
+			span.SetAttributes(attribute.String("sub2api.client_request_id", id))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// endAuthSpan ends the authentication span before the request continues down the
+// chain, marking it as failed when the middleware rejected the request.
+func endAuthSpan(c *gin.Context, span trace.Span) {
+	if c.IsAborted() {
+		span.SetAttributes(attribute.Int("http.response.status_code", c.Writer.Status()))
+		span.SetStatus(codes.Error, "rejected")
+	}
+	span.End()
+}
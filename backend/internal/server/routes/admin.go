@@ -41,6 +41,19 @@ func RegisterAdminRoutes(
 		// 代理管理
 		registerProxyRoutes(admin, h)
 
+		// 代理池
+		registerProxyPoolRoutes(admin, h)
+		registerContentPolicyRoutes(admin, h)
+
+		// 消费异常审查
+		registerSpendAnomalyRoutes(admin, h)
+
+		// 账号池容量预测
+		registerCapacityForecastRoutes(admin, h)
+
+		// 集群节点与滚动更新
+		registerClusterRoutes(admin, h)
+
 		// 卡密管理
 		registerRedeemCodeRoutes(admin, h)
 
@@ -78,6 +91,8 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		// Alerts (rules + events)
 		ops.GET("/alert-rules", h.Admin.Ops.ListAlertRules)
 		ops.POST("/alert-rules", h.Admin.Ops.CreateAlertRule)
+		ops.POST("/alert-rules/validate", h.Admin.Ops.ValidateAlertExpression)
+		ops.POST("/alert-rules/preview", h.Admin.Ops.PreviewAlertExpression)
 		ops.PUT("/alert-rules/:id", h.Admin.Ops.UpdateAlertRule)
 		ops.DELETE("/alert-rules/:id", h.Admin.Ops.DeleteAlertRule)
 		ops.GET("/alert-events", h.Admin.Ops.ListAlertEvents)
@@ -85,6 +100,9 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		ops.PUT("/alert-events/:id/status", h.Admin.Ops.UpdateAlertEventStatus)
 		ops.POST("/alert-silences", h.Admin.Ops.CreateAlertSilence)
 
+		// Protocol translation playground (never contacts upstream)
+		ops.POST("/dry-run", h.Admin.Ops.DryRunGatewayRequest)
+
 		// Email notification config (DB-backed)
 		ops.GET("/email-notification/config", h.Admin.Ops.GetEmailNotificationConfig)
 		ops.PUT("/email-notification/config", h.Admin.Ops.UpdateEmailNotificationConfig)
@@ -155,6 +173,7 @@ func registerDashboardRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		dashboard.GET("/models", h.Admin.Dashboard.GetModelStats)
 		dashboard.GET("/api-keys-trend", h.Admin.Dashboard.GetAPIKeyUsageTrend)
 		dashboard.GET("/users-trend", h.Admin.Dashboard.GetUserUsageTrend)
+		dashboard.GET("/account-cache-stats", h.Admin.Dashboard.GetAccountCacheStats)
 		dashboard.POST("/users-usage", h.Admin.Dashboard.GetBatchUsersUsage)
 		dashboard.POST("/api-keys-usage", h.Admin.Dashboard.GetBatchAPIKeysUsage)
 		dashboard.POST("/aggregation/backfill", h.Admin.Dashboard.BackfillAggregation)
@@ -176,6 +195,11 @@ func registerUserManagementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		// User attribute values
 		users.GET("/:id/attributes", h.Admin.UserAttribute.GetUserAttributes)
 		users.PUT("/:id/attributes", h.Admin.UserAttribute.UpdateUserAttributes)
+
+		// Login sessions
+		users.GET("/:id/sessions", h.Admin.UserSession.List)
+		users.POST("/:id/sessions/revoke", h.Admin.UserSession.ForceLogout)
+		users.DELETE("/:id/sessions/:session_id", h.Admin.UserSession.Revoke)
 	}
 }
 
@@ -189,6 +213,8 @@ func registerGroupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		groups.PUT("/:id", h.Admin.Group.Update)
 		groups.DELETE("/:id", h.Admin.Group.Delete)
 		groups.GET("/:id/stats", h.Admin.Group.GetStats)
+		groups.GET("/:id/account-selection/dry-run", h.Admin.Group.AccountSelectionDryRun)
+		groups.POST("/:id/request-rewrite/test", h.Admin.Group.TestRequestRewrite)
 		groups.GET("/:id/api-keys", h.Admin.Group.GetGroupAPIKeys)
 	}
 }
@@ -212,6 +238,9 @@ func registerAccountRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		accounts.POST("/:id/clear-rate-limit", h.Admin.Account.ClearRateLimit)
 		accounts.GET("/:id/temp-unschedulable", h.Admin.Account.GetTempUnschedulable)
 		accounts.DELETE("/:id/temp-unschedulable", h.Admin.Account.ClearTempUnschedulable)
+		accounts.GET("/:id/circuit-breaker", h.Admin.Account.GetCircuitBreaker)
+		accounts.DELETE("/:id/circuit-breaker", h.Admin.Account.ResetCircuitBreaker)
+		accounts.POST("/circuit-breaker/reset", h.Admin.Account.BatchResetCircuitBreaker)
 		accounts.POST("/:id/schedulable", h.Admin.Account.SetSchedulable)
 		accounts.GET("/:id/models", h.Admin.Account.GetAvailableModels)
 		accounts.POST("/batch", h.Admin.Account.BatchCreate)
@@ -257,6 +286,58 @@ func registerAntigravityOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers)
 	}
 }
 
+func registerProxyPoolRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	pools := admin.Group("/proxy-pools")
+	{
+		pools.GET("", h.Admin.ProxyPool.List)
+		pools.GET("/:id", h.Admin.ProxyPool.GetByID)
+		pools.POST("", h.Admin.ProxyPool.Create)
+		pools.PUT("/:id", h.Admin.ProxyPool.Update)
+		pools.DELETE("/:id", h.Admin.ProxyPool.Delete)
+		pools.POST("/:id/probe", h.Admin.ProxyPool.Probe)
+	}
+}
+
+func registerContentPolicyRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	policy := admin.Group("/content-policy")
+	{
+		policy.GET("/rules", h.Admin.ContentPolicy.ListRules)
+		policy.POST("/rules", h.Admin.ContentPolicy.CreateRule)
+		policy.PUT("/rules/:id", h.Admin.ContentPolicy.UpdateRule)
+		policy.DELETE("/rules/:id", h.Admin.ContentPolicy.DeleteRule)
+		policy.POST("/test", h.Admin.ContentPolicy.Test)
+		policy.GET("/hits", h.Admin.ContentPolicy.ListHits)
+		policy.POST("/hits/:id/review", h.Admin.ContentPolicy.ReviewHit)
+	}
+}
+
+func registerSpendAnomalyRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	anomalies := admin.Group("/spend-anomalies")
+	{
+		anomalies.GET("", h.Admin.SpendAnomaly.List)
+		anomalies.GET("/:id", h.Admin.SpendAnomaly.GetByID)
+		anomalies.POST("/:id/review", h.Admin.SpendAnomaly.Review)
+		anomalies.POST("/detect", h.Admin.SpendAnomaly.Detect)
+	}
+}
+
+func registerClusterRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	cluster := admin.Group("/cluster")
+	{
+		cluster.GET("", h.Admin.Cluster.GetOverview)
+		cluster.GET("/rollout", h.Admin.Cluster.GetRollout)
+		cluster.POST("/rollout", h.Admin.Cluster.StartRollout)
+	}
+}
+
+func registerCapacityForecastRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	forecast := admin.Group("/capacity-forecast")
+	{
+		forecast.GET("", h.Admin.CapacityForecast.List)
+		forecast.GET("/:group_id", h.Admin.CapacityForecast.GetByGroup)
+	}
+}
+
 func registerProxyRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	proxies := admin.Group("/proxies")
 	{
@@ -325,6 +406,8 @@ func registerSystemRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		system.POST("/update", h.Admin.System.PerformUpdate)
 		system.POST("/rollback", h.Admin.System.Rollback)
 		system.POST("/restart", h.Admin.System.RestartService)
+		system.GET("/log-level", h.Admin.System.GetLogLevel)
+		system.PUT("/log-level", h.Admin.System.SetLogLevel)
 	}
 }
 
@@ -337,6 +420,11 @@ func registerSubscriptionRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		subscriptions.POST("/assign", h.Admin.Subscription.Assign)
 		subscriptions.POST("/bulk-assign", h.Admin.Subscription.BulkAssign)
 		subscriptions.POST("/:id/extend", h.Admin.Subscription.Extend)
+		subscriptions.PUT("/:id/auto-renew", h.Admin.Subscription.SetAutoRenew)
+		subscriptions.POST("/:id/change", h.Admin.Subscription.ChangePlan)
+		subscriptions.POST("/:id/pause", h.Admin.Subscription.Pause)
+		subscriptions.POST("/:id/resume", h.Admin.Subscription.Resume)
+		subscriptions.GET("/:id/history", h.Admin.Subscription.History)
 		subscriptions.DELETE("/:id", h.Admin.Subscription.Revoke)
 	}
 
@@ -27,6 +27,18 @@ func RegisterAuthRoutes(
 		auth.POST("/register", h.Auth.Register)
 		auth.POST("/login", h.Auth.Login)
 		auth.POST("/login/2fa", h.Auth.Login2FA)
+		auth.POST("/login/2fa/webauthn/begin", h.Auth.Login2FAWebAuthnBegin)
+		auth.POST("/login/2fa/webauthn/finish", h.Auth.Login2FAWebAuthnFinish)
+		// Passkey 无密码登录：每分钟最多 20 次（Redis 故障时 fail-close）
+		passkeyLimit := rateLimiter.LimitWithOptions("passkey-login", 20, time.Minute, middleware.RateLimitOptions{
+			FailureMode: middleware.RateLimitFailClose,
+		})
+		auth.POST("/passkey/begin", passkeyLimit, h.Auth.PasskeyLoginBegin)
+		auth.POST("/passkey/finish", passkeyLimit, h.Auth.PasskeyLoginFinish)
+		// 刷新 token：允许携带已过期 token，但其绑定的登录会话必须仍然有效
+		auth.POST("/refresh", rateLimiter.LimitWithOptions("refresh-token", 30, time.Minute, middleware.RateLimitOptions{
+			FailureMode: middleware.RateLimitFailClose,
+		}), h.Auth.RefreshToken)
 		auth.POST("/send-verify-code", h.Auth.SendVerifyCode)
 		// 优惠码验证接口添加速率限制：每分钟最多 10 次（Redis 故障时 fail-close）
 		auth.POST("/validate-promo-code", rateLimiter.LimitWithOptions("validate-promo", 10, time.Minute, middleware.RateLimitOptions{
@@ -55,5 +67,6 @@ func RegisterAuthRoutes(
 	authenticated.Use(gin.HandlerFunc(jwtAuth))
 	{
 		authenticated.GET("/auth/me", h.Auth.GetCurrentUser)
+		authenticated.POST("/auth/logout", h.Auth.Logout)
 	}
 }
@@ -19,30 +19,50 @@ func RegisterGatewayRoutes(
 	opsService *service.OpsService,
 	cfg *config.Config,
 ) {
+	tracingMiddleware := middleware.Tracing()
+	inFlight := middleware.InFlightRequests()
 	bodyLimit := middleware.RequestBodyLimit(cfg.Gateway.MaxBodySize)
 	clientRequestID := middleware.ClientRequestID()
 	opsErrorLogger := handler.OpsErrorLoggerMiddleware(opsService)
+	gatewayDiagnostics := handler.GatewayDiagnosticsMiddleware(cfg.Gateway.DiagnosticHeaders)
 
 	// API网关（Claude API兼容）
 	gateway := r.Group("/v1")
+	gateway.Use(tracingMiddleware)
+	gateway.Use(inFlight)
 	gateway.Use(bodyLimit)
 	gateway.Use(clientRequestID)
 	gateway.Use(opsErrorLogger)
+	gateway.Use(gatewayDiagnostics)
 	gateway.Use(gin.HandlerFunc(apiKeyAuth))
 	{
 		gateway.POST("/messages", h.Gateway.Messages)
 		gateway.POST("/messages/count_tokens", h.Gateway.CountTokens)
+		// Anthropic Message Batches API
+		gateway.POST("/messages/batches", h.MessageBatch.Create)
+		gateway.GET("/messages/batches", h.MessageBatch.List)
+		gateway.GET("/messages/batches/:id", h.MessageBatch.Retrieve)
+		gateway.POST("/messages/batches/:id/cancel", h.MessageBatch.Cancel)
+		gateway.GET("/messages/batches/:id/results", h.MessageBatch.Results)
 		gateway.GET("/models", h.Gateway.Models)
 		gateway.GET("/usage", h.Gateway.Usage)
 		// OpenAI Responses API
 		gateway.POST("/responses", h.OpenAIGateway.Responses)
+		// OpenAI Embeddings API
+		gateway.POST("/embeddings", h.OpenAIGateway.Embeddings)
+		// OpenAI Images API（OpenAI API Key 账号透传，Gemini 图片模型翻译）
+		gateway.POST("/images/generations", h.OpenAIGateway.ImageGenerations)
+		gateway.POST("/images/edits", h.OpenAIGateway.ImageEdits)
 	}
 
 	// Gemini 原生 API 兼容层（Gemini SDK/CLI 直连）
 	gemini := r.Group("/v1beta")
+	gemini.Use(tracingMiddleware)
+	gemini.Use(inFlight)
 	gemini.Use(bodyLimit)
 	gemini.Use(clientRequestID)
 	gemini.Use(opsErrorLogger)
+	gemini.Use(gatewayDiagnostics)
 	gemini.Use(middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg))
 	{
 		gemini.GET("/models", h.Gateway.GeminiV1BetaListModels)
@@ -52,16 +72,19 @@ func RegisterGatewayRoutes(
 	}
 
 	// OpenAI Responses API（不带v1前缀的别名）
-	r.POST("/responses", bodyLimit, clientRequestID, opsErrorLogger, gin.HandlerFunc(apiKeyAuth), h.OpenAIGateway.Responses)
+	r.POST("/responses", tracingMiddleware, inFlight, bodyLimit, clientRequestID, opsErrorLogger, gatewayDiagnostics, gin.HandlerFunc(apiKeyAuth), h.OpenAIGateway.Responses)
 
 	// Antigravity 模型列表
 	r.GET("/antigravity/models", gin.HandlerFunc(apiKeyAuth), h.Gateway.AntigravityModels)
 
 	// Antigravity 专用路由（仅使用 antigravity 账户，不混合调度）
 	antigravityV1 := r.Group("/antigravity/v1")
+	antigravityV1.Use(tracingMiddleware)
+	antigravityV1.Use(inFlight)
 	antigravityV1.Use(bodyLimit)
 	antigravityV1.Use(clientRequestID)
 	antigravityV1.Use(opsErrorLogger)
+	antigravityV1.Use(gatewayDiagnostics)
 	antigravityV1.Use(middleware.ForcePlatform(service.PlatformAntigravity))
 	antigravityV1.Use(gin.HandlerFunc(apiKeyAuth))
 	{
@@ -72,9 +95,12 @@ func RegisterGatewayRoutes(
 	}
 
 	antigravityV1Beta := r.Group("/antigravity/v1beta")
+	antigravityV1Beta.Use(tracingMiddleware)
+	antigravityV1Beta.Use(inFlight)
 	antigravityV1Beta.Use(bodyLimit)
 	antigravityV1Beta.Use(clientRequestID)
 	antigravityV1Beta.Use(opsErrorLogger)
+	antigravityV1Beta.Use(gatewayDiagnostics)
 	antigravityV1Beta.Use(middleware.ForcePlatform(service.PlatformAntigravity))
 	antigravityV1Beta.Use(middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg))
 	{
@@ -33,6 +33,30 @@ func RegisterUserRoutes(
 				totp.POST("/enable", h.Totp.Enable)
 				totp.POST("/disable", h.Totp.Disable)
 			}
+
+			// WebAuthn 安全密钥 / Passkey
+			webauthn := user.Group("/webauthn")
+			{
+				webauthn.GET("/credentials", h.WebAuthn.ListCredentials)
+				webauthn.POST("/register/begin", h.WebAuthn.BeginRegistration)
+				webauthn.POST("/register/finish", h.WebAuthn.FinishRegistration)
+				webauthn.DELETE("/credentials/:id", h.WebAuthn.DeleteCredential)
+			}
+
+			// 双因素认证状态与恢复码
+			twoFactor := user.Group("/2fa")
+			{
+				twoFactor.GET("/status", h.TwoFactor.GetStatus)
+				twoFactor.POST("/recovery-codes", h.TwoFactor.RegenerateRecoveryCodes)
+			}
+
+			// 登录会话管理
+			sessions := user.Group("/sessions")
+			{
+				sessions.GET("", h.Session.List)
+				sessions.POST("/revoke-others", h.Session.RevokeOthers)
+				sessions.DELETE("/:session_id", h.Session.Revoke)
+			}
 		}
 
 		// API Key管理
@@ -78,6 +102,11 @@ func RegisterUserRoutes(
 			subscriptions.GET("/active", h.Subscription.GetActive)
 			subscriptions.GET("/progress", h.Subscription.GetProgress)
 			subscriptions.GET("/summary", h.Subscription.GetSummary)
+			subscriptions.PUT("/:id/auto-renew", h.Subscription.SetAutoRenew)
+			subscriptions.POST("/:id/change", h.Subscription.ChangePlan)
+			subscriptions.POST("/:id/pause", h.Subscription.Pause)
+			subscriptions.POST("/:id/resume", h.Subscription.Resume)
+			subscriptions.GET("/:id/history", h.Subscription.History)
 		}
 	}
 }
@@ -17,6 +17,8 @@ type Account struct {
 	Credentials map[string]any
 	Extra       map[string]any
 	ProxyID     *int64
+	// ProxyPoolID 绑定的代理池（与 ProxyID 互斥），请求时由 ProxyPoolService 选择具体代理
+	ProxyPoolID *int64
 	Concurrency int
 	Priority    int
 	// RateMultiplier 账号计费倍率（>=0，允许 0 表示该账号计费为 0）。
@@ -605,6 +607,18 @@ func (a *Account) IsAnthropicOAuthOrSetupToken() bool {
 	return a.Platform == PlatformAnthropic && (a.Type == AccountTypeOAuth || a.Type == AccountTypeSetupToken)
 }
 
+// UpstreamProxyURL 返回经 HTTPUpstream 发送的请求所使用的代理地址。
+// 绑定代理池时返回代理池伪地址（由 HTTPUpstream 解析为具体成员并自动切换），否则返回账户代理地址。
+func (a *Account) UpstreamProxyURL() string {
+	if a.ProxyPoolID != nil && *a.ProxyPoolID > 0 {
+		return ProxyPoolURL(*a.ProxyPoolID)
+	}
+	if a.ProxyID != nil && a.Proxy != nil {
+		return a.Proxy.URL()
+	}
+	return ""
+}
+
 // IsTLSFingerprintEnabled 检查是否启用 TLS 指纹伪装
 // 仅适用于 Anthropic OAuth/SetupToken 类型账号
 // 启用后将模拟 Claude Code (Node.js) 客户端的 TLS 握手特征
@@ -697,6 +711,21 @@ func (a *Account) GetSessionIdleTimeoutMinutes() int {
 	return 5
 }
 
+// GetSelectionWeight 获取加权轮询策略下的账号权重
+// 默认值为 1，非正数视为默认值
+func (a *Account) GetSelectionWeight() int {
+	if a.Extra == nil {
+		return 1
+	}
+	if v, ok := a.Extra["selection_weight"]; ok {
+		val := parseExtraInt(v)
+		if val > 0 {
+			return val
+		}
+	}
+	return 1
+}
+
 // CheckWindowCostSchedulability 根据当前窗口费用检查调度状态
 // - 费用 < 阈值: WindowCostSchedulable（可正常调度）
 // - 费用 >= 阈值 且 < 阈值+预留: WindowCostStickyOnly（仅粘性会话）
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 账号选择策略（分组级配置）
+// 策略只在同优先级账号之间生效，账号优先级仍然优先于策略评分。
+const (
+	AccountSelectionStrategyDefault            = ""                     // 默认：优先级 + 负载 + 最近最少使用
+	AccountSelectionStrategyWeightedRoundRobin = "weighted_round_robin" // 按管理员配置的权重平滑加权轮询
+	AccountSelectionStrategyLeastCost          = "least_cost"           // 账号计费倍率最低者优先
+	AccountSelectionStrategyMostRemainingQuota = "most_remaining_quota" // 剩余额度（Claude 5h 窗口 / Codex 用量）最多者优先
+	AccountSelectionStrategyErrorRatePenalized = "error_rate_penalized" // 近期上游错误率越高越靠后
+)
+
+const (
+	// accountOutcomeWindow 错误率统计窗口
+	accountOutcomeWindow = 10 * time.Minute
+	// accountOutcomeMinSamples 样本数不足时不计算错误率，避免少量请求造成误判
+	accountOutcomeMinSamples = 5
+	// wrrStateIdleTTL 加权轮询状态的闲置淘汰时间：账号/分组被删除后不再参与调度，其状态在此时间后清理
+	wrrStateIdleTTL = time.Hour
+	// wrrStatePruneInterval 加权轮询状态的清理间隔（在调度时顺带执行）
+	wrrStatePruneInterval = 5 * time.Minute
+)
+
+var (
+	ErrAccountSelectionStrategyInvalid     = infraerrors.BadRequest("ACCOUNT_SELECTION_STRATEGY_INVALID", "invalid account selection strategy")
+	ErrAccountSelectionStrategyUnsupported = infraerrors.BadRequest("ACCOUNT_SELECTION_STRATEGY_UNSUPPORTED", "account selection strategy is only supported for anthropic and openai groups")
+)
+
+// AccountSelectionStrategies 返回所有可配置的选择策略（含默认策略）
+func AccountSelectionStrategies() []string {
+	return []string{
+		AccountSelectionStrategyDefault,
+		AccountSelectionStrategyWeightedRoundRobin,
+		AccountSelectionStrategyLeastCost,
+		AccountSelectionStrategyMostRemainingQuota,
+		AccountSelectionStrategyErrorRatePenalized,
+	}
+}
+
+// ValidateAccountSelectionStrategy 校验分组账号选择策略。
+// 仅 Anthropic（GatewayService）与 OpenAI（OpenAIGatewayService）调度支持非默认策略，Gemini/Antigravity 分组只能使用默认策略。
+func ValidateAccountSelectionStrategy(platform, strategy string) error {
+	if strategy == AccountSelectionStrategyDefault {
+		return nil
+	}
+	valid := false
+	for _, s := range AccountSelectionStrategies() {
+		if s == strategy {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrAccountSelectionStrategyInvalid
+	}
+	if platform != PlatformAnthropic && platform != PlatformOpenAI {
+		return ErrAccountSelectionStrategyUnsupported
+	}
+	return nil
+}
+
+// AccountOutcomeStats 账号在统计窗口内的上游请求结果
+type AccountOutcomeStats struct {
+	Success int64
+	Failure int64
+}
+
+// ErrorRate 返回错误率；样本不足时返回 0
+func (s AccountOutcomeStats) ErrorRate() float64 {
+	total := s.Success + s.Failure
+	if total < accountOutcomeMinSamples {
+		return 0
+	}
+	return float64(s.Failure) / float64(total)
+}
+
+// AccountOutcomeCache 按分钟桶记录账号上游请求成功/失败次数
+type AccountOutcomeCache interface {
+	RecordOutcome(ctx context.Context, accountID int64, success bool, now time.Time) error
+	GetOutcomes(ctx context.Context, accountIDs []int64, since time.Time) (map[int64]AccountOutcomeStats, error)
+}
+
+// AccountSelectionService 根据分组配置的选择策略为候选账号打分（分数越低越优先）
+type AccountSelectionService struct {
+	accountRepo       AccountRepository
+	groupRepo         GroupRepository
+	usageLogRepo      UsageLogRepository
+	sessionLimitCache SessionLimitCache
+	outcomeCache      AccountOutcomeCache
+
+	// 平滑加权轮询状态：(groupID, priority) -> accountID -> current weight
+	wrrMu       sync.Mutex
+	wrrState    map[wrrTierKey]map[int64]wrrWeight
+	wrrPrunedAt time.Time
+}
+
+// wrrTierKey 加权轮询按分组内的优先级层独立轮转（调度总是先按优先级排序）
+type wrrTierKey struct {
+	groupID  int64
+	priority int
+}
+
+// wrrWeight 单个账号的轮询状态，seenAt 为最近一次参与调度的时间
+type wrrWeight struct {
+	current int
+	seenAt  time.Time
+}
+
+// NewAccountSelectionService 创建账号选择策略服务
+func NewAccountSelectionService(
+	accountRepo AccountRepository,
+	groupRepo GroupRepository,
+	usageLogRepo UsageLogRepository,
+	sessionLimitCache SessionLimitCache,
+	outcomeCache AccountOutcomeCache,
+) *AccountSelectionService {
+	return &AccountSelectionService{
+		accountRepo:       accountRepo,
+		groupRepo:         groupRepo,
+		usageLogRepo:      usageLogRepo,
+		sessionLimitCache: sessionLimitCache,
+		outcomeCache:      outcomeCache,
+		wrrState:          make(map[wrrTierKey]map[int64]wrrWeight),
+	}
+}
+
+// StrategyForGroup 获取分组配置的选择策略，优先使用 context 中的分组信息
+func (s *AccountSelectionService) StrategyForGroup(ctx context.Context, groupID *int64) string {
+	if s == nil || groupID == nil {
+		return AccountSelectionStrategyDefault
+	}
+	if group, ok := ctx.Value(ctxkey.Group).(*Group); ok && IsGroupContextValid(group) && group.ID == *groupID {
+		return group.AccountSelectionStrategy
+	}
+	if s.groupRepo == nil {
+		return AccountSelectionStrategyDefault
+	}
+	group, err := s.groupRepo.GetByIDLite(ctx, *groupID)
+	if err != nil || group == nil {
+		return AccountSelectionStrategyDefault
+	}
+	return group.AccountSelectionStrategy
+}
+
+// ScoreForGroup 按分组策略为候选账号打分，用于调度排序；默认策略返回 nil。
+// 打分不改变任何状态，加权轮询的推进由 RecordSelection 在实际获得槽位后完成。
+func (s *AccountSelectionService) ScoreForGroup(ctx context.Context, groupID *int64, accounts []*Account) map[int64]float64 {
+	strategy := s.StrategyForGroup(ctx, groupID)
+	if strategy == AccountSelectionStrategyDefault {
+		return nil
+	}
+	return s.scores(ctx, strategy, derefGroupID(groupID), accounts)
+}
+
+// RecordSelection 记录实际获得槽位的账号：加权轮询据此推进该账号所在优先级层的轮询状态。
+// candidates 为本次调度的候选账号（同层账号均参与本轮权重累加）。
+func (s *AccountSelectionService) RecordSelection(ctx context.Context, groupID *int64, candidates []*Account, selected *Account) {
+	if s == nil || selected == nil {
+		return
+	}
+	if s.StrategyForGroup(ctx, groupID) != AccountSelectionStrategyWeightedRoundRobin {
+		return
+	}
+	s.advanceWeightedRoundRobin(derefGroupID(groupID), candidates, selected)
+}
+
+// scores 计算指定策略下各账号的分数（越低越优先）
+func (s *AccountSelectionService) scores(ctx context.Context, strategy string, groupID int64, accounts []*Account) map[int64]float64 {
+	if s == nil || len(accounts) == 0 {
+		return nil
+	}
+	switch strategy {
+	case AccountSelectionStrategyWeightedRoundRobin:
+		return s.weightedRoundRobinScores(groupID, accounts)
+	case AccountSelectionStrategyLeastCost:
+		out := make(map[int64]float64, len(accounts))
+		for _, acc := range accounts {
+			out[acc.ID] = acc.BillingRateMultiplier()
+		}
+		return out
+	case AccountSelectionStrategyMostRemainingQuota:
+		out := make(map[int64]float64, len(accounts))
+		for _, acc := range accounts {
+			out[acc.ID] = s.usedQuotaRatio(ctx, acc)
+		}
+		return out
+	case AccountSelectionStrategyErrorRatePenalized:
+		return s.errorRateScores(ctx, accounts)
+	}
+	return nil
+}
+
+// weightedRoundRobinScores 平滑加权轮询（nginx 算法）的候选排序：
+// 分数为本轮累加后的 current（取负），同层 current 最大者优先。
+func (s *AccountSelectionService) weightedRoundRobinScores(groupID int64, accounts []*Account) map[int64]float64 {
+	s.wrrMu.Lock()
+	defer s.wrrMu.Unlock()
+
+	out := make(map[int64]float64, len(accounts))
+	for _, acc := range accounts {
+		state := s.wrrState[wrrTierKey{groupID: groupID, priority: acc.Priority}]
+		out[acc.ID] = -float64(state[acc.ID].current + acc.GetSelectionWeight())
+	}
+	return out
+}
+
+// advanceWeightedRoundRobin 推进一轮平滑加权轮询：选中账号所在层的所有账号 current += weight，
+// 选中账号再减去该层权重总和。
+func (s *AccountSelectionService) advanceWeightedRoundRobin(groupID int64, candidates []*Account, selected *Account) {
+	s.wrrMu.Lock()
+	defer s.wrrMu.Unlock()
+
+	now := time.Now()
+	s.pruneWRRStateLocked(now)
+	key := wrrTierKey{groupID: groupID, priority: selected.Priority}
+	state := s.wrrState[key]
+	if state == nil {
+		state = make(map[int64]wrrWeight)
+		s.wrrState[key] = state
+	}
+
+	total := 0
+	for _, acc := range candidates {
+		if acc.Priority != selected.Priority {
+			continue
+		}
+		weight := acc.GetSelectionWeight()
+		total += weight
+		state[acc.ID] = wrrWeight{current: state[acc.ID].current + weight, seenAt: now}
+	}
+	st := state[selected.ID]
+	st.current -= total
+	state[selected.ID] = st
+}
+
+// pruneWRRStateLocked 清理长时间未参与调度的账号与优先级层状态（含已删除的账号/分组），调用方需持有 wrrMu
+func (s *AccountSelectionService) pruneWRRStateLocked(now time.Time) {
+	if now.Sub(s.wrrPrunedAt) < wrrStatePruneInterval {
+		return
+	}
+	s.wrrPrunedAt = now
+	for key, state := range s.wrrState {
+		for accountID, w := range state {
+			if now.Sub(w.seenAt) > wrrStateIdleTTL {
+				delete(state, accountID)
+			}
+		}
+		if len(state) == 0 {
+			delete(s.wrrState, key)
+		}
+	}
+}
+
+// usedQuotaRatio 返回账号已用额度比例（0~1），未知时视为 0（额度充足）。
+// - Anthropic OAuth/SetupToken：优先使用 5h 窗口费用 / 窗口费用阈值，否则按 5h 窗口状态粗略估算
+// - OpenAI OAuth：使用 Codex 5h / 7d 用量快照中较高者
+func (s *AccountSelectionService) usedQuotaRatio(ctx context.Context, account *Account) float64 {
+	switch {
+	case account.IsAnthropicOAuthOrSetupToken():
+		if limit := account.GetWindowCostLimit(); limit > 0 {
+			if cost, ok := lookupAccountWindowCost(ctx, s.sessionLimitCache, s.usageLogRepo, account); ok {
+				return clampRatio(cost / limit)
+			}
+		}
+		if account.SessionWindowEnd != nil && time.Now().Before(*account.SessionWindowEnd) {
+			switch account.SessionWindowStatus {
+			case "rejected":
+				return 1
+			case "allowed_warning":
+				return 0.8
+			}
+		}
+		return 0
+	case account.Platform == PlatformOpenAI && account.Extra != nil:
+		used := 0.0
+		for _, key := range []string{"codex_5h_used_percent", "codex_7d_used_percent"} {
+			if v, ok := account.Extra[key]; ok {
+				if pct := parseExtraFloat64(v); pct > used {
+					used = pct
+				}
+			}
+		}
+		return clampRatio(used / 100)
+	}
+	return 0
+}
+
+func clampRatio(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func (s *AccountSelectionService) errorRateScores(ctx context.Context, accounts []*Account) map[int64]float64 {
+	out := make(map[int64]float64, len(accounts))
+	if s.outcomeCache == nil {
+		return out
+	}
+	ids := make([]int64, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, acc.ID)
+	}
+	outcomes, err := s.outcomeCache.GetOutcomes(ctx, ids, time.Now().Add(-accountOutcomeWindow))
+	if err != nil {
+		log.Printf("[AccountSelection] get account outcomes failed: %v", err)
+		return out
+	}
+	for _, acc := range accounts {
+		out[acc.ID] = outcomes[acc.ID].ErrorRate()
+	}
+	return out
+}
+
+// sortAccountsBySelectionScores 在保持优先级顺序的前提下按策略分数稳定排序
+func sortAccountsBySelectionScores(accounts []*Account, scores map[int64]float64) {
+	if len(scores) == 0 {
+		return
+	}
+	sort.SliceStable(accounts, func(i, j int) bool {
+		a, b := accounts[i], accounts[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return scores[a.ID] < scores[b.ID]
+	})
+}
+
+// AccountSelectionCandidate 试运行中单个候选账号的各策略评分
+type AccountSelectionCandidate struct {
+	AccountID      int64              `json:"account_id"`
+	AccountName    string             `json:"account_name"`
+	Priority       int                `json:"priority"`
+	Weight         int                `json:"weight"`
+	RateMultiplier float64            `json:"rate_multiplier"`
+	Scores         map[string]float64 `json:"scores"`
+}
+
+// AccountSelectionPick 某个策略会选中的账号
+type AccountSelectionPick struct {
+	Strategy    string  `json:"strategy"`
+	AccountID   int64   `json:"account_id"`
+	AccountName string  `json:"account_name"`
+	Ranking     []int64 `json:"ranking"`
+}
+
+// AccountSelectionDryRunResult 账号选择策略试运行结果
+type AccountSelectionDryRunResult struct {
+	GroupID         int64                       `json:"group_id"`
+	Platform        string                      `json:"platform"`
+	Model           string                      `json:"model"`
+	CurrentStrategy string                      `json:"current_strategy"`
+	Candidates      []AccountSelectionCandidate `json:"candidates"`
+	Picks           []AccountSelectionPick      `json:"picks"`
+}
+
+// DryRun 展示分组内每种策略会选中哪个账号。
+// 不考虑并发槽位、粘性会话与模型路由，也不推进加权轮询状态。
+func (s *AccountSelectionService) DryRun(ctx context.Context, groupID int64, model string) (*AccountSelectionDryRunResult, error) {
+	group, err := s.groupRepo.GetByIDLite(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.accountRepo.ListSchedulableByGroupIDAndPlatform(ctx, groupID, group.Platform)
+	if err != nil {
+		return nil, fmt.Errorf("list schedulable accounts: %w", err)
+	}
+
+	candidates := make([]*Account, 0, len(accounts))
+	for i := range accounts {
+		acc := &accounts[i]
+		if !acc.IsSchedulable() {
+			continue
+		}
+		if model != "" && (!acc.IsModelSupported(model) || !acc.IsSchedulableForModel(model)) {
+			continue
+		}
+		candidates = append(candidates, acc)
+	}
+
+	result := &AccountSelectionDryRunResult{
+		GroupID:         groupID,
+		Platform:        group.Platform,
+		Model:           model,
+		CurrentStrategy: group.AccountSelectionStrategy,
+		Candidates:      make([]AccountSelectionCandidate, 0, len(candidates)),
+		Picks:           make([]AccountSelectionPick, 0, len(AccountSelectionStrategies())),
+	}
+	byID := make(map[int64]*AccountSelectionCandidate, len(candidates))
+	for _, acc := range candidates {
+		result.Candidates = append(result.Candidates, AccountSelectionCandidate{
+			AccountID:      acc.ID,
+			AccountName:    acc.Name,
+			Priority:       acc.Priority,
+			Weight:         acc.GetSelectionWeight(),
+			RateMultiplier: acc.BillingRateMultiplier(),
+			Scores:         map[string]float64{},
+		})
+	}
+	for i := range result.Candidates {
+		byID[result.Candidates[i].AccountID] = &result.Candidates[i]
+	}
+	if len(candidates) == 0 {
+		return result, nil
+	}
+
+	for _, strategy := range AccountSelectionStrategies() {
+		ordered := append([]*Account(nil), candidates...)
+		sortAccountsByPriorityAndLastUsed(ordered, false)
+		scores := s.scores(ctx, strategy, groupID, ordered)
+		sortAccountsBySelectionScores(ordered, scores)
+		for id, score := range scores {
+			if c := byID[id]; c != nil {
+				c.Scores[strategy] = score
+			}
+		}
+		pick := AccountSelectionPick{
+			Strategy:    strategy,
+			AccountID:   ordered[0].ID,
+			AccountName: ordered[0].Name,
+			Ranking:     make([]int64, 0, len(ordered)),
+		}
+		for _, acc := range ordered {
+			pick.Ranking = append(pick.Ranking, acc.ID)
+		}
+		result.Picks = append(result.Picks, pick)
+	}
+	return result, nil
+}
@@ -0,0 +1,204 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/stretchr/testify/require"
+)
+
+type accountOutcomeCacheStub struct {
+	stats map[int64]AccountOutcomeStats
+}
+
+func (c *accountOutcomeCacheStub) RecordOutcome(_ context.Context, accountID int64, success bool, _ time.Time) error {
+	if c.stats == nil {
+		c.stats = make(map[int64]AccountOutcomeStats)
+	}
+	st := c.stats[accountID]
+	if success {
+		st.Success++
+	} else {
+		st.Failure++
+	}
+	c.stats[accountID] = st
+	return nil
+}
+
+func (c *accountOutcomeCacheStub) GetOutcomes(_ context.Context, accountIDs []int64, _ time.Time) (map[int64]AccountOutcomeStats, error) {
+	out := make(map[int64]AccountOutcomeStats, len(accountIDs))
+	for _, id := range accountIDs {
+		out[id] = c.stats[id]
+	}
+	return out, nil
+}
+
+func f64(v float64) *float64 { return &v }
+
+func TestValidateAccountSelectionStrategy(t *testing.T) {
+	for _, s := range AccountSelectionStrategies() {
+		require.NoError(t, ValidateAccountSelectionStrategy(PlatformAnthropic, s))
+		require.NoError(t, ValidateAccountSelectionStrategy(PlatformOpenAI, s))
+	}
+	require.ErrorIs(t, ValidateAccountSelectionStrategy(PlatformAnthropic, "random"), ErrAccountSelectionStrategyInvalid)
+
+	// Gemini/Antigravity 调度不支持选择策略，仅允许默认策略
+	for _, platform := range []string{PlatformGemini, PlatformAntigravity} {
+		require.NoError(t, ValidateAccountSelectionStrategy(platform, AccountSelectionStrategyDefault))
+		require.ErrorIs(t, ValidateAccountSelectionStrategy(platform, AccountSelectionStrategyLeastCost), ErrAccountSelectionStrategyUnsupported)
+	}
+}
+
+// wrrPick 模拟一次调度：按分数排序后选中第一个可用账号并推进轮询状态
+func wrrPick(svc *AccountSelectionService, groupID int64, accounts []*Account, available func(*Account) bool) *Account {
+	ordered := append([]*Account(nil), accounts...)
+	sortAccountsBySelectionScores(ordered, svc.scores(context.Background(), AccountSelectionStrategyWeightedRoundRobin, groupID, ordered))
+	for _, acc := range ordered {
+		if available == nil || available(acc) {
+			svc.advanceWeightedRoundRobin(groupID, accounts, acc)
+			return acc
+		}
+	}
+	return nil
+}
+
+func TestAccountSelectionService_WeightedRoundRobin(t *testing.T) {
+	svc := NewAccountSelectionService(nil, nil, nil, nil, nil)
+	accounts := []*Account{
+		{ID: 1, Extra: map[string]any{"selection_weight": 3}},
+		{ID: 2},
+	}
+
+	picks := map[int64]int{}
+	for i := 0; i < 8; i++ {
+		picks[wrrPick(svc, 1, accounts, nil).ID]++
+	}
+	require.Equal(t, 6, picks[1])
+	require.Equal(t, 2, picks[2])
+
+	// 打分不推进轮询状态
+	first := svc.scores(context.Background(), AccountSelectionStrategyWeightedRoundRobin, 1, accounts)
+	second := svc.scores(context.Background(), AccountSelectionStrategyWeightedRoundRobin, 1, accounts)
+	require.Equal(t, first, second)
+}
+
+func TestAccountSelectionService_WeightedRoundRobinPerPriorityTier(t *testing.T) {
+	svc := NewAccountSelectionService(nil, nil, nil, nil, nil)
+	accounts := []*Account{
+		{ID: 1, Priority: 0, Extra: map[string]any{"selection_weight": 3}},
+		{ID: 2, Priority: 0},
+		{ID: 3, Priority: 1},
+		{ID: 4, Priority: 1, Extra: map[string]any{"selection_weight": 2}},
+	}
+
+	// 高优先级层每隔一次满载，槽位落到低优先级层；各层仍应按各自权重分配
+	picks := map[int64]int{}
+	for i := 0; i < 24; i++ {
+		tierFull := i%2 == 1
+		acc := wrrPick(svc, 1, accounts, func(a *Account) bool { return !tierFull || a.Priority == 1 })
+		picks[acc.ID]++
+	}
+	require.Equal(t, 9, picks[1])
+	require.Equal(t, 3, picks[2])
+	require.Equal(t, 4, picks[3])
+	require.Equal(t, 8, picks[4])
+}
+
+func TestAccountSelectionService_WeightedRoundRobinPrunesIdleState(t *testing.T) {
+	svc := NewAccountSelectionService(nil, nil, nil, nil, nil)
+	accounts := []*Account{{ID: 1}, {ID: 2}}
+	wrrPick(svc, 1, accounts, nil)
+	wrrPick(svc, 2, accounts, nil)
+
+	// 分组 1 已删除、分组 2 的账号 2 已删除：长时间未参与调度
+	group1 := wrrTierKey{groupID: 1}
+	group2 := wrrTierKey{groupID: 2}
+	stale := time.Now().Add(-2 * wrrStateIdleTTL)
+	for id, w := range svc.wrrState[group1] {
+		w.seenAt = stale
+		svc.wrrState[group1][id] = w
+	}
+	w := svc.wrrState[group2][2]
+	w.seenAt = stale
+	svc.wrrState[group2][2] = w
+	svc.wrrPrunedAt = time.Time{}
+
+	wrrPick(svc, 2, accounts[:1], nil)
+	require.NotContains(t, svc.wrrState, group1)
+	require.Contains(t, svc.wrrState[group2], int64(1))
+	require.NotContains(t, svc.wrrState[group2], int64(2))
+}
+
+func TestAccountSelectionService_ScoresRespectPriority(t *testing.T) {
+	svc := NewAccountSelectionService(nil, nil, nil, nil, nil)
+	accounts := []*Account{
+		{ID: 1, Priority: 1, RateMultiplier: f64(2)},
+		{ID: 2, Priority: 1, RateMultiplier: f64(0.5)},
+		{ID: 3, Priority: 0, RateMultiplier: f64(5)},
+	}
+	sortAccountsBySelectionScores(accounts, svc.scores(context.Background(), AccountSelectionStrategyLeastCost, 0, accounts))
+	require.Equal(t, []int64{3, 2, 1}, []int64{accounts[0].ID, accounts[1].ID, accounts[2].ID})
+}
+
+func TestAccountSelectionService_MostRemainingQuota(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	svc := NewAccountSelectionService(nil, nil, nil, nil, nil)
+	accounts := []*Account{
+		{ID: 1, Platform: PlatformAnthropic, Type: AccountTypeOAuth, SessionWindowEnd: &future, SessionWindowStatus: "allowed_warning"},
+		{ID: 2, Platform: PlatformOpenAI, Type: AccountTypeOAuth, Extra: map[string]any{"codex_5h_used_percent": 30.0, "codex_7d_used_percent": 60.0}},
+		{ID: 3, Platform: PlatformOpenAI, Type: AccountTypeOAuth, Extra: map[string]any{"codex_5h_used_percent": 10.0}},
+	}
+	scores := svc.scores(context.Background(), AccountSelectionStrategyMostRemainingQuota, 0, accounts)
+	require.InDelta(t, 0.8, scores[1], 1e-9)
+	require.InDelta(t, 0.6, scores[2], 1e-9)
+	require.InDelta(t, 0.1, scores[3], 1e-9)
+}
+
+func TestAccountSelectionService_ErrorRatePenalized(t *testing.T) {
+	outcomes := &accountOutcomeCacheStub{stats: map[int64]AccountOutcomeStats{
+		1: {Success: 5, Failure: 5},
+		2: {Success: 10},
+		3: {Failure: 2}, // 样本不足，不惩罚
+	}}
+	svc := NewAccountSelectionService(nil, nil, nil, nil, outcomes)
+	accounts := []*Account{{ID: 1}, {ID: 2}, {ID: 3}}
+	scores := svc.scores(context.Background(), AccountSelectionStrategyErrorRatePenalized, 0, accounts)
+	require.InDelta(t, 0.5, scores[1], 1e-9)
+	require.Zero(t, scores[2])
+	require.Zero(t, scores[3])
+}
+
+func TestGatewayService_SelectAccountWithLoadAwareness_LeastCostStrategy(t *testing.T) {
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, RateMultiplier: f64(1.5)},
+			{ID: 2, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, RateMultiplier: f64(0.8)},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	cfg := testConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+	svc := &GatewayService{
+		accountRepo:        repo,
+		cache:              &mockGatewayCacheForPlatform{},
+		cfg:                cfg,
+		concurrencyService: NewConcurrencyService(&mockConcurrencyCache{}),
+		accountSelection:   NewAccountSelectionService(nil, nil, nil, nil, nil),
+	}
+
+	groupID := int64(10)
+	group := &Group{ID: groupID, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true, AccountSelectionStrategy: AccountSelectionStrategyLeastCost}
+	ctx := context.WithValue(context.Background(), ctxkey.Group, group)
+
+	result, err := svc.SelectAccountWithLoadAwareness(ctx, &groupID, "", "", nil, "")
+	require.NoError(t, err)
+	require.True(t, result.Acquired)
+	require.Equal(t, int64(2), result.Account.ID)
+}
@@ -101,6 +101,8 @@ type CreateGroupInput struct {
 	DailyLimitUSD    *float64 // 日限额 (USD)
 	WeeklyLimitUSD   *float64 // 周限额 (USD)
 	MonthlyLimitUSD  *float64 // 月限额 (USD)
+	// 订阅续期价格（负数或 nil 表示不支持自助续期/变更）
+	SubscriptionPrice *float64
 	// 图片生成计费配置（仅 antigravity 平台使用）
 	ImagePrice1K    *float64
 	ImagePrice2K    *float64
@@ -110,6 +112,20 @@ type CreateGroupInput struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool // 是否启用模型路由
+	// 账号选择策略（空表示默认）
+	AccountSelectionStrategy string
+	// 图片生成模型白名单（为空表示不限制）
+	ImageModels []string
+	// 内容策略命中动作（空表示不检查）
+	ContentPolicyAction string
+	// 请求改写规则（为空表示不改写）
+	RequestRewriteRules []RequestRewriteRule
+	// 模型别名与访问策略（为空表示不限制）
+	ModelAliases  map[string]string
+	AllowedModels []string
+	DeniedModels  []string
+	// 订阅 token/请求数限额（为空表示不限制）
+	UsageLimits []SubscriptionUsageLimit
 }
 
 type UpdateGroupInput struct {
@@ -123,6 +139,8 @@ type UpdateGroupInput struct {
 	DailyLimitUSD    *float64 // 日限额 (USD)
 	WeeklyLimitUSD   *float64 // 周限额 (USD)
 	MonthlyLimitUSD  *float64 // 月限额 (USD)
+	// 订阅续期价格（nil 表示不修改，负数表示清除）
+	SubscriptionPrice *float64
 	// 图片生成计费配置（仅 antigravity 平台使用）
 	ImagePrice1K    *float64
 	ImagePrice2K    *float64
@@ -132,6 +150,20 @@ type UpdateGroupInput struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled *bool // 是否启用模型路由
+	// 账号选择策略（传入空字符串恢复默认）
+	AccountSelectionStrategy *string
+	// 图片生成模型白名单（nil 表示不修改，空数组表示清除限制）
+	ImageModels *[]string
+	// 内容策略命中动作（传入空字符串关闭检查）
+	ContentPolicyAction *string
+	// 请求改写规则（nil 表示不修改，空数组表示清除）
+	RequestRewriteRules *[]RequestRewriteRule
+	// 模型别名与访问策略（nil 表示不修改，空值表示清除）
+	ModelAliases  *map[string]string
+	AllowedModels *[]string
+	DeniedModels  *[]string
+	// 订阅 token/请求数限额（nil 表示不修改，空数组表示清除）
+	UsageLimits *[]SubscriptionUsageLimit
 }
 
 type CreateAccountInput struct {
@@ -142,6 +174,7 @@ type CreateAccountInput struct {
 	Credentials        map[string]any
 	Extra              map[string]any
 	ProxyID            *int64
+	ProxyPoolID        *int64 // 与 ProxyID 互斥
 	Concurrency        int
 	Priority           int
 	RateMultiplier     *float64 // 账号计费倍率（>=0，允许 0）
@@ -160,6 +193,7 @@ type UpdateAccountInput struct {
 	Credentials           map[string]any
 	Extra                 map[string]any
 	ProxyID               *int64
+	ProxyPoolID           *int64   // 0 表示清除；设置代理池会清除 ProxyID，反之亦然
 	Concurrency           *int     // 使用指针区分"未提供"和"设置为0"
 	Priority              *int     // 使用指针区分"未提供"和"设置为0"
 	RateMultiplier        *float64 // 账号计费倍率（>=0，允许 0）
@@ -175,6 +209,7 @@ type BulkUpdateAccountsInput struct {
 	AccountIDs     []int64
 	Name           string
 	ProxyID        *int64
+	ProxyPoolID    *int64
 	Concurrency    *int
 	Priority       *int
 	RateMultiplier *float64 // 账号计费倍率（>=0，允许 0）
@@ -279,6 +314,7 @@ type adminServiceImpl struct {
 	proxyProber          ProxyExitInfoProber
 	proxyLatencyCache    ProxyLatencyCache
 	authCacheInvalidator APIKeyAuthCacheInvalidator
+	sessionService       *SessionService
 }
 
 // NewAdminService creates a new AdminService
@@ -293,6 +329,7 @@ func NewAdminService(
 	proxyProber ProxyExitInfoProber,
 	proxyLatencyCache ProxyLatencyCache,
 	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	sessionService *SessionService,
 ) AdminService {
 	return &adminServiceImpl{
 		userRepo:             userRepo,
@@ -305,6 +342,7 @@ func NewAdminService(
 		proxyProber:          proxyProber,
 		proxyLatencyCache:    proxyLatencyCache,
 		authCacheInvalidator: authCacheInvalidator,
+		sessionService:       sessionService,
 	}
 }
 
@@ -393,6 +431,9 @@ func (s *adminServiceImpl) UpdateUser(ctx context.Context, id int64, input *Upda
 			s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, user.ID)
 		}
 	}
+	if user.Status == StatusDisabled && oldStatus != StatusDisabled {
+		s.sessionService.revokeAllBestEffort(ctx, user.ID, "", SessionRevokeReasonUserDisabled)
+	}
 
 	concurrencyDiff := user.Concurrency - oldConcurrency
 	if concurrencyDiff != 0 {
@@ -571,24 +612,51 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 			return nil, err
 		}
 	}
+	if err := ValidateAccountSelectionStrategy(platform, input.AccountSelectionStrategy); err != nil {
+		return nil, err
+	}
+	if err := ValidateContentPolicyAction(input.ContentPolicyAction); err != nil {
+		return nil, err
+	}
+	if err := ValidateRequestRewriteRules(platform, input.RequestRewriteRules); err != nil {
+		return nil, err
+	}
+	if err := ValidateSubscriptionUsageLimits(subscriptionType, input.UsageLimits); err != nil {
+		return nil, err
+	}
+	modelAliases := normalizeModelAliases(input.ModelAliases)
+	allowedModels := normalizeModelPatterns(input.AllowedModels)
+	deniedModels := normalizeModelPatterns(input.DeniedModels)
+	if err := ValidateGroupModelPolicy(modelAliases, allowedModels, deniedModels); err != nil {
+		return nil, err
+	}
 
 	group := &Group{
-		Name:             input.Name,
-		Description:      input.Description,
-		Platform:         platform,
-		RateMultiplier:   input.RateMultiplier,
-		IsExclusive:      input.IsExclusive,
-		Status:           StatusActive,
-		SubscriptionType: subscriptionType,
-		DailyLimitUSD:    dailyLimit,
-		WeeklyLimitUSD:   weeklyLimit,
-		MonthlyLimitUSD:  monthlyLimit,
-		ImagePrice1K:     imagePrice1K,
-		ImagePrice2K:     imagePrice2K,
-		ImagePrice4K:     imagePrice4K,
-		ClaudeCodeOnly:   input.ClaudeCodeOnly,
-		FallbackGroupID:  input.FallbackGroupID,
-		ModelRouting:     input.ModelRouting,
+		Name:                     input.Name,
+		Description:              input.Description,
+		Platform:                 platform,
+		RateMultiplier:           input.RateMultiplier,
+		IsExclusive:              input.IsExclusive,
+		Status:                   StatusActive,
+		SubscriptionType:         subscriptionType,
+		DailyLimitUSD:            dailyLimit,
+		WeeklyLimitUSD:           weeklyLimit,
+		MonthlyLimitUSD:          monthlyLimit,
+		SubscriptionPrice:        normalizePrice(input.SubscriptionPrice),
+		ImagePrice1K:             imagePrice1K,
+		ImagePrice2K:             imagePrice2K,
+		ImagePrice4K:             imagePrice4K,
+		ClaudeCodeOnly:           input.ClaudeCodeOnly,
+		FallbackGroupID:          input.FallbackGroupID,
+		ModelRouting:             input.ModelRouting,
+		AccountSelectionStrategy: input.AccountSelectionStrategy,
+		ImageModels:              normalizeModelPatterns(input.ImageModels),
+		ContentPolicyAction:      input.ContentPolicyAction,
+		RequestRewriteRules:      input.RequestRewriteRules,
+		ModelAliases:             modelAliases,
+		AllowedModels:            allowedModels,
+		DeniedModels:             deniedModels,
+		UsageLimits:              input.UsageLimits,
 	}
 	if err := s.groupRepo.Create(ctx, group); err != nil {
 		return nil, err
@@ -689,6 +757,9 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 	if input.MonthlyLimitUSD != nil {
 		group.MonthlyLimitUSD = normalizeLimit(input.MonthlyLimitUSD)
 	}
+	if input.SubscriptionPrice != nil {
+		group.SubscriptionPrice = normalizePrice(input.SubscriptionPrice)
+	}
 	// 图片生成计费配置：负数表示清除（使用默认价格）
 	if input.ImagePrice1K != nil {
 		group.ImagePrice1K = normalizePrice(input.ImagePrice1K)
@@ -725,6 +796,65 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 		group.ModelRoutingEnabled = *input.ModelRoutingEnabled
 	}
 
+	// 账号选择策略
+	if input.AccountSelectionStrategy != nil {
+		group.AccountSelectionStrategy = *input.AccountSelectionStrategy
+	}
+	if input.AccountSelectionStrategy != nil || input.Platform != "" {
+		if err := ValidateAccountSelectionStrategy(group.Platform, group.AccountSelectionStrategy); err != nil {
+			return nil, err
+		}
+	}
+
+	// 图片生成模型白名单
+	if input.ImageModels != nil {
+		group.ImageModels = normalizeModelPatterns(*input.ImageModels)
+	}
+
+	// 内容策略命中动作
+	if input.ContentPolicyAction != nil {
+		if err := ValidateContentPolicyAction(*input.ContentPolicyAction); err != nil {
+			return nil, err
+		}
+		group.ContentPolicyAction = *input.ContentPolicyAction
+	}
+
+	// 请求改写规则
+	if input.RequestRewriteRules != nil {
+		group.RequestRewriteRules = *input.RequestRewriteRules
+	}
+	if input.RequestRewriteRules != nil || input.Platform != "" {
+		if err := ValidateRequestRewriteRules(group.Platform, group.RequestRewriteRules); err != nil {
+			return nil, err
+		}
+	}
+
+	// 订阅 token/请求数限额
+	if input.UsageLimits != nil {
+		group.UsageLimits = *input.UsageLimits
+	}
+	if input.UsageLimits != nil || input.SubscriptionType != "" {
+		if err := ValidateSubscriptionUsageLimits(group.SubscriptionType, group.UsageLimits); err != nil {
+			return nil, err
+		}
+	}
+
+	// 模型别名与访问策略（合并后整体校验）
+	if input.ModelAliases != nil || input.AllowedModels != nil || input.DeniedModels != nil {
+		if input.ModelAliases != nil {
+			group.ModelAliases = normalizeModelAliases(*input.ModelAliases)
+		}
+		if input.AllowedModels != nil {
+			group.AllowedModels = normalizeModelPatterns(*input.AllowedModels)
+		}
+		if input.DeniedModels != nil {
+			group.DeniedModels = normalizeModelPatterns(*input.DeniedModels)
+		}
+		if err := ValidateGroupModelPolicy(group.ModelAliases, group.AllowedModels, group.DeniedModels); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.groupRepo.Update(ctx, group); err != nil {
 		return nil, err
 	}
@@ -807,6 +937,9 @@ func (s *adminServiceImpl) GetAccountsByIDs(ctx context.Context, ids []int64) ([
 }
 
 func (s *adminServiceImpl) CreateAccount(ctx context.Context, input *CreateAccountInput) (*Account, error) {
+	if err := validateAccountProxyBinding(input.ProxyID, input.ProxyPoolID); err != nil {
+		return nil, err
+	}
 	// 绑定分组
 	groupIDs := input.GroupIDs
 	// 如果没有指定分组,自动绑定对应平台的默认分组
@@ -837,7 +970,8 @@ func (s *adminServiceImpl) CreateAccount(ctx context.Context, input *CreateAccou
 		Type:        input.Type,
 		Credentials: input.Credentials,
 		Extra:       input.Extra,
-		ProxyID:     input.ProxyID,
+		ProxyID:     normalizeOptionalID(input.ProxyID),
+		ProxyPoolID: normalizeOptionalID(input.ProxyPoolID),
 		Concurrency: input.Concurrency,
 		Priority:    input.Priority,
 		Status:      StatusActive,
@@ -873,6 +1007,9 @@ func (s *adminServiceImpl) CreateAccount(ctx context.Context, input *CreateAccou
 }
 
 func (s *adminServiceImpl) UpdateAccount(ctx context.Context, id int64, input *UpdateAccountInput) (*Account, error) {
+	if err := validateAccountProxyBinding(input.ProxyID, input.ProxyPoolID); err != nil {
+		return nil, err
+	}
 	account, err := s.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -901,6 +1038,16 @@ func (s *adminServiceImpl) UpdateAccount(ctx context.Context, id int64, input *U
 			account.ProxyID = input.ProxyID
 		}
 		account.Proxy = nil // 清除关联对象，防止 GORM Save 时根据 Proxy.ID 覆盖 ProxyID
+		if account.ProxyID != nil {
+			account.ProxyPoolID = nil
+		}
+	}
+	if input.ProxyPoolID != nil {
+		account.ProxyPoolID = normalizeOptionalID(input.ProxyPoolID)
+		if account.ProxyPoolID != nil {
+			account.ProxyID = nil
+			account.Proxy = nil
+		}
 	}
 	// 只在指针非 nil 时更新 Concurrency（支持设置为 0）
 	if input.Concurrency != nil {
@@ -994,6 +1141,9 @@ func (s *adminServiceImpl) BulkUpdateAccounts(ctx context.Context, input *BulkUp
 			return nil, errors.New("rate_multiplier must be >= 0")
 		}
 	}
+	if err := validateAccountProxyBinding(input.ProxyID, input.ProxyPoolID); err != nil {
+		return nil, err
+	}
 
 	// Prepare bulk updates for columns and JSONB fields.
 	repoUpdates := AccountBulkUpdate{
@@ -1003,9 +1153,7 @@ func (s *adminServiceImpl) BulkUpdateAccounts(ctx context.Context, input *BulkUp
 	if input.Name != "" {
 		repoUpdates.Name = &input.Name
 	}
-	if input.ProxyID != nil {
-		repoUpdates.ProxyID = input.ProxyID
-	}
+	applyProxyBindingUpdate(&repoUpdates, input.ProxyID, input.ProxyPoolID)
 	if input.Concurrency != nil {
 		repoUpdates.Concurrency = input.Concurrency
 	}
@@ -1494,6 +1642,7 @@ func (s *adminServiceImpl) attachProxyLatency(ctx context.Context, proxies []Pro
 		proxies[i].CountryCode = info.CountryCode
 		proxies[i].Region = info.Region
 		proxies[i].City = info.City
+		proxies[i].ConsecutiveFailures = info.ConsecutiveFailures
 	}
 }
 
@@ -1501,11 +1650,50 @@ func (s *adminServiceImpl) saveProxyLatency(ctx context.Context, proxyID int64,
 	if s.proxyLatencyCache == nil || info == nil {
 		return
 	}
+	// 累计连续失败次数，避免手动测试覆盖代理池的失效判定
+	var prev *ProxyLatencyInfo
+	if cached, err := s.proxyLatencyCache.GetProxyLatencies(ctx, []int64{proxyID}); err == nil {
+		prev = cached[proxyID]
+	}
+	applyProxyProbeResult(prev, info)
 	if err := s.proxyLatencyCache.SetProxyLatency(ctx, proxyID, info); err != nil {
 		log.Printf("Warning: store proxy latency cache failed: %v", err)
 	}
 }
 
+// validateAccountProxyBinding 账户不能同时绑定代理和代理池
+func validateAccountProxyBinding(proxyID, proxyPoolID *int64) error {
+	if normalizeOptionalID(proxyID) != nil && normalizeOptionalID(proxyPoolID) != nil {
+		return ErrAccountProxyConflict
+	}
+	return nil
+}
+
+// applyProxyBindingUpdate 写入代理/代理池绑定变更；绑定其中一个时清除另一个
+func applyProxyBindingUpdate(updates *AccountBulkUpdate, proxyID, proxyPoolID *int64) {
+	none := int64(0)
+	if proxyID != nil {
+		updates.ProxyID = proxyID
+		if *proxyID > 0 {
+			updates.ProxyPoolID = &none
+		}
+	}
+	if proxyPoolID != nil {
+		updates.ProxyPoolID = proxyPoolID
+		if *proxyPoolID > 0 {
+			updates.ProxyID = &none
+		}
+	}
+}
+
+// normalizeOptionalID 将 nil/非正数 ID 统一为 nil
+func normalizeOptionalID(id *int64) *int64 {
+	if id == nil || *id <= 0 {
+		return nil
+	}
+	return id
+}
+
 // getAccountPlatform 根据账号 platform 判断混合渠道检查用的平台标识
 func getAccountPlatform(accountPlatform string) string {
 	switch strings.ToLower(strings.TrimSpace(accountPlatform)) {
@@ -78,6 +78,10 @@ func (s *userRepoStub) DeductBalance(ctx context.Context, id int64, amount float
 	panic("unexpected DeductBalance call")
 }
 
+func (s *userRepoStub) DeductBalanceIfSufficient(ctx context.Context, id int64, amount float64) error {
+	panic("unexpected DeductBalanceIfSufficient call")
+}
+
 func (s *userRepoStub) UpdateConcurrency(ctx context.Context, id int64, amount int) error {
 	panic("unexpected UpdateConcurrency call")
 }
@@ -315,11 +319,31 @@ func (s *billingCacheStub) UpdateSubscriptionUsage(ctx context.Context, userID,
 	panic("unexpected UpdateSubscriptionUsage call")
 }
 
+func (s *billingCacheStub) UpdateSubscriptionCounters(ctx context.Context, userID, groupID int64, delta SubscriptionUsageCounters) error {
+	panic("unexpected UpdateSubscriptionCounters call")
+}
+
 func (s *billingCacheStub) InvalidateSubscriptionCache(ctx context.Context, userID, groupID int64) error {
 	s.invalidations <- subscriptionInvalidateCall{userID: userID, groupID: groupID}
 	return nil
 }
 
+func (s *billingCacheStub) ReserveBalanceHold(ctx context.Context, userID int64, holdID string, amount float64, expiresAt time.Time) (BillingHoldStatus, error) {
+	panic("unexpected ReserveBalanceHold call")
+}
+
+func (s *billingCacheStub) SettleBalanceHold(ctx context.Context, userID int64, holdID string, cost float64) error {
+	panic("unexpected SettleBalanceHold call")
+}
+
+func (s *billingCacheStub) ReserveSubscriptionHold(ctx context.Context, userID, groupID int64, holdID string, amount float64, expiresAt time.Time, limits SubscriptionHoldLimits) (BillingHoldStatus, error) {
+	panic("unexpected ReserveSubscriptionHold call")
+}
+
+func (s *billingCacheStub) SettleSubscriptionHold(ctx context.Context, userID, groupID int64, holdID string, cost float64) error {
+	panic("unexpected SettleSubscriptionHold call")
+}
+
 func waitForInvalidations(t *testing.T, ch <-chan subscriptionInvalidateCall, expected int) []subscriptionInvalidateCall {
 	t.Helper()
 	calls := make([]subscriptionInvalidateCall, 0, expected)
@@ -8,7 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	mathrand "math/rand"
 	"net"
 	"net/http"
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -34,7 +35,6 @@ const antigravityScopeRateLimitEnv = "GATEWAY_ANTIGRAVITY_429_SCOPE_LIMIT"
 // antigravityRetryLoopParams 重试循环的参数
 type antigravityRetryLoopParams struct {
 	ctx            context.Context
-	prefix         string
 	account        *Account
 	proxyURL       string
 	accessToken    string
@@ -44,7 +44,7 @@ type antigravityRetryLoopParams struct {
 	c              *gin.Context
 	httpUpstream   HTTPUpstream
 	settingService *SettingService
-	handleError    func(ctx context.Context, prefix string, account *Account, statusCode int, headers http.Header, body []byte, quotaScope AntigravityQuotaScope)
+	handleError    func(ctx context.Context, account *Account, statusCode int, headers http.Header, body []byte, quotaScope AntigravityQuotaScope)
 }
 
 // antigravityRetryLoopResult 重试循环的结果
@@ -79,7 +79,7 @@ urlFallbackLoop:
 		for attempt := 1; attempt <= antigravityMaxRetries; attempt++ {
 			select {
 			case <-p.ctx.Done():
-				log.Printf("%s status=context_canceled error=%v", p.prefix, p.ctx.Err())
+				slog.WarnContext(p.ctx, "antigravity_request_canceled", "account_id", p.account.ID, "error", p.ctx.Err())
 				return nil, p.ctx.Err()
 			default:
 			}
@@ -106,18 +106,18 @@ urlFallbackLoop:
 					Message:            safeErr,
 				})
 				if shouldAntigravityFallbackToNextURL(err, 0) && urlIdx < len(availableURLs)-1 {
-					log.Printf("%s URL fallback (connection error): %s -> %s", p.prefix, baseURL, availableURLs[urlIdx+1])
+					slog.WarnContext(p.ctx, "antigravity_url_fallback", "account_id", p.account.ID, "reason", "connection_error", "from", baseURL, "to", availableURLs[urlIdx+1])
 					continue urlFallbackLoop
 				}
 				if attempt < antigravityMaxRetries {
-					log.Printf("%s status=request_failed retry=%d/%d error=%v", p.prefix, attempt, antigravityMaxRetries, err)
+					slog.WarnContext(p.ctx, "antigravity_request_failed", "account_id", p.account.ID, "attempt", attempt, "max_attempts", antigravityMaxRetries, "error", err)
 					if !sleepAntigravityBackoffWithContext(p.ctx, attempt) {
-						log.Printf("%s status=context_canceled_during_backoff", p.prefix)
+						slog.WarnContext(p.ctx, "antigravity_request_canceled", "account_id", p.account.ID, "stage", "backoff")
 						return nil, p.ctx.Err()
 					}
 					continue
 				}
-				log.Printf("%s status=request_failed retries_exhausted error=%v", p.prefix, err)
+				slog.WarnContext(p.ctx, "antigravity_request_retries_exhausted", "account_id", p.account.ID, "error", err)
 				setOpsUpstreamError(p.c, 0, safeErr, "")
 				return nil, fmt.Errorf("upstream request failed after retries: %w", err)
 			}
@@ -129,7 +129,7 @@ urlFallbackLoop:
 
 				// "Resource has been exhausted" 是 URL 级别限流，切换 URL
 				if isURLLevelRateLimit(respBody) && urlIdx < len(availableURLs)-1 {
-					log.Printf("%s URL fallback (429): %s -> %s", p.prefix, baseURL, availableURLs[urlIdx+1])
+					slog.WarnContext(p.ctx, "antigravity_url_fallback", "account_id", p.account.ID, "reason", "429", "from", baseURL, "to", availableURLs[urlIdx+1])
 					continue urlFallbackLoop
 				}
 
@@ -147,17 +147,17 @@ urlFallbackLoop:
 						Message:            upstreamMsg,
 						Detail:             getUpstreamDetail(respBody),
 					})
-					log.Printf("%s status=429 retry=%d/%d body=%s", p.prefix, attempt, antigravityMaxRetries, truncateForLog(respBody, 200))
+					slog.WarnContext(p.ctx, "antigravity_upstream_429_retry", "account_id", p.account.ID, "attempt", attempt, "max_attempts", antigravityMaxRetries, "body", truncateForLog(respBody, 200))
 					if !sleepAntigravityBackoffWithContext(p.ctx, attempt) {
-						log.Printf("%s status=context_canceled_during_backoff", p.prefix)
+						slog.WarnContext(p.ctx, "antigravity_request_canceled", "account_id", p.account.ID, "stage", "backoff")
 						return nil, p.ctx.Err()
 					}
 					continue
 				}
 
 				// 重试用尽，标记账户限流
-				p.handleError(p.ctx, p.prefix, p.account, resp.StatusCode, resp.Header, respBody, p.quotaScope)
-				log.Printf("%s status=429 rate_limited base_url=%s body=%s", p.prefix, baseURL, truncateForLog(respBody, 200))
+				p.handleError(p.ctx, p.account, resp.StatusCode, resp.Header, respBody, p.quotaScope)
+				slog.WarnContext(p.ctx, "antigravity_upstream_rate_limited", "account_id", p.account.ID, "base_url", baseURL, "body", truncateForLog(respBody, 200))
 				resp = &http.Response{
 					StatusCode: resp.StatusCode,
 					Header:     resp.Header.Clone(),
@@ -184,9 +184,9 @@ urlFallbackLoop:
 						Message:            upstreamMsg,
 						Detail:             getUpstreamDetail(respBody),
 					})
-					log.Printf("%s status=%d retry=%d/%d body=%s", p.prefix, resp.StatusCode, attempt, antigravityMaxRetries, truncateForLog(respBody, 500))
+					slog.WarnContext(p.ctx, "antigravity_upstream_error_retry", "account_id", p.account.ID, "status", resp.StatusCode, "attempt", attempt, "max_attempts", antigravityMaxRetries, "body", truncateForLog(respBody, 500))
 					if !sleepAntigravityBackoffWithContext(p.ctx, attempt) {
-						log.Printf("%s status=context_canceled_during_backoff", p.prefix)
+						slog.WarnContext(p.ctx, "antigravity_request_canceled", "account_id", p.account.ID, "stage", "backoff")
 						return nil, p.ctx.Err()
 					}
 					continue
@@ -264,14 +264,6 @@ func getSessionID(c *gin.Context) string {
 	return c.GetHeader("session_id")
 }
 
-// logPrefix 生成统一的日志前缀
-func logPrefix(sessionID, accountName string) string {
-	if sessionID != "" {
-		return fmt.Sprintf("[antigravity-Forward] session=%s account=%s", sessionID, accountName)
-	}
-	return fmt.Sprintf("[antigravity-Forward] account=%s", accountName)
-}
-
 // Antigravity 直接支持的模型（精确匹配透传）
 var antigravitySupportedModels = map[string]bool{
 	"claude-opus-4-5-thinking":   true,
@@ -412,10 +404,7 @@ func (s *AntigravityGatewayService) TestConnection(ctx context.Context, account
 	}
 
 	// 代理 URL
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.UpstreamProxyURL()
 
 	// URL fallback 循环
 	availableURLs := antigravity.DefaultURLAvailability.GetAvailableURLs()
@@ -433,14 +422,14 @@ func (s *AntigravityGatewayService) TestConnection(ctx context.Context, account
 		}
 
 		// 调试日志：Test 请求信息
-		log.Printf("[antigravity-Test] account=%s request_size=%d url=%s", account.Name, len(requestBody), req.URL.String())
+		slog.InfoContext(ctx, "antigravity_test_connection", "account_id", account.ID, "request_size", len(requestBody), "url", req.URL.String())
 
 		// 发送请求
 		resp, err := s.httpUpstream.Do(req, proxyURL, account.ID, account.Concurrency)
 		if err != nil {
 			lastErr = fmt.Errorf("请求失败: %w", err)
 			if shouldAntigravityFallbackToNextURL(err, 0) && urlIdx < len(availableURLs)-1 {
-				log.Printf("[antigravity-Test] URL fallback: %s -> %s", baseURL, availableURLs[urlIdx+1])
+				slog.WarnContext(ctx, "antigravity_test_url_fallback", "account_id", account.ID, "reason", "connection_error", "from", baseURL, "to", availableURLs[urlIdx+1])
 				continue
 			}
 			return nil, lastErr
@@ -455,7 +444,7 @@ func (s *AntigravityGatewayService) TestConnection(ctx context.Context, account
 
 		// 检查是否需要 URL 降级
 		if shouldAntigravityFallbackToNextURL(nil, resp.StatusCode) && urlIdx < len(availableURLs)-1 {
-			log.Printf("[antigravity-Test] URL fallback (HTTP %d): %s -> %s", resp.StatusCode, baseURL, availableURLs[urlIdx+1])
+			slog.WarnContext(ctx, "antigravity_test_url_fallback", "account_id", account.ID, "status", resp.StatusCode, "from", baseURL, "to", availableURLs[urlIdx+1])
 			continue
 		}
 
@@ -696,8 +685,9 @@ func isModelNotFoundError(statusCode int, body []byte) bool {
 // Forward 转发 Claude 协议请求（Claude → Gemini 转换）
 func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context, account *Account, body []byte) (*ForwardResult, error) {
 	startTime := time.Now()
-	sessionID := getSessionID(c)
-	prefix := logPrefix(sessionID, account.Name)
+	if sessionID := getSessionID(c); sessionID != "" {
+		ctx = logger.With(ctx, "session", sessionID)
+	}
 
 	// 解析 Claude 请求
 	var claudeReq antigravity.ClaudeRequest
@@ -725,10 +715,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 	projectID := strings.TrimSpace(account.GetCredential("project_id"))
 
 	// 代理 URL
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.UpstreamProxyURL()
 
 	// 获取转换选项
 	// Antigravity 上游要求必须包含身份提示词，否则会返回 429
@@ -748,7 +735,6 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 	// 执行带重试的请求
 	result, err := antigravityRetryLoop(antigravityRetryLoopParams{
 		ctx:            ctx,
-		prefix:         prefix,
 		account:        account,
 		proxyURL:       proxyURL,
 		accessToken:    accessToken,
@@ -816,7 +802,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 					continue
 				}
 
-				log.Printf("Antigravity account %d: detected signature-related 400, retrying once (%s)", account.ID, stage.name)
+				slog.WarnContext(ctx, "antigravity_signature_retry", "account_id", account.ID, "stage", stage.name)
 
 				retryGeminiBody, txErr := antigravity.TransformClaudeToGeminiWithOptions(&retryClaudeReq, projectID, mappedModel, s.getClaudeTransformOptions(ctx))
 				if txErr != nil {
@@ -824,7 +810,6 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 				}
 				retryResult, retryErr := antigravityRetryLoop(antigravityRetryLoopParams{
 					ctx:            ctx,
-					prefix:         prefix,
 					account:        account,
 					proxyURL:       proxyURL,
 					accessToken:    accessToken,
@@ -845,7 +830,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 						Kind:               "signature_retry_request_error",
 						Message:            sanitizeUpstreamErrorMessage(retryErr.Error()),
 					})
-					log.Printf("Antigravity account %d: signature retry request failed (%s): %v", account.ID, stage.name, retryErr)
+					slog.WarnContext(ctx, "antigravity_signature_retry_failed", "account_id", account.ID, "stage", stage.name, "error", retryErr)
 					continue
 				}
 
@@ -864,7 +849,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 					if retryResp.Request != nil && retryResp.Request.URL != nil {
 						retryBaseURL = retryResp.Request.URL.Scheme + "://" + retryResp.Request.URL.Host
 					}
-					log.Printf("%s status=429 rate_limited base_url=%s retry_stage=%s body=%s", prefix, retryBaseURL, stage.name, truncateForLog(retryBody, 200))
+					slog.WarnContext(ctx, "antigravity_upstream_rate_limited", "account_id", account.ID, "base_url", retryBaseURL, "retry_stage", stage.name, "body", truncateForLog(retryBody, 200))
 				}
 				kind := "signature_retry"
 				if strings.TrimSpace(stage.name) != "" {
@@ -910,7 +895,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 
 		// 处理错误响应（重试后仍失败或不触发重试）
 		if resp.StatusCode >= 400 {
-			s.handleUpstreamError(ctx, prefix, account, resp.StatusCode, resp.Header, respBody, quotaScope)
+			s.handleUpstreamError(ctx, account, resp.StatusCode, resp.Header, respBody, quotaScope)
 
 			if s.shouldFailoverUpstreamError(resp.StatusCode) {
 				upstreamMsg := strings.TrimSpace(extractAntigravityErrorMessage(respBody))
@@ -952,7 +937,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 		// 客户端要求流式，直接透传转换
 		streamRes, err := s.handleClaudeStreamingResponse(c, resp, startTime, originalModel)
 		if err != nil {
-			log.Printf("%s status=stream_error error=%v", prefix, err)
+			slog.WarnContext(ctx, "antigravity_stream_error", "account_id", account.ID, "error", err)
 			return nil, err
 		}
 		usage = streamRes.usage
@@ -961,7 +946,7 @@ func (s *AntigravityGatewayService) Forward(ctx context.Context, c *gin.Context,
 		// 客户端要求非流式，收集流式响应后转换返回
 		streamRes, err := s.handleClaudeStreamToNonStreaming(c, resp, startTime, originalModel)
 		if err != nil {
-			log.Printf("%s status=stream_collect_error error=%v", prefix, err)
+			slog.WarnContext(ctx, "antigravity_stream_collect_error", "account_id", account.ID, "error", err)
 			return nil, err
 		}
 		usage = streamRes.usage
@@ -1244,8 +1229,9 @@ func stripSignatureSensitiveBlocksFromClaudeRequest(req *antigravity.ClaudeReque
 // ForwardGemini 转发 Gemini 协议请求
 func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Context, account *Account, originalModel string, action string, stream bool, body []byte) (*ForwardResult, error) {
 	startTime := time.Now()
-	sessionID := getSessionID(c)
-	prefix := logPrefix(sessionID, account.Name)
+	if sessionID := getSessionID(c); sessionID != "" {
+		ctx = logger.With(ctx, "session", sessionID)
+	}
 
 	if strings.TrimSpace(originalModel) == "" {
 		return nil, s.writeGoogleError(c, http.StatusBadRequest, "Missing model in URL")
@@ -1294,10 +1280,7 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 	projectID := strings.TrimSpace(account.GetCredential("project_id"))
 
 	// 代理 URL
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.UpstreamProxyURL()
 
 	// Antigravity 上游要求必须包含身份提示词，注入到请求中
 	injectedBody, err := injectIdentityPatchToGeminiRequest(body)
@@ -1308,9 +1291,9 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 	// 清理 Schema
 	if cleanedBody, err := cleanGeminiRequest(injectedBody); err == nil {
 		injectedBody = cleanedBody
-		log.Printf("[Antigravity] Cleaned request schema in forwarded request for account %s", account.Name)
+		slog.DebugContext(ctx, "antigravity_schema_cleaned", "account_id", account.ID)
 	} else {
-		log.Printf("[Antigravity] Failed to clean schema: %v", err)
+		slog.WarnContext(ctx, "antigravity_schema_clean_failed", "account_id", account.ID, "error", err)
 	}
 
 	// 包装请求
@@ -1326,7 +1309,6 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 	// 执行带重试的请求
 	result, err := antigravityRetryLoop(antigravityRetryLoopParams{
 		ctx:            ctx,
-		prefix:         prefix,
 		account:        account,
 		proxyURL:       proxyURL,
 		accessToken:    accessToken,
@@ -1360,7 +1342,7 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 			isModelNotFoundError(resp.StatusCode, respBody) {
 			fallbackModel := s.settingService.GetFallbackModel(ctx, PlatformAntigravity)
 			if fallbackModel != "" && fallbackModel != mappedModel {
-				log.Printf("[Antigravity] Model not found (%s), retrying with fallback model %s (account: %s)", mappedModel, fallbackModel, account.Name)
+				slog.WarnContext(ctx, "antigravity_model_fallback", "account_id", account.ID, "model", mappedModel, "fallback_model", fallbackModel)
 
 				fallbackWrapped, err := s.wrapV1InternalRequest(projectID, fallbackModel, injectedBody)
 				if err == nil {
@@ -1393,7 +1375,7 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 		if unwrapErr != nil || len(unwrappedForOps) == 0 {
 			unwrappedForOps = respBody
 		}
-		s.handleUpstreamError(ctx, prefix, account, resp.StatusCode, resp.Header, respBody, quotaScope)
+		s.handleUpstreamError(ctx, account, resp.StatusCode, resp.Header, respBody, quotaScope)
 		upstreamMsg := strings.TrimSpace(extractAntigravityErrorMessage(unwrappedForOps))
 		upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
 
@@ -1438,7 +1420,7 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 			Message:            upstreamMsg,
 			Detail:             upstreamDetail,
 		})
-		log.Printf("[antigravity-Forward] upstream error status=%d body=%s", resp.StatusCode, truncateForLog(unwrappedForOps, 500))
+		slog.WarnContext(ctx, "antigravity_upstream_error", "account_id", account.ID, "status", resp.StatusCode, "body", truncateForLog(unwrappedForOps, 500))
 		c.Data(resp.StatusCode, contentType, unwrappedForOps)
 		return nil, fmt.Errorf("antigravity upstream error: %d", resp.StatusCode)
 	}
@@ -1456,7 +1438,7 @@ handleSuccess:
 		// 客户端要求流式，直接透传
 		streamRes, err := s.handleGeminiStreamingResponse(c, resp, startTime)
 		if err != nil {
-			log.Printf("%s status=stream_error error=%v", prefix, err)
+			slog.WarnContext(ctx, "antigravity_stream_error", "account_id", account.ID, "error", err)
 			return nil, err
 		}
 		usage = streamRes.usage
@@ -1465,7 +1447,7 @@ handleSuccess:
 		// 客户端要求非流式，收集流式响应后返回
 		streamRes, err := s.handleGeminiStreamToNonStreaming(c, resp, startTime)
 		if err != nil {
-			log.Printf("%s status=stream_collect_error error=%v", prefix, err)
+			slog.WarnContext(ctx, "antigravity_stream_collect_error", "account_id", account.ID, "error", err)
 			return nil, err
 		}
 		usage = streamRes.usage
@@ -1533,7 +1515,7 @@ func antigravityUseScopeRateLimit() bool {
 	return v == "1" || v == "true" || v == "yes" || v == "on"
 }
 
-func (s *AntigravityGatewayService) handleUpstreamError(ctx context.Context, prefix string, account *Account, statusCode int, headers http.Header, body []byte, quotaScope AntigravityQuotaScope) {
+func (s *AntigravityGatewayService) handleUpstreamError(ctx context.Context, account *Account, statusCode int, headers http.Header, body []byte, quotaScope AntigravityQuotaScope) {
 	// 429 使用 Gemini 格式解析（从 body 解析重置时间）
 	if statusCode == 429 {
 		useScopeLimit := antigravityUseScopeRateLimit() && quotaScope != ""
@@ -1547,28 +1529,28 @@ func (s *AntigravityGatewayService) handleUpstreamError(ctx context.Context, pre
 			defaultDur := time.Duration(fallbackMinutes) * time.Minute
 			ra := time.Now().Add(defaultDur)
 			if useScopeLimit {
-				log.Printf("%s status=429 rate_limited scope=%s reset_in=%v (fallback)", prefix, quotaScope, defaultDur)
+				slog.WarnContext(ctx, "antigravity_rate_limited", "account_id", account.ID, "scope", quotaScope, "reset_in", defaultDur, "fallback", true)
 				if err := s.accountRepo.SetAntigravityQuotaScopeLimit(ctx, account.ID, quotaScope, ra); err != nil {
-					log.Printf("%s status=429 rate_limit_set_failed scope=%s error=%v", prefix, quotaScope, err)
+					slog.ErrorContext(ctx, "antigravity_rate_limit_set_failed", "account_id", account.ID, "scope", quotaScope, "error", err)
 				}
 			} else {
-				log.Printf("%s status=429 rate_limited account=%d reset_in=%v (fallback)", prefix, account.ID, defaultDur)
+				slog.WarnContext(ctx, "antigravity_rate_limited", "account_id", account.ID, "reset_in", defaultDur, "fallback", true)
 				if err := s.accountRepo.SetRateLimited(ctx, account.ID, ra); err != nil {
-					log.Printf("%s status=429 rate_limit_set_failed account=%d error=%v", prefix, account.ID, err)
+					slog.ErrorContext(ctx, "antigravity_rate_limit_set_failed", "account_id", account.ID, "error", err)
 				}
 			}
 			return
 		}
 		resetTime := time.Unix(*resetAt, 0)
 		if useScopeLimit {
-			log.Printf("%s status=429 rate_limited scope=%s reset_at=%v reset_in=%v", prefix, quotaScope, resetTime.Format("15:04:05"), time.Until(resetTime).Truncate(time.Second))
+			slog.WarnContext(ctx, "antigravity_rate_limited", "account_id", account.ID, "scope", quotaScope, "reset_at", resetTime, "reset_in", time.Until(resetTime).Truncate(time.Second))
 			if err := s.accountRepo.SetAntigravityQuotaScopeLimit(ctx, account.ID, quotaScope, resetTime); err != nil {
-				log.Printf("%s status=429 rate_limit_set_failed scope=%s error=%v", prefix, quotaScope, err)
+				slog.ErrorContext(ctx, "antigravity_rate_limit_set_failed", "account_id", account.ID, "scope", quotaScope, "error", err)
 			}
 		} else {
-			log.Printf("%s status=429 rate_limited account=%d reset_at=%v reset_in=%v", prefix, account.ID, resetTime.Format("15:04:05"), time.Until(resetTime).Truncate(time.Second))
+			slog.WarnContext(ctx, "antigravity_rate_limited", "account_id", account.ID, "reset_at", resetTime, "reset_in", time.Until(resetTime).Truncate(time.Second))
 			if err := s.accountRepo.SetRateLimited(ctx, account.ID, resetTime); err != nil {
-				log.Printf("%s status=429 rate_limit_set_failed account=%d error=%v", prefix, account.ID, err)
+				slog.ErrorContext(ctx, "antigravity_rate_limit_set_failed", "account_id", account.ID, "error", err)
 			}
 		}
 		return
@@ -1579,7 +1561,7 @@ func (s *AntigravityGatewayService) handleUpstreamError(ctx context.Context, pre
 	}
 	shouldDisable := s.rateLimitService.HandleUpstreamError(ctx, account, statusCode, headers, body)
 	if shouldDisable {
-		log.Printf("%s status=%d marked_error", prefix, statusCode)
+		slog.WarnContext(ctx, "antigravity_account_marked_error", "account_id", account.ID, "status", statusCode)
 	}
 }
 
@@ -1589,6 +1571,9 @@ type antigravityStreamResult struct {
 }
 
 func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context, resp *http.Response, startTime time.Time) (*antigravityStreamResult, error) {
+	span := startStreamRelaySpan(c.Request.Context(), nil, "")
+	defer span.End()
+
 	c.Status(resp.StatusCode)
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -1680,7 +1665,7 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 			}
 			if ev.err != nil {
 				if errors.Is(ev.err, bufio.ErrTooLong) {
-					log.Printf("SSE line too long (antigravity): max_size=%d error=%v", maxLineSize, ev.err)
+					slog.WarnContext(c.Request.Context(), "sse_line_too_long", "platform", PlatformAntigravity, "max_size", maxLineSize, "error", ev.err)
 					sendErrorEvent("response_too_large")
 					return &antigravityStreamResult{usage: usage, firstTokenMs: firstTokenMs}, ev.err
 				}
@@ -1717,10 +1702,10 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 					if candidates, ok := parsed["candidates"].([]any); ok && len(candidates) > 0 {
 						if cand, ok := candidates[0].(map[string]any); ok {
 							if fr, ok := cand["finishReason"].(string); ok && fr == "MALFORMED_FUNCTION_CALL" {
-								log.Printf("[Antigravity] MALFORMED_FUNCTION_CALL detected in forward stream")
+								slog.WarnContext(c.Request.Context(), "antigravity_malformed_function_call", "stage", "stream")
 								if content, ok := cand["content"]; ok {
 									if b, err := json.Marshal(content); err == nil {
-										log.Printf("[Antigravity] Malformed content: %s", string(b))
+										slog.WarnContext(c.Request.Context(), "antigravity_malformed_content", "body", truncateForLog(b, 2048))
 									}
 								}
 							}
@@ -1731,6 +1716,7 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 				if firstTokenMs == nil {
 					ms := int(time.Since(startTime).Milliseconds())
 					firstTokenMs = &ms
+					markStreamFirstToken(span, ms)
 				}
 
 				if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", payload); err != nil {
@@ -1752,7 +1738,7 @@ func (s *AntigravityGatewayService) handleGeminiStreamingResponse(c *gin.Context
 			if time.Since(lastRead) < streamInterval {
 				continue
 			}
-			log.Printf("Stream data interval timeout (antigravity)")
+			slog.WarnContext(c.Request.Context(), "stream_data_interval_timeout", "platform", PlatformAntigravity)
 			// 注意：此函数没有 account 上下文，无法调用 HandleStreamTimeout
 			sendErrorEvent("stream_timeout")
 			return &antigravityStreamResult{usage: usage, firstTokenMs: firstTokenMs}, fmt.Errorf("stream data interval timeout")
@@ -1834,7 +1820,7 @@ func (s *AntigravityGatewayService) handleGeminiStreamToNonStreaming(c *gin.Cont
 			}
 			if ev.err != nil {
 				if errors.Is(ev.err, bufio.ErrTooLong) {
-					log.Printf("SSE line too long (antigravity non-stream): max_size=%d error=%v", maxLineSize, ev.err)
+					slog.WarnContext(c.Request.Context(), "sse_line_too_long", "platform", PlatformAntigravity, "mode", "non_stream", "max_size", maxLineSize, "error", ev.err)
 				}
 				return nil, ev.err
 			}
@@ -1879,10 +1865,10 @@ func (s *AntigravityGatewayService) handleGeminiStreamToNonStreaming(c *gin.Cont
 			if candidates, ok := parsed["candidates"].([]any); ok && len(candidates) > 0 {
 				if cand, ok := candidates[0].(map[string]any); ok {
 					if fr, ok := cand["finishReason"].(string); ok && fr == "MALFORMED_FUNCTION_CALL" {
-						log.Printf("[Antigravity] MALFORMED_FUNCTION_CALL detected in forward non-stream collect")
+						slog.WarnContext(c.Request.Context(), "antigravity_malformed_function_call", "stage", "non_stream_collect")
 						if content, ok := cand["content"]; ok {
 							if b, err := json.Marshal(content); err == nil {
-								log.Printf("[Antigravity] Malformed content: %s", string(b))
+								slog.WarnContext(c.Request.Context(), "antigravity_malformed_content", "body", truncateForLog(b, 2048))
 							}
 						}
 					}
@@ -1909,7 +1895,7 @@ func (s *AntigravityGatewayService) handleGeminiStreamToNonStreaming(c *gin.Cont
 			if time.Since(lastRead) < streamInterval {
 				continue
 			}
-			log.Printf("Stream data interval timeout (antigravity non-stream)")
+			slog.WarnContext(c.Request.Context(), "stream_data_interval_timeout", "platform", PlatformAntigravity, "mode", "non_stream")
 			return nil, fmt.Errorf("stream data interval timeout")
 		}
 	}
@@ -1920,7 +1906,7 @@ returnResponse:
 
 	// 处理空响应情况
 	if last == nil && lastWithParts == nil {
-		log.Printf("[antigravity-Forward] warning: empty stream response, no valid chunks received")
+		slog.WarnContext(c.Request.Context(), "antigravity_empty_stream_response")
 	}
 
 	// 如果收集到了图片 parts，需要合并到最终响应中
@@ -2139,7 +2125,7 @@ func (s *AntigravityGatewayService) writeMappedClaudeError(c *gin.Context, accou
 
 	// 记录上游错误详情便于排障（可选：由配置控制；不回显到客户端）
 	if logBody {
-		log.Printf("[antigravity-Forward] upstream_error status=%d body=%s", upstreamStatus, truncateForLog(body, maxBytes))
+		slog.WarnContext(c.Request.Context(), "antigravity_upstream_error", "account_id", account.ID, "status", upstreamStatus, "body", truncateForLog(body, maxBytes))
 	}
 
 	var statusCode int
@@ -2279,7 +2265,7 @@ func (s *AntigravityGatewayService) handleClaudeStreamToNonStreaming(c *gin.Cont
 			}
 			if ev.err != nil {
 				if errors.Is(ev.err, bufio.ErrTooLong) {
-					log.Printf("SSE line too long (antigravity claude non-stream): max_size=%d error=%v", maxLineSize, ev.err)
+					slog.WarnContext(c.Request.Context(), "sse_line_too_long", "platform", PlatformAntigravity, "mode", "claude_non_stream", "max_size", maxLineSize, "error", ev.err)
 				}
 				return nil, ev.err
 			}
@@ -2328,7 +2314,7 @@ func (s *AntigravityGatewayService) handleClaudeStreamToNonStreaming(c *gin.Cont
 			if time.Since(lastRead) < streamInterval {
 				continue
 			}
-			log.Printf("Stream data interval timeout (antigravity claude non-stream)")
+			slog.WarnContext(c.Request.Context(), "stream_data_interval_timeout", "platform", PlatformAntigravity, "mode", "claude_non_stream")
 			return nil, fmt.Errorf("stream data interval timeout")
 		}
 	}
@@ -2339,7 +2325,7 @@ returnResponse:
 
 	// 处理空响应情况
 	if last == nil && lastWithParts == nil {
-		log.Printf("[antigravity-Forward] warning: empty stream response, no valid chunks received")
+		slog.WarnContext(c.Request.Context(), "antigravity_empty_stream_response")
 		return nil, s.writeClaudeError(c, http.StatusBadGateway, "upstream_error", "Empty response from upstream")
 	}
 
@@ -2357,7 +2343,7 @@ returnResponse:
 	// 转换 Gemini 响应为 Claude 格式
 	claudeResp, agUsage, err := antigravity.TransformGeminiToClaude(geminiBody, originalModel)
 	if err != nil {
-		log.Printf("[antigravity-Forward] transform_error error=%v body=%s", err, string(geminiBody))
+		slog.WarnContext(c.Request.Context(), "antigravity_transform_error", "error", err, "body", truncateForLog(geminiBody, 2048))
 		return nil, s.writeClaudeError(c, http.StatusBadGateway, "upstream_error", "Failed to parse upstream response")
 	}
 
@@ -2376,6 +2362,9 @@ returnResponse:
 
 // handleClaudeStreamingResponse 处理 Claude 流式响应（Gemini SSE → Claude SSE 转换）
 func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context, resp *http.Response, startTime time.Time, originalModel string) (*antigravityStreamResult, error) {
+	span := startStreamRelaySpan(c.Request.Context(), nil, originalModel)
+	defer span.End()
+
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -2480,7 +2469,7 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 			}
 			if ev.err != nil {
 				if errors.Is(ev.err, bufio.ErrTooLong) {
-					log.Printf("SSE line too long (antigravity): max_size=%d error=%v", maxLineSize, ev.err)
+					slog.WarnContext(c.Request.Context(), "sse_line_too_long", "platform", PlatformAntigravity, "max_size", maxLineSize, "error", ev.err)
 					sendErrorEvent("response_too_large")
 					return &antigravityStreamResult{usage: convertUsage(nil), firstTokenMs: firstTokenMs}, ev.err
 				}
@@ -2496,6 +2485,7 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 				if firstTokenMs == nil {
 					ms := int(time.Since(startTime).Milliseconds())
 					firstTokenMs = &ms
+					markStreamFirstToken(span, ms)
 				}
 
 				if _, writeErr := c.Writer.Write(claudeEvents); writeErr != nil {
@@ -2514,7 +2504,7 @@ func (s *AntigravityGatewayService) handleClaudeStreamingResponse(c *gin.Context
 			if time.Since(lastRead) < streamInterval {
 				continue
 			}
-			log.Printf("Stream data interval timeout (antigravity)")
+			slog.WarnContext(c.Request.Context(), "stream_data_interval_timeout", "platform", PlatformAntigravity)
 			// 注意：此函数没有 account 上下文，无法调用 HandleStreamTimeout
 			sendErrorEvent("stream_timeout")
 			return &antigravityStreamResult{usage: convertUsage(nil), firstTokenMs: firstTokenMs}, fmt.Errorf("stream data interval timeout")
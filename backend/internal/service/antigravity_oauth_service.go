@@ -13,12 +13,14 @@ import (
 type AntigravityOAuthService struct {
 	sessionStore *antigravity.SessionStore
 	proxyRepo    ProxyRepository
+	proxyPools   *ProxyPoolService
 }
 
-func NewAntigravityOAuthService(proxyRepo ProxyRepository) *AntigravityOAuthService {
+func NewAntigravityOAuthService(proxyRepo ProxyRepository, proxyPools *ProxyPoolService) *AntigravityOAuthService {
 	return &AntigravityOAuthService{
 		sessionStore: antigravity.NewSessionStore(),
 		proxyRepo:    proxyRepo,
+		proxyPools:   proxyPools,
 	}
 }
 
@@ -219,13 +221,7 @@ func (s *AntigravityOAuthService) RefreshAccountToken(ctx context.Context, accou
 		return nil, fmt.Errorf("无可用的 refresh_token")
 	}
 
-	var proxyURL string
-	if account.ProxyID != nil {
-		proxy, err := s.proxyRepo.GetByID(ctx, *account.ProxyID)
-		if err == nil && proxy != nil {
-			proxyURL = proxy.URL()
-		}
-	}
+	proxyURL := resolveAccountProxyURL(ctx, s.proxyRepo, s.proxyPools, account)
 
 	tokenInfo, err := s.RefreshToken(ctx, refreshToken, proxyURL)
 	if err != nil {
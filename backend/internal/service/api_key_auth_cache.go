@@ -42,6 +42,26 @@ type APIKeyAuthGroupSnapshot struct {
 	// Only anthropic groups use these fields; others may leave them empty.
 	ModelRouting        map[string][]int64 `json:"model_routing,omitempty"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
+
+	// Account selection strategy is used by gateway account selection as well.
+	AccountSelectionStrategy string `json:"account_selection_strategy,omitempty"`
+
+	// Image model allowlist is enforced by the images endpoints.
+	ImageModels []string `json:"image_models,omitempty"`
+
+	// Content policy action is enforced by gateway handlers before forwarding.
+	ContentPolicyAction string `json:"content_policy_action,omitempty"`
+
+	// Request rewrite rules are applied by gateway handlers before forwarding.
+	RequestRewriteRules []RequestRewriteRule `json:"request_rewrite_rules,omitempty"`
+
+	// Model aliases and access lists are resolved before account selection.
+	ModelAliases  map[string]string `json:"model_aliases,omitempty"`
+	AllowedModels []string          `json:"allowed_models,omitempty"`
+	DeniedModels  []string          `json:"denied_models,omitempty"`
+
+	// Token/request-count limits are enforced by billing eligibility checks.
+	UsageLimits []SubscriptionUsageLimit `json:"usage_limits,omitempty"`
 }
 
 // APIKeyAuthCacheEntry 缓存条目，支持负缓存
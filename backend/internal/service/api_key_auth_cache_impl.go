@@ -223,22 +223,30 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 	}
 	if apiKey.Group != nil {
 		snapshot.Group = &APIKeyAuthGroupSnapshot{
-			ID:                  apiKey.Group.ID,
-			Name:                apiKey.Group.Name,
-			Platform:            apiKey.Group.Platform,
-			Status:              apiKey.Group.Status,
-			SubscriptionType:    apiKey.Group.SubscriptionType,
-			RateMultiplier:      apiKey.Group.RateMultiplier,
-			DailyLimitUSD:       apiKey.Group.DailyLimitUSD,
-			WeeklyLimitUSD:      apiKey.Group.WeeklyLimitUSD,
-			MonthlyLimitUSD:     apiKey.Group.MonthlyLimitUSD,
-			ImagePrice1K:        apiKey.Group.ImagePrice1K,
-			ImagePrice2K:        apiKey.Group.ImagePrice2K,
-			ImagePrice4K:        apiKey.Group.ImagePrice4K,
-			ClaudeCodeOnly:      apiKey.Group.ClaudeCodeOnly,
-			FallbackGroupID:     apiKey.Group.FallbackGroupID,
-			ModelRouting:        apiKey.Group.ModelRouting,
-			ModelRoutingEnabled: apiKey.Group.ModelRoutingEnabled,
+			ID:                       apiKey.Group.ID,
+			Name:                     apiKey.Group.Name,
+			Platform:                 apiKey.Group.Platform,
+			Status:                   apiKey.Group.Status,
+			SubscriptionType:         apiKey.Group.SubscriptionType,
+			RateMultiplier:           apiKey.Group.RateMultiplier,
+			DailyLimitUSD:            apiKey.Group.DailyLimitUSD,
+			WeeklyLimitUSD:           apiKey.Group.WeeklyLimitUSD,
+			MonthlyLimitUSD:          apiKey.Group.MonthlyLimitUSD,
+			ImagePrice1K:             apiKey.Group.ImagePrice1K,
+			ImagePrice2K:             apiKey.Group.ImagePrice2K,
+			ImagePrice4K:             apiKey.Group.ImagePrice4K,
+			ClaudeCodeOnly:           apiKey.Group.ClaudeCodeOnly,
+			FallbackGroupID:          apiKey.Group.FallbackGroupID,
+			ModelRouting:             apiKey.Group.ModelRouting,
+			ModelRoutingEnabled:      apiKey.Group.ModelRoutingEnabled,
+			AccountSelectionStrategy: apiKey.Group.AccountSelectionStrategy,
+			ImageModels:              apiKey.Group.ImageModels,
+			ContentPolicyAction:      apiKey.Group.ContentPolicyAction,
+			RequestRewriteRules:      apiKey.Group.RequestRewriteRules,
+			ModelAliases:             apiKey.Group.ModelAliases,
+			AllowedModels:            apiKey.Group.AllowedModels,
+			DeniedModels:             apiKey.Group.DeniedModels,
+			UsageLimits:              apiKey.Group.UsageLimits,
 		}
 	}
 	return snapshot
@@ -266,23 +274,31 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 	}
 	if snapshot.Group != nil {
 		apiKey.Group = &Group{
-			ID:                  snapshot.Group.ID,
-			Name:                snapshot.Group.Name,
-			Platform:            snapshot.Group.Platform,
-			Status:              snapshot.Group.Status,
-			Hydrated:            true,
-			SubscriptionType:    snapshot.Group.SubscriptionType,
-			RateMultiplier:      snapshot.Group.RateMultiplier,
-			DailyLimitUSD:       snapshot.Group.DailyLimitUSD,
-			WeeklyLimitUSD:      snapshot.Group.WeeklyLimitUSD,
-			MonthlyLimitUSD:     snapshot.Group.MonthlyLimitUSD,
-			ImagePrice1K:        snapshot.Group.ImagePrice1K,
-			ImagePrice2K:        snapshot.Group.ImagePrice2K,
-			ImagePrice4K:        snapshot.Group.ImagePrice4K,
-			ClaudeCodeOnly:      snapshot.Group.ClaudeCodeOnly,
-			FallbackGroupID:     snapshot.Group.FallbackGroupID,
-			ModelRouting:        snapshot.Group.ModelRouting,
-			ModelRoutingEnabled: snapshot.Group.ModelRoutingEnabled,
+			ID:                       snapshot.Group.ID,
+			Name:                     snapshot.Group.Name,
+			Platform:                 snapshot.Group.Platform,
+			Status:                   snapshot.Group.Status,
+			Hydrated:                 true,
+			SubscriptionType:         snapshot.Group.SubscriptionType,
+			RateMultiplier:           snapshot.Group.RateMultiplier,
+			DailyLimitUSD:            snapshot.Group.DailyLimitUSD,
+			WeeklyLimitUSD:           snapshot.Group.WeeklyLimitUSD,
+			MonthlyLimitUSD:          snapshot.Group.MonthlyLimitUSD,
+			ImagePrice1K:             snapshot.Group.ImagePrice1K,
+			ImagePrice2K:             snapshot.Group.ImagePrice2K,
+			ImagePrice4K:             snapshot.Group.ImagePrice4K,
+			ClaudeCodeOnly:           snapshot.Group.ClaudeCodeOnly,
+			FallbackGroupID:          snapshot.Group.FallbackGroupID,
+			ModelRouting:             snapshot.Group.ModelRouting,
+			ModelRoutingEnabled:      snapshot.Group.ModelRoutingEnabled,
+			AccountSelectionStrategy: snapshot.Group.AccountSelectionStrategy,
+			ImageModels:              snapshot.Group.ImageModels,
+			ContentPolicyAction:      snapshot.Group.ContentPolicyAction,
+			RequestRewriteRules:      snapshot.Group.RequestRewriteRules,
+			ModelAliases:             snapshot.Group.ModelAliases,
+			AllowedModels:            snapshot.Group.AllowedModels,
+			DeniedModels:             snapshot.Group.DeniedModels,
+			UsageLimits:              snapshot.Group.UsageLimits,
 		}
 	}
 	return apiKey
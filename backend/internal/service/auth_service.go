@@ -41,6 +41,7 @@ type JWTClaims struct {
 	Email        string `json:"email"`
 	Role         string `json:"role"`
 	TokenVersion int64  `json:"token_version"` // Used to invalidate tokens on password change
+	SessionID    string `json:"sid,omitempty"` // Server-side login session (empty for legacy/stateless tokens)
 	jwt.RegisteredClaims
 }
 
@@ -53,6 +54,7 @@ type AuthService struct {
 	turnstileService  *TurnstileService
 	emailQueueService *EmailQueueService
 	promoService      *PromoService
+	sessionService    *SessionService
 }
 
 // NewAuthService 创建认证服务实例
@@ -64,6 +66,7 @@ func NewAuthService(
 	turnstileService *TurnstileService,
 	emailQueueService *EmailQueueService,
 	promoService *PromoService,
+	sessionService *SessionService,
 ) *AuthService {
 	return &AuthService{
 		userRepo:          userRepo,
@@ -73,6 +76,7 @@ func NewAuthService(
 		turnstileService:  turnstileService,
 		emailQueueService: emailQueueService,
 		promoService:      promoService,
+		sessionService:    sessionService,
 	}
 }
 
@@ -167,7 +171,7 @@ func (s *AuthService) RegisterWithVerification(ctx context.Context, email, passw
 	}
 
 	// 生成token
-	token, err := s.GenerateToken(user)
+	token, err := s.IssueToken(ctx, user)
 	if err != nil {
 		return "", nil, fmt.Errorf("generate token: %w", err)
 	}
@@ -323,34 +327,44 @@ func (s *AuthService) IsEmailVerifyEnabled(ctx context.Context) bool {
 
 // Login 用户登录，返回JWT token
 func (s *AuthService) Login(ctx context.Context, email, password string) (string, *User, error) {
+	user, err := s.Authenticate(ctx, email, password)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// 生成JWT token
+	token, err := s.IssueToken(ctx, user)
+	if err != nil {
+		return "", nil, fmt.Errorf("generate token: %w", err)
+	}
+
+	return token, user, nil
+}
+
+// Authenticate 校验邮箱密码与用户状态，但不签发 token（用于需要二次验证的登录流程）
+func (s *AuthService) Authenticate(ctx context.Context, email, password string) (*User, error) {
 	// 查找用户
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			return "", nil, ErrInvalidCredentials
+			return nil, ErrInvalidCredentials
 		}
 		// 记录数据库错误但不暴露给用户
 		log.Printf("[Auth] Database error during login: %v", err)
-		return "", nil, ErrServiceUnavailable
+		return nil, ErrServiceUnavailable
 	}
 
 	// 验证密码
 	if !s.CheckPassword(password, user.PasswordHash) {
-		return "", nil, ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
 	}
 
 	// 检查用户状态
 	if !user.IsActive() {
-		return "", nil, ErrUserNotActive
+		return nil, ErrUserNotActive
 	}
 
-	// 生成JWT token
-	token, err := s.GenerateToken(user)
-	if err != nil {
-		return "", nil, fmt.Errorf("generate token: %w", err)
-	}
-
-	return token, user, nil
+	return user, nil
 }
 
 // LoginOrRegisterOAuth 用于第三方 OAuth/SSO 登录：
@@ -442,7 +456,7 @@ func (s *AuthService) LoginOrRegisterOAuth(ctx context.Context, email, username
 		}
 	}
 
-	token, err := s.GenerateToken(user)
+	token, err := s.IssueToken(ctx, user)
 	if err != nil {
 		return "", nil, fmt.Errorf("generate token: %w", err)
 	}
@@ -507,8 +521,70 @@ func isReservedEmail(email string) bool {
 	return strings.HasSuffix(normalized, LinuxDoConnectSyntheticEmailDomain)
 }
 
+// IssueToken 为用户创建登录会话并签发携带会话 ID 的 JWT。
+// 客户端信息（IP/User-Agent）通过 WithSessionClientInfo 写入 ctx；未配置会话服务时退化为无状态 token。
+func (s *AuthService) IssueToken(ctx context.Context, user *User) (string, error) {
+	if s.sessionService == nil {
+		return s.GenerateToken(user)
+	}
+	session, err := s.sessionService.Create(ctx, user.ID, sessionClientInfoFromContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	return s.generateToken(user, session.SessionID)
+}
+
+// ValidateSession 校验 token 绑定的登录会话是否仍然有效。
+// 未携带 sid 的旧 token 仅在迁移窗口内放行（见 SessionService.ValidateLegacyToken），刷新时迁移为会话 token。
+// 会话已吊销/过期/不存在时返回对应的会话错误；存储异常返回 ErrServiceUnavailable，调用方不应据此判定登出。
+func (s *AuthService) ValidateSession(ctx context.Context, claims *JWTClaims) error {
+	if s.sessionService == nil || claims == nil {
+		return nil
+	}
+	if claims.SessionID == "" {
+		return sessionValidationError(s.sessionService.ValidateLegacyToken(ctx, claims.UserID, tokenIssuedAt(claims)))
+	}
+	_, err := s.sessionService.Validate(ctx, claims.SessionID, claims.UserID)
+	return sessionValidationError(err)
+}
+
+func tokenIssuedAt(claims *JWTClaims) time.Time {
+	if claims.IssuedAt == nil {
+		return time.Time{}
+	}
+	return claims.IssuedAt.Time
+}
+
+// IsSessionInvalid 判断错误是否表示会话本身已失效（吊销/过期/不存在）
+func IsSessionInvalid(err error) bool {
+	return errors.Is(err, ErrSessionRevoked) || errors.Is(err, ErrSessionExpired) || errors.Is(err, ErrSessionNotFound)
+}
+
+func sessionValidationError(err error) error {
+	if err == nil || IsSessionInvalid(err) {
+		return err
+	}
+	log.Printf("[Auth] Session validation failed: %v", err)
+	return ErrServiceUnavailable.WithCause(err)
+}
+
+// Logout 吊销当前 token 绑定的登录会话；未绑定会话的旧 token 登出时使该用户所有旧 token 失效
+func (s *AuthService) Logout(ctx context.Context, userID int64, sessionID string) error {
+	if s.sessionService == nil {
+		return nil
+	}
+	if sessionID == "" {
+		return s.sessionService.RevokeLegacyTokens(ctx, userID)
+	}
+	return s.sessionService.Revoke(ctx, userID, sessionID, SessionRevokeReasonLogout)
+}
+
 // GenerateToken 生成JWT token
 func (s *AuthService) GenerateToken(user *User) (string, error) {
+	return s.generateToken(user, "")
+}
+
+func (s *AuthService) generateToken(user *User, sessionID string) (string, error) {
 	now := time.Now()
 	expiresAt := now.Add(time.Duration(s.cfg.JWT.ExpireHour) * time.Hour)
 
@@ -517,6 +593,7 @@ func (s *AuthService) GenerateToken(user *User) (string, error) {
 		Email:        user.Email,
 		Role:         user.Role,
 		TokenVersion: user.TokenVersion,
+		SessionID:    sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -577,8 +654,31 @@ func (s *AuthService) RefreshToken(ctx context.Context, oldTokenString string) (
 		return "", ErrTokenRevoked
 	}
 
+	if s.sessionService == nil {
+		return s.generateToken(user, claims.SessionID)
+	}
+
+	// 未绑定会话的旧 token：刷新时创建会话，使新 token 可被列出和吊销
+	if claims.SessionID == "" {
+		if err := s.sessionService.ValidateLegacyToken(ctx, claims.UserID, tokenIssuedAt(claims)); err != nil {
+			if IsSessionInvalid(err) {
+				return "", ErrTokenRevoked
+			}
+			return "", sessionValidationError(err)
+		}
+		return s.IssueToken(ctx, user)
+	}
+
+	// 绑定会话的 token：会话必须仍然有效，刷新时滑动延长会话有效期
+	if _, err := s.sessionService.Refresh(ctx, claims.SessionID, claims.UserID); err != nil {
+		if IsSessionInvalid(err) {
+			return "", ErrTokenRevoked
+		}
+		return "", sessionValidationError(err)
+	}
+
 	// 生成新token
-	return s.GenerateToken(user)
+	return s.generateToken(user, claims.SessionID)
 }
 
 // IsPasswordResetEnabled 检查是否启用密码重置功能
@@ -723,6 +823,9 @@ func (s *AuthService) ResetPassword(ctx context.Context, email, token, newPasswo
 		return ErrServiceUnavailable
 	}
 
+	// 密码重置后吊销所有登录会话
+	s.sessionService.revokeAllBestEffort(ctx, user.ID, "", SessionRevokeReasonPasswordReset)
+
 	log.Printf("[Auth] Password reset successful for user: %s", email)
 	return nil
 }
@@ -11,5 +11,7 @@ type SubscriptionCacheData struct {
 	DailyUsage   float64
 	WeeklyUsage  float64
 	MonthlyUsage float64
-	Version      int64
+	// token/请求数限额计数
+	Counters SubscriptionUsageCounters
+	Version  int64
 }
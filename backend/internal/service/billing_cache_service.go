@@ -27,6 +27,7 @@ type subscriptionCacheData struct {
 	DailyUsage   float64
 	WeeklyUsage  float64
 	MonthlyUsage float64
+	Counters     SubscriptionUsageCounters
 	Version      int64
 }
 
@@ -38,6 +39,7 @@ const (
 	cacheWriteSetSubscription
 	cacheWriteUpdateSubscriptionUsage
 	cacheWriteDeductBalance
+	cacheWriteUpdateSubscriptionCounters
 )
 
 // 异步缓存写入工作池配置
@@ -68,6 +70,7 @@ type cacheWriteTask struct {
 	balance          float64
 	amount           float64
 	subscriptionData *subscriptionCacheData
+	counters         SubscriptionUsageCounters
 }
 
 // BillingCacheService 计费缓存服务
@@ -76,6 +79,7 @@ type BillingCacheService struct {
 	cache          BillingCache
 	userRepo       UserRepository
 	subRepo        UserSubscriptionRepository
+	billingService *BillingService
 	cfg            *config.Config
 	circuitBreaker *billingCircuitBreaker
 
@@ -90,12 +94,13 @@ type BillingCacheService struct {
 }
 
 // NewBillingCacheService 创建计费缓存服务
-func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, cfg *config.Config) *BillingCacheService {
+func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, billingService *BillingService, cfg *config.Config) *BillingCacheService {
 	svc := &BillingCacheService{
-		cache:    cache,
-		userRepo: userRepo,
-		subRepo:  subRepo,
-		cfg:      cfg,
+		cache:          cache,
+		userRepo:       userRepo,
+		subRepo:        subRepo,
+		billingService: billingService,
+		cfg:            cfg,
 	}
 	svc.circuitBreaker = newBillingCircuitBreaker(cfg.Billing.CircuitBreaker)
 	svc.startCacheWriteWorkers()
@@ -165,6 +170,12 @@ func (s *BillingCacheService) cacheWriteWorker() {
 					log.Printf("Warning: deduct balance cache failed for user %d: %v", task.userID, err)
 				}
 			}
+		case cacheWriteUpdateSubscriptionCounters:
+			if s.cache != nil {
+				if err := s.cache.UpdateSubscriptionCounters(ctx, task.userID, task.groupID, task.counters); err != nil {
+					log.Printf("Warning: update subscription counters cache failed for user %d group %d: %v", task.userID, task.groupID, err)
+				}
+			}
 		}
 		cancel()
 	}
@@ -181,6 +192,8 @@ func cacheWriteKindName(kind cacheWriteKind) string {
 		return "update_subscription_usage"
 	case cacheWriteDeductBalance:
 		return "deduct_balance"
+	case cacheWriteUpdateSubscriptionCounters:
+		return "update_subscription_counters"
 	default:
 		return "unknown"
 	}
@@ -358,6 +371,7 @@ func (s *BillingCacheService) convertFromPortsData(data *SubscriptionCacheData)
 		DailyUsage:   data.DailyUsage,
 		WeeklyUsage:  data.WeeklyUsage,
 		MonthlyUsage: data.MonthlyUsage,
+		Counters:     data.Counters,
 		Version:      data.Version,
 	}
 }
@@ -369,6 +383,7 @@ func (s *BillingCacheService) convertToPortsData(data *subscriptionCacheData) *S
 		DailyUsage:   data.DailyUsage,
 		WeeklyUsage:  data.WeeklyUsage,
 		MonthlyUsage: data.MonthlyUsage,
+		Counters:     data.Counters,
 		Version:      data.Version,
 	}
 }
@@ -386,6 +401,7 @@ func (s *BillingCacheService) getSubscriptionFromDB(ctx context.Context, userID,
 		DailyUsage:   sub.DailyUsageUSD,
 		WeeklyUsage:  sub.WeeklyUsageUSD,
 		MonthlyUsage: sub.MonthlyUsageUSD,
+		Counters:     sub.UsageCounters(),
 		Version:      sub.UpdatedAt.Unix(),
 	}, nil
 }
@@ -429,6 +445,42 @@ func (s *BillingCacheService) QueueUpdateSubscriptionUsage(userID, groupID int64
 	}
 }
 
+// RecordSubscriptionUsageCounters 按分组的 token/请求数限额累加订阅计数（数据库 + 缓存）。
+// 分组未配置限额或请求模型未命中任何限额时不做任何操作。
+func (s *BillingCacheService) RecordSubscriptionUsageCounters(ctx context.Context, subscription *UserSubscription, group *Group, model string, tokens UsageTokens) {
+	if subscription == nil || group == nil || len(group.UsageLimits) == 0 {
+		return
+	}
+	delta := group.usageCounterDelta(model, tokens)
+	if delta.IsEmpty() {
+		return
+	}
+	if err := s.subRepo.IncrementUsageCounters(ctx, subscription.ID, delta); err != nil {
+		log.Printf("Increment subscription usage counters failed for subscription %d: %v", subscription.ID, err)
+	}
+	s.QueueUpdateSubscriptionCounters(subscription.UserID, group.ID, delta)
+}
+
+// QueueUpdateSubscriptionCounters 异步累加订阅限额计数缓存，队列满时同步回退
+func (s *BillingCacheService) QueueUpdateSubscriptionCounters(userID, groupID int64, delta SubscriptionUsageCounters) {
+	if s.cache == nil || delta.IsEmpty() {
+		return
+	}
+	if s.enqueueCacheWrite(cacheWriteTask{
+		kind:     cacheWriteUpdateSubscriptionCounters,
+		userID:   userID,
+		groupID:  groupID,
+		counters: delta,
+	}) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+	defer cancel()
+	if err := s.cache.UpdateSubscriptionCounters(ctx, userID, groupID, delta); err != nil {
+		log.Printf("Warning: update subscription counters cache fallback failed for user %d group %d: %v", userID, groupID, err)
+	}
+}
+
 // InvalidateSubscription 失效指定订阅缓存
 func (s *BillingCacheService) InvalidateSubscription(ctx context.Context, userID, groupID int64) error {
 	if s.cache == nil {
@@ -447,8 +499,8 @@ func (s *BillingCacheService) InvalidateSubscription(ctx context.Context, userID
 
 // CheckBillingEligibility 检查用户是否有资格发起请求
 // 余额模式：检查缓存余额 > 0
-// 订阅模式：检查缓存用量未超过限额（Group限额从参数传入）
-func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription) error {
+// 订阅模式：检查缓存用量未超过限额（Group限额从参数传入），model 用于匹配按模型族配置的 token/请求数限额
+func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription, model string) error {
 	// 简易模式：跳过所有计费检查
 	if s.cfg.RunMode == config.RunModeSimple {
 		return nil
@@ -461,7 +513,7 @@ func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user
 	isSubscriptionMode := group != nil && group.IsSubscriptionType() && subscription != nil
 
 	if isSubscriptionMode {
-		return s.checkSubscriptionEligibility(ctx, user.ID, group, subscription)
+		return s.checkSubscriptionEligibility(ctx, user.ID, group, subscription, model)
 	}
 
 	return s.checkBalanceEligibility(ctx, user.ID)
@@ -489,7 +541,7 @@ func (s *BillingCacheService) checkBalanceEligibility(ctx context.Context, userI
 }
 
 // checkSubscriptionEligibility 检查订阅模式资格
-func (s *BillingCacheService) checkSubscriptionEligibility(ctx context.Context, userID int64, group *Group, subscription *UserSubscription) error {
+func (s *BillingCacheService) checkSubscriptionEligibility(ctx context.Context, userID int64, group *Group, subscription *UserSubscription, model string) error {
 	// 获取订阅缓存数据
 	subData, err := s.GetSubscriptionStatus(ctx, userID, group.ID)
 	if err != nil {
@@ -526,7 +578,8 @@ func (s *BillingCacheService) checkSubscriptionEligibility(ctx context.Context,
 		return ErrMonthlyLimitExceeded
 	}
 
-	return nil
+	// 检查请求模型命中的 token/请求数限额
+	return group.checkUsageLimits(model, subData.Counters)
 }
 
 type billingCircuitBreakerState int
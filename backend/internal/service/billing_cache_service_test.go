@@ -48,13 +48,34 @@ func (b *billingCacheWorkerStub) UpdateSubscriptionUsage(ctx context.Context, us
 	return nil
 }
 
+func (b *billingCacheWorkerStub) UpdateSubscriptionCounters(ctx context.Context, userID, groupID int64, delta SubscriptionUsageCounters) error {
+	atomic.AddInt64(&b.subscriptionUpdates, 1)
+	return nil
+}
+
 func (b *billingCacheWorkerStub) InvalidateSubscriptionCache(ctx context.Context, userID, groupID int64) error {
 	return nil
 }
 
+func (b *billingCacheWorkerStub) ReserveBalanceHold(ctx context.Context, userID int64, holdID string, amount float64, expiresAt time.Time) (BillingHoldStatus, error) {
+	return BillingHoldReserved, nil
+}
+
+func (b *billingCacheWorkerStub) SettleBalanceHold(ctx context.Context, userID int64, holdID string, cost float64) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) ReserveSubscriptionHold(ctx context.Context, userID, groupID int64, holdID string, amount float64, expiresAt time.Time, limits SubscriptionHoldLimits) (BillingHoldStatus, error) {
+	return BillingHoldReserved, nil
+}
+
+func (b *billingCacheWorkerStub) SettleSubscriptionHold(ctx context.Context, userID, groupID int64, holdID string, cost float64) error {
+	return nil
+}
+
 func TestBillingCacheServiceQueueHighLoad(t *testing.T) {
 	cache := &billingCacheWorkerStub{}
-	svc := NewBillingCacheService(cache, nil, nil, &config.Config{})
+	svc := NewBillingCacheService(cache, nil, nil, nil, &config.Config{})
 	t.Cleanup(svc.Stop)
 
 	start := time.Now()
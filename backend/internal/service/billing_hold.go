@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+)
+
+// ErrInsufficientAvailableBalance 余额扣除进行中请求的冻结后不足以覆盖本次请求的预估费用
+var ErrInsufficientAvailableBalance = infraerrors.BadRequest(
+	"INSUFFICIENT_AVAILABLE_BALANCE",
+	"insufficient balance for the estimated cost of this request (including in-flight requests)",
+)
+
+// BillingHoldStatus 预授权冻结结果
+type BillingHoldStatus int
+
+const (
+	// BillingHoldCacheMiss 余额/订阅缓存不存在，需要回源建立缓存后重试
+	BillingHoldCacheMiss BillingHoldStatus = iota
+	BillingHoldReserved
+	BillingHoldInsufficientBalance
+	BillingHoldDailyLimitExceeded
+	BillingHoldWeeklyLimitExceeded
+	BillingHoldMonthlyLimitExceeded
+)
+
+// SubscriptionHoldLimits 订阅限额（nil 或非正数表示不限制）
+type SubscriptionHoldLimits struct {
+	DailyUSD   *float64
+	WeeklyUSD  *float64
+	MonthlyUSD *float64
+}
+
+// holdOutputTokenPaths 各协议请求中输出 token 上限字段
+var holdOutputTokenPaths = []string{
+	"max_tokens",
+	"max_output_tokens",
+	"max_completion_tokens",
+	"generationConfig.maxOutputTokens",
+	"generation_config.max_output_tokens",
+}
+
+// BillingHold 单个请求的预授权冻结。
+// 由网关 handler 在转发前创建并 defer Release；转交给异步用量记录时通过 Detach 移交所有权，
+// 之后由 RecordUsage 按实际费用 Settle。Release/Settle 只会生效一次。
+type BillingHold struct {
+	ID      string
+	UserID  int64
+	GroupID int64
+	Amount  float64
+	// Subscription 为 true 时冻结的是订阅额度（按原始费用），否则冻结余额（按倍率后费用）
+	Subscription bool
+
+	svc  *BillingCacheService
+	done *atomic.Bool
+}
+
+// Detach 移交冻结所有权：返回新的句柄，原句柄上的 Release 变为空操作
+func (h *BillingHold) Detach() *BillingHold {
+	if h == nil || !h.done.CompareAndSwap(false, true) {
+		return nil
+	}
+	detached := *h
+	detached.done = &atomic.Bool{}
+	return &detached
+}
+
+// Release 释放冻结（请求失败或未产生计费时调用）
+func (h *BillingHold) Release() {
+	if h == nil || !h.done.CompareAndSwap(false, true) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+	defer cancel()
+	if err := h.svc.settleHold(ctx, h, 0); err != nil {
+		log.Printf("Warning: release billing hold %s failed for user %d: %v", h.ID, h.UserID, err)
+	}
+}
+
+// ReserveBillingHold 按预估费用上限冻结余额或订阅额度。
+// 余额模式：可用余额 = 缓存余额 - 未结算冻结，不足以覆盖预估费用时拒绝；
+// 订阅模式：当前用量 + 未结算冻结 + 预估费用超过日/周/月限额时拒绝。
+// 未启用、简易模式、无法估价或缓存异常时返回 nil（不冻结，退化为原有的事后扣费）。
+func (s *BillingCacheService) ReserveBillingHold(ctx context.Context, user *User, group *Group, subscription *UserSubscription, model string, body []byte) (*BillingHold, error) {
+	return s.reserveBillingHold(ctx, user, group, subscription, func(multiplier float64) float64 {
+		return s.estimateHoldCost(model, body, multiplier)
+	})
+}
+
+// ReserveEmbeddingBillingHold 向量嵌入请求的预授权冻结：仅按输入 token 与嵌入模型价格估算，
+// 避免按对话模型价格和默认输出上限冻结过多额度。
+func (s *BillingCacheService) ReserveEmbeddingBillingHold(ctx context.Context, user *User, group *Group, subscription *UserSubscription, model string, body []byte) (*BillingHold, error) {
+	return s.reserveBillingHold(ctx, user, group, subscription, func(multiplier float64) float64 {
+		inputTokens, _ := estimateHoldTokens(body, s.cfg.Billing.Hold.InputBytesPerToken, 0)
+		cost, err := s.billingService.CalculateEmbeddingCost(model, inputTokens, multiplier)
+		if err != nil {
+			return 0
+		}
+		return cost.ActualCost
+	})
+}
+
+// ReserveImageBillingHold 图片生成请求的预授权冻结：按请求张数与尺寸档位的单价估算。
+func (s *BillingCacheService) ReserveImageBillingHold(ctx context.Context, user *User, group *Group, subscription *UserSubscription, model string, imageSize string, imageCount int) (*BillingHold, error) {
+	var groupConfig *ImagePriceConfig
+	if group != nil {
+		groupConfig = &ImagePriceConfig{
+			Price1K: group.ImagePrice1K,
+			Price2K: group.ImagePrice2K,
+			Price4K: group.ImagePrice4K,
+		}
+	}
+	return s.reserveBillingHold(ctx, user, group, subscription, func(multiplier float64) float64 {
+		return s.billingService.CalculateImageCost(model, imageSize, imageCount, groupConfig, multiplier).ActualCost
+	})
+}
+
+func (s *BillingCacheService) reserveBillingHold(ctx context.Context, user *User, group *Group, subscription *UserSubscription, estimate func(multiplier float64) float64) (*BillingHold, error) {
+	if !s.holdEnabled() || user == nil {
+		return nil, nil
+	}
+
+	isSubscriptionMode := group != nil && group.IsSubscriptionType() && subscription != nil
+	// 订阅用量按原始费用累计，余额按倍率后费用扣减
+	multiplier := 1.0
+	if !isSubscriptionMode {
+		multiplier = s.cfg.Default.RateMultiplier
+		if group != nil {
+			multiplier = group.RateMultiplier
+		}
+	}
+	amount := estimate(multiplier)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	hold := &BillingHold{
+		ID:           uuid.NewString(),
+		UserID:       user.ID,
+		Amount:       amount,
+		Subscription: isSubscriptionMode,
+		svc:          s,
+		done:         &atomic.Bool{},
+	}
+	if isSubscriptionMode {
+		hold.GroupID = group.ID
+	}
+
+	status, err := s.reserveHold(ctx, hold, group)
+	if err == nil && status == BillingHoldCacheMiss {
+		// 缓存不存在：同步回源建立缓存后重试一次
+		if warmErr := s.warmHoldCache(ctx, hold); warmErr != nil {
+			err = warmErr
+		} else {
+			status, err = s.reserveHold(ctx, hold, group)
+		}
+	}
+	if err != nil {
+		log.Printf("Warning: reserve billing hold failed for user %d: %v", user.ID, err)
+		return nil, nil
+	}
+
+	switch status {
+	case BillingHoldReserved:
+		return hold, nil
+	case BillingHoldInsufficientBalance:
+		return nil, ErrInsufficientAvailableBalance
+	case BillingHoldDailyLimitExceeded:
+		return nil, ErrDailyLimitExceeded
+	case BillingHoldWeeklyLimitExceeded:
+		return nil, ErrWeeklyLimitExceeded
+	case BillingHoldMonthlyLimitExceeded:
+		return nil, ErrMonthlyLimitExceeded
+	default:
+		return nil, nil
+	}
+}
+
+// SettleBillingHold 将冻结结算为实际费用（同时释放冻结并扣减缓存中的余额/订阅用量）。
+// 返回 false 表示没有可结算的冻结或结算失败，调用方应走原有的缓存扣减流程。
+func (s *BillingCacheService) SettleBillingHold(ctx context.Context, hold *BillingHold, cost float64) bool {
+	if hold == nil || !hold.done.CompareAndSwap(false, true) {
+		return false
+	}
+	if err := s.settleHold(ctx, hold, cost); err != nil {
+		log.Printf("Warning: settle billing hold %s failed for user %d: %v", hold.ID, hold.UserID, err)
+		return false
+	}
+	return true
+}
+
+func (s *BillingCacheService) holdEnabled() bool {
+	return s.cache != nil && s.billingService != nil && s.cfg != nil &&
+		s.cfg.Billing.Hold.Enabled && s.cfg.RunMode != config.RunModeSimple
+}
+
+func (s *BillingCacheService) holdTTL() time.Duration {
+	if s.cfg.Billing.Hold.TTLSeconds > 0 {
+		return time.Duration(s.cfg.Billing.Hold.TTLSeconds) * time.Second
+	}
+	return 30 * time.Minute
+}
+
+func (s *BillingCacheService) reserveHold(ctx context.Context, hold *BillingHold, group *Group) (BillingHoldStatus, error) {
+	expiresAt := time.Now().Add(s.holdTTL())
+	if !hold.Subscription {
+		return s.cache.ReserveBalanceHold(ctx, hold.UserID, hold.ID, hold.Amount, expiresAt)
+	}
+	limits := SubscriptionHoldLimits{}
+	if group.HasDailyLimit() {
+		limits.DailyUSD = group.DailyLimitUSD
+	}
+	if group.HasWeeklyLimit() {
+		limits.WeeklyUSD = group.WeeklyLimitUSD
+	}
+	if group.HasMonthlyLimit() {
+		limits.MonthlyUSD = group.MonthlyLimitUSD
+	}
+	return s.cache.ReserveSubscriptionHold(ctx, hold.UserID, hold.GroupID, hold.ID, hold.Amount, expiresAt, limits)
+}
+
+func (s *BillingCacheService) settleHold(ctx context.Context, hold *BillingHold, cost float64) error {
+	if hold.Subscription {
+		return s.cache.SettleSubscriptionHold(ctx, hold.UserID, hold.GroupID, hold.ID, cost)
+	}
+	return s.cache.SettleBalanceHold(ctx, hold.UserID, hold.ID, cost)
+}
+
+// warmHoldCache 同步建立余额/订阅缓存（预授权需要在缓存上原子判断）
+func (s *BillingCacheService) warmHoldCache(ctx context.Context, hold *BillingHold) error {
+	if hold.Subscription {
+		data, err := s.getSubscriptionFromDB(ctx, hold.UserID, hold.GroupID)
+		if err != nil {
+			return err
+		}
+		return s.cache.SetSubscriptionCache(ctx, hold.UserID, hold.GroupID, s.convertToPortsData(data))
+	}
+	balance, err := s.getUserBalanceFromDB(ctx, hold.UserID)
+	if err != nil {
+		return err
+	}
+	return s.cache.SetUserBalance(ctx, hold.UserID, balance)
+}
+
+// estimateHoldCost 估算请求费用上限：输入按请求体大小估算，输出取请求中的 max_tokens（未指定时取配置默认值）
+func (s *BillingCacheService) estimateHoldCost(model string, body []byte, multiplier float64) float64 {
+	if model == "" {
+		return 0
+	}
+	inputTokens, outputTokens := estimateHoldTokens(body, s.cfg.Billing.Hold.InputBytesPerToken, s.cfg.Billing.Hold.DefaultMaxOutputTokens)
+	cost, err := s.billingService.CalculateCost(model, UsageTokens{
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+	}, multiplier)
+	if err != nil {
+		return 0
+	}
+	return cost.ActualCost
+}
+
+// estimateHoldTokens 按请求体估算输入/输出 token 上限
+func estimateHoldTokens(body []byte, bytesPerToken, defaultMaxOutput int) (inputTokens, outputTokens int) {
+	if bytesPerToken <= 0 {
+		bytesPerToken = 4
+	}
+	inputTokens = (len(body) + bytesPerToken - 1) / bytesPerToken
+	for _, path := range holdOutputTokenPaths {
+		if v := gjson.GetBytes(body, path).Int(); v > 0 {
+			return inputTokens, int(v)
+		}
+	}
+	return inputTokens, defaultMaxOutput
+}
@@ -0,0 +1,152 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// billingHoldCacheStub 内存版余额缓存 + 冻结，语义与 Redis 脚本一致
+type billingHoldCacheStub struct {
+	billingCacheWorkerStub
+
+	mu       sync.Mutex
+	balances map[int64]float64
+	holds    map[string]float64
+	settled  map[string]float64
+}
+
+func newBillingHoldCacheStub() *billingHoldCacheStub {
+	return &billingHoldCacheStub{
+		balances: make(map[int64]float64),
+		holds:    make(map[string]float64),
+		settled:  make(map[string]float64),
+	}
+}
+
+func (c *billingHoldCacheStub) SetUserBalance(ctx context.Context, userID int64, balance float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.balances[userID] = balance
+	return nil
+}
+
+func (c *billingHoldCacheStub) ReserveBalanceHold(ctx context.Context, userID int64, holdID string, amount float64, expiresAt time.Time) (BillingHoldStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	balance, ok := c.balances[userID]
+	if !ok {
+		return BillingHoldCacheMiss, nil
+	}
+	held := 0.0
+	for _, v := range c.holds {
+		held += v
+	}
+	if balance-held < amount {
+		return BillingHoldInsufficientBalance, nil
+	}
+	c.holds[holdID] = amount
+	return BillingHoldReserved, nil
+}
+
+func (c *billingHoldCacheStub) SettleBalanceHold(ctx context.Context, userID int64, holdID string, cost float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.holds, holdID)
+	c.settled[holdID] += cost
+	if balance, ok := c.balances[userID]; ok {
+		c.balances[userID] = balance - cost
+	}
+	return nil
+}
+
+func newBillingHoldServiceForTest(t *testing.T, cache BillingCache, userRepo UserRepository) *BillingCacheService {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Default.RateMultiplier = 1
+	cfg.Billing.Hold.Enabled = true
+	cfg.Billing.Hold.TTLSeconds = 60
+	cfg.Billing.Hold.InputBytesPerToken = 4
+	cfg.Billing.Hold.DefaultMaxOutputTokens = 1000
+	svc := NewBillingCacheService(cache, userRepo, nil, NewBillingService(cfg, nil), cfg)
+	t.Cleanup(svc.Stop)
+	return svc
+}
+
+func TestEstimateHoldTokens(t *testing.T) {
+	in, out := estimateHoldTokens([]byte(`{"model":"m","max_tokens":2048}`), 4, 100)
+	require.Equal(t, 8, in)
+	require.Equal(t, 2048, out)
+
+	_, out = estimateHoldTokens([]byte(`{"generationConfig":{"maxOutputTokens":512}}`), 4, 100)
+	require.Equal(t, 512, out)
+
+	_, out = estimateHoldTokens([]byte(`{"input":"hi"}`), 4, 100)
+	require.Equal(t, 100, out, "falls back to the configured default")
+}
+
+func TestReserveBillingHold_RejectsWhenInflightHoldsExhaustBalance(t *testing.T) {
+	cache := newBillingHoldCacheStub()
+	svc := newBillingHoldServiceForTest(t, cache, &userRepoStub{user: &User{ID: 1, Balance: 0.1}})
+	user := &User{ID: 1}
+	body := []byte(`{"model":"claude-opus-4","max_tokens":1000}`)
+
+	// 首次冻结时缓存不存在，回源建立缓存后重试
+	first, err := svc.ReserveBillingHold(context.Background(), user, nil, nil, "claude-opus-4", body)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	require.Greater(t, first.Amount, 0.0)
+
+	_, err = svc.ReserveBillingHold(context.Background(), user, nil, nil, "claude-opus-4", body)
+	require.ErrorIs(t, err, ErrInsufficientAvailableBalance)
+
+	first.Release()
+	second, err := svc.ReserveBillingHold(context.Background(), user, nil, nil, "claude-opus-4", body)
+	require.NoError(t, err, "released hold frees the balance")
+	require.NotNil(t, second)
+	second.Release()
+}
+
+func TestBillingHold_DetachTransfersSettlement(t *testing.T) {
+	cache := newBillingHoldCacheStub()
+	cache.balances[1] = 10
+	svc := newBillingHoldServiceForTest(t, cache, nil)
+
+	hold, err := svc.ReserveBillingHold(context.Background(), &User{ID: 1}, nil, nil, "claude-sonnet-4", []byte(`{"max_tokens":100}`))
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+
+	detached := hold.Detach()
+	require.NotNil(t, detached)
+	hold.Release()
+	require.Len(t, cache.holds, 1, "release on the original handle is a no-op after detach")
+
+	require.True(t, svc.SettleBillingHold(context.Background(), detached, 0.25))
+	require.False(t, svc.SettleBillingHold(context.Background(), detached, 0.25), "settle applies only once")
+	detached.Release()
+
+	require.Empty(t, cache.holds)
+	require.InDelta(t, 0.25, cache.settled[hold.ID], 1e-12)
+	require.InDelta(t, 9.75, cache.balances[1], 1e-12)
+}
+
+func TestReserveBillingHold_DisabledOrSimpleMode(t *testing.T) {
+	cache := newBillingHoldCacheStub()
+	svc := newBillingHoldServiceForTest(t, cache, nil)
+	svc.cfg.RunMode = config.RunModeSimple
+
+	hold, err := svc.ReserveBillingHold(context.Background(), &User{ID: 1}, nil, nil, "claude-sonnet-4", []byte(`{}`))
+	require.NoError(t, err)
+	require.Nil(t, hold)
+
+	// nil 冻结上的操作均为空操作
+	hold.Release()
+	require.Nil(t, hold.Detach())
+	require.False(t, svc.SettleBillingHold(context.Background(), hold, 1))
+}
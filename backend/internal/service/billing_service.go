@@ -6,6 +6,7 @@ import (
 
 	"log"
 	"strings"
+	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 )
@@ -22,7 +23,14 @@ type BillingCache interface {
 	GetSubscriptionCache(ctx context.Context, userID, groupID int64) (*SubscriptionCacheData, error)
 	SetSubscriptionCache(ctx context.Context, userID, groupID int64, data *SubscriptionCacheData) error
 	UpdateSubscriptionUsage(ctx context.Context, userID, groupID int64, cost float64) error
+	UpdateSubscriptionCounters(ctx context.Context, userID, groupID int64, delta SubscriptionUsageCounters) error
 	InvalidateSubscriptionCache(ctx context.Context, userID, groupID int64) error
+
+	// Hold operations（预授权冻结，过期的冻结在预留时自动清理）
+	ReserveBalanceHold(ctx context.Context, userID int64, holdID string, amount float64, expiresAt time.Time) (BillingHoldStatus, error)
+	SettleBalanceHold(ctx context.Context, userID int64, holdID string, cost float64) error
+	ReserveSubscriptionHold(ctx context.Context, userID, groupID int64, holdID string, amount float64, expiresAt time.Time, limits SubscriptionHoldLimits) (BillingHoldStatus, error)
+	SettleSubscriptionHold(ctx context.Context, userID, groupID int64, holdID string, cost float64) error
 }
 
 // ModelPricing 模型价格配置（per-token价格，与LiteLLM格式一致）
@@ -232,6 +240,67 @@ func (s *BillingService) CalculateCost(model string, tokens UsageTokens, rateMul
 	return breakdown, nil
 }
 
+// BatchDiscountFactor Message Batches 价格系数（上游批量请求按标准价格五折计费）
+const BatchDiscountFactor = 0.5
+
+// ApplyBatchDiscount 对费用明细应用批量折扣
+func (s *BillingService) ApplyBatchDiscount(breakdown *CostBreakdown) *CostBreakdown {
+	if breakdown == nil {
+		return nil
+	}
+	return &CostBreakdown{
+		InputCost:         breakdown.InputCost * BatchDiscountFactor,
+		OutputCost:        breakdown.OutputCost * BatchDiscountFactor,
+		CacheCreationCost: breakdown.CacheCreationCost * BatchDiscountFactor,
+		CacheReadCost:     breakdown.CacheReadCost * BatchDiscountFactor,
+		TotalCost:         breakdown.TotalCost * BatchDiscountFactor,
+		ActualCost:        breakdown.ActualCost * BatchDiscountFactor,
+	}
+}
+
+// fallbackEmbeddingPrices 嵌入模型硬编码回退价格（USD per token，LiteLLM 价格表不可用时使用）
+var fallbackEmbeddingPrices = map[string]float64{
+	"text-embedding-3-small": 0.02e-6,
+	"text-embedding-3-large": 0.13e-6,
+	"text-embedding-ada-002": 0.1e-6,
+	"gemini-embedding-001":   0.15e-6,
+	"text-embedding-004":     0,
+	"embedding-001":          0,
+}
+
+// CalculateEmbeddingCost 计算向量嵌入费用（仅按输入 token 计费）。
+// 价格取 LiteLLM 价格表中 mode=embedding 的条目，缺失时使用硬编码回退价格；
+// 未知的嵌入模型返回错误（不会按对话模型价格计费）。
+func (s *BillingService) CalculateEmbeddingCost(model string, inputTokens int, rateMultiplier float64) (*CostBreakdown, error) {
+	pricePerToken, ok := s.getEmbeddingPrice(model)
+	if !ok {
+		return nil, fmt.Errorf("embedding pricing not found for model: %s", model)
+	}
+
+	inputCost := float64(inputTokens) * pricePerToken
+	if rateMultiplier <= 0 {
+		rateMultiplier = 1.0
+	}
+	return &CostBreakdown{
+		InputCost:  inputCost,
+		TotalCost:  inputCost,
+		ActualCost: inputCost * rateMultiplier,
+	}, nil
+}
+
+func (s *BillingService) getEmbeddingPrice(model string) (float64, bool) {
+	if s.pricingService != nil {
+		if pricing := s.pricingService.GetEmbeddingPricing(model); pricing != nil {
+			return pricing.InputCostPerToken, true
+		}
+	}
+	price, ok := fallbackEmbeddingPrices[normalizeModelNameForPricing(strings.ToLower(strings.TrimSpace(model)))]
+	if ok {
+		log.Printf("[Billing] Using fallback embedding pricing for model: %s", model)
+	}
+	return price, ok
+}
+
 // CalculateCostWithConfig 使用配置中的默认倍率计算费用
 func (s *BillingService) CalculateCostWithConfig(model string, tokens UsageTokens) (*CostBreakdown, error) {
 	multiplier := s.cfg.Default.RateMultiplier
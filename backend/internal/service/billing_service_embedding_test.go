@@ -0,0 +1,46 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCalculateEmbeddingCost_LiteLLMPricing 测试嵌入模型使用 LiteLLM 中 mode=embedding 的价格
+func TestCalculateEmbeddingCost_LiteLLMPricing(t *testing.T) {
+	pricing := &PricingService{pricingData: map[string]*LiteLLMModelPricing{
+		"text-embedding-3-small":      {InputCostPerToken: 0.02e-6, Mode: PricingModeEmbedding},
+		"gemini/gemini-embedding-001": {InputCostPerToken: 0.15e-6, Mode: PricingModeEmbedding},
+		"gpt-4o":                      {InputCostPerToken: 2.5e-6, OutputCostPerToken: 10e-6, Mode: "chat"},
+	}}
+	svc := &BillingService{pricingService: pricing}
+
+	cost, err := svc.CalculateEmbeddingCost("text-embedding-3-small", 1_000_000, 2.0)
+	require.NoError(t, err)
+	require.InDelta(t, 0.02, cost.InputCost, 1e-9)
+	require.InDelta(t, 0.02, cost.TotalCost, 1e-9)
+	require.InDelta(t, 0.04, cost.ActualCost, 1e-9)
+	require.Zero(t, cost.OutputCost)
+
+	// Gemini 嵌入模型以 "gemini/" 前缀登记，同时兼容 "models/" 前缀
+	cost, err = svc.CalculateEmbeddingCost("models/gemini-embedding-001", 1_000_000, 1.0)
+	require.NoError(t, err)
+	require.InDelta(t, 0.15, cost.TotalCost, 1e-9)
+
+	// 对话模型条目不会被当作嵌入价格
+	require.Nil(t, pricing.GetEmbeddingPricing("gpt-4o"))
+}
+
+// TestCalculateEmbeddingCost_FallbackAndUnknown 测试回退价格与未知模型
+func TestCalculateEmbeddingCost_FallbackAndUnknown(t *testing.T) {
+	svc := &BillingService{}
+
+	cost, err := svc.CalculateEmbeddingCost("text-embedding-3-large", 1_000_000, 1.0)
+	require.NoError(t, err)
+	require.InDelta(t, 0.13, cost.ActualCost, 1e-9)
+
+	_, err = svc.CalculateEmbeddingCost("my-custom-embedder", 100, 1.0)
+	require.Error(t, err)
+}
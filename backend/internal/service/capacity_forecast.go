@@ -0,0 +1,303 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// 分组容量状态
+const (
+	CapacityStatusOK        = "ok"
+	CapacityStatusWarning   = "warning"   // 预测耗尽时间低于 warning_hours
+	CapacityStatusCritical  = "critical"  // 预测耗尽时间低于 critical_hours
+	CapacityStatusUnbounded = "unbounded" // 分组内有无配额窗口的账号（API Key 等），不会因配额耗尽
+	CapacityStatusNoData    = "no_data"   // 没有可估算容量的账号
+)
+
+// 配额窗口名称
+const (
+	CapacityWindowClaude5h     = "claude_5h"
+	CapacityWindowClaude7d     = "claude_7d"
+	CapacityWindowCodex5h      = "codex_5h"
+	CapacityWindowCodex7d      = "codex_7d"
+	CapacityWindowGeminiDaily  = "gemini_daily"
+	CapacityWindowAntigravity  = "antigravity"
+	capacityAntigravityWindow  = 5 * time.Hour
+	capacityMinUtilization     = 2.0 // 使用率低于该值（%）时按同类账号中位数估算容量
+	capacityMinWindowRequests  = 5   // 窗口内请求数低于该值时同上
+	capacitySimulationStep     = 5 * time.Minute
+	capacityDemandRecentWindow = time.Hour
+)
+
+// 告警规则中的容量类指标
+const (
+	OpsMetricGroupHoursToExhaustion   = "group_hours_to_exhaustion"  // 分组预测耗尽时间（小时）；未指定分组时取所有分组的最小值
+	OpsMetricGroupRecommendedAccounts = "group_recommended_accounts" // 分组建议扩容的账号数；未指定分组时取总和
+)
+
+// CapacityQuotaWindow 账号的一个配额窗口
+type CapacityQuotaWindow struct {
+	Name string `json:"name"`
+	// Scope 配额域（Antigravity 的 claude/gemini_text/gemini_image，Gemini 的 pro/flash）；空表示账号级配额
+	Scope       string        `json:"scope,omitempty"`
+	Utilization float64       `json:"utilization"` // 0-100
+	Window      time.Duration `json:"-"`
+	WindowHours float64       `json:"window_hours"`
+	ResetsAt    *time.Time    `json:"resets_at,omitempty"`
+	// UsedRequests 窗口开始以来的请求数
+	UsedRequests int64 `json:"used_requests"`
+	// CapacityRequests 单窗口容量（请求数）；硬限额时为限额，否则由 使用量 / 使用率 估算
+	CapacityRequests  float64 `json:"capacity_requests"`
+	RemainingRequests float64 `json:"remaining_requests"`
+	// Estimated 容量由使用率推算（false 表示硬限额）；Imputed 样本不足时使用同类账号中位数
+	Estimated bool `json:"estimated"`
+	Imputed   bool `json:"imputed,omitempty"`
+	// limit 硬限额（请求数），0 表示无
+	limit int64
+}
+
+// known 窗口容量是否可用
+func (w *CapacityQuotaWindow) known() bool {
+	return w.CapacityRequests > 0
+}
+
+// estimate 根据硬限额或使用率推算容量；样本不足时容量保持为 0，由 imputeCapacity 补齐
+func (w *CapacityQuotaWindow) estimate() {
+	if w.limit > 0 {
+		w.CapacityRequests = float64(w.limit)
+		w.RemainingRequests = math.Max(0, float64(w.limit-w.UsedRequests))
+		return
+	}
+	w.Estimated = true
+	if w.Utilization >= capacityMinUtilization && w.UsedRequests >= capacityMinWindowRequests {
+		w.CapacityRequests = float64(w.UsedRequests) / (math.Min(w.Utilization, 100) / 100)
+		w.RemainingRequests = math.Max(0, w.CapacityRequests-float64(w.UsedRequests))
+	}
+}
+
+// AccountCapacityForecast 单个账号的容量估算
+type AccountCapacityForecast struct {
+	AccountID int64  `json:"account_id"`
+	Name      string `json:"name"`
+	Platform  string `json:"platform"`
+	Type      string `json:"type"`
+	// Unbounded 无配额窗口（API Key 账号），不参与耗尽预测
+	Unbounded bool `json:"unbounded"`
+	// BindingWindow 剩余容量最少的窗口
+	BindingWindow     string                 `json:"binding_window,omitempty"`
+	RemainingRequests float64                `json:"remaining_requests"`
+	RequestsPerHour   float64                `json:"sustainable_requests_per_hour"`
+	RateLimitedUntil  *time.Time             `json:"rate_limited_until,omitempty"`
+	Windows           []*CapacityQuotaWindow `json:"windows"`
+	Error             string                 `json:"error,omitempty"`
+}
+
+// GroupCapacityForecast 分组容量预测
+type GroupCapacityForecast struct {
+	GroupID   int64  `json:"group_id"`
+	GroupName string `json:"group_name"`
+	Platform  string `json:"platform"`
+	Status    string `json:"status"`
+
+	AccountCount      int `json:"account_count"`
+	BoundedAccounts   int `json:"bounded_accounts"`
+	UnboundedAccounts int `json:"unbounded_accounts"`
+	UnknownAccounts   int `json:"unknown_accounts"`
+
+	// 需求（请求/小时）：取最近 1 小时与回看期小时均值中的较大者
+	DemandPerHour        float64 `json:"demand_per_hour"`
+	DemandLastHour       float64 `json:"demand_last_hour"`
+	DemandAveragePerHour float64 `json:"demand_average_per_hour"`
+
+	RemainingRequests          float64 `json:"remaining_requests"`
+	SustainableRequestsPerHour float64 `json:"sustainable_requests_per_hour"`
+	// HoursToExhaustion 预测耗尽时间；nil 表示预测期内不会耗尽
+	HoursToExhaustion *float64   `json:"hours_to_exhaustion"`
+	ExhaustsAt        *time.Time `json:"exhausts_at,omitempty"`
+	// RecommendedAccounts 按需求（含余量）与持续供给能力之差估算的建议新增账号数
+	RecommendedAccounts int `json:"recommended_accounts"`
+
+	ActiveScopes []string                   `json:"active_scopes,omitempty"`
+	Accounts     []*AccountCapacityForecast `json:"accounts"`
+	GeneratedAt  time.Time                  `json:"generated_at"`
+}
+
+// CapacityForecastReport 所有分组的容量预测
+type CapacityForecastReport struct {
+	HorizonHours int                      `json:"horizon_hours"`
+	GeneratedAt  time.Time                `json:"generated_at"`
+	Groups       []*GroupCapacityForecast `json:"groups"`
+}
+
+// capacityWindowKey 同类窗口（平台 + 窗口 + 配额域）用于样本不足时的容量补齐
+func capacityWindowKey(platform string, w *CapacityQuotaWindow) string {
+	return platform + "|" + w.Name + "|" + w.Scope
+}
+
+// imputeCapacity 对样本不足的窗口使用同类窗口容量中位数补齐；没有同类样本时保持未知
+func imputeCapacity(accounts []*AccountCapacityForecast) {
+	samples := make(map[string][]float64)
+	for _, acc := range accounts {
+		for _, w := range acc.Windows {
+			if w.known() && !w.Imputed {
+				key := capacityWindowKey(acc.Platform, w)
+				samples[key] = append(samples[key], w.CapacityRequests)
+			}
+		}
+	}
+	for _, acc := range accounts {
+		for _, w := range acc.Windows {
+			if w.known() {
+				continue
+			}
+			values := samples[capacityWindowKey(acc.Platform, w)]
+			if len(values) == 0 {
+				continue
+			}
+			w.CapacityRequests = median(values)
+			w.RemainingRequests = w.CapacityRequests * math.Max(0, 1-w.Utilization/100)
+			w.Imputed = true
+		}
+	}
+}
+
+// summarize 计算账号的绑定窗口、剩余容量与可持续供给速率（各窗口 容量/窗口时长 的最小值）
+func (a *AccountCapacityForecast) summarize() bool {
+	if a.Unbounded || len(a.Windows) == 0 {
+		return false
+	}
+	var binding *CapacityQuotaWindow
+	rate := math.Inf(1)
+	for _, w := range a.Windows {
+		if !w.known() {
+			return false
+		}
+		if binding == nil || w.RemainingRequests < binding.RemainingRequests {
+			binding = w
+		}
+		if w.Window > 0 {
+			rate = math.Min(rate, w.CapacityRequests/w.Window.Hours())
+		}
+	}
+	a.BindingWindow = binding.Name
+	a.RemainingRequests = binding.RemainingRequests
+	if a.RateLimitedUntil != nil {
+		a.RemainingRequests = 0
+	}
+	if !math.IsInf(rate, 1) {
+		a.RequestsPerHour = rate
+	}
+	return true
+}
+
+// capacitySimWindow / capacitySimAccount 模拟用的可变状态
+type capacitySimWindow struct {
+	capacity  float64
+	remaining float64
+	window    time.Duration
+	nextReset time.Time
+}
+
+type capacitySimAccount struct {
+	windows      []*capacitySimWindow
+	blockedUntil time.Time
+}
+
+func (a *capacitySimAccount) available(t time.Time) float64 {
+	if t.Before(a.blockedUntil) {
+		return 0
+	}
+	avail := math.Inf(1)
+	for _, w := range a.windows {
+		avail = math.Min(avail, w.remaining)
+	}
+	if math.IsInf(avail, 1) {
+		return 0
+	}
+	return avail
+}
+
+func newCapacitySimAccount(acc *AccountCapacityForecast, now time.Time) *capacitySimAccount {
+	sim := &capacitySimAccount{}
+	if acc.RateLimitedUntil != nil {
+		sim.blockedUntil = *acc.RateLimitedUntil
+	}
+	for _, w := range acc.Windows {
+		sw := &capacitySimWindow{capacity: w.CapacityRequests, remaining: w.RemainingRequests, window: w.Window}
+		if w.ResetsAt != nil && w.ResetsAt.After(now) {
+			sw.nextReset = *w.ResetsAt
+		} else {
+			sw.nextReset = now.Add(w.Window)
+		}
+		sim.windows = append(sim.windows, sw)
+	}
+	return sim
+}
+
+// simulateCapacityExhaustion 以固定需求逐步消耗账号池剩余容量（按各账号可用量比例分摊），窗口到期时恢复容量。
+// 返回首次无法满足需求的时间；预测期内不会耗尽时返回 false。
+func simulateCapacityExhaustion(accounts []*capacitySimAccount, demandPerHour float64, now time.Time, horizon time.Duration) (time.Time, bool) {
+	if demandPerHour <= 0 || len(accounts) == 0 {
+		return time.Time{}, false
+	}
+	step := capacitySimulationStep
+	need := demandPerHour * step.Hours()
+	for t := now; t.Before(now.Add(horizon)); t = t.Add(step) {
+		total := 0.0
+		avail := make([]float64, len(accounts))
+		for i, acc := range accounts {
+			for _, w := range acc.windows {
+				for w.window > 0 && !t.Before(w.nextReset) {
+					w.remaining = w.capacity
+					w.nextReset = w.nextReset.Add(w.window)
+				}
+			}
+			avail[i] = acc.available(t)
+			total += avail[i]
+		}
+		if total < need {
+			return t.Add(time.Duration(total / demandPerHour * float64(time.Hour))), true
+		}
+		for i, acc := range accounts {
+			if avail[i] <= 0 {
+				continue
+			}
+			draw := need * avail[i] / total
+			for _, w := range acc.windows {
+				w.remaining -= draw
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// recommendAdditionalAccounts 需求（含余量）超过持续供给能力时，按单账号供给中位数估算需新增的账号数
+func recommendAdditionalAccounts(demandPerHour, headroom float64, accounts []*AccountCapacityForecast) int {
+	var supply float64
+	var rates []float64
+	for _, acc := range accounts {
+		if acc.RequestsPerHour > 0 {
+			supply += acc.RequestsPerHour
+			rates = append(rates, acc.RequestsPerHour)
+		}
+	}
+	deficit := demandPerHour*(1+headroom) - supply
+	if deficit <= 0 || len(rates) == 0 {
+		return 0
+	}
+	return int(math.Ceil(deficit / median(rates)))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+// CapacityForecastService 账号池容量预测
+//
+//   - 汇总分组内每个可调度账号的配额窗口（Claude 5h/7d 使用率、Codex 用量快照、Gemini 日配额、Antigravity 配额域），
+//     结合窗口内请求数把使用率换算为请求容量；
+//   - 以使用记录中的近期需求（请求/小时）模拟消耗，窗口到期时恢复容量，预测分组何时无法满足需求；
+//   - 按需求（含余量）与账号池可持续供给能力之差给出建议扩容账号数。
+//
+// 结果按 capacity_forecast.cache_ttl_seconds 缓存，避免频繁调用上游用量接口。
+type CapacityForecastService struct {
+	accountRepo         AccountRepository
+	groupRepo           GroupRepository
+	usageLogRepo        UsageLogRepository
+	accountUsageService *AccountUsageService
+	cfg                 *config.Config
+
+	mu       sync.Mutex
+	cached   *CapacityForecastReport
+	cachedAt time.Time
+}
+
+// NewCapacityForecastService 创建容量预测服务
+func NewCapacityForecastService(
+	accountRepo AccountRepository,
+	groupRepo GroupRepository,
+	usageLogRepo UsageLogRepository,
+	accountUsageService *AccountUsageService,
+	cfg *config.Config,
+) *CapacityForecastService {
+	return &CapacityForecastService{
+		accountRepo:         accountRepo,
+		groupRepo:           groupRepo,
+		usageLogRepo:        usageLogRepo,
+		accountUsageService: accountUsageService,
+		cfg:                 cfg,
+	}
+}
+
+func (s *CapacityForecastService) settings() config.CapacityForecastConfig {
+	if s.cfg == nil {
+		return config.CapacityForecastConfig{HorizonHours: 168, DemandLookbackHours: 24, Headroom: 0.2, WarningHours: 24, CriticalHours: 5, UsageFetchConcurrency: 4}
+	}
+	return s.cfg.CapacityForecast
+}
+
+// ForecastGroups 预测所有活跃分组；refresh 为 true 时忽略缓存
+func (s *CapacityForecastService) ForecastGroups(ctx context.Context, refresh bool) (*CapacityForecastReport, error) {
+	ttl := time.Duration(s.settings().CacheTTLSeconds) * time.Second
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !refresh && s.cached != nil && ttl > 0 && time.Since(s.cachedAt) < ttl {
+		return s.cached, nil
+	}
+
+	groups, err := s.groupRepo.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list groups: %w", err)
+	}
+	now := time.Now()
+	report := &CapacityForecastReport{HorizonHours: s.settings().HorizonHours, GeneratedAt: now}
+	for i := range groups {
+		forecast, err := s.forecastGroup(ctx, &groups[i], now)
+		if err != nil {
+			return nil, err
+		}
+		report.Groups = append(report.Groups, forecast)
+	}
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return capacityForecastSortKey(report.Groups[i]) < capacityForecastSortKey(report.Groups[j])
+	})
+	s.cached, s.cachedAt = report, now
+	return report, nil
+}
+
+// ForecastGroup 预测单个分组（不使用缓存）
+func (s *CapacityForecastService) ForecastGroup(ctx context.Context, groupID int64) (*GroupCapacityForecast, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.forecastGroup(ctx, group, time.Now())
+}
+
+// ComputeOpsMetric 基于缓存的预测结果计算告警规则中的容量类指标；未指定分组时对所有分组取最坏值
+func (s *CapacityForecastService) ComputeOpsMetric(ctx context.Context, metricType string, groupID *int64) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	report, err := s.ForecastGroups(ctx, false)
+	if err != nil {
+		return 0, false
+	}
+	groups := report.Groups
+	if groupID != nil && *groupID > 0 {
+		groups = nil
+		for _, g := range report.Groups {
+			if g.GroupID == *groupID {
+				groups = append(groups, g)
+			}
+		}
+		if len(groups) == 0 {
+			return 0, false
+		}
+	}
+
+	switch metricType {
+	case OpsMetricGroupHoursToExhaustion:
+		// 预测期内不会耗尽时返回预测期长度，便于配置 “< N 小时” 规则
+		value := float64(s.settings().HorizonHours)
+		for _, g := range groups {
+			if g.HoursToExhaustion != nil && *g.HoursToExhaustion < value {
+				value = *g.HoursToExhaustion
+			}
+		}
+		return value, true
+	case OpsMetricGroupRecommendedAccounts:
+		total := 0
+		for _, g := range groups {
+			total += g.RecommendedAccounts
+		}
+		return float64(total), true
+	}
+	return 0, false
+}
+
+func capacityForecastSortKey(g *GroupCapacityForecast) float64 {
+	if g.HoursToExhaustion != nil {
+		return *g.HoursToExhaustion
+	}
+	return math.MaxFloat64
+}
+
+func (s *CapacityForecastService) forecastGroup(ctx context.Context, group *Group, now time.Time) (*GroupCapacityForecast, error) {
+	cfg := s.settings()
+	forecast := &GroupCapacityForecast{
+		GroupID:     group.ID,
+		GroupName:   group.Name,
+		Platform:    group.Platform,
+		GeneratedAt: now,
+		Accounts:    []*AccountCapacityForecast{},
+	}
+
+	if err := s.fillDemand(ctx, forecast, cfg, now); err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.accountRepo.ListByGroup(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list group accounts: %w", err)
+	}
+	for i := range accounts {
+		acc := &accounts[i]
+		if !acc.IsActive() || !acc.Schedulable {
+			continue
+		}
+		forecast.Accounts = append(forecast.Accounts, &AccountCapacityForecast{
+			AccountID: acc.ID,
+			Name:      acc.Name,
+			Platform:  acc.Platform,
+			Type:      acc.Type,
+		})
+	}
+	forecast.AccountCount = len(forecast.Accounts)
+	s.collectWindows(ctx, accounts, forecast.Accounts, forecast.ActiveScopes, cfg, now)
+	imputeCapacity(forecast.Accounts)
+
+	var sims []*capacitySimAccount
+	for _, acc := range forecast.Accounts {
+		switch {
+		case acc.Unbounded:
+			forecast.UnboundedAccounts++
+		case acc.summarize():
+			forecast.BoundedAccounts++
+			forecast.RemainingRequests += acc.RemainingRequests
+			forecast.SustainableRequestsPerHour += acc.RequestsPerHour
+			sims = append(sims, newCapacitySimAccount(acc, now))
+		default:
+			forecast.UnknownAccounts++
+		}
+	}
+
+	switch {
+	case forecast.UnboundedAccounts > 0:
+		forecast.Status = CapacityStatusUnbounded
+		return forecast, nil
+	case forecast.BoundedAccounts == 0:
+		forecast.Status = CapacityStatusNoData
+		return forecast, nil
+	}
+
+	forecast.Status = CapacityStatusOK
+	horizon := time.Duration(cfg.HorizonHours) * time.Hour
+	if at, ok := simulateCapacityExhaustion(sims, forecast.DemandPerHour, now, horizon); ok {
+		hours := math.Round(at.Sub(now).Hours()*100) / 100
+		forecast.HoursToExhaustion = &hours
+		forecast.ExhaustsAt = &at
+		switch {
+		case hours < cfg.CriticalHours:
+			forecast.Status = CapacityStatusCritical
+		case hours < cfg.WarningHours:
+			forecast.Status = CapacityStatusWarning
+		}
+	}
+	forecast.RecommendedAccounts = recommendAdditionalAccounts(forecast.DemandPerHour, cfg.Headroom, forecast.Accounts)
+	return forecast, nil
+}
+
+// fillDemand 统计分组近期需求与使用到的配额域
+func (s *CapacityForecastService) fillDemand(ctx context.Context, forecast *GroupCapacityForecast, cfg config.CapacityForecastConfig, now time.Time) error {
+	recentStart := now.Add(-capacityDemandRecentWindow)
+	recent, err := s.usageLogRepo.GetStatsWithFilters(ctx, usagestats.UsageLogFilters{GroupID: forecast.GroupID, StartTime: &recentStart, EndTime: &now})
+	if err != nil {
+		return fmt.Errorf("get recent group usage: %w", err)
+	}
+	lookbackStart := now.Add(-time.Duration(cfg.DemandLookbackHours) * time.Hour)
+	lookback, err := s.usageLogRepo.GetStatsWithFilters(ctx, usagestats.UsageLogFilters{GroupID: forecast.GroupID, StartTime: &lookbackStart, EndTime: &now})
+	if err != nil {
+		return fmt.Errorf("get group usage: %w", err)
+	}
+	forecast.DemandLastHour = float64(recent.TotalRequests)
+	forecast.DemandAveragePerHour = math.Round(float64(lookback.TotalRequests)/float64(cfg.DemandLookbackHours)*100) / 100
+	forecast.DemandPerHour = math.Max(forecast.DemandLastHour, forecast.DemandAveragePerHour)
+
+	models, err := s.usageLogRepo.GetModelStatsWithFilters(ctx, lookbackStart, now, 0, 0, 0, forecast.GroupID, nil, nil)
+	if err != nil {
+		return fmt.Errorf("get group model usage: %w", err)
+	}
+	scopes := make(map[string]struct{})
+	for _, m := range models {
+		if scope := capacityScopeForModel(forecast.Platform, m.Model); scope != "" {
+			scopes[scope] = struct{}{}
+		}
+	}
+	for scope := range scopes {
+		forecast.ActiveScopes = append(forecast.ActiveScopes, scope)
+	}
+	sort.Strings(forecast.ActiveScopes)
+	return nil
+}
+
+// capacityScopeForModel 返回模型所属的配额域；平台没有分域配额时返回空
+func capacityScopeForModel(platform, model string) string {
+	switch platform {
+	case PlatformAntigravity:
+		if scope, ok := resolveAntigravityQuotaScope(model); ok {
+			return string(scope)
+		}
+	case PlatformGemini:
+		return string(geminiModelClassFromName(model))
+	}
+	return ""
+}
+
+// collectWindows 以有限并发获取各账号的配额窗口
+func (s *CapacityForecastService) collectWindows(ctx context.Context, accounts []Account, forecasts []*AccountCapacityForecast, activeScopes []string, cfg config.CapacityForecastConfig, now time.Time) {
+	byID := make(map[int64]*Account, len(accounts))
+	for i := range accounts {
+		byID[accounts[i].ID] = &accounts[i]
+	}
+	concurrency := cfg.UsageFetchConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, f := range forecasts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(f *AccountCapacityForecast) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			account := byID[f.AccountID]
+			if account.RateLimitResetAt != nil && now.Before(*account.RateLimitResetAt) {
+				until := *account.RateLimitResetAt
+				f.RateLimitedUntil = &until
+			}
+			windows, err := s.accountWindows(ctx, account, now)
+			if err != nil {
+				f.Error = err.Error()
+				log.Printf("[CapacityForecast] account %d usage unavailable: %v", account.ID, err)
+				return
+			}
+			windows = filterCapacityWindows(windows, activeScopes)
+			if len(windows) == 0 && account.Type == AccountTypeAPIKey {
+				f.Unbounded = true
+				return
+			}
+			if err := s.fillWindowUsage(ctx, account, windows, now); err != nil {
+				f.Error = err.Error()
+				return
+			}
+			for _, w := range windows {
+				w.WindowHours = w.Window.Hours()
+				w.estimate()
+			}
+			f.Windows = windows
+		}(f)
+	}
+	wg.Wait()
+}
+
+// filterCapacityWindows 只保留分组近期实际使用到的配额域（账号级窗口始终保留）
+func filterCapacityWindows(windows []*CapacityQuotaWindow, activeScopes []string) []*CapacityQuotaWindow {
+	if len(activeScopes) == 0 {
+		return windows
+	}
+	active := make(map[string]struct{}, len(activeScopes))
+	for _, scope := range activeScopes {
+		active[scope] = struct{}{}
+	}
+	out := windows[:0]
+	for _, w := range windows {
+		if _, ok := active[w.Scope]; ok || w.Scope == "" {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// accountWindows 读取账号的配额窗口（使用率与重置时间）
+func (s *CapacityForecastService) accountWindows(ctx context.Context, account *Account, now time.Time) ([]*CapacityQuotaWindow, error) {
+	switch {
+	case account.Platform == PlatformOpenAI && account.Type == AccountTypeOAuth:
+		return codexCapacityWindows(account, now), nil
+	case account.Platform == PlatformAnthropic && (account.Type == AccountTypeOAuth || account.Type == AccountTypeSetupToken),
+		account.Platform == PlatformGemini,
+		account.Platform == PlatformAntigravity:
+		if s.accountUsageService == nil {
+			return nil, nil
+		}
+		usage, err := s.accountUsageService.GetUsage(ctx, account.ID)
+		if err != nil {
+			return nil, err
+		}
+		return usageCapacityWindows(usage, now), nil
+	}
+	return nil, nil
+}
+
+// usageCapacityWindows 将 AccountUsageService 的用量信息转换为配额窗口
+func usageCapacityWindows(usage *UsageInfo, now time.Time) []*CapacityQuotaWindow {
+	if usage == nil {
+		return nil
+	}
+	var windows []*CapacityQuotaWindow
+	progress := func(name, scope string, window time.Duration, p *UsageProgress) {
+		if p == nil {
+			return
+		}
+		w := &CapacityQuotaWindow{Name: name, Scope: scope, Utilization: p.Utilization, Window: window, ResetsAt: p.ResetsAt}
+		if p.LimitRequests > 0 {
+			w.limit = p.LimitRequests
+			w.UsedRequests = p.UsedRequests
+			w.Utilization = math.Round(float64(p.UsedRequests)/float64(p.LimitRequests)*10000) / 100
+		}
+		windows = append(windows, w)
+	}
+	progress(CapacityWindowClaude5h, "", 5*time.Hour, usage.FiveHour)
+	progress(CapacityWindowClaude7d, "", 7*24*time.Hour, usage.SevenDay)
+	progress(CapacityWindowGeminiDaily, "", 24*time.Hour, usage.GeminiSharedDaily)
+	progress(CapacityWindowGeminiDaily, string(geminiModelPro), 24*time.Hour, usage.GeminiProDaily)
+	progress(CapacityWindowGeminiDaily, string(geminiModelFlash), 24*time.Hour, usage.GeminiFlashDaily)
+
+	// Antigravity 按配额域汇总：同一域内多个模型取最高使用率与最早重置时间
+	scopes := make(map[string]*CapacityQuotaWindow)
+	for model, quota := range usage.AntigravityQuota {
+		scope, ok := resolveAntigravityQuotaScope(model)
+		if !ok || quota == nil {
+			continue
+		}
+		w := scopes[string(scope)]
+		if w == nil {
+			w = &CapacityQuotaWindow{Name: CapacityWindowAntigravity, Scope: string(scope), Window: capacityAntigravityWindow}
+			scopes[string(scope)] = w
+		}
+		w.Utilization = math.Max(w.Utilization, float64(quota.Utilization))
+		if resetAt, err := time.Parse(time.RFC3339, quota.ResetTime); err == nil && resetAt.After(now) {
+			if w.ResetsAt == nil || resetAt.Before(*w.ResetsAt) {
+				w.ResetsAt = &resetAt
+			}
+		}
+	}
+	keys := make([]string, 0, len(scopes))
+	for scope := range scopes {
+		keys = append(keys, scope)
+	}
+	sort.Strings(keys)
+	for _, scope := range keys {
+		windows = append(windows, scopes[scope])
+	}
+	return windows
+}
+
+// codexCapacityWindows 读取 OpenAI OAuth 账号最近一次响应头中的 Codex 用量快照
+func codexCapacityWindows(account *Account, now time.Time) []*CapacityQuotaWindow {
+	if account.Extra == nil {
+		return nil
+	}
+	updatedAt := now
+	if raw, ok := account.Extra["codex_usage_updated_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			updatedAt = t
+		}
+	}
+	var windows []*CapacityQuotaWindow
+	add := func(name, prefix string, defaultWindow time.Duration) {
+		used, ok := account.Extra[prefix+"_used_percent"]
+		if !ok {
+			return
+		}
+		w := &CapacityQuotaWindow{Name: name, Utilization: parseExtraFloat64(used), Window: defaultWindow}
+		if minutes := parseExtraFloat64(account.Extra[prefix+"_window_minutes"]); minutes > 0 {
+			w.Window = time.Duration(minutes) * time.Minute
+		}
+		if v, ok := account.Extra[prefix+"_reset_after_seconds"]; ok {
+			resetAt := updatedAt.Add(time.Duration(parseExtraFloat64(v)) * time.Second)
+			if !resetAt.After(now) {
+				// 快照之后窗口已重置
+				w.Utilization = 0
+				resetAt = resetAt.Add(w.Window * time.Duration(1+now.Sub(resetAt)/w.Window))
+			}
+			w.ResetsAt = &resetAt
+		}
+		windows = append(windows, w)
+	}
+	add(CapacityWindowCodex5h, "codex_5h", 5*time.Hour)
+	add(CapacityWindowCodex7d, "codex_7d", 7*24*time.Hour)
+	return windows
+}
+
+// fillWindowUsage 统计每个窗口开始以来的请求数（硬限额窗口已自带计数）
+func (s *CapacityForecastService) fillWindowUsage(ctx context.Context, account *Account, windows []*CapacityQuotaWindow, now time.Time) error {
+	for _, w := range windows {
+		if w.limit > 0 {
+			continue
+		}
+		start := now.Add(-w.Window)
+		if w.ResetsAt != nil && w.ResetsAt.After(now) {
+			start = w.ResetsAt.Add(-w.Window)
+		}
+		if w.Scope == "" {
+			stats, err := s.usageLogRepo.GetAccountWindowStats(ctx, account.ID, start)
+			if err != nil {
+				return fmt.Errorf("get account window stats: %w", err)
+			}
+			w.UsedRequests = stats.Requests
+			continue
+		}
+		models, err := s.usageLogRepo.GetModelStatsWithFilters(ctx, start, now, 0, 0, account.ID, 0, nil, nil)
+		if err != nil {
+			return fmt.Errorf("get account model stats: %w", err)
+		}
+		for _, m := range models {
+			if strings.EqualFold(capacityScopeForModel(account.Platform, m.Model), w.Scope) {
+				w.UsedRequests += m.Requests
+			}
+		}
+	}
+	return nil
+}
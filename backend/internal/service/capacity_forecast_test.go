@@ -0,0 +1,129 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapacityQuotaWindowEstimate(t *testing.T) {
+	w := &CapacityQuotaWindow{Utilization: 40, UsedRequests: 200, Window: 5 * time.Hour}
+	w.estimate()
+	require.True(t, w.Estimated)
+	require.InDelta(t, 500, w.CapacityRequests, 0.001)
+	require.InDelta(t, 300, w.RemainingRequests, 0.001)
+
+	// 硬限额窗口直接使用限额
+	limited := &CapacityQuotaWindow{Utilization: 10, UsedRequests: 150, limit: 1000}
+	limited.estimate()
+	require.False(t, limited.Estimated)
+	require.InDelta(t, 1000, limited.CapacityRequests, 0.001)
+	require.InDelta(t, 850, limited.RemainingRequests, 0.001)
+
+	// 样本不足时容量未知
+	sparse := &CapacityQuotaWindow{Utilization: 1, UsedRequests: 2}
+	sparse.estimate()
+	require.False(t, sparse.known())
+}
+
+func TestImputeCapacityUsesPeerMedian(t *testing.T) {
+	mk := func(id int64, util float64, used int64) *AccountCapacityForecast {
+		w := &CapacityQuotaWindow{Name: CapacityWindowClaude5h, Utilization: util, UsedRequests: used, Window: 5 * time.Hour}
+		w.estimate()
+		return &AccountCapacityForecast{AccountID: id, Platform: PlatformAnthropic, Windows: []*CapacityQuotaWindow{w}}
+	}
+	accounts := []*AccountCapacityForecast{mk(1, 50, 100), mk(2, 50, 200), mk(3, 50, 300), mk(4, 0, 0)}
+	imputeCapacity(accounts)
+
+	w := accounts[3].Windows[0]
+	require.True(t, w.Imputed)
+	require.InDelta(t, 400, w.CapacityRequests, 0.001)
+	require.InDelta(t, 400, w.RemainingRequests, 0.001)
+}
+
+func TestSimulateCapacityExhaustion(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	resetAt := now.Add(5 * time.Hour)
+	account := func(remaining float64) *AccountCapacityForecast {
+		return &AccountCapacityForecast{Windows: []*CapacityQuotaWindow{{
+			Window: 5 * time.Hour, ResetsAt: &resetAt, CapacityRequests: 100, RemainingRequests: remaining,
+		}}}
+	}
+
+	// 两个账号剩余 100 请求，需求 50 请求/小时：约 2 小时后耗尽（窗口 5 小时后才重置）
+	sims := []*capacitySimAccount{newCapacitySimAccount(account(50), now), newCapacitySimAccount(account(50), now)}
+	at, ok := simulateCapacityExhaustion(sims, 50, now, 24*time.Hour)
+	require.True(t, ok)
+	require.InDelta(t, 2, at.Sub(now).Hours(), 0.1)
+
+	// 需求低于窗口恢复速度：预测期内不会耗尽
+	sims = []*capacitySimAccount{newCapacitySimAccount(account(100), now), newCapacitySimAccount(account(100), now)}
+	_, ok = simulateCapacityExhaustion(sims, 30, now, 24*time.Hour)
+	require.False(t, ok)
+
+	// 限流中的账号在限流结束前不提供容量
+	limitedUntil := now.Add(3 * time.Hour)
+	limited := account(100)
+	limited.RateLimitedUntil = &limitedUntil
+	sims = []*capacitySimAccount{newCapacitySimAccount(limited, now)}
+	at, ok = simulateCapacityExhaustion(sims, 10, now, 24*time.Hour)
+	require.True(t, ok)
+	require.Equal(t, now, at)
+}
+
+func TestRecommendAdditionalAccounts(t *testing.T) {
+	accounts := []*AccountCapacityForecast{{RequestsPerHour: 20}, {RequestsPerHour: 20}, {RequestsPerHour: 40}}
+	// 需求 100 * 1.2 = 120，供给 80，缺口 40，单账号中位数 20
+	require.Equal(t, 2, recommendAdditionalAccounts(100, 0.2, accounts))
+	require.Equal(t, 0, recommendAdditionalAccounts(50, 0.2, accounts))
+	require.Equal(t, 0, recommendAdditionalAccounts(100, 0.2, nil))
+}
+
+func TestCapacityWindowsFromUsage(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	usage := &UsageInfo{
+		FiveHour: &UsageProgress{Utilization: 30},
+		AntigravityQuota: map[string]*AntigravityModelQuota{
+			"claude-sonnet-4-5": {Utilization: 20, ResetTime: now.Add(2 * time.Hour).Format(time.RFC3339)},
+			"claude-opus-4-5":   {Utilization: 60, ResetTime: now.Add(time.Hour).Format(time.RFC3339)},
+			"gemini-3-pro":      {Utilization: 10},
+		},
+	}
+	windows := usageCapacityWindows(usage, now)
+	require.Len(t, windows, 3)
+	require.Equal(t, CapacityWindowClaude5h, windows[0].Name)
+	require.Equal(t, "claude", windows[1].Scope)
+	require.InDelta(t, 60, windows[1].Utilization, 0.001)
+	require.Equal(t, now.Add(time.Hour), *windows[1].ResetsAt)
+	require.Equal(t, "gemini_text", windows[2].Scope)
+
+	filtered := filterCapacityWindows(windows, []string{"gemini_text"})
+	require.Len(t, filtered, 2)
+	require.Equal(t, "gemini_text", filtered[1].Scope)
+}
+
+func TestCodexCapacityWindows(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	account := &Account{Extra: map[string]any{
+		"codex_usage_updated_at":       now.Add(-time.Hour).Format(time.RFC3339),
+		"codex_5h_used_percent":        40.0,
+		"codex_5h_reset_after_seconds": 1800.0, // 快照后 30 分钟已重置
+		"codex_5h_window_minutes":      300.0,
+		"codex_7d_used_percent":        25.0,
+		"codex_7d_reset_after_seconds": 86400.0,
+	}}
+	windows := codexCapacityWindows(account, now)
+	require.Len(t, windows, 2)
+
+	require.Equal(t, CapacityWindowCodex5h, windows[0].Name)
+	require.Zero(t, windows[0].Utilization)
+	require.Equal(t, now.Add(-30*time.Minute).Add(5*time.Hour), *windows[0].ResetsAt)
+
+	require.Equal(t, CapacityWindowCodex7d, windows[1].Name)
+	require.InDelta(t, 25, windows[1].Utilization, 0.001)
+	require.Equal(t, 7*24*time.Hour, windows[1].Window)
+	require.Equal(t, now.Add(23*time.Hour), *windows[1].ResetsAt)
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 账号熔断状态
+const (
+	CircuitStateClosed   = "closed"    // 正常调度
+	CircuitStateOpen     = "open"      // 熔断中，不参与调度
+	CircuitStateHalfOpen = "half_open" // 半开，仅放行单个探测请求
+)
+
+// ErrAccountCircuitOpen 账号处于熔断状态，不参与调度
+var ErrAccountCircuitOpen = errors.New("account circuit breaker is open")
+
+// CircuitBreakerState 账号熔断状态快照
+type CircuitBreakerState struct {
+	State      string     `json:"state"`
+	Reason     string     `json:"reason,omitempty"` // error_rate / slow_call_rate / probe_failed
+	ErrorRate  float64    `json:"error_rate,omitempty"`
+	OpenedAt   *time.Time `json:"opened_at,omitempty"`
+	OpenUntil  *time.Time `json:"open_until,omitempty"`
+	ProbeUntil *time.Time `json:"probe_until,omitempty"`
+}
+
+// CircuitBreakerPolicy 熔断判定参数
+type CircuitBreakerPolicy struct {
+	Window                time.Duration
+	MinRequests           int
+	ErrorRateThreshold    float64
+	SlowCallRateThreshold float64 // 0 表示不按慢请求率熔断
+	OpenDuration          time.Duration
+	ProbeTimeout          time.Duration
+}
+
+// CircuitBreakerCache 熔断状态存储，状态转换需原子完成以保证多实例一致
+type CircuitBreakerCache interface {
+	// RecordResult 记录一次请求结果并按策略推进状态机，返回最新状态及是否发生状态转换
+	RecordResult(ctx context.Context, accountID int64, failed, slow bool, now time.Time, policy CircuitBreakerPolicy) (state string, transitioned bool, err error)
+	// AcquireProbe 熔断到期时转入半开并占用探测名额（占用至 now + ProbeTimeout）；关闭状态直接放行
+	AcquireProbe(ctx context.Context, accountID int64, now time.Time, policy CircuitBreakerPolicy) (bool, error)
+	// ReleaseProbe 归还半开探测名额；仅当账号仍处于半开且名额仍由该次占用（probeUntil 一致）时生效
+	ReleaseProbe(ctx context.Context, accountID int64, probeUntil time.Time) error
+	// GetStates 批量获取账号熔断状态，仅返回非关闭状态的账号
+	GetStates(ctx context.Context, accountIDs []int64) (map[int64]*CircuitBreakerState, error)
+	// Reset 清除账号熔断状态与统计
+	Reset(ctx context.Context, accountID int64) error
+}
+
+// CircuitBreakerService 账号熔断服务
+// 失败（5xx / 529 / 网络错误）与慢请求按滚动窗口统计，超过阈值后熔断；熔断到期后半开放行一个探测请求。
+type CircuitBreakerService struct {
+	cache CircuitBreakerCache
+	cfg   *config.Config
+}
+
+// NewCircuitBreakerService 创建账号熔断服务
+func NewCircuitBreakerService(cache CircuitBreakerCache, cfg *config.Config) *CircuitBreakerService {
+	return &CircuitBreakerService{cache: cache, cfg: cfg}
+}
+
+// Enabled 是否启用账号熔断
+func (s *CircuitBreakerService) Enabled() bool {
+	return s != nil && s.cache != nil && s.cfg != nil && s.cfg.Gateway.Scheduling.CircuitBreaker.Enabled
+}
+
+func (s *CircuitBreakerService) policy() CircuitBreakerPolicy {
+	cb := s.cfg.Gateway.Scheduling.CircuitBreaker
+	policy := CircuitBreakerPolicy{
+		Window:             cb.Window,
+		MinRequests:        cb.MinRequests,
+		ErrorRateThreshold: cb.ErrorRateThreshold,
+		OpenDuration:       cb.OpenDuration,
+		ProbeTimeout:       cb.ProbeTimeout,
+	}
+	if cb.SlowCallDuration > 0 {
+		policy.SlowCallRateThreshold = cb.SlowCallRateThreshold
+	}
+	return policy
+}
+
+// RecordSuccess 记录一次成功请求；latency 超过慢请求阈值时计为慢请求
+func (s *CircuitBreakerService) RecordSuccess(ctx context.Context, accountID int64, latency time.Duration) {
+	if !s.Enabled() {
+		return
+	}
+	slowThreshold := s.cfg.Gateway.Scheduling.CircuitBreaker.SlowCallDuration
+	s.record(ctx, accountID, false, slowThreshold > 0 && latency >= slowThreshold)
+}
+
+// RecordFailure 记录一次失败请求
+func (s *CircuitBreakerService) RecordFailure(ctx context.Context, accountID int64) {
+	if !s.Enabled() {
+		return
+	}
+	s.record(ctx, accountID, true, false)
+}
+
+func (s *CircuitBreakerService) record(ctx context.Context, accountID int64, failed, slow bool) {
+	state, transitioned, err := s.cache.RecordResult(ctx, accountID, failed, slow, time.Now(), s.policy())
+	if err != nil {
+		slog.Warn("circuit_breaker_record_failed", "account_id", accountID, "error", err)
+		return
+	}
+	if transitioned {
+		slog.Info("circuit_breaker_state_changed", "account_id", accountID, "state", state)
+	}
+}
+
+// FilterAccounts 过滤掉熔断中的账号（仅检查状态，不占用探测名额）。
+// 熔断到期或半开探测名额空闲的账号保留在候选中，由 SelectWithProbe 为最终选中的账号占用名额；Redis 异常时失败开放。
+func (s *CircuitBreakerService) FilterAccounts(ctx context.Context, accounts []Account) []Account {
+	if !s.Enabled() || len(accounts) == 0 {
+		return accounts
+	}
+	ids := make([]int64, 0, len(accounts))
+	for i := range accounts {
+		ids = append(ids, accounts[i].ID)
+	}
+	states, err := s.cache.GetStates(ctx, ids)
+	if err != nil {
+		slog.Warn("circuit_breaker_get_states_failed", "error", err)
+		return accounts
+	}
+	if len(states) == 0 {
+		return accounts
+	}
+
+	now := time.Now()
+	filtered := make([]Account, 0, len(accounts))
+	for i := range accounts {
+		if allowCircuitState(states[accounts[i].ID], now) {
+			filtered = append(filtered, accounts[i])
+		}
+	}
+	return filtered
+}
+
+// Allow 检查单个账号是否可调度（用于粘性会话命中），仅检查状态
+func (s *CircuitBreakerService) Allow(ctx context.Context, accountID int64) bool {
+	if !s.Enabled() {
+		return true
+	}
+	state, err := s.getState(ctx, accountID)
+	if err != nil {
+		slog.Warn("circuit_breaker_get_states_failed", "account_id", accountID, "error", err)
+		return true
+	}
+	return allowCircuitState(state, time.Now())
+}
+
+func (s *CircuitBreakerService) getState(ctx context.Context, accountID int64) (*CircuitBreakerState, error) {
+	states, err := s.cache.GetStates(ctx, []int64{accountID})
+	if err != nil {
+		return nil, err
+	}
+	return states[accountID], nil
+}
+
+// allowCircuitState 按状态快照判断账号是否可参与调度：熔断中或半开探测进行中的账号不可调度
+func allowCircuitState(state *CircuitBreakerState, now time.Time) bool {
+	if state == nil {
+		return true
+	}
+	switch state.State {
+	case CircuitStateOpen:
+		return state.OpenUntil == nil || !now.Before(*state.OpenUntil)
+	case CircuitStateHalfOpen:
+		return state.ProbeUntil == nil || !now.Before(*state.ProbeUntil)
+	}
+	return true
+}
+
+// SelectWithProbe 在调度结果上为最终选中的账号占用半开探测名额。
+// 只有已获取并发槽位的账号才占用名额，名额随槽位在 ReleaseFunc 中一并归还（请求结果已推进状态时归还为空操作）；
+// 账号需要探测但未获取到槽位、或名额已被其他请求占用时，释放槽位并排除该账号重新调度。
+func (s *CircuitBreakerService) SelectWithProbe(ctx context.Context, excludedIDs map[int64]struct{}, selectFn func(excludedIDs map[int64]struct{}) (*AccountSelectionResult, error)) (*AccountSelectionResult, error) {
+	for {
+		result, err := selectFn(excludedIDs)
+		if err != nil || result == nil || result.Account == nil || !s.Enabled() {
+			return result, err
+		}
+		accountID := result.Account.ID
+		if result.Acquired {
+			release, ok := s.acquireProbe(ctx, accountID)
+			if ok {
+				if release != nil {
+					result.ReleaseFunc = chainReleaseFuncs(result.ReleaseFunc, release)
+				}
+				return result, nil
+			}
+			if result.ReleaseFunc != nil {
+				result.ReleaseFunc()
+			}
+		} else if !s.needsProbe(ctx, accountID) {
+			return result, nil
+		}
+		slog.Debug("circuit_breaker_probe_busy", "account_id", accountID)
+		excluded := make(map[int64]struct{}, len(excludedIDs)+1)
+		for id := range excludedIDs {
+			excluded[id] = struct{}{}
+		}
+		excluded[accountID] = struct{}{}
+		excludedIDs = excluded
+	}
+}
+
+// needsProbe 账号当前是否处于需要探测的状态（熔断到期或半开）；Redis 异常时失败开放
+func (s *CircuitBreakerService) needsProbe(ctx context.Context, accountID int64) bool {
+	state, err := s.getState(ctx, accountID)
+	if err != nil {
+		slog.Warn("circuit_breaker_get_states_failed", "account_id", accountID, "error", err)
+		return false
+	}
+	return state != nil && state.State != CircuitStateClosed
+}
+
+// acquireProbe 为账号占用半开探测名额，返回的 release 用于归还名额。
+// 关闭状态直接放行（release 为 nil）；Redis 异常时失败开放。
+func (s *CircuitBreakerService) acquireProbe(ctx context.Context, accountID int64) (func(), bool) {
+	state, err := s.getState(ctx, accountID)
+	if err != nil {
+		slog.Warn("circuit_breaker_get_states_failed", "account_id", accountID, "error", err)
+		return nil, true
+	}
+	if state == nil || state.State == CircuitStateClosed {
+		return nil, true
+	}
+	now := time.Now()
+	if !allowCircuitState(state, now) {
+		return nil, false
+	}
+	policy := s.policy()
+	ok, err := s.cache.AcquireProbe(ctx, accountID, now, policy)
+	if err != nil {
+		slog.Warn("circuit_breaker_acquire_probe_failed", "account_id", accountID, "error", err)
+		return nil, true
+	}
+	if !ok {
+		return nil, false
+	}
+	probeUntil := time.UnixMilli(now.UnixMilli() + policy.ProbeTimeout.Milliseconds())
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			if err := s.cache.ReleaseProbe(releaseCtx, accountID, probeUntil); err != nil {
+				slog.Warn("circuit_breaker_release_probe_failed", "account_id", accountID, "error", err)
+			}
+		})
+	}
+	return release, true
+}
+
+func chainReleaseFuncs(funcs ...func()) func() {
+	return func() {
+		for _, fn := range funcs {
+			if fn != nil {
+				fn()
+			}
+		}
+	}
+}
+
+// GetStates 批量获取账号熔断状态（未启用时返回空）
+func (s *CircuitBreakerService) GetStates(ctx context.Context, accountIDs []int64) (map[int64]*CircuitBreakerState, error) {
+	if !s.Enabled() || len(accountIDs) == 0 {
+		return map[int64]*CircuitBreakerState{}, nil
+	}
+	return s.cache.GetStates(ctx, accountIDs)
+}
+
+// Reset 手动恢复账号熔断状态
+func (s *CircuitBreakerService) Reset(ctx context.Context, accountID int64) error {
+	if s == nil || s.cache == nil {
+		return nil
+	}
+	if err := s.cache.Reset(ctx, accountID); err != nil {
+		return err
+	}
+	slog.Info("circuit_breaker_reset", "account_id", accountID)
+	return nil
+}
+
+// forwardLatency 熔断慢请求判定使用的耗时：流式请求取首 token 耗时，否则取总耗时
+func forwardLatency(stream bool, duration time.Duration, firstTokenMs *int) time.Duration {
+	if stream && firstTokenMs != nil {
+		return time.Duration(*firstTokenMs) * time.Millisecond
+	}
+	return duration
+}
@@ -0,0 +1,196 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// circuitBreakerCacheStub 内存版熔断状态机，仅用于验证服务层调用逻辑
+type circuitBreakerCacheStub struct {
+	states   map[int64]*CircuitBreakerState
+	failures map[int64]int
+	probes   []int64
+}
+
+func (c *circuitBreakerCacheStub) RecordResult(_ context.Context, accountID int64, failed, _ bool, now time.Time, policy CircuitBreakerPolicy) (string, bool, error) {
+	if c.failures == nil {
+		c.failures = make(map[int64]int)
+	}
+	if failed {
+		c.failures[accountID]++
+	}
+	if c.failures[accountID] >= policy.MinRequests && c.states[accountID] == nil {
+		until := now.Add(policy.OpenDuration)
+		c.states[accountID] = &CircuitBreakerState{State: CircuitStateOpen, OpenUntil: &until}
+		return CircuitStateOpen, true, nil
+	}
+	return CircuitStateClosed, false, nil
+}
+
+func (c *circuitBreakerCacheStub) AcquireProbe(_ context.Context, accountID int64, now time.Time, policy CircuitBreakerPolicy) (bool, error) {
+	if !allowCircuitState(c.states[accountID], now) {
+		return false, nil
+	}
+	c.probes = append(c.probes, accountID)
+	probeUntil := now.Add(policy.ProbeTimeout)
+	c.states[accountID] = &CircuitBreakerState{State: CircuitStateHalfOpen, ProbeUntil: &probeUntil}
+	return true, nil
+}
+
+func (c *circuitBreakerCacheStub) ReleaseProbe(_ context.Context, accountID int64, _ time.Time) error {
+	if st := c.states[accountID]; st != nil && st.State == CircuitStateHalfOpen {
+		st.ProbeUntil = nil
+	}
+	return nil
+}
+
+func (c *circuitBreakerCacheStub) GetStates(_ context.Context, accountIDs []int64) (map[int64]*CircuitBreakerState, error) {
+	out := make(map[int64]*CircuitBreakerState)
+	for _, id := range accountIDs {
+		if st := c.states[id]; st != nil {
+			out[id] = st
+		}
+	}
+	return out, nil
+}
+
+func (c *circuitBreakerCacheStub) Reset(_ context.Context, accountID int64) error {
+	delete(c.states, accountID)
+	delete(c.failures, accountID)
+	return nil
+}
+
+func newCircuitBreakerForTest(cache CircuitBreakerCache) *CircuitBreakerService {
+	cfg := &config.Config{}
+	cfg.Gateway.Scheduling.CircuitBreaker = config.GatewayCircuitBreakerConfig{
+		Enabled:            true,
+		Window:             time.Minute,
+		MinRequests:        3,
+		ErrorRateThreshold: 0.5,
+		OpenDuration:       30 * time.Second,
+		ProbeTimeout:       time.Minute,
+	}
+	return NewCircuitBreakerService(cache, cfg)
+}
+
+func TestCircuitBreakerService_FilterAccounts(t *testing.T) {
+	future := time.Now().Add(time.Minute)
+	past := time.Now().Add(-time.Second)
+	cache := &circuitBreakerCacheStub{states: map[int64]*CircuitBreakerState{
+		2: {State: CircuitStateOpen, OpenUntil: &future},
+		3: {State: CircuitStateOpen, OpenUntil: &past},
+		4: {State: CircuitStateHalfOpen, ProbeUntil: &future},
+	}}
+	svc := newCircuitBreakerForTest(cache)
+
+	filtered := svc.FilterAccounts(context.Background(), []Account{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}})
+	ids := make([]int64, 0, len(filtered))
+	for _, acc := range filtered {
+		ids = append(ids, acc.ID)
+	}
+	require.Equal(t, []int64{1, 3}, ids, "熔断到期的账号保留为探测候选，熔断中/探测占用中的账号被过滤")
+	require.Empty(t, cache.probes, "过滤只检查状态，不占用探测名额")
+	require.True(t, svc.Allow(context.Background(), 3))
+	require.False(t, svc.Allow(context.Background(), 4))
+
+	require.NoError(t, svc.Reset(context.Background(), 2))
+	require.True(t, svc.Allow(context.Background(), 2))
+}
+
+func TestCircuitBreakerService_SelectWithProbe(t *testing.T) {
+	past := time.Now().Add(-time.Second)
+	cache := &circuitBreakerCacheStub{states: map[int64]*CircuitBreakerState{
+		3: {State: CircuitStateOpen, OpenUntil: &past},
+	}}
+	svc := newCircuitBreakerForTest(cache)
+
+	slotsReleased := map[int64]int{}
+	selectFn := func(excluded map[int64]struct{}) (*AccountSelectionResult, error) {
+		for _, id := range []int64{3, 1} {
+			if _, ok := excluded[id]; ok {
+				continue
+			}
+			id := id
+			return &AccountSelectionResult{Account: &Account{ID: id}, Acquired: true, ReleaseFunc: func() { slotsReleased[id]++ }}, nil
+		}
+		return nil, errors.New("no available accounts")
+	}
+
+	// 最终选中的账号 3 占用探测名额
+	first, err := svc.SelectWithProbe(context.Background(), nil, selectFn)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), first.Account.ID)
+	require.Equal(t, []int64{3}, cache.probes)
+
+	// 名额已被占用：释放槽位并改选其他账号
+	second, err := svc.SelectWithProbe(context.Background(), nil, selectFn)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), second.Account.ID)
+	require.Equal(t, 1, slotsReleased[3])
+
+	// 请求结束（未产生结果）时随槽位归还探测名额
+	first.ReleaseFunc()
+	require.Equal(t, 2, slotsReleased[3])
+	require.Nil(t, cache.states[3].ProbeUntil)
+	third, err := svc.SelectWithProbe(context.Background(), nil, selectFn)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), third.Account.ID)
+
+	// 未获取到槽位（等待计划）的探测账号被排除
+	waitFn := func(excluded map[int64]struct{}) (*AccountSelectionResult, error) {
+		if _, ok := excluded[3]; !ok {
+			return &AccountSelectionResult{Account: &Account{ID: 3}, WaitPlan: &AccountWaitPlan{AccountID: 3}}, nil
+		}
+		return &AccountSelectionResult{Account: &Account{ID: 1}, WaitPlan: &AccountWaitPlan{AccountID: 1}}, nil
+	}
+	waited, err := svc.SelectWithProbe(context.Background(), nil, waitFn)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), waited.Account.ID)
+}
+
+func TestCircuitBreakerService_Disabled(t *testing.T) {
+	future := time.Now().Add(time.Minute)
+	cache := &circuitBreakerCacheStub{states: map[int64]*CircuitBreakerState{1: {State: CircuitStateOpen, OpenUntil: &future}}}
+	svc := newCircuitBreakerForTest(cache)
+	svc.cfg.Gateway.Scheduling.CircuitBreaker.Enabled = false
+
+	require.Len(t, svc.FilterAccounts(context.Background(), []Account{{ID: 1}}), 1)
+	require.True(t, svc.Allow(context.Background(), 1))
+
+	var nilSvc *CircuitBreakerService
+	require.True(t, nilSvc.Allow(context.Background(), 1))
+	nilSvc.RecordFailure(context.Background(), 1)
+}
+
+func TestRateLimitService_RecordsCircuitBreakerFailures(t *testing.T) {
+	cache := &circuitBreakerCacheStub{states: map[int64]*CircuitBreakerState{}}
+	rl := &RateLimitService{}
+	rl.SetCircuitBreaker(newCircuitBreakerForTest(cache))
+	ctx := context.Background()
+
+	rl.RecordUpstreamRequestError(ctx, 1)
+	rl.RecordUpstreamRequestError(ctx, 1)
+	require.Nil(t, cache.states[1])
+	rl.RecordUpstreamRequestError(ctx, 1)
+	require.Equal(t, CircuitStateOpen, cache.states[1].State)
+
+	// 客户端取消不计入失败
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	rl.RecordUpstreamRequestError(canceled, 2)
+	require.Zero(t, cache.failures[2])
+}
+
+func TestForwardLatency(t *testing.T) {
+	ms := 1500
+	require.Equal(t, 1500*time.Millisecond, forwardLatency(true, 10*time.Second, &ms))
+	require.Equal(t, 10*time.Second, forwardLatency(false, 10*time.Second, &ms))
+	require.Equal(t, 10*time.Second, forwardLatency(true, 10*time.Second, nil))
+}
@@ -39,6 +39,15 @@ func NewClaudeTokenProvider(
 
 // GetAccessToken 获取有效的 access_token
 func (p *ClaudeTokenProvider) GetAccessToken(ctx context.Context, account *Account) (string, error) {
+	if token, ok := dryRunAccessToken(ctx, account); ok {
+		return token, nil
+	}
+	return traceGetAccessToken(ctx, account, func(ctx context.Context) (string, error) {
+		return p.getAccessToken(ctx, account)
+	})
+}
+
+func (p *ClaudeTokenProvider) getAccessToken(ctx context.Context, account *Account) (string, error) {
 	if account == nil {
 		return "", errors.New("account is nil")
 	}
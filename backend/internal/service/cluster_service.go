@@ -0,0 +1,789 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/sysutil"
+	"github.com/google/uuid"
+)
+
+// 滚动操作类型
+const (
+	ClusterRolloutActionUpdate  = "update"
+	ClusterRolloutActionRestart = "restart"
+)
+
+// 滚动与步骤状态
+const (
+	ClusterRolloutStatusRunning   = "running"
+	ClusterRolloutStatusCompleted = "completed"
+	ClusterRolloutStatusFailed    = "failed"
+
+	ClusterStepStatusPending    = "pending"
+	ClusterStepStatusRunning    = "running"
+	ClusterStepStatusRestarting = "restarting"
+	ClusterStepStatusDone       = "done"
+	ClusterStepStatusFailed     = "failed"
+	ClusterStepStatusSkipped    = "skipped"
+)
+
+// 节点执行命令的结果状态
+const (
+	ClusterCommandStatusRestarting = "restarting"
+	ClusterCommandStatusUpToDate   = "up_to_date"
+	ClusterCommandStatusFailed     = "failed"
+)
+
+const (
+	// 心跳超过该时长的节点记录会被清理
+	clusterNodePruneAfter = 24 * time.Hour
+	// 等待远端节点时的轮询间隔
+	clusterRolloutPollInterval = 2 * time.Second
+	// 重启前等待进行中网关请求结束的最长时间（不超过单节点滚动超时的一半）
+	clusterDrainTimeout      = 30 * time.Second
+	clusterDrainPollInterval = 100 * time.Millisecond
+)
+
+var (
+	ErrClusterRolloutInProgress    = infraerrors.Conflict("CLUSTER_ROLLOUT_IN_PROGRESS", "a cluster rollout is already in progress")
+	ErrClusterRolloutInvalidAction = infraerrors.BadRequest("CLUSTER_ROLLOUT_INVALID_ACTION", "action must be update or restart")
+)
+
+// processInstanceID 标识当前进程，重启后变化；各后台任务的 Redis leader 锁以它作为锁值，
+// 集群视图据此把锁映射到节点。
+var processInstanceID = uuid.NewString()
+
+var (
+	gatewayInFlight      atomic.Int64
+	gatewayRequestsTotal atomic.Int64
+	gatewayDraining      atomic.Bool
+)
+
+// GatewayDraining 节点即将重启时返回 true，此时不再接收新的网关请求
+func GatewayDraining() bool {
+	return gatewayDraining.Load()
+}
+
+// BeginGatewayRequest 记录一个进行中的网关请求，返回请求结束时调用的回调
+func BeginGatewayRequest() func() {
+	gatewayInFlight.Add(1)
+	gatewayRequestsTotal.Add(1)
+	return func() { gatewayInFlight.Add(-1) }
+}
+
+// ClusterNode 节点心跳内容
+type ClusterNode struct {
+	NodeID     string `json:"node_id"`
+	InstanceID string `json:"instance_id"`
+	Hostname   string `json:"hostname"`
+	Version    string `json:"version"`
+	BuildType  string `json:"build_type"`
+	ConfigHash string `json:"config_hash"`
+
+	StartedAt     time.Time `json:"started_at"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+
+	InFlightRequests  int64   `json:"in_flight_requests"`
+	RequestsTotal     int64   `json:"requests_total"`
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Goroutines        int     `json:"goroutines"`
+	HeapAllocBytes    uint64  `json:"heap_alloc_bytes"`
+
+	LastCommand *ClusterCommandResult `json:"last_command,omitempty"`
+}
+
+// ClusterCommand 下发给单个节点的命令
+type ClusterCommand struct {
+	ID        string    `json:"id"`
+	Action    string    `json:"action"`
+	RolloutID string    `json:"rollout_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ClusterCommandResult 节点执行命令的结果，随心跳上报
+type ClusterCommandResult struct {
+	ID     string    `json:"id"`
+	Action string    `json:"action"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// ClusterRollout 集群滚动更新/重启进度
+type ClusterRollout struct {
+	ID              string               `json:"id"`
+	Action          string               `json:"action"`
+	Status          string               `json:"status"`
+	InitiatorNodeID string               `json:"initiator_node_id"`
+	Error           string               `json:"error,omitempty"`
+	StartedAt       time.Time            `json:"started_at"`
+	FinishedAt      *time.Time           `json:"finished_at,omitempty"`
+	Steps           []ClusterRolloutStep `json:"steps"`
+}
+
+// ClusterRolloutStep 单个节点的滚动步骤
+type ClusterRolloutStep struct {
+	NodeID      string     `json:"node_id"`
+	Status      string     `json:"status"`
+	FromVersion string     `json:"from_version"`
+	ToVersion   string     `json:"to_version,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// ClusterNodeStatus 管理端展示的节点状态
+type ClusterNodeStatus struct {
+	ClusterNode
+	Online              bool     `json:"online"`
+	Self                bool     `json:"self"`
+	UptimeSeconds       int64    `json:"uptime_seconds"`
+	HeartbeatAgeSeconds int64    `json:"heartbeat_age_seconds"`
+	HeldLocks           []string `json:"held_locks"`
+}
+
+// ClusterLockStatus leader 锁持有情况
+type ClusterLockStatus struct {
+	Key              string `json:"key"`
+	HolderNodeID     string `json:"holder_node_id,omitempty"`
+	HolderInstanceID string `json:"holder_instance_id,omitempty"`
+}
+
+// ClusterOverview 集群视图
+type ClusterOverview struct {
+	SelfNodeID           string              `json:"self_node_id"`
+	Nodes                []ClusterNodeStatus `json:"nodes"`
+	Locks                []ClusterLockStatus `json:"locks"`
+	ConfigHashConsistent bool                `json:"config_hash_consistent"`
+	VersionConsistent    bool                `json:"version_consistent"`
+	Rollout              *ClusterRollout     `json:"rollout,omitempty"`
+}
+
+// ClusterCache 集群节点注册、命令队列与滚动进度的存储（Redis）
+type ClusterCache interface {
+	SaveNode(ctx context.Context, node *ClusterNode) error
+	ListNodes(ctx context.Context) ([]*ClusterNode, error)
+	DeleteNode(ctx context.Context, nodeID string) error
+	// GetLockHolders 返回各锁键当前的锁值（持有者进程 ID），未被持有的键不出现在结果中
+	GetLockHolders(ctx context.Context, keys []string) (map[string]string, error)
+	PushCommand(ctx context.Context, nodeID string, cmd *ClusterCommand, ttl time.Duration) error
+	PopCommand(ctx context.Context, nodeID string) (*ClusterCommand, error)
+	AcquireRolloutLock(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	ReleaseRolloutLock(ctx context.Context, owner string) error
+	SaveRollout(ctx context.Context, rollout *ClusterRollout) error
+	GetRollout(ctx context.Context) (*ClusterRollout, error)
+}
+
+// ClusterService 多实例集群感知
+//
+//   - 每个节点按 cluster.heartbeat_interval_seconds 把版本、启动时间、进行中请求数、运行时指标与配置摘要写入 Redis；
+//   - 管理端汇总所有节点，并把各后台任务的 leader 锁映射到持有节点；
+//   - 滚动更新/重启由收到请求的节点编排：逐个向其他节点下发命令，等待该节点以新进程重新上报心跳后再处理下一个，
+//     最后处理自身；新进程启动时补全自身步骤并结束滚动。
+type ClusterService struct {
+	cache      ClusterCache
+	updateSvc  *UpdateService
+	opsService *OpsService
+	cfg        *config.Config
+
+	nodeID     string
+	instanceID string
+	hostname   string
+	version    string
+	buildType  string
+	configHash string
+	startedAt  time.Time
+
+	// 以下字段便于测试替换
+	restartProcess func()
+	pollInterval   time.Duration
+
+	lastCommand *ClusterCommandResult
+	lastTotal   int64
+	lastTotalAt time.Time
+	heartbeatMu sync.Mutex
+	startOnce   sync.Once
+	stopOnce    sync.Once
+	stopCtx     context.Context
+	stop        context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewClusterService 创建集群服务
+func NewClusterService(cache ClusterCache, updateSvc *UpdateService, opsService *OpsService, buildInfo BuildInfo, cfg *config.Config) *ClusterService {
+	hostname, _ := os.Hostname()
+	nodeID := ""
+	if cfg != nil {
+		nodeID = cfg.Cluster.NodeID
+	}
+	if nodeID == "" {
+		port := 0
+		if cfg != nil {
+			port = cfg.Server.Port
+		}
+		nodeID = hostname + ":" + strconv.Itoa(port)
+	}
+	stopCtx, stop := context.WithCancel(context.Background())
+	return &ClusterService{
+		cache:          cache,
+		updateSvc:      updateSvc,
+		opsService:     opsService,
+		cfg:            cfg,
+		nodeID:         nodeID,
+		instanceID:     processInstanceID,
+		hostname:       hostname,
+		version:        buildInfo.Version,
+		buildType:      buildInfo.BuildType,
+		configHash:     computeConfigHash(cfg),
+		startedAt:      time.Now().UTC(),
+		restartProcess: terminateSelf,
+		pollInterval:   clusterRolloutPollInterval,
+		stopCtx:        stopCtx,
+		stop:           stop,
+	}
+}
+
+func (s *ClusterService) settings() config.ClusterConfig {
+	if s.cfg == nil {
+		return config.ClusterConfig{HeartbeatIntervalSeconds: 10, NodeTTLSeconds: 35, RolloutNodeTimeoutSeconds: 600}
+	}
+	return s.cfg.Cluster
+}
+
+// NodeID 返回当前节点 ID
+func (s *ClusterService) NodeID() string {
+	return s.nodeID
+}
+
+// Start 注册节点并开始心跳
+func (s *ClusterService) Start() {
+	if s == nil || s.cache == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.resumeRollout(s.stopCtx)
+		s.wg.Add(1)
+		go s.run()
+	})
+}
+
+// Stop 停止心跳并注销节点
+func (s *ClusterService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		s.stop()
+		s.wg.Wait()
+		if s.cache == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := s.cache.DeleteNode(ctx, s.nodeID); err != nil {
+			slog.Warn("cluster_node_deregister_failed", "node_id", s.nodeID, "error", err)
+		}
+	})
+}
+
+func (s *ClusterService) run() {
+	defer s.wg.Done()
+	interval := time.Duration(s.settings().HeartbeatIntervalSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.heartbeat(s.stopCtx)
+	for {
+		select {
+		case <-ticker.C:
+			s.heartbeat(s.stopCtx)
+		case <-s.stopCtx.Done():
+			return
+		}
+	}
+}
+
+// heartbeat 上报节点状态并处理待执行的命令
+func (s *ClusterService) heartbeat(ctx context.Context) {
+	hbCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.saveNode(hbCtx); err != nil {
+		slog.Warn("cluster_heartbeat_failed", "node_id", s.nodeID, "error", err)
+		return
+	}
+	cmd, err := s.cache.PopCommand(hbCtx, s.nodeID)
+	if err != nil {
+		slog.Warn("cluster_command_pop_failed", "node_id", s.nodeID, "error", err)
+		return
+	}
+	if cmd != nil {
+		s.handleCommand(ctx, cmd)
+	}
+}
+
+func (s *ClusterService) saveNode(ctx context.Context) error {
+	s.heartbeatMu.Lock()
+	defer s.heartbeatMu.Unlock()
+
+	now := time.Now().UTC()
+	total := gatewayRequestsTotal.Load()
+	rps := 0.0
+	if !s.lastTotalAt.IsZero() {
+		if elapsed := now.Sub(s.lastTotalAt).Seconds(); elapsed > 0 {
+			rps = float64(total-s.lastTotal) / elapsed
+		}
+	}
+	s.lastTotal, s.lastTotalAt = total, now
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	node := &ClusterNode{
+		NodeID:            s.nodeID,
+		InstanceID:        s.instanceID,
+		Hostname:          s.hostname,
+		Version:           s.version,
+		BuildType:         s.buildType,
+		ConfigHash:        s.configHash,
+		StartedAt:         s.startedAt,
+		LastHeartbeat:     now,
+		InFlightRequests:  gatewayInFlight.Load(),
+		RequestsTotal:     total,
+		RequestsPerSecond: rps,
+		Goroutines:        runtime.NumGoroutine(),
+		HeapAllocBytes:    mem.HeapAlloc,
+		LastCommand:       s.lastCommand,
+	}
+	return s.cache.SaveNode(ctx, node)
+}
+
+// handleCommand 执行滚动命令；需要重启时先上报结果再退出进程
+func (s *ClusterService) handleCommand(ctx context.Context, cmd *ClusterCommand) {
+	slog.Info("cluster_command_received", "node_id", s.nodeID, "command_id", cmd.ID, "action", cmd.Action)
+	status, err := s.prepareRestart(ctx, cmd.Action)
+	result := &ClusterCommandResult{ID: cmd.ID, Action: cmd.Action, Status: status, At: time.Now().UTC()}
+	if err != nil {
+		result.Status = ClusterCommandStatusFailed
+		result.Error = err.Error()
+		slog.Warn("cluster_command_failed", "node_id", s.nodeID, "command_id", cmd.ID, "error", err)
+	}
+	s.heartbeatMu.Lock()
+	s.lastCommand = result
+	s.heartbeatMu.Unlock()
+
+	saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.saveNode(saveCtx); err != nil {
+		slog.Warn("cluster_heartbeat_failed", "node_id", s.nodeID, "error", err)
+	}
+	if result.Status == ClusterCommandStatusRestarting {
+		s.drainAndRestart()
+	}
+}
+
+// prepareRestart 为滚动操作做准备：update 会下载并替换二进制；返回 restarting 表示需要重启，up_to_date 表示无需处理
+func (s *ClusterService) prepareRestart(ctx context.Context, action string) (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", errors.New("restart requires Linux with systemd (Restart=always)")
+	}
+	switch action {
+	case ClusterRolloutActionRestart:
+		return ClusterCommandStatusRestarting, nil
+	case ClusterRolloutActionUpdate:
+		if s.updateSvc == nil {
+			return "", errors.New("update service not available")
+		}
+		info, err := s.updateSvc.CheckUpdate(ctx, true)
+		if err != nil {
+			return "", fmt.Errorf("check update: %w", err)
+		}
+		if !info.HasUpdate {
+			return ClusterCommandStatusUpToDate, nil
+		}
+		if err := s.updateSvc.PerformUpdate(ctx); err != nil {
+			return "", fmt.Errorf("perform update: %w", err)
+		}
+		return ClusterCommandStatusRestarting, nil
+	default:
+		return "", ErrClusterRolloutInvalidAction
+	}
+}
+
+// Overview 返回集群视图
+func (s *ClusterService) Overview(ctx context.Context) (*ClusterOverview, error) {
+	nodes, err := s.cache.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list cluster nodes: %w", err)
+	}
+	keys := s.leaderLockKeys(ctx)
+	holders, err := s.cache.GetLockHolders(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("get leader locks: %w", err)
+	}
+	rollout, err := s.cache.GetRollout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get cluster rollout: %w", err)
+	}
+
+	now := time.Now()
+	ttl := time.Duration(s.settings().NodeTTLSeconds) * time.Second
+	overview := &ClusterOverview{
+		SelfNodeID:           s.nodeID,
+		Nodes:                make([]ClusterNodeStatus, 0, len(nodes)),
+		ConfigHashConsistent: true,
+		VersionConsistent:    true,
+		Rollout:              rollout,
+	}
+	nodeByInstance := make(map[string]int, len(nodes))
+	var configHash, version string
+	for _, node := range nodes {
+		age := now.Sub(node.LastHeartbeat)
+		if age > clusterNodePruneAfter {
+			_ = s.cache.DeleteNode(ctx, node.NodeID)
+			continue
+		}
+		status := ClusterNodeStatus{
+			ClusterNode:         *node,
+			Online:              age <= ttl,
+			Self:                node.NodeID == s.nodeID,
+			UptimeSeconds:       int64(node.LastHeartbeat.Sub(node.StartedAt).Seconds()),
+			HeartbeatAgeSeconds: int64(age.Seconds()),
+			HeldLocks:           []string{},
+		}
+		if status.Online {
+			if configHash == "" {
+				configHash, version = node.ConfigHash, node.Version
+			}
+			overview.ConfigHashConsistent = overview.ConfigHashConsistent && node.ConfigHash == configHash
+			overview.VersionConsistent = overview.VersionConsistent && node.Version == version
+		}
+		overview.Nodes = append(overview.Nodes, status)
+	}
+	sort.Slice(overview.Nodes, func(i, j int) bool { return overview.Nodes[i].NodeID < overview.Nodes[j].NodeID })
+	for i := range overview.Nodes {
+		nodeByInstance[overview.Nodes[i].InstanceID] = i
+	}
+
+	overview.Locks = make([]ClusterLockStatus, 0, len(keys))
+	for _, key := range keys {
+		lock := ClusterLockStatus{Key: key, HolderInstanceID: holders[key]}
+		if idx, ok := nodeByInstance[lock.HolderInstanceID]; ok && lock.HolderInstanceID != "" {
+			lock.HolderNodeID = overview.Nodes[idx].NodeID
+			overview.Nodes[idx].HeldLocks = append(overview.Nodes[idx].HeldLocks, key)
+		}
+		overview.Locks = append(overview.Locks, lock)
+	}
+	return overview, nil
+}
+
+// leaderLockKeys 返回各后台任务使用的 leader 锁键（告警评估的锁键可在运行时设置中修改）
+func (s *ClusterService) leaderLockKeys(ctx context.Context) []string {
+	keys := []string{
+		opsMetricsCollectorLeaderLockKey,
+		opsAggHourlyLeaderLockKey,
+		opsAggDailyLeaderLockKey,
+		opsAlertEvaluatorLeaderLockKeyDefault,
+		opsCleanupLeaderLockKeyDefault,
+		opsScheduledReportLeaderLockKeyDefault,
+		subscriptionRenewLeaderLockKey,
+	}
+	if s.opsService == nil {
+		return keys
+	}
+	if runtimeCfg, err := s.opsService.GetOpsAlertRuntimeSettings(ctx); err == nil && runtimeCfg != nil {
+		if key := runtimeCfg.DistributedLock.Key; key != "" && key != opsAlertEvaluatorLeaderLockKeyDefault {
+			keys[3] = key
+		}
+	}
+	return keys
+}
+
+// GetRollout 返回最近一次滚动的进度
+func (s *ClusterService) GetRollout(ctx context.Context) (*ClusterRollout, error) {
+	return s.cache.GetRollout(ctx)
+}
+
+// StartRollout 开始集群滚动更新/重启：按节点 ID 顺序逐个处理在线节点，当前节点最后处理
+func (s *ClusterService) StartRollout(ctx context.Context, action string) (*ClusterRollout, error) {
+	if action != ClusterRolloutActionUpdate && action != ClusterRolloutActionRestart {
+		return nil, ErrClusterRolloutInvalidAction
+	}
+	nodes, err := s.cache.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list cluster nodes: %w", err)
+	}
+	ttl := time.Duration(s.settings().NodeTTLSeconds) * time.Second
+	now := time.Now()
+	var steps []ClusterRolloutStep
+	selfVersion := s.version
+	for _, node := range nodes {
+		if node.NodeID == s.nodeID || now.Sub(node.LastHeartbeat) > ttl {
+			continue
+		}
+		steps = append(steps, ClusterRolloutStep{NodeID: node.NodeID, Status: ClusterStepStatusPending, FromVersion: node.Version})
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].NodeID < steps[j].NodeID })
+	steps = append(steps, ClusterRolloutStep{NodeID: s.nodeID, Status: ClusterStepStatusPending, FromVersion: selfVersion})
+
+	nodeTimeout := time.Duration(s.settings().RolloutNodeTimeoutSeconds) * time.Second
+	ok, err := s.cache.AcquireRolloutLock(ctx, s.instanceID, time.Duration(len(steps)+1)*nodeTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("acquire rollout lock: %w", err)
+	}
+	if !ok {
+		return nil, ErrClusterRolloutInProgress
+	}
+
+	rollout := &ClusterRollout{
+		ID:              uuid.NewString(),
+		Action:          action,
+		Status:          ClusterRolloutStatusRunning,
+		InitiatorNodeID: s.nodeID,
+		StartedAt:       now.UTC(),
+		Steps:           steps,
+	}
+	if err := s.cache.SaveRollout(ctx, rollout); err != nil {
+		_ = s.cache.ReleaseRolloutLock(ctx, s.instanceID)
+		return nil, fmt.Errorf("save rollout: %w", err)
+	}
+	slog.InfoContext(ctx, "cluster_rollout_started", "rollout_id", rollout.ID, "action", action, "nodes", len(steps))
+
+	snapshot := *rollout
+	snapshot.Steps = append([]ClusterRolloutStep(nil), steps...)
+	s.wg.Add(1)
+	go s.runRollout(rollout)
+	return &snapshot, nil
+}
+
+func (s *ClusterService) runRollout(rollout *ClusterRollout) {
+	defer s.wg.Done()
+	ctx := s.stopCtx
+
+	for i := range rollout.Steps {
+		step := &rollout.Steps[i]
+		started := time.Now().UTC()
+		step.StartedAt = &started
+		step.Status = ClusterStepStatusRunning
+		s.saveRollout(rollout)
+
+		if step.NodeID == s.nodeID {
+			s.runLocalStep(ctx, rollout, step)
+			return
+		}
+		if err := s.runRemoteStep(ctx, rollout, step); err != nil {
+			s.failRollout(rollout, i, err)
+			return
+		}
+		s.saveRollout(rollout)
+	}
+}
+
+// runRemoteStep 向节点下发命令，等待其以新进程上报心跳
+func (s *ClusterService) runRemoteStep(ctx context.Context, rollout *ClusterRollout, step *ClusterRolloutStep) error {
+	prevInstance := ""
+	nodes, err := s.cache.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("list cluster nodes: %w", err)
+	}
+	for _, node := range nodes {
+		if node.NodeID == step.NodeID {
+			prevInstance = node.InstanceID
+		}
+	}
+	cmd := &ClusterCommand{ID: uuid.NewString(), Action: rollout.Action, RolloutID: rollout.ID, CreatedAt: time.Now().UTC()}
+	nodeTimeout := time.Duration(s.settings().RolloutNodeTimeoutSeconds) * time.Second
+	if err := s.cache.PushCommand(ctx, step.NodeID, cmd, nodeTimeout); err != nil {
+		return fmt.Errorf("push command: %w", err)
+	}
+
+	deadline := time.NewTimer(nodeTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("timed out waiting for node %s", step.NodeID)
+		case <-ticker.C:
+		}
+		nodes, err := s.cache.ListNodes(ctx)
+		if err != nil {
+			continue
+		}
+		for _, node := range nodes {
+			if node.NodeID != step.NodeID {
+				continue
+			}
+			if node.InstanceID != prevInstance && node.LastHeartbeat.After(cmd.CreatedAt) {
+				s.finishStep(step, ClusterStepStatusDone, node.Version)
+				return nil
+			}
+			if last := node.LastCommand; last != nil && last.ID == cmd.ID {
+				switch last.Status {
+				case ClusterCommandStatusFailed:
+					return errors.New(last.Error)
+				case ClusterCommandStatusUpToDate:
+					s.finishStep(step, ClusterStepStatusDone, node.Version)
+					return nil
+				case ClusterCommandStatusRestarting:
+					if step.Status != ClusterStepStatusRestarting {
+						step.Status = ClusterStepStatusRestarting
+						s.saveRollout(rollout)
+					}
+				}
+			}
+		}
+	}
+}
+
+// runLocalStep 处理当前节点（最后一步）；需要重启时由新进程在 resumeRollout 中结束滚动
+func (s *ClusterService) runLocalStep(ctx context.Context, rollout *ClusterRollout, step *ClusterRolloutStep) {
+	status, err := s.prepareRestart(ctx, rollout.Action)
+	if err != nil {
+		s.failRollout(rollout, len(rollout.Steps)-1, err)
+		return
+	}
+	if status == ClusterCommandStatusUpToDate {
+		s.finishStep(step, ClusterStepStatusDone, s.version)
+		s.completeRollout(rollout)
+		return
+	}
+	step.Status = ClusterStepStatusRestarting
+	s.saveRollout(rollout)
+	s.releaseRolloutLock()
+	slog.Info("cluster_rollout_restarting_self", "rollout_id", rollout.ID, "node_id", s.nodeID)
+	s.drainAndRestart()
+}
+
+// drainAndRestart 停止接收新的网关请求，等待进行中的请求结束（有超时）后重启进程
+func (s *ClusterService) drainAndRestart() {
+	gatewayDraining.Store(true)
+	timeout := clusterDrainTimeout
+	if s.cfg != nil {
+		if half := time.Duration(s.cfg.Cluster.RolloutNodeTimeoutSeconds) * time.Second / 2; half > 0 && half < timeout {
+			timeout = half
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(clusterDrainPollInterval)
+	defer ticker.Stop()
+	for gatewayInFlight.Load() > 0 {
+		select {
+		case <-timer.C:
+			slog.Warn("cluster_drain_timeout", "node_id", s.nodeID, "in_flight", gatewayInFlight.Load(), "timeout", timeout)
+			s.restartProcess()
+			return
+		case <-ticker.C:
+		}
+	}
+	slog.Info("cluster_drain_completed", "node_id", s.nodeID)
+	s.restartProcess()
+}
+
+// terminateSelf 向自身发送 SIGTERM，走与手动停止相同的优雅退出流程
+// （Server.Shutdown、后台服务 Stop、追踪数据刷新），由 systemd（Restart=always）拉起新进程
+func terminateSelf() {
+	proc, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = proc.Signal(syscall.SIGTERM)
+	}
+	if err != nil {
+		slog.Error("cluster_self_terminate_failed", "error", err)
+		sysutil.RestartServiceAsync()
+	}
+}
+
+// resumeRollout 新进程启动时，若上一个进程在滚动中重启了自身，则补全该步骤并结束滚动
+func (s *ClusterService) resumeRollout(ctx context.Context) {
+	rollout, err := s.cache.GetRollout(ctx)
+	if err != nil || rollout == nil || rollout.Status != ClusterRolloutStatusRunning || rollout.InitiatorNodeID != s.nodeID {
+		return
+	}
+	last := &rollout.Steps[len(rollout.Steps)-1]
+	if last.NodeID != s.nodeID || last.Status != ClusterStepStatusRestarting {
+		return
+	}
+	s.finishStep(last, ClusterStepStatusDone, s.version)
+	s.completeRollout(rollout)
+}
+
+func (s *ClusterService) finishStep(step *ClusterRolloutStep, status, version string) {
+	finished := time.Now().UTC()
+	step.Status = status
+	step.ToVersion = version
+	step.FinishedAt = &finished
+}
+
+func (s *ClusterService) completeRollout(rollout *ClusterRollout) {
+	finished := time.Now().UTC()
+	rollout.Status = ClusterRolloutStatusCompleted
+	rollout.FinishedAt = &finished
+	s.saveRollout(rollout)
+	s.releaseRolloutLock()
+	slog.Info("cluster_rollout_completed", "rollout_id", rollout.ID, "action", rollout.Action)
+}
+
+// failRollout 标记步骤失败并中止滚动，后续节点不再处理
+func (s *ClusterService) failRollout(rollout *ClusterRollout, idx int, err error) {
+	s.finishStep(&rollout.Steps[idx], ClusterStepStatusFailed, "")
+	rollout.Steps[idx].Error = err.Error()
+	for i := idx + 1; i < len(rollout.Steps); i++ {
+		rollout.Steps[i].Status = ClusterStepStatusSkipped
+	}
+	finished := time.Now().UTC()
+	rollout.Status = ClusterRolloutStatusFailed
+	rollout.Error = fmt.Sprintf("node %s: %v", rollout.Steps[idx].NodeID, err)
+	rollout.FinishedAt = &finished
+	s.saveRollout(rollout)
+	s.releaseRolloutLock()
+	slog.Warn("cluster_rollout_failed", "rollout_id", rollout.ID, "node_id", rollout.Steps[idx].NodeID, "error", err)
+}
+
+func (s *ClusterService) saveRollout(rollout *ClusterRollout) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.cache.SaveRollout(ctx, rollout); err != nil {
+		slog.Warn("cluster_rollout_save_failed", "rollout_id", rollout.ID, "error", err)
+	}
+}
+
+func (s *ClusterService) releaseRolloutLock() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.cache.ReleaseRolloutLock(ctx, s.instanceID); err != nil {
+		slog.Warn("cluster_rollout_lock_release_failed", "error", err)
+	}
+}
+
+// computeConfigHash 计算配置摘要，用于发现各节点配置不一致；节点 ID 本身不参与计算
+func computeConfigHash(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	normalized := *cfg
+	normalized.Cluster.NodeID = ""
+	raw, err := json.Marshal(normalized)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])[:12]
+}
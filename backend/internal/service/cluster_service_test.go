@@ -0,0 +1,293 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type clusterCacheStub struct {
+	mu       sync.Mutex
+	nodes    map[string]ClusterNode
+	locks    map[string]string
+	commands map[string][]*ClusterCommand
+	rollout  *ClusterRollout
+	lockedBy string
+}
+
+func newClusterCacheStub() *clusterCacheStub {
+	return &clusterCacheStub{
+		nodes:    map[string]ClusterNode{},
+		locks:    map[string]string{},
+		commands: map[string][]*ClusterCommand{},
+	}
+}
+
+func (c *clusterCacheStub) SaveNode(ctx context.Context, node *ClusterNode) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nodes[node.NodeID] = *node
+	return nil
+}
+
+func (c *clusterCacheStub) ListNodes(ctx context.Context) ([]*ClusterNode, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]*ClusterNode, 0, len(c.nodes))
+	for _, n := range c.nodes {
+		n := n
+		out = append(out, &n)
+	}
+	return out, nil
+}
+
+func (c *clusterCacheStub) DeleteNode(ctx context.Context, nodeID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nodes, nodeID)
+	return nil
+}
+
+func (c *clusterCacheStub) GetLockHolders(ctx context.Context, keys []string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := map[string]string{}
+	for _, k := range keys {
+		if v, ok := c.locks[k]; ok {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+func (c *clusterCacheStub) PushCommand(ctx context.Context, nodeID string, cmd *ClusterCommand, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.commands[nodeID] = append(c.commands[nodeID], cmd)
+	return nil
+}
+
+func (c *clusterCacheStub) PopCommand(ctx context.Context, nodeID string) (*ClusterCommand, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queue := c.commands[nodeID]
+	if len(queue) == 0 {
+		return nil, nil
+	}
+	c.commands[nodeID] = queue[1:]
+	return queue[0], nil
+}
+
+func (c *clusterCacheStub) AcquireRolloutLock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lockedBy != "" {
+		return false, nil
+	}
+	c.lockedBy = owner
+	return true, nil
+}
+
+func (c *clusterCacheStub) ReleaseRolloutLock(ctx context.Context, owner string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lockedBy == owner {
+		c.lockedBy = ""
+	}
+	return nil
+}
+
+func (c *clusterCacheStub) SaveRollout(ctx context.Context, rollout *ClusterRollout) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp := *rollout
+	cp.Steps = append([]ClusterRolloutStep(nil), rollout.Steps...)
+	c.rollout = &cp
+	return nil
+}
+
+func (c *clusterCacheStub) GetRollout(ctx context.Context) (*ClusterRollout, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rollout == nil {
+		return nil, nil
+	}
+	cp := *c.rollout
+	cp.Steps = append([]ClusterRolloutStep(nil), c.rollout.Steps...)
+	return &cp, nil
+}
+
+func newTestClusterService(cache ClusterCache, nodeID, instanceID string) *ClusterService {
+	cfg := &config.Config{Cluster: config.ClusterConfig{
+		NodeID:                    nodeID,
+		HeartbeatIntervalSeconds:  10,
+		NodeTTLSeconds:            30,
+		RolloutNodeTimeoutSeconds: 5,
+	}}
+	svc := NewClusterService(cache, nil, nil, BuildInfo{Version: "1.0.0"}, cfg)
+	svc.instanceID = instanceID
+	svc.pollInterval = 10 * time.Millisecond
+	svc.restartProcess = func() {}
+	return svc
+}
+
+func TestClusterOverviewMapsLocksAndFlagsOfflineNodes(t *testing.T) {
+	cache := newClusterCacheStub()
+	self := newTestClusterService(cache, "node-a", "inst-a")
+	require.NoError(t, self.saveNode(context.Background()))
+
+	now := time.Now().UTC()
+	cache.nodes["node-b"] = ClusterNode{NodeID: "node-b", InstanceID: "inst-b", Version: "0.9.0", ConfigHash: "other", StartedAt: now.Add(-time.Hour), LastHeartbeat: now}
+	cache.nodes["node-c"] = ClusterNode{NodeID: "node-c", InstanceID: "inst-c", LastHeartbeat: now.Add(-time.Minute)}
+	cache.nodes["node-d"] = ClusterNode{NodeID: "node-d", InstanceID: "inst-d", LastHeartbeat: now.Add(-48 * time.Hour)}
+	cache.locks[opsCleanupLeaderLockKeyDefault] = "inst-b"
+	cache.locks[opsMetricsCollectorLeaderLockKey] = "inst-a"
+
+	overview, err := self.Overview(context.Background())
+	require.NoError(t, err)
+	require.Len(t, overview.Nodes, 3, "node-d is pruned")
+	require.NotContains(t, cache.nodes, "node-d")
+
+	byID := map[string]ClusterNodeStatus{}
+	for _, n := range overview.Nodes {
+		byID[n.NodeID] = n
+	}
+	require.True(t, byID["node-a"].Self)
+	require.True(t, byID["node-a"].Online)
+	require.Equal(t, []string{opsMetricsCollectorLeaderLockKey}, byID["node-a"].HeldLocks)
+	require.Equal(t, []string{opsCleanupLeaderLockKeyDefault}, byID["node-b"].HeldLocks)
+	require.Equal(t, int64(3600), byID["node-b"].UptimeSeconds)
+	require.False(t, byID["node-c"].Online)
+	require.False(t, overview.ConfigHashConsistent)
+	require.False(t, overview.VersionConsistent)
+}
+
+func TestClusterRolloutRestartsNodesOneAtATime(t *testing.T) {
+	cache := newClusterCacheStub()
+	self := newTestClusterService(cache, "node-a", "inst-a")
+	remote := newTestClusterService(cache, "node-b", "inst-b1")
+	remoteRestarts := 0
+	remote.restartProcess = func() {
+		// 模拟重启：新进程使用新的实例 ID
+		remoteRestarts++
+		remote.instanceID = "inst-b2"
+	}
+	selfRestarted := make(chan struct{})
+	self.restartProcess = func() { close(selfRestarted) }
+	require.NoError(t, self.saveNode(context.Background()))
+	require.NoError(t, remote.saveNode(context.Background()))
+
+	stopRemote := make(chan struct{})
+	defer close(stopRemote)
+	go func() {
+		for {
+			select {
+			case <-stopRemote:
+				return
+			case <-time.After(5 * time.Millisecond):
+				remote.heartbeat(context.Background())
+			}
+		}
+	}()
+
+	rollout, err := self.StartRollout(context.Background(), ClusterRolloutActionRestart)
+	require.NoError(t, err)
+	require.Equal(t, []string{"node-b", "node-a"}, []string{rollout.Steps[0].NodeID, rollout.Steps[1].NodeID})
+
+	_, err = self.StartRollout(context.Background(), ClusterRolloutActionRestart)
+	require.ErrorIs(t, err, ErrClusterRolloutInProgress)
+
+	select {
+	case <-selfRestarted:
+	case <-time.After(3 * time.Second):
+		t.Fatal("self restart not triggered")
+	}
+	self.wg.Wait()
+	require.Equal(t, 1, remoteRestarts)
+
+	saved, err := cache.GetRollout(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ClusterRolloutStatusRunning, saved.Status)
+	require.Equal(t, ClusterStepStatusDone, saved.Steps[0].Status)
+	require.Equal(t, ClusterStepStatusRestarting, saved.Steps[1].Status)
+
+	// 新进程启动后补全自身步骤
+	restarted := newTestClusterService(cache, "node-a", "inst-a2")
+	restarted.resumeRollout(context.Background())
+	saved, err = cache.GetRollout(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ClusterRolloutStatusCompleted, saved.Status)
+	require.Equal(t, ClusterStepStatusDone, saved.Steps[1].Status)
+	require.Empty(t, cache.lockedBy)
+}
+
+func TestClusterRolloutStopsOnNodeFailure(t *testing.T) {
+	cache := newClusterCacheStub()
+	self := newTestClusterService(cache, "node-a", "inst-a")
+	self.restartProcess = func() { t.Error("self must not restart after a failed step") }
+	require.NoError(t, self.saveNode(context.Background()))
+	now := time.Now().UTC()
+	cache.nodes["node-b"] = ClusterNode{NodeID: "node-b", InstanceID: "inst-b", LastHeartbeat: now}
+
+	_, err := self.StartRollout(context.Background(), ClusterRolloutActionRestart)
+	require.NoError(t, err)
+
+	// 节点 b 上报命令执行失败
+	require.Eventually(t, func() bool {
+		cmd, _ := cache.PopCommand(context.Background(), "node-b")
+		if cmd == nil {
+			return false
+		}
+		_ = cache.SaveNode(context.Background(), &ClusterNode{
+			NodeID: "node-b", InstanceID: "inst-b", LastHeartbeat: time.Now().UTC(),
+			LastCommand: &ClusterCommandResult{ID: cmd.ID, Action: cmd.Action, Status: ClusterCommandStatusFailed, Error: "boom"},
+		})
+		return true
+	}, time.Second, 5*time.Millisecond)
+	self.wg.Wait()
+
+	saved, err := cache.GetRollout(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ClusterRolloutStatusFailed, saved.Status)
+	require.Equal(t, ClusterStepStatusFailed, saved.Steps[0].Status)
+	require.Equal(t, "boom", saved.Steps[0].Error)
+	require.Equal(t, ClusterStepStatusSkipped, saved.Steps[1].Status)
+	require.Empty(t, cache.lockedBy)
+}
+
+func TestClusterDrainWaitsForInFlightRequests(t *testing.T) {
+	t.Cleanup(func() { gatewayDraining.Store(false) })
+	svc := newTestClusterService(newClusterCacheStub(), "node-a", "inst-a")
+	restarted := make(chan struct{})
+	svc.restartProcess = func() { close(restarted) }
+
+	done := BeginGatewayRequest()
+	go svc.drainAndRestart()
+
+	require.Eventually(t, GatewayDraining, time.Second, 5*time.Millisecond)
+	select {
+	case <-restarted:
+		t.Fatal("restarted while a gateway request was still in flight")
+	case <-time.After(3 * clusterDrainPollInterval):
+	}
+	done()
+	select {
+	case <-restarted:
+	case <-time.After(time.Second):
+		t.Fatal("restart not triggered after in-flight requests finished")
+	}
+}
+
+func TestComputeConfigHashIgnoresNodeID(t *testing.T) {
+	a := &config.Config{Cluster: config.ClusterConfig{NodeID: "a"}}
+	b := &config.Config{Cluster: config.ClusterConfig{NodeID: "b"}}
+	require.Equal(t, computeConfigHash(a), computeConfigHash(b))
+	b.Server.Port = 9000
+	require.NotEqual(t, computeConfigHash(a), computeConfigHash(b))
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 分组内容策略命中动作
+const (
+	ContentPolicyActionNone  = ""      // 不检查
+	ContentPolicyActionBlock = "block" // 拒绝请求
+	ContentPolicyActionLog   = "log"   // 仅记录
+	ContentPolicyActionStrip = "strip" // 删除命中内容后继续转发（审核服务命中时无法定位内容，按 block 处理）
+)
+
+// 内容策略规则匹配方式
+const (
+	ContentPolicyMatchKeyword = "keyword" // 关键词（不区分大小写的子串匹配）
+	ContentPolicyMatchRegex   = "regex"   // 正则表达式（RE2 语法）
+)
+
+// 命中来源
+const (
+	ContentPolicyHitSourceRule       = "rule"
+	ContentPolicyHitSourceModeration = "moderation"
+)
+
+var (
+	ErrContentPolicyActionInvalid = infraerrors.BadRequest("CONTENT_POLICY_ACTION_INVALID", "content policy action must be one of block, log, strip")
+	ErrContentPolicyRuleNotFound  = infraerrors.NotFound("CONTENT_POLICY_RULE_NOT_FOUND", "content policy rule not found")
+	ErrContentPolicyRuleInvalid   = infraerrors.BadRequest("CONTENT_POLICY_RULE_INVALID", "invalid content policy rule")
+	ErrContentPolicyHitNotFound   = infraerrors.NotFound("CONTENT_POLICY_HIT_NOT_FOUND", "content policy hit not found")
+	// ErrContentPolicyBlocked 请求内容命中策略被拒绝（网关按各平台错误格式返回）
+	ErrContentPolicyBlocked = infraerrors.BadRequest("CONTENT_POLICY_BLOCKED", "Request blocked by content policy")
+)
+
+// ValidateContentPolicyAction 校验分组内容策略命中动作
+func ValidateContentPolicyAction(action string) error {
+	switch action {
+	case ContentPolicyActionNone, ContentPolicyActionBlock, ContentPolicyActionLog, ContentPolicyActionStrip:
+		return nil
+	}
+	return ErrContentPolicyActionInvalid
+}
+
+// ContentPolicyRule 管理员定义的关键词/正则规则（全局生效，命中后的动作由分组决定）
+type ContentPolicyRule struct {
+	ID          int64
+	Name        string
+	MatchType   string
+	Pattern     string
+	Description string
+	Enabled     bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ContentPolicyHit 策略命中记录，供管理员审查
+type ContentPolicyHit struct {
+	ID         int64
+	UserID     int64
+	APIKeyID   int64
+	GroupID    *int64
+	RuleID     *int64
+	RuleName   string
+	Source     string
+	Action     string
+	Platform   string
+	Model      string
+	Excerpt    string
+	Categories []string
+	ReviewedAt *time.Time
+	ReviewedBy *int64
+	CreatedAt  time.Time
+}
+
+// ContentPolicyHitFilter 命中记录查询条件
+type ContentPolicyHitFilter struct {
+	UserID   *int64
+	GroupID  *int64
+	Reviewed *bool
+}
+
+// CreateContentPolicyRuleInput 创建规则参数
+type CreateContentPolicyRuleInput struct {
+	Name        string
+	MatchType   string
+	Pattern     string
+	Description string
+	Enabled     bool
+}
+
+// UpdateContentPolicyRuleInput 更新规则参数（nil 表示不修改）
+type UpdateContentPolicyRuleInput struct {
+	Name        *string
+	MatchType   *string
+	Pattern     *string
+	Description *string
+	Enabled     *bool
+}
+
+type ContentPolicyRepository interface {
+	CreateRule(ctx context.Context, rule *ContentPolicyRule) error
+	GetRule(ctx context.Context, id int64) (*ContentPolicyRule, error)
+	UpdateRule(ctx context.Context, rule *ContentPolicyRule) error
+	DeleteRule(ctx context.Context, id int64) error
+	ListRules(ctx context.Context) ([]ContentPolicyRule, error)
+
+	CreateHit(ctx context.Context, hit *ContentPolicyHit) error
+	ListHits(ctx context.Context, params pagination.PaginationParams, filter ContentPolicyHitFilter) ([]ContentPolicyHit, *pagination.PaginationResult, error)
+	// MarkHitReviewed 标记命中记录已审查
+	MarkHitReviewed(ctx context.Context, id int64, reviewerID int64) error
+	// CountViolationsSince 统计用户自 since 起计入封禁阈值的命中次数（仅记录模式的命中不计入）
+	CountViolationsSince(ctx context.Context, userID int64, since time.Time) (int64, error)
+}
@@ -0,0 +1,741 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// 命中摘录：匹配位置前后各保留的字符数与摘录最大长度
+	contentPolicyExcerptContext = 40
+	contentPolicyExcerptMaxLen  = 200
+	// 发送给审核服务的最大文本长度（字节）
+	contentPolicyModerationMaxBytes = 32 << 10
+	contentPolicyRecordTimeout      = 5 * time.Second
+)
+
+// ErrContentPolicyUnavailable 审核服务不可用且配置为不放行
+var ErrContentPolicyUnavailable = infraerrors.ServiceUnavailable("CONTENT_POLICY_UNAVAILABLE", "Content moderation service is unavailable, please retry later")
+
+// contentPolicyTextKeys 请求体中承载用户/系统文本的字段，strip 动作仅改写这些字段的字符串值
+var contentPolicyTextKeys = map[string]bool{
+	"text":         true,
+	"content":      true,
+	"input":        true,
+	"prompt":       true,
+	"instructions": true,
+	"system":       true,
+}
+
+// ContentPolicyCheckInput 网关请求的策略检查参数
+type ContentPolicyCheckInput struct {
+	User     *User
+	APIKey   *APIKey
+	Group    *Group
+	Platform string
+	Model    string
+	Body     []byte
+}
+
+// ContentPolicyMatch 规则试运行结果
+type ContentPolicyMatch struct {
+	RuleID   int64
+	RuleName string
+	Excerpt  string
+}
+
+type compiledContentPolicyRule struct {
+	rule    ContentPolicyRule
+	keyword string // 小写关键词（keyword 规则）
+	re      *regexp.Regexp
+}
+
+// find 返回首个匹配的字节区间，未命中返回 nil
+func (r *compiledContentPolicyRule) find(text string) []int {
+	if r.re != nil {
+		return r.re.FindStringIndex(text)
+	}
+	if r.keyword == "" {
+		return nil
+	}
+	idx := strings.Index(strings.ToLower(text), r.keyword)
+	if idx < 0 {
+		return nil
+	}
+	return []int{idx, idx + len(r.keyword)}
+}
+
+// strip 删除全部匹配内容
+func (r *compiledContentPolicyRule) strip(text string) string {
+	if r.re != nil {
+		return r.re.ReplaceAllString(text, "")
+	}
+	if r.keyword == "" {
+		return text
+	}
+	lower := strings.ToLower(text)
+	// 大小写转换可能改变字节长度（少数 Unicode 字符），此时退化为大小写敏感替换
+	if len(lower) != len(text) {
+		return strings.ReplaceAll(text, r.keyword, "")
+	}
+	var b strings.Builder
+	last := 0
+	for {
+		idx := strings.Index(lower[last:], r.keyword)
+		if idx < 0 {
+			break
+		}
+		b.WriteString(text[last : last+idx])
+		last += idx + len(r.keyword)
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func compileContentPolicyRule(rule ContentPolicyRule) (*compiledContentPolicyRule, error) {
+	compiled := &compiledContentPolicyRule{rule: rule}
+	switch rule.MatchType {
+	case ContentPolicyMatchKeyword:
+		compiled.keyword = strings.ToLower(rule.Pattern)
+	case ContentPolicyMatchRegex:
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled.re = re
+	default:
+		return nil, fmt.Errorf("unknown match type %q", rule.MatchType)
+	}
+	return compiled, nil
+}
+
+// ContentPolicyService 网关请求内容策略引擎
+//
+//   - 规则（关键词/正则）全局生效，命中后的处理由 API Key 所属分组的 content_policy_action 决定：
+//     block 拒绝请求、log 仅记录、strip 删除命中内容后继续转发。
+//   - 规则与审核检查客户端提供的全部文本（系统提示与完整历史，客户端可以伪造历史轮次），
+//     strip 模式下清理整个请求体，防止重发的历史消息把已删除内容带回上游。
+//   - 命中记录与违规计数只针对本次新增的内容（最新一轮用户输入及其后的预填充）：仅出现在历史中的命中
+//     照常拦截/清理，但在非 block 模式下不再记录，避免客户端每次重发历史都把同一条旧内容重复计数。
+//   - 未命中规则时可调用本地审核服务（OpenAI moderation 兼容接口）进一步判定。
+//   - 非 log 模式的命中计入违规，窗口内达到阈值后自动停用用户。
+type ContentPolicyService struct {
+	repo                 ContentPolicyRepository
+	userRepo             UserRepository
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	sessionService       *SessionService
+	httpClient           *http.Client
+	cfg                  *config.Config
+
+	mu       sync.RWMutex
+	rules    []*compiledContentPolicyRule
+	loadedAt time.Time
+	reloadMu sync.Mutex
+}
+
+// NewContentPolicyService 创建内容策略服务
+func NewContentPolicyService(
+	repo ContentPolicyRepository,
+	userRepo UserRepository,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	sessionService *SessionService,
+	cfg *config.Config,
+) *ContentPolicyService {
+	timeout := 3 * time.Second
+	if cfg != nil && cfg.ContentPolicy.ModerationTimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.ContentPolicy.ModerationTimeoutSeconds) * time.Second
+	}
+	return &ContentPolicyService{
+		repo:                 repo,
+		userRepo:             userRepo,
+		authCacheInvalidator: authCacheInvalidator,
+		sessionService:       sessionService,
+		httpClient:           &http.Client{Timeout: timeout},
+		cfg:                  cfg,
+	}
+}
+
+func (s *ContentPolicyService) refreshInterval() time.Duration {
+	if s.cfg != nil && s.cfg.ContentPolicy.RuleRefreshIntervalSeconds > 0 {
+		return time.Duration(s.cfg.ContentPolicy.RuleRefreshIntervalSeconds) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// loadRules 返回已编译的启用规则快照，过期时同步重载（失败时沿用旧快照）
+func (s *ContentPolicyService) loadRules(ctx context.Context) []*compiledContentPolicyRule {
+	s.mu.RLock()
+	rules, loadedAt := s.rules, s.loadedAt
+	s.mu.RUnlock()
+	if !loadedAt.IsZero() && time.Since(loadedAt) < s.refreshInterval() {
+		return rules
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.mu.RLock()
+	rules, loadedAt = s.rules, s.loadedAt
+	s.mu.RUnlock()
+	if !loadedAt.IsZero() && time.Since(loadedAt) < s.refreshInterval() {
+		return rules
+	}
+
+	all, err := s.repo.ListRules(ctx)
+	if err != nil {
+		log.Printf("[ContentPolicy] load rules failed: %v", err)
+		s.mu.Lock()
+		s.loadedAt = time.Now()
+		s.mu.Unlock()
+		return rules
+	}
+	compiled := make([]*compiledContentPolicyRule, 0, len(all))
+	for _, rule := range all {
+		if !rule.Enabled {
+			continue
+		}
+		c, err := compileContentPolicyRule(rule)
+		if err != nil {
+			log.Printf("[ContentPolicy] skip invalid rule %d: %v", rule.ID, err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	s.mu.Lock()
+	s.rules = compiled
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return compiled
+}
+
+// invalidateRules 规则修改后让下次检查重新加载
+func (s *ContentPolicyService) invalidateRules() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// Enforce 在转发前检查请求内容，返回（可能被 strip 改写的）请求体。
+// 命中 block 时返回 ErrContentPolicyBlocked；审核服务不可用且不放行时返回 ErrContentPolicyUnavailable。
+func (s *ContentPolicyService) Enforce(ctx context.Context, in *ContentPolicyCheckInput) ([]byte, error) {
+	if s == nil || in == nil || in.Group == nil {
+		return in.bodyOrNil(), nil
+	}
+	action := in.Group.ContentPolicyAction
+	if action == ContentPolicyActionNone {
+		return in.Body, nil
+	}
+
+	rules := s.loadRules(ctx)
+	latest, text := extractPolicyTexts(in.Body)
+
+	// text 以最新一轮内容开头，首个匹配落在该范围内即视为新内容命中
+	var hits, historyHits []ContentPolicyHit
+	for _, rule := range rules {
+		if loc := rule.find(text); loc != nil {
+			ruleID := rule.rule.ID
+			hit := ContentPolicyHit{
+				RuleID:   &ruleID,
+				RuleName: rule.rule.Name,
+				Source:   ContentPolicyHitSourceRule,
+				Excerpt:  contentPolicyExcerpt(text, loc[0], loc[1]),
+			}
+			if loc[1] <= len(latest) {
+				hits = append(hits, hit)
+			} else {
+				historyHits = append(historyHits, hit)
+			}
+		}
+	}
+	ruleMatched := len(hits) > 0 || len(historyHits) > 0
+
+	body := in.Body
+	effective := action
+	if action == ContentPolicyActionStrip {
+		body = stripContentPolicyBody(body, rules)
+		if ruleMatched {
+			for _, rule := range rules {
+				text = rule.strip(text)
+			}
+		}
+	}
+
+	// 规则未命中（或 strip 后）再交给审核服务判定
+	if !ruleMatched || action == ContentPolicyActionStrip {
+		flagged, categories, err := s.moderate(ctx, text)
+		if err != nil {
+			if s.cfg == nil || !s.cfg.ContentPolicy.ModerationFailOpen {
+				log.Printf("[ContentPolicy] moderation failed (fail closed): %v", err)
+				return nil, ErrContentPolicyUnavailable
+			}
+			log.Printf("[ContentPolicy] moderation failed (fail open): %v", err)
+		} else if flagged {
+			hits = append(hits, ContentPolicyHit{
+				Source:     ContentPolicyHitSourceModeration,
+				Categories: categories,
+				Excerpt:    contentPolicyExcerpt(text, 0, 0),
+			})
+			// 审核服务无法定位具体内容，strip 模式下按 block 处理
+			if action == ContentPolicyActionStrip {
+				effective = ContentPolicyActionBlock
+			}
+		}
+	}
+
+	// 被拦截的请求内容不会进入会话历史，仅出现在历史中的命中也计入；否则只记录新内容的命中
+	if effective == ContentPolicyActionBlock {
+		hits = append(hits, historyHits...)
+	}
+	if len(hits) == 0 {
+		return body, nil
+	}
+
+	s.recordHits(ctx, in, hits, effective)
+
+	if effective == ContentPolicyActionBlock {
+		return nil, ErrContentPolicyBlocked
+	}
+	return body, nil
+}
+
+func (in *ContentPolicyCheckInput) bodyOrNil() []byte {
+	if in == nil {
+		return nil
+	}
+	return in.Body
+}
+
+// recordHits 写入命中记录并检查违规阈值；记录失败不影响请求处理
+func (s *ContentPolicyService) recordHits(ctx context.Context, in *ContentPolicyCheckInput, hits []ContentPolicyHit, action string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), contentPolicyRecordTimeout)
+	defer cancel()
+
+	for i := range hits {
+		hit := &hits[i]
+		hit.Action = action
+		hit.Platform = in.Platform
+		hit.Model = in.Model
+		if in.User != nil {
+			hit.UserID = in.User.ID
+		}
+		if in.APIKey != nil {
+			hit.APIKeyID = in.APIKey.ID
+		}
+		groupID := in.Group.ID
+		hit.GroupID = &groupID
+		if err := s.repo.CreateHit(ctx, hit); err != nil {
+			log.Printf("[ContentPolicy] record hit failed: user=%d err=%v", hit.UserID, err)
+		}
+	}
+
+	if action == ContentPolicyActionLog || in.User == nil {
+		return
+	}
+	s.maybeSuspendUser(ctx, in.User)
+}
+
+// maybeSuspendUser 窗口内违规次数达到阈值时停用用户（管理员除外）
+func (s *ContentPolicyService) maybeSuspendUser(ctx context.Context, user *User) {
+	if s.cfg == nil || s.cfg.ContentPolicy.SuspendAfterViolations <= 0 || user.IsAdmin() {
+		return
+	}
+	window := time.Duration(s.cfg.ContentPolicy.ViolationWindowHours) * time.Hour
+	count, err := s.repo.CountViolationsSince(ctx, user.ID, time.Now().Add(-window))
+	if err != nil {
+		log.Printf("[ContentPolicy] count violations failed: user=%d err=%v", user.ID, err)
+		return
+	}
+	if count < int64(s.cfg.ContentPolicy.SuspendAfterViolations) {
+		return
+	}
+
+	current, err := s.userRepo.GetByID(ctx, user.ID)
+	if err != nil || current == nil || current.Status == StatusDisabled {
+		return
+	}
+	current.Status = StatusDisabled
+	if err := s.userRepo.Update(ctx, current); err != nil {
+		log.Printf("[ContentPolicy] suspend user failed: user=%d err=%v", user.ID, err)
+		return
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, user.ID)
+	}
+	if s.sessionService != nil {
+		s.sessionService.revokeAllBestEffort(ctx, user.ID, "", SessionRevokeReasonUserDisabled)
+	}
+	log.Printf("[ContentPolicy] user %d suspended after %d violations within %s", user.ID, count, window)
+}
+
+// moderate 调用审核服务（OpenAI moderation 兼容），未配置或文本为空时视为未命中。
+// 超长文本从末尾截断：调用方传入的文本以最新一轮内容开头，保证新内容总会送审。
+func (s *ContentPolicyService) moderate(ctx context.Context, text string) (bool, []string, error) {
+	if s.cfg == nil || strings.TrimSpace(s.cfg.ContentPolicy.ModerationURL) == "" || strings.TrimSpace(text) == "" {
+		return false, nil, nil
+	}
+	if len(text) > contentPolicyModerationMaxBytes {
+		text = truncateUTF8(text, contentPolicyModerationMaxBytes)
+	}
+	payload, err := json.Marshal(map[string]string{"input": text})
+	if err != nil {
+		return false, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.ContentPolicy.ModerationURL, bytes.NewReader(payload))
+	if err != nil {
+		return false, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return false, nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return false, nil, fmt.Errorf("moderation service returned status %d", resp.StatusCode)
+	}
+	return parseModerationResponse(respBody)
+}
+
+// parseModerationResponse 解析 results[].flagged 与 results[].categories（取值为 true 的类别）
+func parseModerationResponse(body []byte) (bool, []string, error) {
+	results := gjson.GetBytes(body, "results")
+	if !results.IsArray() {
+		return false, nil, errors.New("invalid moderation response")
+	}
+	flagged := false
+	seen := map[string]struct{}{}
+	var categories []string
+	results.ForEach(func(_, result gjson.Result) bool {
+		if !result.Get("flagged").Bool() {
+			return true
+		}
+		flagged = true
+		result.Get("categories").ForEach(func(key, value gjson.Result) bool {
+			if value.Bool() {
+				if _, ok := seen[key.String()]; !ok {
+					seen[key.String()] = struct{}{}
+					categories = append(categories, key.String())
+				}
+			}
+			return true
+		})
+		return true
+	})
+	sort.Strings(categories)
+	return flagged, categories, nil
+}
+
+// extractPolicyText 提取请求中所有由客户端提供的文本，最新一轮内容在前（见 extractPolicyTexts）
+func extractPolicyText(body []byte) string {
+	_, all := extractPolicyTexts(body)
+	return all
+}
+
+// extractPolicyTexts 提取请求中由客户端提供的文本，返回本次新增的内容 latest 与全部文本 all（以 latest 开头）。
+//   - latest：最后一条用户轮次及其后的内容（如 assistant 预填充），以及嵌入接口的 input、图片接口的 prompt；
+//   - all：latest + 系统提示 + 更早的历史轮次。
+//
+// 兼容 Claude messages / OpenAI Chat、OpenAI Responses（instructions + input）、Gemini（systemInstruction + contents）、
+// OpenAI 嵌入的 input、Gemini 嵌入的 content / requests[].content 以及图片接口的 prompt。
+func extractPolicyTexts(body []byte) (latest, all string) {
+	root := gjson.ParseBytes(body)
+	var current, history []string
+	add := func(dst *[]string, text string) {
+		if text != "" {
+			*dst = append(*dst, text)
+		}
+	}
+	// addTurns 最后一条用户轮次及之后的内容视为新内容；没有用户轮次时全部视为新内容
+	addTurns := func(items []gjson.Result, isUser func(gjson.Result) bool, text func(gjson.Result) string) {
+		last := 0
+		for i, item := range items {
+			if isUser(item) {
+				last = i
+			}
+		}
+		for i, item := range items {
+			if i >= last {
+				add(&current, text(item))
+			} else {
+				add(&history, text(item))
+			}
+		}
+	}
+	roleIsUser := func(item gjson.Result) bool { return item.Get("role").String() == "user" }
+	contentText := func(item gjson.Result) string { return collectPolicyText(item.Get("content")) }
+
+	add(&history, collectPolicyText(root.Get("system")))
+	add(&history, root.Get("instructions").String())
+	add(&history, collectPolicyText(root.Get("systemInstruction.parts")))
+	add(&history, collectPolicyText(root.Get("system_instruction.parts")))
+	addTurns(root.Get("messages").Array(), roleIsUser, contentText)
+	input := root.Get("input")
+	inputItems := input.Array()
+	switch {
+	case input.Type == gjson.String:
+		add(&current, input.String())
+	case len(inputItems) > 0 && inputItems[0].Type == gjson.String:
+		// 嵌入接口的字符串数组均为新内容
+		for _, item := range inputItems {
+			add(&current, item.String())
+		}
+	default:
+		addTurns(inputItems, roleIsUser, contentText)
+	}
+	addTurns(root.Get("contents").Array(), func(item gjson.Result) bool {
+		role := item.Get("role").String()
+		return role == "" || role == "user"
+	}, func(item gjson.Result) string {
+		return collectPolicyText(item.Get("parts"))
+	})
+	add(&current, collectPolicyText(root.Get("content.parts")))
+	for _, req := range root.Get("requests").Array() {
+		add(&current, collectPolicyText(req.Get("content.parts")))
+	}
+	add(&current, root.Get("prompt").String())
+
+	latest = strings.Join(current, "\n")
+	return latest, strings.Join(append(current, history...), "\n")
+}
+
+// collectPolicyText 拼接字符串内容或内容块中的 text 字段（忽略图片、工具结果等非文本块）
+func collectPolicyText(v gjson.Result) string {
+	if v.Type == gjson.String {
+		return v.String()
+	}
+	if !v.IsArray() {
+		return ""
+	}
+	var parts []string
+	v.ForEach(func(_, item gjson.Result) bool {
+		if item.Type == gjson.String {
+			parts = append(parts, item.String())
+			return true
+		}
+		if t := item.Get("text"); t.Type == gjson.String {
+			parts = append(parts, t.String())
+		}
+		return true
+	})
+	return strings.Join(parts, "\n")
+}
+
+// stripContentPolicyBody 删除请求体中所有文本字段里的规则匹配内容；无改动时返回原请求体
+func stripContentPolicyBody(body []byte, rules []*compiledContentPolicyRule) []byte {
+	if len(rules) == 0 {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var root any
+	if err := dec.Decode(&root); err != nil {
+		return body
+	}
+	changed := false
+	root = stripContentPolicyValue(root, "", rules, &changed)
+	if !changed {
+		return body
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(root); err != nil {
+		return body
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+func stripContentPolicyValue(v any, key string, rules []*compiledContentPolicyRule, changed *bool) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			val[k] = stripContentPolicyValue(child, k, rules, changed)
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			// 数组元素继承父字段名（如 "system": ["..."]、"input": ["..."]）
+			val[i] = stripContentPolicyValue(child, key, rules, changed)
+		}
+		return val
+	case string:
+		if !contentPolicyTextKeys[key] {
+			return val
+		}
+		out := val
+		for _, rule := range rules {
+			out = rule.strip(out)
+		}
+		if out != val {
+			*changed = true
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// contentPolicyExcerpt 截取匹配位置附近的文本作为审查摘录
+func contentPolicyExcerpt(text string, start, end int) string {
+	from := start - contentPolicyExcerptContext
+	if from < 0 {
+		from = 0
+	}
+	to := end + contentPolicyExcerptContext
+	if to > len(text) {
+		to = len(text)
+	}
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+	return truncateUTF8(text[from:to], contentPolicyExcerptMaxLen)
+}
+
+func truncateUTF8(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
+
+// ---- 管理接口 ----
+
+// ListRules 列出全部规则
+func (s *ContentPolicyService) ListRules(ctx context.Context) ([]ContentPolicyRule, error) {
+	return s.repo.ListRules(ctx)
+}
+
+// CreateRule 创建规则
+func (s *ContentPolicyService) CreateRule(ctx context.Context, input *CreateContentPolicyRuleInput) (*ContentPolicyRule, error) {
+	rule := &ContentPolicyRule{
+		Name:        strings.TrimSpace(input.Name),
+		MatchType:   input.MatchType,
+		Pattern:     input.Pattern,
+		Description: input.Description,
+		Enabled:     input.Enabled,
+	}
+	if err := validateContentPolicyRule(rule); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.invalidateRules()
+	return rule, nil
+}
+
+// UpdateRule 更新规则
+func (s *ContentPolicyService) UpdateRule(ctx context.Context, id int64, input *UpdateContentPolicyRuleInput) (*ContentPolicyRule, error) {
+	rule, err := s.repo.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		rule.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.MatchType != nil {
+		rule.MatchType = *input.MatchType
+	}
+	if input.Pattern != nil {
+		rule.Pattern = *input.Pattern
+	}
+	if input.Description != nil {
+		rule.Description = *input.Description
+	}
+	if input.Enabled != nil {
+		rule.Enabled = *input.Enabled
+	}
+	if err := validateContentPolicyRule(rule); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.invalidateRules()
+	return rule, nil
+}
+
+// DeleteRule 删除规则（已有命中记录保留规则名称）
+func (s *ContentPolicyService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateRules()
+	return nil
+}
+
+// TestRules 用当前启用的规则试运行一段文本（不调用审核服务、不记录命中）
+func (s *ContentPolicyService) TestRules(ctx context.Context, text string) []ContentPolicyMatch {
+	s.invalidateRules()
+	matches := make([]ContentPolicyMatch, 0)
+	for _, rule := range s.loadRules(ctx) {
+		if loc := rule.find(text); loc != nil {
+			matches = append(matches, ContentPolicyMatch{
+				RuleID:   rule.rule.ID,
+				RuleName: rule.rule.Name,
+				Excerpt:  contentPolicyExcerpt(text, loc[0], loc[1]),
+			})
+		}
+	}
+	return matches
+}
+
+// ListHits 分页查询命中记录
+func (s *ContentPolicyService) ListHits(ctx context.Context, params pagination.PaginationParams, filter ContentPolicyHitFilter) ([]ContentPolicyHit, *pagination.PaginationResult, error) {
+	return s.repo.ListHits(ctx, params, filter)
+}
+
+// ReviewHit 标记命中记录已审查
+func (s *ContentPolicyService) ReviewHit(ctx context.Context, id int64, reviewerID int64) error {
+	return s.repo.MarkHitReviewed(ctx, id, reviewerID)
+}
+
+func validateContentPolicyRule(rule *ContentPolicyRule) error {
+	if rule.Name == "" {
+		return infraerrors.BadRequest(ErrContentPolicyRuleInvalid.Reason, "rule name is required")
+	}
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return infraerrors.BadRequest(ErrContentPolicyRuleInvalid.Reason, "rule pattern is required")
+	}
+	switch rule.MatchType {
+	case ContentPolicyMatchKeyword:
+	case ContentPolicyMatchRegex:
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return infraerrors.BadRequest(ErrContentPolicyRuleInvalid.Reason, "invalid regex: "+err.Error())
+		}
+	default:
+		return infraerrors.BadRequest(ErrContentPolicyRuleInvalid.Reason, "match_type must be one of keyword, regex")
+	}
+	return nil
+}
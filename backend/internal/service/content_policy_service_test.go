@@ -0,0 +1,348 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type contentPolicyRepoStub struct {
+	rules      []ContentPolicyRule
+	hits       []ContentPolicyHit
+	violations int64
+}
+
+func (s *contentPolicyRepoStub) CreateRule(ctx context.Context, rule *ContentPolicyRule) error {
+	rule.ID = int64(len(s.rules) + 1)
+	s.rules = append(s.rules, *rule)
+	return nil
+}
+
+func (s *contentPolicyRepoStub) GetRule(ctx context.Context, id int64) (*ContentPolicyRule, error) {
+	for i := range s.rules {
+		if s.rules[i].ID == id {
+			rule := s.rules[i]
+			return &rule, nil
+		}
+	}
+	return nil, ErrContentPolicyRuleNotFound
+}
+
+func (s *contentPolicyRepoStub) UpdateRule(ctx context.Context, rule *ContentPolicyRule) error {
+	for i := range s.rules {
+		if s.rules[i].ID == rule.ID {
+			s.rules[i] = *rule
+			return nil
+		}
+	}
+	return ErrContentPolicyRuleNotFound
+}
+
+func (s *contentPolicyRepoStub) DeleteRule(ctx context.Context, id int64) error {
+	panic("unexpected DeleteRule call")
+}
+
+func (s *contentPolicyRepoStub) ListRules(ctx context.Context) ([]ContentPolicyRule, error) {
+	return s.rules, nil
+}
+
+func (s *contentPolicyRepoStub) CreateHit(ctx context.Context, hit *ContentPolicyHit) error {
+	s.hits = append(s.hits, *hit)
+	if hit.Action != ContentPolicyActionLog {
+		s.violations++
+	}
+	return nil
+}
+
+func (s *contentPolicyRepoStub) ListHits(ctx context.Context, params pagination.PaginationParams, filter ContentPolicyHitFilter) ([]ContentPolicyHit, *pagination.PaginationResult, error) {
+	panic("unexpected ListHits call")
+}
+
+func (s *contentPolicyRepoStub) MarkHitReviewed(ctx context.Context, id int64, reviewerID int64) error {
+	panic("unexpected MarkHitReviewed call")
+}
+
+func (s *contentPolicyRepoStub) CountViolationsSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	return s.violations, nil
+}
+
+func newContentPolicyTestService(repo *contentPolicyRepoStub, cfg *config.Config) *ContentPolicyService {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	return NewContentPolicyService(repo, nil, nil, nil, cfg)
+}
+
+func contentPolicyInput(action string, body string) *ContentPolicyCheckInput {
+	return &ContentPolicyCheckInput{
+		User:     &User{ID: 7, Role: RoleUser},
+		APIKey:   &APIKey{ID: 3},
+		Group:    &Group{ID: 2, ContentPolicyAction: action},
+		Platform: PlatformAnthropic,
+		Model:    "claude-sonnet-4-5",
+		Body:     []byte(body),
+	}
+}
+
+var contentPolicyTestRules = []ContentPolicyRule{
+	{ID: 1, Name: "secret", MatchType: ContentPolicyMatchKeyword, Pattern: "Secret Word", Enabled: true},
+	{ID: 2, Name: "card", MatchType: ContentPolicyMatchRegex, Pattern: `\b\d{4}-\d{4}-\d{4}-\d{4}\b`, Enabled: true},
+	{ID: 3, Name: "disabled", MatchType: ContentPolicyMatchKeyword, Pattern: "hello", Enabled: false},
+}
+
+func TestExtractPolicyText(t *testing.T) {
+	claude := `{"system":[{"type":"text","text":"sys"}],"messages":[{"role":"user","content":"old"},{"role":"assistant","content":"hi"},{"role":"user","content":[{"type":"text","text":"new one"},{"type":"image","source":{}}]},{"role":"assistant","content":"prefill"}]}`
+	latest, all := extractPolicyTexts([]byte(claude))
+	require.Equal(t, "new one\nprefill", latest)
+	require.Equal(t, "new one\nprefill\nsys\nold\nhi", all)
+
+	responsesString := `{"instructions":"be brief","input":"plain input"}`
+	require.Equal(t, "plain input\nbe brief", extractPolicyText([]byte(responsesString)))
+
+	responsesItems := `{"input":[{"role":"developer","content":"dev"},{"role":"user","content":[{"type":"input_text","text":"a"}]},{"type":"function_call_output","output":"x"},{"role":"user","content":[{"type":"input_text","text":"b"}]}]}`
+	require.Equal(t, "b\ndev\na", extractPolicyText([]byte(responsesItems)))
+
+	gemini := `{"systemInstruction":{"parts":[{"text":"gs"}]},"contents":[{"role":"user","parts":[{"text":"q1"}]},{"role":"model","parts":[{"text":"a1"}]},{"parts":[{"text":"q2"},{"text":"q3"}]}]}`
+	require.Equal(t, "q2\nq3\ngs\nq1\na1", extractPolicyText([]byte(gemini)))
+
+	latest, all = extractPolicyTexts([]byte(`{"model":"text-embedding-3-small","input":["e1","e2"]}`))
+	require.Equal(t, "e1\ne2", latest)
+	require.Equal(t, "e1\ne2", all)
+	require.Equal(t, "draw a cat", extractPolicyText([]byte(`{"prompt":"draw a cat"}`)))
+
+	// Gemini embedContent / batchEmbedContents
+	require.Equal(t, "c", extractPolicyText([]byte(`{"content":{"parts":[{"text":"c"}]}}`)))
+	require.Equal(t, "r1\nr2", extractPolicyText([]byte(`{"requests":[{"model":"models/text-embedding-004","content":{"parts":[{"text":"r1"}]}},{"content":{"parts":[{"text":"r2"}]}}]}`)))
+}
+
+func TestContentPolicyEnforce_NoActionSkipsCheck(t *testing.T) {
+	repo := &contentPolicyRepoStub{rules: contentPolicyTestRules}
+	svc := newContentPolicyTestService(repo, nil)
+
+	body := `{"messages":[{"role":"user","content":"the secret word"}]}`
+	out, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionNone, body))
+	require.NoError(t, err)
+	require.Equal(t, body, string(out))
+	require.Empty(t, repo.hits)
+}
+
+func TestContentPolicyEnforce_BlockAndLog(t *testing.T) {
+	repo := &contentPolicyRepoStub{rules: contentPolicyTestRules}
+	svc := newContentPolicyTestService(repo, nil)
+
+	body := `{"messages":[{"role":"user","content":"tell me the SECRET WORD please"}]}`
+	_, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.ErrorIs(t, err, ErrContentPolicyBlocked)
+	require.Len(t, repo.hits, 1)
+	require.Equal(t, "secret", repo.hits[0].RuleName)
+	require.Equal(t, ContentPolicyActionBlock, repo.hits[0].Action)
+	require.Equal(t, int64(7), repo.hits[0].UserID)
+	require.Contains(t, repo.hits[0].Excerpt, "SECRET WORD")
+
+	out, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionLog, body))
+	require.NoError(t, err)
+	require.Equal(t, body, string(out))
+	require.Len(t, repo.hits, 2)
+	require.Equal(t, ContentPolicyActionLog, repo.hits[1].Action)
+
+	// 禁用规则不生效
+	clean := `{"messages":[{"role":"user","content":"hello"}]}`
+	_, err = svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, clean))
+	require.NoError(t, err)
+	require.Len(t, repo.hits, 2)
+
+	// 系统提示、历史轮次与 assistant 预填充同样检查
+	for _, body := range []string{
+		`{"system":"secret word","messages":[{"role":"user","content":"hello"}]}`,
+		`{"messages":[{"role":"user","content":"secret word"},{"role":"assistant","content":"no"},{"role":"user","content":"hello"}]}`,
+		`{"messages":[{"role":"user","content":"hello"},{"role":"assistant","content":"the secret word is"}]}`,
+	} {
+		_, err = svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+		require.ErrorIs(t, err, ErrContentPolicyBlocked, body)
+	}
+}
+
+func TestContentPolicyEnforce_StripRewritesWholeBody(t *testing.T) {
+	repo := &contentPolicyRepoStub{rules: contentPolicyTestRules}
+	svc := newContentPolicyTestService(repo, nil)
+
+	body := `{"model":"claude","max_tokens":1024,"system":"keep <b>tags</b> Secret Word","messages":[{"role":"user","content":"earlier secret word"},{"role":"user","content":[{"type":"text","text":"card 1234-5678-9012-3456 ok"}]}]}`
+	out, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionStrip, body))
+	require.NoError(t, err)
+	require.Equal(t, "keep <b>tags</b> ", gjson.GetBytes(out, "system").String())
+	require.Equal(t, "earlier ", gjson.GetBytes(out, "messages.0.content").String())
+	require.Equal(t, "card  ok", gjson.GetBytes(out, "messages.1.content.0.text").String())
+	require.Equal(t, "1024", gjson.GetBytes(out, "max_tokens").Raw)
+	// 仅出现在系统提示/历史中的命中照常清理，但不重复记录
+	require.Len(t, repo.hits, 1)
+	require.Equal(t, "card", repo.hits[0].RuleName)
+	require.Equal(t, ContentPolicyActionStrip, repo.hits[0].Action)
+
+	clean := `{"messages":[{"role":"user","content":"nothing here"}]}`
+	out, err = svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionStrip, clean))
+	require.NoError(t, err)
+	require.Equal(t, clean, string(out))
+}
+
+func TestContentPolicyEnforce_Moderation(t *testing.T) {
+	flagged := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !flagged {
+			_, _ = w.Write([]byte(`{"results":[{"flagged":false,"categories":{"violence":false}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"flagged":true,"categories":{"violence":true,"hate":false,"harassment":true}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.ContentPolicy.ModerationURL = server.URL
+	repo := &contentPolicyRepoStub{}
+	svc := newContentPolicyTestService(repo, cfg)
+
+	body := `{"messages":[{"role":"user","content":"something bad"}]}`
+	_, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.ErrorIs(t, err, ErrContentPolicyBlocked)
+	require.Len(t, repo.hits, 1)
+	require.Equal(t, ContentPolicyHitSourceModeration, repo.hits[0].Source)
+	require.Equal(t, []string{"harassment", "violence"}, repo.hits[0].Categories)
+
+	// strip 无法定位审核服务命中的内容，按 block 处理
+	_, err = svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionStrip, body))
+	require.ErrorIs(t, err, ErrContentPolicyBlocked)
+	require.Equal(t, ContentPolicyActionBlock, repo.hits[1].Action)
+
+	flagged = false
+	_, err = svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.NoError(t, err)
+	require.Len(t, repo.hits, 2)
+}
+
+func TestContentPolicyEnforce_ModerationSeesLatestTurn(t *testing.T) {
+	var input string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		input = gjson.GetBytes(body, "input").String()
+		_, _ = w.Write([]byte(`{"results":[{"flagged":false}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.ContentPolicy.ModerationURL = server.URL
+	svc := newContentPolicyTestService(&contentPolicyRepoStub{}, cfg)
+
+	// 超长历史不能把最新一轮挤出审核文本
+	padding := strings.Repeat("x", contentPolicyModerationMaxBytes)
+	body := `{"messages":[{"role":"user","content":"` + padding + `"},{"role":"assistant","content":"ok"},{"role":"user","content":"newest question"}]}`
+	_, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(input, "newest question\n"))
+	require.LessOrEqual(t, len(input), contentPolicyModerationMaxBytes)
+}
+
+func TestContentPolicyEnforce_HistoryHitsNotRecountedOutsideBlock(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ContentPolicy.SuspendAfterViolations = 1
+	cfg.ContentPolicy.ViolationWindowHours = 24
+
+	repo := &contentPolicyRepoStub{rules: contentPolicyTestRules}
+	userRepo := &balanceUserRepoStub{userRepoStub: &userRepoStub{user: &User{ID: 7, Role: RoleUser, Status: StatusActive}}}
+	svc := NewContentPolicyService(repo, userRepo, &authCacheInvalidatorStub{}, nil, cfg)
+
+	// 客户端重发的历史中仍带着旧违规内容：清理后继续转发，不再计入违规
+	body := `{"messages":[{"role":"user","content":"the secret word"},{"role":"assistant","content":"ok"},{"role":"user","content":"next question"}]}`
+	for i := 0; i < 3; i++ {
+		out, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionStrip, body))
+		require.NoError(t, err)
+		require.Equal(t, "the ", gjson.GetBytes(out, "messages.0.content").String())
+	}
+	require.Empty(t, repo.hits)
+	require.Empty(t, userRepo.updated)
+
+	// block 模式下仍拦截并计数
+	_, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.ErrorIs(t, err, ErrContentPolicyBlocked)
+	require.Len(t, repo.hits, 1)
+}
+
+func TestContentPolicyEnforce_ModerationFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.ContentPolicy.ModerationURL = server.URL
+	cfg.ContentPolicy.ModerationFailOpen = true
+	svc := newContentPolicyTestService(&contentPolicyRepoStub{}, cfg)
+
+	body := `{"messages":[{"role":"user","content":"anything"}]}`
+	_, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.NoError(t, err)
+
+	cfg.ContentPolicy.ModerationFailOpen = false
+	_, err = svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.ErrorIs(t, err, ErrContentPolicyUnavailable)
+}
+
+func TestContentPolicyEnforce_SuspendsUserAfterViolations(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ContentPolicy.SuspendAfterViolations = 2
+	cfg.ContentPolicy.ViolationWindowHours = 24
+
+	repo := &contentPolicyRepoStub{rules: contentPolicyTestRules}
+	userRepo := &balanceUserRepoStub{userRepoStub: &userRepoStub{user: &User{ID: 7, Role: RoleUser, Status: StatusActive}}}
+	invalidator := &authCacheInvalidatorStub{}
+	svc := NewContentPolicyService(repo, userRepo, invalidator, nil, cfg)
+
+	body := `{"messages":[{"role":"user","content":"secret word"}]}`
+
+	// 仅记录模式的命中不计入阈值
+	_, err := svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionLog, body))
+	require.NoError(t, err)
+	_, err = svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.ErrorIs(t, err, ErrContentPolicyBlocked)
+	require.Empty(t, userRepo.updated)
+
+	_, err = svc.Enforce(context.Background(), contentPolicyInput(ContentPolicyActionBlock, body))
+	require.ErrorIs(t, err, ErrContentPolicyBlocked)
+	require.Len(t, userRepo.updated, 1)
+	require.Equal(t, StatusDisabled, userRepo.updated[0].Status)
+	require.Equal(t, []int64{7}, invalidator.userIDs)
+}
+
+func TestContentPolicyRuleValidation(t *testing.T) {
+	repo := &contentPolicyRepoStub{}
+	svc := newContentPolicyTestService(repo, nil)
+
+	_, err := svc.CreateRule(context.Background(), &CreateContentPolicyRuleInput{Name: "bad", MatchType: ContentPolicyMatchRegex, Pattern: "(unclosed", Enabled: true})
+	require.ErrorIs(t, err, ErrContentPolicyRuleInvalid)
+	_, err = svc.CreateRule(context.Background(), &CreateContentPolicyRuleInput{Name: "x", MatchType: "glob", Pattern: "a*"})
+	require.ErrorIs(t, err, ErrContentPolicyRuleInvalid)
+
+	rule, err := svc.CreateRule(context.Background(), &CreateContentPolicyRuleInput{Name: "kw", MatchType: ContentPolicyMatchKeyword, Pattern: "Forbidden", Enabled: true})
+	require.NoError(t, err)
+
+	matches := svc.TestRules(context.Background(), "this is forbidden text")
+	require.Len(t, matches, 1)
+	require.Equal(t, rule.ID, matches[0].RuleID)
+
+	disabled := false
+	_, err = svc.UpdateRule(context.Background(), rule.ID, &UpdateContentPolicyRuleInput{Enabled: &disabled})
+	require.NoError(t, err)
+	require.Empty(t, svc.TestRules(context.Background(), "this is forbidden text"))
+
+	require.NoError(t, ValidateContentPolicyAction(ContentPolicyActionStrip))
+	require.ErrorIs(t, ValidateContentPolicyAction("drop"), ErrContentPolicyActionInvalid)
+}
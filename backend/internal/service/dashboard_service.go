@@ -140,6 +140,14 @@ func (s *DashboardService) GetModelStatsWithFilters(ctx context.Context, startTi
 	return stats, nil
 }
 
+func (s *DashboardService) GetAccountCacheStats(ctx context.Context, startTime, endTime time.Time, groupID int64) ([]usagestats.AccountCacheStat, error) {
+	stats, err := s.usageRepo.GetAccountCacheStats(ctx, startTime, endTime, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("get account cache stats: %w", err)
+	}
+	return stats, nil
+}
+
 func (s *DashboardService) getCachedDashboardStats(ctx context.Context) (*usagestats.DashboardStats, bool, error) {
 	data, err := s.cache.GetDashboardStats(ctx)
 	if err != nil {
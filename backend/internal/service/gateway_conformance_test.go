@@ -0,0 +1,298 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/fakeupstream"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// 协议翻译一致性测试：
+// testdata/conformance/<name>.json 描述入站请求、账号与录制的上游响应，
+// 经真实的 Forward 链路转发后，将上游请求、客户端响应与用量提取结果与 <name>.golden.json 比对。
+// 修改转换逻辑后可使用 `go test -tags unit ./internal/service -run TestGatewayConformance -update` 重新生成 golden 文件。
+
+var updateConformanceGolden = flag.Bool("update", false, "update conformance golden files")
+
+type conformanceCase struct {
+	Description string            `json:"description"`
+	Route       string            `json:"route"` // messages | openai_responses | gemini_v1beta
+	Model       string            `json:"model,omitempty"`
+	Stream      bool              `json:"stream,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Account     struct {
+		Platform    string         `json:"platform"`
+		Type        string         `json:"type"`
+		Credentials map[string]any `json:"credentials"`
+		Extra       map[string]any `json:"extra,omitempty"`
+	} `json:"account"`
+	Request  json.RawMessage `json:"request"`
+	Upstream struct {
+		Status  int               `json:"status"`
+		Headers map[string]string `json:"headers,omitempty"`
+		// Body 为 JSON 响应；SSE 响应使用 Events（每项为一行，空字符串表示事件分隔）
+		Body   json.RawMessage `json:"body,omitempty"`
+		Events []string        `json:"events,omitempty"`
+	} `json:"upstream"`
+}
+
+type conformanceGolden struct {
+	UpstreamRequests []*CapturedUpstreamRequest `json:"upstream_requests"`
+	ClientStatus     int                        `json:"client_status"`
+	ClientBody       any                        `json:"client_body"`
+	Usage            any                        `json:"usage,omitempty"`
+	Error            string                     `json:"error,omitempty"`
+}
+
+// conformanceVolatileHeaders 每次运行都会变化的请求头，不参与比对
+var conformanceVolatileHeaders = map[string]bool{
+	"x-stainless-retry-count": true,
+	"x-request-id":            true,
+}
+
+// conformanceRandomIDs 转发链路中随机生成的 ID，替换为固定占位符后再比对
+var conformanceRandomIDs = []struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`agent-[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`), "agent-<uuid>"},
+	{regexp.MustCompile(`toolu_[0-9a-f]{16}\b`), "toolu_<random>"},
+	{regexp.MustCompile(`msg_[0-9a-f]{24}\b`), "msg_<random>"},
+}
+
+type conformanceUpstream struct {
+	tc       *conformanceCase
+	requests []*CapturedUpstreamRequest
+}
+
+func (u *conformanceUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	captured := captureUpstreamRequest(req, body, proxyURL != "")
+	for k := range captured.Headers {
+		if conformanceVolatileHeaders[k] {
+			delete(captured.Headers, k)
+		}
+	}
+	u.requests = append(u.requests, captured)
+
+	header := http.Header{}
+	for k, v := range u.tc.Upstream.Headers {
+		header.Set(k, v)
+	}
+	var payload []byte
+	if len(u.tc.Upstream.Events) > 0 {
+		payload = []byte(strings.Join(u.tc.Upstream.Events, "\n") + "\n")
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "text/event-stream")
+		}
+	} else {
+		payload = u.tc.Upstream.Body
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/json")
+		}
+	}
+	status := u.tc.Upstream.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return fakeupstream.NewResponse(status, header, payload), nil
+}
+
+func (u *conformanceUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	return u.Do(req, proxyURL, accountID, accountConcurrency)
+}
+
+func TestGatewayConformance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Codex OAuth 转换会读取 opencode 指令缓存，预置缓存避免联网
+	setupCodexCache(t)
+
+	files, err := filepath.Glob(filepath.Join("testdata", "conformance", "*.json"))
+	require.NoError(t, err)
+	var cases []string
+	for _, f := range files {
+		if !strings.HasSuffix(f, ".golden.json") {
+			cases = append(cases, f)
+		}
+	}
+	sort.Strings(cases)
+	require.NotEmpty(t, cases)
+
+	for _, path := range cases {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var tc conformanceCase
+			require.NoError(t, json.Unmarshal(raw, &tc))
+
+			got := runConformanceCase(t, &tc)
+			gotJSON, err := json.MarshalIndent(got, "", "  ")
+			require.NoError(t, err)
+			for _, r := range conformanceRandomIDs {
+				gotJSON = r.re.ReplaceAll(gotJSON, []byte(r.placeholder))
+			}
+			gotJSON = append(gotJSON, '\n')
+
+			goldenPath := strings.TrimSuffix(path, ".json") + ".golden.json"
+			if *updateConformanceGolden {
+				require.NoError(t, os.WriteFile(goldenPath, gotJSON, 0o644))
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			require.NoError(t, err, "missing golden file, run with -update")
+			require.JSONEq(t, string(want), string(gotJSON))
+		})
+	}
+}
+
+func runConformanceCase(t *testing.T, tc *conformanceCase) *conformanceGolden {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Security.URLAllowlist.Enabled = false
+	cfg.Security.URLAllowlist.AllowInsecureHTTP = true
+	cfg.Gateway.MaxLineSize = 1 << 20
+
+	upstream := &conformanceUpstream{tc: tc}
+	account := &Account{
+		ID:          1,
+		Name:        "conformance",
+		Platform:    tc.Account.Platform,
+		Type:        tc.Account.Type,
+		Credentials: tc.Account.Credentials,
+		Extra:       tc.Account.Extra,
+		Concurrency: 1,
+		Status:      StatusActive,
+		Schedulable: true,
+	}
+
+	var path string
+	switch tc.Route {
+	case "messages":
+		path = "/v1/messages"
+	case "openai_responses":
+		path = "/v1/responses"
+	case "gemini_v1beta":
+		action := "generateContent"
+		if tc.Stream {
+			action = "streamGenerateContent"
+		}
+		path = "/v1beta/models/" + tc.Model + ":" + action
+	default:
+		t.Fatalf("unknown route %q", tc.Route)
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c.Request = httptest.NewRequest(http.MethodPost, path, bytes.NewReader(tc.Request)).WithContext(ctx)
+	c.Request.Header.Set("Content-Type", "application/json")
+	for k, v := range tc.Headers {
+		c.Request.Header.Set(k, v)
+	}
+
+	gateway := &GatewayService{cfg: cfg, httpUpstream: upstream}
+	antigravitySvc := &AntigravityGatewayService{tokenProvider: &AntigravityTokenProvider{}, httpUpstream: upstream, settingService: &SettingService{settingRepo: &settingRepoStub{}, cfg: cfg}}
+	geminiSvc := &GeminiMessagesCompatService{cfg: cfg, httpUpstream: upstream, antigravityGatewayService: antigravitySvc}
+	openaiSvc := &OpenAIGatewayService{cfg: cfg, httpUpstream: upstream}
+
+	out := &conformanceGolden{}
+	var (
+		usage any
+		err   error
+	)
+	switch tc.Route {
+	case "messages":
+		switch account.Platform {
+		case PlatformAntigravity:
+			var r *ForwardResult
+			r, err = antigravitySvc.Forward(ctx, c, account, tc.Request)
+			usage = forwardResultUsage(r)
+		case PlatformGemini:
+			var r *ForwardResult
+			r, err = geminiSvc.Forward(ctx, c, account, tc.Request)
+			usage = forwardResultUsage(r)
+		default:
+			parsed, parseErr := ParseGatewayRequest(tc.Request)
+			require.NoError(t, parseErr)
+			var r *ForwardResult
+			r, err = gateway.Forward(ctx, c, account, parsed)
+			usage = forwardResultUsage(r)
+		}
+	case "openai_responses":
+		var r *OpenAIForwardResult
+		r, err = openaiSvc.Forward(ctx, c, account, tc.Request)
+		if r != nil {
+			usage = r.Usage
+		}
+	case "gemini_v1beta":
+		var r *ForwardResult
+		switch account.Platform {
+		case PlatformAnthropic:
+			r, err = gateway.ForwardGeminiAsClaude(ctx, c, account, tc.Model, tc.Stream, tc.Request)
+		case PlatformAntigravity:
+			r, err = antigravitySvc.ForwardGemini(ctx, c, account, tc.Model, "generateContent", tc.Stream, tc.Request)
+		default:
+			action := "generateContent"
+			if tc.Stream {
+				action = "streamGenerateContent"
+			}
+			r, err = geminiSvc.ForwardNative(ctx, c, account, tc.Model, action, tc.Stream, tc.Request)
+		}
+		usage = forwardResultUsage(r)
+	}
+
+	out.UpstreamRequests = upstream.requests
+	out.ClientStatus = rec.Code
+	out.ClientBody = conformanceBody(rec.Body.Bytes())
+	out.Usage = usage
+	if err != nil {
+		out.Error = err.Error()
+	}
+	return out
+}
+
+func forwardResultUsage(r *ForwardResult) any {
+	if r == nil {
+		return nil
+	}
+	return r.Usage
+}
+
+// conformanceBody JSON 响应按对象比对，SSE 响应按非空行比对
+func conformanceBody(body []byte) any {
+	if json.Valid(body) {
+		var v any
+		_ = json.Unmarshal(body, &v)
+		return v
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
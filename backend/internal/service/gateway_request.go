@@ -25,6 +25,7 @@ type ParsedRequest struct {
 	MetadataUserID string // metadata.user_id（用于会话亲和）
 	System         any    // system 字段内容
 	Messages       []any  // messages 数组
+	Tools          []any  // tools 数组（用于 prompt 缓存前缀识别）
 	HasSystem      bool   // 是否包含 system 字段（包含 null 也视为显式传入）
 }
 
@@ -68,6 +69,9 @@ func ParseGatewayRequest(body []byte) (*ParsedRequest, error) {
 	if messages, ok := req["messages"].([]any); ok {
 		parsed.Messages = messages
 	}
+	if tools, ok := req["tools"].([]any); ok {
+		parsed.Tools = tools
+	}
 
 	return parsed, nil
 }
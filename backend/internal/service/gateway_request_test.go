@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -296,3 +297,15 @@ func TestFilterSignatureSensitiveBlocksForRetry_DowngradesTools(t *testing.T) {
 	require.Contains(t, content0["text"], "tool_use")
 	require.Contains(t, content1["text"], "tool_result")
 }
+
+func TestTruncateForLogRedactsBeforeCut(t *testing.T) {
+	secret := "upstream-secret-" + strings.Repeat("x", 64)
+	body := []byte(`{"padding":"` + strings.Repeat("a", 40) + `","access_token":"` + secret + `","model":"claude"}`)
+	// 截断点落在 access_token 值中间
+	maxBytes := strings.Index(string(body), secret) + 20
+
+	out := truncateForLog(body, maxBytes)
+	require.NotContains(t, out, "upstream-secret")
+	require.True(t, strings.HasSuffix(out, "...(truncated)"))
+	require.LessOrEqual(t, len(out), maxBytes+len("...(truncated)"))
+}
@@ -10,7 +10,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"log/slog"
 	mathrand "math/rand"
 	"net/http"
@@ -24,6 +23,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/util/logredact"
 	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
 	"github.com/tidwall/gjson"
@@ -184,6 +184,9 @@ type ForwardResult struct {
 	// 图片生成计费字段（仅 gemini-3-pro-image 使用）
 	ImageCount int    // 生成的图片数量
 	ImageSize  string // 图片尺寸 "1K", "2K", "4K"
+
+	// Embedding 向量嵌入请求（按输入 token 使用嵌入模型价格计费）
+	Embedding bool
 }
 
 // UpstreamFailoverError indicates an upstream error that should trigger account failover.
@@ -213,7 +216,10 @@ type GatewayService struct {
 	deferredService     *DeferredService
 	concurrencyService  *ConcurrencyService
 	claudeTokenProvider *ClaudeTokenProvider
-	sessionLimitCache   SessionLimitCache // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
+	sessionLimitCache   SessionLimitCache        // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
+	promptCacheAffinity PromptCacheAffinityCache // Prompt 缓存亲和调度（可选）
+	accountSelection    *AccountSelectionService // 分组账号选择策略（可选）
+	circuitBreaker      *CircuitBreakerService   // 账号熔断（可选）
 }
 
 // NewGatewayService creates a new GatewayService
@@ -235,6 +241,9 @@ func NewGatewayService(
 	deferredService *DeferredService,
 	claudeTokenProvider *ClaudeTokenProvider,
 	sessionLimitCache SessionLimitCache,
+	promptCacheAffinity PromptCacheAffinityCache,
+	accountSelection *AccountSelectionService,
+	circuitBreaker *CircuitBreakerService,
 ) *GatewayService {
 	return &GatewayService{
 		accountRepo:         accountRepo,
@@ -254,6 +263,9 @@ func NewGatewayService(
 		deferredService:     deferredService,
 		claudeTokenProvider: claudeTokenProvider,
 		sessionLimitCache:   sessionLimitCache,
+		promptCacheAffinity: promptCacheAffinity,
+		accountSelection:    accountSelection,
+		circuitBreaker:      circuitBreaker,
 	}
 }
 
@@ -462,6 +474,14 @@ func (s *GatewayService) SelectAccountForModelWithExclusions(ctx context.Context
 // SelectAccountWithLoadAwareness selects account with load-awareness and wait plan.
 // metadataUserID: 已废弃参数，会话限制现在统一使用 sessionHash
 func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}, metadataUserID string) (*AccountSelectionResult, error) {
+	return traceAccountSelection(ctx, groupID, requestedModel, excludedIDs, func(ctx context.Context) (*AccountSelectionResult, error) {
+		return s.circuitBreaker.SelectWithProbe(ctx, excludedIDs, func(excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+			return s.selectAccountWithLoadAwareness(ctx, groupID, sessionHash, requestedModel, excludedIDs)
+		})
+	})
+}
+
+func (s *GatewayService) selectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
 	// 调试日志：记录调度入口参数
 	excludedIDsList := make([]int64, 0, len(excludedIDs))
 	for id := range excludedIDs {
@@ -494,8 +514,9 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 		if group != nil {
 			groupPlatform = group.Platform
 		}
-		log.Printf("[ModelRoutingDebug] select entry: group_id=%v group_platform=%s model=%s session=%s sticky_account=%d load_batch=%v concurrency=%v",
-			derefGroupID(groupID), groupPlatform, requestedModel, shortSessionHash(sessionHash), stickyAccountID, cfg.LoadBatchEnabled, s.concurrencyService != nil)
+		slog.InfoContext(ctx, "model_routing_select_entry",
+			"group_id", derefGroupID(groupID), "group_platform", groupPlatform, "model", requestedModel, "session", shortSessionHash(sessionHash),
+			"sticky_account_id", stickyAccountID, "load_batch", cfg.LoadBatchEnabled, "concurrency", s.concurrencyService != nil)
 	}
 
 	if s.concurrencyService == nil || !cfg.LoadBatchEnabled {
@@ -564,7 +585,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	}
 	preferOAuth := platform == PlatformGemini
 	if s.debugModelRoutingEnabled() && platform == PlatformAnthropic && requestedModel != "" {
-		log.Printf("[ModelRoutingDebug] load-aware enabled: group_id=%v model=%s session=%s platform=%s", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), platform)
+		slog.InfoContext(ctx, "model_routing_load_aware", "group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "platform", platform)
 	}
 
 	accounts, useMixed, err := s.listSchedulableAccounts(ctx, groupID, platform, hasForcePlatform)
@@ -594,8 +615,9 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	if group != nil && requestedModel != "" && group.Platform == PlatformAnthropic {
 		routingAccountIDs = group.GetRoutingAccountIDs(requestedModel)
 		if s.debugModelRoutingEnabled() {
-			log.Printf("[ModelRoutingDebug] context group routing: group_id=%d model=%s enabled=%v rules=%d matched_ids=%v session=%s sticky_account=%d",
-				group.ID, requestedModel, group.ModelRoutingEnabled, len(group.ModelRouting), routingAccountIDs, shortSessionHash(sessionHash), stickyAccountID)
+			slog.InfoContext(ctx, "model_routing_context_group",
+				"group_id", group.ID, "model", requestedModel, "enabled", group.ModelRoutingEnabled, "rules", len(group.ModelRouting),
+				"matched_ids", routingAccountIDs, "session", shortSessionHash(sessionHash), "sticky_account_id", stickyAccountID)
 			if len(routingAccountIDs) == 0 && group.ModelRoutingEnabled && len(group.ModelRouting) > 0 {
 				keys := make([]string, 0, len(group.ModelRouting))
 				for k := range group.ModelRouting {
@@ -606,7 +628,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 				if len(keys) > maxKeys {
 					keys = keys[:maxKeys]
 				}
-				log.Printf("[ModelRoutingDebug] context group routing miss: group_id=%d model=%s patterns(sample)=%v", group.ID, requestedModel, keys)
+				slog.InfoContext(ctx, "model_routing_context_group_miss", "group_id", group.ID, "model", requestedModel, "patterns_sample", keys)
 			}
 		}
 	}
@@ -651,9 +673,11 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 		}
 
 		if s.debugModelRoutingEnabled() {
-			log.Printf("[ModelRoutingDebug] routed candidates: group_id=%v model=%s routed=%d candidates=%d filtered(excluded=%d missing=%d unsched=%d platform=%d model_scope=%d model_mapping=%d window_cost=%d)",
-				derefGroupID(groupID), requestedModel, len(routingAccountIDs), len(routingCandidates),
-				filteredExcluded, filteredMissing, filteredUnsched, filteredPlatform, filteredModelScope, filteredModelMapping, filteredWindowCost)
+			slog.InfoContext(ctx, "model_routing_routed_candidates",
+				"group_id", derefGroupID(groupID), "model", requestedModel, "routed", len(routingAccountIDs), "candidates", len(routingCandidates),
+				"filtered_excluded", filteredExcluded, "filtered_missing", filteredMissing, "filtered_unsched", filteredUnsched,
+				"filtered_platform", filteredPlatform, "filtered_model_scope", filteredModelScope, "filtered_model_mapping", filteredModelMapping,
+				"filtered_window_cost", filteredWindowCost)
 		}
 
 		if len(routingCandidates) > 0 {
@@ -677,7 +701,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 								} else {
 									_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL)
 									if s.debugModelRoutingEnabled() {
-										log.Printf("[ModelRoutingDebug] routed sticky hit: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), stickyAccountID)
+										slog.InfoContext(ctx, "model_routing_sticky_hit", "group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "account_id", stickyAccountID)
 									}
 									return &AccountSelectionResult{
 										Account:     stickyAccount,
@@ -773,7 +797,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 							_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, item.account.ID, stickySessionTTL)
 						}
 						if s.debugModelRoutingEnabled() {
-							log.Printf("[ModelRoutingDebug] routed select: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), item.account.ID)
+							slog.InfoContext(ctx, "model_routing_select", "group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "account_id", item.account.ID)
 						}
 						return &AccountSelectionResult{
 							Account:     item.account,
@@ -790,7 +814,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 						continue // 会话限制已满，尝试下一个
 					}
 					if s.debugModelRoutingEnabled() {
-						log.Printf("[ModelRoutingDebug] routed wait: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), item.account.ID)
+						slog.InfoContext(ctx, "model_routing_wait", "group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "account_id", item.account.ID)
 					}
 					return &AccountSelectionResult{
 						Account: item.account,
@@ -805,7 +829,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 				// 所有路由账号会话限制都已满，继续到 Layer 2 回退
 			}
 			// 路由列表中的账号都不可用（负载率 >= 100），继续到 Layer 2 回退
-			log.Printf("[ModelRouting] All routed accounts unavailable for model=%s, falling back to normal selection", requestedModel)
+			slog.InfoContext(ctx, "model_routing_fallback", "model", requestedModel, "reason", "all routed accounts unavailable")
 		}
 	}
 
@@ -907,9 +931,15 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 		})
 	}
 
+	// 缓存亲和：查询候选账号持有的热 prompt 缓存前缀深度
+	cachePrefixes := promptCachePrefixesFromContext(ctx)
+	warmDepth := s.warmPrefixDepths(ctx, cachePrefixes)
+	// 分组账号选择策略评分（越低越优先，仅在同优先级内生效）
+	selectionScores := s.accountSelection.ScoreForGroup(ctx, groupID, candidates)
+
 	loadMap, err := s.concurrencyService.GetAccountsLoadBatch(ctx, accountLoads)
 	if err != nil {
-		if result, ok := s.tryAcquireByLegacyOrder(ctx, candidates, groupID, sessionHash, preferOAuth); ok {
+		if result, ok := s.tryAcquireByLegacyOrder(ctx, candidates, groupID, sessionHash, preferOAuth, selectionScores); ok {
 			return result, nil
 		}
 	} else {
@@ -934,9 +964,16 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 		if len(available) > 0 {
 			sort.SliceStable(available, func(i, j int) bool {
 				a, b := available[i], available[j]
+				// 持有更深热缓存前缀的账号优先（与粘性会话一致，优先于账号优先级）
+				if warmDepth[a.account.ID] != warmDepth[b.account.ID] {
+					return warmDepth[a.account.ID] > warmDepth[b.account.ID]
+				}
 				if a.account.Priority != b.account.Priority {
 					return a.account.Priority < b.account.Priority
 				}
+				if selectionScores[a.account.ID] != selectionScores[b.account.ID] {
+					return selectionScores[a.account.ID] < selectionScores[b.account.ID]
+				}
 				if a.loadInfo.LoadRate != b.loadInfo.LoadRate {
 					return a.loadInfo.LoadRate < b.loadInfo.LoadRate
 				}
@@ -966,6 +1003,9 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 					if sessionHash != "" && s.cache != nil {
 						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, item.account.ID, stickySessionTTL)
 					}
+					if selectionScores != nil {
+						s.accountSelection.RecordSelection(ctx, groupID, candidates, item.account)
+					}
 					return &AccountSelectionResult{
 						Account:     item.account,
 						Acquired:    true,
@@ -976,8 +1016,15 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 		}
 	}
 
+	// ============ Layer 2.5: 缓存亲和排队 ============
+	// 热账号均已满载时，按前缀长度权衡是否值得排队等待热账号，而不是在冷账号上重建缓存
+	if result := s.cacheAffinityWaitPlan(ctx, candidates, warmDepth, cachePrefixes, cfg, sessionHash); result != nil {
+		return result, nil
+	}
+
 	// ============ Layer 3: 兜底排队 ============
 	s.sortCandidatesForFallback(candidates, preferOAuth, cfg.FallbackSelectionMode)
+	sortAccountsBySelectionScores(candidates, selectionScores)
 	for _, acc := range candidates {
 		// 会话数量限制检查（等待计划也需要占用会话配额）
 		if !s.checkAndRegisterSession(ctx, acc, sessionHash) {
@@ -996,9 +1043,10 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	return nil, errors.New("no available accounts")
 }
 
-func (s *GatewayService) tryAcquireByLegacyOrder(ctx context.Context, candidates []*Account, groupID *int64, sessionHash string, preferOAuth bool) (*AccountSelectionResult, bool) {
+func (s *GatewayService) tryAcquireByLegacyOrder(ctx context.Context, candidates []*Account, groupID *int64, sessionHash string, preferOAuth bool, selectionScores map[int64]float64) (*AccountSelectionResult, bool) {
 	ordered := append([]*Account(nil), candidates...)
 	sortAccountsByPriorityAndLastUsed(ordered, preferOAuth)
+	sortAccountsBySelectionScores(ordered, selectionScores)
 
 	for _, acc := range ordered {
 		result, err := s.tryAcquireAccountSlot(ctx, acc.ID, acc.Concurrency)
@@ -1011,6 +1059,9 @@ func (s *GatewayService) tryAcquireByLegacyOrder(ctx context.Context, candidates
 			if sessionHash != "" && s.cache != nil {
 				_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, acc.ID, stickySessionTTL)
 			}
+			if selectionScores != nil {
+				s.accountSelection.RecordSelection(ctx, groupID, candidates, acc)
+			}
 			return &AccountSelectionResult{
 				Account:     acc,
 				Acquired:    true,
@@ -1071,21 +1122,21 @@ func (s *GatewayService) routingAccountIDsForRequest(ctx context.Context, groupI
 	group, err := s.resolveGroupByID(ctx, *groupID)
 	if err != nil || group == nil {
 		if s.debugModelRoutingEnabled() {
-			log.Printf("[ModelRoutingDebug] resolve group failed: group_id=%v model=%s platform=%s err=%v", derefGroupID(groupID), requestedModel, platform, err)
+			slog.InfoContext(ctx, "model_routing_resolve_group_failed", "group_id", derefGroupID(groupID), "model", requestedModel, "platform", platform, "error", err)
 		}
 		return nil
 	}
 	// Preserve existing behavior: model routing only applies to anthropic groups.
 	if group.Platform != PlatformAnthropic {
 		if s.debugModelRoutingEnabled() {
-			log.Printf("[ModelRoutingDebug] skip: non-anthropic group platform: group_id=%d group_platform=%s model=%s", group.ID, group.Platform, requestedModel)
+			slog.InfoContext(ctx, "model_routing_skip_platform", "group_id", group.ID, "group_platform", group.Platform, "model", requestedModel)
 		}
 		return nil
 	}
 	ids := group.GetRoutingAccountIDs(requestedModel)
 	if s.debugModelRoutingEnabled() {
-		log.Printf("[ModelRoutingDebug] routing lookup: group_id=%d model=%s enabled=%v rules=%d matched_ids=%v",
-			group.ID, requestedModel, group.ModelRoutingEnabled, len(group.ModelRouting), ids)
+		slog.InfoContext(ctx, "model_routing_lookup",
+			"group_id", group.ID, "model", requestedModel, "enabled", group.ModelRoutingEnabled, "rules", len(group.ModelRouting), "matched_ids", ids)
 	}
 	return ids
 }
@@ -1159,7 +1210,16 @@ func (s *GatewayService) resolvePlatform(ctx context.Context, groupID *int64, gr
 	return PlatformAnthropic, false, nil
 }
 
+// listSchedulableAccounts 获取可调度账号列表，并过滤掉熔断中的账号
 func (s *GatewayService) listSchedulableAccounts(ctx context.Context, groupID *int64, platform string, hasForcePlatform bool) ([]Account, bool, error) {
+	accounts, useMixed, err := s.loadSchedulableAccounts(ctx, groupID, platform, hasForcePlatform)
+	if err != nil {
+		return accounts, useMixed, err
+	}
+	return s.circuitBreaker.FilterAccounts(ctx, accounts), useMixed, nil
+}
+
+func (s *GatewayService) loadSchedulableAccounts(ctx context.Context, groupID *int64, platform string, hasForcePlatform bool) ([]Account, bool, error) {
 	if s.schedulerSnapshot != nil {
 		accounts, useMixed, err := s.schedulerSnapshot.ListSchedulableAccounts(ctx, groupID, platform, hasForcePlatform)
 		if err == nil {
@@ -1305,36 +1365,12 @@ func (s *GatewayService) isAccountSchedulableForWindowCost(ctx context.Context,
 		return true // 未启用窗口费用限制
 	}
 
-	// 尝试从缓存获取窗口费用
-	var currentCost float64
-	if s.sessionLimitCache != nil {
-		if cost, hit, err := s.sessionLimitCache.GetWindowCost(ctx, account.ID); err == nil && hit {
-			currentCost = cost
-			goto checkSchedulability
-		}
-	}
-
-	// 缓存未命中，从数据库查询
-	{
-		// 使用统一的窗口开始时间计算逻辑（考虑窗口过期情况）
-		startTime := account.GetCurrentWindowStartTime()
-
-		stats, err := s.usageLogRepo.GetAccountWindowStats(ctx, account.ID, startTime)
-		if err != nil {
-			// 失败开放：查询失败时允许调度
-			return true
-		}
-
-		// 使用标准费用（不含账号倍率）
-		currentCost = stats.StandardCost
-
-		// 设置缓存（忽略错误）
-		if s.sessionLimitCache != nil {
-			_ = s.sessionLimitCache.SetWindowCost(ctx, account.ID, currentCost)
-		}
+	currentCost, ok := lookupAccountWindowCost(ctx, s.sessionLimitCache, s.usageLogRepo, account)
+	if !ok {
+		// 失败开放：查询失败时允许调度
+		return true
 	}
 
-checkSchedulability:
 	schedulability := account.CheckWindowCostSchedulability(currentCost)
 
 	switch schedulability {
@@ -1348,6 +1384,37 @@ checkSchedulability:
 	return true
 }
 
+// lookupAccountWindowCost 获取账号当前 5h 窗口的标准费用（不含账号倍率），优先读缓存，未命中时查询数据库并回写缓存。
+// 查询失败时返回 false。
+func lookupAccountWindowCost(ctx context.Context, cache SessionLimitCache, usageLogRepo UsageLogRepository, account *Account) (float64, bool) {
+	// 尝试从缓存获取窗口费用
+	if cache != nil {
+		if cost, hit, err := cache.GetWindowCost(ctx, account.ID); err == nil && hit {
+			return cost, true
+		}
+	}
+	if usageLogRepo == nil {
+		return 0, false
+	}
+
+	// 缓存未命中，从数据库查询
+	// 使用统一的窗口开始时间计算逻辑（考虑窗口过期情况）
+	startTime := account.GetCurrentWindowStartTime()
+	stats, err := usageLogRepo.GetAccountWindowStats(ctx, account.ID, startTime)
+	if err != nil {
+		return 0, false
+	}
+
+	// 使用标准费用（不含账号倍率）
+	currentCost := stats.StandardCost
+
+	// 设置缓存（忽略错误）
+	if cache != nil {
+		_ = cache.SetWindowCost(ctx, account.ID, currentCost)
+	}
+	return currentCost, true
+}
+
 // checkAndRegisterSession 检查并注册会话，用于会话数量限制
 // 仅适用于 Anthropic OAuth/SetupToken 账号
 // sessionID: 会话标识符（使用粘性会话的 hash）
@@ -1378,6 +1445,9 @@ func (s *GatewayService) checkAndRegisterSession(ctx context.Context, account *A
 }
 
 func (s *GatewayService) getSchedulableAccount(ctx context.Context, accountID int64) (*Account, error) {
+	if !s.circuitBreaker.Allow(ctx, accountID) {
+		return nil, ErrAccountCircuitOpen
+	}
 	if s.schedulerSnapshot != nil {
 		return s.schedulerSnapshot.GetAccount(ctx, accountID)
 	}
@@ -1469,8 +1539,8 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 	// so switching model can switch upstream account within the same sticky session.
 	if len(routingAccountIDs) > 0 {
 		if s.debugModelRoutingEnabled() {
-			log.Printf("[ModelRoutingDebug] legacy routed begin: group_id=%v model=%s platform=%s session=%s routed_ids=%v",
-				derefGroupID(groupID), requestedModel, platform, shortSessionHash(sessionHash), routingAccountIDs)
+			slog.InfoContext(ctx, "model_routing_legacy_begin",
+				"group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "platform", platform, "routed_ids", routingAccountIDs)
 		}
 		// 1) Sticky session only applies if the bound account is within the routing set.
 		if sessionHash != "" && s.cache != nil {
@@ -1486,10 +1556,10 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 						}
 						if !clearSticky && s.isAccountInGroup(account, groupID) && account.Platform == platform && account.IsSchedulableForModel(requestedModel) && (requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel)) {
 							if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL); err != nil {
-								log.Printf("refresh session ttl failed: session=%s err=%v", sessionHash, err)
+								slog.WarnContext(ctx, "refresh_session_ttl_failed", "session", shortSessionHash(sessionHash), "error", err)
 							}
 							if s.debugModelRoutingEnabled() {
-								log.Printf("[ModelRoutingDebug] legacy routed sticky hit: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), accountID)
+								slog.InfoContext(ctx, "model_routing_legacy_sticky_hit", "group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "account_id", accountID)
 							}
 							return account, nil
 						}
@@ -1564,15 +1634,15 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 		if selected != nil {
 			if sessionHash != "" && s.cache != nil {
 				if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, stickySessionTTL); err != nil {
-					log.Printf("set session account failed: session=%s account_id=%d err=%v", sessionHash, selected.ID, err)
+					slog.WarnContext(ctx, "set_session_account_failed", "session", shortSessionHash(sessionHash), "account_id", selected.ID, "error", err)
 				}
 			}
 			if s.debugModelRoutingEnabled() {
-				log.Printf("[ModelRoutingDebug] legacy routed select: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), selected.ID)
+				slog.InfoContext(ctx, "model_routing_legacy_select", "group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "account_id", selected.ID)
 			}
 			return selected, nil
 		}
-		log.Printf("[ModelRouting] No routed accounts available for model=%s, falling back to normal selection", requestedModel)
+		slog.InfoContext(ctx, "model_routing_fallback", "model", requestedModel, "reason", "no routed accounts available")
 	}
 
 	// 1. 查询粘性会话
@@ -1589,7 +1659,7 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 					}
 					if !clearSticky && s.isAccountInGroup(account, groupID) && account.Platform == platform && account.IsSchedulableForModel(requestedModel) && (requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel)) {
 						if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL); err != nil {
-							log.Printf("refresh session ttl failed: session=%s err=%v", sessionHash, err)
+							slog.WarnContext(ctx, "refresh_session_ttl_failed", "session", shortSessionHash(sessionHash), "error", err)
 						}
 						return account, nil
 					}
@@ -1663,7 +1733,7 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 	// 4. 建立粘性绑定
 	if sessionHash != "" && s.cache != nil {
 		if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, stickySessionTTL); err != nil {
-			log.Printf("set session account failed: session=%s account_id=%d err=%v", sessionHash, selected.ID, err)
+			slog.WarnContext(ctx, "set_session_account_failed", "session", shortSessionHash(sessionHash), "account_id", selected.ID, "error", err)
 		}
 	}
 
@@ -1682,8 +1752,8 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 	// ============ Model Routing (legacy path): apply before sticky session ============
 	if len(routingAccountIDs) > 0 {
 		if s.debugModelRoutingEnabled() {
-			log.Printf("[ModelRoutingDebug] legacy mixed routed begin: group_id=%v model=%s platform=%s session=%s routed_ids=%v",
-				derefGroupID(groupID), requestedModel, nativePlatform, shortSessionHash(sessionHash), routingAccountIDs)
+			slog.InfoContext(ctx, "model_routing_legacy_mixed_begin",
+				"group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "platform", nativePlatform, "routed_ids", routingAccountIDs)
 		}
 		// 1) Sticky session only applies if the bound account is within the routing set.
 		if sessionHash != "" && s.cache != nil {
@@ -1700,10 +1770,10 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 						if !clearSticky && s.isAccountInGroup(account, groupID) && account.IsSchedulableForModel(requestedModel) && (requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel)) {
 							if account.Platform == nativePlatform || (account.Platform == PlatformAntigravity && account.IsMixedSchedulingEnabled()) {
 								if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL); err != nil {
-									log.Printf("refresh session ttl failed: session=%s err=%v", sessionHash, err)
+									slog.WarnContext(ctx, "refresh_session_ttl_failed", "session", shortSessionHash(sessionHash), "error", err)
 								}
 								if s.debugModelRoutingEnabled() {
-									log.Printf("[ModelRoutingDebug] legacy mixed routed sticky hit: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), accountID)
+									slog.InfoContext(ctx, "model_routing_legacy_mixed_sticky_hit", "group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "account_id", accountID)
 								}
 								return account, nil
 							}
@@ -1779,15 +1849,15 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 		if selected != nil {
 			if sessionHash != "" && s.cache != nil {
 				if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, stickySessionTTL); err != nil {
-					log.Printf("set session account failed: session=%s account_id=%d err=%v", sessionHash, selected.ID, err)
+					slog.WarnContext(ctx, "set_session_account_failed", "session", shortSessionHash(sessionHash), "account_id", selected.ID, "error", err)
 				}
 			}
 			if s.debugModelRoutingEnabled() {
-				log.Printf("[ModelRoutingDebug] legacy mixed routed select: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), selected.ID)
+				slog.InfoContext(ctx, "model_routing_legacy_mixed_select", "group_id", derefGroupID(groupID), "model", requestedModel, "session", shortSessionHash(sessionHash), "account_id", selected.ID)
 			}
 			return selected, nil
 		}
-		log.Printf("[ModelRouting] No routed accounts available for model=%s, falling back to normal selection", requestedModel)
+		slog.InfoContext(ctx, "model_routing_fallback", "model", requestedModel, "reason", "no routed accounts available")
 	}
 
 	// 1. 查询粘性会话
@@ -1805,7 +1875,7 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 					if !clearSticky && s.isAccountInGroup(account, groupID) && account.IsSchedulableForModel(requestedModel) && (requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel)) {
 						if account.Platform == nativePlatform || (account.Platform == PlatformAntigravity && account.IsMixedSchedulingEnabled()) {
 							if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL); err != nil {
-								log.Printf("refresh session ttl failed: session=%s err=%v", sessionHash, err)
+								slog.WarnContext(ctx, "refresh_session_ttl_failed", "session", shortSessionHash(sessionHash), "error", err)
 							}
 							return account, nil
 						}
@@ -1880,7 +1950,7 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 	// 4. 建立粘性绑定
 	if sessionHash != "" && s.cache != nil {
 		if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, stickySessionTTL); err != nil {
-			log.Printf("set session account failed: session=%s account_id=%d err=%v", sessionHash, selected.ID, err)
+			slog.WarnContext(ctx, "set_session_account_failed", "session", shortSessionHash(sessionHash), "account_id", selected.ID, "error", err)
 		}
 	}
 
@@ -2082,7 +2152,7 @@ func injectClaudeCodePrompt(body []byte, system any) []byte {
 
 	result, err := sjson.SetBytes(body, "system", newSystem)
 	if err != nil {
-		log.Printf("Warning: failed to inject Claude Code prompt: %v", err)
+		slog.Warn("inject_claude_code_prompt_failed", "error", err)
 		return body
 	}
 	return result
@@ -2238,7 +2308,7 @@ func removeCacheControlFromThinkingBlocks(data map[string]any) {
 				if blockType, _ := m["type"].(string); blockType == "thinking" {
 					if _, has := m["cache_control"]; has {
 						delete(m, "cache_control")
-						log.Printf("[Warning] Removed illegal cache_control from thinking block in system")
+						slog.Warn("removed_thinking_cache_control", "location", "system")
 					}
 				}
 			}
@@ -2255,7 +2325,7 @@ func removeCacheControlFromThinkingBlocks(data map[string]any) {
 							if blockType, _ := m["type"].(string); blockType == "thinking" {
 								if _, has := m["cache_control"]; has {
 									delete(m, "cache_control")
-									log.Printf("[Warning] Removed illegal cache_control from thinking block in messages[%d].content[%d]", msgIdx, contentIdx)
+									slog.Warn("removed_thinking_cache_control", "location", "messages", "message_index", msgIdx, "content_index", contentIdx)
 								}
 							}
 						}
@@ -2297,7 +2367,7 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 			// 替换请求体中的模型名
 			body = s.replaceModelInBody(body, mappedModel)
 			reqModel = mappedModel
-			log.Printf("Model mapping applied: %s -> %s (account: %s)", originalModel, mappedModel, account.Name)
+			slog.DebugContext(ctx, "model_mapping_applied", "original_model", originalModel, "mapped_model", mappedModel, "account_id", account.ID)
 		}
 	}
 
@@ -2308,14 +2378,12 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 	}
 
 	// 获取代理URL
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.UpstreamProxyURL()
 
 	// 调试日志：记录即将转发的账号信息
-	log.Printf("[Forward] Using account: ID=%d Name=%s Platform=%s Type=%s TLSFingerprint=%v Proxy=%s",
-		account.ID, account.Name, account.Platform, account.Type, account.IsTLSFingerprintEnabled(), proxyURL)
+	slog.DebugContext(ctx, "forward_using_account",
+		"account_id", account.ID, "account_name", account.Name, "account_type", account.Type,
+		"tls_fingerprint", account.IsTLSFingerprintEnabled(), "proxy", proxyURL != "")
 
 	// 重试循环
 	var resp *http.Response
@@ -2336,6 +2404,7 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 			if resp != nil && resp.Body != nil {
 				_ = resp.Body.Close()
 			}
+			s.rateLimitService.RecordUpstreamRequestError(ctx, account.ID)
 			// Ensure the client receives an error response (handlers assume Forward writes on non-failover errors).
 			safeErr := sanitizeUpstreamErrorMessage(err.Error())
 			setOpsUpstreamError(c, 0, safeErr, "")
@@ -2395,7 +2464,7 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 						resp.Body = io.NopCloser(bytes.NewReader(respBody))
 						break
 					}
-					log.Printf("Account %d: detected thinking block signature error, retrying with filtered thinking blocks", account.ID)
+					slog.WarnContext(ctx, "signature_error_retry", "account_id", account.ID, "mode", "filter_thinking")
 
 					// Conservative two-stage fallback:
 					// 1) Disable thinking + thinking->text (preserve content)
@@ -2408,7 +2477,7 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 						retryResp, retryErr := s.httpUpstream.DoWithTLS(retryReq, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
 						if retryErr == nil {
 							if retryResp.StatusCode < 400 {
-								log.Printf("Account %d: signature error retry succeeded (thinking downgraded)", account.ID)
+								slog.InfoContext(ctx, "signature_error_retry_succeeded", "account_id", account.ID, "mode", "filter_thinking")
 								resp = retryResp
 								break
 							}
@@ -2433,7 +2502,7 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 								})
 								msg2 := extractUpstreamErrorMessage(retryRespBody)
 								if looksLikeToolSignatureError(msg2) && time.Since(retryStart) < maxRetryElapsed {
-									log.Printf("Account %d: signature retry still failing and looks tool-related, retrying with tool blocks downgraded", account.ID)
+									slog.WarnContext(ctx, "signature_error_retry", "account_id", account.ID, "mode", "downgrade_tools")
 									filteredBody2 := FilterSignatureSensitiveBlocksForRetry(body)
 									retryReq2, buildErr2 := s.buildUpstreamRequest(ctx, c, account, filteredBody2, token, tokenType, reqModel)
 									if buildErr2 == nil {
@@ -2453,9 +2522,9 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 											Kind:               "signature_retry_tools_request_error",
 											Message:            sanitizeUpstreamErrorMessage(retryErr2.Error()),
 										})
-										log.Printf("Account %d: tool-downgrade signature retry failed: %v", account.ID, retryErr2)
+										slog.WarnContext(ctx, "signature_error_retry_failed", "account_id", account.ID, "mode", "downgrade_tools", "error", retryErr2)
 									} else {
-										log.Printf("Account %d: tool-downgrade signature retry build failed: %v", account.ID, buildErr2)
+										slog.WarnContext(ctx, "signature_error_retry_build_failed", "account_id", account.ID, "mode", "downgrade_tools", "error", buildErr2)
 									}
 								}
 							}
@@ -2471,9 +2540,9 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 						if retryResp != nil && retryResp.Body != nil {
 							_ = retryResp.Body.Close()
 						}
-						log.Printf("Account %d: signature error retry failed: %v", account.ID, retryErr)
+						slog.WarnContext(ctx, "signature_error_retry_failed", "account_id", account.ID, "mode", "filter_thinking", "error", retryErr)
 					} else {
-						log.Printf("Account %d: signature error retry build request failed: %v", account.ID, buildErr)
+						slog.WarnContext(ctx, "signature_error_retry_build_failed", "account_id", account.ID, "mode", "filter_thinking", "error", buildErr)
 					}
 
 					// Retry failed: restore original response body and continue handling.
@@ -2519,8 +2588,9 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 						return ""
 					}(),
 				})
-				log.Printf("Account %d: upstream error %d, retry %d/%d after %v (elapsed=%v/%v)",
-					account.ID, resp.StatusCode, attempt, maxRetryAttempts, delay, elapsed, maxRetryElapsed)
+				slog.WarnContext(ctx, "upstream_error_retry",
+					"account_id", account.ID, "status", resp.StatusCode, "attempt", attempt, "max_attempts", maxRetryAttempts,
+					"delay", delay, "elapsed", elapsed, "max_elapsed", maxRetryElapsed)
 				if err := sleepWithContext(ctx, delay); err != nil {
 					return nil, err
 				}
@@ -2533,10 +2603,7 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 		// 不需要重试（成功或不可重试的错误），跳出循环
 		// DEBUG: 输出响应 headers（用于检测 rate limit 信息）
 		if account.Platform == PlatformGemini && resp.StatusCode < 400 {
-			log.Printf("[DEBUG] Gemini API Response Headers for account %d:", account.ID)
-			for k, v := range resp.Header {
-				log.Printf("[DEBUG]   %s: %v", k, v)
-			}
+			slog.DebugContext(ctx, "gemini_response_headers", "account_id", account.ID, "headers", resp.Header)
 		}
 		break
 	}
@@ -2553,8 +2620,9 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 			resp.Body = io.NopCloser(bytes.NewReader(respBody))
 
 			// 调试日志：打印重试耗尽后的错误响应
-			log.Printf("[Forward] Upstream error (retry exhausted, failover): Account=%d(%s) Status=%d RequestID=%s Body=%s",
-				account.ID, account.Name, resp.StatusCode, resp.Header.Get("x-request-id"), truncateString(string(respBody), 1000))
+			slog.WarnContext(ctx, "upstream_error_retry_exhausted_failover",
+				"account_id", account.ID, "status", resp.StatusCode, "upstream_request_id", resp.Header.Get("x-request-id"),
+				"body", logredact.Excerpt(respBody, 1000))
 
 			s.handleRetryExhaustedSideEffects(ctx, resp, account)
 			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
@@ -2584,8 +2652,9 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 		resp.Body = io.NopCloser(bytes.NewReader(respBody))
 
 		// 调试日志：打印上游错误响应
-		log.Printf("[Forward] Upstream error (failover): Account=%d(%s) Status=%d RequestID=%s Body=%s",
-			account.ID, account.Name, resp.StatusCode, resp.Header.Get("x-request-id"), truncateString(string(respBody), 1000))
+		slog.WarnContext(ctx, "upstream_error_failover",
+			"account_id", account.ID, "status", resp.StatusCode, "upstream_request_id", resp.Header.Get("x-request-id"),
+			"body", logredact.Excerpt(respBody, 1000))
 
 		s.handleFailoverSideEffects(ctx, resp, account)
 		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
@@ -2640,13 +2709,10 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 				})
 
 				if s.cfg.Gateway.LogUpstreamErrorBody {
-					log.Printf(
-						"Account %d: 400 error, attempting failover: %s",
-						account.ID,
-						truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
-					)
+					slog.WarnContext(ctx, "upstream_400_failover",
+						"account_id", account.ID, "body", truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 				} else {
-					log.Printf("Account %d: 400 error, attempting failover", account.ID)
+					slog.WarnContext(ctx, "upstream_400_failover", "account_id", account.ID)
 				}
 				s.handleFailoverSideEffects(ctx, resp, account)
 				return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode}
@@ -2679,6 +2745,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 		}
 	}
 
+	s.markPromptCacheWarm(ctx, account.ID, *usage)
+
 	return &ForwardResult{
 		RequestID:        resp.Header.Get("x-request-id"),
 		Usage:            *usage,
@@ -2710,7 +2778,7 @@ func (s *GatewayService) buildUpstreamRequest(ctx context.Context, c *gin.Contex
 		// 1. 获取或创建指纹（包含随机生成的ClientID）
 		fp, err := s.identityService.GetOrCreateFingerprint(ctx, account.ID, c.Request.Header)
 		if err != nil {
-			log.Printf("Warning: failed to get fingerprint for account %d: %v", account.ID, err)
+			slog.WarnContext(ctx, "get_fingerprint_failed", "account_id", account.ID, "error", err)
 			// 失败时降级为透传原始headers
 		} else {
 			fingerprint = fp
@@ -2846,11 +2914,8 @@ func truncateForLog(b []byte, maxBytes int) string {
 	if maxBytes <= 0 {
 		maxBytes = 2048
 	}
-	if len(b) > maxBytes {
-		b = b[:maxBytes]
-	}
-	s := string(b)
-	// 保持一行，避免污染日志格式
+	// 先对完整请求体脱敏再截断（预先截断会破坏 JSON 导致字段脱敏失效），保持一行，避免污染日志格式
+	s := logredact.Excerpt(b, maxBytes)
 	s = strings.ReplaceAll(s, "\n", "\\n")
 	s = strings.ReplaceAll(s, "\r", "\\r")
 	return s
@@ -2865,26 +2930,26 @@ func (s *GatewayService) isThinkingBlockSignatureError(respBody []byte) bool {
 	}
 
 	// Log for debugging
-	log.Printf("[SignatureCheck] Checking error message: %s", msg)
+	slog.Debug("signature_check", "message", msg)
 
 	// 检测signature相关的错误（更宽松的匹配）
 	// 例如: "Invalid `signature` in `thinking` block", "***.signature" 等
 	if strings.Contains(msg, "signature") {
-		log.Printf("[SignatureCheck] Detected signature error")
+		slog.Debug("signature_check_detected", "kind", "signature")
 		return true
 	}
 
 	// 检测 thinking block 顺序/类型错误
 	// 例如: "Expected `thinking` or `redacted_thinking`, but found `text`"
 	if strings.Contains(msg, "expected") && (strings.Contains(msg, "thinking") || strings.Contains(msg, "redacted_thinking")) {
-		log.Printf("[SignatureCheck] Detected thinking block type error")
+		slog.Debug("signature_check_detected", "kind", "thinking_block_type")
 		return true
 	}
 
 	// 检测空消息内容错误（可能是过滤 thinking blocks 后导致的）
 	// 例如: "all messages must have non-empty content"
 	if strings.Contains(msg, "non-empty content") || strings.Contains(msg, "empty content") {
-		log.Printf("[SignatureCheck] Detected empty content error")
+		slog.Debug("signature_check_detected", "kind", "empty_content")
 		return true
 	}
 
@@ -2939,8 +3004,9 @@ func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Res
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
 
 	// 调试日志：打印上游错误响应
-	log.Printf("[Forward] Upstream error (non-retryable): Account=%d(%s) Status=%d RequestID=%s Body=%s",
-		account.ID, account.Name, resp.StatusCode, resp.Header.Get("x-request-id"), truncateString(string(body), 1000))
+	slog.WarnContext(ctx, "upstream_error",
+		"account_id", account.ID, "status", resp.StatusCode, "upstream_request_id", resp.Header.Get("x-request-id"),
+		"body", logredact.Excerpt(body, 1000))
 
 	upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(body))
 	upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
@@ -2976,14 +3042,9 @@ func (s *GatewayService) handleErrorResponse(ctx context.Context, resp *http.Res
 
 	// 记录上游错误响应体摘要便于排障（可选：由配置控制；不回显到客户端）
 	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		log.Printf(
-			"Upstream error %d (account=%d platform=%s type=%s): %s",
-			resp.StatusCode,
-			account.ID,
-			account.Platform,
-			account.Type,
-			truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
-		)
+		slog.WarnContext(ctx, "upstream_error_body",
+			"status", resp.StatusCode, "account_id", account.ID, "account_type", account.Type,
+			"body", truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 	}
 
 	// 根据状态码返回适当的自定义错误响应（不透传上游详细信息）
@@ -3049,10 +3110,10 @@ func (s *GatewayService) handleRetryExhaustedSideEffects(ctx context.Context, re
 	// OAuth/Setup Token 账号的 403：标记账号异常
 	if account.IsOAuth() && statusCode == 403 {
 		s.rateLimitService.HandleUpstreamError(ctx, account, statusCode, resp.Header, body)
-		log.Printf("Account %d: marked as error after %d retries for status %d", account.ID, maxRetryAttempts, statusCode)
+		slog.WarnContext(ctx, "account_marked_error_after_retries", "account_id", account.ID, "retries", maxRetryAttempts, "status", statusCode)
 	} else {
 		// API Key 未配置错误码：不标记账号状态
-		log.Printf("Account %d: upstream error %d after %d retries (not marking account)", account.ID, statusCode, maxRetryAttempts)
+		slog.WarnContext(ctx, "upstream_error_after_retries", "account_id", account.ID, "status", statusCode, "retries", maxRetryAttempts)
 	}
 }
 
@@ -3094,14 +3155,9 @@ func (s *GatewayService) handleRetryExhaustedError(ctx context.Context, resp *ht
 	})
 
 	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		log.Printf(
-			"Upstream error %d retries_exhausted (account=%d platform=%s type=%s): %s",
-			resp.StatusCode,
-			account.ID,
-			account.Platform,
-			account.Type,
-			truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
-		)
+		slog.WarnContext(ctx, "upstream_error_retries_exhausted",
+			"status", resp.StatusCode, "account_id", account.ID, "account_type", account.Type,
+			"body", truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 	}
 
 	// 返回统一的重试耗尽错误响应
@@ -3127,6 +3183,9 @@ type streamingResult struct {
 }
 
 func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, startTime time.Time, originalModel, mappedModel string) (*streamingResult, error) {
+	span := startStreamRelaySpan(ctx, account, originalModel)
+	defer span.End()
+
 	// 更新5h窗口状态
 	s.rateLimitService.UpdateSessionWindow(ctx, account, resp.Header)
 
@@ -3231,17 +3290,17 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 			if ev.err != nil {
 				// 检测 context 取消（客户端断开会导致 context 取消，进而影响上游读取）
 				if errors.Is(ev.err, context.Canceled) || errors.Is(ev.err, context.DeadlineExceeded) {
-					log.Printf("Context canceled during streaming, returning collected usage")
+					slog.InfoContext(ctx, "stream_context_canceled", "account_id", account.ID, "detail", "returning collected usage")
 					return &streamingResult{usage: usage, firstTokenMs: firstTokenMs, clientDisconnect: true}, nil
 				}
 				// 客户端已通过写入失败检测到断开，上游也出错了，返回已收集的 usage
 				if clientDisconnected {
-					log.Printf("Upstream read error after client disconnect: %v, returning collected usage", ev.err)
+					slog.InfoContext(ctx, "stream_upstream_read_error_after_disconnect", "account_id", account.ID, "error", ev.err)
 					return &streamingResult{usage: usage, firstTokenMs: firstTokenMs, clientDisconnect: true}, nil
 				}
 				// 客户端未断开，正常的错误处理
 				if errors.Is(ev.err, bufio.ErrTooLong) {
-					log.Printf("SSE line too long: account=%d max_size=%d error=%v", account.ID, maxLineSize, ev.err)
+					slog.WarnContext(ctx, "sse_line_too_long", "account_id", account.ID, "max_size", maxLineSize, "error", ev.err)
 					sendErrorEvent("response_too_large")
 					return &streamingResult{usage: usage, firstTokenMs: firstTokenMs}, ev.err
 				}
@@ -3271,7 +3330,7 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 			if !clientDisconnected {
 				if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
 					clientDisconnected = true
-					log.Printf("Client disconnected during streaming, continuing to drain upstream for billing")
+					slog.InfoContext(ctx, "stream_client_disconnected", "account_id", account.ID, "detail", "draining upstream for billing")
 				} else {
 					flusher.Flush()
 				}
@@ -3282,6 +3341,7 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 				if firstTokenMs == nil && data != "[DONE]" {
 					ms := int(time.Since(startTime).Milliseconds())
 					firstTokenMs = &ms
+					markStreamFirstToken(span, ms)
 				}
 				s.parseSSEUsage(data, usage)
 			}
@@ -3293,10 +3353,10 @@ func (s *GatewayService) handleStreamingResponse(ctx context.Context, resp *http
 			}
 			if clientDisconnected {
 				// 客户端已断开，上游也超时了，返回已收集的 usage
-				log.Printf("Upstream timeout after client disconnect, returning collected usage")
+				slog.InfoContext(ctx, "stream_upstream_timeout_after_disconnect", "account_id", account.ID)
 				return &streamingResult{usage: usage, firstTokenMs: firstTokenMs, clientDisconnect: true}, nil
 			}
-			log.Printf("Stream data interval timeout: account=%d model=%s interval=%s", account.ID, originalModel, streamInterval)
+			slog.WarnContext(ctx, "stream_data_interval_timeout", "account_id", account.ID, "model", originalModel, "interval", streamInterval)
 			// 处理流超时，可能标记账户为临时不可调度或错误状态
 			if s.rateLimitService != nil {
 				s.rateLimitService.HandleStreamTimeout(ctx, account, originalModel)
@@ -3457,15 +3517,25 @@ type RecordUsageInput struct {
 	Subscription *UserSubscription // 可选：订阅信息
 	UserAgent    string            // 请求的 User-Agent
 	IPAddress    string            // 请求的客户端 IP 地址
+	BillingHold  *BillingHold      // 可选：转发前的预授权冻结，按实际费用结算
+	Batch        bool              // Message Batches 结果：按批量折扣计费，不计入账号延迟统计
 }
 
 // RecordUsage 记录使用量并扣费（或更新订阅用量）
 func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInput) error {
+	return traceRecordUsage(ctx, input.Account, func(ctx context.Context) error {
+		return s.recordUsage(ctx, input)
+	})
+}
+
+func (s *GatewayService) recordUsage(ctx context.Context, input *RecordUsageInput) error {
 	result := input.Result
 	apiKey := input.APIKey
 	user := input.User
 	account := input.Account
 	subscription := input.Subscription
+	// 未结算的冻结（简易模式、未计费等）在返回时释放
+	defer input.BillingHold.Release()
 
 	// 获取费率倍数
 	multiplier := s.cfg.Default.RateMultiplier
@@ -3487,6 +3557,14 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 			}
 		}
 		cost = s.billingService.CalculateImageCost(result.Model, result.ImageSize, result.ImageCount, groupConfig, multiplier)
+	} else if result.Embedding {
+		// 向量嵌入计费（仅输入 token）
+		var err error
+		cost, err = s.billingService.CalculateEmbeddingCost(result.Model, result.Usage.InputTokens, multiplier)
+		if err != nil {
+			slog.ErrorContext(ctx, "calculate_embedding_cost_failed", "error", err)
+			cost = &CostBreakdown{ActualCost: 0}
+		}
 	} else {
 		// Token 计费
 		tokens := UsageTokens{
@@ -3498,10 +3576,13 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		var err error
 		cost, err = s.billingService.CalculateCost(result.Model, tokens, multiplier)
 		if err != nil {
-			log.Printf("Calculate cost failed: %v", err)
+			slog.ErrorContext(ctx, "calculate_cost_failed", "error", err)
 			cost = &CostBreakdown{ActualCost: 0}
 		}
 	}
+	if input.Batch {
+		cost = s.billingService.ApplyBatchDiscount(cost)
+	}
 
 	// 判断计费方式：订阅模式 vs 余额模式
 	isSubscriptionBilling := subscription != nil && apiKey.Group != nil && apiKey.Group.IsSubscriptionType()
@@ -3553,6 +3634,7 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	if input.IPAddress != "" {
 		usageLog.IPAddress = &input.IPAddress
 	}
+	usageLog.TraceID = traceIDPtr(ctx)
 
 	// 添加分组和订阅关联
 	if apiKey.GroupID != nil {
@@ -3563,12 +3645,15 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	}
 
 	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
+	if !input.Batch {
+		s.rateLimitService.RecordUpstreamSuccess(ctx, account.ID, forwardLatency(result.Stream, result.Duration, result.FirstTokenMs))
+	}
 	if err != nil {
-		log.Printf("Create usage log failed: %v", err)
+		slog.ErrorContext(ctx, "create_usage_log_failed", "error", err)
 	}
 
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
-		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
+		slog.InfoContext(ctx, "usage_recorded_simple_mode", "user_id", usageLog.UserID, "tokens", usageLog.TotalTokens())
 		s.deferredService.ScheduleLastUsedUpdate(account.ID)
 		return nil
 	}
@@ -3580,19 +3665,32 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		// 订阅模式：更新订阅用量（使用 TotalCost 原始费用，不考虑倍率）
 		if shouldBill && cost.TotalCost > 0 {
 			if err := s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost); err != nil {
-				log.Printf("Increment subscription usage failed: %v", err)
+				slog.ErrorContext(ctx, "increment_subscription_usage_failed", "error", err)
 			}
-			// 异步更新订阅缓存
-			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
+			// 结算预授权冻结，无冻结时异步更新订阅缓存
+			if !s.billingCacheService.SettleBillingHold(ctx, input.BillingHold, cost.TotalCost) {
+				s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
+			}
+		}
+		// token/请求数限额计数（与费用无关，零成本请求同样计入）
+		if shouldBill {
+			s.billingCacheService.RecordSubscriptionUsageCounters(ctx, subscription, apiKey.Group, result.Model, UsageTokens{
+				InputTokens:         result.Usage.InputTokens,
+				OutputTokens:        result.Usage.OutputTokens,
+				CacheCreationTokens: result.Usage.CacheCreationInputTokens,
+				CacheReadTokens:     result.Usage.CacheReadInputTokens,
+			})
 		}
 	} else {
 		// 余额模式：扣除用户余额（使用 ActualCost 考虑倍率后的费用）
 		if shouldBill && cost.ActualCost > 0 {
 			if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
-				log.Printf("Deduct balance failed: %v", err)
+				slog.ErrorContext(ctx, "deduct_balance_failed", "error", err)
+			}
+			// 结算预授权冻结，无冻结时异步更新余额缓存
+			if !s.billingCacheService.SettleBillingHold(ctx, input.BillingHold, cost.ActualCost) {
+				s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
 			}
-			// 异步更新余额缓存
-			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
 		}
 	}
 
@@ -3626,7 +3724,7 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 			if mappedModel != reqModel {
 				body = s.replaceModelInBody(body, mappedModel)
 				reqModel = mappedModel
-				log.Printf("CountTokens model mapping applied: %s -> %s (account: %s)", parsed.Model, mappedModel, account.Name)
+				slog.DebugContext(ctx, "count_tokens_model_mapping_applied", "original_model", parsed.Model, "mapped_model", mappedModel, "account_id", account.ID)
 			}
 		}
 	}
@@ -3646,10 +3744,7 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 	}
 
 	// 获取代理URL
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.UpstreamProxyURL()
 
 	// 发送请求
 	resp, err := s.httpUpstream.DoWithTLS(upstreamReq, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
@@ -3669,7 +3764,7 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 
 	// 检测 thinking block 签名错误（400）并重试一次（过滤 thinking blocks）
 	if resp.StatusCode == 400 && s.isThinkingBlockSignatureError(respBody) {
-		log.Printf("Account %d: detected thinking block signature error on count_tokens, retrying with filtered thinking blocks", account.ID)
+		slog.WarnContext(ctx, "signature_error_retry", "account_id", account.ID, "mode", "filter_thinking", "endpoint", "count_tokens")
 
 		filteredBody := FilterThinkingBlocksForRetry(body)
 		retryReq, buildErr := s.buildCountTokensRequest(ctx, c, account, filteredBody, token, tokenType, reqModel)
@@ -3706,14 +3801,9 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 
 		// 记录上游错误摘要便于排障（不回显请求内容）
 		if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-			log.Printf(
-				"count_tokens upstream error %d (account=%d platform=%s type=%s): %s",
-				resp.StatusCode,
-				account.ID,
-				account.Platform,
-				account.Type,
-				truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
-			)
+			slog.WarnContext(ctx, "count_tokens_upstream_error",
+				"account_id", account.ID, "platform", account.Platform, "account_type", account.Type, "status", resp.StatusCode,
+				"body", truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 		}
 
 		// 返回简化的错误响应
@@ -3830,6 +3920,9 @@ func (s *GatewayService) countTokensError(c *gin.Context, status int, errType, m
 }
 
 func (s *GatewayService) validateUpstreamBaseURL(raw string) (string, error) {
+	if isMockUpstreamBaseURL(s.cfg, raw) {
+		return strings.TrimRight(strings.TrimSpace(raw), "/"), nil
+	}
 	if s.cfg != nil && !s.cfg.Security.URLAllowlist.Enabled {
 		normalized, err := urlvalidator.ValidateURLFormat(raw, s.cfg.Security.URLAllowlist.AllowInsecureHTTP)
 		if err != nil {
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 网关请求生命周期各阶段的 span 名称（账号选择、获取 token、流式转发、记录使用量）
+const (
+	spanAccountSelect  = "gateway.select_account"
+	spanGetAccessToken = "token.get_access_token"
+	spanStreamRelay    = "stream.relay"
+	spanRecordUsage    = "usage.record"
+)
+
+func accountSpanAttrs(account *Account) []attribute.KeyValue {
+	if account == nil {
+		return nil
+	}
+	return []attribute.KeyValue{
+		attribute.Int64("sub2api.account_id", account.ID),
+		attribute.String("sub2api.platform", account.Platform),
+		attribute.String("sub2api.account_type", account.Type),
+	}
+}
+
+// traceAccountSelection 为账号选择包一层 span，记录选中的账号与是否直接获取到槽位
+func traceAccountSelection(ctx context.Context, groupID *int64, requestedModel string, excludedIDs map[int64]struct{}, selectFn func(context.Context) (*AccountSelectionResult, error)) (*AccountSelectionResult, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("sub2api.requested_model", requestedModel),
+		attribute.Int("sub2api.excluded_accounts", len(excludedIDs)),
+	}
+	if groupID != nil {
+		attrs = append(attrs, attribute.Int64("sub2api.group_id", *groupID))
+	}
+	ctx, span := tracing.Start(ctx, spanAccountSelect, attrs...)
+	result, err := selectFn(ctx)
+	if result != nil && result.Account != nil {
+		span.SetAttributes(accountSpanAttrs(result.Account)...)
+		span.SetAttributes(attribute.Bool("sub2api.slot_acquired", result.Acquired))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+// traceGetAccessToken 为各平台 TokenProvider 的获取 token 包一层 span（可能触发刷新，耗时差异较大）
+func traceGetAccessToken(ctx context.Context, account *Account, fetch func(context.Context) (string, error)) (string, error) {
+	ctx, span := tracing.Start(ctx, spanGetAccessToken, accountSpanAttrs(account)...)
+	token, err := fetch(ctx)
+	tracing.End(span, err)
+	return token, err
+}
+
+// startStreamRelaySpan 开始流式转发 span，首 token 时调用 markStreamFirstToken 记录事件
+func startStreamRelaySpan(ctx context.Context, account *Account, model string) trace.Span {
+	attrs := append(accountSpanAttrs(account), attribute.String("sub2api.model", model))
+	_, span := tracing.Start(ctx, spanStreamRelay, attrs...)
+	return span
+}
+
+func markStreamFirstToken(span trace.Span, ms int) {
+	span.AddEvent("first_token", trace.WithAttributes(attribute.Int("sub2api.first_token_ms", ms)))
+}
+
+// traceRecordUsage 为使用量记录包一层 span
+func traceRecordUsage(ctx context.Context, account *Account, record func(context.Context) error) error {
+	ctx, span := tracing.Start(ctx, spanRecordUsage, accountSpanAttrs(account)...)
+	err := record(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// traceIDPtr 返回 ctx 中的 trace id，用于写入使用记录；没有 trace 时返回 nil
+func traceIDPtr(ctx context.Context) *string {
+	if id := tracing.TraceID(ctx); id != "" {
+		return &id
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/googleapi"
+	"github.com/gin-gonic/gin"
+)
+
+// Gemini 原生接口（/v1beta/models/*:generateContent）兜底到 Anthropic 账号
+//
+// Gemini 分组中绑定的 Anthropic 平台账号不参与常规调度，仅当 Gemini/Antigravity 账号
+// 全部不可用（或 failover 次数耗尽）时，由 Handler 通过 SelectGeminiClaudeFallbackAccount
+// 选出，并经 ForwardGeminiAsClaude 完成 Gemini → Claude 请求翻译与 Claude → Gemini 响应回译。
+
+const defaultGeminiClaudeFallbackModel = "claude-sonnet-4-5"
+
+// SelectGeminiClaudeFallbackAccount 在分组内选择用于 Gemini 兜底的 Anthropic 账号
+func (s *GatewayService) SelectGeminiClaudeFallbackAccount(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+	ctx = context.WithValue(ctx, ctxkey.ForcePlatform, PlatformAnthropic)
+	return s.SelectAccountWithLoadAwareness(ctx, groupID, sessionHash, requestedModel, excludedIDs, "")
+}
+
+// ForwardGeminiAsClaude 将 Gemini generateContent/streamGenerateContent 请求翻译为 Claude Messages 请求，
+// 经 Forward 发往 Anthropic 账号，并把响应（含流式 SSE 与错误）回译为 Gemini 格式写给客户端。
+func (s *GatewayService) ForwardGeminiAsClaude(ctx context.Context, c *gin.Context, account *Account, geminiModel string, stream bool, body []byte) (*ForwardResult, error) {
+	claudeModel := s.resolveGeminiClaudeModel(account, geminiModel)
+	claudeBody, err := antigravity.TransformGeminiRequestToClaude(body, claudeModel, stream)
+	if err != nil {
+		writeGeminiClaudeError(c, http.StatusBadRequest, err.Error())
+		return nil, fmt.Errorf("transform gemini request: %w", err)
+	}
+	parsed, err := ParseGatewayRequest(claudeBody)
+	if err != nil {
+		writeGeminiClaudeError(c, http.StatusBadRequest, "Failed to build Claude request")
+		return nil, fmt.Errorf("parse translated request: %w", err)
+	}
+
+	writer := newGeminiClaudeResponseWriter(c.Writer, stream)
+	c.Writer = writer
+	result, err := s.Forward(ctx, c, account, parsed)
+	c.Writer = writer.ResponseWriter
+	writer.finish()
+	return result, err
+}
+
+// resolveGeminiClaudeModel 优先使用账号 model_mapping 将 Gemini 模型映射到 claude-*，否则使用配置的默认模型
+func (s *GatewayService) resolveGeminiClaudeModel(account *Account, geminiModel string) string {
+	if mapped := account.GetMappedModel(geminiModel); strings.HasPrefix(mapped, "claude-") {
+		return mapped
+	}
+	if s.cfg != nil && strings.TrimSpace(s.cfg.Gateway.GeminiClaudeFallbackModel) != "" {
+		return strings.TrimSpace(s.cfg.Gateway.GeminiClaudeFallbackModel)
+	}
+	return defaultGeminiClaudeFallbackModel
+}
+
+func writeGeminiClaudeError(c *gin.Context, status int, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"code":    status,
+			"message": message,
+			"status":  googleapi.HTTPStatusToGoogleStatus(status),
+		},
+	})
+}
+
+// geminiClaudeResponseWriter 拦截 Forward 写出的 Claude 响应并回译为 Gemini 格式
+//   - 流式成功响应：按行解析 Claude SSE，实时输出 Gemini SSE chunk
+//   - 非流式响应与错误响应（status >= 400）：缓冲完整响应体，在 finish 时一次性转换写出
+type geminiClaudeResponseWriter struct {
+	gin.ResponseWriter
+	stream    bool
+	processor *antigravity.ClaudeToGeminiStreamProcessor
+	streaming bool
+	pending   []byte
+	buffered  bytes.Buffer
+}
+
+func newGeminiClaudeResponseWriter(w gin.ResponseWriter, stream bool) *geminiClaudeResponseWriter {
+	return &geminiClaudeResponseWriter{
+		ResponseWriter: w,
+		stream:         stream,
+		processor:      antigravity.NewClaudeToGeminiStreamProcessor(),
+	}
+}
+
+func (w *geminiClaudeResponseWriter) Write(b []byte) (int, error) {
+	if !w.stream || w.Status() >= http.StatusBadRequest {
+		return w.buffered.Write(b)
+	}
+	w.streaming = true
+	w.pending = append(w.pending, b...)
+	for {
+		idx := bytes.IndexByte(w.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(w.pending[:idx])
+		w.pending = w.pending[idx+1:]
+		if out := w.processor.ProcessLine(line); len(out) > 0 {
+			if _, err := w.ResponseWriter.Write(out); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(b), nil
+}
+
+func (w *geminiClaudeResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// finish 输出缓冲内容或流的收尾 chunk；Forward 未写任何内容（例如触发 failover）时不输出
+func (w *geminiClaudeResponseWriter) finish() {
+	if w.streaming {
+		if len(w.pending) > 0 {
+			if out := w.processor.ProcessLine(string(w.pending)); len(out) > 0 {
+				_, _ = w.ResponseWriter.Write(out)
+			}
+			w.pending = nil
+		}
+		if out := w.processor.Finish(); len(out) > 0 {
+			_, _ = w.ResponseWriter.Write(out)
+		}
+		w.ResponseWriter.Flush()
+		return
+	}
+	if w.buffered.Len() == 0 {
+		return
+	}
+
+	status := w.Status()
+	var out []byte
+	if status >= http.StatusBadRequest {
+		out = antigravity.TransformClaudeErrorToGemini(status, w.buffered.Bytes())
+	} else {
+		converted, err := antigravity.TransformClaudeResponseToGemini(w.buffered.Bytes())
+		if err != nil {
+			status = http.StatusBadGateway
+			w.ResponseWriter.WriteHeader(status)
+			converted = antigravity.TransformClaudeErrorToGemini(status, []byte("Failed to convert upstream response"))
+		}
+		out = converted
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.ResponseWriter.Write(out)
+}
@@ -10,7 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math"
 	mathrand "math/rand"
 	"net/http"
@@ -26,6 +26,8 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 const geminiStickySessionTTL = time.Hour
@@ -46,6 +48,7 @@ type GeminiMessagesCompatService struct {
 	httpUpstream              HTTPUpstream
 	antigravityGatewayService *AntigravityGatewayService
 	cfg                       *config.Config
+	circuitBreaker            *CircuitBreakerService
 }
 
 func NewGeminiMessagesCompatService(
@@ -58,6 +61,7 @@ func NewGeminiMessagesCompatService(
 	httpUpstream HTTPUpstream,
 	antigravityGatewayService *AntigravityGatewayService,
 	cfg *config.Config,
+	circuitBreaker *CircuitBreakerService,
 ) *GeminiMessagesCompatService {
 	return &GeminiMessagesCompatService{
 		accountRepo:               accountRepo,
@@ -69,6 +73,7 @@ func NewGeminiMessagesCompatService(
 		httpUpstream:              httpUpstream,
 		antigravityGatewayService: antigravityGatewayService,
 		cfg:                       cfg,
+		circuitBreaker:            circuitBreaker,
 	}
 }
 
@@ -111,6 +116,10 @@ func (s *GeminiMessagesCompatService) SelectAccountForModelWithExclusions(ctx co
 		}
 	}
 
+	// 跳过熔断中的账号
+	// Skip accounts whose circuit breaker is open
+	accounts = s.circuitBreaker.FilterAccounts(ctx, accounts)
+
 	// 4. 按优先级 + LRU 选择最佳账号
 	// Select best account by priority + LRU
 	selected := s.selectBestGeminiAccount(ctx, accounts, requestedModel, excludedIDs, platform, useMixedScheduling)
@@ -276,7 +285,7 @@ func (s *GeminiMessagesCompatService) passesRateLimitPreCheck(ctx context.Contex
 	}
 	ok, err := s.rateLimitService.PreCheckUsage(ctx, account, requestedModel)
 	if err != nil {
-		log.Printf("[Gemini PreCheck] Account %d precheck error: %v", account.ID, err)
+		slog.WarnContext(ctx, "gemini_precheck_failed", "account_id", account.ID, "error", err)
 	}
 	return ok
 }
@@ -368,6 +377,9 @@ func (s *GeminiMessagesCompatService) GetAntigravityGatewayService() *Antigravit
 }
 
 func (s *GeminiMessagesCompatService) getSchedulableAccount(ctx context.Context, accountID int64) (*Account, error) {
+	if !s.circuitBreaker.Allow(ctx, accountID) {
+		return nil, ErrAccountCircuitOpen
+	}
 	if s.schedulerSnapshot != nil {
 		return s.schedulerSnapshot.GetAccount(ctx, accountID)
 	}
@@ -393,6 +405,9 @@ func (s *GeminiMessagesCompatService) listSchedulableAccountsOnce(ctx context.Co
 }
 
 func (s *GeminiMessagesCompatService) validateUpstreamBaseURL(raw string) (string, error) {
+	if isMockUpstreamBaseURL(s.cfg, raw) {
+		return strings.TrimRight(strings.TrimSpace(raw), "/"), nil
+	}
 	if s.cfg != nil && !s.cfg.Security.URLAllowlist.Enabled {
 		normalized, err := urlvalidator.ValidateURLFormat(raw, s.cfg.Security.URLAllowlist.AllowInsecureHTTP)
 		if err != nil {
@@ -530,10 +545,7 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 	}
 	originalClaudeBody := body
 
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.UpstreamProxyURL()
 
 	var requestIDHeader string
 	var buildReq func(ctx context.Context) (*http.Request, string, error)
@@ -684,6 +696,7 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 
 		resp, err = s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
 		if err != nil {
+			s.rateLimitService.RecordUpstreamRequestError(ctx, account.ID)
 			safeErr := sanitizeUpstreamErrorMessage(err.Error())
 			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
 				Platform:           account.Platform,
@@ -694,7 +707,7 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 				Message:            safeErr,
 			})
 			if attempt < geminiMaxRetries {
-				log.Printf("Gemini account %d: upstream request failed, retry %d/%d: %v", account.ID, attempt, geminiMaxRetries, err)
+				slog.WarnContext(ctx, "gemini_request_failed", "account_id", account.ID, "attempt", attempt, "max_attempts", geminiMaxRetries, "error", err)
 				sleepGeminiBackoff(attempt)
 				continue
 			}
@@ -750,7 +763,7 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 				}
 				retryGeminiReq, txErr := convertClaudeMessagesToGeminiGenerateContent(strippedClaudeBody)
 				if txErr == nil {
-					log.Printf("Gemini account %d: detected signature-related 400, retrying with downgraded Claude blocks (%s)", account.ID, stageName)
+					slog.WarnContext(ctx, "gemini_signature_retry", "account_id", account.ID, "stage", stageName)
 					geminiReq = retryGeminiReq
 					// Consume one retry budget attempt and continue with the updated request payload.
 					sleepGeminiBackoff(1)
@@ -809,7 +822,7 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 					Detail:             upstreamDetail,
 				})
 
-				log.Printf("Gemini account %d: upstream status %d, retry %d/%d", account.ID, resp.StatusCode, attempt, geminiMaxRetries)
+				slog.WarnContext(ctx, "gemini_upstream_error_retry", "account_id", account.ID, "status", resp.StatusCode, "attempt", attempt, "max_attempts", geminiMaxRetries)
 				sleepGeminiBackoff(attempt)
 				continue
 			}
@@ -972,22 +985,23 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 	}
 
 	switch action {
-	case "generateContent", "streamGenerateContent", "countTokens":
+	case "generateContent", "streamGenerateContent", "countTokens", "embedContent", "batchEmbedContents":
 		// ok
 	default:
 		return nil, s.writeGoogleError(c, http.StatusNotFound, "Unsupported action: "+action)
 	}
+	embedding := IsGeminiEmbeddingAction(action)
 
 	mappedModel := originalModel
 	if account.Type == AccountTypeAPIKey {
 		mappedModel = account.GetMappedModel(originalModel)
 	}
-
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
+	if embedding && mappedModel != originalModel {
+		body = rewriteGeminiBatchEmbedModels(body, mappedModel)
 	}
 
+	proxyURL := account.UpstreamProxyURL()
+
 	useUpstreamStream := stream
 	upstreamAction := action
 	if account.Type == AccountTypeOAuth && !stream && action == "generateContent" && strings.TrimSpace(account.GetCredential("project_id")) != "" {
@@ -995,7 +1009,8 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 		useUpstreamStream = true
 		upstreamAction = "streamGenerateContent"
 	}
-	forceAIStudio := action == "countTokens"
+	// countTokens / 嵌入接口 Code Assist 不支持，统一走 AI Studio
+	forceAIStudio := action == "countTokens" || embedding
 
 	var requestIDHeader string
 	var buildReq func(ctx context.Context) (*http.Request, string, error)
@@ -1131,6 +1146,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 
 		resp, err = s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
 		if err != nil {
+			s.rateLimitService.RecordUpstreamRequestError(ctx, account.ID)
 			safeErr := sanitizeUpstreamErrorMessage(err.Error())
 			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
 				Platform:           account.Platform,
@@ -1141,7 +1157,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 				Message:            safeErr,
 			})
 			if attempt < geminiMaxRetries {
-				log.Printf("Gemini account %d: upstream request failed, retry %d/%d: %v", account.ID, attempt, geminiMaxRetries, err)
+				slog.WarnContext(ctx, "gemini_request_failed", "account_id", account.ID, "attempt", attempt, "max_attempts", geminiMaxRetries, "error", err)
 				sleepGeminiBackoff(attempt)
 				continue
 			}
@@ -1202,7 +1218,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 					Detail:             upstreamDetail,
 				})
 
-				log.Printf("Gemini account %d: upstream status %d, retry %d/%d", account.ID, resp.StatusCode, attempt, geminiMaxRetries)
+				slog.WarnContext(ctx, "gemini_upstream_error_retry", "account_id", account.ID, "status", resp.StatusCode, "attempt", attempt, "max_attempts", geminiMaxRetries)
 				sleepGeminiBackoff(attempt)
 				continue
 			}
@@ -1323,7 +1339,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 				maxBytes = 2048
 			}
 			upstreamDetail = truncateString(string(respBody), maxBytes)
-			log.Printf("[Gemini] native upstream error %d: %s", resp.StatusCode, truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
+			slog.WarnContext(ctx, "gemini_native_upstream_error", "account_id", account.ID, "status", resp.StatusCode, "body", truncateForLog(respBody, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 		}
 		setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
 		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
@@ -1380,6 +1396,20 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 		usage = &ClaudeUsage{}
 	}
 
+	if embedding {
+		// 嵌入响应不返回用量，按请求文本估算输入 token
+		if usage.InputTokens == 0 {
+			usage.InputTokens = estimateGeminiEmbeddingTokens(body)
+		}
+		return &ForwardResult{
+			RequestID: requestID,
+			Usage:     ClaudeUsage{InputTokens: usage.InputTokens},
+			Model:     originalModel,
+			Duration:  time.Since(startTime),
+			Embedding: true,
+		}, nil
+	}
+
 	// 图片生成计费
 	imageCount := 0
 	imageSize := s.extractImageSize(body)
@@ -1399,6 +1429,47 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 	}, nil
 }
 
+// IsGeminiEmbeddingAction 是否为 Gemini 嵌入接口（embedContent / batchEmbedContents）
+func IsGeminiEmbeddingAction(action string) bool {
+	return action == "embedContent" || action == "batchEmbedContents"
+}
+
+// rewriteGeminiBatchEmbedModels 对 batchEmbedContents 的 requests[].model 应用模型映射（与 URL 中的模型保持一致）
+func rewriteGeminiBatchEmbedModels(body []byte, mappedModel string) []byte {
+	requests := gjson.GetBytes(body, "requests")
+	if !requests.IsArray() {
+		return body
+	}
+	for i, item := range requests.Array() {
+		if !item.Get("model").Exists() {
+			continue
+		}
+		if updated, err := sjson.SetBytes(body, fmt.Sprintf("requests.%d.model", i), "models/"+mappedModel); err == nil {
+			body = updated
+		}
+	}
+	return body
+}
+
+// estimateGeminiEmbeddingTokens 估算嵌入请求的输入 token（content.parts[].text 与 requests[].content.parts[].text）
+func estimateGeminiEmbeddingTokens(body []byte) int {
+	total := 0
+	addContent := func(content gjson.Result) {
+		content.Get("parts").ForEach(func(_, part gjson.Result) bool {
+			total += estimateTokensForText(part.Get("text").String())
+			return true
+		})
+	}
+	if content := gjson.GetBytes(body, "content"); content.Exists() {
+		addContent(content)
+	}
+	gjson.GetBytes(body, "requests").ForEach(func(_, req gjson.Result) bool {
+		addContent(req.Get("content"))
+		return true
+	})
+	return total
+}
+
 func (s *GeminiMessagesCompatService) shouldRetryGeminiUpstreamError(account *Account, statusCode int) bool {
 	switch statusCode {
 	case 429, 500, 502, 503, 504, 529:
@@ -1480,7 +1551,7 @@ func (s *GeminiMessagesCompatService) writeGeminiMappedError(c *gin.Context, acc
 	})
 
 	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		log.Printf("[Gemini] upstream error %d: %s", upstreamStatus, truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
+		slog.WarnContext(c.Request.Context(), "gemini_upstream_error", "account_id", account.ID, "status", upstreamStatus, "body", truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 	}
 
 	var statusCode int
@@ -1690,6 +1761,9 @@ func (s *GeminiMessagesCompatService) handleNonStreamingResponse(c *gin.Context,
 }
 
 func (s *GeminiMessagesCompatService) handleStreamingResponse(c *gin.Context, resp *http.Response, startTime time.Time, originalModel string) (*geminiStreamResult, error) {
+	span := startStreamRelaySpan(c.Request.Context(), nil, originalModel)
+	defer span.End()
+
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
@@ -1797,6 +1871,7 @@ func (s *GeminiMessagesCompatService) handleStreamingResponse(c *gin.Context, re
 				if firstTokenMs == nil {
 					ms := int(time.Since(startTime).Milliseconds())
 					firstTokenMs = &ms
+					markStreamFirstToken(span, ms)
 				}
 				writeSSE(c.Writer, "content_block_delta", map[string]any{
 					"type":  "content_block_delta",
@@ -2228,15 +2303,20 @@ type UpstreamHTTPResult struct {
 	Body       []byte
 }
 
-func (s *GeminiMessagesCompatService) handleNativeNonStreamingResponse(c *gin.Context, resp *http.Response, isOAuth bool) (*ClaudeUsage, error) {
-	// Log response headers for debugging
-	log.Printf("[GeminiAPI] ========== Response Headers ==========")
-	for key, values := range resp.Header {
+// geminiRateLimitHeaders 提取上游响应中的 x-ratelimit-* 头，用于调试日志
+func geminiRateLimitHeaders(h http.Header) http.Header {
+	out := make(http.Header)
+	for key, values := range h {
 		if strings.HasPrefix(strings.ToLower(key), "x-ratelimit") {
-			log.Printf("[GeminiAPI] %s: %v", key, values)
+			out[key] = values
 		}
 	}
-	log.Printf("[GeminiAPI] ========================================")
+	return out
+}
+
+func (s *GeminiMessagesCompatService) handleNativeNonStreamingResponse(c *gin.Context, resp *http.Response, isOAuth bool) (*ClaudeUsage, error) {
+	// Log rate limit headers for debugging
+	slog.DebugContext(c.Request.Context(), "gemini_rate_limit_headers", "mode", "non_stream", "headers", geminiRateLimitHeaders(resp.Header))
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -2270,14 +2350,8 @@ func (s *GeminiMessagesCompatService) handleNativeNonStreamingResponse(c *gin.Co
 }
 
 func (s *GeminiMessagesCompatService) handleNativeStreamingResponse(c *gin.Context, resp *http.Response, startTime time.Time, isOAuth bool) (*geminiNativeStreamResult, error) {
-	// Log response headers for debugging
-	log.Printf("[GeminiAPI] ========== Streaming Response Headers ==========")
-	for key, values := range resp.Header {
-		if strings.HasPrefix(strings.ToLower(key), "x-ratelimit") {
-			log.Printf("[GeminiAPI] %s: %v", key, values)
-		}
-	}
-	log.Printf("[GeminiAPI] ====================================================")
+	// Log rate limit headers for debugging
+	slog.DebugContext(c.Request.Context(), "gemini_rate_limit_headers", "mode", "stream", "headers", geminiRateLimitHeaders(resp.Header))
 
 	if s.cfg != nil {
 		responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
@@ -2390,10 +2464,7 @@ func (s *GeminiMessagesCompatService) ForwardAIStudioGET(ctx context.Context, ac
 	}
 	fullURL := strings.TrimRight(normalizedBaseURL, "/") + path
 
-	var proxyURL string
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.UpstreamProxyURL()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
 	if err != nil {
@@ -2572,16 +2643,16 @@ func (s *GeminiMessagesCompatService) handleGeminiUpstreamError(ctx context.Cont
 				cooldown = s.rateLimitService.GeminiCooldown(ctx, account)
 			}
 			ra = time.Now().Add(cooldown)
-			log.Printf("[Gemini 429] Account %d (Code Assist, tier=%s, project=%s) rate limited, cooldown=%v", account.ID, tierID, projectID, time.Until(ra).Truncate(time.Second))
+			slog.WarnContext(ctx, "gemini_rate_limited", "account_id", account.ID, "kind", "code_assist", "tier", tierID, "project", projectID, "cooldown", time.Until(ra).Truncate(time.Second))
 		} else {
 			// API Key / AI Studio OAuth: PST 午夜
 			if ts := nextGeminiDailyResetUnix(); ts != nil {
 				ra = time.Unix(*ts, 0)
-				log.Printf("[Gemini 429] Account %d (API Key/AI Studio, type=%s) rate limited, reset at PST midnight (%v)", account.ID, account.Type, ra)
+				slog.WarnContext(ctx, "gemini_rate_limited", "account_id", account.ID, "kind", "ai_studio", "account_type", account.Type, "reset_at", ra)
 			} else {
 				// 兜底：5 分钟
 				ra = time.Now().Add(5 * time.Minute)
-				log.Printf("[Gemini 429] Account %d rate limited, fallback to 5min", account.ID)
+				slog.WarnContext(ctx, "gemini_rate_limited", "account_id", account.ID, "reset_in", 5*time.Minute, "fallback", true)
 			}
 		}
 		_ = s.accountRepo.SetRateLimited(ctx, account.ID, ra)
@@ -2591,8 +2662,7 @@ func (s *GeminiMessagesCompatService) handleGeminiUpstreamError(ctx context.Cont
 	// 使用解析到的重置时间
 	resetTime := time.Unix(*resetAt, 0)
 	_ = s.accountRepo.SetRateLimited(ctx, account.ID, resetTime)
-	log.Printf("[Gemini 429] Account %d rate limited until %v (oauth_type=%s, tier=%s)",
-		account.ID, resetTime, oauthType, tierID)
+	slog.WarnContext(ctx, "gemini_rate_limited", "account_id", account.ID, "reset_at", resetTime, "oauth_type", oauthType, "tier", tierID)
 }
 
 // ParseGeminiRateLimitResetTime 解析 Gemini 格式的 429 响应，返回重置时间的 Unix 时间戳
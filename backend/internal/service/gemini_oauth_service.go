@@ -52,6 +52,7 @@ const (
 type GeminiOAuthService struct {
 	sessionStore *geminicli.SessionStore
 	proxyRepo    ProxyRepository
+	proxyPools   *ProxyPoolService
 	oauthClient  GeminiOAuthClient
 	codeAssist   GeminiCliCodeAssistClient
 	cfg          *config.Config
@@ -64,6 +65,7 @@ type GeminiOAuthCapabilities struct {
 
 func NewGeminiOAuthService(
 	proxyRepo ProxyRepository,
+	proxyPools *ProxyPoolService,
 	oauthClient GeminiOAuthClient,
 	codeAssist GeminiCliCodeAssistClient,
 	cfg *config.Config,
@@ -71,6 +73,7 @@ func NewGeminiOAuthService(
 	return &GeminiOAuthService{
 		sessionStore: geminicli.NewSessionStore(),
 		proxyRepo:    proxyRepo,
+		proxyPools:   proxyPools,
 		oauthClient:  oauthClient,
 		codeAssist:   codeAssist,
 		cfg:          cfg,
@@ -410,10 +413,7 @@ func (s *GeminiOAuthService) RefreshAccountGoogleOneTier(
 	}
 
 	// 获取 proxy URL
-	var proxyURL string
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := resolveAccountProxyURL(ctx, nil, s.proxyPools, account)
 
 	// 调用 Drive API
 	tierID, storageInfo, err := s.FetchGoogleOneTier(ctx, accessToken, proxyURL)
@@ -747,13 +747,7 @@ func (s *GeminiOAuthService) RefreshAccountToken(ctx context.Context, account *A
 		oauthType = "code_assist"
 	}
 
-	var proxyURL string
-	if account.ProxyID != nil {
-		proxy, err := s.proxyRepo.GetByID(ctx, *account.ProxyID)
-		if err == nil && proxy != nil {
-			proxyURL = proxy.URL()
-		}
-	}
+	proxyURL := resolveAccountProxyURL(ctx, s.proxyRepo, s.proxyPools, account)
 
 	tokenInfo, err := s.RefreshToken(ctx, oauthType, refreshToken, proxyURL)
 	// Backward compatibility:
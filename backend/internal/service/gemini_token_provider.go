@@ -34,6 +34,15 @@ func NewGeminiTokenProvider(
 }
 
 func (p *GeminiTokenProvider) GetAccessToken(ctx context.Context, account *Account) (string, error) {
+	if token, ok := dryRunAccessToken(ctx, account); ok {
+		return token, nil
+	}
+	return traceGetAccessToken(ctx, account, func(ctx context.Context) (string, error) {
+		return p.getAccessToken(ctx, account)
+	})
+}
+
+func (p *GeminiTokenProvider) getAccessToken(ctx context.Context, account *Account) (string, error) {
 	if account == nil {
 		return "", errors.New("account is nil")
 	}
@@ -106,12 +115,7 @@ func (p *GeminiTokenProvider) GetAccessToken(ctx context.Context, account *Accou
 			return accessToken, nil // Fallback to AI Studio API mode
 		}
 
-		var proxyURL string
-		if account.ProxyID != nil && p.geminiOAuthService.proxyRepo != nil {
-			if proxy, err := p.geminiOAuthService.proxyRepo.GetByID(ctx, *account.ProxyID); err == nil && proxy != nil {
-				proxyURL = proxy.URL()
-			}
-		}
+		proxyURL := resolveAccountProxyURL(ctx, p.geminiOAuthService.proxyRepo, p.geminiOAuthService.proxyPools, account)
 
 		detected, tierID, err := p.geminiOAuthService.fetchProjectID(ctx, accessToken, proxyURL)
 		if err != nil {
@@ -20,6 +20,8 @@ type Group struct {
 	WeeklyLimitUSD      *float64
 	MonthlyLimitUSD     *float64
 	DefaultValidityDays int
+	// 订阅续期价格（每个 DefaultValidityDays 周期；nil 表示不支持自助续期/变更）
+	SubscriptionPrice *float64
 
 	// 图片生成计费配置（antigravity 和 gemini 平台使用）
 	ImagePrice1K *float64
@@ -36,6 +38,26 @@ type Group struct {
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool
 
+	// 账号选择策略（空表示默认：优先级 + 最后使用时间/随机）
+	AccountSelectionStrategy string
+
+	// 图片生成模型白名单（支持末尾 * 通配符，为空表示不限制）
+	ImageModels []string
+
+	// 内容策略命中动作（空表示不检查）
+	ContentPolicyAction string
+
+	// 请求改写规则（按顺序应用，为空表示不改写）
+	RequestRewriteRules []RequestRewriteRule
+
+	// 模型别名（别名 -> 实际模型）与访问策略（支持末尾 * 通配符，黑名单优先）
+	ModelAliases  map[string]string
+	AllowedModels []string
+	DeniedModels  []string
+
+	// 订阅 token/请求数限额（与 USD 限额同时生效，为空表示不限制）
+	UsageLimits []SubscriptionUsageLimit
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -83,6 +105,43 @@ func (g *Group) GetImagePrice(imageSize string) *float64 {
 	}
 }
 
+// IsImageModelAllowed 检查图片生成模型是否在分组白名单内（白名单为空表示不限制）
+func (g *Group) IsImageModelAllowed(model string) bool {
+	if g == nil || len(g.ImageModels) == 0 {
+		return true
+	}
+	for _, pattern := range g.ImageModels {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeModelPatterns 去除模型模式列表中的空白与重复项，保持原有顺序
+func normalizeModelPatterns(models []string) []string {
+	if len(models) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(models))
+	out := make([]string, 0, len(models))
+	for _, m := range models {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+		if _, ok := seen[m]; ok {
+			continue
+		}
+		seen[m] = struct{}{}
+		out = append(out, m)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
 // IsGroupContextValid reports whether a group from context has the fields required for routing decisions.
 func IsGroupContextValid(group *Group) bool {
 	if group == nil {
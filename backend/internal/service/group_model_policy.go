@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// 模型列表响应格式
+const (
+	ModelListFormatData   = "data"   // {"data":[{"id":...}]}（Claude / OpenAI / Antigravity）
+	ModelListFormatGemini = "gemini" // {"models":[{"name":"models/..."}]}（Gemini v1beta）
+)
+
+const geminiModelNamePrefix = "models/"
+
+var (
+	ErrModelNotAllowed     = infraerrors.Forbidden("MODEL_NOT_ALLOWED", "model is not allowed for this group")
+	ErrModelAliasInvalid   = infraerrors.BadRequest("MODEL_ALIAS_INVALID", "invalid model alias")
+	ErrModelPolicyConflict = infraerrors.BadRequest("MODEL_POLICY_CONFLICT", "model alias target is not allowed by the group model policy")
+)
+
+// HasModelPolicy 分组是否配置了模型别名或访问限制
+func (g *Group) HasModelPolicy() bool {
+	return g != nil && (len(g.ModelAliases) > 0 || len(g.AllowedModels) > 0 || len(g.DeniedModels) > 0)
+}
+
+// ResolveModelAlias 将分组别名解析为实际模型（非别名原样返回）
+func (g *Group) ResolveModelAlias(model string) string {
+	if g == nil || len(g.ModelAliases) == 0 {
+		return model
+	}
+	if target, ok := g.ModelAliases[model]; ok && target != "" {
+		return target
+	}
+	return model
+}
+
+// IsModelAllowed 检查模型是否符合分组访问策略：命中黑名单拒绝；白名单非空时必须命中白名单
+func (g *Group) IsModelAllowed(model string) bool {
+	if g == nil {
+		return true
+	}
+	model = strings.TrimPrefix(model, geminiModelNamePrefix)
+	for _, pattern := range g.DeniedModels {
+		if matchModelPattern(pattern, model) {
+			return false
+		}
+	}
+	if len(g.AllowedModels) == 0 {
+		return true
+	}
+	for _, pattern := range g.AllowedModels {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveRequestModel 解析别名并校验访问策略，返回实际使用的模型
+func (g *Group) ResolveRequestModel(model string) (string, error) {
+	if !g.HasModelPolicy() {
+		return model, nil
+	}
+	prefixed := strings.HasPrefix(model, geminiModelNamePrefix)
+	resolved := g.ResolveModelAlias(strings.TrimPrefix(model, geminiModelNamePrefix))
+	if !g.IsModelAllowed(resolved) {
+		return "", infraerrors.Forbidden(ErrModelNotAllowed.Reason, fmt.Sprintf("Model %s is not allowed for this group", model))
+	}
+	if prefixed {
+		resolved = geminiModelNamePrefix + resolved
+	}
+	return resolved, nil
+}
+
+// ApplyModelPolicyToList 按分组策略过滤模型列表响应，并为目标模型可用的别名追加条目。
+// 无策略或响应无法解析时原样返回。
+func (g *Group) ApplyModelPolicyToList(body []byte, format string) []byte {
+	if !g.HasModelPolicy() {
+		return body
+	}
+	arrayPath, idKey, prefix := "data", "id", ""
+	if format == ModelListFormatGemini {
+		arrayPath, idKey, prefix = "models", "name", geminiModelNamePrefix
+	}
+	list := gjson.GetBytes(body, arrayPath)
+	if !list.IsArray() {
+		return body
+	}
+
+	kept := make([]string, 0, len(list.Array()))
+	byID := make(map[string]gjson.Result)
+	for _, item := range list.Array() {
+		id := strings.TrimPrefix(item.Get(idKey).String(), prefix)
+		if !g.IsModelAllowed(id) {
+			continue
+		}
+		// 别名本身即模型名时以别名为准，避免同名条目重复
+		if _, aliased := g.ModelAliases[id]; aliased {
+			continue
+		}
+		byID[id] = item
+		kept = append(kept, item.Raw)
+	}
+
+	aliases := make([]string, 0, len(g.ModelAliases))
+	for alias := range g.ModelAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		target := g.ModelAliases[alias]
+		if !g.IsModelAllowed(target) {
+			continue
+		}
+		entry := modelAliasListEntry(format, prefix, alias)
+		if item, ok := byID[target]; ok {
+			// 复用目标模型的元数据（能力、上下文长度等），仅替换标识与显示名
+			raw, err := sjson.Set(item.Raw, idKey, prefix+alias)
+			if err == nil {
+				displayKey := "display_name"
+				if format == ModelListFormatGemini {
+					displayKey = "displayName"
+				}
+				if item.Get(displayKey).Exists() {
+					raw, _ = sjson.Set(raw, displayKey, alias)
+				}
+				entry = raw
+			}
+		}
+		kept = append(kept, entry)
+	}
+
+	out, err := sjson.SetRawBytes(body, arrayPath, []byte("["+strings.Join(kept, ",")+"]"))
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func modelAliasListEntry(format, prefix, alias string) string {
+	if format == ModelListFormatGemini {
+		return fmt.Sprintf(`{"name":%s,"displayName":%s,"supportedGenerationMethods":["generateContent","streamGenerateContent"]}`,
+			jsonString(prefix+alias), jsonString(alias))
+	}
+	return fmt.Sprintf(`{"id":%s,"type":"model","display_name":%s,"created_at":"2024-01-01T00:00:00Z"}`,
+		jsonString(alias), jsonString(alias))
+}
+
+// normalizeModelAliases 去除空白项，返回 nil 表示无别名
+func normalizeModelAliases(aliases map[string]string) map[string]string {
+	if len(aliases) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(aliases))
+	for alias, target := range aliases {
+		alias, target = strings.TrimSpace(alias), strings.TrimSpace(target)
+		if alias == "" && target == "" {
+			continue
+		}
+		out[alias] = target
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// ValidateGroupModelPolicy 校验别名（不允许空值、自引用与链式别名）以及别名目标与访问策略的一致性
+func ValidateGroupModelPolicy(aliases map[string]string, allowed, denied []string) error {
+	probe := &Group{AllowedModels: allowed, DeniedModels: denied}
+	for alias, target := range aliases {
+		switch {
+		case alias == "" || target == "":
+			return infraerrors.BadRequest(ErrModelAliasInvalid.Reason, "model alias and target must not be empty")
+		case strings.Contains(alias, "*"):
+			return infraerrors.BadRequest(ErrModelAliasInvalid.Reason, fmt.Sprintf("model alias %q must not contain wildcards", alias))
+		case alias == target:
+			return infraerrors.BadRequest(ErrModelAliasInvalid.Reason, fmt.Sprintf("model alias %q points to itself", alias))
+		}
+		if _, chained := aliases[target]; chained {
+			return infraerrors.BadRequest(ErrModelAliasInvalid.Reason, fmt.Sprintf("model alias %q points to another alias %q", alias, target))
+		}
+		if !probe.IsModelAllowed(target) {
+			return infraerrors.BadRequest(ErrModelPolicyConflict.Reason, fmt.Sprintf("model alias %q targets %q which is not allowed by this group", alias, target))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestGroupResolveRequestModel(t *testing.T) {
+	g := &Group{
+		ModelAliases:  map[string]string{"fast": "claude-haiku-4-5", "smart": "claude-opus-4-1"},
+		AllowedModels: []string{"claude-haiku-*", "claude-sonnet-*", "gemini-*"},
+		DeniedModels:  []string{"claude-sonnet-3*"},
+	}
+
+	model, err := g.ResolveRequestModel("fast")
+	require.NoError(t, err)
+	require.Equal(t, "claude-haiku-4-5", model)
+
+	// 别名目标不在白名单内
+	_, err = g.ResolveRequestModel("smart")
+	require.ErrorIs(t, err, ErrModelNotAllowed)
+
+	// 黑名单优先于白名单
+	_, err = g.ResolveRequestModel("claude-sonnet-3-7")
+	require.ErrorIs(t, err, ErrModelNotAllowed)
+
+	model, err = g.ResolveRequestModel("claude-sonnet-4-5")
+	require.NoError(t, err)
+	require.Equal(t, "claude-sonnet-4-5", model)
+
+	// Gemini 的 models/ 前缀保留
+	model, err = g.ResolveRequestModel("models/gemini-2.5-pro")
+	require.NoError(t, err)
+	require.Equal(t, "models/gemini-2.5-pro", model)
+
+	// 无策略时原样放行
+	var empty *Group
+	model, err = empty.ResolveRequestModel("anything")
+	require.NoError(t, err)
+	require.Equal(t, "anything", model)
+	require.True(t, (&Group{}).IsModelAllowed("anything"))
+}
+
+func TestGroupApplyModelPolicyToList_Data(t *testing.T) {
+	g := &Group{
+		ModelAliases: map[string]string{"fast": "claude-haiku-4-5"},
+		DeniedModels: []string{"claude-opus-*"},
+	}
+	body := []byte(`{"object":"list","data":[{"id":"claude-opus-4-1","type":"model"},{"id":"claude-haiku-4-5","type":"model","display_name":"Claude Haiku 4.5","created_at":"2025-10-01T00:00:00Z"}]}`)
+
+	out := g.ApplyModelPolicyToList(body, ModelListFormatData)
+	data := gjson.GetBytes(out, "data").Array()
+	require.Len(t, data, 2)
+	require.Equal(t, "claude-haiku-4-5", data[0].Get("id").String())
+	// 别名条目复用目标模型元数据
+	require.Equal(t, "fast", data[1].Get("id").String())
+	require.Equal(t, "fast", data[1].Get("display_name").String())
+	require.Equal(t, "2025-10-01T00:00:00Z", data[1].Get("created_at").String())
+	require.Equal(t, "list", gjson.GetBytes(out, "object").String())
+
+	// 无策略时原样返回
+	require.Equal(t, string(body), string((&Group{}).ApplyModelPolicyToList(body, ModelListFormatData)))
+}
+
+func TestGroupApplyModelPolicyToList_Gemini(t *testing.T) {
+	g := &Group{
+		ModelAliases:  map[string]string{"pro": "gemini-2.5-pro", "flash": "gemini-9-flash"},
+		AllowedModels: []string{"gemini-2.5-*"},
+	}
+	body := []byte(`{"models":[{"name":"models/gemini-2.5-pro","displayName":"Gemini 2.5 Pro"},{"name":"models/gemini-2.0-flash"}]}`)
+
+	out := g.ApplyModelPolicyToList(body, ModelListFormatGemini)
+	models := gjson.GetBytes(out, "models").Array()
+	require.Len(t, models, 2)
+	require.Equal(t, "models/gemini-2.5-pro", models[0].Get("name").String())
+	require.Equal(t, "models/pro", models[1].Get("name").String())
+	require.Equal(t, "pro", models[1].Get("displayName").String())
+}
+
+func TestValidateGroupModelPolicy(t *testing.T) {
+	require.NoError(t, ValidateGroupModelPolicy(map[string]string{"fast": "claude-haiku-4-5"}, []string{"claude-*"}, nil))
+	require.ErrorIs(t, ValidateGroupModelPolicy(map[string]string{"fast": ""}, nil, nil), ErrModelAliasInvalid)
+	require.ErrorIs(t, ValidateGroupModelPolicy(map[string]string{"f*": "claude"}, nil, nil), ErrModelAliasInvalid)
+	require.ErrorIs(t, ValidateGroupModelPolicy(map[string]string{"a": "a"}, nil, nil), ErrModelAliasInvalid)
+	require.ErrorIs(t, ValidateGroupModelPolicy(map[string]string{"a": "b", "b": "c"}, nil, nil), ErrModelAliasInvalid)
+	require.ErrorIs(t, ValidateGroupModelPolicy(map[string]string{"a": "gpt-5"}, []string{"claude-*"}, nil), ErrModelPolicyConflict)
+	require.ErrorIs(t, ValidateGroupModelPolicy(map[string]string{"a": "claude-opus-4-1"}, nil, []string{"claude-opus-*"}), ErrModelPolicyConflict)
+}
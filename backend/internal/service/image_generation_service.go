@@ -0,0 +1,456 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// imageGenerationMaxN 单次请求允许生成的最大图片数量（与 OpenAI 接口上限一致）
+	imageGenerationMaxN = 10
+	// geminiImageMaxN Gemini 每次调用只生成一张图片，翻译路径下按 n 逐张调用，限制更严格
+	geminiImageMaxN = 4
+	// geminiImageCaptureLimit 翻译路径捕获上游响应的最大字节数（4K 图片 base64 后可达十余 MB）
+	geminiImageCaptureLimit = 64 << 20
+
+	ImageResponseFormatURL     = "url"
+	ImageResponseFormatB64JSON = "b64_json"
+)
+
+var (
+	ErrImageRequestInvalid   = infraerrors.BadRequest("IMAGE_REQUEST_INVALID", "invalid image generation request")
+	ErrImageModelNotAllowed  = infraerrors.Forbidden("IMAGE_MODEL_NOT_ALLOWED", "image model is not allowed for this group")
+	ErrImageModelUnsupported = infraerrors.BadRequest("IMAGE_MODEL_UNSUPPORTED", "model does not support image generation")
+	ErrImageMaskUnsupported  = infraerrors.BadRequest("IMAGE_MASK_UNSUPPORTED", "mask is not supported for Gemini image models")
+)
+
+// ImageInput 图片编辑请求中的输入图片
+type ImageInput struct {
+	MimeType string
+	Data     []byte
+}
+
+// ImageGenerationRequest OpenAI 兼容的图片生成/编辑请求
+type ImageGenerationRequest struct {
+	Model          string
+	Prompt         string
+	N              int
+	Size           string
+	ResponseFormat string
+
+	// Edit 为 /v1/images/edits 请求
+	Edit bool
+	// Multipart 请求体为 multipart/form-data（edits 的标准格式）
+	Multipart bool
+	// Images edits 请求的输入图片（仅解析 multipart 请求）
+	Images  []ImageInput
+	HasMask bool
+
+	// 原始请求体，透传给 OpenAI API Key 账号
+	Body        []byte
+	ContentType string
+}
+
+// SetModel 替换请求模型并同步改写请求体（用于分组模型别名）
+func (r *ImageGenerationRequest) SetModel(model string) error {
+	if model == r.Model {
+		return nil
+	}
+	body, contentType, err := rewriteImageRequestField(r, "model", model)
+	if err != nil {
+		return err
+	}
+	r.Model, r.Body, r.ContentType = model, body, contentType
+	return nil
+}
+
+// SetPrompt 替换提示词并同步改写请求体（用于内容策略 strip）
+func (r *ImageGenerationRequest) SetPrompt(prompt string) error {
+	if prompt == r.Prompt {
+		return nil
+	}
+	body, contentType, err := rewriteImageRequestField(r, "prompt", prompt)
+	if err != nil {
+		return err
+	}
+	r.Prompt, r.Body, r.ContentType = prompt, body, contentType
+	return nil
+}
+
+// SizeTier 返回请求 size 对应的计费档位，未指定时按 1K 计费。
+// Gemini 2.5 Flash Image 不支持 imageSize，固定输出 1K。
+func (r *ImageGenerationRequest) SizeTier() string {
+	if isImageGenerationModel(r.Model) && !supportsGeminiImageSize(r.Model) {
+		return "1K"
+	}
+	return imageSizeTier(r.Size, "1K")
+}
+
+// ParseImageGenerationRequest 解析 /v1/images/generations 与 /v1/images/edits 请求体
+func ParseImageGenerationRequest(body []byte, contentType string, edit bool) (*ImageGenerationRequest, error) {
+	req := &ImageGenerationRequest{
+		Edit:        edit,
+		Body:        body,
+		ContentType: contentType,
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType == "multipart/form-data" {
+		req.Multipart = true
+		if err := parseImageMultipart(req, params["boundary"]); err != nil {
+			return nil, err
+		}
+	} else {
+		if !gjson.ValidBytes(body) {
+			return nil, imageRequestError("Failed to parse request body")
+		}
+		req.ContentType = "application/json"
+		req.Model = gjson.GetBytes(body, "model").String()
+		req.Prompt = gjson.GetBytes(body, "prompt").String()
+		req.N = int(gjson.GetBytes(body, "n").Int())
+		req.Size = gjson.GetBytes(body, "size").String()
+		req.ResponseFormat = gjson.GetBytes(body, "response_format").String()
+	}
+
+	req.Model = strings.TrimSpace(req.Model)
+	if req.Model == "" {
+		return nil, imageRequestError("model is required")
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		return nil, imageRequestError("prompt is required")
+	}
+	if req.N == 0 {
+		req.N = 1
+	}
+	if req.N < 0 || req.N > imageGenerationMaxN {
+		return nil, imageRequestError(fmt.Sprintf("n must be between 1 and %d", imageGenerationMaxN))
+	}
+	switch req.ResponseFormat {
+	case "", ImageResponseFormatURL, ImageResponseFormatB64JSON:
+	default:
+		return nil, imageRequestError("response_format must be one of url, b64_json")
+	}
+	return req, nil
+}
+
+func parseImageMultipart(req *ImageGenerationRequest, boundary string) error {
+	if boundary == "" {
+		return imageRequestError("missing multipart boundary")
+	}
+	reader := multipart.NewReader(bytes.NewReader(req.Body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ErrImageRequestInvalid.WithCause(err)
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return ErrImageRequestInvalid.WithCause(err)
+		}
+		name := part.FormName()
+		if part.FileName() != "" {
+			switch name {
+			case "image", "image[]":
+				mimeType := part.Header.Get("Content-Type")
+				if mimeType == "" || mimeType == "application/octet-stream" {
+					mimeType = http.DetectContentType(data)
+				}
+				req.Images = append(req.Images, ImageInput{MimeType: mimeType, Data: data})
+			case "mask":
+				req.HasMask = true
+			}
+			continue
+		}
+		value := string(data)
+		switch name {
+		case "model":
+			req.Model = value
+		case "prompt":
+			req.Prompt = value
+		case "size":
+			req.Size = value
+		case "response_format":
+			req.ResponseFormat = value
+		case "n":
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return imageRequestError("n must be an integer")
+			}
+			req.N = n
+		}
+	}
+}
+
+func imageRequestError(message string) error {
+	return infraerrors.BadRequest(ErrImageRequestInvalid.Reason, message)
+}
+
+// ImageGenerationService 将 OpenAI 图片接口翻译为 Gemini generateContent 调用（gemini / antigravity 分组）
+type ImageGenerationService struct {
+	geminiCompatService       *GeminiMessagesCompatService
+	antigravityGatewayService *AntigravityGatewayService
+}
+
+// NewImageGenerationService creates a new ImageGenerationService
+func NewImageGenerationService(geminiCompatService *GeminiMessagesCompatService, antigravityGatewayService *AntigravityGatewayService) *ImageGenerationService {
+	return &ImageGenerationService{
+		geminiCompatService:       geminiCompatService,
+		antigravityGatewayService: antigravityGatewayService,
+	}
+}
+
+// ValidateGeminiRequest 校验请求能否翻译为 Gemini 图片生成调用
+func (s *ImageGenerationService) ValidateGeminiRequest(req *ImageGenerationRequest) error {
+	if !isImageGenerationModel(req.Model) {
+		return ErrImageModelUnsupported
+	}
+	if req.HasMask {
+		return ErrImageMaskUnsupported
+	}
+	if req.Edit && len(req.Images) == 0 {
+		return imageRequestError("image is required")
+	}
+	if req.N > geminiImageMaxN {
+		return imageRequestError(fmt.Sprintf("n must be between 1 and %d for Gemini image models", geminiImageMaxN))
+	}
+	return nil
+}
+
+// ForwardGemini 将图片请求翻译为 Gemini generateContent 调用，逐张生成后以 OpenAI 格式写回客户端。
+// 首张图片失败时透传 failover 错误；后续失败则返回已生成的图片（仅按实际张数计费）。
+func (s *ImageGenerationService) ForwardGemini(ctx context.Context, c *gin.Context, account *Account, req *ImageGenerationRequest) (*ForwardResult, error) {
+	startTime := time.Now()
+	sizeTier := req.SizeTier()
+	body, err := buildGeminiImageRequest(req, sizeTier)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		images    []geminiInlineImage
+		usage     ClaudeUsage
+		requestID string
+	)
+	for i := 0; i < req.N; i++ {
+		captured, w := newImageCaptureContext(c)
+		var result *ForwardResult
+		if account.Platform == PlatformAntigravity {
+			result, err = s.antigravityGatewayService.ForwardGemini(ctx, captured, account, req.Model, "generateContent", false, body)
+		} else {
+			result, err = s.geminiCompatService.ForwardNative(ctx, captured, account, req.Model, "generateContent", false, body)
+		}
+		if err != nil || w.truncated() {
+			if len(images) > 0 {
+				log.Printf("[ImageGeneration] account=%d stopped after %d/%d images: %v", account.ID, len(images), req.N, err)
+				break
+			}
+			var failoverErr *UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				return nil, err
+			}
+			status := captured.Writer.Status()
+			if status < http.StatusBadRequest {
+				status = http.StatusBadGateway
+			}
+			message := gjson.GetBytes(w.bodyBytes(), "error.message").String()
+			if message == "" {
+				message = "Upstream request failed"
+			}
+			writeOpenAIImageError(c, status, "upstream_error", message)
+			if err == nil {
+				err = errors.New("upstream image response exceeds capture limit")
+			}
+			return nil, err
+		}
+		if result != nil {
+			usage.InputTokens += result.Usage.InputTokens
+			usage.OutputTokens += result.Usage.OutputTokens
+			if requestID == "" {
+				requestID = result.RequestID
+			}
+		}
+		images = append(images, extractGeminiInlineImages(w.bodyBytes())...)
+	}
+
+	if len(images) == 0 {
+		writeOpenAIImageError(c, http.StatusBadGateway, "upstream_error", "Upstream returned no images")
+		return nil, errors.New("upstream returned no images")
+	}
+	if len(images) > req.N {
+		images = images[:req.N]
+	}
+
+	c.Data(http.StatusOK, "application/json", buildOpenAIImageResponse(images, req.ResponseFormat, time.Now().Unix()))
+
+	return &ForwardResult{
+		RequestID:  requestID,
+		Usage:      usage,
+		Model:      req.Model,
+		Duration:   time.Since(startTime),
+		ImageCount: len(images),
+		ImageSize:  sizeTier,
+	}, nil
+}
+
+// newImageCaptureContext 创建捕获上游响应的 gin 上下文；共享原请求与 Keys，保证运维错误上下文写回原请求
+func newImageCaptureContext(c *gin.Context) (*gin.Context, *limitedResponseWriter) {
+	w := newLimitedResponseWriter(geminiImageCaptureLimit)
+	captured, _ := gin.CreateTestContext(w)
+	captured.Request = c.Request
+	captured.Keys = c.Keys
+	return captured, w
+}
+
+// supportsGeminiImageSize 仅 Gemini 3 Pro Image 支持 imageConfig.imageSize（1K/2K/4K）
+func supportsGeminiImageSize(model string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(strings.ToLower(model), "models/"), "gemini-3-pro-image")
+}
+
+// geminiAspectRatios Gemini imageConfig.aspectRatio 支持的宽高比
+var geminiAspectRatios = []struct {
+	name  string
+	ratio float64
+}{
+	{"1:1", 1},
+	{"2:3", 2.0 / 3},
+	{"3:2", 3.0 / 2},
+	{"3:4", 3.0 / 4},
+	{"4:3", 4.0 / 3},
+	{"4:5", 4.0 / 5},
+	{"5:4", 5.0 / 4},
+	{"9:16", 9.0 / 16},
+	{"16:9", 16.0 / 9},
+	{"21:9", 21.0 / 9},
+}
+
+// geminiAspectRatio 将 "WIDTHxHEIGHT" 映射为最接近的 Gemini 宽高比，无法解析时返回空字符串
+func geminiAspectRatio(size string) string {
+	width, height, ok := parseImageDimensions(size)
+	if !ok {
+		return ""
+	}
+	target := float64(width) / float64(height)
+	best := ""
+	bestDiff := math.MaxFloat64
+	for _, ar := range geminiAspectRatios {
+		if diff := math.Abs(ar.ratio - target); diff < bestDiff {
+			best, bestDiff = ar.name, diff
+		}
+	}
+	return best
+}
+
+// buildGeminiImageRequest 构造 Gemini generateContent 请求体
+func buildGeminiImageRequest(req *ImageGenerationRequest, sizeTier string) ([]byte, error) {
+	parts := []map[string]any{{"text": req.Prompt}}
+	for _, img := range req.Images {
+		parts = append(parts, map[string]any{
+			"inlineData": map[string]any{
+				"mimeType": img.MimeType,
+				"data":     base64.StdEncoding.EncodeToString(img.Data),
+			},
+		})
+	}
+
+	imageConfig := map[string]any{}
+	if ar := geminiAspectRatio(req.Size); ar != "" {
+		imageConfig["aspectRatio"] = ar
+	}
+	if supportsGeminiImageSize(req.Model) {
+		imageConfig["imageSize"] = sizeTier
+	}
+	generationConfig := map[string]any{
+		"responseModalities": []string{"TEXT", "IMAGE"},
+	}
+	if len(imageConfig) > 0 {
+		generationConfig["imageConfig"] = imageConfig
+	}
+
+	return json.Marshal(map[string]any{
+		"contents": []map[string]any{{
+			"role":  "user",
+			"parts": parts,
+		}},
+		"generationConfig": generationConfig,
+	})
+}
+
+type geminiInlineImage struct {
+	MimeType string
+	Data     string // base64
+}
+
+// extractGeminiInlineImages 提取 Gemini 响应中的 inlineData 图片（兼容 snake_case 字段）
+func extractGeminiInlineImages(body []byte) []geminiInlineImage {
+	var images []geminiInlineImage
+	gjson.GetBytes(body, "candidates").ForEach(func(_, candidate gjson.Result) bool {
+		candidate.Get("content.parts").ForEach(func(_, part gjson.Result) bool {
+			inline := part.Get("inlineData")
+			if !inline.Exists() {
+				inline = part.Get("inline_data")
+			}
+			data := inline.Get("data").String()
+			if data == "" {
+				return true
+			}
+			mimeType := inline.Get("mimeType").String()
+			if mimeType == "" {
+				mimeType = inline.Get("mime_type").String()
+			}
+			if mimeType == "" {
+				mimeType = "image/png"
+			}
+			images = append(images, geminiInlineImage{MimeType: mimeType, Data: data})
+			return true
+		})
+		return true
+	})
+	return images
+}
+
+// buildOpenAIImageResponse 构造 OpenAI 图片响应；url 格式以 data URL 返回（网关不托管图片）
+func buildOpenAIImageResponse(images []geminiInlineImage, responseFormat string, created int64) []byte {
+	data := make([]map[string]string, 0, len(images))
+	for _, img := range images {
+		if responseFormat == ImageResponseFormatURL {
+			data = append(data, map[string]string{"url": "data:" + img.MimeType + ";base64," + img.Data})
+		} else {
+			data = append(data, map[string]string{"b64_json": img.Data})
+		}
+	}
+	out, _ := json.Marshal(map[string]any{
+		"created": created,
+		"data":    data,
+	})
+	return out
+}
+
+func writeOpenAIImageError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,183 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func buildImageEditForm(t *testing.T, fields map[string]string, withMask bool) ([]byte, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		require.NoError(t, w.WriteField(k, v))
+	}
+	part, err := w.CreateFormFile("image", "cat.png")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("\x89PNG\r\n\x1a\nfake"))
+	if withMask {
+		part, err = w.CreateFormFile("mask", "mask.png")
+		require.NoError(t, err)
+		_, _ = part.Write([]byte("\x89PNG\r\n\x1a\nmask"))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes(), w.FormDataContentType()
+}
+
+func TestImageSizeTier(t *testing.T) {
+	cases := map[string]string{
+		"":          "1K",
+		"auto":      "1K",
+		"256x256":   "1K",
+		"1024x1024": "1K",
+		"1536x1024": "2K",
+		"1792x1024": "2K",
+		"2048x2048": "2K",
+		"4096x2304": "4K",
+		"2k":        "2K",
+		"4K":        "4K",
+	}
+	for size, want := range cases {
+		require.Equal(t, want, imageSizeTier(size, "1K"), size)
+	}
+
+	flash := &ImageGenerationRequest{Model: "gemini-2.5-flash-image", Size: "2048x2048"}
+	require.Equal(t, "1K", flash.SizeTier(), "flash image only outputs 1K")
+	pro := &ImageGenerationRequest{Model: "gemini-3-pro-image-preview", Size: "4K"}
+	require.Equal(t, "4K", pro.SizeTier())
+}
+
+func TestParseImageGenerationRequest_JSON(t *testing.T) {
+	req, err := ParseImageGenerationRequest([]byte(`{"model":"gpt-image-1","prompt":"a cat","size":"1536x1024"}`), "application/json", false)
+	require.NoError(t, err)
+	require.Equal(t, "gpt-image-1", req.Model)
+	require.Equal(t, 1, req.N)
+	require.Equal(t, "2K", req.SizeTier())
+	require.False(t, req.Multipart)
+
+	_, err = ParseImageGenerationRequest([]byte(`{"model":"gpt-image-1"}`), "application/json", false)
+	require.Error(t, err)
+	_, err = ParseImageGenerationRequest([]byte(`{"model":"gpt-image-1","prompt":"x","n":11}`), "application/json", false)
+	require.Error(t, err)
+	_, err = ParseImageGenerationRequest([]byte(`{"model":"gpt-image-1","prompt":"x","response_format":"png"}`), "application/json", false)
+	require.Error(t, err)
+}
+
+func TestParseImageGenerationRequest_Multipart(t *testing.T) {
+	body, contentType := buildImageEditForm(t, map[string]string{"model": "gemini-3-pro-image-preview", "prompt": "add a hat", "n": "2"}, true)
+	req, err := ParseImageGenerationRequest(body, contentType, true)
+	require.NoError(t, err)
+	require.True(t, req.Multipart)
+	require.True(t, req.HasMask)
+	require.Equal(t, 2, req.N)
+	require.Len(t, req.Images, 1)
+	require.Equal(t, "image/png", req.Images[0].MimeType)
+
+	svc := &ImageGenerationService{}
+	require.ErrorIs(t, svc.ValidateGeminiRequest(req), ErrImageMaskUnsupported)
+
+	req.HasMask = false
+	require.NoError(t, svc.ValidateGeminiRequest(req))
+
+	req.Model = "gemini-2.5-pro"
+	require.ErrorIs(t, svc.ValidateGeminiRequest(req), ErrImageModelUnsupported)
+}
+
+func TestBuildGeminiImageRequest(t *testing.T) {
+	req := &ImageGenerationRequest{
+		Model:  "gemini-3-pro-image-preview",
+		Prompt: "a lighthouse",
+		Size:   "1792x1024",
+		Images: []ImageInput{{MimeType: "image/png", Data: []byte("abc")}},
+	}
+	body, err := buildGeminiImageRequest(req, req.SizeTier())
+	require.NoError(t, err)
+	require.Equal(t, "a lighthouse", gjson.GetBytes(body, "contents.0.parts.0.text").String())
+	require.Equal(t, "YWJj", gjson.GetBytes(body, "contents.0.parts.1.inlineData.data").String())
+	require.Equal(t, "16:9", gjson.GetBytes(body, "generationConfig.imageConfig.aspectRatio").String())
+	require.Equal(t, "2K", gjson.GetBytes(body, "generationConfig.imageConfig.imageSize").String())
+
+	flash := &ImageGenerationRequest{Model: "gemini-2.5-flash-image", Prompt: "x"}
+	body, err = buildGeminiImageRequest(flash, flash.SizeTier())
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(body, "generationConfig.imageConfig").Exists())
+}
+
+func TestGeminiImageResponseConversion(t *testing.T) {
+	geminiResp := []byte(`{"candidates":[{"content":{"parts":[{"text":"here you go"},{"inlineData":{"mimeType":"image/jpeg","data":"AAA"}},{"inline_data":{"mime_type":"image/png","data":"BBB"}}]}}]}`)
+	images := extractGeminiInlineImages(geminiResp)
+	require.Len(t, images, 2)
+
+	b64 := buildOpenAIImageResponse(images, "", 1700000000)
+	require.Equal(t, int64(1700000000), gjson.GetBytes(b64, "created").Int())
+	require.Equal(t, "AAA", gjson.GetBytes(b64, "data.0.b64_json").String())
+
+	urls := buildOpenAIImageResponse(images, ImageResponseFormatURL, 1)
+	require.Equal(t, "data:image/png;base64,BBB", gjson.GetBytes(urls, "data.1.url").String())
+}
+
+func TestGroupIsImageModelAllowed(t *testing.T) {
+	var nilGroup *Group
+	require.True(t, nilGroup.IsImageModelAllowed("gpt-image-1"))
+	require.True(t, (&Group{}).IsImageModelAllowed("gpt-image-1"))
+
+	g := &Group{ImageModels: []string{"gpt-image-*", "gemini-3-pro-image-preview"}}
+	require.True(t, g.IsImageModelAllowed("gpt-image-1"))
+	require.True(t, g.IsImageModelAllowed("gemini-3-pro-image-preview"))
+	require.False(t, g.IsImageModelAllowed("dall-e-3"))
+
+	require.Equal(t, []string{"a", "b"}, normalizeModelPatterns([]string{" a ", "", "b", "a"}))
+	require.Nil(t, normalizeModelPatterns([]string{" "}))
+}
+
+func TestOpenAIForwardImages_MultipartModelMappingAndBilling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := &embeddingUpstreamStub{
+		status:   http.StatusOK,
+		response: `{"created":1,"data":[{"b64_json":"AAA"},{"b64_json":"BBB"}],"usage":{"input_tokens":10,"output_tokens":100}}`,
+	}
+	svc := &OpenAIGatewayService{cfg: &config.Config{}, httpUpstream: upstream}
+	account := &Account{
+		ID:       1,
+		Platform: PlatformOpenAI,
+		Type:     AccountTypeAPIKey,
+		Credentials: map[string]any{
+			"api_key":       "sk-test",
+			"model_mapping": map[string]any{"image-default": "gpt-image-1"},
+		},
+	}
+
+	body, contentType := buildImageEditForm(t, map[string]string{"model": "image-default", "prompt": "add a hat", "size": "1024x1536"}, false)
+	req, err := ParseImageGenerationRequest(body, contentType, true)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/edits", nil)
+
+	result, err := svc.ForwardImages(context.Background(), c, account, req)
+	require.NoError(t, err)
+	require.Equal(t, openaiImagesEditsURL, upstream.req.URL.String())
+
+	forwarded, err := ParseImageGenerationRequest(upstream.body, upstream.req.Header.Get("content-type"), true)
+	require.NoError(t, err)
+	require.Equal(t, "gpt-image-1", forwarded.Model)
+	require.Equal(t, "add a hat", forwarded.Prompt)
+	require.Len(t, forwarded.Images, 1)
+
+	require.Equal(t, "image-default", result.Model)
+	require.Equal(t, 2, result.ImageCount)
+	require.Equal(t, "2K", result.ImageSize)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrMessageBatchNotFound = infraerrors.NotFound("MESSAGE_BATCH_NOT_FOUND", "message batch not found")
+	// ErrMessageBatchNoAccount 分组内没有可用于 Message Batches 的 Anthropic API Key 账号
+	ErrMessageBatchNoAccount = infraerrors.ServiceUnavailable("MESSAGE_BATCH_NO_ACCOUNT", "no available API key account supports message batches")
+	// ErrMessageBatchAccountGone 创建批次的上游账号已不可用（批次固定在该账号上，无法切换）
+	ErrMessageBatchAccountGone = infraerrors.ServiceUnavailable("MESSAGE_BATCH_ACCOUNT_UNAVAILABLE", "the account that created this batch is no longer available")
+)
+
+// Message Batch 处理状态（与上游 processing_status 一致）
+const (
+	MessageBatchStatusInProgress = "in_progress"
+	MessageBatchStatusCanceling  = "canceling"
+	MessageBatchStatusEnded      = "ended"
+)
+
+// MessageBatchDefaultListLimit / MessageBatchMaxListLimit 列表分页参数（与上游一致）
+const (
+	MessageBatchDefaultListLimit = 20
+	MessageBatchMaxListLimit     = 1000
+)
+
+// MessageBatch 批次归属记录：批次属于创建它的 API Key，并固定在创建它的上游账号上
+type MessageBatch struct {
+	ID               int64
+	BatchID          string
+	APIKeyID         int64
+	UserID           int64
+	GroupID          *int64
+	AccountID        int64
+	ProcessingStatus string
+	// Payload 最近一次从上游获取的批次对象（原始 JSON）
+	Payload         json.RawMessage
+	ResultsBilledAt *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// MessageBatchListParams 列表分页参数（按创建时间倒序，before_id/after_id 为批次 ID 游标）
+type MessageBatchListParams struct {
+	Limit    int
+	BeforeID string
+	AfterID  string
+}
+
+type MessageBatchRepository interface {
+	Create(ctx context.Context, batch *MessageBatch) error
+	// GetByBatchID 按上游批次 ID 查询指定 API Key 的批次，不属于该 API Key 时返回 ErrMessageBatchNotFound
+	GetByBatchID(ctx context.Context, apiKeyID int64, batchID string) (*MessageBatch, error)
+	UpdatePayload(ctx context.Context, id int64, status string, payload json.RawMessage) error
+	// ListByAPIKey 分页列出 API Key 的批次，返回是否还有更多
+	ListByAPIKey(ctx context.Context, apiKeyID int64, params MessageBatchListParams) ([]MessageBatch, bool, error)
+	MarkResultsBilled(ctx context.Context, id int64) error
+}
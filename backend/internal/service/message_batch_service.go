@@ -0,0 +1,457 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	mathrand "math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	messageBatchesPath            = "/v1/messages/batches"
+	messageBatchDefaultAPIVersion = "2023-06-01"
+)
+
+// MessageBatchUpstreamResponse 上游响应（状态码与响应体，2xx 时响应体已改写）
+type MessageBatchUpstreamResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// MessageBatchRequestContext 批次请求上下文
+type MessageBatchRequestContext struct {
+	APIKey       *APIKey
+	Subscription *UserSubscription // 可选：订阅信息（订阅分组按订阅用量计费）
+	// Header 客户端请求头（透传 anthropic-version / anthropic-beta）
+	Header http.Header
+	// PublicBaseURL 网关对外地址，用于改写批次对象中的 results_url
+	PublicBaseURL string
+}
+
+// MessageBatchService Anthropic Message Batches 代理服务。
+//
+//   - 批次仅由 Anthropic API Key 账号创建，并固定在创建它的账号上（批次 ID 只在该上游账号下有效）；
+//   - 批次归属记录在本地（按 sub2api API Key），查询/列表/取消/结果均只能访问自己的批次；
+//   - 结果按批量折扣计费：拉取结果时汇总成功请求的用量，按模型写入使用记录并扣费，
+//     使用记录的 request_id 固定为批次 + 模型，重复拉取不会重复计费。
+type MessageBatchService struct {
+	batchRepo      MessageBatchRepository
+	accountRepo    AccountRepository
+	gatewayService *GatewayService
+	httpUpstream   HTTPUpstream
+}
+
+// NewMessageBatchService 创建 Message Batches 服务
+func NewMessageBatchService(
+	batchRepo MessageBatchRepository,
+	accountRepo AccountRepository,
+	gatewayService *GatewayService,
+	httpUpstream HTTPUpstream,
+) *MessageBatchService {
+	return &MessageBatchService{
+		batchRepo:      batchRepo,
+		accountRepo:    accountRepo,
+		gatewayService: gatewayService,
+		httpUpstream:   httpUpstream,
+	}
+}
+
+// Create 在分组内选择 Anthropic API Key 账号创建批次，并记录批次归属
+func (s *MessageBatchService) Create(ctx context.Context, rc *MessageBatchRequestContext, body []byte) (*MessageBatchUpstreamResponse, error) {
+	account, err := s.selectAccount(ctx, rc.APIKey.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	body = applyBatchModelMapping(body, account)
+
+	resp, err := s.doUpstream(ctx, account, http.MethodPost, messageBatchesPath, body, rc.Header)
+	if err != nil {
+		return nil, err
+	}
+	if !isSuccessStatus(resp.StatusCode) {
+		return resp, nil
+	}
+
+	batchID := gjson.GetBytes(resp.Body, "id").String()
+	if batchID == "" {
+		return nil, fmt.Errorf("upstream batch response missing id")
+	}
+	batch := &MessageBatch{
+		BatchID:          batchID,
+		APIKeyID:         rc.APIKey.ID,
+		UserID:           rc.APIKey.UserID,
+		GroupID:          rc.APIKey.GroupID,
+		AccountID:        account.ID,
+		ProcessingStatus: batchProcessingStatus(resp.Body),
+		Payload:          json.RawMessage(resp.Body),
+	}
+	if err := s.batchRepo.Create(ctx, batch); err != nil {
+		// 上游批次已创建但归属未记录：客户端将无法再访问该批次，需人工处理
+		log.Printf("ALERT: message batch %s created on account %d but ownership not saved: %v", batchID, account.ID, err)
+		return nil, fmt.Errorf("save message batch: %w", err)
+	}
+	resp.Body = rewriteBatchResultsURL(resp.Body, rc.PublicBaseURL)
+	return resp, nil
+}
+
+// Retrieve 查询批次（从创建批次的账号获取最新状态）
+func (s *MessageBatchService) Retrieve(ctx context.Context, rc *MessageBatchRequestContext, batchID string) (*MessageBatchUpstreamResponse, error) {
+	batch, account, err := s.getOwnedBatch(ctx, rc.APIKey, batchID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.doUpstream(ctx, account, http.MethodGet, messageBatchesPath+"/"+batchID, nil, rc.Header)
+	if err != nil {
+		return nil, err
+	}
+	if isSuccessStatus(resp.StatusCode) {
+		s.savePayload(ctx, batch, resp.Body)
+		resp.Body = rewriteBatchResultsURL(resp.Body, rc.PublicBaseURL)
+	}
+	return resp, nil
+}
+
+// Cancel 取消批次
+func (s *MessageBatchService) Cancel(ctx context.Context, rc *MessageBatchRequestContext, batchID string) (*MessageBatchUpstreamResponse, error) {
+	batch, account, err := s.getOwnedBatch(ctx, rc.APIKey, batchID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.doUpstream(ctx, account, http.MethodPost, messageBatchesPath+"/"+batchID+"/cancel", nil, rc.Header)
+	if err != nil {
+		return nil, err
+	}
+	if isSuccessStatus(resp.StatusCode) {
+		s.savePayload(ctx, batch, resp.Body)
+		resp.Body = rewriteBatchResultsURL(resp.Body, rc.PublicBaseURL)
+	}
+	return resp, nil
+}
+
+// List 列出当前 API Key 的批次（使用本地记录的最近一次批次对象，不会暴露同一上游账号下其他用户的批次）
+func (s *MessageBatchService) List(ctx context.Context, rc *MessageBatchRequestContext, params MessageBatchListParams) ([]byte, error) {
+	if params.Limit <= 0 {
+		params.Limit = MessageBatchDefaultListLimit
+	}
+	if params.Limit > MessageBatchMaxListLimit {
+		params.Limit = MessageBatchMaxListLimit
+	}
+	batches, hasMore, err := s.batchRepo.ListByAPIKey(ctx, rc.APIKey.ID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]json.RawMessage, 0, len(batches))
+	for i := range batches {
+		data = append(data, json.RawMessage(rewriteBatchResultsURL(batches[i].Payload, rc.PublicBaseURL)))
+	}
+	out := map[string]any{
+		"data":     data,
+		"has_more": hasMore,
+		"first_id": nil,
+		"last_id":  nil,
+	}
+	if len(batches) > 0 {
+		out["first_id"] = batches[0].BatchID
+		out["last_id"] = batches[len(batches)-1].BatchID
+	}
+	return json.Marshal(out)
+}
+
+// Results 流式返回批次结果（JSONL），同时汇总成功请求的用量；完整读取后按批量折扣计费。
+// 响应头写出前发生的错误通过返回值交给调用方处理；upstream 非 2xx 时返回其响应体。
+func (s *MessageBatchService) Results(ctx context.Context, rc *MessageBatchRequestContext, batchID string, start func(contentType string), w io.Writer) (*MessageBatchUpstreamResponse, error) {
+	batch, account, err := s.getOwnedBatch(ctx, rc.APIKey, batchID)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.newUpstreamRequest(ctx, account, http.MethodGet, messageBatchesPath+"/"+batchID+"/results", nil, rc.Header)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpUpstream.Do(req, account.UpstreamProxyURL(), account.ID, account.Concurrency)
+	if err != nil {
+		return nil, fmt.Errorf("upstream request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if !isSuccessStatus(resp.StatusCode) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return &MessageBatchUpstreamResponse{StatusCode: resp.StatusCode, ContentType: resp.Header.Get("Content-Type"), Body: body}, nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/binary"
+	}
+	start(contentType)
+
+	usage := newBatchUsageAggregator()
+	reader := bufio.NewReaderSize(resp.Body, 64*1024)
+	clientGone := false
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			usage.add(line)
+			// 客户端中途断开时继续读完上游结果，保证计费完整
+			if !clientGone {
+				if _, err := w.Write(line); err != nil {
+					clientGone = true
+				}
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			// 上游中断：用量不完整，本次不计费（下次完整拉取时计费）
+			log.Printf("Message batch %s results stream interrupted: %v", batchID, readErr)
+			return nil, nil
+		}
+	}
+
+	if batch.ResultsBilledAt == nil {
+		s.billResults(batch, account, rc, usage)
+	}
+	return nil, nil
+}
+
+// billResults 按模型写入使用记录并按批量折扣扣费（request_id 固定，重复拉取不重复计费）
+func (s *MessageBatchService) billResults(batch *MessageBatch, account *Account, rc *MessageBatchRequestContext, usage *batchUsageAggregator) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, model := range usage.models() {
+		tokens := usage.byModel[model]
+		if err := s.gatewayService.RecordUsage(ctx, &RecordUsageInput{
+			Result: &ForwardResult{
+				RequestID: "msgbatch:" + batch.BatchID + ":" + model,
+				Usage:     *tokens,
+				Model:     model,
+			},
+			APIKey:       rc.APIKey,
+			User:         rc.APIKey.User,
+			Account:      account,
+			Subscription: rc.Subscription,
+			Batch:        true,
+		}); err != nil {
+			log.Printf("Record message batch usage failed: batch=%s model=%s err=%v", batch.BatchID, model, err)
+			return
+		}
+	}
+	if err := s.batchRepo.MarkResultsBilled(ctx, batch.ID); err != nil {
+		log.Printf("Mark message batch %s billed failed: %v", batch.BatchID, err)
+	}
+}
+
+func (s *MessageBatchService) getOwnedBatch(ctx context.Context, apiKey *APIKey, batchID string) (*MessageBatch, *Account, error) {
+	batch, err := s.batchRepo.GetByBatchID(ctx, apiKey.ID, batchID)
+	if err != nil {
+		return nil, nil, err
+	}
+	account, err := s.accountRepo.GetByID(ctx, batch.AccountID)
+	if err != nil || account == nil || account.Platform != PlatformAnthropic || account.Type != AccountTypeAPIKey {
+		return nil, nil, ErrMessageBatchAccountGone
+	}
+	return batch, account, nil
+}
+
+func (s *MessageBatchService) savePayload(ctx context.Context, batch *MessageBatch, body []byte) {
+	if err := s.batchRepo.UpdatePayload(ctx, batch.ID, batchProcessingStatus(body), json.RawMessage(body)); err != nil {
+		log.Printf("Update message batch %s failed: %v", batch.BatchID, err)
+	}
+}
+
+// selectAccount 选择分组内可调度的 Anthropic API Key 账号（按优先级，同优先级随机）
+func (s *MessageBatchService) selectAccount(ctx context.Context, groupID *int64) (*Account, error) {
+	var (
+		accounts []Account
+		err      error
+	)
+	if groupID != nil {
+		accounts, err = s.accountRepo.ListSchedulableByGroupIDAndPlatform(ctx, *groupID, PlatformAnthropic)
+	} else {
+		accounts, err = s.accountRepo.ListSchedulableByPlatform(ctx, PlatformAnthropic)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+
+	candidates := make([]*Account, 0, len(accounts))
+	for i := range accounts {
+		if accounts[i].Type == AccountTypeAPIKey && accounts[i].IsSchedulable() {
+			candidates = append(candidates, &accounts[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrMessageBatchNoAccount
+	}
+	mathrand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Priority < candidates[j].Priority })
+	return candidates[0], nil
+}
+
+func (s *MessageBatchService) newUpstreamRequest(ctx context.Context, account *Account, method, path string, body []byte, header http.Header) (*http.Request, error) {
+	token, _, err := s.gatewayService.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := s.gatewayService.validateUpstreamBaseURL(account.GetBaseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(baseURL, "/")+path, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", token)
+	version := messageBatchDefaultAPIVersion
+	if header != nil {
+		if v := header.Get("anthropic-version"); v != "" {
+			version = v
+		}
+		if beta := header.Get("anthropic-beta"); beta != "" {
+			req.Header.Set("anthropic-beta", beta)
+		}
+	}
+	req.Header.Set("anthropic-version", version)
+	if body != nil {
+		req.Header.Set("content-type", "application/json")
+	}
+	return req, nil
+}
+
+func (s *MessageBatchService) doUpstream(ctx context.Context, account *Account, method, path string, body []byte, header http.Header) (*MessageBatchUpstreamResponse, error) {
+	req, err := s.newUpstreamRequest(ctx, account, method, path, body, header)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpUpstream.Do(req, account.UpstreamProxyURL(), account.ID, account.Concurrency)
+	if err != nil {
+		return nil, fmt.Errorf("upstream request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read upstream response: %w", err)
+	}
+	return &MessageBatchUpstreamResponse{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        respBody,
+	}, nil
+}
+
+// batchUsageAggregator 按模型汇总批次结果中成功请求的用量
+type batchUsageAggregator struct {
+	byModel map[string]*ClaudeUsage
+}
+
+func newBatchUsageAggregator() *batchUsageAggregator {
+	return &batchUsageAggregator{byModel: make(map[string]*ClaudeUsage)}
+}
+
+// add 解析一行结果；仅 succeeded 的请求计费（errored/canceled/expired 上游不收费）
+func (a *batchUsageAggregator) add(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	result := gjson.GetBytes(line, "result")
+	if result.Get("type").String() != "succeeded" {
+		return
+	}
+	message := result.Get("message")
+	model := message.Get("model").String()
+	if model == "" {
+		return
+	}
+	usage := a.byModel[model]
+	if usage == nil {
+		usage = &ClaudeUsage{}
+		a.byModel[model] = usage
+	}
+	u := message.Get("usage")
+	usage.InputTokens += int(u.Get("input_tokens").Int())
+	usage.OutputTokens += int(u.Get("output_tokens").Int())
+	usage.CacheCreationInputTokens += int(u.Get("cache_creation_input_tokens").Int())
+	usage.CacheReadInputTokens += int(u.Get("cache_read_input_tokens").Int())
+}
+
+func (a *batchUsageAggregator) models() []string {
+	models := make([]string, 0, len(a.byModel))
+	for model := range a.byModel {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	return models
+}
+
+// applyBatchModelMapping 对 API Key 账号应用模型映射（逐个改写 requests[].params.model）
+func applyBatchModelMapping(body []byte, account *Account) []byte {
+	requests := gjson.GetBytes(body, "requests")
+	if !requests.IsArray() {
+		return body
+	}
+	for i, item := range requests.Array() {
+		model := item.Get("params.model").String()
+		if model == "" {
+			continue
+		}
+		if mapped := account.GetMappedModel(model); mapped != model {
+			if updated, err := sjson.SetBytes(body, fmt.Sprintf("requests.%d.params.model", i), mapped); err == nil {
+				body = updated
+			}
+		}
+	}
+	return body
+}
+
+// rewriteBatchResultsURL 将批次对象中的 results_url 指向网关（结果需经网关拉取以完成计费）
+func rewriteBatchResultsURL(body []byte, publicBaseURL string) []byte {
+	if publicBaseURL == "" {
+		return body
+	}
+	resultsURL := gjson.GetBytes(body, "results_url")
+	if !resultsURL.Exists() || resultsURL.Type == gjson.Null {
+		return body
+	}
+	batchID := gjson.GetBytes(body, "id").String()
+	if batchID == "" {
+		return body
+	}
+	rewritten, err := sjson.SetBytes(body, "results_url", strings.TrimRight(publicBaseURL, "/")+messageBatchesPath+"/"+batchID+"/results")
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
+
+func batchProcessingStatus(body []byte) string {
+	if status := gjson.GetBytes(body, "processing_status").String(); status != "" {
+		return status
+	}
+	return MessageBatchStatusInProgress
+}
+
+func isSuccessStatus(status int) bool {
+	return status >= 200 && status < 300
+}
@@ -0,0 +1,58 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestBatchUsageAggregator_OnlySucceededResults(t *testing.T) {
+	agg := newBatchUsageAggregator()
+	agg.add([]byte(`{"custom_id":"a","result":{"type":"succeeded","message":{"model":"claude-sonnet-4","usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":3}}}}` + "\n"))
+	agg.add([]byte(`{"custom_id":"b","result":{"type":"succeeded","message":{"model":"claude-sonnet-4","usage":{"input_tokens":7,"output_tokens":2,"cache_creation_input_tokens":4}}}}`))
+	agg.add([]byte(`{"custom_id":"c","result":{"type":"succeeded","message":{"model":"claude-haiku-4","usage":{"input_tokens":1,"output_tokens":1}}}}`))
+	agg.add([]byte(`{"custom_id":"d","result":{"type":"errored","error":{"type":"invalid_request_error"}}}`))
+	agg.add([]byte(`{"custom_id":"e","result":{"type":"expired"}}`))
+	agg.add([]byte("\n"))
+
+	require.Equal(t, []string{"claude-haiku-4", "claude-sonnet-4"}, agg.models())
+	sonnet := agg.byModel["claude-sonnet-4"]
+	require.Equal(t, 17, sonnet.InputTokens)
+	require.Equal(t, 7, sonnet.OutputTokens)
+	require.Equal(t, 3, sonnet.CacheReadInputTokens)
+	require.Equal(t, 4, sonnet.CacheCreationInputTokens)
+}
+
+func TestRewriteBatchResultsURL(t *testing.T) {
+	body := []byte(`{"id":"msgbatch_1","processing_status":"ended","results_url":"https://api.anthropic.com/v1/messages/batches/msgbatch_1/results"}`)
+	out := rewriteBatchResultsURL(body, "https://gw.example.com/")
+	require.Equal(t, "https://gw.example.com/v1/messages/batches/msgbatch_1/results", gjson.GetBytes(out, "results_url").String())
+
+	pending := []byte(`{"id":"msgbatch_1","results_url":null}`)
+	require.Equal(t, pending, rewriteBatchResultsURL(pending, "https://gw.example.com"), "null results_url is kept")
+}
+
+func TestApplyBatchModelMapping(t *testing.T) {
+	account := &Account{
+		Platform:    PlatformAnthropic,
+		Type:        AccountTypeAPIKey,
+		Credentials: map[string]any{"model_mapping": map[string]any{"claude-sonnet-4": "claude-sonnet-4-20250514"}},
+	}
+	body := []byte(`{"requests":[{"custom_id":"a","params":{"model":"claude-sonnet-4"}},{"custom_id":"b","params":{"model":"claude-other"}}]}`)
+	out := applyBatchModelMapping(body, account)
+	require.Equal(t, "claude-sonnet-4-20250514", gjson.GetBytes(out, "requests.0.params.model").String())
+	require.Equal(t, "claude-other", gjson.GetBytes(out, "requests.1.params.model").String())
+}
+
+func TestApplyBatchDiscount(t *testing.T) {
+	svc := &BillingService{}
+	cost := svc.ApplyBatchDiscount(&CostBreakdown{InputCost: 2, OutputCost: 4, TotalCost: 6, ActualCost: 6})
+	require.InDelta(t, 1, cost.InputCost, 1e-12)
+	require.InDelta(t, 2, cost.OutputCost, 1e-12)
+	require.InDelta(t, 3, cost.TotalCost, 1e-12)
+	require.InDelta(t, 3, cost.ActualCost, 1e-12)
+	require.Nil(t, svc.ApplyBatchDiscount(nil))
+}
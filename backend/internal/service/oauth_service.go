@@ -28,14 +28,16 @@ type ClaudeOAuthClient interface {
 type OAuthService struct {
 	sessionStore *oauth.SessionStore
 	proxyRepo    ProxyRepository
+	proxyPools   *ProxyPoolService
 	oauthClient  ClaudeOAuthClient
 }
 
 // NewOAuthService creates a new OAuth service
-func NewOAuthService(proxyRepo ProxyRepository, oauthClient ClaudeOAuthClient) *OAuthService {
+func NewOAuthService(proxyRepo ProxyRepository, proxyPools *ProxyPoolService, oauthClient ClaudeOAuthClient) *OAuthService {
 	return &OAuthService{
 		sessionStore: oauth.NewSessionStore(),
 		proxyRepo:    proxyRepo,
+		proxyPools:   proxyPools,
 		oauthClient:  oauthClient,
 	}
 }
@@ -291,13 +293,7 @@ func (s *OAuthService) RefreshAccountToken(ctx context.Context, account *Account
 		return nil, fmt.Errorf("no refresh token available")
 	}
 
-	var proxyURL string
-	if account.ProxyID != nil {
-		proxy, err := s.proxyRepo.GetByID(ctx, *account.ProxyID)
-		if err == nil && proxy != nil {
-			proxyURL = proxy.URL()
-		}
-	}
+	proxyURL := resolveAccountProxyURL(ctx, s.proxyRepo, s.proxyPools, account)
 
 	return s.RefreshToken(ctx, refreshToken, proxyURL)
 }
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// openaiEmbeddingsAPIURL OpenAI Platform 嵌入接口（API Key 账号未配置 base_url 时使用）
+const openaiEmbeddingsAPIURL = "https://api.openai.com/v1/embeddings"
+
+// APIKeyOnlyExcludedAccountIDs 返回分组内非 API Key 类型的 OpenAI 账号（OAuth 账号只能访问 Codex Responses 接口），
+// 作为 /v1/embeddings、/v1/images/* 等 Platform 接口账号选择的初始排除集合。
+func (s *OpenAIGatewayService) APIKeyOnlyExcludedAccountIDs(ctx context.Context, groupID *int64) map[int64]struct{} {
+	excluded := make(map[int64]struct{})
+	accounts, err := s.listSchedulableAccounts(ctx, groupID)
+	if err != nil {
+		return excluded
+	}
+	for i := range accounts {
+		if accounts[i].Type != AccountTypeAPIKey {
+			excluded[accounts[i].ID] = struct{}{}
+		}
+	}
+	return excluded
+}
+
+// ForwardEmbeddings 转发 OpenAI /v1/embeddings 请求（仅 API Key 账号）。
+// 嵌入响应为非流式 JSON，用量取 usage.prompt_tokens，按嵌入模型价格计费。
+func (s *OpenAIGatewayService) ForwardEmbeddings(ctx context.Context, c *gin.Context, account *Account, body []byte) (*OpenAIForwardResult, error) {
+	startTime := time.Now()
+
+	if account.Type != AccountTypeAPIKey {
+		return nil, &UpstreamFailoverError{StatusCode: http.StatusBadRequest}
+	}
+
+	originalModel := gjson.GetBytes(body, "model").String()
+	mappedModel := account.GetMappedModel(originalModel)
+	if mappedModel != originalModel {
+		updated, err := sjson.SetBytes(body, "model", mappedModel)
+		if err != nil {
+			return nil, fmt.Errorf("apply model mapping: %w", err)
+		}
+		body = updated
+		log.Printf("[OpenAI] Embedding model mapping applied: %s -> %s (account: %s)", originalModel, mappedModel, account.Name)
+	}
+
+	token, _, err := s.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := openaiEmbeddingsAPIURL
+	if baseURL := strings.TrimSpace(account.GetCredential("base_url")); baseURL != "" {
+		validatedURL, err := s.validateUpstreamBaseURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		targetURL = strings.TrimRight(validatedURL, "/") + "/embeddings"
+	}
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	upstreamReq.Header.Set("authorization", "Bearer "+token)
+	for key, values := range c.Request.Header {
+		if openaiAllowedHeaders[strings.ToLower(key)] {
+			for _, v := range values {
+				upstreamReq.Header.Add(key, v)
+			}
+		}
+	}
+	if customUA := account.GetOpenAIUserAgent(); customUA != "" {
+		upstreamReq.Header.Set("user-agent", customUA)
+	}
+	upstreamReq.Header.Set("content-type", "application/json")
+
+	c.Set(OpsUpstreamRequestBodyKey, string(body))
+
+	resp, err := s.httpUpstream.Do(upstreamReq, account.UpstreamProxyURL(), account.ID, account.Concurrency)
+	if err != nil {
+		s.rateLimitService.RecordUpstreamRequestError(ctx, account.ID)
+		safeErr := sanitizeUpstreamErrorMessage(err.Error())
+		setOpsUpstreamError(c, 0, safeErr, "")
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: 0,
+			Kind:               "request_error",
+			Message:            safeErr,
+		})
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": gin.H{
+				"type":    "upstream_error",
+				"message": "Upstream request failed",
+			},
+		})
+		return nil, fmt.Errorf("upstream request failed: %s", safeErr)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		if s.shouldFailoverUpstreamError(resp.StatusCode) {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+			_ = resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+			upstreamMsg := sanitizeUpstreamErrorMessage(strings.TrimSpace(extractUpstreamErrorMessage(respBody)))
+			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+				Platform:           account.Platform,
+				AccountID:          account.ID,
+				AccountName:        account.Name,
+				UpstreamStatusCode: resp.StatusCode,
+				UpstreamRequestID:  resp.Header.Get("x-request-id"),
+				Kind:               "failover",
+				Message:            upstreamMsg,
+			})
+			s.handleFailoverSideEffects(ctx, resp, account)
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode}
+		}
+		return s.handleErrorResponse(ctx, resp, c, account)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read upstream response: %w", err)
+	}
+	if mappedModel != originalModel {
+		respBody = s.replaceModelInResponseBody(respBody, mappedModel, originalModel)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(resp.StatusCode, contentType, respBody)
+
+	return &OpenAIForwardResult{
+		RequestID: resp.Header.Get("x-request-id"),
+		Usage: OpenAIUsage{
+			InputTokens: int(gjson.GetBytes(respBody, "usage.prompt_tokens").Int()),
+		},
+		Model:     originalModel,
+		Duration:  time.Since(startTime),
+		Embedding: true,
+	}, nil
+}
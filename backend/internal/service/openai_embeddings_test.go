@@ -0,0 +1,93 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type embeddingUpstreamStub struct {
+	req      *http.Request
+	body     []byte
+	status   int
+	response string
+}
+
+func (s *embeddingUpstreamStub) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	s.req = req
+	s.body, _ = io.ReadAll(req.Body)
+	return &http.Response{
+		StatusCode: s.status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}, "X-Request-Id": []string{"req-emb"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(s.response))),
+	}, nil
+}
+
+func (s *embeddingUpstreamStub) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	return s.Do(req, proxyURL, accountID, accountConcurrency)
+}
+
+func TestOpenAIForwardEmbeddings_MapsModelAndReportsUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	upstream := &embeddingUpstreamStub{
+		status:   http.StatusOK,
+		response: `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]}],"model":"text-embedding-3-large","usage":{"prompt_tokens":12,"total_tokens":12}}`,
+	}
+	svc := &OpenAIGatewayService{cfg: &config.Config{}, httpUpstream: upstream}
+	account := &Account{
+		ID:       1,
+		Platform: PlatformOpenAI,
+		Type:     AccountTypeAPIKey,
+		Credentials: map[string]any{
+			"api_key":       "sk-test",
+			"model_mapping": map[string]any{"embed-large": "text-embedding-3-large"},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/embeddings", nil)
+
+	result, err := svc.ForwardEmbeddings(context.Background(), c, account, []byte(`{"model":"embed-large","input":"hello"}`))
+	require.NoError(t, err)
+	require.Equal(t, openaiEmbeddingsAPIURL, upstream.req.URL.String())
+	require.Equal(t, "Bearer sk-test", upstream.req.Header.Get("authorization"))
+	require.Equal(t, "text-embedding-3-large", gjson.GetBytes(upstream.body, "model").String())
+
+	require.True(t, result.Embedding)
+	require.Equal(t, "embed-large", result.Model)
+	require.Equal(t, 12, result.Usage.InputTokens)
+	require.Equal(t, "req-emb", result.RequestID)
+	require.Equal(t, "embed-large", gjson.Get(rec.Body.String(), "model").String(), "response model is mapped back")
+}
+
+func TestOpenAIForwardEmbeddings_OAuthAccountFailsOver(t *testing.T) {
+	svc := &OpenAIGatewayService{cfg: &config.Config{}}
+	account := &Account{ID: 2, Platform: PlatformOpenAI, Type: AccountTypeOAuth}
+
+	_, err := svc.ForwardEmbeddings(context.Background(), nil, account, []byte(`{"model":"text-embedding-3-small","input":"x"}`))
+	var failoverErr *UpstreamFailoverError
+	require.ErrorAs(t, err, &failoverErr)
+}
+
+func TestEstimateGeminiEmbeddingTokens(t *testing.T) {
+	single := []byte(`{"content":{"parts":[{"text":"abcdefgh"}]}}`)
+	require.Equal(t, 2, estimateGeminiEmbeddingTokens(single))
+
+	batch := []byte(`{"requests":[{"model":"models/text-embedding-004","content":{"parts":[{"text":"abcd"}]}},{"model":"models/text-embedding-004","content":{"parts":[{"text":"abcdefgh"}]}}]}`)
+	require.Equal(t, 3, estimateGeminiEmbeddingTokens(batch))
+
+	mapped := rewriteGeminiBatchEmbedModels(batch, "gemini-embedding-001")
+	require.Equal(t, "models/gemini-embedding-001", gjson.GetBytes(mapped, "requests.0.model").String())
+	require.Equal(t, "models/gemini-embedding-001", gjson.GetBytes(mapped, "requests.1.model").String())
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"sort"
@@ -162,6 +163,11 @@ type OpenAIForwardResult struct {
 	Stream       bool
 	Duration     time.Duration
 	FirstTokenMs *int
+	// Embedding marks /v1/embeddings requests (billed by input tokens at embedding prices)
+	Embedding bool
+	// ImageCount/ImageSize are set for /v1/images/* requests (billed per image by size tier)
+	ImageCount int
+	ImageSize  string
 }
 
 // OpenAIGatewayService handles OpenAI API gateway operations
@@ -181,6 +187,8 @@ type OpenAIGatewayService struct {
 	deferredService     *DeferredService
 	openAITokenProvider *OpenAITokenProvider
 	toolCorrector       *CodexToolCorrector
+	accountSelection    *AccountSelectionService
+	circuitBreaker      *CircuitBreakerService
 }
 
 // NewOpenAIGatewayService creates a new OpenAIGatewayService
@@ -199,6 +207,8 @@ func NewOpenAIGatewayService(
 	httpUpstream HTTPUpstream,
 	deferredService *DeferredService,
 	openAITokenProvider *OpenAITokenProvider,
+	accountSelection *AccountSelectionService,
+	circuitBreaker *CircuitBreakerService,
 ) *OpenAIGatewayService {
 	return &OpenAIGatewayService{
 		accountRepo:         accountRepo,
@@ -216,6 +226,8 @@ func NewOpenAIGatewayService(
 		deferredService:     deferredService,
 		openAITokenProvider: openAITokenProvider,
 		toolCorrector:       NewCodexToolCorrector(),
+		accountSelection:    accountSelection,
+		circuitBreaker:      circuitBreaker,
 	}
 }
 
@@ -428,6 +440,14 @@ func (s *OpenAIGatewayService) isBetterAccount(candidate, current *Account) bool
 
 // SelectAccountWithLoadAwareness selects an account with load-awareness and wait plan.
 func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+	return traceAccountSelection(ctx, groupID, requestedModel, excludedIDs, func(ctx context.Context) (*AccountSelectionResult, error) {
+		return s.circuitBreaker.SelectWithProbe(ctx, excludedIDs, func(excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+			return s.selectAccountWithLoadAwareness(ctx, groupID, sessionHash, requestedModel, excludedIDs)
+		})
+	})
+}
+
+func (s *OpenAIGatewayService) selectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
 	cfg := s.schedulingConfig()
 	var stickyAccountID int64
 	if sessionHash != "" && s.cache != nil {
@@ -559,16 +579,23 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 		})
 	}
 
+	// Per-group account selection strategy scores (lower is better, within the same priority)
+	selectionScores := s.accountSelection.ScoreForGroup(ctx, groupID, candidates)
+
 	loadMap, err := s.concurrencyService.GetAccountsLoadBatch(ctx, accountLoads)
 	if err != nil {
 		ordered := append([]*Account(nil), candidates...)
 		sortAccountsByPriorityAndLastUsed(ordered, false)
+		sortAccountsBySelectionScores(ordered, selectionScores)
 		for _, acc := range ordered {
 			result, err := s.tryAcquireAccountSlot(ctx, acc.ID, acc.Concurrency)
 			if err == nil && result.Acquired {
 				if sessionHash != "" {
 					_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, acc.ID, openaiStickySessionTTL)
 				}
+				if selectionScores != nil {
+					s.accountSelection.RecordSelection(ctx, groupID, candidates, acc)
+				}
 				return &AccountSelectionResult{
 					Account:     acc,
 					Acquired:    true,
@@ -601,6 +628,9 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 				if a.account.Priority != b.account.Priority {
 					return a.account.Priority < b.account.Priority
 				}
+				if selectionScores[a.account.ID] != selectionScores[b.account.ID] {
+					return selectionScores[a.account.ID] < selectionScores[b.account.ID]
+				}
 				if a.loadInfo.LoadRate != b.loadInfo.LoadRate {
 					return a.loadInfo.LoadRate < b.loadInfo.LoadRate
 				}
@@ -622,6 +652,9 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 					if sessionHash != "" {
 						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, item.account.ID, openaiStickySessionTTL)
 					}
+					if selectionScores != nil {
+						s.accountSelection.RecordSelection(ctx, groupID, candidates, item.account)
+					}
 					return &AccountSelectionResult{
 						Account:     item.account,
 						Acquired:    true,
@@ -634,6 +667,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 
 	// ============ Layer 3: Fallback wait ============
 	sortAccountsByPriorityAndLastUsed(candidates, false)
+	sortAccountsBySelectionScores(candidates, selectionScores)
 	for _, acc := range candidates {
 		return &AccountSelectionResult{
 			Account: acc,
@@ -652,7 +686,10 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 func (s *OpenAIGatewayService) listSchedulableAccounts(ctx context.Context, groupID *int64) ([]Account, error) {
 	if s.schedulerSnapshot != nil {
 		accounts, _, err := s.schedulerSnapshot.ListSchedulableAccounts(ctx, groupID, PlatformOpenAI, false)
-		return accounts, err
+		if err != nil {
+			return nil, err
+		}
+		return s.circuitBreaker.FilterAccounts(ctx, accounts), nil
 	}
 	var accounts []Account
 	var err error
@@ -666,7 +703,8 @@ func (s *OpenAIGatewayService) listSchedulableAccounts(ctx context.Context, grou
 	if err != nil {
 		return nil, fmt.Errorf("query accounts failed: %w", err)
 	}
-	return accounts, nil
+	// Skip accounts whose circuit breaker is open
+	return s.circuitBreaker.FilterAccounts(ctx, accounts), nil
 }
 
 func (s *OpenAIGatewayService) tryAcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int) (*AcquireResult, error) {
@@ -677,6 +715,9 @@ func (s *OpenAIGatewayService) tryAcquireAccountSlot(ctx context.Context, accoun
 }
 
 func (s *OpenAIGatewayService) getSchedulableAccount(ctx context.Context, accountID int64) (*Account, error) {
+	if !s.circuitBreaker.Allow(ctx, accountID) {
+		return nil, ErrAccountCircuitOpen
+	}
 	if s.schedulerSnapshot != nil {
 		return s.schedulerSnapshot.GetAccount(ctx, accountID)
 	}
@@ -866,10 +907,7 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 	}
 
 	// Get proxy URL
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.UpstreamProxyURL()
 
 	// Capture upstream request body for ops retry of this attempt.
 	if c != nil {
@@ -879,6 +917,7 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 	// Send request
 	resp, err := s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
 	if err != nil {
+		s.rateLimitService.RecordUpstreamRequestError(ctx, account.ID)
 		// Ensure the client receives an error response (handlers assume Forward writes on non-failover errors).
 		safeErr := sanitizeUpstreamErrorMessage(err.Error())
 		setOpsUpstreamError(c, 0, safeErr, "")
@@ -1063,14 +1102,9 @@ func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *ht
 	setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
 
 	if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
-		log.Printf(
-			"OpenAI upstream error %d (account=%d platform=%s type=%s): %s",
-			resp.StatusCode,
-			account.ID,
-			account.Platform,
-			account.Type,
-			truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes),
-		)
+		slog.WarnContext(ctx, "upstream_error_body",
+			"status", resp.StatusCode, "account_id", account.ID, "account_type", account.Type,
+			"body", truncateForLog(body, s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes))
 	}
 
 	// Check custom error codes
@@ -1167,6 +1201,9 @@ type openaiStreamingResult struct {
 }
 
 func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, startTime time.Time, originalModel, mappedModel string) (*openaiStreamingResult, error) {
+	span := startStreamRelaySpan(ctx, account, originalModel)
+	defer span.End()
+
 	if s.cfg != nil {
 		responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
 	}
@@ -1281,7 +1318,7 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 			}
 			if ev.err != nil {
 				if errors.Is(ev.err, bufio.ErrTooLong) {
-					log.Printf("SSE line too long: account=%d max_size=%d error=%v", account.ID, maxLineSize, ev.err)
+					slog.WarnContext(ctx, "sse_line_too_long", "account_id", account.ID, "max_size", maxLineSize, "error", ev.err)
 					sendErrorEvent("response_too_large")
 					return &openaiStreamingResult{usage: usage, firstTokenMs: firstTokenMs}, ev.err
 				}
@@ -1317,6 +1354,7 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 				if firstTokenMs == nil && data != "" && data != "[DONE]" {
 					ms := int(time.Since(startTime).Milliseconds())
 					firstTokenMs = &ms
+					markStreamFirstToken(span, ms)
 				}
 				s.parseSSEUsage(data, usage)
 			} else {
@@ -1333,7 +1371,7 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 			if time.Since(lastRead) < streamInterval {
 				continue
 			}
-			log.Printf("Stream data interval timeout: account=%d model=%s interval=%s", account.ID, originalModel, streamInterval)
+			slog.WarnContext(ctx, "stream_data_interval_timeout", "account_id", account.ID, "model", originalModel, "interval", streamInterval)
 			// 处理流超时，可能标记账户为临时不可调度或错误状态
 			if s.rateLimitService != nil {
 				s.rateLimitService.HandleStreamTimeout(ctx, account, originalModel)
@@ -1588,6 +1626,9 @@ func (s *OpenAIGatewayService) replaceModelInSSEBody(body, fromModel, toModel st
 }
 
 func (s *OpenAIGatewayService) validateUpstreamBaseURL(raw string) (string, error) {
+	if isMockUpstreamBaseURL(s.cfg, raw) {
+		return strings.TrimRight(strings.TrimSpace(raw), "/"), nil
+	}
 	if s.cfg != nil && !s.cfg.Security.URLAllowlist.Enabled {
 		normalized, err := urlvalidator.ValidateURLFormat(raw, s.cfg.Security.URLAllowlist.AllowInsecureHTTP)
 		if err != nil {
@@ -1633,17 +1674,26 @@ type OpenAIRecordUsageInput struct {
 	User         *User
 	Account      *Account
 	Subscription *UserSubscription
-	UserAgent    string // 请求的 User-Agent
-	IPAddress    string // 请求的客户端 IP 地址
+	UserAgent    string       // 请求的 User-Agent
+	IPAddress    string       // 请求的客户端 IP 地址
+	BillingHold  *BillingHold // 可选：转发前的预授权冻结，按实际费用结算
 }
 
 // RecordUsage records usage and deducts balance
 func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRecordUsageInput) error {
+	return traceRecordUsage(ctx, input.Account, func(ctx context.Context) error {
+		return s.recordUsage(ctx, input)
+	})
+}
+
+func (s *OpenAIGatewayService) recordUsage(ctx context.Context, input *OpenAIRecordUsageInput) error {
 	result := input.Result
 	apiKey := input.APIKey
 	user := input.User
 	account := input.Account
 	subscription := input.Subscription
+	// Release any unsettled hold (simple mode, not billed, ...)
+	defer input.BillingHold.Release()
 
 	// 计算实际的新输入token（减去缓存读取的token）
 	// 因为 input_tokens 包含了 cache_read_tokens，而缓存读取的token不应按输入价格计费
@@ -1666,7 +1716,26 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		multiplier = apiKey.Group.RateMultiplier
 	}
 
-	cost, err := s.billingService.CalculateCost(result.Model, tokens, multiplier)
+	var cost *CostBreakdown
+	var err error
+	if result.ImageCount > 0 {
+		var groupConfig *ImagePriceConfig
+		if apiKey.Group != nil {
+			groupConfig = &ImagePriceConfig{
+				Price1K: apiKey.Group.ImagePrice1K,
+				Price2K: apiKey.Group.ImagePrice2K,
+				Price4K: apiKey.Group.ImagePrice4K,
+			}
+		}
+		cost = s.billingService.CalculateImageCost(result.Model, result.ImageSize, result.ImageCount, groupConfig, multiplier)
+	} else if result.Embedding {
+		cost, err = s.billingService.CalculateEmbeddingCost(result.Model, tokens.InputTokens, multiplier)
+		if err != nil {
+			slog.ErrorContext(ctx, "calculate_embedding_cost_failed", "error", err)
+		}
+	} else {
+		cost, err = s.billingService.CalculateCost(result.Model, tokens, multiplier)
+	}
 	if err != nil {
 		cost = &CostBreakdown{ActualCost: 0}
 	}
@@ -1703,8 +1772,12 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		Stream:                result.Stream,
 		DurationMs:            &durationMs,
 		FirstTokenMs:          result.FirstTokenMs,
+		ImageCount:            result.ImageCount,
 		CreatedAt:             time.Now(),
 	}
+	if result.ImageSize != "" {
+		usageLog.ImageSize = &result.ImageSize
+	}
 
 	// 添加 UserAgent
 	if input.UserAgent != "" {
@@ -1715,6 +1788,7 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	if input.IPAddress != "" {
 		usageLog.IPAddress = &input.IPAddress
 	}
+	usageLog.TraceID = traceIDPtr(ctx)
 
 	if apiKey.GroupID != nil {
 		usageLog.GroupID = apiKey.GroupID
@@ -1724,6 +1798,7 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	}
 
 	inserted, err := s.usageLogRepo.Create(ctx, usageLog)
+	s.rateLimitService.RecordUpstreamSuccess(ctx, account.ID, forwardLatency(result.Stream, result.Duration, result.FirstTokenMs))
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
 		log.Printf("[SIMPLE MODE] Usage recorded (not billed): user=%d, tokens=%d", usageLog.UserID, usageLog.TotalTokens())
 		s.deferredService.ScheduleLastUsedUpdate(account.ID)
@@ -1736,12 +1811,19 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	if isSubscriptionBilling {
 		if shouldBill && cost.TotalCost > 0 {
 			_ = s.userSubRepo.IncrementUsage(ctx, subscription.ID, cost.TotalCost)
-			s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
+			if !s.billingCacheService.SettleBillingHold(ctx, input.BillingHold, cost.TotalCost) {
+				s.billingCacheService.QueueUpdateSubscriptionUsage(user.ID, *apiKey.GroupID, cost.TotalCost)
+			}
+		}
+		if shouldBill {
+			s.billingCacheService.RecordSubscriptionUsageCounters(ctx, subscription, apiKey.Group, result.Model, tokens)
 		}
 	} else {
 		if shouldBill && cost.ActualCost > 0 {
 			_ = s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost)
-			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+			if !s.billingCacheService.SettleBillingHold(ctx, input.BillingHold, cost.ActualCost) {
+				s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+			}
 		}
 	}
 
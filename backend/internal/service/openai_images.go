@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// OpenAI Platform 图片接口（API Key 账号未配置 base_url 时使用）
+const (
+	openaiImagesGenerationsURL = "https://api.openai.com/v1/images/generations"
+	openaiImagesEditsURL       = "https://api.openai.com/v1/images/edits"
+)
+
+// ForwardImages 转发 OpenAI /v1/images/generations 与 /v1/images/edits 请求（仅 API Key 账号）。
+// edits 请求体为 multipart/form-data，需要模型映射时重建表单；按响应 data 数量与请求 size 档位计费。
+func (s *OpenAIGatewayService) ForwardImages(ctx context.Context, c *gin.Context, account *Account, req *ImageGenerationRequest) (*OpenAIForwardResult, error) {
+	startTime := time.Now()
+
+	if account.Type != AccountTypeAPIKey {
+		return nil, &UpstreamFailoverError{StatusCode: http.StatusBadRequest}
+	}
+
+	body := req.Body
+	contentType := req.ContentType
+	originalModel := req.Model
+	mappedModel := account.GetMappedModel(originalModel)
+	if mappedModel != originalModel {
+		var err error
+		body, contentType, err = rewriteImageRequestField(req, "model", mappedModel)
+		if err != nil {
+			return nil, fmt.Errorf("apply model mapping: %w", err)
+		}
+		log.Printf("[OpenAI] Image model mapping applied: %s -> %s (account: %s)", originalModel, mappedModel, account.Name)
+	}
+
+	token, _, err := s.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := openaiImagesGenerationsURL
+	endpoint := "/images/generations"
+	if req.Edit {
+		targetURL = openaiImagesEditsURL
+		endpoint = "/images/edits"
+	}
+	if baseURL := strings.TrimSpace(account.GetCredential("base_url")); baseURL != "" {
+		validatedURL, err := s.validateUpstreamBaseURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		targetURL = strings.TrimRight(validatedURL, "/") + endpoint
+	}
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	upstreamReq.Header.Set("authorization", "Bearer "+token)
+	for key, values := range c.Request.Header {
+		if openaiAllowedHeaders[strings.ToLower(key)] {
+			for _, v := range values {
+				upstreamReq.Header.Add(key, v)
+			}
+		}
+	}
+	if customUA := account.GetOpenAIUserAgent(); customUA != "" {
+		upstreamReq.Header.Set("user-agent", customUA)
+	}
+	upstreamReq.Header.Set("content-type", contentType)
+
+	// multipart 请求体包含原始图片，仅在 JSON 请求时记录请求体
+	if !req.Multipart {
+		c.Set(OpsUpstreamRequestBodyKey, string(body))
+	}
+
+	resp, err := s.httpUpstream.Do(upstreamReq, account.UpstreamProxyURL(), account.ID, account.Concurrency)
+	if err != nil {
+		s.rateLimitService.RecordUpstreamRequestError(ctx, account.ID)
+		safeErr := sanitizeUpstreamErrorMessage(err.Error())
+		setOpsUpstreamError(c, 0, safeErr, "")
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: 0,
+			Kind:               "request_error",
+			Message:            safeErr,
+		})
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": gin.H{
+				"type":    "upstream_error",
+				"message": "Upstream request failed",
+			},
+		})
+		return nil, fmt.Errorf("upstream request failed: %s", safeErr)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		if s.shouldFailoverUpstreamError(resp.StatusCode) {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+			_ = resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+			upstreamMsg := sanitizeUpstreamErrorMessage(strings.TrimSpace(extractUpstreamErrorMessage(respBody)))
+			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+				Platform:           account.Platform,
+				AccountID:          account.ID,
+				AccountName:        account.Name,
+				UpstreamStatusCode: resp.StatusCode,
+				UpstreamRequestID:  resp.Header.Get("x-request-id"),
+				Kind:               "failover",
+				Message:            upstreamMsg,
+			})
+			s.handleFailoverSideEffects(ctx, resp, account)
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode}
+		}
+		return s.handleErrorResponse(ctx, resp, c, account)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read upstream response: %w", err)
+	}
+
+	respContentType := resp.Header.Get("Content-Type")
+	if respContentType == "" {
+		respContentType = "application/json"
+	}
+	c.Data(resp.StatusCode, respContentType, respBody)
+
+	return &OpenAIForwardResult{
+		RequestID: resp.Header.Get("x-request-id"),
+		Usage: OpenAIUsage{
+			InputTokens:  int(gjson.GetBytes(respBody, "usage.input_tokens").Int()),
+			OutputTokens: int(gjson.GetBytes(respBody, "usage.output_tokens").Int()),
+		},
+		Model:      originalModel,
+		Duration:   time.Since(startTime),
+		ImageCount: int(gjson.GetBytes(respBody, "data.#").Int()),
+		ImageSize:  req.SizeTier(),
+	}, nil
+}
+
+// rewriteImageRequestField 替换请求中的文本字段（如 model、prompt），返回新的请求体与 Content-Type
+func rewriteImageRequestField(req *ImageGenerationRequest, field, value string) ([]byte, string, error) {
+	if !req.Multipart {
+		body, err := sjson.SetBytes(req.Body, field, value)
+		if err != nil {
+			return nil, "", err
+		}
+		return body, req.ContentType, nil
+	}
+
+	_, params, err := mime.ParseMediaType(req.ContentType)
+	if err != nil {
+		return nil, "", err
+	}
+	reader := multipart.NewReader(bytes.NewReader(req.Body), params["boundary"])
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	written := false
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() == field && part.FileName() == "" {
+			if err := writer.WriteField(field, value); err != nil {
+				return nil, "", err
+			}
+			written = true
+			continue
+		}
+		dst, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := io.Copy(dst, part); err != nil {
+			return nil, "", err
+		}
+	}
+	if !written {
+		if err := writer.WriteField(field, value); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// imageSizeTier 将 OpenAI 风格的 size（如 "1024x1024"、"1536x1024"）或 Gemini 档位（"1K"/"2K"/"4K"）
+// 归一化为计费档位：最长边 <= 1024 为 1K，<= 2048 为 2K，其余为 4K。无法识别时返回 defaultTier。
+func imageSizeTier(size string, defaultTier string) string {
+	size = strings.TrimSpace(size)
+	switch strings.ToUpper(size) {
+	case "1K", "2K", "4K":
+		return strings.ToUpper(size)
+	}
+	width, height, ok := parseImageDimensions(size)
+	if !ok {
+		return defaultTier
+	}
+	longest := width
+	if height > longest {
+		longest = height
+	}
+	switch {
+	case longest <= 1024:
+		return "1K"
+	case longest <= 2048:
+		return "2K"
+	default:
+		return "4K"
+	}
+}
+
+// parseImageDimensions 解析 "WIDTHxHEIGHT" 格式的尺寸
+func parseImageDimensions(size string) (int, int, bool) {
+	w, h, found := strings.Cut(strings.ToLower(size), "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, err := strconv.Atoi(strings.TrimSpace(w))
+	if err != nil || width <= 0 {
+		return 0, 0, false
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(h))
+	if err != nil || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
@@ -13,14 +13,16 @@ import (
 type OpenAIOAuthService struct {
 	sessionStore *openai.SessionStore
 	proxyRepo    ProxyRepository
+	proxyPools   *ProxyPoolService
 	oauthClient  OpenAIOAuthClient
 }
 
 // NewOpenAIOAuthService creates a new OpenAI OAuth service
-func NewOpenAIOAuthService(proxyRepo ProxyRepository, oauthClient OpenAIOAuthClient) *OpenAIOAuthService {
+func NewOpenAIOAuthService(proxyRepo ProxyRepository, proxyPools *ProxyPoolService, oauthClient OpenAIOAuthClient) *OpenAIOAuthService {
 	return &OpenAIOAuthService{
 		sessionStore: openai.NewSessionStore(),
 		proxyRepo:    proxyRepo,
+		proxyPools:   proxyPools,
 		oauthClient:  oauthClient,
 	}
 }
@@ -216,13 +218,7 @@ func (s *OpenAIOAuthService) RefreshAccountToken(ctx context.Context, account *A
 		return nil, infraerrors.New(http.StatusBadRequest, "OPENAI_OAUTH_NO_REFRESH_TOKEN", "no refresh token available")
 	}
 
-	var proxyURL string
-	if account.ProxyID != nil {
-		proxy, err := s.proxyRepo.GetByID(ctx, *account.ProxyID)
-		if err == nil && proxy != nil {
-			proxyURL = proxy.URL()
-		}
-	}
+	proxyURL := resolveAccountProxyURL(ctx, s.proxyRepo, s.proxyPools, account)
 
 	return s.RefreshToken(ctx, refreshToken, proxyURL)
 }
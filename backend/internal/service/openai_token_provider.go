@@ -38,6 +38,15 @@ func NewOpenAITokenProvider(
 
 // GetAccessToken 获取有效的 access_token
 func (p *OpenAITokenProvider) GetAccessToken(ctx context.Context, account *Account) (string, error) {
+	if token, ok := dryRunAccessToken(ctx, account); ok {
+		return token, nil
+	}
+	return traceGetAccessToken(ctx, account, func(ctx context.Context) (string, error) {
+		return p.getAccessToken(ctx, account)
+	})
+}
+
+func (p *OpenAITokenProvider) getAccessToken(ctx context.Context, account *Account) (string, error) {
 	if account == nil {
 		return "", errors.New("account is nil")
 	}
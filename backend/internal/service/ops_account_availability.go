@@ -41,6 +41,16 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 	now := time.Now()
 	collectedAt := now
 
+	accountIDs := make([]int64, 0, len(accounts))
+	for _, acc := range accounts {
+		accountIDs = append(accountIDs, acc.ID)
+	}
+	circuitStates, err := s.circuitBreaker.GetStates(ctx, accountIDs)
+	if err != nil {
+		// 熔断状态仅用于展示，查询失败时忽略
+		circuitStates = nil
+	}
+
 	platform := make(map[string]*PlatformAvailability)
 	group := make(map[int64]*GroupAvailability)
 	account := make(map[int64]*AccountAvailability)
@@ -65,7 +75,12 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 			isOverloaded = false
 		}
 
-		isAvailable := acc.Status == StatusActive && acc.Schedulable && !isRateLimited && !isOverloaded && !isTempUnsched
+		circuitState := circuitStates[acc.ID]
+		// 半开状态仍可放行探测请求，仅 open 且未到期视为熔断中
+		isCircuitOpen := circuitState != nil && circuitState.State == CircuitStateOpen &&
+			circuitState.OpenUntil != nil && now.Before(*circuitState.OpenUntil)
+
+		isAvailable := acc.Status == StatusActive && acc.Schedulable && !isRateLimited && !isOverloaded && !isTempUnsched && !isCircuitOpen
 
 		if acc.Platform != "" {
 			if _, ok := platform[acc.Platform]; !ok {
@@ -84,6 +99,9 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 			if hasError {
 				p.ErrorCount++
 			}
+			if isCircuitOpen {
+				p.CircuitOpenCount++
+			}
 		}
 
 		for _, grp := range acc.Groups {
@@ -108,6 +126,9 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 			if hasError {
 				g.ErrorCount++
 			}
+			if isCircuitOpen {
+				g.CircuitOpenCount++
+			}
 		}
 
 		displayGroupID := int64(0)
@@ -131,6 +152,9 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 			HasError:      hasError,
 
 			ErrorMessage: acc.ErrorMessage,
+
+			IsCircuitOpen:  isCircuitOpen,
+			CircuitBreaker: circuitState,
 		}
 
 		if isRateLimited && acc.RateLimitResetAt != nil {
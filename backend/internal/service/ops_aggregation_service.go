@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
-	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -84,7 +83,7 @@ func NewOpsAggregationService(
 		cfg:         cfg,
 		db:          db,
 		redisClient: redisClient,
-		instanceID:  uuid.NewString(),
+		instanceID:  processInstanceID,
 	}
 }
 
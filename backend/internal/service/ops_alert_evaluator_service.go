@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
-	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -35,6 +34,8 @@ type OpsAlertEvaluatorService struct {
 	opsService   *OpsService
 	opsRepo      OpsRepository
 	emailService *EmailService
+	spendService *SpendAnomalyService
+	capacity     *CapacityForecastService
 
 	redisClient *redis.Client
 	cfg         *config.Config
@@ -45,8 +46,9 @@ type OpsAlertEvaluatorService struct {
 	stopOnce  sync.Once
 	wg        sync.WaitGroup
 
-	mu         sync.Mutex
-	ruleStates map[int64]*opsAlertRuleState
+	mu           sync.Mutex
+	ruleStates   map[int64]*opsAlertRuleState
+	seriesStates map[opsAlertSeriesStateKey]*opsAlertRuleState
 
 	emailLimiter *slidingWindowLimiter
 
@@ -74,12 +76,23 @@ func NewOpsAlertEvaluatorService(
 		emailService: emailService,
 		redisClient:  redisClient,
 		cfg:          cfg,
-		instanceID:   uuid.NewString(),
+		instanceID:   processInstanceID,
 		ruleStates:   map[int64]*opsAlertRuleState{},
+		seriesStates: map[opsAlertSeriesStateKey]*opsAlertRuleState{},
 		emailLimiter: newSlidingWindowLimiter(0, time.Hour),
 	}
 }
 
+// SetSpendAnomalyService enables spend metric types (spend_usd, max_user_spend_usd, ...).
+func (s *OpsAlertEvaluatorService) SetSpendAnomalyService(spendService *SpendAnomalyService) {
+	s.spendService = spendService
+}
+
+// SetCapacityForecastService enables capacity metric types (group_hours_to_exhaustion, group_recommended_accounts).
+func (s *OpsAlertEvaluatorService) SetCapacityForecastService(capacity *CapacityForecastService) {
+	s.capacity = capacity
+}
+
 func (s *OpsAlertEvaluatorService) Start() {
 	if s == nil {
 		return
@@ -214,6 +227,17 @@ func (s *OpsAlertEvaluatorService) evaluateOnce(interval time.Duration) {
 		}
 		rulesEnabled++
 
+		if strings.TrimSpace(rule.Expression) != "" {
+			res, ok := s.evaluateExpressionRule(ctx, runtimeCfg, rule, interval, safeEnd, now)
+			if ok {
+				rulesEvaluated++
+			}
+			eventsCreated += res.created
+			eventsResolved += res.resolved
+			emailsSent += res.emailsSent
+			continue
+		}
+
 		scopePlatform, scopeGroupID, scopeRegion := parseOpsAlertRuleScope(rule.Filters)
 
 		windowMinutes := rule.WindowMinutes
@@ -326,6 +350,11 @@ func (s *OpsAlertEvaluatorService) pruneRuleStates(rules []*OpsAlertRule) {
 			delete(s.ruleStates, id)
 		}
 	}
+	for key := range s.seriesStates {
+		if _, ok := live[key.RuleID]; !ok {
+			delete(s.seriesStates, key)
+		}
+	}
 }
 
 func (s *OpsAlertEvaluatorService) resetRuleState(ruleID int64, now time.Time) {
@@ -506,6 +535,16 @@ func (s *OpsAlertEvaluatorService) computeRuleMetric(
 		return float64(countAccountsByCondition(availability.Accounts, func(acc *AccountAvailability) bool {
 			return acc.HasError && acc.TempUnschedulableUntil == nil
 		})), true
+	case OpsMetricSpendUSD, OpsMetricMaxUserSpendUSD, OpsMetricMaxAPIKeySpendUSD, OpsMetricSpendAnomalyPendingCount:
+		if s == nil || s.spendService == nil {
+			return 0, false
+		}
+		return s.spendService.ComputeOpsMetric(ctx, strings.TrimSpace(rule.MetricType), start, end, groupID)
+	case OpsMetricGroupHoursToExhaustion, OpsMetricGroupRecommendedAccounts:
+		if s == nil || s.capacity == nil {
+			return 0, false
+		}
+		return s.capacity.ComputeOpsMetric(ctx, strings.TrimSpace(rule.MetricType), groupID)
 	}
 
 	overview, err := s.opsRepo.GetDashboardOverview(ctx, &OpsDashboardFilter{
@@ -662,6 +701,11 @@ func buildOpsAlertEmailBody(rule *OpsAlertRule, event *OpsAlertEvent) string {
 		return ""
 	}
 	metric := strings.TrimSpace(rule.MetricType)
+	operator := rule.Operator
+	if expr := strings.TrimSpace(rule.Expression); expr != "" {
+		metric = expr
+		operator = "="
+	}
 	value := "-"
 	threshold := fmt.Sprintf("%.2f", rule.Threshold)
 	if event.MetricValue != nil {
@@ -683,7 +727,7 @@ func buildOpsAlertEmailBody(rule *OpsAlertRule, event *OpsAlertEvent) string {
 		htmlEscape(rule.Severity),
 		htmlEscape(event.Status),
 		htmlEscape(metric),
-		htmlEscape(rule.Operator),
+		htmlEscape(operator),
 		htmlEscape(fmt.Sprintf("%s (threshold %s)", value, threshold)),
 		event.FiredAt.Format(time.RFC3339),
 		htmlEscape(event.Description),
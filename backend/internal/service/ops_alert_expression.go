@@ -0,0 +1,528 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ops alert rule expressions.
+//
+// A rule may carry an expression instead of a fixed metric_type/operator/threshold:
+//
+//	p95(ttft_ms{platform="anthropic", model=~"claude-opus.*"}) by (account_id) > 8000
+//	error_rate{group_id="3"} by (model) >= 5
+//	rate(requests) < 0.5
+//	max(cpu_usage_percent) > 90
+//
+// Grammar (whitespace-insensitive):
+//
+//	expr     := [aggregation "("] metric [selector] [")"] [selector] ["by" "(" label {"," label} ")"] cmp number
+//	selector := "{" matcher {"," matcher} "}"
+//	matcher  := label ("=" | "!=" | "=~" | "!~") string
+//	cmp      := ">" | ">=" | "<" | "<=" | "==" | "!="
+//
+// Request metrics are evaluated against usage_logs + ops_error_logs (the same
+// rows as the request details view); system metrics against ops_system_metrics.
+// With "by (...)" every label combination is a separate series that fires and
+// resolves on its own.
+
+const OpsAlertMetricTypeExpression = "expression"
+
+const (
+	OpsAlertExprSourceRequests = "requests"
+	OpsAlertExprSourceSystem   = "system"
+)
+
+// Aggregations.
+const (
+	OpsAlertAggCount = "count"
+	OpsAlertAggRate  = "rate"
+	OpsAlertAggRatio = "ratio"
+	OpsAlertAggSum   = "sum"
+	OpsAlertAggAvg   = "avg"
+	OpsAlertAggMin   = "min"
+	OpsAlertAggMax   = "max"
+	OpsAlertAggP50   = "p50"
+	OpsAlertAggP90   = "p90"
+	OpsAlertAggP95   = "p95"
+	OpsAlertAggP99   = "p99"
+)
+
+// Label matcher operators.
+const (
+	OpsAlertMatchEqual    = "="
+	OpsAlertMatchNotEqual = "!="
+	OpsAlertMatchRegex    = "=~"
+	OpsAlertMatchNotRegex = "!~"
+)
+
+const (
+	opsAlertExprMaxLength   = 1024
+	opsAlertExprMaxMatchers = 16
+	opsAlertExprMaxGroupBy  = 4
+)
+
+type opsAlertExprMetricDef struct {
+	Source       string
+	Aggregations []string
+	// DefaultAggregation is used when the metric is written without a function.
+	// Empty means an explicit aggregation is required.
+	DefaultAggregation string
+}
+
+var opsAlertValueAggregations = []string{
+	OpsAlertAggSum, OpsAlertAggAvg, OpsAlertAggMin, OpsAlertAggMax,
+	OpsAlertAggP50, OpsAlertAggP90, OpsAlertAggP95, OpsAlertAggP99,
+}
+
+var opsAlertExprMetrics = map[string]opsAlertExprMetricDef{
+	// Request metrics (per request row).
+	"requests":    {Source: OpsAlertExprSourceRequests, Aggregations: []string{OpsAlertAggCount, OpsAlertAggRate}, DefaultAggregation: OpsAlertAggCount},
+	"errors":      {Source: OpsAlertExprSourceRequests, Aggregations: []string{OpsAlertAggCount, OpsAlertAggRate}, DefaultAggregation: OpsAlertAggCount},
+	"error_rate":  {Source: OpsAlertExprSourceRequests, Aggregations: []string{OpsAlertAggRatio}, DefaultAggregation: OpsAlertAggRatio},
+	"duration_ms": {Source: OpsAlertExprSourceRequests, Aggregations: opsAlertValueAggregations},
+	"ttft_ms":     {Source: OpsAlertExprSourceRequests, Aggregations: opsAlertValueAggregations},
+	"tokens":      {Source: OpsAlertExprSourceRequests, Aggregations: opsAlertValueAggregations},
+	"cost_usd":    {Source: OpsAlertExprSourceRequests, Aggregations: opsAlertValueAggregations},
+
+	// System metrics (collector snapshots, no labels).
+	"cpu_usage_percent":       {Source: OpsAlertExprSourceSystem, Aggregations: []string{OpsAlertAggAvg, OpsAlertAggMin, OpsAlertAggMax}, DefaultAggregation: OpsAlertAggAvg},
+	"memory_usage_percent":    {Source: OpsAlertExprSourceSystem, Aggregations: []string{OpsAlertAggAvg, OpsAlertAggMin, OpsAlertAggMax}, DefaultAggregation: OpsAlertAggAvg},
+	"concurrency_queue_depth": {Source: OpsAlertExprSourceSystem, Aggregations: []string{OpsAlertAggAvg, OpsAlertAggMin, OpsAlertAggMax}, DefaultAggregation: OpsAlertAggAvg},
+	"goroutine_count":         {Source: OpsAlertExprSourceSystem, Aggregations: []string{OpsAlertAggAvg, OpsAlertAggMin, OpsAlertAggMax}, DefaultAggregation: OpsAlertAggAvg},
+	"db_conn_waiting":         {Source: OpsAlertExprSourceSystem, Aggregations: []string{OpsAlertAggAvg, OpsAlertAggMin, OpsAlertAggMax}, DefaultAggregation: OpsAlertAggAvg},
+}
+
+// OpsAlertExprLabels are the labels usable in selectors and "by (...)".
+var OpsAlertExprLabels = []string{"platform", "group_id", "account_id", "model", "proxy_id"}
+
+var opsAlertExprLabelAliases = map[string]string{
+	"group":   "group_id",
+	"account": "account_id",
+	"proxy":   "proxy_id",
+}
+
+type OpsAlertLabelMatcher struct {
+	Label string `json:"label"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// OpsAlertExpression is a parsed rule expression.
+type OpsAlertExpression struct {
+	Aggregation string                 `json:"aggregation"`
+	Metric      string                 `json:"metric"`
+	Matchers    []OpsAlertLabelMatcher `json:"matchers,omitempty"`
+	GroupBy     []string               `json:"group_by,omitempty"`
+	Operator    string                 `json:"operator"`
+	Threshold   float64                `json:"threshold"`
+}
+
+// Source returns which table family the metric is evaluated against.
+func (e *OpsAlertExpression) Source() string {
+	if e == nil {
+		return ""
+	}
+	return opsAlertExprMetrics[e.Metric].Source
+}
+
+// String renders the expression in canonical form.
+func (e *OpsAlertExpression) String() string {
+	if e == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(e.Aggregation)
+	b.WriteString("(")
+	b.WriteString(e.Metric)
+	if len(e.Matchers) > 0 {
+		parts := make([]string, 0, len(e.Matchers))
+		for _, m := range e.Matchers {
+			parts = append(parts, m.Label+m.Op+strconv.Quote(m.Value))
+		}
+		b.WriteString("{")
+		b.WriteString(strings.Join(parts, ", "))
+		b.WriteString("}")
+	}
+	b.WriteString(")")
+	if len(e.GroupBy) > 0 {
+		b.WriteString(" by (")
+		b.WriteString(strings.Join(e.GroupBy, ", "))
+		b.WriteString(")")
+	}
+	b.WriteString(" ")
+	b.WriteString(e.Operator)
+	b.WriteString(" ")
+	b.WriteString(strconv.FormatFloat(e.Threshold, 'f', -1, 64))
+	return b.String()
+}
+
+// OpsAlertSeriesPoint is one evaluated value of an expression for a label set
+// within a bucket [BucketStart, BucketStart+step).
+type OpsAlertSeriesPoint struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	BucketStart time.Time         `json:"bucket_start"`
+	Value       float64           `json:"value"`
+}
+
+// OpsAlertSeriesKey builds a stable identity for a label set ("" for the ungrouped series).
+func OpsAlertSeriesKey(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+labels[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseOpsAlertExpression parses and validates a rule expression.
+func ParseOpsAlertExpression(input string) (*OpsAlertExpression, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	if len(input) > opsAlertExprMaxLength {
+		return nil, fmt.Errorf("expression is too long (max %d characters)", opsAlertExprMaxLength)
+	}
+
+	p := &opsAlertExprParser{src: input}
+	expr, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if err := expr.validate(); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+func (e *OpsAlertExpression) validate() error {
+	def, ok := opsAlertExprMetrics[e.Metric]
+	if !ok {
+		return fmt.Errorf("unknown metric %q (supported: %s)", e.Metric, strings.Join(opsAlertExprMetricNames(), ", "))
+	}
+
+	if e.Aggregation == "" {
+		if def.DefaultAggregation == "" {
+			return fmt.Errorf("metric %s requires an aggregation (one of: %s)", e.Metric, strings.Join(def.Aggregations, ", "))
+		}
+		e.Aggregation = def.DefaultAggregation
+	}
+	if !slices.Contains(def.Aggregations, e.Aggregation) {
+		return fmt.Errorf("aggregation %s is not supported for metric %s (one of: %s)", e.Aggregation, e.Metric, strings.Join(def.Aggregations, ", "))
+	}
+
+	if def.Source == OpsAlertExprSourceSystem && (len(e.Matchers) > 0 || len(e.GroupBy) > 0) {
+		return fmt.Errorf("system metric %s does not support label filters or group-by", e.Metric)
+	}
+
+	if len(e.Matchers) > opsAlertExprMaxMatchers {
+		return fmt.Errorf("too many label matchers (max %d)", opsAlertExprMaxMatchers)
+	}
+	for i := range e.Matchers {
+		m := &e.Matchers[i]
+		label, err := normalizeOpsAlertExprLabel(m.Label)
+		if err != nil {
+			return err
+		}
+		m.Label = label
+		if m.Op == OpsAlertMatchRegex || m.Op == OpsAlertMatchNotRegex {
+			if _, err := regexp.Compile(m.Value); err != nil {
+				return fmt.Errorf("invalid regex for label %s: %v", m.Label, err)
+			}
+		}
+	}
+
+	if len(e.GroupBy) > opsAlertExprMaxGroupBy {
+		return fmt.Errorf("too many group-by labels (max %d)", opsAlertExprMaxGroupBy)
+	}
+	seen := make(map[string]struct{}, len(e.GroupBy))
+	for i, raw := range e.GroupBy {
+		label, err := normalizeOpsAlertExprLabel(raw)
+		if err != nil {
+			return err
+		}
+		if _, dup := seen[label]; dup {
+			return fmt.Errorf("duplicate group-by label %s", label)
+		}
+		seen[label] = struct{}{}
+		e.GroupBy[i] = label
+	}
+
+	switch e.Operator {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return fmt.Errorf("invalid comparison operator %q", e.Operator)
+	}
+	if math.IsNaN(e.Threshold) || math.IsInf(e.Threshold, 0) {
+		return fmt.Errorf("threshold must be a finite number")
+	}
+	return nil
+}
+
+func normalizeOpsAlertExprLabel(label string) (string, error) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if alias, ok := opsAlertExprLabelAliases[label]; ok {
+		label = alias
+	}
+	if !slices.Contains(OpsAlertExprLabels, label) {
+		return "", fmt.Errorf("unknown label %q (supported: %s)", label, strings.Join(OpsAlertExprLabels, ", "))
+	}
+	return label, nil
+}
+
+func opsAlertExprMetricNames() []string {
+	names := make([]string, 0, len(opsAlertExprMetrics))
+	for name := range opsAlertExprMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type opsAlertExprParser struct {
+	src string
+	pos int
+}
+
+func (p *opsAlertExprParser) parse() (*OpsAlertExpression, error) {
+	expr := &OpsAlertExpression{}
+
+	first := p.ident()
+	if first == "" {
+		return nil, p.errorf("expected metric or aggregation")
+	}
+
+	if p.consume("(") {
+		expr.Aggregation = strings.ToLower(first)
+		expr.Metric = strings.ToLower(p.ident())
+		if expr.Metric == "" {
+			return nil, p.errorf("expected metric name")
+		}
+		if p.peek("{") {
+			if err := p.selector(expr); err != nil {
+				return nil, err
+			}
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("expected ')'")
+		}
+	} else {
+		expr.Metric = strings.ToLower(first)
+	}
+
+	if p.peek("{") {
+		if err := p.selector(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.keyword("by") {
+		if !p.consume("(") {
+			return nil, p.errorf("expected '(' after by")
+		}
+		for {
+			label := p.ident()
+			if label == "" {
+				return nil, p.errorf("expected label name")
+			}
+			expr.GroupBy = append(expr.GroupBy, label)
+			if p.consume(",") {
+				continue
+			}
+			if p.consume(")") {
+				break
+			}
+			return nil, p.errorf("expected ',' or ')'")
+		}
+	}
+
+	expr.Operator = p.comparison()
+	if expr.Operator == "" {
+		return nil, p.errorf("expected comparison operator (>, >=, <, <=, ==, !=)")
+	}
+
+	threshold, err := p.number()
+	if err != nil {
+		return nil, err
+	}
+	expr.Threshold = threshold
+
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected trailing input")
+	}
+	return expr, nil
+}
+
+func (p *opsAlertExprParser) selector(expr *OpsAlertExpression) error {
+	if !p.consume("{") {
+		return p.errorf("expected '{'")
+	}
+	if p.consume("}") {
+		return nil
+	}
+	for {
+		label := p.ident()
+		if label == "" {
+			return p.errorf("expected label name")
+		}
+		op := p.matchOp()
+		if op == "" {
+			return p.errorf("expected label operator (=, !=, =~, !~)")
+		}
+		value, err := p.str()
+		if err != nil {
+			return err
+		}
+		expr.Matchers = append(expr.Matchers, OpsAlertLabelMatcher{Label: label, Op: op, Value: value})
+		if p.consume(",") {
+			continue
+		}
+		if p.consume("}") {
+			return nil
+		}
+		return p.errorf("expected ',' or '}'")
+	}
+}
+
+func (p *opsAlertExprParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *opsAlertExprParser) peek(tok string) bool {
+	p.skipSpace()
+	return strings.HasPrefix(p.src[p.pos:], tok)
+}
+
+func (p *opsAlertExprParser) consume(tok string) bool {
+	if p.peek(tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *opsAlertExprParser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// keyword consumes an identifier only if it equals kw.
+func (p *opsAlertExprParser) keyword(kw string) bool {
+	save := p.pos
+	if strings.EqualFold(p.ident(), kw) {
+		return true
+	}
+	p.pos = save
+	return false
+}
+
+func (p *opsAlertExprParser) matchOp() string {
+	for _, op := range []string{OpsAlertMatchRegex, OpsAlertMatchNotRegex, OpsAlertMatchNotEqual, OpsAlertMatchEqual} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *opsAlertExprParser) comparison() string {
+	for _, op := range []string{">=", "<=", "==", "!=", ">", "<"} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *opsAlertExprParser) str() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return "", p.errorf("expected quoted string")
+	}
+	quote := p.src[p.pos]
+	if quote != '"' && quote != '\'' {
+		// Bare numbers are accepted for id labels: group_id=3.
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		if p.pos == start {
+			return "", p.errorf("expected quoted string")
+		}
+		return p.src[start:p.pos], nil
+	}
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src):
+			b.WriteByte(p.src[p.pos+1])
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *opsAlertExprParser) number() (float64, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if (c >= '0' && c <= '9') || c == '.' || c == '-' || c == '+' || c == 'e' || c == 'E' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if p.pos == start {
+		return 0, p.errorf("expected threshold number")
+	}
+	v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("invalid threshold number")
+	}
+	return v, nil
+}
+
+func (p *opsAlertExprParser) errorf(msg string) error {
+	return fmt.Errorf("%s at position %d", msg, p.pos+1)
+}
@@ -0,0 +1,533 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+const (
+	opsAlertPreviewRange     = 24 * time.Hour
+	opsAlertPreviewMaxSeries = 50
+	opsAlertPreviewMaxEvents = 500
+)
+
+type opsAlertSeriesStateKey struct {
+	RuleID int64
+	Series string
+}
+
+type opsAlertExprEvalResult struct {
+	created    int
+	resolved   int
+	emailsSent int
+}
+
+// evaluateExpressionRule evaluates one expression rule; every series fires/resolves independently.
+// ok=false means the rule could not be evaluated this cycle (bad expression or query failure).
+func (s *OpsAlertEvaluatorService) evaluateExpressionRule(
+	ctx context.Context,
+	runtimeCfg *OpsAlertRuntimeSettings,
+	rule *OpsAlertRule,
+	interval time.Duration,
+	safeEnd time.Time,
+	now time.Time,
+) (res opsAlertExprEvalResult, ok bool) {
+	expr, err := ParseOpsAlertExpression(rule.Expression)
+	if err != nil {
+		log.Printf("[OpsAlertEvaluator] invalid expression (rule=%d): %v", rule.ID, err)
+		return res, false
+	}
+
+	windowMinutes := rule.WindowMinutes
+	if windowMinutes <= 0 {
+		windowMinutes = 1
+	}
+	windowStart := safeEnd.Add(-time.Duration(windowMinutes) * time.Minute)
+
+	points, err := s.opsRepo.QueryAlertExpressionSeries(ctx, expr, windowStart, safeEnd, 0)
+	if err != nil {
+		log.Printf("[OpsAlertEvaluator] query expression failed (rule=%d): %v", rule.ID, err)
+		return res, false
+	}
+
+	activeEvents, err := s.opsRepo.ListActiveAlertEvents(ctx, rule.ID)
+	if err != nil {
+		log.Printf("[OpsAlertEvaluator] list active events failed (rule=%d): %v", rule.ID, err)
+		return res, false
+	}
+	activeBySeries := make(map[string]*OpsAlertEvent, len(activeEvents))
+	for _, ev := range activeEvents {
+		key := opsAlertEventSeriesKey(ev)
+		if _, exists := activeBySeries[key]; !exists {
+			activeBySeries[key] = ev
+		}
+	}
+
+	current := make(map[string]*OpsAlertSeriesPoint, len(points))
+	for _, p := range points {
+		current[OpsAlertSeriesKey(p.Labels)] = p
+	}
+	if opsAlertExprZeroFill(expr) {
+		// Counts are 0 (not "no data") when nothing matched.
+		if len(expr.GroupBy) == 0 && len(current) == 0 {
+			current[""] = &OpsAlertSeriesPoint{BucketStart: windowStart}
+		}
+		for key, ev := range activeBySeries {
+			if _, exists := current[key]; !exists {
+				current[key] = &OpsAlertSeriesPoint{Labels: opsAlertEventSeriesLabels(ev), BucketStart: windowStart}
+			}
+		}
+	}
+
+	required := requiredSustainedBreaches(rule.SustainedMinutes, interval)
+	seen := make(map[string]struct{}, len(current))
+
+	for key, point := range current {
+		seen[key] = struct{}{}
+
+		breachedNow := compareMetric(point.Value, expr.Operator, expr.Threshold)
+		consecutive := s.updateSeriesBreaches(rule.ID, key, now, interval, breachedNow)
+		activeEvent := activeBySeries[key]
+
+		if breachedNow && consecutive >= required {
+			if activeEvent != nil {
+				continue
+			}
+
+			platform, groupID := opsAlertExprSeriesScope(expr, point.Labels)
+			if s.opsService != nil && platform != "" {
+				if silenced, err := s.opsService.IsAlertSilenced(ctx, rule.ID, platform, groupID, nil, now); err == nil && silenced {
+					continue
+				}
+			}
+
+			latestEvent, err := s.opsRepo.GetLatestAlertEventForSeries(ctx, rule.ID, key)
+			if err != nil {
+				log.Printf("[OpsAlertEvaluator] get latest series event failed (rule=%d series=%q): %v", rule.ID, key, err)
+				continue
+			}
+			if latestEvent != nil && rule.CooldownMinutes > 0 {
+				if now.Sub(latestEvent.FiredAt) < time.Duration(rule.CooldownMinutes)*time.Minute {
+					continue
+				}
+			}
+
+			title := fmt.Sprintf("%s: %s", strings.TrimSpace(rule.Severity), strings.TrimSpace(rule.Name))
+			if key != "" {
+				title = fmt.Sprintf("%s [%s]", title, key)
+			}
+			firedEvent := &OpsAlertEvent{
+				RuleID:         rule.ID,
+				Severity:       strings.TrimSpace(rule.Severity),
+				Status:         OpsAlertStatusFiring,
+				Title:          title,
+				Description:    buildOpsAlertExprDescription(expr, point, windowMinutes),
+				MetricValue:    float64Ptr(point.Value),
+				ThresholdValue: float64Ptr(expr.Threshold),
+				Dimensions:     buildOpsAlertSeriesDimensions(key, point.Labels, platform, groupID),
+				FiredAt:        now,
+				CreatedAt:      now,
+			}
+
+			created, err := s.opsRepo.CreateAlertEvent(ctx, firedEvent)
+			if err != nil {
+				log.Printf("[OpsAlertEvaluator] create event failed (rule=%d series=%q): %v", rule.ID, key, err)
+				continue
+			}
+			res.created++
+			if created != nil && created.ID > 0 {
+				if s.maybeSendAlertEmail(ctx, runtimeCfg, rule, created) {
+					res.emailsSent++
+				}
+			}
+			continue
+		}
+
+		if activeEvent != nil && !breachedNow {
+			if s.resolveAlertEvent(ctx, activeEvent, now) {
+				res.resolved++
+			}
+		}
+	}
+
+	// Series that produced no data this window (e.g. model/account went quiet) are resolved.
+	for key, ev := range activeBySeries {
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		if s.resolveAlertEvent(ctx, ev, now) {
+			res.resolved++
+		}
+	}
+
+	s.pruneSeriesStates(rule.ID, seen)
+	return res, true
+}
+
+func (s *OpsAlertEvaluatorService) resolveAlertEvent(ctx context.Context, ev *OpsAlertEvent, now time.Time) bool {
+	resolvedAt := now
+	if err := s.opsRepo.UpdateAlertEventStatus(ctx, ev.ID, OpsAlertStatusResolved, &resolvedAt); err != nil {
+		log.Printf("[OpsAlertEvaluator] resolve event failed (event=%d): %v", ev.ID, err)
+		return false
+	}
+	return true
+}
+
+func (s *OpsAlertEvaluatorService) updateSeriesBreaches(ruleID int64, series string, now time.Time, interval time.Duration, breached bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := opsAlertSeriesStateKey{RuleID: ruleID, Series: series}
+	state, ok := s.seriesStates[key]
+	if !ok {
+		state = &opsAlertRuleState{}
+		s.seriesStates[key] = state
+	}
+
+	if !state.LastEvaluatedAt.IsZero() && interval > 0 {
+		if now.Sub(state.LastEvaluatedAt) > interval*2 {
+			state.ConsecutiveBreaches = 0
+		}
+	}
+
+	state.LastEvaluatedAt = now
+	if breached {
+		state.ConsecutiveBreaches++
+	} else {
+		state.ConsecutiveBreaches = 0
+	}
+	return state.ConsecutiveBreaches
+}
+
+func (s *OpsAlertEvaluatorService) pruneSeriesStates(ruleID int64, seen map[string]struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.seriesStates {
+		if key.RuleID != ruleID {
+			continue
+		}
+		if _, ok := seen[key.Series]; !ok {
+			delete(s.seriesStates, key)
+		}
+	}
+}
+
+// opsAlertExprZeroFill reports whether a missing series value means 0 rather than "no data".
+func opsAlertExprZeroFill(expr *OpsAlertExpression) bool {
+	if expr == nil || expr.Source() != OpsAlertExprSourceRequests {
+		return false
+	}
+	return expr.Aggregation == OpsAlertAggCount || expr.Aggregation == OpsAlertAggRate
+}
+
+func opsAlertEventSeriesKey(ev *OpsAlertEvent) string {
+	if ev == nil || ev.Dimensions == nil {
+		return ""
+	}
+	if v, ok := ev.Dimensions["series_key"].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func opsAlertEventSeriesLabels(ev *OpsAlertEvent) map[string]string {
+	if ev == nil || ev.Dimensions == nil {
+		return nil
+	}
+	raw, ok := ev.Dimensions["labels"].(map[string]any)
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
+
+// opsAlertExprSeriesScope derives platform/group scope (for silences and event filters)
+// from the series labels, falling back to equality matchers.
+func opsAlertExprSeriesScope(expr *OpsAlertExpression, labels map[string]string) (platform string, groupID *int64) {
+	lookup := func(label string) string {
+		if v := strings.TrimSpace(labels[label]); v != "" {
+			return v
+		}
+		if expr == nil {
+			return ""
+		}
+		for _, m := range expr.Matchers {
+			if m.Label == label && m.Op == OpsAlertMatchEqual {
+				return strings.TrimSpace(m.Value)
+			}
+		}
+		return ""
+	}
+
+	platform = lookup("platform")
+	if v := lookup("group_id"); v != "" {
+		if id, err := strconv.ParseInt(v, 10, 64); err == nil && id > 0 {
+			groupID = &id
+		}
+	}
+	return platform, groupID
+}
+
+func buildOpsAlertSeriesDimensions(seriesKey string, labels map[string]string, platform string, groupID *int64) map[string]any {
+	dims := buildOpsAlertDimensions(platform, groupID)
+	if seriesKey == "" {
+		return dims
+	}
+	if dims == nil {
+		dims = map[string]any{}
+	}
+	dims["series_key"] = seriesKey
+	dims["labels"] = labels
+	return dims
+}
+
+func buildOpsAlertExprDescription(expr *OpsAlertExpression, point *OpsAlertSeriesPoint, windowMinutes int) string {
+	if expr == nil || point == nil {
+		return ""
+	}
+	scope := "overall"
+	if key := OpsAlertSeriesKey(point.Labels); key != "" {
+		scope = key
+	}
+	return fmt.Sprintf("%s(%s) = %.2f, threshold %s %s over last %dm (%s)",
+		expr.Aggregation,
+		expr.Metric,
+		point.Value,
+		expr.Operator,
+		strconv.FormatFloat(expr.Threshold, 'f', -1, 64),
+		windowMinutes,
+		scope,
+	)
+}
+
+// OpsAlertRulePreview shows what an expression would have fired over the preview range.
+type OpsAlertRulePreview struct {
+	Expression  string              `json:"expression"`
+	Parsed      *OpsAlertExpression `json:"parsed"`
+	StartTime   time.Time           `json:"start_time"`
+	EndTime     time.Time           `json:"end_time"`
+	StepMinutes int                 `json:"step_minutes"`
+
+	// SeriesTotal counts all series; Series is truncated to the most active ones.
+	SeriesTotal int                      `json:"series_total"`
+	Series      []*OpsAlertPreviewSeries `json:"series"`
+	Events      []*OpsAlertPreviewEvent  `json:"events"`
+}
+
+type OpsAlertPreviewSeries struct {
+	SeriesKey  string                `json:"series_key"`
+	Labels     map[string]string     `json:"labels,omitempty"`
+	Points     []OpsAlertSeriesPoint `json:"points"`
+	FiredCount int                   `json:"fired_count"`
+}
+
+type OpsAlertPreviewEvent struct {
+	SeriesKey   string            `json:"series_key"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	FiredAt     time.Time         `json:"fired_at"`
+	ResolvedAt  *time.Time        `json:"resolved_at,omitempty"`
+	MetricValue float64           `json:"metric_value"`
+	PeakValue   float64           `json:"peak_value"`
+}
+
+// ValidateAlertExpression parses an expression and returns its canonical form.
+func (s *OpsService) ValidateAlertExpression(expression string) (*OpsAlertExpression, error) {
+	expr, err := ParseOpsAlertExpression(expression)
+	if err != nil {
+		return nil, infraerrors.BadRequest("INVALID_ALERT_EXPRESSION", err.Error())
+	}
+	return expr, nil
+}
+
+// PreviewAlertExpression replays an expression over the last 24h using tumbling windows
+// of windowMinutes, applying sustained/cooldown the same way the evaluator does.
+func (s *OpsService) PreviewAlertExpression(ctx context.Context, expression string, windowMinutes, sustainedMinutes, cooldownMinutes int) (*OpsAlertRulePreview, error) {
+	if err := s.RequireMonitoringEnabled(ctx); err != nil {
+		return nil, err
+	}
+	if s.opsRepo == nil {
+		return nil, infraerrors.ServiceUnavailable("OPS_REPO_UNAVAILABLE", "Ops repository not available")
+	}
+	expr, err := s.ValidateAlertExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	if windowMinutes <= 0 {
+		windowMinutes = 1
+	}
+
+	end := time.Now().UTC().Truncate(time.Minute)
+	start := end.Add(-opsAlertPreviewRange)
+	step := time.Duration(windowMinutes) * time.Minute
+
+	points, err := s.opsRepo.QueryAlertExpressionSeries(ctx, expr, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	series, events := simulateOpsAlertExpression(expr, points, start, end, step, sustainedMinutes, cooldownMinutes)
+	total := len(series)
+	if len(series) > opsAlertPreviewMaxSeries {
+		series = series[:opsAlertPreviewMaxSeries]
+	}
+	if len(events) > opsAlertPreviewMaxEvents {
+		events = events[len(events)-opsAlertPreviewMaxEvents:]
+	}
+
+	return &OpsAlertRulePreview{
+		Expression:  expr.String(),
+		Parsed:      expr,
+		StartTime:   start,
+		EndTime:     end,
+		StepMinutes: windowMinutes,
+		SeriesTotal: total,
+		Series:      series,
+		Events:      events,
+	}, nil
+}
+
+// simulateOpsAlertExpression walks bucketed series values and returns per-series points
+// (most-firing first) and the events that would have been created (oldest first).
+func simulateOpsAlertExpression(
+	expr *OpsAlertExpression,
+	points []*OpsAlertSeriesPoint,
+	start, end time.Time,
+	step time.Duration,
+	sustainedMinutes, cooldownMinutes int,
+) ([]*OpsAlertPreviewSeries, []*OpsAlertPreviewEvent) {
+	if expr == nil || step <= 0 || !end.After(start) {
+		return nil, nil
+	}
+	buckets := int(math.Ceil(float64(end.Sub(start)) / float64(step)))
+	zeroFill := opsAlertExprZeroFill(expr)
+
+	type seriesValues struct {
+		labels  map[string]string
+		values  []float64
+		present []bool
+	}
+	bySeries := map[string]*seriesValues{}
+	getSeries := func(key string, labels map[string]string) *seriesValues {
+		sv, ok := bySeries[key]
+		if !ok {
+			sv = &seriesValues{labels: labels, values: make([]float64, buckets), present: make([]bool, buckets)}
+			if zeroFill {
+				for i := range sv.present {
+					sv.present[i] = true
+				}
+			}
+			bySeries[key] = sv
+		}
+		return sv
+	}
+	for _, p := range points {
+		if p == nil {
+			continue
+		}
+		idx := int(p.BucketStart.Sub(start) / step)
+		if idx < 0 || idx >= buckets {
+			continue
+		}
+		sv := getSeries(OpsAlertSeriesKey(p.Labels), p.Labels)
+		sv.values[idx] = p.Value
+		sv.present[idx] = true
+	}
+	if zeroFill && len(expr.GroupBy) == 0 && len(bySeries) == 0 {
+		getSeries("", nil)
+	}
+
+	required := requiredSustainedBreaches(sustainedMinutes, step)
+	cooldown := time.Duration(cooldownMinutes) * time.Minute
+	lowerIsWorse := expr.Operator == "<" || expr.Operator == "<="
+
+	seriesOut := make([]*OpsAlertPreviewSeries, 0, len(bySeries))
+	events := []*OpsAlertPreviewEvent{}
+
+	for key, sv := range bySeries {
+		out := &OpsAlertPreviewSeries{SeriesKey: key, Labels: sv.labels, Points: []OpsAlertSeriesPoint{}}
+		var active *OpsAlertPreviewEvent
+		var lastFiredAt time.Time
+		consecutive := 0
+
+		for i := 0; i < buckets; i++ {
+			evalAt := start.Add(time.Duration(i+1) * step)
+			if !sv.present[i] {
+				consecutive = 0
+				if active != nil {
+					resolvedAt := evalAt
+					active.ResolvedAt = &resolvedAt
+					active = nil
+				}
+				continue
+			}
+
+			value := sv.values[i]
+			out.Points = append(out.Points, OpsAlertSeriesPoint{BucketStart: start.Add(time.Duration(i) * step), Value: value})
+
+			breached := compareMetric(value, expr.Operator, expr.Threshold)
+			if breached {
+				consecutive++
+			} else {
+				consecutive = 0
+			}
+
+			if active != nil {
+				if !breached {
+					resolvedAt := evalAt
+					active.ResolvedAt = &resolvedAt
+					active = nil
+					continue
+				}
+				if (lowerIsWorse && value < active.PeakValue) || (!lowerIsWorse && value > active.PeakValue) {
+					active.PeakValue = value
+				}
+				continue
+			}
+
+			if breached && consecutive >= required {
+				if !lastFiredAt.IsZero() && cooldown > 0 && evalAt.Sub(lastFiredAt) < cooldown {
+					continue
+				}
+				active = &OpsAlertPreviewEvent{
+					SeriesKey:   key,
+					Labels:      sv.labels,
+					FiredAt:     evalAt,
+					MetricValue: value,
+					PeakValue:   value,
+				}
+				lastFiredAt = evalAt
+				out.FiredCount++
+				events = append(events, active)
+			}
+		}
+		seriesOut = append(seriesOut, out)
+	}
+
+	sort.Slice(seriesOut, func(i, j int) bool {
+		if seriesOut[i].FiredCount != seriesOut[j].FiredCount {
+			return seriesOut[i].FiredCount > seriesOut[j].FiredCount
+		}
+		return seriesOut[i].SeriesKey < seriesOut[j].SeriesKey
+	})
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].FiredAt.Equal(events[j].FiredAt) {
+			return events[i].FiredAt.Before(events[j].FiredAt)
+		}
+		return events[i].SeriesKey < events[j].SeriesKey
+	})
+	return seriesOut, events
+}
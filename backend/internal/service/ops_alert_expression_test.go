@@ -0,0 +1,143 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOpsAlertExpression(t *testing.T) {
+	t.Parallel()
+
+	t.Run("聚合 + 标签过滤 + 分组", func(t *testing.T) {
+		t.Parallel()
+
+		expr, err := ParseOpsAlertExpression(`p95(ttft_ms{platform="anthropic", model=~"claude-opus.*"}) by (account, model) > 8000`)
+		require.NoError(t, err)
+		require.Equal(t, OpsAlertAggP95, expr.Aggregation)
+		require.Equal(t, "ttft_ms", expr.Metric)
+		require.Equal(t, []OpsAlertLabelMatcher{
+			{Label: "platform", Op: OpsAlertMatchEqual, Value: "anthropic"},
+			{Label: "model", Op: OpsAlertMatchRegex, Value: "claude-opus.*"},
+		}, expr.Matchers)
+		require.Equal(t, []string{"account_id", "model"}, expr.GroupBy)
+		require.Equal(t, ">", expr.Operator)
+		require.Equal(t, 8000.0, expr.Threshold)
+		require.Equal(t, OpsAlertExprSourceRequests, expr.Source())
+	})
+
+	t.Run("省略聚合使用默认值，选择器可写在外层", func(t *testing.T) {
+		t.Parallel()
+
+		expr, err := ParseOpsAlertExpression(`error_rate{group_id=3} by (model) >= 5.5`)
+		require.NoError(t, err)
+		require.Equal(t, OpsAlertAggRatio, expr.Aggregation)
+		require.Equal(t, "3", expr.Matchers[0].Value)
+		require.Equal(t, `ratio(error_rate{group_id="3"}) by (model) >= 5.5`, expr.String())
+
+		expr, err = ParseOpsAlertExpression(`cpu_usage_percent > 90`)
+		require.NoError(t, err)
+		require.Equal(t, OpsAlertAggAvg, expr.Aggregation)
+		require.Equal(t, OpsAlertExprSourceSystem, expr.Source())
+	})
+
+	t.Run("规范化形式可再次解析", func(t *testing.T) {
+		t.Parallel()
+
+		expr, err := ParseOpsAlertExpression(`rate(requests{proxy!="7"}) < 0.5`)
+		require.NoError(t, err)
+		again, err := ParseOpsAlertExpression(expr.String())
+		require.NoError(t, err)
+		require.Equal(t, expr, again)
+	})
+
+	t.Run("非法表达式", func(t *testing.T) {
+		t.Parallel()
+
+		cases := []string{
+			``,
+			`unknown_metric > 1`,
+			`duration_ms > 1`,
+			`p95(requests) > 1`,
+			`p95(duration_ms{region="us"}) > 1`,
+			`p95(duration_ms) by (model, model) > 1`,
+			`max(cpu_usage_percent{platform="anthropic"}) > 1`,
+			`p95(duration_ms{model=~"("}) > 1`,
+			`p95(duration_ms) > abc`,
+			`p95(duration_ms) 100`,
+			`p95(duration_ms > 100`,
+			`p95(duration_ms) > 100 extra`,
+		}
+		for _, input := range cases {
+			_, err := ParseOpsAlertExpression(input)
+			require.Error(t, err, input)
+		}
+	})
+}
+
+func TestOpsAlertSeriesKey(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", OpsAlertSeriesKey(nil))
+	require.Equal(t, "account_id=12,model=claude", OpsAlertSeriesKey(map[string]string{"model": "claude", "account_id": "12"}))
+}
+
+func TestSimulateOpsAlertExpression(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	step := 5 * time.Minute
+	end := start.Add(12 * step)
+
+	t.Run("按序列独立触发并遵循持续时间", func(t *testing.T) {
+		t.Parallel()
+
+		expr, err := ParseOpsAlertExpression(`p95(duration_ms) by (account_id) > 100`)
+		require.NoError(t, err)
+
+		a := map[string]string{"account_id": "1"}
+		b := map[string]string{"account_id": "2"}
+		points := []*OpsAlertSeriesPoint{
+			{Labels: a, BucketStart: start, Value: 150},
+			{Labels: a, BucketStart: start.Add(step), Value: 200},
+			{Labels: a, BucketStart: start.Add(2 * step), Value: 50},
+			{Labels: b, BucketStart: start.Add(3 * step), Value: 500},
+		}
+
+		series, events := simulateOpsAlertExpression(expr, points, start, end, step, 10, 0)
+		require.Len(t, series, 2)
+		require.Len(t, events, 1)
+
+		ev := events[0]
+		require.Equal(t, "account_id=1", ev.SeriesKey)
+		require.Equal(t, start.Add(2*step), ev.FiredAt)
+		require.NotNil(t, ev.ResolvedAt)
+		require.Equal(t, start.Add(3*step), *ev.ResolvedAt)
+		require.Equal(t, 200.0, ev.PeakValue)
+		require.Equal(t, "account_id=1", series[0].SeriesKey)
+		require.Equal(t, 1, series[0].FiredCount)
+	})
+
+	t.Run("计数类指标缺失桶按 0 处理，冷却期内不重复触发", func(t *testing.T) {
+		t.Parallel()
+
+		expr, err := ParseOpsAlertExpression(`requests < 1`)
+		require.NoError(t, err)
+
+		points := []*OpsAlertSeriesPoint{
+			{BucketStart: start.Add(1 * step), Value: 3},
+			{BucketStart: start.Add(3 * step), Value: 3},
+		}
+
+		_, events := simulateOpsAlertExpression(expr, points, start, end, step, 0, 30)
+		require.Len(t, events, 2)
+		require.Equal(t, start.Add(step), events[0].FiredAt)
+		require.Equal(t, start.Add(2*step), *events[0].ResolvedAt)
+		// Bucket 2 breaches again at t=3*step but is within the 30m cooldown; next fire after cooldown.
+		require.Equal(t, start.Add(7*step), events[1].FiredAt)
+		require.Nil(t, events[1].ResolvedAt)
+	})
+}
@@ -33,6 +33,9 @@ type OpsAlertRule struct {
 
 	Filters map[string]any `json:"filters,omitempty"`
 
+	// Expression replaces MetricType/Operator/Threshold when set (MetricType is "expression").
+	Expression string `json:"expression,omitempty"`
+
 	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
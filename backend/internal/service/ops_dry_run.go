@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	opsDryRunMaxAccounts = 10
+	opsDryRunMaxBodySize = 2 << 20
+)
+
+// opsDryRunBlockedHeaders 客户端鉴权头不参与预演，避免被误当作上游凭证透传
+var opsDryRunBlockedHeaders = map[string]bool{
+	"authorization":  true,
+	"x-api-key":      true,
+	"x-goog-api-key": true,
+	"cookie":         true,
+}
+
+// OpsDryRunRequest 管理员提交的预演请求：入站请求按指定协议格式描述，逐个账号走一遍真实转发链路
+type OpsDryRunRequest struct {
+	AccountIDs []int64 `json:"account_ids"`
+	// Format: messages | openai_responses | gemini_v1beta
+	Format string `json:"format"`
+	// Model/Stream 仅 gemini_v1beta 使用（Gemini 原生接口的模型与流式由 URL 决定）
+	Model   string            `json:"model"`
+	Stream  bool              `json:"stream"`
+	Headers map[string]string `json:"headers"`
+	Body    []byte            `json:"-"`
+}
+
+// OpsDryRunResult 单个账号的预演结果
+type OpsDryRunResult struct {
+	AccountID         int64                      `json:"account_id"`
+	AccountName       string                     `json:"account_name"`
+	Platform          string                     `json:"platform"`
+	AccountType       string                     `json:"account_type"`
+	UpstreamRequests  []*CapturedUpstreamRequest `json:"upstream_requests"`
+	StatusCode        int                        `json:"status_code"`
+	ResponsePreview   string                     `json:"response_preview,omitempty"`
+	ResponseTruncated bool                       `json:"response_truncated"`
+	Error             string                     `json:"error,omitempty"`
+}
+
+// DryRunGatewayRequest 预演入站请求：展示每个账号（平台链路）实际会发往上游的请求，不访问上游。
+// 上游调用在 HTTPUpstream 层被拦截并返回模拟的成功响应，因此返回的客户端响应同样是模拟结果。
+func (s *OpsService) DryRunGatewayRequest(ctx context.Context, req *OpsDryRunRequest) ([]*OpsDryRunResult, error) {
+	if req == nil {
+		return nil, infraerrors.BadRequest("INVALID_DRY_RUN", "request is required")
+	}
+	if s.accountRepo == nil {
+		return nil, infraerrors.ServiceUnavailable("ACCOUNT_REPOSITORY_UNAVAILABLE", "account repository not available")
+	}
+	if len(req.AccountIDs) == 0 || len(req.AccountIDs) > opsDryRunMaxAccounts {
+		return nil, infraerrors.BadRequest("INVALID_DRY_RUN_ACCOUNTS", fmt.Sprintf("account_ids must contain 1-%d accounts", opsDryRunMaxAccounts))
+	}
+	if len(req.Body) == 0 || !gjson.ValidBytes(req.Body) {
+		return nil, infraerrors.BadRequest("INVALID_DRY_RUN_BODY", "body must be a JSON object")
+	}
+	if len(req.Body) > opsDryRunMaxBodySize {
+		return nil, infraerrors.BadRequest("INVALID_DRY_RUN_BODY", "body is too large")
+	}
+
+	reqType := opsRetryRequestType(strings.TrimSpace(req.Format))
+	target := opsForwardTarget{action: "generateContent"}
+	var path string
+	switch reqType {
+	case opsRetryTypeMessages:
+		path = "/v1/messages"
+	case opsRetryTypeOpenAI:
+		path = "/v1/responses"
+	case opsRetryTypeGeminiV1B:
+		target.model = strings.TrimSpace(req.Model)
+		if target.model == "" {
+			return nil, infraerrors.BadRequest("INVALID_DRY_RUN_MODEL", "model is required for gemini_v1beta")
+		}
+		target.stream = req.Stream
+		if req.Stream {
+			target.action = "streamGenerateContent"
+		}
+		path = "/v1beta/models/" + target.model + ":" + target.action
+	default:
+		return nil, infraerrors.BadRequest("INVALID_DRY_RUN_FORMAT", "format must be one of: messages, openai_responses, gemini_v1beta")
+	}
+
+	results := make([]*OpsDryRunResult, 0, len(req.AccountIDs))
+	for _, accountID := range req.AccountIDs {
+		account, err := s.accountRepo.GetByID(ctx, accountID)
+		if err != nil || account == nil {
+			results = append(results, &OpsDryRunResult{AccountID: accountID, Error: "account not found"})
+			continue
+		}
+		results = append(results, s.dryRunWithAccount(ctx, reqType, target, path, req, account))
+	}
+	return results, nil
+}
+
+func (s *OpsService) dryRunWithAccount(ctx context.Context, reqType opsRetryRequestType, target opsForwardTarget, path string, req *OpsDryRunRequest, account *Account) *OpsDryRunResult {
+	result := &OpsDryRunResult{
+		AccountID:   account.ID,
+		AccountName: account.Name,
+		Platform:    account.Platform,
+		AccountType: account.Type,
+	}
+
+	dryCtx, rec := WithUpstreamDryRun(ctx)
+	c, w := newOpsDryRunContext(dryCtx, path, req.Headers)
+
+	// 每个账号使用独立的请求体副本，避免转发链路原地修改影响后续账号
+	body := append([]byte(nil), req.Body...)
+	err := s.forwardWithAccount(dryCtx, c, reqType, target, body, account)
+
+	result.UpstreamRequests = rec.Requests()
+	result.StatusCode = c.Writer.Status()
+	result.ResponsePreview, result.ResponseTruncated = extractResponsePreview(w)
+
+	var unavailable *opsForwardUnavailableError
+	switch {
+	case errors.As(err, &unavailable):
+		result.Error = unavailable.message
+	case err != nil:
+		result.Error = err.Error()
+	case len(result.UpstreamRequests) == 0:
+		result.Error = "no upstream request was produced"
+	}
+	return result
+}
+
+func newOpsDryRunContext(ctx context.Context, path string, headers map[string]string) (*gin.Context, *limitedResponseWriter) {
+	w := newLimitedResponseWriter(opsRetryCaptureBytesLimit)
+	c, _ := gin.CreateTestContext(w)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost"+path, bytes.NewReader(nil))
+	req.Header.Set("content-type", "application/json")
+	for k, v := range headers {
+		key := strings.TrimSpace(k)
+		if key == "" || opsDryRunBlockedHeaders[strings.ToLower(key)] {
+			continue
+		}
+		req.Header.Set(key, strings.TrimSpace(v))
+	}
+
+	c.Request = req
+	return c, w
+}
@@ -0,0 +1,120 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type dryRunInnerUpstream struct {
+	calls int
+}
+
+func (u *dryRunInnerUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	u.calls++
+	return nil, nil
+}
+
+func (u *dryRunInnerUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	u.calls++
+	return nil, nil
+}
+
+func TestOpsService_DryRunGatewayRequest(t *testing.T) {
+	inner := &dryRunInnerUpstream{}
+	upstream := NewDryRunHTTPUpstream(inner)
+	cfg := testConfig()
+
+	repo := &mockAccountRepoForPlatform{accountsByID: map[int64]*Account{
+		1: {ID: 1, Name: "claude", Platform: PlatformAnthropic, Type: AccountTypeAPIKey, Credentials: map[string]any{"api_key": "sk-ant-secret"}},
+		2: {ID: 2, Name: "gemini", Platform: PlatformGemini, Type: AccountTypeAPIKey, Credentials: map[string]any{"api_key": "AIza-secret"}},
+	}}
+	svc := &OpsService{
+		accountRepo:         repo,
+		gatewayService:      &GatewayService{cfg: cfg, httpUpstream: upstream},
+		geminiCompatService: &GeminiMessagesCompatService{cfg: cfg, httpUpstream: upstream},
+	}
+
+	results, err := svc.DryRunGatewayRequest(context.Background(), &OpsDryRunRequest{
+		AccountIDs: []int64{1, 2, 3},
+		Format:     "messages",
+		Headers:    map[string]string{"anthropic-version": "2023-06-01", "x-api-key": "client-key"},
+		Body:       []byte(`{"model":"claude-sonnet-4-5","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`),
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Zero(t, inner.calls, "dry run must never reach the real upstream")
+
+	claude := results[0]
+	require.Empty(t, claude.Error)
+	require.Equal(t, http.StatusOK, claude.StatusCode)
+	require.Len(t, claude.UpstreamRequests, 1)
+	require.Equal(t, "https://api.anthropic.com/v1/messages", claude.UpstreamRequests[0].URL)
+	require.Equal(t, redactedValue, claude.UpstreamRequests[0].Headers["x-api-key"])
+	require.NotContains(t, string(claude.UpstreamRequests[0].Body), "secret")
+
+	gemini := results[1]
+	require.Empty(t, gemini.Error)
+	require.Len(t, gemini.UpstreamRequests, 1)
+	require.Contains(t, gemini.UpstreamRequests[0].URL, ":generateContent")
+	require.Equal(t, redactedValue, gemini.UpstreamRequests[0].Headers["x-goog-api-key"])
+	var preview map[string]any
+	require.NoError(t, json.Unmarshal([]byte(gemini.ResponsePreview), &preview))
+	require.Equal(t, "message", preview["type"], "gemini response must be translated back to Claude format")
+
+	require.Equal(t, "account not found", results[2].Error)
+}
+
+func TestOpsService_DryRunGatewayRequest_Validation(t *testing.T) {
+	svc := &OpsService{accountRepo: &mockAccountRepoForPlatform{}}
+	body := []byte(`{"contents":[]}`)
+
+	_, err := svc.DryRunGatewayRequest(context.Background(), &OpsDryRunRequest{AccountIDs: []int64{1}, Format: "gemini_v1beta", Body: body})
+	require.Error(t, err, "gemini_v1beta requires model")
+
+	_, err = svc.DryRunGatewayRequest(context.Background(), &OpsDryRunRequest{AccountIDs: []int64{1}, Format: "chat", Body: body})
+	require.Error(t, err)
+
+	_, err = svc.DryRunGatewayRequest(context.Background(), &OpsDryRunRequest{Format: "messages", Body: body})
+	require.Error(t, err)
+}
+
+func TestDryRunAccessTokenSkipsRefresh(t *testing.T) {
+	cache := newClaudeTokenCacheStub()
+	provider := NewClaudeTokenProvider(nil, cache, nil)
+	expired := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	account := &Account{ID: 1, Platform: PlatformAnthropic, Type: AccountTypeOAuth, Credentials: map[string]any{
+		"access_token": "stored-token",
+		"expires_at":   expired,
+	}}
+
+	ctx, _ := WithUpstreamDryRun(context.Background())
+	token, err := provider.GetAccessToken(ctx, account)
+	require.NoError(t, err)
+	require.Equal(t, "stored-token", token)
+	require.Zero(t, atomic.LoadInt32(&cache.getCalled))
+	require.Zero(t, atomic.LoadInt32(&cache.lockCalled), "dry run must not take the refresh lock")
+
+	delete(account.Credentials, "access_token")
+	token, err = provider.GetAccessToken(ctx, account)
+	require.NoError(t, err)
+	require.Equal(t, dryRunPlaceholderToken, token)
+}
+
+func TestRedactUpstreamURL(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://generativelanguage.googleapis.com/v1beta/models/x:generateContent?key=AIza-secret&alt=sse", nil)
+	require.NoError(t, err)
+	got := redactUpstreamURL(req.URL)
+	require.NotContains(t, got, "AIza-secret")
+	require.Contains(t, got, "alt=sse")
+	require.Equal(t, "Bearer "+redactedValue, redactCredential("Bearer abc"))
+	require.Equal(t, redactedValue, redactCredential("abc"))
+}
@@ -34,6 +34,7 @@ type OpsErrorLog struct {
 
 	ClientRequestID string `json:"client_request_id"`
 	RequestID       string `json:"request_id"`
+	TraceID         string `json:"trace_id"`
 	Message         string `json:"message"`
 
 	UserID      *int64 `json:"user_id"`
@@ -98,6 +99,7 @@ type OpsErrorLogFilter struct {
 	// Optional correlation keys for exact matching.
 	RequestID       string
 	ClientRequestID string
+	TraceID         string
 
 	// View controls error categorization for list endpoints.
 	// - errors: show actionable errors (exclude business-limited / 429 / 529)
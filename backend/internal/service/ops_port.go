@@ -48,6 +48,11 @@ type OpsRepository interface {
 	UpdateAlertEventStatus(ctx context.Context, eventID int64, status string, resolvedAt *time.Time) error
 	UpdateAlertEventEmailSent(ctx context.Context, eventID int64, emailSent bool) error
 
+	// Expression rules (per-series evaluation)
+	QueryAlertExpressionSeries(ctx context.Context, expr *OpsAlertExpression, start, end time.Time, step time.Duration) ([]*OpsAlertSeriesPoint, error)
+	ListActiveAlertEvents(ctx context.Context, ruleID int64) ([]*OpsAlertEvent, error)
+	GetLatestAlertEventForSeries(ctx context.Context, ruleID int64, seriesKey string) (*OpsAlertEvent, error)
+
 	// Alert silences
 	CreateAlertSilence(ctx context.Context, input *OpsAlertSilence) (*OpsAlertSilence, error)
 	IsAlertSilenced(ctx context.Context, ruleID int64, platform string, groupID *int64, region *string, now time.Time) (bool, error)
@@ -62,6 +67,7 @@ type OpsRepository interface {
 type OpsInsertErrorLogInput struct {
 	RequestID       string
 	ClientRequestID string
+	TraceID         string
 
 	UserID    *int64
 	APIKeyID  *int64
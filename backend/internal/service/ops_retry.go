@@ -46,7 +46,10 @@ type opsRetryRequestType string
 const (
 	opsRetryTypeMessages  opsRetryRequestType = "messages"
 	opsRetryTypeOpenAI    opsRetryRequestType = "openai_responses"
+	opsRetryTypeEmbedding opsRetryRequestType = "openai_embeddings"
 	opsRetryTypeGeminiV1B opsRetryRequestType = "gemini_v1beta"
+	// 图片接口按张计费且请求体可能为 multipart，不支持重放
+	opsRetryTypeImages opsRetryRequestType = "openai_images"
 )
 
 type limitedResponseWriter struct {
@@ -361,7 +364,12 @@ func (s *OpsService) executeRetry(ctx context.Context, errorLog *OpsErrorLogDeta
 	switch reqType {
 	case opsRetryTypeMessages:
 		bodyBytes = FilterThinkingBlocksForRetry(bodyBytes)
-	case opsRetryTypeOpenAI, opsRetryTypeGeminiV1B:
+	case opsRetryTypeImages:
+		return &opsRetryExecution{
+			status:       opsRetryStatusFailed,
+			errorMessage: "retry is not supported for image requests",
+		}
+	case opsRetryTypeOpenAI, opsRetryTypeEmbedding, opsRetryTypeGeminiV1B:
 		// No-op
 	}
 
@@ -389,6 +397,10 @@ func detectOpsRetryType(path string) opsRetryRequestType {
 	switch {
 	case strings.Contains(p, "/responses"):
 		return opsRetryTypeOpenAI
+	case strings.HasSuffix(p, "/embeddings"):
+		return opsRetryTypeEmbedding
+	case strings.Contains(p, "/images/"):
+		return opsRetryTypeImages
 	case strings.Contains(p, "/v1beta/"):
 		return opsRetryTypeGeminiV1B
 	default:
@@ -510,6 +522,14 @@ func (s *OpsService) selectAccountForRetry(ctx context.Context, reqType opsRetry
 			return nil, fmt.Errorf("openai gateway service not available")
 		}
 		return s.openAIGatewayService.SelectAccountWithLoadAwareness(ctx, groupID, "", model, excludedIDs)
+	case opsRetryTypeEmbedding:
+		if s.openAIGatewayService == nil {
+			return nil, fmt.Errorf("openai gateway service not available")
+		}
+		for id := range s.openAIGatewayService.APIKeyOnlyExcludedAccountIDs(ctx, groupID) {
+			excludedIDs[id] = struct{}{}
+		}
+		return s.openAIGatewayService.SelectAccountWithLoadAwareness(ctx, groupID, "", model, excludedIDs)
 	case opsRetryTypeGeminiV1B, opsRetryTypeMessages:
 		if s.gatewayService == nil {
 			return nil, fmt.Errorf("gateway service not available")
@@ -528,7 +548,7 @@ func extractRetryModelAndStream(reqType opsRetryRequestType, errorLog *OpsErrorL
 			return "", false, fmt.Errorf("failed to parse messages request body: %w", parseErr)
 		}
 		return parsed.Model, parsed.Stream, nil
-	case opsRetryTypeOpenAI:
+	case opsRetryTypeOpenAI, opsRetryTypeEmbedding:
 		var v struct {
 			Model  string `json:"model"`
 			Stream bool   `json:"stream"`
@@ -554,51 +574,10 @@ func (s *OpsService) executeWithAccount(ctx context.Context, reqType opsRetryReq
 
 	c, w := newOpsRetryContext(ctx, errorLog)
 
-	var err error
-	switch reqType {
-	case opsRetryTypeOpenAI:
-		if s.openAIGatewayService == nil {
-			return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: "openai gateway service not available"}
-		}
-		_, err = s.openAIGatewayService.Forward(ctx, c, account, body)
-	case opsRetryTypeGeminiV1B:
-		if s.geminiCompatService == nil || s.antigravityGatewayService == nil {
-			return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: "gemini services not available"}
-		}
-		modelName := strings.TrimSpace(errorLog.Model)
-		action := "generateContent"
-		if errorLog.Stream {
-			action = "streamGenerateContent"
-		}
-		if account.Platform == PlatformAntigravity {
-			_, err = s.antigravityGatewayService.ForwardGemini(ctx, c, account, modelName, action, errorLog.Stream, body)
-		} else {
-			_, err = s.geminiCompatService.ForwardNative(ctx, c, account, modelName, action, errorLog.Stream, body)
-		}
-	case opsRetryTypeMessages:
-		switch account.Platform {
-		case PlatformAntigravity:
-			if s.antigravityGatewayService == nil {
-				return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: "antigravity gateway service not available"}
-			}
-			_, err = s.antigravityGatewayService.Forward(ctx, c, account, body)
-		case PlatformGemini:
-			if s.geminiCompatService == nil {
-				return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: "gemini gateway service not available"}
-			}
-			_, err = s.geminiCompatService.Forward(ctx, c, account, body)
-		default:
-			if s.gatewayService == nil {
-				return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: "gateway service not available"}
-			}
-			parsedReq, parseErr := ParseGatewayRequest(body)
-			if parseErr != nil {
-				return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: "failed to parse request body"}
-			}
-			_, err = s.gatewayService.Forward(ctx, c, account, parsedReq)
-		}
-	default:
-		return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: "unsupported retry type"}
+	err := s.forwardWithAccount(ctx, c, reqType, opsForwardTargetFromErrorLog(errorLog), body, account)
+	var unavailable *opsForwardUnavailableError
+	if errors.As(err, &unavailable) {
+		return &opsRetryExecution{status: opsRetryStatusFailed, errorMessage: unavailable.message}
 	}
 
 	statusCode := http.StatusOK
@@ -632,6 +611,99 @@ func (s *OpsService) executeWithAccount(ctx context.Context, reqType opsRetryReq
 	return exec
 }
 
+// opsForwardTarget 重放/预演请求时 Gemini 原生接口所需的模型与动作（URL 中携带，不在请求体内）
+type opsForwardTarget struct {
+	model  string
+	action string
+	stream bool
+}
+
+func opsForwardTargetFromErrorLog(errorLog *OpsErrorLogDetail) opsForwardTarget {
+	target := opsForwardTarget{action: "generateContent"}
+	if errorLog == nil {
+		return target
+	}
+	target.model = strings.TrimSpace(errorLog.Model)
+	target.stream = errorLog.Stream
+	if errorLog.Stream {
+		target.action = "streamGenerateContent"
+	}
+	if _, pathAction, found := strings.Cut(errorLog.RequestPath, ":"); found && IsGeminiEmbeddingAction(pathAction) {
+		target.action = pathAction
+	}
+	return target
+}
+
+// opsForwardUnavailableError 表示所需的网关服务不可用或请求类型不支持（未产生任何上游请求）
+type opsForwardUnavailableError struct {
+	message string
+}
+
+func (e *opsForwardUnavailableError) Error() string { return e.message }
+
+// forwardWithAccount 按请求类型与账号平台分派到对应的网关转发实现（与线上 Handler 的分流保持一致）
+func (s *OpsService) forwardWithAccount(ctx context.Context, c *gin.Context, reqType opsRetryRequestType, target opsForwardTarget, body []byte, account *Account) error {
+	var err error
+	switch reqType {
+	case opsRetryTypeOpenAI:
+		if s.openAIGatewayService == nil {
+			return &opsForwardUnavailableError{"openai gateway service not available"}
+		}
+		_, err = s.openAIGatewayService.Forward(ctx, c, account, body)
+	case opsRetryTypeEmbedding:
+		if s.openAIGatewayService == nil {
+			return &opsForwardUnavailableError{"openai gateway service not available"}
+		}
+		_, err = s.openAIGatewayService.ForwardEmbeddings(ctx, c, account, body)
+	case opsRetryTypeGeminiV1B:
+		switch account.Platform {
+		case PlatformAntigravity:
+			if s.antigravityGatewayService == nil {
+				return &opsForwardUnavailableError{"antigravity gateway service not available"}
+			}
+			_, err = s.antigravityGatewayService.ForwardGemini(ctx, c, account, target.model, target.action, target.stream, body)
+		case PlatformAnthropic:
+			if s.gatewayService == nil {
+				return &opsForwardUnavailableError{"gateway service not available"}
+			}
+			if IsGeminiEmbeddingAction(target.action) {
+				return &opsForwardUnavailableError{"anthropic accounts do not support gemini embeddings"}
+			}
+			_, err = s.gatewayService.ForwardGeminiAsClaude(ctx, c, account, target.model, target.stream, body)
+		default:
+			if s.geminiCompatService == nil {
+				return &opsForwardUnavailableError{"gemini services not available"}
+			}
+			_, err = s.geminiCompatService.ForwardNative(ctx, c, account, target.model, target.action, target.stream, body)
+		}
+	case opsRetryTypeMessages:
+		switch account.Platform {
+		case PlatformAntigravity:
+			if s.antigravityGatewayService == nil {
+				return &opsForwardUnavailableError{"antigravity gateway service not available"}
+			}
+			_, err = s.antigravityGatewayService.Forward(ctx, c, account, body)
+		case PlatformGemini:
+			if s.geminiCompatService == nil {
+				return &opsForwardUnavailableError{"gemini gateway service not available"}
+			}
+			_, err = s.geminiCompatService.Forward(ctx, c, account, body)
+		default:
+			if s.gatewayService == nil {
+				return &opsForwardUnavailableError{"gateway service not available"}
+			}
+			parsedReq, parseErr := ParseGatewayRequest(body)
+			if parseErr != nil {
+				return &opsForwardUnavailableError{"failed to parse request body"}
+			}
+			_, err = s.gatewayService.Forward(ctx, c, account, parsedReq)
+		}
+	default:
+		return &opsForwardUnavailableError{"unsupported retry type"}
+	}
+	return err
+}
+
 func newOpsRetryContext(ctx context.Context, errorLog *OpsErrorLogDetail) (*gin.Context, *limitedResponseWriter) {
 	w := newLimitedResponseWriter(opsRetryCaptureBytesLimit)
 	c, _ := gin.CreateTestContext(w)
@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
-	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/robfig/cron/v3"
 )
@@ -77,7 +76,7 @@ func NewOpsScheduledReportService(
 		redisClient:  redisClient,
 		cfg:          cfg,
 
-		instanceID:        uuid.NewString(),
+		instanceID:        processInstanceID,
 		loc:               loc,
 		distributedLockOn: lockOn,
 		warnNoRedisOnce:   sync.Once{},
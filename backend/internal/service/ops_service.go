@@ -36,6 +36,7 @@ type OpsService struct {
 	openAIGatewayService      *OpenAIGatewayService
 	geminiCompatService       *GeminiMessagesCompatService
 	antigravityGatewayService *AntigravityGatewayService
+	circuitBreaker            *CircuitBreakerService
 }
 
 func NewOpsService(
@@ -48,6 +49,7 @@ func NewOpsService(
 	openAIGatewayService *OpenAIGatewayService,
 	geminiCompatService *GeminiMessagesCompatService,
 	antigravityGatewayService *AntigravityGatewayService,
+	circuitBreaker *CircuitBreakerService,
 ) *OpsService {
 	return &OpsService{
 		opsRepo:     opsRepo,
@@ -61,6 +63,7 @@ func NewOpsService(
 		openAIGatewayService:      openAIGatewayService,
 		geminiCompatService:       geminiCompatService,
 		antigravityGatewayService: antigravityGatewayService,
+		circuitBreaker:            circuitBreaker,
 	}
 }
 
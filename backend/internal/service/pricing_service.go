@@ -24,6 +24,9 @@ var (
 	openAIModelBasePattern = regexp.MustCompile(`^(gpt-\d+(?:\.\d+)?)(?:-|$)`)
 )
 
+// PricingModeEmbedding LiteLLM 中向量嵌入模型的 mode 取值
+const PricingModeEmbedding = "embedding"
+
 // LiteLLMModelPricing LiteLLM价格数据结构
 // 只保留我们需要的字段，使用指针来处理可能缺失的值
 type LiteLLMModelPricing struct {
@@ -497,6 +500,28 @@ func (s *PricingService) GetModelPricing(modelName string) *LiteLLMModelPricing
 	return nil
 }
 
+// GetEmbeddingPricing 获取向量嵌入模型价格（仅匹配 mode=embedding 的条目，不做模糊匹配，
+// 避免嵌入模型按同名前缀的对话模型计费）。
+// Gemini 嵌入模型在 LiteLLM 中以 "gemini/" 前缀登记，这里同时尝试带前缀的名称。
+func (s *PricingService) GetEmbeddingPricing(modelName string) *LiteLLMModelPricing {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if modelName == "" {
+		return nil
+	}
+
+	modelLower := strings.ToLower(strings.TrimSpace(modelName))
+	for _, candidate := range s.buildModelLookupCandidates(modelLower) {
+		for _, key := range []string{candidate, "gemini/" + candidate, "openai/" + candidate} {
+			if pricing, ok := s.pricingData[key]; ok && pricing.Mode == PricingModeEmbedding {
+				return pricing
+			}
+		}
+	}
+	return nil
+}
+
 func (s *PricingService) buildModelLookupCandidates(modelLower string) []string {
 	// Prefer canonical model name first (this also improves billing compatibility with "models/xxx").
 	candidates := []string{
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+const (
+	promptCacheTTLDefault = 5 * time.Minute // Anthropic ephemeral 缓存默认 TTL
+	promptCacheTTLLong    = time.Hour       // cache_control.ttl = "1h"
+	// 估算 token 数时每个 token 对应的字节数（粗略估算，仅用于权衡排队成本）
+	promptCacheBytesPerToken = 4
+)
+
+// PromptCachePrefix 表示请求中某个 cache_control 断点之前的完整前缀（tools → system → messages）。
+type PromptCachePrefix struct {
+	Hash            string        // 前缀内容 hash（含模型）
+	TTL             time.Duration // 缓存 TTL（5m 或 1h）
+	EstimatedTokens int           // 前缀长度估算（token）
+}
+
+// PromptCacheAffinityCache 记录各账号持有的热 prompt 缓存前缀。
+type PromptCacheAffinityCache interface {
+	// MarkWarm 标记账号已缓存这些前缀，各前缀按自身 TTL 过期
+	MarkWarm(ctx context.Context, accountID int64, prefixes []PromptCachePrefix, now time.Time) error
+	// GetWarmAccounts 返回每个前缀 hash 当前仍持有热缓存的账号 ID
+	GetWarmAccounts(ctx context.Context, prefixHashes []string, now time.Time) (map[string][]int64, error)
+}
+
+// extractPromptCachePrefixes 按 Anthropic prompt 缓存的前缀顺序（tools → system → messages）
+// 计算每个 cache_control 断点对应的前缀 hash，结果按前缀长度递增排列。
+// 缓存按模型隔离，因此 hash 中包含模型名；cache_control 本身不计入前缀内容。
+func extractPromptCachePrefixes(parsed *ParsedRequest) []PromptCachePrefix {
+	if parsed == nil {
+		return nil
+	}
+
+	hasher := sha256.New()
+	_, _ = hasher.Write([]byte(parsed.Model))
+	var size int
+	var prefixes []PromptCachePrefix
+
+	writeBlock := func(block any) {
+		cc, content := splitCacheControl(block)
+		data, err := json.Marshal(content)
+		if err != nil {
+			return
+		}
+		_, _ = hasher.Write([]byte{'\n'})
+		_, _ = hasher.Write(data)
+		size += len(data)
+		if cc == nil || cc["type"] != "ephemeral" || len(prefixes) >= maxCacheControlBlocks {
+			return
+		}
+		ttl := promptCacheTTLDefault
+		if cc["ttl"] == "1h" {
+			ttl = promptCacheTTLLong
+		}
+		sum := hasher.Sum(nil)
+		prefixes = append(prefixes, PromptCachePrefix{
+			Hash:            hex.EncodeToString(sum[:16]),
+			TTL:             ttl,
+			EstimatedTokens: size / promptCacheBytesPerToken,
+		})
+	}
+
+	for _, tool := range parsed.Tools {
+		writeBlock(tool)
+	}
+	switch system := parsed.System.(type) {
+	case string:
+		writeBlock(system)
+	case []any:
+		for _, part := range system {
+			writeBlock(part)
+		}
+	}
+	for _, msg := range parsed.Messages {
+		msgMap, ok := msg.(map[string]any)
+		if !ok {
+			continue
+		}
+		// 角色边界也是前缀的一部分
+		writeBlock(msgMap["role"])
+		switch content := msgMap["content"].(type) {
+		case string:
+			writeBlock(content)
+		case []any:
+			for _, part := range content {
+				writeBlock(part)
+			}
+		}
+	}
+	return prefixes
+}
+
+// splitCacheControl 拆分内容块中的 cache_control，返回 cache_control 与去除该字段后的内容。
+func splitCacheControl(block any) (map[string]any, any) {
+	blockMap, ok := block.(map[string]any)
+	if !ok {
+		return nil, block
+	}
+	cc, ok := blockMap["cache_control"].(map[string]any)
+	if !ok {
+		return nil, block
+	}
+	content := make(map[string]any, len(blockMap)-1)
+	for k, v := range blockMap {
+		if k != "cache_control" {
+			content[k] = v
+		}
+	}
+	return cc, content
+}
+
+// promptCacheWaitBudget 权衡缓存未命中成本与排队等待：前缀越长，越值得在热账号上排队。
+// 返回愿意在热账号上排队的最大等待请求数（不超过 maxWaiting）。
+func promptCacheWaitBudget(prefixTokens, tokensPerWaiting, maxWaiting int) int {
+	if tokensPerWaiting <= 0 || prefixTokens <= 0 {
+		return 0
+	}
+	budget := prefixTokens / tokensPerWaiting
+	if budget > maxWaiting {
+		budget = maxWaiting
+	}
+	return budget
+}
+
+func (s *GatewayService) cacheAffinityEnabled() bool {
+	return s.promptCacheAffinity != nil && s.cfg != nil && s.cfg.Gateway.Scheduling.CacheAffinity.Enabled
+}
+
+// WithPromptCacheAffinity 解析请求中的 prompt 缓存前缀并写入 context，供后续调度与转发使用。
+// 未启用缓存亲和调度或请求不含足够长的缓存前缀时原样返回 ctx。
+func (s *GatewayService) WithPromptCacheAffinity(ctx context.Context, parsed *ParsedRequest) context.Context {
+	if !s.cacheAffinityEnabled() {
+		return ctx
+	}
+	minTokens := s.cfg.Gateway.Scheduling.CacheAffinity.MinPrefixTokens
+	var prefixes []PromptCachePrefix
+	for _, p := range extractPromptCachePrefixes(parsed) {
+		if p.EstimatedTokens >= minTokens {
+			prefixes = append(prefixes, p)
+		}
+	}
+	if len(prefixes) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxkey.PromptCachePrefixes, prefixes)
+}
+
+func promptCachePrefixesFromContext(ctx context.Context) []PromptCachePrefix {
+	prefixes, _ := ctx.Value(ctxkey.PromptCachePrefixes).([]PromptCachePrefix)
+	return prefixes
+}
+
+// warmPrefixDepths 查询候选账号持有的最深热前缀，返回 accountID -> 前缀序号（从 1 开始，越大越长）。
+func (s *GatewayService) warmPrefixDepths(ctx context.Context, prefixes []PromptCachePrefix) map[int64]int {
+	if len(prefixes) == 0 || !s.cacheAffinityEnabled() {
+		return nil
+	}
+	hashes := make([]string, 0, len(prefixes))
+	for _, p := range prefixes {
+		hashes = append(hashes, p.Hash)
+	}
+	warm, err := s.promptCacheAffinity.GetWarmAccounts(ctx, hashes, time.Now())
+	if err != nil {
+		log.Printf("[CacheAffinity] get warm accounts failed: %v", err)
+		return nil
+	}
+	depths := make(map[int64]int)
+	for i, hash := range hashes {
+		for _, accountID := range warm[hash] {
+			if i+1 > depths[accountID] {
+				depths[accountID] = i + 1
+			}
+		}
+	}
+	return depths
+}
+
+// cacheAffinityWaitPlan 在热账号均无空闲槽位时，为持有最深热前缀的账号生成等待计划。
+// 仅当该账号当前排队数低于按前缀长度计算的等待预算时才排队，否则返回 nil 交由兜底层处理。
+func (s *GatewayService) cacheAffinityWaitPlan(ctx context.Context, candidates []*Account, warmDepth map[int64]int, prefixes []PromptCachePrefix, cfg config.GatewaySchedulingConfig, sessionHash string) *AccountSelectionResult {
+	if len(warmDepth) == 0 || s.concurrencyService == nil {
+		return nil
+	}
+	var best *Account
+	for _, acc := range candidates {
+		depth := warmDepth[acc.ID]
+		if depth == 0 {
+			continue
+		}
+		if best == nil || depth > warmDepth[best.ID] {
+			best = acc
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	prefix := prefixes[warmDepth[best.ID]-1]
+	budget := promptCacheWaitBudget(prefix.EstimatedTokens, cfg.CacheAffinity.TokensPerWaitingRequest, cfg.StickySessionMaxWaiting)
+	if budget <= 0 {
+		return nil
+	}
+	waitingCount, err := s.concurrencyService.GetAccountWaitingCount(ctx, best.ID)
+	if err != nil || waitingCount >= budget {
+		return nil
+	}
+	if !s.checkAndRegisterSession(ctx, best, sessionHash) {
+		return nil
+	}
+	return &AccountSelectionResult{
+		Account: best,
+		WaitPlan: &AccountWaitPlan{
+			AccountID:      best.ID,
+			MaxConcurrency: best.Concurrency,
+			Timeout:        cfg.StickySessionWaitTimeout,
+			MaxWaiting:     budget,
+		},
+	}
+}
+
+// markPromptCacheWarm 在上游实际写入或命中缓存后记录该账号持有的前缀。
+func (s *GatewayService) markPromptCacheWarm(ctx context.Context, accountID int64, usage ClaudeUsage) {
+	if !s.cacheAffinityEnabled() || (usage.CacheCreationInputTokens <= 0 && usage.CacheReadInputTokens <= 0) {
+		return
+	}
+	// dry run 的 usage 来自模拟响应，上游并未真正写入缓存
+	if IsUpstreamDryRun(ctx) {
+		return
+	}
+	prefixes := promptCachePrefixesFromContext(ctx)
+	if len(prefixes) == 0 {
+		return
+	}
+	// 请求 context 可能已因客户端断开而取消，这里使用独立超时
+	markCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := s.promptCacheAffinity.MarkWarm(markCtx, accountID, prefixes, time.Now()); err != nil {
+		log.Printf("[CacheAffinity] mark warm failed: account=%d err=%v", accountID, err)
+	}
+}
@@ -0,0 +1,193 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type promptCacheAffinityStub struct {
+	warm   map[string][]int64
+	marked map[int64][]PromptCachePrefix
+}
+
+func (s *promptCacheAffinityStub) MarkWarm(_ context.Context, accountID int64, prefixes []PromptCachePrefix, _ time.Time) error {
+	if s.marked == nil {
+		s.marked = make(map[int64][]PromptCachePrefix)
+	}
+	s.marked[accountID] = prefixes
+	return nil
+}
+
+func (s *promptCacheAffinityStub) GetWarmAccounts(_ context.Context, prefixHashes []string, _ time.Time) (map[string][]int64, error) {
+	out := make(map[string][]int64)
+	for _, h := range prefixHashes {
+		if ids, ok := s.warm[h]; ok {
+			out[h] = ids
+		}
+	}
+	return out, nil
+}
+
+func cacheAffinityTestRequest(t *testing.T, model, system string, turns []string, ttl string) *ParsedRequest {
+	t.Helper()
+	cc := map[string]any{"type": "ephemeral"}
+	if ttl != "" {
+		cc["ttl"] = ttl
+	}
+	messages := make([]any, 0, len(turns))
+	for i, text := range turns {
+		block := map[string]any{"type": "text", "text": text}
+		// 仅最后一条消息带缓存断点（客户端通常将断点移动到最新一轮）
+		if i == len(turns)-1 {
+			block["cache_control"] = map[string]any{"type": "ephemeral"}
+		}
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages = append(messages, map[string]any{"role": role, "content": []any{block}})
+	}
+	return &ParsedRequest{
+		Model:    model,
+		System:   []any{map[string]any{"type": "text", "text": system, "cache_control": cc}},
+		Messages: messages,
+		Tools:    []any{map[string]any{"name": "read_file", "input_schema": map[string]any{"type": "object"}}},
+	}
+}
+
+func TestExtractPromptCachePrefixes(t *testing.T) {
+	system := strings.Repeat("s", 8000)
+	first := extractPromptCachePrefixes(cacheAffinityTestRequest(t, "claude-sonnet-4-5", system, []string{"hello"}, ""))
+	require.Len(t, first, 2)
+	require.Equal(t, promptCacheTTLDefault, first[0].TTL)
+	require.Greater(t, first[0].EstimatedTokens, 2000)
+	require.Greater(t, first[1].EstimatedTokens, first[0].EstimatedTokens)
+
+	// 下一轮对话：system 断点前缀不变，消息断点前缀随对话增长而变化
+	next := extractPromptCachePrefixes(cacheAffinityTestRequest(t, "claude-sonnet-4-5", system, []string{"hello", "hi", "again"}, ""))
+	require.Len(t, next, 2)
+	require.Equal(t, first[0].Hash, next[0].Hash)
+	require.NotEqual(t, first[1].Hash, next[1].Hash)
+
+	// 缓存按模型隔离
+	otherModel := extractPromptCachePrefixes(cacheAffinityTestRequest(t, "claude-opus-4-1", system, []string{"hello"}, ""))
+	require.NotEqual(t, first[0].Hash, otherModel[0].Hash)
+
+	// TTL 不影响前缀内容
+	longTTL := extractPromptCachePrefixes(cacheAffinityTestRequest(t, "claude-sonnet-4-5", system, []string{"hello"}, "1h"))
+	require.Equal(t, promptCacheTTLLong, longTTL[0].TTL)
+	require.Equal(t, first[0].Hash, longTTL[0].Hash)
+
+	require.Empty(t, extractPromptCachePrefixes(&ParsedRequest{Model: "m", System: "plain"}))
+	require.Nil(t, extractPromptCachePrefixes(nil))
+}
+
+func TestPromptCacheWaitBudget(t *testing.T) {
+	require.Equal(t, 0, promptCacheWaitBudget(50000, 0, 3))
+	require.Equal(t, 0, promptCacheWaitBudget(10000, 20000, 3))
+	require.Equal(t, 2, promptCacheWaitBudget(40000, 20000, 3))
+	require.Equal(t, 3, promptCacheWaitBudget(200000, 20000, 3))
+}
+
+func TestGatewayService_SelectAccountWithLoadAwareness_CacheAffinity(t *testing.T) {
+	newSvc := func(affinity *promptCacheAffinityStub, concurrencyCache *mockConcurrencyCache) *GatewayService {
+		repo := &mockAccountRepoForPlatform{
+			accounts: []Account{
+				{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+				{ID: 2, Platform: PlatformAnthropic, Priority: 2, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			},
+			accountsByID: map[int64]*Account{},
+		}
+		for i := range repo.accounts {
+			repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+		}
+		cfg := testConfig()
+		cfg.Gateway.Scheduling.LoadBatchEnabled = true
+		cfg.Gateway.Scheduling.StickySessionMaxWaiting = 3
+		cfg.Gateway.Scheduling.StickySessionWaitTimeout = time.Minute
+		cfg.Gateway.Scheduling.FallbackWaitTimeout = time.Second
+		cfg.Gateway.Scheduling.FallbackMaxWaiting = 10
+		cfg.Gateway.Scheduling.CacheAffinity.Enabled = true
+		cfg.Gateway.Scheduling.CacheAffinity.MinPrefixTokens = 1024
+		cfg.Gateway.Scheduling.CacheAffinity.TokensPerWaitingRequest = 1000
+		return &GatewayService{
+			accountRepo:         repo,
+			cache:               &mockGatewayCacheForPlatform{},
+			cfg:                 cfg,
+			concurrencyService:  NewConcurrencyService(concurrencyCache),
+			promptCacheAffinity: affinity,
+		}
+	}
+	parsed := cacheAffinityTestRequest(t, "claude-sonnet-4-5", strings.Repeat("s", 8000), []string{"hello"}, "")
+	prefixes := extractPromptCachePrefixes(parsed)
+
+	t.Run("优先选择持有热缓存的账号", func(t *testing.T) {
+		affinity := &promptCacheAffinityStub{warm: map[string][]int64{prefixes[0].Hash: {2}}}
+		svc := newSvc(affinity, &mockConcurrencyCache{})
+		ctx := svc.WithPromptCacheAffinity(context.Background(), parsed)
+
+		result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "", "claude-sonnet-4-5", nil, "")
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+		require.Equal(t, int64(2), result.Account.ID, "热缓存账号应优先于更高优先级的冷账号")
+	})
+
+	t.Run("热账号满载时按前缀长度排队等待", func(t *testing.T) {
+		affinity := &promptCacheAffinityStub{warm: map[string][]int64{prefixes[0].Hash: {2}}}
+		concurrencyCache := &mockConcurrencyCache{
+			loadMap:        map[int64]*AccountLoadInfo{2: {AccountID: 2, LoadRate: 100}},
+			acquireResults: map[int64]bool{1: false},
+		}
+		svc := newSvc(affinity, concurrencyCache)
+		ctx := svc.WithPromptCacheAffinity(context.Background(), parsed)
+
+		result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "", "claude-sonnet-4-5", nil, "")
+		require.NoError(t, err)
+		require.False(t, result.Acquired)
+		require.NotNil(t, result.WaitPlan)
+		require.Equal(t, int64(2), result.Account.ID)
+		require.Equal(t, time.Minute, result.WaitPlan.Timeout)
+	})
+
+	t.Run("热账号排队已超预算时回退", func(t *testing.T) {
+		affinity := &promptCacheAffinityStub{warm: map[string][]int64{prefixes[0].Hash: {2}}}
+		concurrencyCache := &mockConcurrencyCache{
+			loadMap:    map[int64]*AccountLoadInfo{2: {AccountID: 2, LoadRate: 100}},
+			waitCounts: map[int64]int{2: 3},
+		}
+		svc := newSvc(affinity, concurrencyCache)
+		ctx := svc.WithPromptCacheAffinity(context.Background(), parsed)
+
+		result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "", "claude-sonnet-4-5", nil, "")
+		require.NoError(t, err)
+		require.True(t, result.Acquired)
+		require.Equal(t, int64(1), result.Account.ID)
+	})
+
+	t.Run("命中或写入缓存后记录热前缀", func(t *testing.T) {
+		affinity := &promptCacheAffinityStub{}
+		svc := newSvc(affinity, &mockConcurrencyCache{})
+		ctx := svc.WithPromptCacheAffinity(context.Background(), parsed)
+
+		svc.markPromptCacheWarm(ctx, 1, ClaudeUsage{InputTokens: 10})
+		require.Empty(t, affinity.marked, "未使用缓存时不记录")
+
+		svc.markPromptCacheWarm(ctx, 1, ClaudeUsage{CacheCreationInputTokens: 2000})
+		require.Equal(t, prefixes, affinity.marked[1])
+	})
+
+	t.Run("dry run 不记录热前缀", func(t *testing.T) {
+		affinity := &promptCacheAffinityStub{}
+		svc := newSvc(affinity, &mockConcurrencyCache{})
+		ctx, _ := WithUpstreamDryRun(svc.WithPromptCacheAffinity(context.Background(), parsed))
+
+		svc.markPromptCacheWarm(ctx, 1, ClaudeUsage{CacheCreationInputTokens: 2000})
+		require.Empty(t, affinity.marked)
+	})
+}
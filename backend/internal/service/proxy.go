@@ -40,6 +40,8 @@ type ProxyWithAccountCount struct {
 	CountryCode    string
 	Region         string
 	City           string
+	// ConsecutiveFailures 连续探测失败次数（代理池健康检查据此判定失效）
+	ConsecutiveFailures int
 }
 
 type ProxyAccountSummary struct {
@@ -6,15 +6,17 @@ import (
 )
 
 type ProxyLatencyInfo struct {
-	Success     bool      `json:"success"`
-	LatencyMs   *int64    `json:"latency_ms,omitempty"`
-	Message     string    `json:"message,omitempty"`
-	IPAddress   string    `json:"ip_address,omitempty"`
-	Country     string    `json:"country,omitempty"`
-	CountryCode string    `json:"country_code,omitempty"`
-	Region      string    `json:"region,omitempty"`
-	City        string    `json:"city,omitempty"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Success     bool   `json:"success"`
+	LatencyMs   *int64 `json:"latency_ms,omitempty"`
+	Message     string `json:"message,omitempty"`
+	IPAddress   string `json:"ip_address,omitempty"`
+	Country     string `json:"country,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+	Region      string `json:"region,omitempty"`
+	City        string `json:"city,omitempty"`
+	// ConsecutiveFailures 连续探测失败次数，成功后清零；达到阈值的代理池成员被判定为失效
+	ConsecutiveFailures int       `json:"consecutive_failures,omitempty"`
+	UpdatedAt           time.Time `json:"updated_at"`
 }
 
 type ProxyLatencyCache interface {
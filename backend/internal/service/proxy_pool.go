@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrProxyPoolNotFound = infraerrors.NotFound("PROXY_POOL_NOT_FOUND", "proxy pool not found")
+	ErrProxyPoolInUse    = infraerrors.Conflict("PROXY_POOL_IN_USE", "proxy pool is in use by accounts")
+	ErrProxyPoolEmpty    = infraerrors.BadRequest("PROXY_POOL_EMPTY", "proxy pool must contain at least one proxy")
+	// ErrProxyPoolNoMember 代理池不存在或没有可用成员（网关请求失败时返回）
+	ErrProxyPoolNoMember = infraerrors.ServiceUnavailable("PROXY_POOL_NO_MEMBER", "proxy pool has no available proxy")
+	// ErrAccountProxyConflict 账户不能同时绑定代理和代理池
+	ErrAccountProxyConflict = infraerrors.BadRequest("ACCOUNT_PROXY_CONFLICT", "proxy_id and proxy_pool_id are mutually exclusive")
+)
+
+// proxyPoolURLScheme 代理池伪地址协议。
+// 绑定代理池的账户在网关请求中使用 proxypool://{poolID} 作为代理地址，
+// 由 HTTPUpstream 在发送时解析为具体成员并在网络错误时切换。
+const proxyPoolURLScheme = "proxypool://"
+
+// ProxyPool 代理池：一组代理的命名集合
+type ProxyPool struct {
+	ID          int64
+	Name        string
+	Description string
+	Status      string
+	ProxyIDs    []int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (p *ProxyPool) IsActive() bool {
+	return p.Status == StatusActive
+}
+
+// ProxyPoolMember 代理池成员及其健康状态
+type ProxyPoolMember struct {
+	Proxy
+	Healthy bool
+	Health  *ProxyLatencyInfo
+}
+
+// ProxyPoolDetail 代理池详情（成员健康状态与绑定账户数）
+type ProxyPoolDetail struct {
+	ProxyPool
+	Members      []ProxyPoolMember
+	AccountCount int64
+}
+
+type ProxyPoolRepository interface {
+	// Create 创建代理池并写入成员
+	Create(ctx context.Context, pool *ProxyPool) error
+	GetByID(ctx context.Context, id int64) (*ProxyPool, error)
+	// Update 更新代理池字段并整体替换成员
+	Update(ctx context.Context, pool *ProxyPool) error
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context) ([]ProxyPool, error)
+	CountAccountsByPoolID(ctx context.Context, poolID int64) (int64, error)
+}
+
+// CreateProxyPoolInput 创建代理池参数
+type CreateProxyPoolInput struct {
+	Name        string
+	Description string
+	ProxyIDs    []int64
+}
+
+// UpdateProxyPoolInput 更新代理池参数（nil 表示不修改）
+type UpdateProxyPoolInput struct {
+	Name        *string
+	Description *string
+	Status      *string
+	ProxyIDs    *[]int64
+}
+
+// ProxyPoolURL 返回代理池伪地址
+func ProxyPoolURL(poolID int64) string {
+	return proxyPoolURLScheme + strconv.FormatInt(poolID, 10)
+}
+
+// parseProxyPoolURL 解析代理池伪地址，非代理池地址返回 false
+func parseProxyPoolURL(proxyURL string) (int64, bool) {
+	if !strings.HasPrefix(proxyURL, proxyPoolURLScheme) {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(proxyURL, proxyPoolURLScheme), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
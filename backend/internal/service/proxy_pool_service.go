@@ -0,0 +1,669 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+const (
+	// 代理池快照中找不到目标代理池时，两次同步重载之间的最小间隔
+	proxyPoolLazyReloadInterval = 10 * time.Second
+	// 健康检查未启用时的快照刷新间隔
+	proxyPoolDefaultRefreshInterval = time.Minute
+	// 单轮健康检查的最大并发探测数
+	proxyPoolProbeConcurrency = 4
+)
+
+// ProxyPoolService 代理池服务
+//
+//   - 账户绑定代理池后，按 (账户, 代理) 的最高随机权重（rendezvous hashing）在健康成员中选取首选出口，
+//     成员增减或失效时只影响原本落在该成员上的账户，其余账户出口 IP 保持稳定。
+//   - 后台定期通过 ProxyExitInfoProber 探测成员延迟与出口信息，结果写入 ProxyLatencyCache 供多实例共享；
+//     连续失败达到阈值的成员判定为失效，不再作为首选。
+//   - 请求级网络错误同样计入失败（进程内），由 HTTPUpstream 装饰器切换到下一个成员重试。
+type ProxyPoolService struct {
+	poolRepo     ProxyPoolRepository
+	proxyRepo    ProxyRepository
+	latencyCache ProxyLatencyCache
+	prober       ProxyExitInfoProber
+	cfg          *config.Config
+
+	mu            sync.RWMutex
+	pools         map[int64]*proxyPoolSnapshot
+	proxyIDsByURL map[string]int64
+	health        map[int64]*ProxyLatencyInfo
+	failures      map[int64]int // 请求网络错误导致的连续失败次数
+	loadedAt      time.Time
+
+	reloadMu sync.Mutex
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+type proxyPoolSnapshot struct {
+	pool    ProxyPool
+	members []Proxy
+}
+
+// NewProxyPoolService 创建代理池服务
+func NewProxyPoolService(
+	poolRepo ProxyPoolRepository,
+	proxyRepo ProxyRepository,
+	latencyCache ProxyLatencyCache,
+	prober ProxyExitInfoProber,
+	cfg *config.Config,
+) *ProxyPoolService {
+	return &ProxyPoolService{
+		poolRepo:      poolRepo,
+		proxyRepo:     proxyRepo,
+		latencyCache:  latencyCache,
+		prober:        prober,
+		cfg:           cfg,
+		pools:         make(map[int64]*proxyPoolSnapshot),
+		proxyIDsByURL: make(map[string]int64),
+		health:        make(map[int64]*ProxyLatencyInfo),
+		failures:      make(map[int64]int),
+		stopCh:        make(chan struct{}),
+	}
+}
+
+func (s *ProxyPoolService) healthCheckEnabled() bool {
+	return s.cfg != nil && s.cfg.ProxyPool.HealthCheckEnabled && s.prober != nil
+}
+
+func (s *ProxyPoolService) refreshInterval() time.Duration {
+	if s.healthCheckEnabled() && s.cfg.ProxyPool.HealthCheckIntervalSeconds > 0 {
+		return time.Duration(s.cfg.ProxyPool.HealthCheckIntervalSeconds) * time.Second
+	}
+	return proxyPoolDefaultRefreshInterval
+}
+
+func (s *ProxyPoolService) failureThreshold() int {
+	if s.cfg != nil && s.cfg.ProxyPool.FailureThreshold > 0 {
+		return s.cfg.ProxyPool.FailureThreshold
+	}
+	return 3
+}
+
+func (s *ProxyPoolService) maxFailoverAttempts() int {
+	if s.cfg != nil && s.cfg.ProxyPool.MaxFailoverAttempts > 0 {
+		return s.cfg.ProxyPool.MaxFailoverAttempts
+	}
+	return 3
+}
+
+// Start 启动后台快照刷新与健康检查
+func (s *ProxyPoolService) Start() {
+	if s == nil || s.poolRepo == nil || s.proxyRepo == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.refreshInterval())
+		defer ticker.Stop()
+
+		s.runOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台任务
+func (s *ProxyPoolService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *ProxyPoolService) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := s.reload(ctx); err != nil {
+		slog.Warn("proxy_pool_reload_failed", "error", err)
+	}
+	cancel()
+
+	if s.healthCheckEnabled() {
+		// 单次探测自带超时（30s），整轮预留足够时间
+		probeCtx, probeCancel := context.WithTimeout(context.Background(), s.refreshInterval())
+		s.probeMembers(probeCtx)
+		probeCancel()
+	}
+}
+
+// reload 从数据库重建代理池快照，并同步共享健康状态
+func (s *ProxyPoolService) reload(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	pools, err := s.poolRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list proxy pools: %w", err)
+	}
+	proxies, err := s.proxyRepo.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("list active proxies: %w", err)
+	}
+	proxyByID := make(map[int64]Proxy, len(proxies))
+	for i := range proxies {
+		proxyByID[proxies[i].ID] = proxies[i]
+	}
+
+	snapshots := make(map[int64]*proxyPoolSnapshot, len(pools))
+	proxyIDsByURL := make(map[string]int64)
+	memberIDs := make([]int64, 0)
+	memberSet := make(map[int64]struct{})
+	for i := range pools {
+		if !pools[i].IsActive() {
+			continue
+		}
+		snap := &proxyPoolSnapshot{pool: pools[i]}
+		for _, id := range pools[i].ProxyIDs {
+			proxy, ok := proxyByID[id]
+			if !ok {
+				continue
+			}
+			snap.members = append(snap.members, proxy)
+			if _, seen := memberSet[proxy.ID]; !seen {
+				memberSet[proxy.ID] = struct{}{}
+				proxyIDsByURL[proxy.URL()] = proxy.ID
+				memberIDs = append(memberIDs, proxy.ID)
+			}
+		}
+		snapshots[pools[i].ID] = snap
+	}
+
+	health := make(map[int64]*ProxyLatencyInfo, len(memberIDs))
+	if s.latencyCache != nil && len(memberIDs) > 0 {
+		cached, err := s.latencyCache.GetProxyLatencies(ctx, memberIDs)
+		if err != nil {
+			slog.Warn("proxy_pool_load_health_failed", "error", err)
+		}
+		for id, info := range cached {
+			health[id] = info
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pools = snapshots
+	s.proxyIDsByURL = proxyIDsByURL
+	for id, info := range health {
+		// 其他实例的探测结果较新时采用之
+		if prev := s.health[id]; prev == nil || !info.UpdatedAt.Before(prev.UpdatedAt) {
+			s.health[id] = info
+		}
+	}
+	for id := range s.health {
+		if _, ok := memberSet[id]; !ok {
+			delete(s.health, id)
+		}
+	}
+	s.loadedAt = time.Now()
+	return nil
+}
+
+// probeMembers 探测所有代理池成员；其他实例近期已探测过的成员跳过
+func (s *ProxyPoolService) probeMembers(ctx context.Context) {
+	interval := s.refreshInterval()
+	now := time.Now()
+
+	s.mu.RLock()
+	targets := make([]Proxy, 0)
+	seen := make(map[int64]struct{})
+	for _, snap := range s.pools {
+		for _, member := range snap.members {
+			if _, ok := seen[member.ID]; ok {
+				continue
+			}
+			seen[member.ID] = struct{}{}
+			if info := s.health[member.ID]; info != nil && now.Sub(info.UpdatedAt) < interval*4/5 {
+				continue
+			}
+			targets = append(targets, member)
+		}
+	}
+	s.mu.RUnlock()
+
+	sem := make(chan struct{}, proxyPoolProbeConcurrency)
+	var wg sync.WaitGroup
+	for i := range targets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(proxy Proxy) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.probeProxy(ctx, proxy)
+		}(targets[i])
+	}
+	wg.Wait()
+}
+
+func (s *ProxyPoolService) probeProxy(ctx context.Context, proxy Proxy) {
+	exitInfo, latencyMs, err := s.prober.ProbeProxy(ctx, proxy.URL())
+	info := &ProxyLatencyInfo{UpdatedAt: time.Now()}
+	if err != nil {
+		info.Message = err.Error()
+	} else {
+		latency := latencyMs
+		info.Success = true
+		info.LatencyMs = &latency
+		info.Message = "Proxy is accessible"
+		info.IPAddress = exitInfo.IP
+		info.Country = exitInfo.Country
+		info.CountryCode = exitInfo.CountryCode
+		info.Region = exitInfo.Region
+		info.City = exitInfo.City
+	}
+
+	threshold := s.failureThreshold()
+	s.mu.Lock()
+	prev := s.health[proxy.ID]
+	applyProxyProbeResult(prev, info)
+	wasDead := prev != nil && prev.ConsecutiveFailures >= threshold
+	s.health[proxy.ID] = info
+	if info.Success {
+		delete(s.failures, proxy.ID)
+	}
+	s.mu.Unlock()
+
+	if s.latencyCache != nil {
+		if err := s.latencyCache.SetProxyLatency(ctx, proxy.ID, info); err != nil {
+			slog.Warn("proxy_pool_save_health_failed", "proxy_id", proxy.ID, "error", err)
+		}
+	}
+
+	isDead := info.ConsecutiveFailures >= threshold
+	switch {
+	case isDead && !wasDead:
+		slog.Warn("proxy_pool_member_dead", "proxy_id", proxy.ID, "proxy_name", proxy.Name, "failures", info.ConsecutiveFailures, "error", info.Message)
+	case !isDead && wasDead:
+		slog.Info("proxy_pool_member_recovered", "proxy_id", proxy.ID, "proxy_name", proxy.Name, "exit_ip", info.IPAddress)
+	}
+}
+
+// applyProxyProbeResult 根据上一次结果累计连续失败次数。
+// 探测失败时沿用上次成功探测到的出口信息，便于管理端查看失效前的出口。
+func applyProxyProbeResult(prev, next *ProxyLatencyInfo) {
+	if next.Success {
+		next.ConsecutiveFailures = 0
+		return
+	}
+	if prev == nil {
+		next.ConsecutiveFailures = 1
+		return
+	}
+	next.ConsecutiveFailures = prev.ConsecutiveFailures + 1
+	if next.IPAddress == "" {
+		next.IPAddress = prev.IPAddress
+		next.Country = prev.Country
+		next.CountryCode = prev.CountryCode
+		next.Region = prev.Region
+		next.City = prev.City
+	}
+}
+
+func (s *ProxyPoolService) isHealthyLocked(proxyID int64) bool {
+	threshold := s.failureThreshold()
+	if info := s.health[proxyID]; info != nil && info.ConsecutiveFailures >= threshold {
+		return false
+	}
+	return s.failures[proxyID] < threshold
+}
+
+// snapshot 获取代理池快照；快照中不存在时（如其他实例刚创建）限频同步重载
+func (s *ProxyPoolService) snapshot(poolID int64) *proxyPoolSnapshot {
+	s.mu.RLock()
+	snap := s.pools[poolID]
+	loadedAt := s.loadedAt
+	s.mu.RUnlock()
+	if snap != nil || time.Since(loadedAt) < proxyPoolLazyReloadInterval {
+		return snap
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.reload(ctx); err != nil {
+		slog.Warn("proxy_pool_reload_failed", "pool_id", poolID, "error", err)
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pools[poolID]
+}
+
+// ResolveProxyURLs 将代理地址解析为按优先级排列的候选代理地址。
+// 非代理池地址原样返回；代理池地址返回健康成员（按账户稳定排序）在前、失效成员在后的候选列表，
+// 数量不超过 max_failover_attempts。
+func (s *ProxyPoolService) ResolveProxyURLs(proxyURL string, accountID int64) ([]string, error) {
+	poolID, ok := parseProxyPoolURL(proxyURL)
+	if !ok {
+		return []string{proxyURL}, nil
+	}
+	if s == nil {
+		return nil, ErrProxyPoolNoMember
+	}
+	snap := s.snapshot(poolID)
+	if snap == nil || len(snap.members) == 0 {
+		return nil, ErrProxyPoolNoMember
+	}
+
+	s.mu.RLock()
+	ordered := orderProxyPoolMembers(snap.members, accountID, s.isHealthyLocked)
+	s.mu.RUnlock()
+
+	if limit := s.maxFailoverAttempts(); len(ordered) > limit {
+		ordered = ordered[:limit]
+	}
+	urls := make([]string, 0, len(ordered))
+	for i := range ordered {
+		urls = append(urls, ordered[i].URL())
+	}
+	return urls, nil
+}
+
+// ReportProxyResult 记录请求经由代理的结果；err 非空表示网络层失败
+func (s *ProxyPoolService) ReportProxyResult(proxyURL string, err error) {
+	if s == nil || proxyURL == "" {
+		return
+	}
+	s.mu.RLock()
+	proxyID, ok := s.proxyIDsByURL[proxyURL]
+	s.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	threshold := s.failureThreshold()
+	s.mu.Lock()
+	if err == nil {
+		delete(s.failures, proxyID)
+		s.mu.Unlock()
+		return
+	}
+	s.failures[proxyID]++
+	failures := s.failures[proxyID]
+	s.mu.Unlock()
+
+	if failures == threshold {
+		slog.Warn("proxy_pool_member_dead", "proxy_id", proxyID, "failures", failures, "source", "request", "error", err)
+	}
+}
+
+// AccountProxyURL 返回不经过 HTTPUpstream 的请求（令牌刷新、用量查询等）使用的具体代理地址：
+// 绑定代理池时取该账户当前首选的成员，否则返回空字符串由调用方按账户代理处理。
+func (s *ProxyPoolService) AccountProxyURL(account *Account) string {
+	if s == nil || account == nil || account.ProxyPoolID == nil {
+		return ""
+	}
+	urls, err := s.ResolveProxyURLs(ProxyPoolURL(*account.ProxyPoolID), account.ID)
+	if err != nil || len(urls) == 0 {
+		return ""
+	}
+	return urls[0]
+}
+
+// resolveAccountProxyURL 返回不经过 HTTPUpstream 的请求所使用的具体代理地址
+func resolveAccountProxyURL(ctx context.Context, proxyRepo ProxyRepository, pools *ProxyPoolService, account *Account) string {
+	if account == nil {
+		return ""
+	}
+	if account.ProxyPoolID != nil {
+		return pools.AccountProxyURL(account)
+	}
+	if account.ProxyID == nil {
+		return ""
+	}
+	if account.Proxy != nil {
+		return account.Proxy.URL()
+	}
+	if proxyRepo == nil {
+		return ""
+	}
+	proxy, err := proxyRepo.GetByID(ctx, *account.ProxyID)
+	if err != nil || proxy == nil {
+		return ""
+	}
+	return proxy.URL()
+}
+
+// orderProxyPoolMembers 按账户对成员排序：健康成员在前，同一健康状态内按 rendezvous 权重降序
+func orderProxyPoolMembers(members []Proxy, accountID int64, healthy func(proxyID int64) bool) []Proxy {
+	type candidate struct {
+		proxy   Proxy
+		healthy bool
+		score   uint64
+	}
+	candidates := make([]candidate, 0, len(members))
+	for i := range members {
+		candidates = append(candidates, candidate{
+			proxy:   members[i],
+			healthy: healthy(members[i].ID),
+			score:   proxyPoolScore(accountID, members[i].ID),
+		})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].healthy != candidates[j].healthy {
+			return candidates[i].healthy
+		}
+		return candidates[i].score > candidates[j].score
+	})
+	ordered := make([]Proxy, 0, len(candidates))
+	for i := range candidates {
+		ordered = append(ordered, candidates[i].proxy)
+	}
+	return ordered
+}
+
+// proxyPoolScore 计算 (账户, 代理) 的 rendezvous 权重
+func proxyPoolScore(accountID, proxyID int64) uint64 {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(accountID))
+	binary.BigEndian.PutUint64(buf[8:], uint64(proxyID))
+	h := fnv.New64a()
+	_, _ = h.Write(buf[:])
+	// splitmix64 收尾，改善相邻 ID 的分布
+	z := h.Sum64() + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// ========== 管理接口 ==========
+
+// List 列出代理池及成员健康状态
+func (s *ProxyPoolService) List(ctx context.Context) ([]ProxyPoolDetail, error) {
+	pools, err := s.poolRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list proxy pools: %w", err)
+	}
+	details := make([]ProxyPoolDetail, 0, len(pools))
+	for i := range pools {
+		detail, err := s.buildDetail(ctx, &pools[i])
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, *detail)
+	}
+	return details, nil
+}
+
+// Get 获取代理池详情
+func (s *ProxyPoolService) Get(ctx context.Context, id int64) (*ProxyPoolDetail, error) {
+	pool, err := s.poolRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildDetail(ctx, pool)
+}
+
+func (s *ProxyPoolService) buildDetail(ctx context.Context, pool *ProxyPool) (*ProxyPoolDetail, error) {
+	count, err := s.poolRepo.CountAccountsByPoolID(ctx, pool.ID)
+	if err != nil {
+		return nil, fmt.Errorf("count proxy pool accounts: %w", err)
+	}
+	var latencies map[int64]*ProxyLatencyInfo
+	if s.latencyCache != nil && len(pool.ProxyIDs) > 0 {
+		latencies, err = s.latencyCache.GetProxyLatencies(ctx, pool.ProxyIDs)
+		if err != nil {
+			slog.Warn("proxy_pool_load_health_failed", "pool_id", pool.ID, "error", err)
+		}
+	}
+
+	detail := &ProxyPoolDetail{ProxyPool: *pool, AccountCount: count, Members: make([]ProxyPoolMember, 0, len(pool.ProxyIDs))}
+	threshold := s.failureThreshold()
+	for _, proxyID := range pool.ProxyIDs {
+		proxy, err := s.proxyRepo.GetByID(ctx, proxyID)
+		if err != nil {
+			continue
+		}
+		info := latencies[proxyID]
+		s.mu.RLock()
+		passiveFailures := s.failures[proxyID]
+		s.mu.RUnlock()
+		detail.Members = append(detail.Members, ProxyPoolMember{
+			Proxy:   *proxy,
+			Health:  info,
+			Healthy: proxy.IsActive() && (info == nil || info.ConsecutiveFailures < threshold) && passiveFailures < threshold,
+		})
+	}
+	return detail, nil
+}
+
+// Create 创建代理池
+func (s *ProxyPoolService) Create(ctx context.Context, input *CreateProxyPoolInput) (*ProxyPool, error) {
+	proxyIDs, err := s.validateProxyIDs(ctx, input.ProxyIDs)
+	if err != nil {
+		return nil, err
+	}
+	pool := &ProxyPool{
+		Name:        strings.TrimSpace(input.Name),
+		Description: strings.TrimSpace(input.Description),
+		Status:      StatusActive,
+		ProxyIDs:    proxyIDs,
+	}
+	if err := s.poolRepo.Create(ctx, pool); err != nil {
+		return nil, fmt.Errorf("create proxy pool: %w", err)
+	}
+	s.reloadAfterChange(ctx)
+	return pool, nil
+}
+
+// Update 更新代理池
+func (s *ProxyPoolService) Update(ctx context.Context, id int64, input *UpdateProxyPoolInput) (*ProxyPool, error) {
+	pool, err := s.poolRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		pool.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		pool.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Status != nil {
+		pool.Status = *input.Status
+	}
+	if input.ProxyIDs != nil {
+		proxyIDs, err := s.validateProxyIDs(ctx, *input.ProxyIDs)
+		if err != nil {
+			return nil, err
+		}
+		pool.ProxyIDs = proxyIDs
+	}
+	if err := s.poolRepo.Update(ctx, pool); err != nil {
+		return nil, fmt.Errorf("update proxy pool: %w", err)
+	}
+	s.reloadAfterChange(ctx)
+	return pool, nil
+}
+
+// Delete 删除代理池（仍有账户绑定时拒绝）
+func (s *ProxyPoolService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.poolRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	count, err := s.poolRepo.CountAccountsByPoolID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("count proxy pool accounts: %w", err)
+	}
+	if count > 0 {
+		return ErrProxyPoolInUse
+	}
+	if err := s.poolRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete proxy pool: %w", err)
+	}
+	s.reloadAfterChange(ctx)
+	return nil
+}
+
+// ProbeNow 立即探测代理池全部成员
+func (s *ProxyPoolService) ProbeNow(ctx context.Context, id int64) (*ProxyPoolDetail, error) {
+	pool, err := s.poolRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.prober != nil {
+		for _, proxyID := range pool.ProxyIDs {
+			proxy, err := s.proxyRepo.GetByID(ctx, proxyID)
+			if err != nil {
+				continue
+			}
+			s.probeProxy(ctx, *proxy)
+		}
+	}
+	return s.buildDetail(ctx, pool)
+}
+
+func (s *ProxyPoolService) validateProxyIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	seen := make(map[int64]struct{}, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		if _, err := s.proxyRepo.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	if len(out) == 0 {
+		return nil, ErrProxyPoolEmpty
+	}
+	return out, nil
+}
+
+func (s *ProxyPoolService) reloadAfterChange(ctx context.Context) {
+	if err := s.reload(ctx); err != nil {
+		slog.Warn("proxy_pool_reload_failed", "error", err)
+	}
+}
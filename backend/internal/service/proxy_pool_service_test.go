@@ -0,0 +1,184 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newProxyPoolServiceForTest(t *testing.T, poolID int64, members []Proxy) *ProxyPoolService {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.ProxyPool.FailureThreshold = 2
+	cfg.ProxyPool.MaxFailoverAttempts = 3
+
+	svc := NewProxyPoolService(nil, nil, nil, nil, cfg)
+	ids := make([]int64, 0, len(members))
+	for i := range members {
+		ids = append(ids, members[i].ID)
+		svc.proxyIDsByURL[members[i].URL()] = members[i].ID
+	}
+	svc.pools[poolID] = &proxyPoolSnapshot{
+		pool:    ProxyPool{ID: poolID, Name: "pool", Status: StatusActive, ProxyIDs: ids},
+		members: members,
+	}
+	svc.loadedAt = time.Now()
+	return svc
+}
+
+func proxyPoolTestMembers(n int) []Proxy {
+	members := make([]Proxy, 0, n)
+	for i := 1; i <= n; i++ {
+		members = append(members, Proxy{ID: int64(i), Protocol: "http", Host: "10.0.0.1", Port: 8000 + i, Status: StatusActive})
+	}
+	return members
+}
+
+func TestOrderProxyPoolMembers_StableAndMinimalMovement(t *testing.T) {
+	members := proxyPoolTestMembers(5)
+	allHealthy := func(int64) bool { return true }
+
+	const accounts = 500
+	before := make(map[int64]int64, accounts)
+	for accountID := int64(1); accountID <= accounts; accountID++ {
+		first := orderProxyPoolMembers(members, accountID, allHealthy)[0].ID
+		again := orderProxyPoolMembers(members, accountID, allHealthy)[0].ID
+		require.Equal(t, first, again, "ordering must be deterministic per account")
+		before[accountID] = first
+	}
+
+	const dead = int64(3)
+	withoutDead := func(id int64) bool { return id != dead }
+	moved := 0
+	for accountID := int64(1); accountID <= accounts; accountID++ {
+		ordered := orderProxyPoolMembers(members, accountID, withoutDead)
+		require.Equal(t, dead, ordered[len(ordered)-1].ID, "dead member must be ordered last")
+		if before[accountID] != dead {
+			require.Equal(t, before[accountID], ordered[0].ID, "accounts not on the dead member keep their exit")
+		} else {
+			moved++
+		}
+	}
+	require.Greater(t, moved, 0)
+	require.Less(t, moved, accounts/2)
+}
+
+func TestProxyPoolService_ResolveProxyURLs(t *testing.T) {
+	members := proxyPoolTestMembers(5)
+	svc := newProxyPoolServiceForTest(t, 7, members)
+
+	urls, err := svc.ResolveProxyURLs("http://direct:8080", 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://direct:8080"}, urls)
+
+	urls, err = svc.ResolveProxyURLs(ProxyPoolURL(7), 42)
+	require.NoError(t, err)
+	require.Len(t, urls, 3, "candidates are capped by max_failover_attempts")
+
+	_, err = svc.ResolveProxyURLs(ProxyPoolURL(99), 42)
+	require.ErrorIs(t, err, ErrProxyPoolNoMember)
+}
+
+func TestProxyPoolService_ReportProxyResultMarksMemberDead(t *testing.T) {
+	members := proxyPoolTestMembers(3)
+	svc := newProxyPoolServiceForTest(t, 1, members)
+
+	urls, err := svc.ResolveProxyURLs(ProxyPoolURL(1), 42)
+	require.NoError(t, err)
+	preferred := urls[0]
+
+	svc.ReportProxyResult(preferred, errors.New("dial tcp: connection refused"))
+	urls, _ = svc.ResolveProxyURLs(ProxyPoolURL(1), 42)
+	require.Equal(t, preferred, urls[0], "a single failure stays below the threshold")
+
+	svc.ReportProxyResult(preferred, errors.New("dial tcp: connection refused"))
+	urls, _ = svc.ResolveProxyURLs(ProxyPoolURL(1), 42)
+	require.NotEqual(t, preferred, urls[0])
+	require.Equal(t, preferred, urls[len(urls)-1])
+
+	svc.ReportProxyResult(preferred, nil)
+	urls, _ = svc.ResolveProxyURLs(ProxyPoolURL(1), 42)
+	require.Equal(t, preferred, urls[0], "a success restores the member")
+}
+
+func TestApplyProxyProbeResult(t *testing.T) {
+	first := &ProxyLatencyInfo{Success: false}
+	applyProxyProbeResult(nil, first)
+	require.Equal(t, 1, first.ConsecutiveFailures)
+
+	prev := &ProxyLatencyInfo{Success: false, ConsecutiveFailures: 2, IPAddress: "1.2.3.4", Country: "US"}
+	next := &ProxyLatencyInfo{Success: false}
+	applyProxyProbeResult(prev, next)
+	require.Equal(t, 3, next.ConsecutiveFailures)
+	require.Equal(t, "1.2.3.4", next.IPAddress)
+	require.Equal(t, "US", next.Country)
+
+	ok := &ProxyLatencyInfo{Success: true}
+	applyProxyProbeResult(next, ok)
+	require.Equal(t, 0, ok.ConsecutiveFailures)
+}
+
+type proxyPoolUpstreamStub struct {
+	failURLs map[string]bool
+	proxies  []string
+	bodies   []string
+}
+
+func (s *proxyPoolUpstreamStub) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	s.proxies = append(s.proxies, proxyURL)
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		s.bodies = append(s.bodies, string(body))
+	}
+	if s.failURLs[proxyURL] {
+		return nil, errors.New("proxyconnect tcp: connection refused")
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+}
+
+func (s *proxyPoolUpstreamStub) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	return s.Do(req, proxyURL, accountID, accountConcurrency)
+}
+
+func TestProxyPoolHTTPUpstream_FailsOverAndRewindsBody(t *testing.T) {
+	members := proxyPoolTestMembers(3)
+	svc := newProxyPoolServiceForTest(t, 1, members)
+	candidates, err := svc.ResolveProxyURLs(ProxyPoolURL(1), 42)
+	require.NoError(t, err)
+
+	inner := &proxyPoolUpstreamStub{failURLs: map[string]bool{candidates[0]: true}}
+	upstream := NewProxyPoolHTTPUpstream(inner, svc)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://api.example.com/v1/messages", bytes.NewReader([]byte(`{"a":1}`)))
+	require.NoError(t, err)
+
+	resp, err := upstream.Do(req, ProxyPoolURL(1), 42, 1)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	require.Equal(t, candidates[:2], inner.proxies)
+	require.Equal(t, []string{`{"a":1}`, `{"a":1}`}, inner.bodies)
+	require.Equal(t, 1, svc.failures[members[0].ID]+svc.failures[members[1].ID]+svc.failures[members[2].ID])
+}
+
+func TestProxyPoolHTTPUpstream_PassesThroughPlainProxy(t *testing.T) {
+	svc := newProxyPoolServiceForTest(t, 1, proxyPoolTestMembers(2))
+	inner := &proxyPoolUpstreamStub{}
+	upstream := NewProxyPoolHTTPUpstream(inner, svc)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://api.example.com/v1/models", nil)
+	require.NoError(t, err)
+	resp, err := upstream.Do(req, "http://direct:8080", 1, 1)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, []string{"http://direct:8080"}, inner.proxies)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// proxyPoolHTTPUpstream 代理池感知的 HTTPUpstream 装饰器。
+// 代理地址为代理池伪地址时按账户解析候选成员依次尝试：网络层失败（含代理连接失败）计入成员失败并切换到下一个成员，
+// 收到任何 HTTP 响应即视为代理可用。请求体不可重放（无 GetBody）时不做切换。
+type proxyPoolHTTPUpstream struct {
+	inner HTTPUpstream
+	pools *ProxyPoolService
+}
+
+// NewProxyPoolHTTPUpstream 为 HTTPUpstream 增加代理池解析与故障切换
+func NewProxyPoolHTTPUpstream(inner HTTPUpstream, pools *ProxyPoolService) HTTPUpstream {
+	if pools == nil {
+		return inner
+	}
+	return &proxyPoolHTTPUpstream{inner: inner, pools: pools}
+}
+
+func (u *proxyPoolHTTPUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	return u.do(req, proxyURL, accountID, func(r *http.Request, resolved string) (*http.Response, error) {
+		return u.inner.Do(r, resolved, accountID, accountConcurrency)
+	})
+}
+
+func (u *proxyPoolHTTPUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	return u.do(req, proxyURL, accountID, func(r *http.Request, resolved string) (*http.Response, error) {
+		return u.inner.DoWithTLS(r, resolved, accountID, accountConcurrency, enableTLSFingerprint)
+	})
+}
+
+func (u *proxyPoolHTTPUpstream) do(req *http.Request, proxyURL string, accountID int64, send func(*http.Request, string) (*http.Response, error)) (*http.Response, error) {
+	if _, ok := parseProxyPoolURL(proxyURL); !ok {
+		return send(req, proxyURL)
+	}
+	candidates, err := u.pools.ResolveProxyURLs(proxyURL, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for i, candidate := range candidates {
+		attempt := req
+		if i > 0 {
+			rewound, ok := rewindRequest(req)
+			if !ok {
+				break
+			}
+			attempt = rewound
+		}
+		resp, err := send(attempt, candidate)
+		if err == nil {
+			u.pools.ReportProxyResult(candidate, nil)
+			return resp, nil
+		}
+		lastErr = err
+		// 客户端断开或超时不归咎于代理
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		u.pools.ReportProxyResult(candidate, err)
+		if i+1 < len(candidates) {
+			slog.Warn("proxy_pool_failover", "account_id", accountID, "attempt", i+1, "error", err)
+		}
+	}
+	return nil, lastErr
+}
+
+// rewindRequest 复制请求并重建请求体，用于切换代理后重发；请求体不可重放时返回 false
+func rewindRequest(req *http.Request) (*http.Request, bool) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	clone.Body = body
+	return clone, true
+}
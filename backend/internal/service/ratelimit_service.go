@@ -23,6 +23,8 @@ type RateLimitService struct {
 	timeoutCounterCache   TimeoutCounterCache
 	settingService        *SettingService
 	tokenCacheInvalidator TokenCacheInvalidator
+	outcomeCache          AccountOutcomeCache
+	circuitBreaker        *CircuitBreakerService
 	usageCacheMu          sync.RWMutex
 	usageCache            map[int64]*geminiUsageCacheEntry
 }
@@ -62,6 +64,44 @@ func (s *RateLimitService) SetTokenCacheInvalidator(invalidator TokenCacheInvali
 	s.tokenCacheInvalidator = invalidator
 }
 
+// SetAccountOutcomeCache 设置账号请求结果缓存（可选依赖，用于错误率惩罚选择策略）
+func (s *RateLimitService) SetAccountOutcomeCache(cache AccountOutcomeCache) {
+	s.outcomeCache = cache
+}
+
+// SetCircuitBreaker 设置账号熔断服务（可选依赖）
+func (s *RateLimitService) SetCircuitBreaker(circuitBreaker *CircuitBreakerService) {
+	s.circuitBreaker = circuitBreaker
+}
+
+// RecordUpstreamSuccess 记录账号一次成功的上游请求，latency 用于熔断慢请求判定
+func (s *RateLimitService) RecordUpstreamSuccess(ctx context.Context, accountID int64, latency time.Duration) {
+	if s == nil {
+		return
+	}
+	s.recordOutcome(ctx, accountID, true)
+	s.circuitBreaker.RecordSuccess(ctx, accountID, latency)
+}
+
+// RecordUpstreamRequestError 记录账号一次上游网络错误（连接失败、超时等，未收到响应）
+func (s *RateLimitService) RecordUpstreamRequestError(ctx context.Context, accountID int64) {
+	// 客户端取消导致的失败不计入账号
+	if s == nil || ctx.Err() != nil {
+		return
+	}
+	s.recordOutcome(ctx, accountID, false)
+	s.circuitBreaker.RecordFailure(ctx, accountID)
+}
+
+func (s *RateLimitService) recordOutcome(ctx context.Context, accountID int64, success bool) {
+	if s == nil || s.outcomeCache == nil {
+		return
+	}
+	if err := s.outcomeCache.RecordOutcome(ctx, accountID, success, time.Now()); err != nil {
+		slog.Warn("account_outcome_record_failed", "account_id", accountID, "error", err)
+	}
+}
+
 // HandleUpstreamError 处理上游错误响应，标记账号状态
 // 返回是否应该停止该账号的调度
 func (s *RateLimitService) HandleUpstreamError(ctx context.Context, account *Account, statusCode int, headers http.Header, responseBody []byte) (shouldDisable bool) {
@@ -73,6 +113,15 @@ func (s *RateLimitService) HandleUpstreamError(ctx context.Context, account *Acc
 		return false
 	}
 
+	// 400 通常是请求本身的问题，不计入账号错误率
+	if statusCode != 400 {
+		s.recordOutcome(ctx, account.ID, false)
+	}
+	// 熔断只统计上游服务端错误（含 529 过载）
+	if statusCode >= 500 {
+		s.circuitBreaker.RecordFailure(ctx, account.ID)
+	}
+
 	// 先尝试临时不可调度规则（401除外）
 	// 如果匹配成功，直接返回，不执行后续禁用逻辑
 	if statusCode != 401 {
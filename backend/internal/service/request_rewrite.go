@@ -0,0 +1,415 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// 分组请求改写动作
+const (
+	RewriteActionPrependSystem     = "prepend_system"      // 在系统提示词前插入文本
+	RewriteActionAppendSystem      = "append_system"       // 在系统提示词后追加文本
+	RewriteActionSetMaxTokens      = "set_max_tokens"      // 强制设置最大输出 tokens
+	RewriteActionCapMaxTokens      = "cap_max_tokens"      // 最大输出 tokens 上限（未指定时按上限设置）
+	RewriteActionStripThinking     = "strip_thinking"      // 移除 thinking / reasoning 配置
+	RewriteActionSetThinkingBudget = "set_thinking_budget" // 强制 thinking 预算（Responses API 无预算概念，忽略）
+	RewriteActionSetTemperature    = "set_temperature"     // 覆盖 temperature
+	RewriteActionRemoveTools       = "remove_tools"        // 移除指定名称的工具（支持末尾 * 通配符）
+	RewriteActionDefaultMetadata   = "default_metadata"    // 为缺失的 metadata 字段设置默认值（Gemini 无 metadata，忽略）
+)
+
+// 改写规则适用的请求格式
+const (
+	RewriteFormatAnthropic = "anthropic" // Claude Messages API
+	RewriteFormatResponses = "responses" // OpenAI Responses API
+	RewriteFormatGemini    = "gemini"    // Gemini generateContent
+)
+
+// Claude extended thinking 的最小预算
+const anthropicMinThinkingBudget = 1024
+
+var ErrRequestRewriteRuleInvalid = infraerrors.BadRequest("REQUEST_REWRITE_RULE_INVALID", "invalid request rewrite rule")
+
+// RequestRewriteRule 分组级声明式请求改写规则，按列表顺序依次应用
+type RequestRewriteRule struct {
+	Action string `json:"action"`
+	// Models 仅对匹配的请求模型生效（支持末尾 * 通配符），为空表示全部模型
+	Models   []string          `json:"models,omitempty"`
+	Text     string            `json:"text,omitempty"`
+	Value    *float64          `json:"value,omitempty"`
+	Tools    []string          `json:"tools,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (r *RequestRewriteRule) appliesTo(model string) bool {
+	if len(r.Models) == 0 {
+		return true
+	}
+	for _, pattern := range r.Models {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRequestRewriteRules 校验规则列表。
+// OpenAI 分组（Responses API）转发时会移除 max_output_tokens（API Key 与 Codex 上游均不支持），
+// 因此不允许配置 set_max_tokens / cap_max_tokens，避免规则静默失效。
+func ValidateRequestRewriteRules(platform string, rules []RequestRewriteRule) error {
+	for i := range rules {
+		rule := &rules[i]
+		invalid := func(msg string) error {
+			return infraerrors.BadRequest(ErrRequestRewriteRuleInvalid.Reason, fmt.Sprintf("rewrite rule #%d (%s): %s", i+1, rule.Action, msg))
+		}
+		switch rule.Action {
+		case RewriteActionPrependSystem, RewriteActionAppendSystem:
+			if strings.TrimSpace(rule.Text) == "" {
+				return invalid("text is required")
+			}
+		case RewriteActionSetMaxTokens, RewriteActionCapMaxTokens:
+			if platform == PlatformOpenAI {
+				return invalid("max output tokens cannot be enforced for OpenAI groups")
+			}
+			if rule.Value == nil || *rule.Value < 1 {
+				return invalid("value must be a positive integer")
+			}
+		case RewriteActionSetThinkingBudget:
+			if rule.Value == nil || *rule.Value < 0 {
+				return invalid("value must be a non-negative integer")
+			}
+		case RewriteActionSetTemperature:
+			if rule.Value == nil || *rule.Value < 0 || *rule.Value > 2 {
+				return invalid("value must be between 0 and 2")
+			}
+		case RewriteActionRemoveTools:
+			if len(rule.Tools) == 0 {
+				return invalid("tools is required")
+			}
+		case RewriteActionDefaultMetadata:
+			if len(rule.Metadata) == 0 {
+				return invalid("metadata is required")
+			}
+		case RewriteActionStripThinking:
+		default:
+			return infraerrors.BadRequest(ErrRequestRewriteRuleInvalid.Reason, fmt.Sprintf("rewrite rule #%d: unknown action %q", i+1, rule.Action))
+		}
+	}
+	return nil
+}
+
+// ApplyRequestRewriteRules 按规则改写请求体，返回改写后的请求体与是否发生变化。
+// 非 JSON 请求体或无适用规则时原样返回。
+func ApplyRequestRewriteRules(body []byte, format, model string, rules []RequestRewriteRule) ([]byte, bool, error) {
+	if len(rules) == 0 || !gjson.ValidBytes(body) {
+		return body, false, nil
+	}
+	p, ok := rewritePathsByFormat[format]
+	if !ok {
+		return body, false, fmt.Errorf("unsupported rewrite format %q", format)
+	}
+
+	out := body
+	var err error
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.appliesTo(model) {
+			continue
+		}
+		out, err = applyRequestRewriteRule(out, format, p, rule)
+		if err != nil {
+			return body, false, fmt.Errorf("apply rewrite rule %s: %w", rule.Action, err)
+		}
+	}
+	if format == RewriteFormatAnthropic {
+		if out, err = normalizeAnthropicThinkingBudget(out); err != nil {
+			return body, false, err
+		}
+	}
+	if string(out) == string(body) {
+		return body, false, nil
+	}
+	return out, true, nil
+}
+
+// rewritePaths 各格式下相关字段的 JSON 路径（空字符串表示该格式不支持）
+type rewritePaths struct {
+	maxTokens      string
+	temperature    string
+	thinking       string
+	thinkingBudget string
+	metadata       string
+}
+
+var rewritePathsByFormat = map[string]rewritePaths{
+	RewriteFormatAnthropic: {
+		maxTokens:      "max_tokens",
+		temperature:    "temperature",
+		thinking:       "thinking",
+		thinkingBudget: "thinking.budget_tokens",
+		metadata:       "metadata",
+	},
+	RewriteFormatResponses: {
+		maxTokens:   "max_output_tokens",
+		temperature: "temperature",
+		thinking:    "reasoning",
+		metadata:    "metadata",
+	},
+	RewriteFormatGemini: {
+		maxTokens:      "generationConfig.maxOutputTokens",
+		temperature:    "generationConfig.temperature",
+		thinking:       "generationConfig.thinkingConfig",
+		thinkingBudget: "generationConfig.thinkingConfig.thinkingBudget",
+	},
+}
+
+func applyRequestRewriteRule(body []byte, format string, p rewritePaths, rule *RequestRewriteRule) ([]byte, error) {
+	switch rule.Action {
+	case RewriteActionPrependSystem, RewriteActionAppendSystem:
+		return rewriteSystemText(body, format, rule.Text, rule.Action == RewriteActionPrependSystem)
+
+	case RewriteActionSetMaxTokens:
+		return sjson.SetBytes(body, p.maxTokens, int64(*rule.Value))
+
+	case RewriteActionCapMaxTokens:
+		limit := int64(*rule.Value)
+		if current := gjson.GetBytes(body, p.maxTokens); current.Exists() && current.Int() <= limit {
+			return body, nil
+		}
+		return sjson.SetBytes(body, p.maxTokens, limit)
+
+	case RewriteActionStripThinking:
+		if !gjson.GetBytes(body, p.thinking).Exists() {
+			return body, nil
+		}
+		return sjson.DeleteBytes(body, p.thinking)
+
+	case RewriteActionSetThinkingBudget:
+		if p.thinkingBudget == "" {
+			return body, nil
+		}
+		budget := int64(*rule.Value)
+		if format == RewriteFormatAnthropic {
+			if budget == 0 {
+				if !gjson.GetBytes(body, p.thinking).Exists() {
+					return body, nil
+				}
+				return sjson.DeleteBytes(body, p.thinking)
+			}
+			return sjson.SetRawBytes(body, p.thinking, []byte(fmt.Sprintf(`{"type":"enabled","budget_tokens":%d}`, budget)))
+		}
+		return sjson.SetBytes(body, p.thinkingBudget, budget)
+
+	case RewriteActionSetTemperature:
+		return sjson.SetBytes(body, p.temperature, *rule.Value)
+
+	case RewriteActionRemoveTools:
+		return removeRequestTools(body, format, rule.Tools)
+
+	case RewriteActionDefaultMetadata:
+		if p.metadata == "" {
+			return body, nil
+		}
+		keys := make([]string, 0, len(rule.Metadata))
+		for k := range rule.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var err error
+		for _, k := range keys {
+			path := p.metadata + "." + escapeJSONPathKey(k)
+			if gjson.GetBytes(body, path).Exists() {
+				continue
+			}
+			if body, err = sjson.SetBytes(body, path, rule.Metadata[k]); err != nil {
+				return nil, err
+			}
+		}
+		return body, nil
+	}
+	return body, nil
+}
+
+// rewriteSystemText 在系统提示词前/后插入文本：字符串形式直接拼接，内容块数组形式插入文本块
+func rewriteSystemText(body []byte, format, text string, prepend bool) ([]byte, error) {
+	switch format {
+	case RewriteFormatAnthropic:
+		return rewriteTextField(body, "system", text, prepend, `{"type":"text","text":%s}`)
+	case RewriteFormatResponses:
+		return rewriteTextField(body, "instructions", text, prepend, "")
+	case RewriteFormatGemini:
+		key := "systemInstruction"
+		if !gjson.GetBytes(body, key).Exists() && gjson.GetBytes(body, "system_instruction").Exists() {
+			key = "system_instruction"
+		}
+		parts := gjson.GetBytes(body, key+".parts")
+		if !parts.IsArray() {
+			return sjson.SetRawBytes(body, key, []byte(`{"parts":[{"text":`+jsonString(text)+`}]}`))
+		}
+		return sjson.SetRawBytes(body, key+".parts", insertJSONArrayElement(parts, `{"text":`+jsonString(text)+`}`, prepend))
+	}
+	return body, nil
+}
+
+func rewriteTextField(body []byte, path, text string, prepend bool, blockTemplate string) ([]byte, error) {
+	current := gjson.GetBytes(body, path)
+	switch {
+	case current.IsArray() && blockTemplate != "":
+		block := fmt.Sprintf(blockTemplate, jsonString(text))
+		return sjson.SetRawBytes(body, path, insertJSONArrayElement(current, block, prepend))
+	case current.Type == gjson.String && current.String() != "":
+		if prepend {
+			return sjson.SetBytes(body, path, text+"\n\n"+current.String())
+		}
+		return sjson.SetBytes(body, path, current.String()+"\n\n"+text)
+	default:
+		return sjson.SetBytes(body, path, text)
+	}
+}
+
+// removeRequestTools 按名称移除工具定义；移除后为空时删除 tools，tool_choice 指向被移除工具时一并删除
+func removeRequestTools(body []byte, format string, patterns []string) ([]byte, error) {
+	match := func(name string) bool {
+		if name == "" {
+			return false
+		}
+		for _, pattern := range patterns {
+			if matchModelPattern(pattern, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	tools := gjson.GetBytes(body, "tools")
+	if !tools.IsArray() {
+		return body, nil
+	}
+
+	var (
+		kept    []string
+		removed bool
+		err     error
+	)
+	for _, tool := range tools.Array() {
+		if format == RewriteFormatGemini {
+			decls := tool.Get("functionDeclarations")
+			if !decls.IsArray() {
+				// 内置工具（googleSearch、codeExecution 等）以对象键名作为工具名
+				builtin := false
+				tool.ForEach(func(key, _ gjson.Result) bool {
+					builtin = builtin || match(key.String())
+					return true
+				})
+				if builtin {
+					removed = true
+					continue
+				}
+				kept = append(kept, tool.Raw)
+				continue
+			}
+			var keptDecls []string
+			for _, decl := range decls.Array() {
+				if match(decl.Get("name").String()) {
+					removed = true
+					continue
+				}
+				keptDecls = append(keptDecls, decl.Raw)
+			}
+			if len(keptDecls) == 0 {
+				continue
+			}
+			raw, setErr := sjson.SetRaw(tool.Raw, "functionDeclarations", "["+strings.Join(keptDecls, ",")+"]")
+			if setErr != nil {
+				return nil, setErr
+			}
+			kept = append(kept, raw)
+			continue
+		}
+
+		name := tool.Get("name").String()
+		if name == "" && format == RewriteFormatResponses {
+			// 内置工具（web_search、file_search 等）以 type 作为工具名
+			name = tool.Get("type").String()
+		}
+		if match(name) {
+			removed = true
+			continue
+		}
+		kept = append(kept, tool.Raw)
+	}
+	if !removed {
+		return body, nil
+	}
+
+	if len(kept) == 0 {
+		if body, err = sjson.DeleteBytes(body, "tools"); err != nil {
+			return nil, err
+		}
+	} else if body, err = sjson.SetRawBytes(body, "tools", []byte("["+strings.Join(kept, ",")+"]")); err != nil {
+		return nil, err
+	}
+
+	choice := gjson.GetBytes(body, "tool_choice")
+	if choice.Exists() && (len(kept) == 0 || match(choice.Get("name").String())) {
+		return sjson.DeleteBytes(body, "tool_choice")
+	}
+	return body, nil
+}
+
+// normalizeAnthropicThinkingBudget 保证 budget_tokens < max_tokens，预算不足最小值时关闭 thinking
+func normalizeAnthropicThinkingBudget(body []byte) ([]byte, error) {
+	thinking := gjson.GetBytes(body, "thinking")
+	if !thinking.Exists() || thinking.Get("type").String() != "enabled" {
+		return body, nil
+	}
+	budget := thinking.Get("budget_tokens").Int()
+	maxTokens := gjson.GetBytes(body, "max_tokens")
+	if !maxTokens.Exists() || budget < maxTokens.Int() {
+		return body, nil
+	}
+	budget = maxTokens.Int() - 1
+	if budget < anthropicMinThinkingBudget {
+		return sjson.DeleteBytes(body, "thinking")
+	}
+	return sjson.SetBytes(body, "thinking.budget_tokens", budget)
+}
+
+func insertJSONArrayElement(arr gjson.Result, element string, prepend bool) []byte {
+	items := make([]string, 0, len(arr.Array())+1)
+	if prepend {
+		items = append(items, element)
+	}
+	for _, item := range arr.Array() {
+		items = append(items, item.Raw)
+	}
+	if !prepend {
+		items = append(items, element)
+	}
+	return []byte("[" + strings.Join(items, ",") + "]")
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// escapeJSONPathKey 转义 gjson/sjson 路径中的特殊字符
+func escapeJSONPathKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '|', '#', '@', '\\', '!', '=', '<', '>', '%', ':':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func rewriteValue(v float64) *float64 { return &v }
+
+func TestApplyRequestRewriteRules_Anthropic(t *testing.T) {
+	body := []byte(`{"model":"claude-sonnet-4-5","max_tokens":32000,"system":[{"type":"text","text":"client"}],"thinking":{"type":"enabled","budget_tokens":20000},"temperature":1,"tools":[{"name":"bash"},{"name":"mcp__fs__read"},{"name":"web"}],"tool_choice":{"type":"tool","name":"bash"},"messages":[{"role":"user","content":"hi"}]}`)
+	rules := []RequestRewriteRule{
+		{Action: RewriteActionPrependSystem, Text: "group header"},
+		{Action: RewriteActionAppendSystem, Text: "group footer"},
+		{Action: RewriteActionCapMaxTokens, Value: rewriteValue(8192)},
+		{Action: RewriteActionRemoveTools, Tools: []string{"bash", "mcp__*"}},
+		{Action: RewriteActionDefaultMetadata, Metadata: map[string]string{"user_id": "group-7"}},
+		{Action: RewriteActionSetTemperature, Value: rewriteValue(0.5), Models: []string{"claude-haiku-*"}},
+	}
+	require.NoError(t, ValidateRequestRewriteRules(PlatformAnthropic, rules))
+
+	out, changed, err := ApplyRequestRewriteRules(body, RewriteFormatAnthropic, "claude-sonnet-4-5", rules)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "group header", gjson.GetBytes(out, "system.0.text").String())
+	require.Equal(t, "client", gjson.GetBytes(out, "system.1.text").String())
+	require.Equal(t, "group footer", gjson.GetBytes(out, "system.2.text").String())
+	require.Equal(t, int64(8192), gjson.GetBytes(out, "max_tokens").Int())
+	// 预算被压到 max_tokens 以下
+	require.Equal(t, int64(8191), gjson.GetBytes(out, "thinking.budget_tokens").Int())
+	require.Equal(t, 1, len(gjson.GetBytes(out, "tools").Array()))
+	require.Equal(t, "web", gjson.GetBytes(out, "tools.0.name").String())
+	require.False(t, gjson.GetBytes(out, "tool_choice").Exists())
+	require.Equal(t, "group-7", gjson.GetBytes(out, "metadata.user_id").String())
+	// 模型不匹配的规则不生效
+	require.Equal(t, int64(1), gjson.GetBytes(out, "temperature").Int())
+}
+
+func TestApplyRequestRewriteRules_AnthropicThinking(t *testing.T) {
+	body := []byte(`{"model":"claude","max_tokens":1000,"system":"base","messages":[]}`)
+
+	out, _, err := ApplyRequestRewriteRules(body, RewriteFormatAnthropic, "claude", []RequestRewriteRule{
+		{Action: RewriteActionSetThinkingBudget, Value: rewriteValue(4096)},
+	})
+	require.NoError(t, err)
+	// max_tokens 不足以容纳最小预算时关闭 thinking
+	require.False(t, gjson.GetBytes(out, "thinking").Exists())
+
+	out, _, err = ApplyRequestRewriteRules(body, RewriteFormatAnthropic, "claude", []RequestRewriteRule{
+		{Action: RewriteActionSetMaxTokens, Value: rewriteValue(16000)},
+		{Action: RewriteActionSetThinkingBudget, Value: rewriteValue(4096)},
+		{Action: RewriteActionPrependSystem, Text: "first"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "enabled", gjson.GetBytes(out, "thinking.type").String())
+	require.Equal(t, int64(4096), gjson.GetBytes(out, "thinking.budget_tokens").Int())
+	require.Equal(t, "first\n\nbase", gjson.GetBytes(out, "system").String())
+
+	out, _, err = ApplyRequestRewriteRules(out, RewriteFormatAnthropic, "claude", []RequestRewriteRule{{Action: RewriteActionStripThinking}})
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(out, "thinking").Exists())
+}
+
+func TestApplyRequestRewriteRules_Responses(t *testing.T) {
+	body := []byte(`{"model":"gpt-5","input":"hi","reasoning":{"effort":"high"},"tools":[{"type":"web_search"},{"type":"function","name":"shell"}],"metadata":{"team":"a"}}`)
+	out, changed, err := ApplyRequestRewriteRules(body, RewriteFormatResponses, "gpt-5", []RequestRewriteRule{
+		{Action: RewriteActionAppendSystem, Text: "be brief"},
+		{Action: RewriteActionCapMaxTokens, Value: rewriteValue(2048)},
+		{Action: RewriteActionStripThinking},
+		{Action: RewriteActionSetThinkingBudget, Value: rewriteValue(1024)},
+		{Action: RewriteActionRemoveTools, Tools: []string{"web_search"}},
+		{Action: RewriteActionDefaultMetadata, Metadata: map[string]string{"team": "b", "tier": "cheap"}},
+	})
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "be brief", gjson.GetBytes(out, "instructions").String())
+	require.Equal(t, int64(2048), gjson.GetBytes(out, "max_output_tokens").Int())
+	require.False(t, gjson.GetBytes(out, "reasoning").Exists())
+	require.Equal(t, "shell", gjson.GetBytes(out, "tools.0.name").String())
+	require.Equal(t, 1, len(gjson.GetBytes(out, "tools").Array()))
+	require.Equal(t, "a", gjson.GetBytes(out, "metadata.team").String())
+	require.Equal(t, "cheap", gjson.GetBytes(out, "metadata.tier").String())
+}
+
+func TestApplyRequestRewriteRules_Gemini(t *testing.T) {
+	body := []byte(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}],"generationConfig":{"maxOutputTokens":100},"tools":[{"functionDeclarations":[{"name":"a"},{"name":"b"}]},{"googleSearch":{}}]}`)
+	out, changed, err := ApplyRequestRewriteRules(body, RewriteFormatGemini, "gemini-2.5-pro", []RequestRewriteRule{
+		{Action: RewriteActionPrependSystem, Text: "sys"},
+		{Action: RewriteActionCapMaxTokens, Value: rewriteValue(4096)},
+		{Action: RewriteActionSetThinkingBudget, Value: rewriteValue(512)},
+		{Action: RewriteActionSetTemperature, Value: rewriteValue(0.2)},
+		{Action: RewriteActionRemoveTools, Tools: []string{"a", "googleSearch"}},
+		{Action: RewriteActionDefaultMetadata, Metadata: map[string]string{"k": "v"}},
+	})
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "sys", gjson.GetBytes(out, "systemInstruction.parts.0.text").String())
+	require.Equal(t, int64(100), gjson.GetBytes(out, "generationConfig.maxOutputTokens").Int())
+	require.Equal(t, int64(512), gjson.GetBytes(out, "generationConfig.thinkingConfig.thinkingBudget").Int())
+	require.Equal(t, 0.2, gjson.GetBytes(out, "generationConfig.temperature").Float())
+	require.Equal(t, 1, len(gjson.GetBytes(out, "tools").Array()))
+	require.Equal(t, "b", gjson.GetBytes(out, "tools.0.functionDeclarations.0.name").String())
+	require.False(t, gjson.GetBytes(out, "metadata").Exists())
+}
+
+func TestApplyRequestRewriteRules_NoopAndValidation(t *testing.T) {
+	body := []byte(`{"model":"claude","max_tokens":100}`)
+	out, changed, err := ApplyRequestRewriteRules(body, RewriteFormatAnthropic, "claude", []RequestRewriteRule{
+		{Action: RewriteActionCapMaxTokens, Value: rewriteValue(4096)},
+	})
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, string(body), string(out))
+
+	_, _, err = ApplyRequestRewriteRules(body, "chat", "claude", []RequestRewriteRule{{Action: RewriteActionStripThinking}})
+	require.Error(t, err)
+
+	require.ErrorIs(t, ValidateRequestRewriteRules(PlatformAnthropic, []RequestRewriteRule{{Action: "drop_messages"}}), ErrRequestRewriteRuleInvalid)
+	require.ErrorIs(t, ValidateRequestRewriteRules(PlatformAnthropic, []RequestRewriteRule{{Action: RewriteActionPrependSystem}}), ErrRequestRewriteRuleInvalid)
+	require.ErrorIs(t, ValidateRequestRewriteRules(PlatformAnthropic, []RequestRewriteRule{{Action: RewriteActionSetTemperature, Value: rewriteValue(3)}}), ErrRequestRewriteRuleInvalid)
+	require.ErrorIs(t, ValidateRequestRewriteRules(PlatformAnthropic, []RequestRewriteRule{{Action: RewriteActionRemoveTools}}), ErrRequestRewriteRuleInvalid)
+
+	// OpenAI 分组转发时移除 max_output_tokens，不允许配置输出 tokens 规则
+	for _, action := range []string{RewriteActionSetMaxTokens, RewriteActionCapMaxTokens} {
+		rules := []RequestRewriteRule{{Action: action, Value: rewriteValue(1024)}}
+		require.NoError(t, ValidateRequestRewriteRules(PlatformAnthropic, rules))
+		require.NoError(t, ValidateRequestRewriteRules(PlatformGemini, rules))
+		require.ErrorIs(t, ValidateRequestRewriteRules(PlatformOpenAI, rules), ErrRequestRewriteRuleInvalid)
+	}
+}
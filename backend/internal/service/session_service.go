@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrSessionNotFound = infraerrors.NotFound("SESSION_NOT_FOUND", "session not found")
+	ErrSessionRevoked  = infraerrors.Unauthorized("SESSION_REVOKED", "session has been revoked")
+	ErrSessionExpired  = infraerrors.Unauthorized("SESSION_EXPIRED", "session has expired")
+)
+
+// 会话吊销原因
+const (
+	SessionRevokeReasonLogout          = "logout"
+	SessionRevokeReasonUserRevoked     = "user_revoked"
+	SessionRevokeReasonAdminForce      = "admin_force_logout"
+	SessionRevokeReasonPasswordReset   = "password_reset"
+	SessionRevokeReasonPasswordChanged = "password_changed"
+	SessionRevokeReasonTotpChanged     = "totp_changed"
+	SessionRevokeReasonWebAuthnChanged = "webauthn_changed"
+	SessionRevokeReasonUserDisabled    = "user_disabled"
+)
+
+const (
+	// sessionTouchInterval 最近活跃时间的最小写入间隔，避免每个请求都写库
+	sessionTouchInterval = time.Minute
+	// sessionRevokedCacheTTL 已吊销会话在缓存中的负缓存时间，避免反复回源数据库
+	sessionRevokedCacheTTL = 10 * time.Minute
+	// sessionRetention 过期/吊销会话在数据库中的保留时间
+	sessionRetention       = 7 * 24 * time.Hour
+	defaultSessionLifetime = 7 * 24 * time.Hour
+)
+
+// UserSession 用户登录会话
+type UserSession struct {
+	ID           int64
+	SessionID    string
+	UserID       int64
+	IPAddress    string
+	UserAgent    string
+	DeviceName   string
+	CreatedAt    time.Time
+	LastActiveAt time.Time
+	ExpiresAt    time.Time
+	RevokedAt    *time.Time
+	RevokeReason string
+}
+
+// IsActive 会话是否仍然有效（未吊销且未过期）
+func (s *UserSession) IsActive(now time.Time) bool {
+	return s != nil && s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionClientInfo 登录时的客户端信息
+type SessionClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// WithSessionClientInfo 将客户端信息写入 context，供签发 token 时创建会话使用
+func WithSessionClientInfo(ctx context.Context, info SessionClientInfo) context.Context {
+	return context.WithValue(ctx, ctxkey.SessionClient, info)
+}
+
+func sessionClientInfoFromContext(ctx context.Context) SessionClientInfo {
+	if ctx == nil {
+		return SessionClientInfo{}
+	}
+	info, _ := ctx.Value(ctxkey.SessionClient).(SessionClientInfo)
+	return info
+}
+
+// CurrentSessionIDFromContext 返回 JWT 认证中间件写入的当前会话 ID
+func CurrentSessionIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	sid, _ := ctx.Value(ctxkey.SessionID).(string)
+	return sid
+}
+
+// UserSessionRepository 会话持久化（PostgreSQL）
+type UserSessionRepository interface {
+	Create(ctx context.Context, session *UserSession) error
+	GetBySessionID(ctx context.Context, sessionID string) (*UserSession, error)
+	ListActiveByUser(ctx context.Context, userID int64, now time.Time) ([]UserSession, error)
+	TouchLastActive(ctx context.Context, sessionID string, at time.Time) error
+	ExtendExpiry(ctx context.Context, sessionID string, expiresAt time.Time) error
+	Revoke(ctx context.Context, sessionID, reason string, at time.Time) (bool, error)
+	// RevokeAllByUser 吊销用户所有有效会话（exceptSessionID 非空时保留该会话），返回被吊销的会话 ID
+	RevokeAllByUser(ctx context.Context, userID int64, exceptSessionID, reason string, at time.Time) ([]string, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UserSessionCache 会话缓存（Redis），用于在每个请求上快速校验会话状态
+type UserSessionCache interface {
+	// GetSession 缓存未命中时返回 (nil, nil)
+	GetSession(ctx context.Context, sessionID string) (*UserSession, error)
+	SetSession(ctx context.Context, session *UserSession, ttl time.Duration) error
+	DeleteSessions(ctx context.Context, sessionIDs ...string) error
+	// GetLegacyTokenCutoff 返回用户旧 token（未携带 sid）的最早有效签发时间，未设置时返回零值
+	GetLegacyTokenCutoff(ctx context.Context, userID int64) (time.Time, error)
+	SetLegacyTokenCutoff(ctx context.Context, userID int64, at time.Time, ttl time.Duration) error
+}
+
+// SessionService 管理用户登录会话：创建、校验、刷新、列出与吊销
+type SessionService struct {
+	repo     UserSessionRepository
+	cache    UserSessionCache
+	lifetime time.Duration
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewSessionService 创建会话服务
+func NewSessionService(repo UserSessionRepository, cache UserSessionCache, cfg *config.Config) *SessionService {
+	lifetime := defaultSessionLifetime
+	if cfg != nil && cfg.JWT.RefreshExpireHour > 0 {
+		lifetime = time.Duration(cfg.JWT.RefreshExpireHour) * time.Hour
+	}
+	return &SessionService{
+		repo:     repo,
+		cache:    cache,
+		lifetime: lifetime,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Create 为用户创建新的登录会话
+func (s *SessionService) Create(ctx context.Context, userID int64, info SessionClientInfo) (*UserSession, error) {
+	sessionID, err := randomHexString(16)
+	if err != nil {
+		return nil, fmt.Errorf("generate session id: %w", err)
+	}
+	now := time.Now()
+	session := &UserSession{
+		SessionID:    sessionID,
+		UserID:       userID,
+		IPAddress:    truncateString(strings.TrimSpace(info.IPAddress), 45),
+		UserAgent:    truncateString(strings.TrimSpace(info.UserAgent), 512),
+		DeviceName:   describeUserAgent(info.UserAgent),
+		CreatedAt:    now,
+		LastActiveAt: now,
+		ExpiresAt:    now.Add(s.lifetime),
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+	s.cacheSession(ctx, session)
+	return session, nil
+}
+
+// Validate 校验会话是否属于该用户且仍然有效，并按间隔刷新最近活跃时间
+func (s *SessionService) Validate(ctx context.Context, sessionID string, userID int64) (*UserSession, error) {
+	session, err := s.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	now := time.Now()
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+	if !session.IsActive(now) {
+		return nil, ErrSessionExpired
+	}
+
+	if now.Sub(session.LastActiveAt) >= sessionTouchInterval {
+		session.LastActiveAt = now
+		if err := s.repo.TouchLastActive(ctx, sessionID, now); err != nil {
+			log.Printf("[Session] Touch last active failed: session=%s err=%v", sessionID, err)
+		}
+		s.cacheSession(ctx, session)
+	}
+	return session, nil
+}
+
+// Refresh 在刷新 token 时滑动延长会话有效期
+func (s *SessionService) Refresh(ctx context.Context, sessionID string, userID int64) (*UserSession, error) {
+	session, err := s.Validate(ctx, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	session.ExpiresAt = time.Now().Add(s.lifetime)
+	if err := s.repo.ExtendExpiry(ctx, sessionID, session.ExpiresAt); err != nil {
+		return nil, fmt.Errorf("extend session: %w", err)
+	}
+	s.cacheSession(ctx, session)
+	return session, nil
+}
+
+// ListActive 列出用户的有效会话
+func (s *SessionService) ListActive(ctx context.Context, userID int64) ([]UserSession, error) {
+	sessions, err := s.repo.ListActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// Revoke 吊销用户的单个会话；userID 用于校验归属
+func (s *SessionService) Revoke(ctx context.Context, userID int64, sessionID, reason string) error {
+	session, err := s.repo.GetBySessionID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	if _, err := s.repo.Revoke(ctx, sessionID, reason, time.Now()); err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	s.evict(ctx, sessionID)
+	return nil
+}
+
+// RevokeAllForUser 吊销用户的所有会话，exceptSessionID 非空时保留当前会话
+func (s *SessionService) RevokeAllForUser(ctx context.Context, userID int64, exceptSessionID, reason string) (int, error) {
+	if s == nil || userID <= 0 {
+		return 0, nil
+	}
+	revoked, err := s.repo.RevokeAllByUser(ctx, userID, exceptSessionID, reason, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("revoke user sessions: %w", err)
+	}
+	s.evict(ctx, revoked...)
+	if err := s.RevokeLegacyTokens(ctx, userID); err != nil {
+		return len(revoked), err
+	}
+	if len(revoked) > 0 {
+		log.Printf("[Session] Revoked %d sessions for user %d (reason=%s)", len(revoked), userID, reason)
+	}
+	return len(revoked), nil
+}
+
+// ValidateLegacyToken 校验未绑定会话的旧 token（会话功能上线前签发）。
+// 签发时间超出会话有效期的旧 token 视为过期；早于用户最近一次登出/批量吊销时间的视为已吊销。
+func (s *SessionService) ValidateLegacyToken(ctx context.Context, userID int64, issuedAt time.Time) error {
+	if issuedAt.IsZero() || time.Since(issuedAt) >= s.lifetime {
+		return ErrSessionExpired
+	}
+	if s.cache == nil {
+		return nil
+	}
+	cutoff, err := s.cache.GetLegacyTokenCutoff(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get legacy token cutoff: %w", err)
+	}
+	if !cutoff.IsZero() && !issuedAt.After(cutoff) {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// RevokeLegacyTokens 使用户此前签发的所有旧 token 失效；记录保留一个会话有效期，之后旧 token 已自然过期
+func (s *SessionService) RevokeLegacyTokens(ctx context.Context, userID int64) error {
+	if s.cache == nil {
+		return nil
+	}
+	if err := s.cache.SetLegacyTokenCutoff(ctx, userID, time.Now(), s.lifetime); err != nil {
+		return fmt.Errorf("revoke legacy tokens: %w", err)
+	}
+	return nil
+}
+
+// revokeAllBestEffort 用于安全事件触发的批量吊销，失败时仅记录日志
+func (s *SessionService) revokeAllBestEffort(ctx context.Context, userID int64, exceptSessionID, reason string) {
+	if s == nil {
+		return
+	}
+	if _, err := s.RevokeAllForUser(ctx, userID, exceptSessionID, reason); err != nil {
+		log.Printf("[Session] Revoke sessions failed: user=%d reason=%s err=%v", userID, reason, err)
+	}
+}
+
+func (s *SessionService) load(ctx context.Context, sessionID string) (*UserSession, error) {
+	if s.cache != nil {
+		cached, err := s.cache.GetSession(ctx, sessionID)
+		if err != nil {
+			log.Printf("[Session] Cache get failed, falling back to database: %v", err)
+		} else if cached != nil {
+			return cached, nil
+		}
+	}
+	session, err := s.repo.GetBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheSession(ctx, session)
+	return session, nil
+}
+
+func (s *SessionService) cacheSession(ctx context.Context, session *UserSession) {
+	if s.cache == nil || session == nil {
+		return
+	}
+	ttl := time.Until(session.ExpiresAt)
+	if session.RevokedAt != nil || ttl <= 0 {
+		ttl = sessionRevokedCacheTTL
+	}
+	if err := s.cache.SetSession(ctx, session, ttl); err != nil {
+		log.Printf("[Session] Cache set failed: session=%s err=%v", session.SessionID, err)
+	}
+}
+
+func (s *SessionService) evict(ctx context.Context, sessionIDs ...string) {
+	if s.cache == nil || len(sessionIDs) == 0 {
+		return
+	}
+	if err := s.cache.DeleteSessions(ctx, sessionIDs...); err != nil {
+		log.Printf("[Session] Cache delete failed: %v", err)
+	}
+}
+
+// Start 启动过期会话清理任务
+func (s *SessionService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.cleanupOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止清理任务
+func (s *SessionService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *SessionService) cleanupOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now().Add(-sessionRetention))
+	if err != nil {
+		log.Printf("[Session] Cleanup expired sessions failed: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("[Session] Cleaned up %d expired sessions", deleted)
+	}
+}
+
+// describeUserAgent 从 User-Agent 中提取简短的设备描述（如 "Chrome on Windows"）
+func describeUserAgent(ua string) string {
+	if strings.TrimSpace(ua) == "" {
+		return ""
+	}
+	lower := strings.ToLower(ua)
+
+	browser := "Unknown"
+	switch {
+	case strings.Contains(lower, "edg/"):
+		browser = "Edge"
+	case strings.Contains(lower, "opr/") || strings.Contains(lower, "opera"):
+		browser = "Opera"
+	case strings.Contains(lower, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(lower, "chrome/") || strings.Contains(lower, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(lower, "safari/"):
+		browser = "Safari"
+	case strings.Contains(lower, "curl/"):
+		browser = "curl"
+	}
+
+	os := ""
+	switch {
+	case strings.Contains(lower, "windows"):
+		os = "Windows"
+	case strings.Contains(lower, "iphone") || strings.Contains(lower, "ipad"):
+		os = "iOS"
+	case strings.Contains(lower, "android"):
+		os = "Android"
+	case strings.Contains(lower, "mac os") || strings.Contains(lower, "macintosh"):
+		os = "macOS"
+	case strings.Contains(lower, "linux"):
+		os = "Linux"
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,328 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type sessionRepoStub struct {
+	sessions map[string]*UserSession
+	touches  int
+	getErr   error
+}
+
+func newSessionRepoStub() *sessionRepoStub {
+	return &sessionRepoStub{sessions: map[string]*UserSession{}}
+}
+
+func (r *sessionRepoStub) Create(_ context.Context, session *UserSession) error {
+	cp := *session
+	cp.ID = int64(len(r.sessions) + 1)
+	session.ID = cp.ID
+	r.sessions[session.SessionID] = &cp
+	return nil
+}
+
+func (r *sessionRepoStub) GetBySessionID(_ context.Context, sessionID string) (*UserSession, error) {
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	s, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (r *sessionRepoStub) ListActiveByUser(_ context.Context, userID int64, now time.Time) ([]UserSession, error) {
+	out := []UserSession{}
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.IsActive(now) {
+			out = append(out, *s)
+		}
+	}
+	return out, nil
+}
+
+func (r *sessionRepoStub) TouchLastActive(_ context.Context, sessionID string, at time.Time) error {
+	r.touches++
+	if s, ok := r.sessions[sessionID]; ok {
+		s.LastActiveAt = at
+	}
+	return nil
+}
+
+func (r *sessionRepoStub) ExtendExpiry(_ context.Context, sessionID string, expiresAt time.Time) error {
+	if s, ok := r.sessions[sessionID]; ok && s.RevokedAt == nil {
+		s.ExpiresAt = expiresAt
+		return nil
+	}
+	return ErrSessionRevoked
+}
+
+func (r *sessionRepoStub) Revoke(_ context.Context, sessionID, reason string, at time.Time) (bool, error) {
+	s, ok := r.sessions[sessionID]
+	if !ok || s.RevokedAt != nil {
+		return false, nil
+	}
+	s.RevokedAt = &at
+	s.RevokeReason = reason
+	return true, nil
+}
+
+func (r *sessionRepoStub) RevokeAllByUser(_ context.Context, userID int64, exceptSessionID, reason string, at time.Time) ([]string, error) {
+	var revoked []string
+	for sid, s := range r.sessions {
+		if s.UserID != userID || s.RevokedAt != nil || sid == exceptSessionID {
+			continue
+		}
+		s.RevokedAt = &at
+		s.RevokeReason = reason
+		revoked = append(revoked, sid)
+	}
+	return revoked, nil
+}
+
+func (r *sessionRepoStub) DeleteExpired(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+type sessionCacheStub struct {
+	sessions      map[string]UserSession
+	legacyCutoffs map[int64]time.Time
+}
+
+func (c *sessionCacheStub) GetSession(_ context.Context, sessionID string) (*UserSession, error) {
+	s, ok := c.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (c *sessionCacheStub) SetSession(_ context.Context, session *UserSession, _ time.Duration) error {
+	c.sessions[session.SessionID] = *session
+	return nil
+}
+
+func (c *sessionCacheStub) DeleteSessions(_ context.Context, sessionIDs ...string) error {
+	for _, sid := range sessionIDs {
+		delete(c.sessions, sid)
+	}
+	return nil
+}
+
+func (c *sessionCacheStub) GetLegacyTokenCutoff(_ context.Context, userID int64) (time.Time, error) {
+	return c.legacyCutoffs[userID], nil
+}
+
+func (c *sessionCacheStub) SetLegacyTokenCutoff(_ context.Context, userID int64, at time.Time, _ time.Duration) error {
+	if c.legacyCutoffs == nil {
+		c.legacyCutoffs = map[int64]time.Time{}
+	}
+	c.legacyCutoffs[userID] = at
+	return nil
+}
+
+func newSessionServiceForTest() (*SessionService, *sessionRepoStub, *sessionCacheStub) {
+	repo := newSessionRepoStub()
+	cache := &sessionCacheStub{sessions: map[string]UserSession{}}
+	return NewSessionService(repo, cache, nil), repo, cache
+}
+
+func TestSessionService_CreateAndValidate(t *testing.T) {
+	svc, _, cache := newSessionServiceForTest()
+	ctx := context.Background()
+
+	session, err := svc.Create(ctx, 1, SessionClientInfo{
+		IPAddress: "203.0.113.7",
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, session.SessionID)
+	require.Equal(t, "Chrome on Windows", session.DeviceName)
+	require.Contains(t, cache.sessions, session.SessionID)
+
+	got, err := svc.Validate(ctx, session.SessionID, 1)
+	require.NoError(t, err)
+	require.Equal(t, session.SessionID, got.SessionID)
+
+	_, err = svc.Validate(ctx, session.SessionID, 2)
+	require.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestSessionService_ValidateFallsBackToRepoOnCacheMiss(t *testing.T) {
+	svc, _, cache := newSessionServiceForTest()
+	ctx := context.Background()
+
+	session, err := svc.Create(ctx, 1, SessionClientInfo{})
+	require.NoError(t, err)
+	delete(cache.sessions, session.SessionID)
+
+	_, err = svc.Validate(ctx, session.SessionID, 1)
+	require.NoError(t, err)
+	require.Contains(t, cache.sessions, session.SessionID, "session should be re-cached after a miss")
+}
+
+func TestSessionService_RevokeRejectsFurtherValidation(t *testing.T) {
+	svc, _, _ := newSessionServiceForTest()
+	ctx := context.Background()
+
+	session, err := svc.Create(ctx, 1, SessionClientInfo{})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, svc.Revoke(ctx, 2, session.SessionID, SessionRevokeReasonUserRevoked), ErrSessionNotFound)
+	require.NoError(t, svc.Revoke(ctx, 1, session.SessionID, SessionRevokeReasonUserRevoked))
+
+	_, err = svc.Validate(ctx, session.SessionID, 1)
+	require.ErrorIs(t, err, ErrSessionRevoked)
+}
+
+func TestSessionService_RevokeAllKeepsCurrentSession(t *testing.T) {
+	svc, _, _ := newSessionServiceForTest()
+	ctx := context.Background()
+
+	current, err := svc.Create(ctx, 1, SessionClientInfo{})
+	require.NoError(t, err)
+	other, err := svc.Create(ctx, 1, SessionClientInfo{})
+	require.NoError(t, err)
+	foreign, err := svc.Create(ctx, 2, SessionClientInfo{})
+	require.NoError(t, err)
+
+	revoked, err := svc.RevokeAllForUser(ctx, 1, current.SessionID, SessionRevokeReasonTotpChanged)
+	require.NoError(t, err)
+	require.Equal(t, 1, revoked)
+
+	_, err = svc.Validate(ctx, current.SessionID, 1)
+	require.NoError(t, err)
+	_, err = svc.Validate(ctx, other.SessionID, 1)
+	require.ErrorIs(t, err, ErrSessionRevoked)
+	_, err = svc.Validate(ctx, foreign.SessionID, 2)
+	require.NoError(t, err)
+}
+
+func TestSessionService_ValidateTouchesLastActiveAfterInterval(t *testing.T) {
+	svc, repo, cache := newSessionServiceForTest()
+	ctx := context.Background()
+
+	session, err := svc.Create(ctx, 1, SessionClientInfo{})
+	require.NoError(t, err)
+
+	_, err = svc.Validate(ctx, session.SessionID, 1)
+	require.NoError(t, err)
+	require.Equal(t, 0, repo.touches, "fresh session should not be touched")
+
+	stale := cache.sessions[session.SessionID]
+	stale.LastActiveAt = time.Now().Add(-2 * sessionTouchInterval)
+	cache.sessions[session.SessionID] = stale
+
+	_, err = svc.Validate(ctx, session.SessionID, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, repo.touches)
+}
+
+func TestAuthService_IssueTokenBindsSession(t *testing.T) {
+	repo := &userRepoStub{}
+	auth := newAuthService(repo, nil, nil)
+	sessions, _, _ := newSessionServiceForTest()
+	auth.sessionService = sessions
+
+	user := &User{ID: 7, Email: "user@test.com", Role: RoleUser, Status: StatusActive}
+	token, err := auth.IssueToken(context.Background(), user)
+	require.NoError(t, err)
+
+	claims, err := auth.ValidateToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, claims.SessionID)
+	require.NoError(t, auth.ValidateSession(context.Background(), claims))
+
+	require.NoError(t, auth.Logout(context.Background(), user.ID, claims.SessionID))
+	require.ErrorIs(t, auth.ValidateSession(context.Background(), claims), ErrSessionRevoked)
+}
+
+func TestAuthService_RefreshLegacyTokenCreatesSession(t *testing.T) {
+	user := &User{ID: 7, Email: "user@test.com", Role: RoleUser, Status: StatusActive}
+	auth := newAuthService(&userRepoStub{user: user}, nil, nil)
+	sessions, repo, _ := newSessionServiceForTest()
+	auth.sessionService = sessions
+
+	// 升级前签发的 token 不携带 sid
+	legacy, err := auth.GenerateToken(user)
+	require.NoError(t, err)
+
+	refreshed, err := auth.RefreshToken(context.Background(), legacy)
+	require.NoError(t, err)
+	claims, err := auth.ValidateToken(refreshed)
+	require.NoError(t, err)
+	require.NotEmpty(t, claims.SessionID)
+	require.Contains(t, repo.sessions, claims.SessionID)
+
+	// 迁移后的会话可被吊销，吊销后无法继续刷新
+	require.NoError(t, auth.Logout(context.Background(), user.ID, claims.SessionID))
+	_, err = auth.RefreshToken(context.Background(), refreshed)
+	require.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestAuthService_LegacyTokenRevokedByRevokeAll(t *testing.T) {
+	user := &User{ID: 7, Email: "user@test.com", Role: RoleUser, Status: StatusActive}
+	auth := newAuthService(&userRepoStub{user: user}, nil, nil)
+	sessions, _, _ := newSessionServiceForTest()
+	auth.sessionService = sessions
+
+	legacy, err := auth.GenerateToken(user)
+	require.NoError(t, err)
+	claims, err := auth.ValidateToken(legacy)
+	require.NoError(t, err)
+	require.NoError(t, auth.ValidateSession(context.Background(), claims))
+
+	// 签发时间早于吊销时间（iat 为秒级精度）
+	claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	_, err = sessions.RevokeAllForUser(context.Background(), user.ID, "", SessionRevokeReasonAdminForce)
+	require.NoError(t, err)
+	require.ErrorIs(t, auth.ValidateSession(context.Background(), claims), ErrSessionRevoked)
+
+	// 吊销后无法通过刷新迁移为会话 token
+	_, err = auth.RefreshToken(context.Background(), legacy)
+	require.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestAuthService_LegacyTokenOutsideMigrationWindow(t *testing.T) {
+	auth := newAuthService(&userRepoStub{}, nil, nil)
+	sessions, _, _ := newSessionServiceForTest()
+	auth.sessionService = sessions
+
+	claims := &JWTClaims{UserID: 7}
+	require.ErrorIs(t, auth.ValidateSession(context.Background(), claims), ErrSessionExpired)
+
+	claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-defaultSessionLifetime - time.Hour))
+	require.ErrorIs(t, auth.ValidateSession(context.Background(), claims), ErrSessionExpired)
+}
+
+func TestAuthService_ValidateSessionStorageErrorIsNotRevocation(t *testing.T) {
+	user := &User{ID: 7, Email: "user@test.com", Role: RoleUser, Status: StatusActive}
+	auth := newAuthService(&userRepoStub{user: user}, nil, nil)
+	sessions, repo, cache := newSessionServiceForTest()
+	auth.sessionService = sessions
+
+	token, err := auth.IssueToken(context.Background(), user)
+	require.NoError(t, err)
+	claims, err := auth.ValidateToken(token)
+	require.NoError(t, err)
+
+	cache.sessions = map[string]UserSession{}
+	repo.getErr = errors.New("db down")
+	err = auth.ValidateSession(context.Background(), claims)
+	require.ErrorIs(t, err, ErrServiceUnavailable)
+	require.False(t, IsSessionInvalid(err))
+
+	_, err = auth.RefreshToken(context.Background(), token)
+	require.ErrorIs(t, err, ErrServiceUnavailable)
+}
@@ -100,6 +100,8 @@ func (s *SettingService) GetPublicSettings(ctx context.Context) (*PublicSettings
 		PromoCodeEnabled:            settings[SettingKeyPromoCodeEnabled] != "false", // 默认启用
 		PasswordResetEnabled:        passwordResetEnabled,
 		TotpEnabled:                 settings[SettingKeyTotpEnabled] == "true",
+		WebAuthnEnabled:             s.cfg != nil && s.cfg.WebAuthn.Enabled,
+		PasskeyLoginEnabled:         s.cfg != nil && s.cfg.WebAuthn.Enabled && s.cfg.WebAuthn.AllowPasswordless,
 		TurnstileEnabled:            settings[SettingKeyTurnstileEnabled] == "true",
 		TurnstileSiteKey:            settings[SettingKeyTurnstileSiteKey],
 		SiteName:                    s.getStringOrDefault(settings, SettingKeySiteName, "Sub2API"),
@@ -142,6 +144,8 @@ func (s *SettingService) GetPublicSettingsForInjection(ctx context.Context) (any
 		PromoCodeEnabled            bool   `json:"promo_code_enabled"`
 		PasswordResetEnabled        bool   `json:"password_reset_enabled"`
 		TotpEnabled                 bool   `json:"totp_enabled"`
+		WebAuthnEnabled             bool   `json:"webauthn_enabled"`
+		PasskeyLoginEnabled         bool   `json:"passkey_login_enabled"`
 		TurnstileEnabled            bool   `json:"turnstile_enabled"`
 		TurnstileSiteKey            string `json:"turnstile_site_key,omitempty"`
 		SiteName                    string `json:"site_name"`
@@ -162,6 +166,8 @@ func (s *SettingService) GetPublicSettingsForInjection(ctx context.Context) (any
 		PromoCodeEnabled:            settings.PromoCodeEnabled,
 		PasswordResetEnabled:        settings.PasswordResetEnabled,
 		TotpEnabled:                 settings.TotpEnabled,
+		WebAuthnEnabled:             settings.WebAuthnEnabled,
+		PasskeyLoginEnabled:         settings.PasskeyLoginEnabled,
 		TurnstileEnabled:            settings.TurnstileEnabled,
 		TurnstileSiteKey:            settings.TurnstileSiteKey,
 		SiteName:                    settings.SiteName,
@@ -189,6 +195,7 @@ func (s *SettingService) UpdateSettings(ctx context.Context, settings *SystemSet
 	updates[SettingKeyPromoCodeEnabled] = strconv.FormatBool(settings.PromoCodeEnabled)
 	updates[SettingKeyPasswordResetEnabled] = strconv.FormatBool(settings.PasswordResetEnabled)
 	updates[SettingKeyTotpEnabled] = strconv.FormatBool(settings.TotpEnabled)
+	updates[SettingKeyRequireAdmin2FA] = strconv.FormatBool(settings.RequireAdmin2FA)
 
 	// 邮件服务设置（只有非空才更新密码）
 	updates[SettingKeySMTPHost] = settings.SMTPHost
@@ -309,6 +316,15 @@ func (s *SettingService) IsTotpEnabled(ctx context.Context) bool {
 	return value == "true"
 }
 
+// IsAdmin2FARequired 检查是否要求管理员必须配置双因素认证
+func (s *SettingService) IsAdmin2FARequired(ctx context.Context) bool {
+	value, err := s.settingRepo.GetValue(ctx, SettingKeyRequireAdmin2FA)
+	if err != nil {
+		return false // 默认关闭
+	}
+	return value == "true"
+}
+
 // IsTotpEncryptionKeyConfigured 检查 TOTP 加密密钥是否已手动配置
 // 只有手动配置了密钥才允许在管理后台启用 TOTP 功能
 func (s *SettingService) IsTotpEncryptionKeyConfigured() bool {
@@ -402,6 +418,7 @@ func (s *SettingService) parseSettings(settings map[string]string) *SystemSettin
 		PromoCodeEnabled:             settings[SettingKeyPromoCodeEnabled] != "false", // 默认启用
 		PasswordResetEnabled:         emailVerifyEnabled && settings[SettingKeyPasswordResetEnabled] == "true",
 		TotpEnabled:                  settings[SettingKeyTotpEnabled] == "true",
+		RequireAdmin2FA:              settings[SettingKeyRequireAdmin2FA] == "true",
 		SMTPHost:                     settings[SettingKeySMTPHost],
 		SMTPUsername:                 settings[SettingKeySMTPUsername],
 		SMTPFrom:                     settings[SettingKeySMTPFrom],
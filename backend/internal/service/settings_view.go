@@ -6,6 +6,7 @@ type SystemSettings struct {
 	PromoCodeEnabled     bool
 	PasswordResetEnabled bool
 	TotpEnabled          bool // TOTP 双因素认证
+	RequireAdmin2FA      bool // 管理员强制 2FA
 
 	SMTPHost               string
 	SMTPPort               int
@@ -66,6 +67,8 @@ type PublicSettings struct {
 	PromoCodeEnabled     bool
 	PasswordResetEnabled bool
 	TotpEnabled          bool // TOTP 双因素认证
+	WebAuthnEnabled      bool // WebAuthn 安全密钥（来自配置文件）
+	PasskeyLoginEnabled  bool // Passkey 无密码登录（来自配置文件）
 	TurnstileEnabled     bool
 	TurnstileSiteKey     string
 	SiteName             string
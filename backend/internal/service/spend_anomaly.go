@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 消费异常类型
+const (
+	SpendAnomalyKindSpike        = "spend_spike"    // 当前小时消费相对基线突增
+	SpendAnomalyKindNewIP        = "new_ip"         // 出现基线期内未见过的客户端 IP
+	SpendAnomalyKindNewUserAgent = "new_user_agent" // 出现基线期内未见过的客户端（User-Agent 产品名）
+	SpendAnomalyKindModelMix     = "model_mix"      // 模型消费分布相对基线大幅偏移
+)
+
+// 消费异常处置动作
+const (
+	SpendAnomalyActionAlert            = "alert"             // 仅进入审查队列
+	SpendAnomalyActionDisableKey       = "disable_key"       // 停用 API Key（用户级命中时停用窗口内有消费的全部 Key）
+	SpendAnomalyActionLowerConcurrency = "lower_concurrency" // 将用户并发降至配置值
+)
+
+// 审查状态
+const (
+	SpendAnomalyStatusPending   = "pending"
+	SpendAnomalyStatusConfirmed = "confirmed" // 确认异常，保留处置
+	SpendAnomalyStatusDismissed = "dismissed" // 驳回，恢复处置前状态
+)
+
+// 告警规则中的消费类指标
+const (
+	OpsMetricSpendUSD                 = "spend_usd"                   // 窗口内总消费（美元，可按分组过滤）
+	OpsMetricMaxUserSpendUSD          = "max_user_spend_usd"          // 窗口内单个用户的最高消费
+	OpsMetricMaxAPIKeySpendUSD        = "max_api_key_spend_usd"       // 窗口内单个 API Key 的最高消费
+	OpsMetricSpendAnomalyPendingCount = "spend_anomaly_pending_count" // 待审查的消费异常数量
+)
+
+var (
+	ErrSpendAnomalyFlagNotFound    = infraerrors.NotFound("SPEND_ANOMALY_FLAG_NOT_FOUND", "spend anomaly flag not found")
+	ErrSpendAnomalyAlreadyReviewed = infraerrors.Conflict("SPEND_ANOMALY_ALREADY_REVIEWED", "spend anomaly flag has already been reviewed")
+	ErrSpendAnomalyDecisionInvalid = infraerrors.BadRequest("SPEND_ANOMALY_DECISION_INVALID", "decision must be one of confirm, dismiss")
+)
+
+// SpendAnomalyFlag 消费异常命中记录（审查队列条目）
+type SpendAnomalyFlag struct {
+	ID            int64
+	UserID        int64
+	APIKeyID      int64 // 0 表示用户级命中
+	Kind          string
+	Action        string
+	Status        string
+	CurrentValue  float64
+	BaselineValue float64
+	WindowStart   time.Time
+	WindowEnd     time.Time
+	Details       map[string]any
+	ActionState   *SpendAnomalyActionState
+	ReviewNote    string
+	ReviewedAt    *time.Time
+	ReviewedBy    *int64
+	CreatedAt     time.Time
+}
+
+// SpendAnomalyActionState 处置前状态，驳回时据此恢复
+type SpendAnomalyActionState struct {
+	DisabledAPIKeyIDs   []int64 `json:"disabled_api_key_ids,omitempty"`
+	PreviousConcurrency *int    `json:"previous_concurrency,omitempty"`
+	LoweredConcurrency  *int    `json:"lowered_concurrency,omitempty"`
+}
+
+// SpendAnomalyFlagFilter 审查队列查询条件
+type SpendAnomalyFlagFilter struct {
+	Status   string
+	Kind     string
+	UserID   *int64
+	APIKeyID *int64
+}
+
+// SpendWindowStat 当前窗口与基线期的消费统计（按 API Key 或用户聚合）
+type SpendWindowStat struct {
+	UserID       int64
+	APIKeyID     int64 // 用户级统计时为 0
+	CurrentCost  float64
+	BaselineCost float64
+	// BaselineFirstAt 基线期内最早的使用记录时间，nil 表示基线期内没有使用记录
+	BaselineFirstAt *time.Time
+}
+
+// SpendAnomalyRepository 消费异常检测的数据访问
+type SpendAnomalyRepository interface {
+	// ListAPIKeySpendStats 返回当前窗口消费不低于 minCurrent 的 API Key 及其基线期消费
+	ListAPIKeySpendStats(ctx context.Context, windowStart, windowEnd, baselineStart time.Time, minCurrent float64) ([]SpendWindowStat, error)
+	// ListUserSpendStats 返回当前窗口消费不低于 minCurrent 的用户及其基线期消费
+	ListUserSpendStats(ctx context.Context, windowStart, windowEnd, baselineStart time.Time, minCurrent float64) ([]SpendWindowStat, error)
+	// ListClientFingerprints 返回 API Key 在时间段内使用过的去重 IP 与 User-Agent
+	ListClientFingerprints(ctx context.Context, apiKeyID int64, start, end time.Time) (ips []string, userAgents []string, err error)
+	// GetModelSpend 返回 API Key 在时间段内按模型聚合的消费
+	GetModelSpend(ctx context.Context, apiKeyID int64, start, end time.Time) (map[string]float64, error)
+	// ListActiveAPIKeyIDs 返回用户在时间段内有消费的 API Key
+	ListActiveAPIKeyIDs(ctx context.Context, userID int64, start, end time.Time) ([]int64, error)
+	// GetSpendMetric 计算告警规则使用的消费类指标
+	GetSpendMetric(ctx context.Context, metricType string, start, end time.Time, groupID *int64) (float64, error)
+
+	// CreateFlag 写入命中记录；同一对象同一类型已有待审查记录时返回 false
+	CreateFlag(ctx context.Context, flag *SpendAnomalyFlag) (bool, error)
+	// HasRecentDismissal 判断同一对象同一类型在 since 之后是否被驳回过
+	HasRecentDismissal(ctx context.Context, userID, apiKeyID int64, kind string, since time.Time) (bool, error)
+	UpdateFlagActionState(ctx context.Context, id int64, state *SpendAnomalyActionState) error
+	GetFlag(ctx context.Context, id int64) (*SpendAnomalyFlag, error)
+	ListFlags(ctx context.Context, params pagination.PaginationParams, filter SpendAnomalyFlagFilter) ([]SpendAnomalyFlag, *pagination.PaginationResult, error)
+	// ReviewFlag 将待审查记录标记为 status；记录已被审查时返回 ErrSpendAnomalyAlreadyReviewed
+	ReviewFlag(ctx context.Context, id int64, status string, reviewerID int64, note string) error
+	CountPendingFlags(ctx context.Context) (int64, error)
+}
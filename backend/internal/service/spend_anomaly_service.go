@@ -0,0 +1,554 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	spendAnomalyWindow     = time.Hour
+	spendAnomalyRunTimeout = 2 * time.Minute
+	// 详情中最多保留的新客户端样本数
+	spendAnomalyMaxSamples = 20
+)
+
+// SpendAnomalyService 基于使用记录的消费异常检测
+//
+//   - 周期性对比最近 1 小时与此前 baseline_days 天的小时均值，发现 API Key / 用户级消费突增；
+//   - 对当前窗口内有显著消费的 Key 检查陌生 IP、陌生客户端（User-Agent 产品名）以及模型消费分布的突变；
+//   - 命中后写入审查队列，并按配置执行处置（停用 Key / 降低用户并发），管理员驳回时恢复处置前状态。
+//
+// 多实例部署时依赖待审查记录的唯一约束去重，只有成功写入记录的实例会执行处置。
+type SpendAnomalyService struct {
+	repo                 SpendAnomalyRepository
+	apiKeyRepo           APIKeyRepository
+	userRepo             UserRepository
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	cfg                  *config.Config
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewSpendAnomalyService 创建消费异常检测服务
+func NewSpendAnomalyService(
+	repo SpendAnomalyRepository,
+	apiKeyRepo APIKeyRepository,
+	userRepo UserRepository,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	cfg *config.Config,
+) *SpendAnomalyService {
+	return &SpendAnomalyService{
+		repo:                 repo,
+		apiKeyRepo:           apiKeyRepo,
+		userRepo:             userRepo,
+		authCacheInvalidator: authCacheInvalidator,
+		cfg:                  cfg,
+		stopCh:               make(chan struct{}),
+	}
+}
+
+// Start 启动后台检测任务
+func (s *SpendAnomalyService) Start() {
+	if s == nil || s.repo == nil || s.cfg == nil || !s.cfg.SpendAnomaly.Enabled {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(time.Duration(s.cfg.SpendAnomaly.IntervalSeconds) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), spendAnomalyRunTimeout)
+				if _, err := s.DetectOnce(ctx, time.Now()); err != nil {
+					log.Printf("[SpendAnomaly] detection failed: %v", err)
+				}
+				cancel()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台检测任务
+func (s *SpendAnomalyService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+// DetectOnce 执行一轮检测，返回本轮新写入的命中记录数
+func (s *SpendAnomalyService) DetectOnce(ctx context.Context, now time.Time) (int, error) {
+	cfg := s.cfg.SpendAnomaly
+	windowEnd := now.UTC()
+	windowStart := windowEnd.Add(-spendAnomalyWindow)
+	baselineStart := windowStart.Add(-time.Duration(cfg.BaselineDays) * 24 * time.Hour)
+
+	keyStats, err := s.repo.ListAPIKeySpendStats(ctx, windowStart, windowEnd, baselineStart, cfg.MinHourlySpendUSD)
+	if err != nil {
+		return 0, fmt.Errorf("list api key spend stats: %w", err)
+	}
+
+	created := 0
+	spikedUsers := map[int64]bool{}
+	for i := range keyStats {
+		stat := &keyStats[i]
+		if flag := s.checkSpike(stat, windowStart); flag != nil {
+			if s.raise(ctx, flag, windowStart, windowEnd) {
+				created++
+			}
+			spikedUsers[stat.UserID] = true
+		}
+		if stat.BaselineFirstAt == nil {
+			// 没有基线的新 Key 无法判断客户端与模型组合是否异常
+			continue
+		}
+		for _, flag := range s.checkKeyBehavior(ctx, stat, windowStart, windowEnd, baselineStart) {
+			if s.raise(ctx, flag, windowStart, windowEnd) {
+				created++
+			}
+		}
+	}
+
+	userStats, err := s.repo.ListUserSpendStats(ctx, windowStart, windowEnd, baselineStart, cfg.MinHourlySpendUSD)
+	if err != nil {
+		return created, fmt.Errorf("list user spend stats: %w", err)
+	}
+	for i := range userStats {
+		stat := &userStats[i]
+		// 已由 Key 级突增覆盖的用户不再重复命中
+		if spikedUsers[stat.UserID] {
+			continue
+		}
+		if flag := s.checkSpike(stat, windowStart); flag != nil {
+			if s.raise(ctx, flag, windowStart, windowEnd) {
+				created++
+			}
+		}
+	}
+	return created, nil
+}
+
+// checkSpike 当前窗口消费达到基线小时均值（不低于 min_hourly_spend_usd）的 spike_multiplier 倍时命中
+func (s *SpendAnomalyService) checkSpike(stat *SpendWindowStat, windowStart time.Time) *SpendAnomalyFlag {
+	cfg := s.cfg.SpendAnomaly
+	baseline := spendBaselineHourly(stat, windowStart)
+	threshold := math.Max(baseline, cfg.MinHourlySpendUSD) * cfg.SpikeMultiplier
+	if stat.CurrentCost < threshold {
+		return nil
+	}
+	return &SpendAnomalyFlag{
+		UserID:        stat.UserID,
+		APIKeyID:      stat.APIKeyID,
+		Kind:          SpendAnomalyKindSpike,
+		Action:        cfg.SpikeAction,
+		CurrentValue:  stat.CurrentCost,
+		BaselineValue: baseline,
+		Details: map[string]any{
+			"threshold_usd":    threshold,
+			"spike_multiplier": cfg.SpikeMultiplier,
+		},
+	}
+}
+
+// spendBaselineHourly 基线小时均值：基线消费按基线期内首次使用以来的小时数平均，避免新 Key 的基线被空白期稀释
+func spendBaselineHourly(stat *SpendWindowStat, windowStart time.Time) float64 {
+	if stat.BaselineFirstAt == nil || stat.BaselineCost <= 0 {
+		return 0
+	}
+	hours := windowStart.Sub(*stat.BaselineFirstAt).Hours()
+	if hours < 1 {
+		hours = 1
+	}
+	return stat.BaselineCost / hours
+}
+
+// checkKeyBehavior 检查陌生客户端与模型组合突变（仅对有基线的 Key）
+func (s *SpendAnomalyService) checkKeyBehavior(ctx context.Context, stat *SpendWindowStat, windowStart, windowEnd, baselineStart time.Time) []*SpendAnomalyFlag {
+	cfg := s.cfg.SpendAnomaly
+	var flags []*SpendAnomalyFlag
+
+	if cfg.NewIPThreshold > 0 || cfg.NewUserAgentThreshold > 0 {
+		curIPs, curUAs, err := s.repo.ListClientFingerprints(ctx, stat.APIKeyID, windowStart, windowEnd)
+		if err != nil {
+			log.Printf("[SpendAnomaly] list client fingerprints failed: api_key=%d err=%v", stat.APIKeyID, err)
+			return nil
+		}
+		baseIPs, baseUAs, err := s.repo.ListClientFingerprints(ctx, stat.APIKeyID, baselineStart, windowStart)
+		if err != nil {
+			log.Printf("[SpendAnomaly] list client fingerprints failed: api_key=%d err=%v", stat.APIKeyID, err)
+			return nil
+		}
+		if cfg.NewIPThreshold > 0 {
+			if newIPs := unseenValues(curIPs, baseIPs, strings.TrimSpace); len(newIPs) >= cfg.NewIPThreshold {
+				flags = append(flags, s.newClientFlag(stat, SpendAnomalyKindNewIP, newIPs, len(baseIPs)))
+			}
+		}
+		if cfg.NewUserAgentThreshold > 0 {
+			if newUAs := unseenValues(curUAs, baseUAs, userAgentFamily); len(newUAs) >= cfg.NewUserAgentThreshold {
+				flags = append(flags, s.newClientFlag(stat, SpendAnomalyKindNewUserAgent, newUAs, len(baseUAs)))
+			}
+		}
+	}
+
+	if cfg.ModelMixThreshold > 0 {
+		current, err := s.repo.GetModelSpend(ctx, stat.APIKeyID, windowStart, windowEnd)
+		if err != nil {
+			log.Printf("[SpendAnomaly] get model spend failed: api_key=%d err=%v", stat.APIKeyID, err)
+			return flags
+		}
+		baseline, err := s.repo.GetModelSpend(ctx, stat.APIKeyID, baselineStart, windowStart)
+		if err != nil {
+			log.Printf("[SpendAnomaly] get model spend failed: api_key=%d err=%v", stat.APIKeyID, err)
+			return flags
+		}
+		if shift, ok := modelMixShift(current, baseline); ok && shift >= cfg.ModelMixThreshold {
+			flags = append(flags, &SpendAnomalyFlag{
+				UserID:        stat.UserID,
+				APIKeyID:      stat.APIKeyID,
+				Kind:          SpendAnomalyKindModelMix,
+				Action:        cfg.ModelMixAction,
+				CurrentValue:  shift,
+				BaselineValue: cfg.ModelMixThreshold,
+				Details: map[string]any{
+					"current_models":  topModelShares(current),
+					"baseline_models": topModelShares(baseline),
+					"current_usd":     stat.CurrentCost,
+				},
+			})
+		}
+	}
+	return flags
+}
+
+func (s *SpendAnomalyService) newClientFlag(stat *SpendWindowStat, kind string, values []string, baselineCount int) *SpendAnomalyFlag {
+	samples := values
+	if len(samples) > spendAnomalyMaxSamples {
+		samples = samples[:spendAnomalyMaxSamples]
+	}
+	return &SpendAnomalyFlag{
+		UserID:        stat.UserID,
+		APIKeyID:      stat.APIKeyID,
+		Kind:          kind,
+		Action:        s.cfg.SpendAnomaly.NewClientAction,
+		CurrentValue:  float64(len(values)),
+		BaselineValue: float64(baselineCount),
+		Details: map[string]any{
+			"new_values":  samples,
+			"current_usd": stat.CurrentCost,
+		},
+	}
+}
+
+// unseenValues 返回 current 中归一化后未在 baseline 出现过的值（按归一化结果去重、排序）
+func unseenValues(current, baseline []string, normalize func(string) string) []string {
+	seen := make(map[string]struct{}, len(baseline))
+	for _, v := range baseline {
+		if n := normalize(v); n != "" {
+			seen[n] = struct{}{}
+		}
+	}
+	var out []string
+	for _, v := range current {
+		n := normalize(v)
+		if n == "" {
+			continue
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// userAgentFamily 提取 User-Agent 的产品名（首个 token 去掉版本号），客户端升级不视为陌生客户端
+func userAgentFamily(ua string) string {
+	ua = strings.ToLower(strings.TrimSpace(ua))
+	if ua == "" {
+		return ""
+	}
+	if i := strings.IndexAny(ua, " ("); i >= 0 {
+		ua = ua[:i]
+	}
+	if i := strings.Index(ua, "/"); i >= 0 {
+		ua = ua[:i]
+	}
+	return ua
+}
+
+// modelMixShift 计算当前与基线模型消费分布的总变差距离（0-1）；任一侧无消费时返回 false
+func modelMixShift(current, baseline map[string]float64) (float64, bool) {
+	curTotal, baseTotal := sumSpend(current), sumSpend(baseline)
+	if curTotal <= 0 || baseTotal <= 0 {
+		return 0, false
+	}
+	models := make(map[string]struct{}, len(current)+len(baseline))
+	for m := range current {
+		models[m] = struct{}{}
+	}
+	for m := range baseline {
+		models[m] = struct{}{}
+	}
+	var distance float64
+	for m := range models {
+		distance += math.Abs(current[m]/curTotal - baseline[m]/baseTotal)
+	}
+	return distance / 2, true
+}
+
+func sumSpend(spend map[string]float64) float64 {
+	var total float64
+	for _, v := range spend {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+// topModelShares 返回消费占比最高的若干模型（占比保留 4 位小数），用于审查详情
+func topModelShares(spend map[string]float64) map[string]float64 {
+	total := sumSpend(spend)
+	if total <= 0 {
+		return nil
+	}
+	models := make([]string, 0, len(spend))
+	for m := range spend {
+		models = append(models, m)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if spend[models[i]] != spend[models[j]] {
+			return spend[models[i]] > spend[models[j]]
+		}
+		return models[i] < models[j]
+	})
+	if len(models) > 5 {
+		models = models[:5]
+	}
+	out := make(map[string]float64, len(models))
+	for _, m := range models {
+		out[m] = math.Round(spend[m]/total*10000) / 10000
+	}
+	return out
+}
+
+// raise 写入命中记录并执行处置；驳回冷却期内或已有待审查记录时跳过，返回是否新写入
+func (s *SpendAnomalyService) raise(ctx context.Context, flag *SpendAnomalyFlag, windowStart, windowEnd time.Time) bool {
+	if cooldown := s.cfg.SpendAnomaly.CooldownMinutes; cooldown > 0 {
+		since := windowEnd.Add(-time.Duration(cooldown) * time.Minute)
+		dismissed, err := s.repo.HasRecentDismissal(ctx, flag.UserID, flag.APIKeyID, flag.Kind, since)
+		if err != nil {
+			log.Printf("[SpendAnomaly] check dismissal failed: user=%d api_key=%d err=%v", flag.UserID, flag.APIKeyID, err)
+			return false
+		}
+		if dismissed {
+			return false
+		}
+	}
+
+	flag.Status = SpendAnomalyStatusPending
+	flag.WindowStart = windowStart
+	flag.WindowEnd = windowEnd
+	created, err := s.repo.CreateFlag(ctx, flag)
+	if err != nil {
+		log.Printf("[SpendAnomaly] create flag failed: user=%d api_key=%d kind=%s err=%v", flag.UserID, flag.APIKeyID, flag.Kind, err)
+		return false
+	}
+	if !created {
+		return false
+	}
+	log.Printf("[SpendAnomaly] flagged user=%d api_key=%d kind=%s current=%.4f baseline=%.4f action=%s",
+		flag.UserID, flag.APIKeyID, flag.Kind, flag.CurrentValue, flag.BaselineValue, flag.Action)
+
+	if flag.Action != SpendAnomalyActionAlert {
+		state := s.applyAction(ctx, flag)
+		if state != nil {
+			if err := s.repo.UpdateFlagActionState(ctx, flag.ID, state); err != nil {
+				log.Printf("[SpendAnomaly] save action state failed: flag=%d err=%v", flag.ID, err)
+			}
+			flag.ActionState = state
+		}
+	}
+	return true
+}
+
+// applyAction 执行处置并返回处置前状态（管理员用户不处置）
+func (s *SpendAnomalyService) applyAction(ctx context.Context, flag *SpendAnomalyFlag) *SpendAnomalyActionState {
+	user, err := s.userRepo.GetByID(ctx, flag.UserID)
+	if err != nil || user == nil {
+		log.Printf("[SpendAnomaly] load user failed: user=%d err=%v", flag.UserID, err)
+		return nil
+	}
+	if user.IsAdmin() {
+		return nil
+	}
+
+	switch flag.Action {
+	case SpendAnomalyActionDisableKey:
+		keyIDs := []int64{flag.APIKeyID}
+		if flag.APIKeyID == 0 {
+			keyIDs, err = s.repo.ListActiveAPIKeyIDs(ctx, flag.UserID, flag.WindowStart, flag.WindowEnd)
+			if err != nil {
+				log.Printf("[SpendAnomaly] list active api keys failed: user=%d err=%v", flag.UserID, err)
+				return nil
+			}
+		}
+		state := &SpendAnomalyActionState{}
+		for _, id := range keyIDs {
+			if s.setAPIKeyStatus(ctx, id, StatusActive, StatusDisabled) {
+				state.DisabledAPIKeyIDs = append(state.DisabledAPIKeyIDs, id)
+			}
+		}
+		return state
+
+	case SpendAnomalyActionLowerConcurrency:
+		lowered := s.cfg.SpendAnomaly.LoweredConcurrency
+		if user.Concurrency <= lowered {
+			return nil
+		}
+		previous := user.Concurrency
+		user.Concurrency = lowered
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			log.Printf("[SpendAnomaly] lower concurrency failed: user=%d err=%v", user.ID, err)
+			return nil
+		}
+		if s.authCacheInvalidator != nil {
+			s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, user.ID)
+		}
+		return &SpendAnomalyActionState{PreviousConcurrency: &previous, LoweredConcurrency: &lowered}
+	}
+	return nil
+}
+
+// setAPIKeyStatus 仅当 Key 当前状态为 from 时改为 to，返回是否修改
+func (s *SpendAnomalyService) setAPIKeyStatus(ctx context.Context, apiKeyID int64, from, to string) bool {
+	key, err := s.apiKeyRepo.GetByID(ctx, apiKeyID)
+	if err != nil || key == nil {
+		log.Printf("[SpendAnomaly] load api key failed: api_key=%d err=%v", apiKeyID, err)
+		return false
+	}
+	if key.Status != from {
+		return false
+	}
+	key.Status = to
+	if err := s.apiKeyRepo.Update(ctx, key); err != nil {
+		log.Printf("[SpendAnomaly] update api key status failed: api_key=%d err=%v", apiKeyID, err)
+		return false
+	}
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByKey(ctx, key.Key)
+	}
+	return true
+}
+
+// ---- 管理接口 ----
+
+// ListFlags 分页查询审查队列
+func (s *SpendAnomalyService) ListFlags(ctx context.Context, params pagination.PaginationParams, filter SpendAnomalyFlagFilter) ([]SpendAnomalyFlag, *pagination.PaginationResult, error) {
+	return s.repo.ListFlags(ctx, params, filter)
+}
+
+// GetFlag 查询单条命中记录
+func (s *SpendAnomalyService) GetFlag(ctx context.Context, id int64) (*SpendAnomalyFlag, error) {
+	return s.repo.GetFlag(ctx, id)
+}
+
+// ReviewFlag 审查命中记录：confirm 保留处置，dismiss 恢复处置前状态（仅恢复未被他人再次修改的部分）
+func (s *SpendAnomalyService) ReviewFlag(ctx context.Context, id int64, decision string, reviewerID int64, note string) (*SpendAnomalyFlag, error) {
+	var status string
+	switch decision {
+	case "confirm":
+		status = SpendAnomalyStatusConfirmed
+	case "dismiss":
+		status = SpendAnomalyStatusDismissed
+	default:
+		return nil, ErrSpendAnomalyDecisionInvalid
+	}
+
+	flag, err := s.repo.GetFlag(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if flag.Status != SpendAnomalyStatusPending {
+		return nil, ErrSpendAnomalyAlreadyReviewed
+	}
+	if err := s.repo.ReviewFlag(ctx, id, status, reviewerID, strings.TrimSpace(note)); err != nil {
+		return nil, err
+	}
+	if status == SpendAnomalyStatusDismissed {
+		s.restoreAction(ctx, flag)
+	}
+	return s.repo.GetFlag(ctx, id)
+}
+
+func (s *SpendAnomalyService) restoreAction(ctx context.Context, flag *SpendAnomalyFlag) {
+	state := flag.ActionState
+	if state == nil {
+		return
+	}
+	for _, id := range state.DisabledAPIKeyIDs {
+		s.setAPIKeyStatus(ctx, id, StatusDisabled, StatusActive)
+	}
+	if state.PreviousConcurrency != nil && state.LoweredConcurrency != nil {
+		user, err := s.userRepo.GetByID(ctx, flag.UserID)
+		if err != nil || user == nil {
+			log.Printf("[SpendAnomaly] load user failed: user=%d err=%v", flag.UserID, err)
+			return
+		}
+		// 管理员在此期间手动调整过并发时不覆盖
+		if user.Concurrency != *state.LoweredConcurrency {
+			return
+		}
+		user.Concurrency = *state.PreviousConcurrency
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			log.Printf("[SpendAnomaly] restore concurrency failed: user=%d err=%v", user.ID, err)
+			return
+		}
+		if s.authCacheInvalidator != nil {
+			s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, user.ID)
+		}
+	}
+}
+
+// ComputeOpsMetric 计算告警规则中的消费类指标，非消费类指标返回 false
+func (s *SpendAnomalyService) ComputeOpsMetric(ctx context.Context, metricType string, start, end time.Time, groupID *int64) (float64, bool) {
+	if s == nil || s.repo == nil {
+		return 0, false
+	}
+	switch metricType {
+	case OpsMetricSpendAnomalyPendingCount:
+		count, err := s.repo.CountPendingFlags(ctx)
+		if err != nil {
+			return 0, false
+		}
+		return float64(count), true
+	case OpsMetricSpendUSD, OpsMetricMaxUserSpendUSD, OpsMetricMaxAPIKeySpendUSD:
+		value, err := s.repo.GetSpendMetric(ctx, metricType, start, end, groupID)
+		if err != nil {
+			return 0, false
+		}
+		return value, true
+	}
+	return 0, false
+}
@@ -0,0 +1,274 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type spendAnomalyRepoStub struct {
+	keyStats   []SpendWindowStat
+	userStats  []SpendWindowStat
+	curIPs     []string
+	curUAs     []string
+	baseIPs    []string
+	baseUAs    []string
+	curModels  map[string]float64
+	baseModels map[string]float64
+	dismissed  bool
+	flags      []*SpendAnomalyFlag
+}
+
+func (s *spendAnomalyRepoStub) ListAPIKeySpendStats(ctx context.Context, windowStart, windowEnd, baselineStart time.Time, minCurrent float64) ([]SpendWindowStat, error) {
+	return s.keyStats, nil
+}
+
+func (s *spendAnomalyRepoStub) ListUserSpendStats(ctx context.Context, windowStart, windowEnd, baselineStart time.Time, minCurrent float64) ([]SpendWindowStat, error) {
+	return s.userStats, nil
+}
+
+func (s *spendAnomalyRepoStub) ListClientFingerprints(ctx context.Context, apiKeyID int64, start, end time.Time) ([]string, []string, error) {
+	if end.Sub(start) <= spendAnomalyWindow {
+		return s.curIPs, s.curUAs, nil
+	}
+	return s.baseIPs, s.baseUAs, nil
+}
+
+func (s *spendAnomalyRepoStub) GetModelSpend(ctx context.Context, apiKeyID int64, start, end time.Time) (map[string]float64, error) {
+	if end.Sub(start) <= spendAnomalyWindow {
+		return s.curModels, nil
+	}
+	return s.baseModels, nil
+}
+
+func (s *spendAnomalyRepoStub) ListActiveAPIKeyIDs(ctx context.Context, userID int64, start, end time.Time) ([]int64, error) {
+	panic("unexpected ListActiveAPIKeyIDs call")
+}
+
+func (s *spendAnomalyRepoStub) GetSpendMetric(ctx context.Context, metricType string, start, end time.Time, groupID *int64) (float64, error) {
+	panic("unexpected GetSpendMetric call")
+}
+
+func (s *spendAnomalyRepoStub) CreateFlag(ctx context.Context, flag *SpendAnomalyFlag) (bool, error) {
+	for _, f := range s.flags {
+		if f.Status == SpendAnomalyStatusPending && f.UserID == flag.UserID && f.APIKeyID == flag.APIKeyID && f.Kind == flag.Kind {
+			return false, nil
+		}
+	}
+	flag.ID = int64(len(s.flags) + 1)
+	clone := *flag
+	s.flags = append(s.flags, &clone)
+	return true, nil
+}
+
+func (s *spendAnomalyRepoStub) HasRecentDismissal(ctx context.Context, userID, apiKeyID int64, kind string, since time.Time) (bool, error) {
+	return s.dismissed, nil
+}
+
+func (s *spendAnomalyRepoStub) UpdateFlagActionState(ctx context.Context, id int64, state *SpendAnomalyActionState) error {
+	s.flags[id-1].ActionState = state
+	return nil
+}
+
+func (s *spendAnomalyRepoStub) GetFlag(ctx context.Context, id int64) (*SpendAnomalyFlag, error) {
+	if id <= 0 || int(id) > len(s.flags) {
+		return nil, ErrSpendAnomalyFlagNotFound
+	}
+	clone := *s.flags[id-1]
+	return &clone, nil
+}
+
+func (s *spendAnomalyRepoStub) ListFlags(ctx context.Context, params pagination.PaginationParams, filter SpendAnomalyFlagFilter) ([]SpendAnomalyFlag, *pagination.PaginationResult, error) {
+	panic("unexpected ListFlags call")
+}
+
+func (s *spendAnomalyRepoStub) ReviewFlag(ctx context.Context, id int64, status string, reviewerID int64, note string) error {
+	flag := s.flags[id-1]
+	if flag.Status != SpendAnomalyStatusPending {
+		return ErrSpendAnomalyAlreadyReviewed
+	}
+	flag.Status = status
+	flag.ReviewNote = note
+	flag.ReviewedBy = &reviewerID
+	return nil
+}
+
+func (s *spendAnomalyRepoStub) CountPendingFlags(ctx context.Context) (int64, error) {
+	panic("unexpected CountPendingFlags call")
+}
+
+type spendAnomalyAPIKeyRepoStub struct {
+	*apiKeyRepoStub
+	updated []*APIKey
+}
+
+func (s *spendAnomalyAPIKeyRepoStub) Update(ctx context.Context, key *APIKey) error {
+	clone := *key
+	s.updated = append(s.updated, &clone)
+	s.apiKey = &clone
+	return nil
+}
+
+func newSpendAnomalyTestConfig() *config.Config {
+	return &config.Config{SpendAnomaly: config.SpendAnomalyConfig{
+		Enabled:               true,
+		IntervalSeconds:       300,
+		BaselineDays:          7,
+		MinHourlySpendUSD:     5,
+		SpikeMultiplier:       5,
+		NewIPThreshold:        2,
+		NewUserAgentThreshold: 1,
+		ModelMixThreshold:     0.8,
+		SpikeAction:           SpendAnomalyActionAlert,
+		NewClientAction:       SpendAnomalyActionAlert,
+		ModelMixAction:        SpendAnomalyActionAlert,
+		LoweredConcurrency:    1,
+		CooldownMinutes:       360,
+	}}
+}
+
+func spendAnomalyTestNow() time.Time {
+	return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+}
+
+func TestSpendAnomalyDetect_SpikeAgainstBaseline(t *testing.T) {
+	now := spendAnomalyTestNow()
+	// 基线期 100 小时共消费 $200（均值 $2/h，按下限 $5 计），阈值 $25
+	first := now.Add(-spendAnomalyWindow - 100*time.Hour)
+	repo := &spendAnomalyRepoStub{
+		keyStats: []SpendWindowStat{
+			{UserID: 7, APIKeyID: 11, CurrentCost: 30, BaselineCost: 200, BaselineFirstAt: &first},
+			{UserID: 8, APIKeyID: 12, CurrentCost: 20, BaselineCost: 200, BaselineFirstAt: &first},
+		},
+		userStats: []SpendWindowStat{
+			{UserID: 7, CurrentCost: 30, BaselineCost: 200, BaselineFirstAt: &first},
+			{UserID: 9, CurrentCost: 40},
+		},
+	}
+	cfg := newSpendAnomalyTestConfig()
+	cfg.SpendAnomaly.NewIPThreshold = 0
+	cfg.SpendAnomaly.NewUserAgentThreshold = 0
+	cfg.SpendAnomaly.ModelMixThreshold = 0
+	svc := NewSpendAnomalyService(repo, nil, nil, nil, cfg)
+
+	created, err := svc.DetectOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, created)
+	require.Equal(t, int64(11), repo.flags[0].APIKeyID)
+	require.Equal(t, SpendAnomalyKindSpike, repo.flags[0].Kind)
+	require.InDelta(t, 2.0, repo.flags[0].BaselineValue, 1e-9)
+	// 用户 7 已由 Key 级命中覆盖；用户 9 无基线，按下限判定
+	require.Equal(t, int64(9), repo.flags[1].UserID)
+	require.Equal(t, int64(0), repo.flags[1].APIKeyID)
+
+	// 已有待审查记录时不重复写入
+	created, err = svc.DetectOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 0, created)
+
+	// 驳回冷却期内不再命中
+	repo.flags = nil
+	repo.dismissed = true
+	created, err = svc.DetectOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 0, created)
+}
+
+func TestSpendAnomalyDetect_NewClientsAndModelMix(t *testing.T) {
+	now := spendAnomalyTestNow()
+	first := now.Add(-48 * time.Hour)
+	repo := &spendAnomalyRepoStub{
+		keyStats: []SpendWindowStat{{UserID: 7, APIKeyID: 11, CurrentCost: 6, BaselineCost: 240, BaselineFirstAt: &first}},
+		curIPs:   []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"},
+		baseIPs:  []string{"1.1.1.1"},
+		// 客户端升级不算陌生客户端
+		curUAs:     []string{"claude-cli/1.0.80 (external, cli)", "python-requests/2.31"},
+		baseUAs:    []string{"claude-cli/1.0.61 (external, cli)"},
+		curModels:  map[string]float64{"claude-opus-4": 6},
+		baseModels: map[string]float64{"claude-haiku": 230, "claude-opus-4": 10},
+	}
+	svc := NewSpendAnomalyService(repo, nil, nil, nil, newSpendAnomalyTestConfig())
+
+	created, err := svc.DetectOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 3, created)
+
+	byKind := map[string]*SpendAnomalyFlag{}
+	for _, f := range repo.flags {
+		byKind[f.Kind] = f
+	}
+	require.Equal(t, []string{"2.2.2.2", "3.3.3.3"}, byKind[SpendAnomalyKindNewIP].Details["new_values"])
+	require.Equal(t, []string{"python-requests"}, byKind[SpendAnomalyKindNewUserAgent].Details["new_values"])
+	require.InDelta(t, 1-10.0/240, byKind[SpendAnomalyKindModelMix].CurrentValue, 1e-9)
+	require.NotContains(t, byKind, SpendAnomalyKindSpike)
+}
+
+func TestSpendAnomalyDisableKeyAndDismissRestores(t *testing.T) {
+	now := spendAnomalyTestNow()
+	repo := &spendAnomalyRepoStub{
+		keyStats: []SpendWindowStat{{UserID: 7, APIKeyID: 11, CurrentCost: 100}},
+	}
+	apiKeyRepo := &spendAnomalyAPIKeyRepoStub{apiKeyRepoStub: &apiKeyRepoStub{
+		apiKey: &APIKey{ID: 11, UserID: 7, Key: "sk-leaked", Status: StatusActive},
+	}}
+	userRepo := &balanceUserRepoStub{userRepoStub: &userRepoStub{user: &User{ID: 7, Role: RoleUser, Status: StatusActive, Concurrency: 5}}}
+	invalidator := &authCacheInvalidatorStub{}
+	cfg := newSpendAnomalyTestConfig()
+	cfg.SpendAnomaly.SpikeAction = SpendAnomalyActionDisableKey
+	svc := NewSpendAnomalyService(repo, apiKeyRepo, userRepo, invalidator, cfg)
+
+	created, err := svc.DetectOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, created)
+	require.Equal(t, StatusDisabled, apiKeyRepo.apiKey.Status)
+	require.Equal(t, []int64{11}, repo.flags[0].ActionState.DisabledAPIKeyIDs)
+	require.Equal(t, []string{"sk-leaked"}, invalidator.keys)
+
+	flag, err := svc.ReviewFlag(context.Background(), 1, "dismiss", 1, " false positive ")
+	require.NoError(t, err)
+	require.Equal(t, SpendAnomalyStatusDismissed, flag.Status)
+	require.Equal(t, "false positive", flag.ReviewNote)
+	require.Equal(t, StatusActive, apiKeyRepo.apiKey.Status)
+
+	_, err = svc.ReviewFlag(context.Background(), 1, "confirm", 1, "")
+	require.ErrorIs(t, err, ErrSpendAnomalyAlreadyReviewed)
+	_, err = svc.ReviewFlag(context.Background(), 1, "ignore", 1, "")
+	require.ErrorIs(t, err, ErrSpendAnomalyDecisionInvalid)
+}
+
+func TestSpendAnomalyLowerConcurrency(t *testing.T) {
+	now := spendAnomalyTestNow()
+	repo := &spendAnomalyRepoStub{
+		keyStats: []SpendWindowStat{{UserID: 7, APIKeyID: 11, CurrentCost: 100}},
+	}
+	userRepo := &balanceUserRepoStub{userRepoStub: &userRepoStub{user: &User{ID: 7, Role: RoleUser, Status: StatusActive, Concurrency: 5}}}
+	invalidator := &authCacheInvalidatorStub{}
+	cfg := newSpendAnomalyTestConfig()
+	cfg.SpendAnomaly.SpikeAction = SpendAnomalyActionLowerConcurrency
+	svc := NewSpendAnomalyService(repo, nil, userRepo, invalidator, cfg)
+
+	_, err := svc.DetectOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, userRepo.user.Concurrency)
+	require.Equal(t, 5, *repo.flags[0].ActionState.PreviousConcurrency)
+	require.Equal(t, []int64{7}, invalidator.userIDs)
+
+	_, err = svc.ReviewFlag(context.Background(), 1, "dismiss", 1, "")
+	require.NoError(t, err)
+	require.Equal(t, 5, userRepo.user.Concurrency)
+
+	// 管理员用户只进入审查队列，不自动处置
+	repo.flags = nil
+	userRepo.user = &User{ID: 7, Role: RoleAdmin, Status: StatusActive, Concurrency: 5}
+	_, err = svc.DetectOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Len(t, repo.flags, 1)
+	require.Nil(t, repo.flags[0].ActionState)
+	require.Equal(t, 5, userRepo.user.Concurrency)
+}
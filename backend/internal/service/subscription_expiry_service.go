@@ -5,23 +5,47 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	subscriptionRenewLeaderLockKey = "subscription:renew:leader"
+	subscriptionRenewLeaderLockTTL = 2 * time.Minute
 )
 
-// SubscriptionExpiryService periodically updates expired subscription status.
+var subscriptionRenewReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// SubscriptionExpiryService periodically renews auto-renew subscriptions and updates expired subscription status.
 type SubscriptionExpiryService struct {
-	userSubRepo UserSubscriptionRepository
-	interval    time.Duration
-	stopCh      chan struct{}
-	stopOnce    sync.Once
-	wg          sync.WaitGroup
+	userSubRepo         UserSubscriptionRepository
+	subscriptionService *SubscriptionService
+	redisClient         *redis.Client
+	instanceID          string
+	// acquireLeaderLock 默认使用 Redis leader 锁，测试可替换
+	acquireLeaderLock func(ctx context.Context) (func(), bool)
+	interval          time.Duration
+	stopCh            chan struct{}
+	stopOnce          sync.Once
+	wg                sync.WaitGroup
 }
 
-func NewSubscriptionExpiryService(userSubRepo UserSubscriptionRepository, interval time.Duration) *SubscriptionExpiryService {
-	return &SubscriptionExpiryService{
-		userSubRepo: userSubRepo,
-		interval:    interval,
-		stopCh:      make(chan struct{}),
+func NewSubscriptionExpiryService(userSubRepo UserSubscriptionRepository, subscriptionService *SubscriptionService, redisClient *redis.Client, interval time.Duration) *SubscriptionExpiryService {
+	s := &SubscriptionExpiryService{
+		userSubRepo:         userSubRepo,
+		subscriptionService: subscriptionService,
+		redisClient:         redisClient,
+		instanceID:          processInstanceID,
+		interval:            interval,
+		stopCh:              make(chan struct{}),
 	}
+	s.acquireLeaderLock = s.tryAcquireRenewLock
+	return s
 }
 
 func (s *SubscriptionExpiryService) Start() {
@@ -57,9 +81,23 @@ func (s *SubscriptionExpiryService) Stop() {
 }
 
 func (s *SubscriptionExpiryService) runOnce() {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// 续期涉及扣费，多实例部署时仅由持有 leader 锁的实例执行；
+	// 批量过期也在同一把锁内、续期处理完之后执行，否则其他实例会把尚未续期的自动续期订阅提前标记为 expired。
+	release, ok := s.acquireLeaderLock(ctx)
+	if !ok {
+		return
+	}
+	if release != nil {
+		defer release()
+	}
+
+	if !s.renewDueSubscriptions(ctx) {
+		return
+	}
+
 	updated, err := s.userSubRepo.BatchUpdateExpiredStatus(ctx)
 	if err != nil {
 		log.Printf("[SubscriptionExpiry] Update expired subscriptions failed: %v", err)
@@ -69,3 +107,37 @@ func (s *SubscriptionExpiryService) runOnce() {
 		log.Printf("[SubscriptionExpiry] Updated %d expired subscriptions", updated)
 	}
 }
+
+// renewDueSubscriptions 处理所有到期的自动续期订阅；返回 false 表示未能处理完，本轮跳过批量过期
+func (s *SubscriptionExpiryService) renewDueSubscriptions(ctx context.Context) bool {
+	if s.subscriptionService == nil {
+		return true
+	}
+	renewed, failed, err := s.subscriptionService.RenewDueSubscriptions(ctx)
+	if renewed > 0 || failed > 0 {
+		log.Printf("[SubscriptionExpiry] Renewed %d subscriptions, %d renewals failed", renewed, failed)
+	}
+	if err != nil {
+		log.Printf("[SubscriptionExpiry] Renew subscriptions failed; skipping expiry this cycle: %v", err)
+		return false
+	}
+	return true
+}
+
+// tryAcquireRenewLock 未配置 Redis 时视为单实例直接执行；Redis 异常时跳过本轮，避免重复扣费
+func (s *SubscriptionExpiryService) tryAcquireRenewLock(ctx context.Context) (func(), bool) {
+	if s.redisClient == nil {
+		return nil, true
+	}
+	ok, err := s.redisClient.SetNX(ctx, subscriptionRenewLeaderLockKey, s.instanceID, subscriptionRenewLeaderLockTTL).Result()
+	if err != nil {
+		log.Printf("[SubscriptionExpiry] renew leader lock SetNX failed; skipping this cycle: %v", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return func() {
+		_, _ = subscriptionRenewReleaseScript.Run(context.Background(), s.redisClient, []string{subscriptionRenewLeaderLockKey}, s.instanceID).Result()
+	}, true
+}
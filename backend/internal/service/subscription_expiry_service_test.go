@@ -0,0 +1,49 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type expiryUserSubRepoStub struct {
+	UserSubscriptionRepository
+	subs         []UserSubscription
+	expireCalled int
+}
+
+func (r *expiryUserSubRepoStub) BatchUpdateExpiredStatus(ctx context.Context) (int64, error) {
+	r.expireCalled++
+	var n int64
+	now := time.Now()
+	for i := range r.subs {
+		if r.subs[i].Status == SubscriptionStatusActive && !r.subs[i].ExpiresAt.After(now) {
+			r.subs[i].Status = SubscriptionStatusExpired
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestSubscriptionExpiryService_NonLeaderLeavesDueAutoRenewActive(t *testing.T) {
+	repo := &expiryUserSubRepoStub{subs: []UserSubscription{
+		{ID: 1, Status: SubscriptionStatusActive, AutoRenew: true, ExpiresAt: time.Now().Add(-time.Minute)},
+	}}
+	svc := NewSubscriptionExpiryService(repo, nil, nil, time.Minute)
+
+	// 其他实例持有 leader 锁：本实例既不续期也不批量过期，到期的自动续期订阅保持 active 等待 leader 续期
+	svc.acquireLeaderLock = func(ctx context.Context) (func(), bool) { return nil, false }
+	svc.runOnce()
+	require.Zero(t, repo.expireCalled)
+	require.Equal(t, SubscriptionStatusActive, repo.subs[0].Status)
+
+	released := false
+	svc.acquireLeaderLock = func(ctx context.Context) (func(), bool) { return func() { released = true }, true }
+	svc.runOnce()
+	require.Equal(t, 1, repo.expireCalled)
+	require.True(t, released)
+}
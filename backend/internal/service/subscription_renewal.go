@@ -0,0 +1,527 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 订阅历史动作
+const (
+	SubscriptionHistoryRenew       = "renew"
+	SubscriptionHistoryRenewFailed = "renew_failed"
+	SubscriptionHistoryUpgrade     = "upgrade"
+	SubscriptionHistoryDowngrade   = "downgrade"
+	SubscriptionHistoryPause       = "pause"
+	SubscriptionHistoryResume      = "resume"
+)
+
+// subscriptionRenewBatchSize 单次续期扫描处理的最大订阅数
+const subscriptionRenewBatchSize = 200
+
+var (
+	ErrSubscriptionPaused           = infraerrors.Forbidden("SUBSCRIPTION_PAUSED", "subscription is paused")
+	ErrSubscriptionNotPausable      = infraerrors.BadRequest("SUBSCRIPTION_NOT_PAUSABLE", "only active, unexpired subscriptions can be paused")
+	ErrSubscriptionNotPaused        = infraerrors.BadRequest("SUBSCRIPTION_NOT_PAUSED", "subscription is not paused")
+	ErrSubscriptionPriceNotSet      = infraerrors.BadRequest("SUBSCRIPTION_PRICE_NOT_SET", "group has no subscription price configured")
+	ErrSubscriptionPlanChangeTarget = infraerrors.BadRequest("SUBSCRIPTION_PLAN_CHANGE_INVALID", "invalid target group for plan change")
+	ErrSubscriptionBillingDisabled  = infraerrors.ServiceUnavailable("SUBSCRIPTION_BILLING_UNAVAILABLE", "subscription billing is not available")
+	ErrSubscriptionChanged          = infraerrors.Conflict("SUBSCRIPTION_CHANGED", "subscription was modified concurrently, please retry")
+)
+
+// SubscriptionHistoryEntry 订阅历史记录（续期、升降级、暂停/恢复）
+type SubscriptionHistoryEntry struct {
+	ID                int64
+	SubscriptionID    int64
+	UserID            int64
+	Action            string
+	FromGroupID       *int64
+	ToGroupID         *int64
+	Amount            float64 // 实际从余额扣除的金额（负数表示退回余额）
+	Credit            float64 // 变更时原订阅折算的抵扣金额
+	PreviousExpiresAt *time.Time
+	NewExpiresAt      *time.Time
+	Note              string
+	CreatedAt         time.Time
+}
+
+// SubscriptionHistoryRepository 订阅历史存储
+type SubscriptionHistoryRepository interface {
+	Create(ctx context.Context, entry *SubscriptionHistoryEntry) error
+	ListBySubscriptionID(ctx context.Context, subscriptionID int64, params pagination.PaginationParams) ([]SubscriptionHistoryEntry, *pagination.PaginationResult, error)
+}
+
+// SubscriptionPlanChangeQuote 订阅变更报价
+type SubscriptionPlanChangeQuote struct {
+	SubscriptionID int64     `json:"subscription_id"`
+	FromGroupID    int64     `json:"from_group_id"`
+	ToGroupID      int64     `json:"to_group_id"`
+	Action         string    `json:"action"`           // upgrade / downgrade
+	RemainingRatio float64   `json:"remaining_ratio"`  // 剩余时长占原周期的比例
+	UsedQuotaRatio float64   `json:"used_quota_ratio"` // 当前窗口已用额度比例（取各窗口最大值）
+	Credit         float64   `json:"credit"`           // 原订阅剩余价值
+	Price          float64   `json:"price"`            // 目标分组一个周期的价格
+	AmountDue      float64   `json:"amount_due"`       // 应从余额扣除的金额（负数表示退回余额）
+	NewExpiresAt   time.Time `json:"new_expires_at"`
+}
+
+// SetBillingDependencies 注入续期/变更所需的依赖（余额扣费、历史记录、事务与缓存失效）
+func (s *SubscriptionService) SetBillingDependencies(entClient *dbent.Client, userRepo UserRepository, historyRepo SubscriptionHistoryRepository, authCacheInvalidator APIKeyAuthCacheInvalidator) {
+	s.entClient = entClient
+	s.userRepo = userRepo
+	s.historyRepo = historyRepo
+	s.authCacheInvalidator = authCacheInvalidator
+}
+
+func (s *SubscriptionService) billingEnabled() bool {
+	return s.entClient != nil && s.userRepo != nil && s.historyRepo != nil
+}
+
+// getOwnedSubscription 获取订阅；userID > 0 时校验归属（不属于该用户视为不存在）
+func (s *SubscriptionService) getOwnedSubscription(ctx context.Context, subscriptionID, userID int64) (*UserSubscription, error) {
+	sub, err := s.userSubRepo.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	if userID > 0 && sub.UserID != userID {
+		return nil, ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+// subscriptionPeriodDays 分组订阅周期（天）
+func subscriptionPeriodDays(group *Group) int {
+	if group == nil || group.DefaultValidityDays <= 0 {
+		return 30
+	}
+	return group.DefaultValidityDays
+}
+
+// roundSubscriptionAmount 金额保留 8 位小数，与数据库 decimal(20,8) 一致
+func roundSubscriptionAmount(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
+
+// subscriptionUsedQuotaRatio 当前各用量窗口已用比例的最大值（已过期窗口视为未使用）
+func subscriptionUsedQuotaRatio(sub *UserSubscription, group *Group) float64 {
+	ratio := 0.0
+	check := func(limit *float64, used float64, expired bool) {
+		if limit == nil || *limit <= 0 || expired {
+			return
+		}
+		ratio = math.Max(ratio, used / *limit)
+	}
+	check(group.DailyLimitUSD, sub.DailyUsageUSD, sub.NeedsDailyReset())
+	check(group.WeeklyLimitUSD, sub.WeeklyUsageUSD, sub.NeedsWeeklyReset())
+	check(group.MonthlyLimitUSD, sub.MonthlyUsageUSD, sub.NeedsMonthlyReset())
+	return clampRatio(ratio)
+}
+
+// computePlanChangeQuote 计算订阅变更报价：
+// 抵扣 = 原分组价格 × 剩余时长比例 × (1 - 已用额度比例)，应付 = 目标价格 - 抵扣。
+func computePlanChangeQuote(sub *UserSubscription, from, to *Group, now time.Time) (*SubscriptionPlanChangeQuote, error) {
+	if to.SubscriptionPrice == nil {
+		return nil, ErrSubscriptionPriceNotSet
+	}
+	fromPeriod := subscriptionPeriodDays(from)
+	toPeriod := subscriptionPeriodDays(to)
+
+	quote := &SubscriptionPlanChangeQuote{
+		SubscriptionID: sub.ID,
+		FromGroupID:    from.ID,
+		ToGroupID:      to.ID,
+		Price:          *to.SubscriptionPrice,
+		UsedQuotaRatio: subscriptionUsedQuotaRatio(sub, from),
+	}
+	remaining := sub.ExpiresAt.Sub(now)
+	quote.RemainingRatio = clampRatio(remaining.Hours() / (24 * float64(fromPeriod)))
+
+	fromPrice := 0.0
+	if from.SubscriptionPrice != nil {
+		fromPrice = *from.SubscriptionPrice
+	}
+	quote.Credit = roundSubscriptionAmount(fromPrice * quote.RemainingRatio * (1 - quote.UsedQuotaRatio))
+	quote.AmountDue = roundSubscriptionAmount(quote.Price - quote.Credit)
+
+	// 按日均价格区分升级与降级
+	if quote.Price/float64(toPeriod) >= fromPrice/float64(fromPeriod) {
+		quote.Action = SubscriptionHistoryUpgrade
+	} else {
+		quote.Action = SubscriptionHistoryDowngrade
+	}
+
+	quote.NewExpiresAt = now.AddDate(0, 0, toPeriod)
+	if quote.NewExpiresAt.After(MaxExpiresAt) {
+		quote.NewExpiresAt = MaxExpiresAt
+	}
+	return quote, nil
+}
+
+// SetAutoRenew 开启或关闭自动续期（开启时要求分组已配置续期价格）
+func (s *SubscriptionService) SetAutoRenew(ctx context.Context, subscriptionID, userID int64, enabled bool) (*UserSubscription, error) {
+	sub, err := s.getOwnedSubscription(ctx, subscriptionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		group, err := s.subscriptionGroup(ctx, sub)
+		if err != nil {
+			return nil, err
+		}
+		if group.SubscriptionPrice == nil {
+			return nil, ErrSubscriptionPriceNotSet
+		}
+	}
+	if err := s.userSubRepo.SetAutoRenew(ctx, sub.ID, enabled); err != nil {
+		return nil, err
+	}
+	return s.userSubRepo.GetByID(ctx, sub.ID)
+}
+
+func (s *SubscriptionService) subscriptionGroup(ctx context.Context, sub *UserSubscription) (*Group, error) {
+	if sub.Group != nil {
+		return sub.Group, nil
+	}
+	group, err := s.groupRepo.GetByID(ctx, sub.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("get group: %w", err)
+	}
+	return group, nil
+}
+
+// RenewDueSubscriptions 分批处理所有已到期且开启自动续期的订阅，直到没有待续期订阅：
+// 成功则扣费顺延；失败（未配置价格、余额不足等）记录历史并立即标记为 expired，使其不再被重复扫描。
+// 返回 nil 错误表示到期的自动续期订阅已全部处理，调用方随后可安全执行批量过期。
+func (s *SubscriptionService) RenewDueSubscriptions(ctx context.Context) (renewed, failed int, err error) {
+	if !s.billingEnabled() {
+		return 0, 0, nil
+	}
+	for {
+		subs, err := s.userSubRepo.ListAutoRenewDue(ctx, time.Now(), subscriptionRenewBatchSize)
+		if err != nil {
+			return renewed, failed, err
+		}
+		progressed := 0
+		for i := range subs {
+			sub := &subs[i]
+			if err := s.renewSubscription(ctx, sub); err != nil {
+				failed++
+				log.Printf("[Subscription] auto-renew failed: sub_id=%d user_id=%d err=%v", sub.ID, sub.UserID, err)
+				if err := s.userSubRepo.MarkExpired(ctx, sub.ID, sub.ExpiresAt); err != nil && !errors.Is(err, ErrSubscriptionChanged) {
+					log.Printf("[Subscription] mark expired after renew failure failed: sub_id=%d err=%v", sub.ID, err)
+					continue
+				}
+				progressed++
+				continue
+			}
+			renewed++
+			progressed++
+		}
+		if len(subs) < subscriptionRenewBatchSize {
+			return renewed, failed, nil
+		}
+		// 整批都无法处理（如数据库异常）时中止，避免死循环；未处理的订阅留到下一轮
+		if progressed == 0 {
+			return renewed, failed, errors.New("auto-renew made no progress")
+		}
+	}
+}
+
+func (s *SubscriptionService) renewSubscription(ctx context.Context, sub *UserSubscription) error {
+	group, err := s.subscriptionGroup(ctx, sub)
+	if err != nil {
+		return err
+	}
+
+	var reason error
+	switch {
+	case !group.IsSubscriptionType() || group.Status != StatusActive:
+		reason = ErrGroupNotSubscriptionType
+	case group.SubscriptionPrice == nil:
+		reason = ErrSubscriptionPriceNotSet
+	}
+	if reason != nil {
+		s.recordRenewFailure(ctx, sub, reason)
+		return reason
+	}
+	price := *group.SubscriptionPrice
+
+	// 按原到期时间顺延，保持周期对齐；长时间未处理导致仍已过期时从当前时间起算
+	newExpiresAt := sub.ExpiresAt.AddDate(0, 0, subscriptionPeriodDays(group))
+	if now := time.Now(); !newExpiresAt.After(now) {
+		newExpiresAt = now.AddDate(0, 0, subscriptionPeriodDays(group))
+	}
+	if newExpiresAt.After(MaxExpiresAt) {
+		newExpiresAt = MaxExpiresAt
+	}
+
+	// 先按原到期时间条件更新订阅，再在同一事务内按余额条件扣费：
+	// 并发续期只有一方能成功顺延，余额不足时整体回滚。
+	err = s.withTx(ctx, func(txCtx context.Context) error {
+		if err := s.userSubRepo.RenewExpiry(txCtx, sub.ID, sub.ExpiresAt, newExpiresAt); err != nil {
+			return fmt.Errorf("extend subscription: %w", err)
+		}
+		if price > 0 {
+			if err := s.userRepo.DeductBalanceIfSufficient(txCtx, sub.UserID, price); err != nil {
+				return fmt.Errorf("deduct balance: %w", err)
+			}
+		}
+		return s.historyRepo.Create(txCtx, &SubscriptionHistoryEntry{
+			SubscriptionID:    sub.ID,
+			UserID:            sub.UserID,
+			Action:            SubscriptionHistoryRenew,
+			FromGroupID:       &sub.GroupID,
+			ToGroupID:         &sub.GroupID,
+			Amount:            price,
+			PreviousExpiresAt: &sub.ExpiresAt,
+			NewExpiresAt:      &newExpiresAt,
+		})
+	})
+	if errors.Is(err, ErrInsufficientBalance) {
+		s.recordRenewFailure(ctx, sub, ErrInsufficientBalance)
+	}
+	if err != nil {
+		return err
+	}
+	s.invalidateSubscriptionBilling(sub.UserID, price != 0, sub.GroupID)
+	return nil
+}
+
+func (s *SubscriptionService) recordRenewFailure(ctx context.Context, sub *UserSubscription, reason error) {
+	s.recordHistory(ctx, &SubscriptionHistoryEntry{
+		SubscriptionID:    sub.ID,
+		UserID:            sub.UserID,
+		Action:            SubscriptionHistoryRenewFailed,
+		FromGroupID:       &sub.GroupID,
+		PreviousExpiresAt: &sub.ExpiresAt,
+		Note:              infraerrors.Message(reason),
+	})
+}
+
+// QuotePlanChange 计算切换到目标分组的报价（不产生任何变更）
+func (s *SubscriptionService) QuotePlanChange(ctx context.Context, subscriptionID, userID, targetGroupID int64) (*SubscriptionPlanChangeQuote, error) {
+	sub, from, to, err := s.preparePlanChange(ctx, subscriptionID, userID, targetGroupID)
+	if err != nil {
+		return nil, err
+	}
+	return computePlanChangeQuote(sub, from, to, time.Now())
+}
+
+// ChangePlan 将订阅升级/降级到目标分组：按报价从余额扣费（或退回差额），开始新周期并重置用量。
+func (s *SubscriptionService) ChangePlan(ctx context.Context, subscriptionID, userID, targetGroupID int64) (*UserSubscription, *SubscriptionPlanChangeQuote, error) {
+	if !s.billingEnabled() {
+		return nil, nil, ErrSubscriptionBillingDisabled
+	}
+	sub, from, to, err := s.preparePlanChange(ctx, subscriptionID, userID, targetGroupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	quote, err := computePlanChangeQuote(sub, from, to, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 订阅按原分组条件迁移、余额按充足条件扣除，均在同一事务内完成，避免并发变更重复扣费或透支
+	err = s.withTx(ctx, func(txCtx context.Context) error {
+		if err := s.userSubRepo.ChangeGroup(txCtx, sub.ID, from.ID, to.ID, now, quote.NewExpiresAt); err != nil {
+			return err
+		}
+		if quote.AmountDue > 0 {
+			if err := s.userRepo.DeductBalanceIfSufficient(txCtx, sub.UserID, quote.AmountDue); err != nil {
+				if errors.Is(err, ErrInsufficientBalance) {
+					return err
+				}
+				return fmt.Errorf("deduct balance: %w", err)
+			}
+		} else if quote.AmountDue < 0 {
+			if err := s.userRepo.UpdateBalance(txCtx, sub.UserID, -quote.AmountDue); err != nil {
+				return fmt.Errorf("refund balance: %w", err)
+			}
+		}
+		return s.historyRepo.Create(txCtx, &SubscriptionHistoryEntry{
+			SubscriptionID:    sub.ID,
+			UserID:            sub.UserID,
+			Action:            quote.Action,
+			FromGroupID:       &from.ID,
+			ToGroupID:         &to.ID,
+			Amount:            quote.AmountDue,
+			Credit:            quote.Credit,
+			PreviousExpiresAt: &sub.ExpiresAt,
+			NewExpiresAt:      &quote.NewExpiresAt,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	s.invalidateSubscriptionBilling(sub.UserID, quote.AmountDue != 0, from.ID, to.ID)
+
+	updated, err := s.userSubRepo.GetByID(ctx, sub.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, quote, nil
+}
+
+func (s *SubscriptionService) preparePlanChange(ctx context.Context, subscriptionID, userID, targetGroupID int64) (*UserSubscription, *Group, *Group, error) {
+	sub, err := s.getOwnedSubscription(ctx, subscriptionID, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if sub.IsPaused() {
+		return nil, nil, nil, ErrSubscriptionPaused
+	}
+	if sub.Status != SubscriptionStatusActive || sub.IsExpired() {
+		return nil, nil, nil, ErrSubscriptionExpired
+	}
+	if targetGroupID == sub.GroupID {
+		return nil, nil, nil, infraerrors.BadRequest(ErrSubscriptionPlanChangeTarget.Reason, "target group is the current group")
+	}
+	from, err := s.subscriptionGroup(ctx, sub)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	to, err := s.groupRepo.GetByID(ctx, targetGroupID)
+	if err != nil {
+		return nil, nil, nil, ErrSubscriptionPlanChangeTarget
+	}
+	if !to.IsSubscriptionType() {
+		return nil, nil, nil, ErrGroupNotSubscriptionType
+	}
+	if to.Status != StatusActive || to.Platform != from.Platform {
+		return nil, nil, nil, infraerrors.BadRequest(ErrSubscriptionPlanChangeTarget.Reason, "target group must be active and on the same platform")
+	}
+	exists, err := s.userSubRepo.ExistsByUserIDAndGroupID(ctx, sub.UserID, to.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if exists {
+		return nil, nil, nil, ErrSubscriptionAlreadyExists
+	}
+	return sub, from, to, nil
+}
+
+// PauseSubscription 暂停订阅：暂停期间不可使用，恢复时顺延暂停时长
+func (s *SubscriptionService) PauseSubscription(ctx context.Context, subscriptionID, userID int64) (*UserSubscription, error) {
+	sub, err := s.getOwnedSubscription(ctx, subscriptionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.userSubRepo.Pause(ctx, sub.ID, now); err != nil {
+		return nil, err
+	}
+	s.recordHistory(ctx, &SubscriptionHistoryEntry{
+		SubscriptionID:    sub.ID,
+		UserID:            sub.UserID,
+		Action:            SubscriptionHistoryPause,
+		FromGroupID:       &sub.GroupID,
+		PreviousExpiresAt: &sub.ExpiresAt,
+	})
+	s.invalidateSubscriptionBilling(sub.UserID, false, sub.GroupID)
+	return s.userSubRepo.GetByID(ctx, sub.ID)
+}
+
+// ResumeSubscription 恢复已暂停的订阅，到期时间顺延暂停时长
+func (s *SubscriptionService) ResumeSubscription(ctx context.Context, subscriptionID, userID int64) (*UserSubscription, error) {
+	sub, err := s.getOwnedSubscription(ctx, subscriptionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.IsPaused() || sub.PausedAt == nil {
+		return nil, ErrSubscriptionNotPaused
+	}
+	newExpiresAt := resumedExpiresAt(sub, time.Now())
+	if err := s.userSubRepo.Resume(ctx, sub.ID, newExpiresAt); err != nil {
+		return nil, err
+	}
+	s.recordHistory(ctx, &SubscriptionHistoryEntry{
+		SubscriptionID:    sub.ID,
+		UserID:            sub.UserID,
+		Action:            SubscriptionHistoryResume,
+		FromGroupID:       &sub.GroupID,
+		PreviousExpiresAt: &sub.ExpiresAt,
+		NewExpiresAt:      &newExpiresAt,
+	})
+	s.invalidateSubscriptionBilling(sub.UserID, false, sub.GroupID)
+	return s.userSubRepo.GetByID(ctx, sub.ID)
+}
+
+// resumedExpiresAt 恢复后的到期时间：原到期时间 + 暂停时长
+func resumedExpiresAt(sub *UserSubscription, now time.Time) time.Time {
+	expiresAt := sub.ExpiresAt
+	if sub.PausedAt != nil && now.After(*sub.PausedAt) {
+		expiresAt = expiresAt.Add(now.Sub(*sub.PausedAt))
+	}
+	if expiresAt.After(MaxExpiresAt) {
+		expiresAt = MaxExpiresAt
+	}
+	return expiresAt
+}
+
+// ListSubscriptionHistory 获取订阅历史（userID > 0 时校验归属）
+func (s *SubscriptionService) ListSubscriptionHistory(ctx context.Context, subscriptionID, userID int64, page, pageSize int) ([]SubscriptionHistoryEntry, *pagination.PaginationResult, error) {
+	if s.historyRepo == nil {
+		return nil, nil, ErrSubscriptionBillingDisabled
+	}
+	if _, err := s.getOwnedSubscription(ctx, subscriptionID, userID); err != nil {
+		return nil, nil, err
+	}
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	return s.historyRepo.ListBySubscriptionID(ctx, subscriptionID, params)
+}
+
+func (s *SubscriptionService) recordHistory(ctx context.Context, entry *SubscriptionHistoryEntry) {
+	if s.historyRepo == nil {
+		return
+	}
+	if err := s.historyRepo.Create(ctx, entry); err != nil {
+		log.Printf("[Subscription] record history failed: sub_id=%d action=%s err=%v", entry.SubscriptionID, entry.Action, err)
+	}
+}
+
+func (s *SubscriptionService) withTx(ctx context.Context, fn func(txCtx context.Context) error) error {
+	// 已处于外部事务中时直接复用
+	if dbent.TxFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	tx, err := s.entClient.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := fn(dbent.NewTxContext(ctx, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// invalidateSubscriptionBilling 失效订阅（及余额）相关缓存
+func (s *SubscriptionService) invalidateSubscriptionBilling(userID int64, balanceChanged bool, groupIDs ...int64) {
+	if balanceChanged && s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(context.Background(), userID)
+	}
+	if s.billingCacheService == nil {
+		return
+	}
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if balanceChanged {
+			_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+		}
+		for _, groupID := range groupIDs {
+			_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, groupID)
+		}
+	}()
+}
@@ -0,0 +1,97 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func subscriptionPrice(v float64) *float64 { return &v }
+
+func TestComputePlanChangeQuote_Upgrade(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	windowStart := time.Now().Add(-time.Hour)
+	from := &Group{ID: 1, DefaultValidityDays: 30, SubscriptionPrice: subscriptionPrice(30), MonthlyLimitUSD: subscriptionPrice(100)}
+	to := &Group{ID: 2, DefaultValidityDays: 30, SubscriptionPrice: subscriptionPrice(60)}
+	sub := &UserSubscription{
+		ID:                 7,
+		GroupID:            1,
+		ExpiresAt:          now.AddDate(0, 0, 15),
+		MonthlyWindowStart: &windowStart,
+		MonthlyUsageUSD:    20,
+	}
+
+	quote, err := computePlanChangeQuote(sub, from, to, now)
+	require.NoError(t, err)
+	require.Equal(t, SubscriptionHistoryUpgrade, quote.Action)
+	require.InDelta(t, 0.5, quote.RemainingRatio, 1e-9)
+	require.InDelta(t, 0.2, quote.UsedQuotaRatio, 1e-9)
+	// 30 × 0.5 × (1 - 0.2) = 12
+	require.InDelta(t, 12, quote.Credit, 1e-9)
+	require.InDelta(t, 48, quote.AmountDue, 1e-9)
+	require.Equal(t, now.AddDate(0, 0, 30), quote.NewExpiresAt)
+}
+
+func TestComputePlanChangeQuote_DowngradeRefund(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	from := &Group{ID: 1, DefaultValidityDays: 30, SubscriptionPrice: subscriptionPrice(90)}
+	to := &Group{ID: 2, DefaultValidityDays: 30, SubscriptionPrice: subscriptionPrice(10)}
+	sub := &UserSubscription{ID: 7, GroupID: 1, ExpiresAt: now.AddDate(0, 0, 60)}
+
+	quote, err := computePlanChangeQuote(sub, from, to, now)
+	require.NoError(t, err)
+	require.Equal(t, SubscriptionHistoryDowngrade, quote.Action)
+	// 剩余时长超过一个周期时按整周期折算
+	require.InDelta(t, 1, quote.RemainingRatio, 1e-9)
+	require.InDelta(t, 90, quote.Credit, 1e-9)
+	require.InDelta(t, -80, quote.AmountDue, 1e-9)
+
+	_, err = computePlanChangeQuote(sub, from, &Group{ID: 3}, now)
+	require.ErrorIs(t, err, ErrSubscriptionPriceNotSet)
+}
+
+func TestSubscriptionUsedQuotaRatio(t *testing.T) {
+	recent := time.Now().Add(-time.Hour)
+	stale := time.Now().Add(-48 * time.Hour)
+	group := &Group{DailyLimitUSD: subscriptionPrice(10), WeeklyLimitUSD: subscriptionPrice(50)}
+
+	sub := &UserSubscription{DailyWindowStart: &recent, DailyUsageUSD: 5, WeeklyWindowStart: &recent, WeeklyUsageUSD: 10}
+	require.InDelta(t, 0.5, subscriptionUsedQuotaRatio(sub, group), 1e-9)
+
+	// 已过期的日窗口不计入
+	sub.DailyWindowStart = &stale
+	require.InDelta(t, 0.2, subscriptionUsedQuotaRatio(sub, group), 1e-9)
+
+	sub.WeeklyUsageUSD = 80
+	require.InDelta(t, 1, subscriptionUsedQuotaRatio(sub, group), 1e-9)
+	require.Zero(t, subscriptionUsedQuotaRatio(sub, &Group{}))
+}
+
+func TestResumedExpiresAt(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	pausedAt := now.AddDate(0, 0, -4)
+	sub := &UserSubscription{Status: SubscriptionStatusPaused, ExpiresAt: now.AddDate(0, 0, 1), PausedAt: &pausedAt}
+	require.Equal(t, now.AddDate(0, 0, 5), resumedExpiresAt(sub, now))
+
+	sub.ExpiresAt = MaxExpiresAt
+	require.Equal(t, MaxExpiresAt, resumedExpiresAt(sub, now))
+}
+
+func TestValidateSubscription_Paused(t *testing.T) {
+	svc := NewSubscriptionService(nil, nil, nil)
+	err := svc.ValidateSubscription(context.Background(), &UserSubscription{
+		Status:    SubscriptionStatusPaused,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrSubscriptionPaused)
+
+	// 未注入计费依赖时不执行自动续期
+	renewed, failed, err := svc.RenewDueSubscriptions(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, renewed)
+	require.Zero(t, failed)
+}
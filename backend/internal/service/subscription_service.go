@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	dbent "github.com/Wei-Shaw/sub2api/ent"
 	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
 )
@@ -35,6 +36,12 @@ type SubscriptionService struct {
 	groupRepo           GroupRepository
 	userSubRepo         UserSubscriptionRepository
 	billingCacheService *BillingCacheService
+
+	// 续期与变更依赖（可选，见 SetBillingDependencies）
+	entClient            *dbent.Client
+	userRepo             UserRepository
+	historyRepo          SubscriptionHistoryRepository
+	authCacheInvalidator APIKeyAuthCacheInvalidator
 }
 
 // NewSubscriptionService 创建订阅服务
@@ -498,6 +505,7 @@ func (s *SubscriptionService) CheckAndResetWindows(ctx context.Context, sub *Use
 		}
 		sub.DailyWindowStart = &windowStart
 		sub.DailyUsageUSD = 0
+		sub.DailyUsageCounters = nil
 		needsInvalidateCache = true
 	}
 
@@ -508,6 +516,7 @@ func (s *SubscriptionService) CheckAndResetWindows(ctx context.Context, sub *Use
 		}
 		sub.WeeklyWindowStart = &windowStart
 		sub.WeeklyUsageUSD = 0
+		sub.WeeklyUsageCounters = nil
 		needsInvalidateCache = true
 	}
 
@@ -518,6 +527,7 @@ func (s *SubscriptionService) CheckAndResetWindows(ctx context.Context, sub *Use
 		}
 		sub.MonthlyWindowStart = &windowStart
 		sub.MonthlyUsageUSD = 0
+		sub.MonthlyUsageCounters = nil
 		needsInvalidateCache = true
 	}
 
@@ -558,6 +568,8 @@ type SubscriptionProgress struct {
 	Daily         *UsageWindowProgress `json:"daily,omitempty"`
 	Weekly        *UsageWindowProgress `json:"weekly,omitempty"`
 	Monthly       *UsageWindowProgress `json:"monthly,omitempty"`
+	// token/请求数限额进度
+	UsageLimits []UsageLimitProgress `json:"usage_limits,omitempty"`
 }
 
 // UsageWindowProgress 使用窗口进度
@@ -665,6 +677,8 @@ func (s *SubscriptionService) GetSubscriptionProgress(ctx context.Context, subsc
 		}
 	}
 
+	progress.UsageLimits = BuildUsageLimitProgress(group, sub)
+
 	return progress, nil
 }
 
@@ -695,6 +709,9 @@ func (s *SubscriptionService) ValidateSubscription(ctx context.Context, sub *Use
 	if sub.Status == SubscriptionStatusSuspended {
 		return ErrSubscriptionSuspended
 	}
+	if sub.Status == SubscriptionStatusPaused {
+		return ErrSubscriptionPaused
+	}
 	if sub.IsExpired() {
 		// 更新状态
 		_ = s.userSubRepo.UpdateStatus(ctx, sub.ID, SubscriptionStatusExpired)
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// 订阅用量限额的统计窗口（与 USD 限额的日/周/月窗口一致）
+const (
+	UsageLimitWindowDaily   = "daily"
+	UsageLimitWindowWeekly  = "weekly"
+	UsageLimitWindowMonthly = "monthly"
+)
+
+// 订阅用量限额的计量指标
+const (
+	UsageLimitMetricInputTokens    = "input_tokens"    // 输入 tokens（含缓存创建/读取）
+	UsageLimitMetricOutputTokens   = "output_tokens"   // 输出 tokens
+	UsageLimitMetricWeightedTokens = "weighted_tokens" // 按权重加总的 tokens
+	UsageLimitMetricRequests       = "requests"        // 请求次数
+)
+
+// 限额名称作为计数器键和 Redis 字段名的一部分，限制长度避免键过长
+const usageLimitNameMaxLen = 64
+
+var (
+	ErrSubscriptionUsageLimitInvalid = infraerrors.BadRequest("SUBSCRIPTION_USAGE_LIMIT_INVALID", "invalid subscription usage limit")
+	ErrUsageLimitExceeded            = infraerrors.TooManyRequests("USAGE_LIMIT_EXCEEDED", "subscription usage limit exceeded")
+)
+
+// SubscriptionUsageLimit 订阅分组的 token/请求数限额。
+// 每条限额独立计数：请求模型命中 Models（为空表示全部模型）时，按 Metric 累加到 Window 窗口的计数器。
+type SubscriptionUsageLimit struct {
+	// Name 限额名称（分组内唯一），作为计数器键
+	Name   string   `json:"name"`
+	Window string   `json:"window"`
+	Metric string   `json:"metric"`
+	Limit  float64  `json:"limit"`
+	Models []string `json:"models,omitempty"`
+
+	// weighted_tokens 的各类 token 权重，未设置视为 0
+	InputWeight         float64 `json:"input_weight,omitempty"`
+	OutputWeight        float64 `json:"output_weight,omitempty"`
+	CacheCreationWeight float64 `json:"cache_creation_weight,omitempty"`
+	CacheReadWeight     float64 `json:"cache_read_weight,omitempty"`
+}
+
+func (l *SubscriptionUsageLimit) appliesTo(model string) bool {
+	if len(l.Models) == 0 {
+		return true
+	}
+	model = strings.TrimPrefix(model, geminiModelNamePrefix)
+	for _, pattern := range l.Models {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// amount 计算一次请求在该限额下的计数增量
+func (l *SubscriptionUsageLimit) amount(tokens UsageTokens) float64 {
+	switch l.Metric {
+	case UsageLimitMetricInputTokens:
+		return float64(tokens.InputTokens + tokens.CacheCreationTokens + tokens.CacheReadTokens)
+	case UsageLimitMetricOutputTokens:
+		return float64(tokens.OutputTokens)
+	case UsageLimitMetricWeightedTokens:
+		return float64(tokens.InputTokens)*l.InputWeight +
+			float64(tokens.OutputTokens)*l.OutputWeight +
+			float64(tokens.CacheCreationTokens)*l.CacheCreationWeight +
+			float64(tokens.CacheReadTokens)*l.CacheReadWeight
+	case UsageLimitMetricRequests:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ValidateSubscriptionUsageLimits 校验限额列表。
+// 限额计数挂在用户订阅上，余额模式分组没有订阅可计数，配置了也不会生效，因此直接拒绝。
+func ValidateSubscriptionUsageLimits(subscriptionType string, limits []SubscriptionUsageLimit) error {
+	if len(limits) > 0 && subscriptionType != SubscriptionTypeSubscription {
+		return infraerrors.BadRequest(ErrSubscriptionUsageLimitInvalid.Reason, "usage limits are only supported on subscription groups")
+	}
+	names := make(map[string]struct{}, len(limits))
+	for i := range limits {
+		limit := &limits[i]
+		invalid := func(msg string) error {
+			return infraerrors.BadRequest(ErrSubscriptionUsageLimitInvalid.Reason, fmt.Sprintf("usage limit #%d (%s): %s", i+1, limit.Name, msg))
+		}
+		name := strings.TrimSpace(limit.Name)
+		if name == "" || name != limit.Name || len(name) > usageLimitNameMaxLen {
+			return invalid(fmt.Sprintf("name is required, must not have surrounding spaces and at most %d characters", usageLimitNameMaxLen))
+		}
+		if _, dup := names[name]; dup {
+			return invalid("duplicate name")
+		}
+		names[name] = struct{}{}
+
+		switch limit.Window {
+		case UsageLimitWindowDaily, UsageLimitWindowWeekly, UsageLimitWindowMonthly:
+		default:
+			return invalid("window must be daily, weekly or monthly")
+		}
+		switch limit.Metric {
+		case UsageLimitMetricInputTokens, UsageLimitMetricOutputTokens, UsageLimitMetricRequests:
+		case UsageLimitMetricWeightedTokens:
+			weights := []float64{limit.InputWeight, limit.OutputWeight, limit.CacheCreationWeight, limit.CacheReadWeight}
+			positive := false
+			for _, w := range weights {
+				if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+					return invalid("weights must be non-negative")
+				}
+				positive = positive || w > 0
+			}
+			if !positive {
+				return invalid("weighted_tokens requires at least one positive weight")
+			}
+		default:
+			return invalid("metric must be input_tokens, output_tokens, weighted_tokens or requests")
+		}
+		if !(limit.Limit > 0) || math.IsInf(limit.Limit, 0) {
+			return invalid("limit must be positive")
+		}
+		for _, pattern := range limit.Models {
+			if strings.TrimSpace(pattern) == "" {
+				return invalid("model patterns must not be empty")
+			}
+		}
+	}
+	return nil
+}
+
+// SubscriptionUsageCounters 订阅各窗口的限额计数（限额名称 -> 累计值）
+type SubscriptionUsageCounters struct {
+	Daily   map[string]float64
+	Weekly  map[string]float64
+	Monthly map[string]float64
+}
+
+// IsEmpty 没有任何计数时返回 true
+func (c SubscriptionUsageCounters) IsEmpty() bool {
+	return len(c.Daily) == 0 && len(c.Weekly) == 0 && len(c.Monthly) == 0
+}
+
+func (c SubscriptionUsageCounters) window(window string) map[string]float64 {
+	switch window {
+	case UsageLimitWindowDaily:
+		return c.Daily
+	case UsageLimitWindowWeekly:
+		return c.Weekly
+	case UsageLimitWindowMonthly:
+		return c.Monthly
+	default:
+		return nil
+	}
+}
+
+func (c *SubscriptionUsageCounters) add(window, name string, value float64) {
+	var m *map[string]float64
+	switch window {
+	case UsageLimitWindowDaily:
+		m = &c.Daily
+	case UsageLimitWindowWeekly:
+		m = &c.Weekly
+	case UsageLimitWindowMonthly:
+		m = &c.Monthly
+	default:
+		return
+	}
+	if *m == nil {
+		*m = make(map[string]float64)
+	}
+	(*m)[name] += value
+}
+
+// UsageCounters 返回订阅当前的限额计数
+func (s *UserSubscription) UsageCounters() SubscriptionUsageCounters {
+	return SubscriptionUsageCounters{
+		Daily:   s.DailyUsageCounters,
+		Weekly:  s.WeeklyUsageCounters,
+		Monthly: s.MonthlyUsageCounters,
+	}
+}
+
+// usageCounterDelta 计算一次请求对分组各限额计数器的增量
+func (g *Group) usageCounterDelta(model string, tokens UsageTokens) SubscriptionUsageCounters {
+	var delta SubscriptionUsageCounters
+	if g == nil {
+		return delta
+	}
+	for i := range g.UsageLimits {
+		limit := &g.UsageLimits[i]
+		if !limit.appliesTo(model) {
+			continue
+		}
+		if v := limit.amount(tokens); v > 0 {
+			delta.add(limit.Window, limit.Name, v)
+		}
+	}
+	return delta
+}
+
+// checkUsageLimits 检查请求模型命中的限额是否已用尽
+func (g *Group) checkUsageLimits(model string, counters SubscriptionUsageCounters) error {
+	if g == nil {
+		return nil
+	}
+	for i := range g.UsageLimits {
+		limit := &g.UsageLimits[i]
+		if !limit.appliesTo(model) {
+			continue
+		}
+		if counters.window(limit.Window)[limit.Name] >= limit.Limit {
+			return infraerrors.TooManyRequests(ErrUsageLimitExceeded.Reason,
+				fmt.Sprintf("%s %s limit %q exceeded", limit.Window, limit.Metric, limit.Name))
+		}
+	}
+	return nil
+}
+
+// UsageLimitProgress 单条 token/请求数限额的使用进度
+type UsageLimitProgress struct {
+	Name            string     `json:"name"`
+	Window          string     `json:"window"`
+	Metric          string     `json:"metric"`
+	Models          []string   `json:"models,omitempty"`
+	Limit           float64    `json:"limit"`
+	Used            float64    `json:"used"`
+	Remaining       float64    `json:"remaining"`
+	Percentage      float64    `json:"percentage"`
+	WindowStart     *time.Time `json:"window_start,omitempty"`
+	ResetsAt        *time.Time `json:"resets_at,omitempty"`
+	ResetsInSeconds int64      `json:"resets_in_seconds"`
+}
+
+// BuildUsageLimitProgress 计算分组 token/请求数限额的使用进度；窗口未激活或已过期时视为未使用。
+func BuildUsageLimitProgress(group *Group, sub *UserSubscription) []UsageLimitProgress {
+	if group == nil || sub == nil || len(group.UsageLimits) == 0 {
+		return nil
+	}
+	counters := sub.UsageCounters()
+	out := make([]UsageLimitProgress, 0, len(group.UsageLimits))
+	for i := range group.UsageLimits {
+		limit := &group.UsageLimits[i]
+		p := UsageLimitProgress{
+			Name:   limit.Name,
+			Window: limit.Window,
+			Metric: limit.Metric,
+			Models: limit.Models,
+			Limit:  limit.Limit,
+		}
+		windowStart, windowDuration := sub.usageWindow(limit.Window)
+		if windowStart != nil {
+			resetsAt := windowStart.Add(windowDuration)
+			if time.Now().Before(resetsAt) {
+				p.Used = counters.window(limit.Window)[limit.Name]
+				p.WindowStart = windowStart
+				p.ResetsAt = &resetsAt
+				p.ResetsInSeconds = int64(time.Until(resetsAt).Seconds())
+			}
+		}
+		p.Remaining = math.Max(limit.Limit-p.Used, 0)
+		p.Percentage = math.Min(p.Used/limit.Limit*100, 100)
+		out = append(out, p)
+	}
+	return out
+}
+
+func (s *UserSubscription) usageWindow(window string) (*time.Time, time.Duration) {
+	switch window {
+	case UsageLimitWindowDaily:
+		return s.DailyWindowStart, 24 * time.Hour
+	case UsageLimitWindowWeekly:
+		return s.WeeklyWindowStart, 7 * 24 * time.Hour
+	case UsageLimitWindowMonthly:
+		return s.MonthlyWindowStart, 30 * 24 * time.Hour
+	default:
+		return nil, 0
+	}
+}
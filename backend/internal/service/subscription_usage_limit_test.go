@@ -0,0 +1,112 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSubscriptionUsageLimits(t *testing.T) {
+	valid := []SubscriptionUsageLimit{
+		{Name: "opus-output", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricOutputTokens, Limit: 100000, Models: []string{"claude-opus-*"}},
+		{Name: "requests", Window: UsageLimitWindowMonthly, Metric: UsageLimitMetricRequests, Limit: 5000},
+		{Name: "weighted", Window: UsageLimitWindowWeekly, Metric: UsageLimitMetricWeightedTokens, Limit: 1e7, InputWeight: 1, OutputWeight: 5, CacheReadWeight: 0.1},
+	}
+	require.NoError(t, ValidateSubscriptionUsageLimits(SubscriptionTypeSubscription, valid))
+	require.NoError(t, ValidateSubscriptionUsageLimits(SubscriptionTypeSubscription, nil))
+	require.NoError(t, ValidateSubscriptionUsageLimits(SubscriptionTypeStandard, nil))
+	require.ErrorIs(t, ValidateSubscriptionUsageLimits(SubscriptionTypeStandard, valid), ErrSubscriptionUsageLimitInvalid, "balance groups cannot enforce usage limits")
+
+	invalid := [][]SubscriptionUsageLimit{
+		{{Name: "", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricRequests, Limit: 1}},
+		{{Name: "a", Window: "hourly", Metric: UsageLimitMetricRequests, Limit: 1}},
+		{{Name: "a", Window: UsageLimitWindowDaily, Metric: "usd", Limit: 1}},
+		{{Name: "a", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricRequests, Limit: 0}},
+		{{Name: "a", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricWeightedTokens, Limit: 1}},
+		{{Name: "a", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricWeightedTokens, Limit: 1, InputWeight: -1, OutputWeight: 1}},
+		{
+			{Name: "a", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricRequests, Limit: 1},
+			{Name: "a", Window: UsageLimitWindowWeekly, Metric: UsageLimitMetricRequests, Limit: 1},
+		},
+	}
+	for _, limits := range invalid {
+		require.ErrorIs(t, ValidateSubscriptionUsageLimits(SubscriptionTypeSubscription, limits), ErrSubscriptionUsageLimitInvalid)
+	}
+}
+
+func TestGroupUsageCounterDelta(t *testing.T) {
+	g := &Group{UsageLimits: []SubscriptionUsageLimit{
+		{Name: "opus-in", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricInputTokens, Limit: 1000, Models: []string{"claude-opus-*"}},
+		{Name: "out", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricOutputTokens, Limit: 1000},
+		{Name: "weighted", Window: UsageLimitWindowWeekly, Metric: UsageLimitMetricWeightedTokens, Limit: 1000, InputWeight: 1, OutputWeight: 4, CacheReadWeight: 0.1},
+		{Name: "requests", Window: UsageLimitWindowMonthly, Metric: UsageLimitMetricRequests, Limit: 10},
+	}}
+	tokens := UsageTokens{InputTokens: 100, OutputTokens: 50, CacheCreationTokens: 20, CacheReadTokens: 1000}
+
+	delta := g.usageCounterDelta("claude-opus-4-1", tokens)
+	require.Equal(t, map[string]float64{"opus-in": 1120, "out": 50}, delta.Daily)
+	require.InDelta(t, 100+200+100, delta.Weekly["weighted"], 1e-9)
+	require.Equal(t, map[string]float64{"requests": 1}, delta.Monthly)
+
+	// 未命中模型族的限额不计数
+	delta = g.usageCounterDelta("claude-sonnet-4-5", tokens)
+	require.NotContains(t, delta.Daily, "opus-in")
+	require.Contains(t, delta.Daily, "out")
+
+	require.True(t, (&Group{}).usageCounterDelta("any", tokens).IsEmpty())
+}
+
+func TestGroupCheckUsageLimits(t *testing.T) {
+	g := &Group{UsageLimits: []SubscriptionUsageLimit{
+		{Name: "opus", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricRequests, Limit: 3, Models: []string{"claude-opus-*"}},
+		{Name: "all", Window: UsageLimitWindowMonthly, Metric: UsageLimitMetricOutputTokens, Limit: 1000},
+	}}
+	counters := SubscriptionUsageCounters{
+		Daily:   map[string]float64{"opus": 3},
+		Monthly: map[string]float64{"all": 999},
+	}
+
+	err := g.checkUsageLimits("claude-opus-4-1", counters)
+	require.ErrorIs(t, err, ErrUsageLimitExceeded)
+	require.Contains(t, infraerrors.Message(err), `"opus"`)
+	require.NoError(t, g.checkUsageLimits("claude-sonnet-4-5", counters))
+
+	counters.Monthly["all"] = 1000
+	require.ErrorIs(t, g.checkUsageLimits("claude-sonnet-4-5", counters), ErrUsageLimitExceeded)
+}
+
+func TestBuildUsageLimitProgress(t *testing.T) {
+	recent := time.Now().Add(-time.Hour)
+	stale := time.Now().Add(-25 * time.Hour)
+	g := &Group{UsageLimits: []SubscriptionUsageLimit{
+		{Name: "daily", Window: UsageLimitWindowDaily, Metric: UsageLimitMetricRequests, Limit: 10},
+		{Name: "weekly", Window: UsageLimitWindowWeekly, Metric: UsageLimitMetricOutputTokens, Limit: 100},
+	}}
+	sub := &UserSubscription{
+		DailyWindowStart:    &recent,
+		WeeklyWindowStart:   &recent,
+		DailyUsageCounters:  map[string]float64{"daily": 4},
+		WeeklyUsageCounters: map[string]float64{"weekly": 150},
+	}
+
+	progress := BuildUsageLimitProgress(g, sub)
+	require.Len(t, progress, 2)
+	require.InDelta(t, 4, progress[0].Used, 1e-9)
+	require.InDelta(t, 6, progress[0].Remaining, 1e-9)
+	require.InDelta(t, 40, progress[0].Percentage, 1e-9)
+	require.NotNil(t, progress[0].ResetsAt)
+	require.Zero(t, progress[1].Remaining)
+	require.InDelta(t, 100, progress[1].Percentage, 1e-9)
+
+	// 已过期的窗口视为未使用
+	sub.DailyWindowStart = &stale
+	progress = BuildUsageLimitProgress(g, sub)
+	require.Zero(t, progress[0].Used)
+	require.Nil(t, progress[0].ResetsAt)
+
+	require.Nil(t, BuildUsageLimitProgress(&Group{}, sub))
+}
@@ -94,6 +94,8 @@ type TotpService struct {
 	settingService    *SettingService
 	emailService      *EmailService
 	emailQueueService *EmailQueueService
+	sessionService    *SessionService
+	twoFactorService  *TwoFactorService
 }
 
 // NewTotpService creates a new TOTP service
@@ -104,6 +106,8 @@ func NewTotpService(
 	settingService *SettingService,
 	emailService *EmailService,
 	emailQueueService *EmailQueueService,
+	sessionService *SessionService,
+	twoFactorService *TwoFactorService,
 ) *TotpService {
 	return &TotpService{
 		userRepo:          userRepo,
@@ -112,6 +116,8 @@ func NewTotpService(
 		settingService:    settingService,
 		emailService:      emailService,
 		emailQueueService: emailQueueService,
+		sessionService:    sessionService,
+		twoFactorService:  twoFactorService,
 	}
 }
 
@@ -150,22 +156,8 @@ func (s *TotpService) InitiateSetup(ctx context.Context, userID int64, emailCode
 	}
 
 	// Verify identity based on email verification setting
-	if s.settingService.IsEmailVerifyEnabled(ctx) {
-		// Email verification enabled - verify email code
-		if emailCode == "" {
-			return nil, ErrVerifyCodeRequired
-		}
-		if err := s.emailService.VerifyCode(ctx, user.Email, emailCode); err != nil {
-			return nil, err
-		}
-	} else {
-		// Email verification disabled - verify password
-		if password == "" {
-			return nil, ErrPasswordRequired
-		}
-		if !user.CheckPassword(password) {
-			return nil, ErrPasswordIncorrect
-		}
+	if err := verifyUserIdentity(ctx, s.settingService, s.emailService, user, emailCode, password); err != nil {
+		return nil, err
 	}
 
 	// Generate a new TOTP key
@@ -203,30 +195,31 @@ func (s *TotpService) InitiateSetup(ctx context.Context, userID int64, emailCode
 }
 
 // CompleteSetup completes the TOTP setup by verifying the code
-func (s *TotpService) CompleteSetup(ctx context.Context, userID int64, totpCode, setupToken string) error {
+// Returns freshly generated recovery codes when the user had none (shown to the user only once)
+func (s *TotpService) CompleteSetup(ctx context.Context, userID int64, totpCode, setupToken string) ([]string, error) {
 	// Check if TOTP feature is enabled globally
 	if !s.settingService.IsTotpEnabled(ctx) {
-		return ErrTotpNotEnabled
+		return nil, ErrTotpNotEnabled
 	}
 
 	// Get the setup session
 	session, err := s.cache.GetSetupSession(ctx, userID)
 	if err != nil {
-		return ErrTotpSetupExpired
+		return nil, ErrTotpSetupExpired
 	}
 
 	if session == nil {
-		return ErrTotpSetupExpired
+		return nil, ErrTotpSetupExpired
 	}
 
 	// Verify the setup token (constant-time comparison)
 	if subtle.ConstantTimeCompare([]byte(session.SetupToken), []byte(setupToken)) != 1 {
-		return ErrTotpSetupExpired
+		return nil, ErrTotpSetupExpired
 	}
 
 	// Verify the TOTP code
 	if !totp.Validate(totpCode, session.Secret) {
-		return ErrTotpInvalidCode
+		return nil, ErrTotpInvalidCode
 	}
 
 	setupSecretPrefix := "N/A"
@@ -241,7 +234,7 @@ func (s *TotpService) CompleteSetup(ctx context.Context, userID int64, totpCode,
 	// Encrypt the secret
 	encryptedSecret, err := s.encryptor.Encrypt(session.Secret)
 	if err != nil {
-		return fmt.Errorf("encrypt totp secret: %w", err)
+		return nil, fmt.Errorf("encrypt totp secret: %w", err)
 	}
 
 	slog.Debug("totp_complete_setup_encrypted",
@@ -269,18 +262,26 @@ func (s *TotpService) CompleteSetup(ctx context.Context, userID int64, totpCode,
 
 	// Update user with encrypted TOTP secret
 	if err := s.userRepo.UpdateTotpSecret(ctx, userID, &encryptedSecret); err != nil {
-		return fmt.Errorf("update totp secret: %w", err)
+		return nil, fmt.Errorf("update totp secret: %w", err)
 	}
 
 	// Enable TOTP for the user
 	if err := s.userRepo.EnableTotp(ctx, userID); err != nil {
-		return fmt.Errorf("enable totp: %w", err)
+		return nil, fmt.Errorf("enable totp: %w", err)
 	}
 
 	// Clean up the setup session
 	_ = s.cache.DeleteSetupSession(ctx, userID)
 
-	return nil
+	// 2FA 配置变更后吊销其他设备上的登录会话
+	s.sessionService.revokeAllBestEffort(ctx, userID, CurrentSessionIDFromContext(ctx), SessionRevokeReasonTotpChanged)
+
+	var recoveryCodes []string
+	if s.twoFactorService != nil {
+		recoveryCodes = s.twoFactorService.ensureRecoveryCodes(ctx, userID)
+	}
+
+	return recoveryCodes, nil
 }
 
 // Disable disables TOTP for a user
@@ -297,22 +298,8 @@ func (s *TotpService) Disable(ctx context.Context, userID int64, emailCode, pass
 	}
 
 	// Verify identity based on email verification setting
-	if s.settingService.IsEmailVerifyEnabled(ctx) {
-		// Email verification enabled - verify email code
-		if emailCode == "" {
-			return ErrVerifyCodeRequired
-		}
-		if err := s.emailService.VerifyCode(ctx, user.Email, emailCode); err != nil {
-			return err
-		}
-	} else {
-		// Email verification disabled - verify password
-		if password == "" {
-			return ErrPasswordRequired
-		}
-		if !user.CheckPassword(password) {
-			return ErrPasswordIncorrect
-		}
+	if err := verifyUserIdentity(ctx, s.settingService, s.emailService, user, emailCode, password); err != nil {
+		return err
 	}
 
 	// Disable TOTP
@@ -320,6 +307,12 @@ func (s *TotpService) Disable(ctx context.Context, userID int64, emailCode, pass
 		return fmt.Errorf("disable totp: %w", err)
 	}
 
+	s.sessionService.revokeAllBestEffort(ctx, userID, CurrentSessionIDFromContext(ctx), SessionRevokeReasonTotpChanged)
+
+	if s.twoFactorService != nil {
+		s.twoFactorService.clearRecoveryCodesIfNoFactor(ctx, userID)
+	}
+
 	return nil
 }
 
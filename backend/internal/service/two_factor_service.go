@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrRecoveryCodeInvalid = infraerrors.BadRequest("RECOVERY_CODE_INVALID", "invalid or already used recovery code")
+	ErrTwoFactorNotSetup   = infraerrors.BadRequest("TWO_FACTOR_NOT_SETUP", "two-factor authentication is not set up for this account")
+	ErrAdmin2FANotSetup    = infraerrors.BadRequest("ADMIN_2FA_NOT_SETUP", "set up two-factor authentication for your own account before requiring it for admins")
+)
+
+// 双因素登录方式
+const (
+	TwoFactorMethodTotp         = "totp"
+	TwoFactorMethodWebAuthn     = "webauthn"
+	TwoFactorMethodRecoveryCode = "recovery_code"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // 去除易混淆字符 0/o/1/l/i
+)
+
+// RecoveryCodeRepository 恢复码持久化（仅保存哈希）
+type RecoveryCodeRepository interface {
+	// ReplaceAll 删除用户现有恢复码并写入新的一组哈希
+	ReplaceAll(ctx context.Context, userID int64, codeHashes []string) error
+	// Consume 将匹配且未使用的恢复码标记为已使用，返回是否命中
+	Consume(ctx context.Context, userID int64, codeHash string, at time.Time) (bool, error)
+	CountRemaining(ctx context.Context, userID int64) (int, error)
+	DeleteAll(ctx context.Context, userID int64) error
+}
+
+// TwoFactorStatus 用户双因素认证总体状态
+type TwoFactorStatus struct {
+	TotpEnabled            bool `json:"totp_enabled"`
+	WebAuthnCredentials    int  `json:"webauthn_credentials"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	Admin2FARequired       bool `json:"admin_2fa_required"`
+}
+
+// TwoFactorService 聚合 TOTP / WebAuthn 之上的双因素逻辑：恢复码、登录方式判定与管理员 2FA 策略
+type TwoFactorService struct {
+	recoveryRepo   RecoveryCodeRepository
+	credentialRepo WebAuthnCredentialRepository
+	userRepo       UserRepository
+	totpCache      TotpCache
+	settingService *SettingService
+	emailService   *EmailService
+	cfg            *config.Config
+}
+
+// NewTwoFactorService creates a new TwoFactorService
+func NewTwoFactorService(
+	recoveryRepo RecoveryCodeRepository,
+	credentialRepo WebAuthnCredentialRepository,
+	userRepo UserRepository,
+	totpCache TotpCache,
+	settingService *SettingService,
+	emailService *EmailService,
+	cfg *config.Config,
+) *TwoFactorService {
+	return &TwoFactorService{
+		recoveryRepo:   recoveryRepo,
+		credentialRepo: credentialRepo,
+		userRepo:       userRepo,
+		totpCache:      totpCache,
+		settingService: settingService,
+		emailService:   emailService,
+		cfg:            cfg,
+	}
+}
+
+func (s *TwoFactorService) webAuthnEnabled() bool {
+	return s.cfg != nil && s.cfg.WebAuthn.Enabled
+}
+
+// HasSecondFactor 用户是否拥有当前可用于登录的第二因素（与 LoginMethods 一致：TOTP 需全局开启，WebAuthn 需配置启用）
+func (s *TwoFactorService) HasSecondFactor(ctx context.Context, user *User) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+	methods, err := s.factorMethods(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	return len(methods) > 0, nil
+}
+
+// hasConfiguredFactor 用户是否绑定过任一第二因素（不考虑功能开关），用于恢复码的生命周期管理
+func (s *TwoFactorService) hasConfiguredFactor(ctx context.Context, user *User) (bool, error) {
+	if user.TotpEnabled {
+		return true, nil
+	}
+	count, err := s.credentialRepo.CountByUser(ctx, user.ID)
+	if err != nil {
+		return false, fmt.Errorf("count webauthn credentials: %w", err)
+	}
+	return count > 0, nil
+}
+
+// factorMethods 返回用户已绑定且功能已开启的第二因素方式（不含恢复码）
+func (s *TwoFactorService) factorMethods(ctx context.Context, user *User) ([]string, error) {
+	methods := make([]string, 0, 3)
+	if user.TotpEnabled && s.settingService.IsTotpEnabled(ctx) {
+		methods = append(methods, TwoFactorMethodTotp)
+	}
+	if s.webAuthnEnabled() {
+		count, err := s.credentialRepo.CountByUser(ctx, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("count webauthn credentials: %w", err)
+		}
+		if count > 0 {
+			methods = append(methods, TwoFactorMethodWebAuthn)
+		}
+	}
+	return methods, nil
+}
+
+// LoginMethods 返回登录时该用户需要（且可用）的第二因素方式；为空表示无需 2FA
+func (s *TwoFactorService) LoginMethods(ctx context.Context, user *User) ([]string, error) {
+	methods, err := s.factorMethods(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) == 0 {
+		return methods, nil
+	}
+	remaining, err := s.recoveryRepo.CountRemaining(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("count recovery codes: %w", err)
+	}
+	if remaining > 0 {
+		methods = append(methods, TwoFactorMethodRecoveryCode)
+	}
+	return methods, nil
+}
+
+// GetStatus 返回用户双因素认证状态
+func (s *TwoFactorService) GetStatus(ctx context.Context, userID int64) (*TwoFactorStatus, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	credentials, err := s.credentialRepo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("count webauthn credentials: %w", err)
+	}
+	remaining, err := s.recoveryRepo.CountRemaining(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("count recovery codes: %w", err)
+	}
+	return &TwoFactorStatus{
+		TotpEnabled:            user.TotpEnabled,
+		WebAuthnCredentials:    credentials,
+		RecoveryCodesRemaining: remaining,
+		Admin2FARequired:       user.IsAdmin() && s.settingService.IsAdmin2FARequired(ctx),
+	}, nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码（旧码全部失效），需要验证身份
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int64, emailCode, password string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	hasFactor, err := s.HasSecondFactor(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if !hasFactor {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if err := s.VerifyIdentity(ctx, user, emailCode, password); err != nil {
+		return nil, err
+	}
+	return s.generateRecoveryCodes(ctx, userID)
+}
+
+// ensureRecoveryCodes 在首次启用第二因素时生成恢复码；已有未使用的恢复码时返回 nil（不覆盖用户已保存的码）
+func (s *TwoFactorService) ensureRecoveryCodes(ctx context.Context, userID int64) []string {
+	remaining, err := s.recoveryRepo.CountRemaining(ctx, userID)
+	if err != nil {
+		slog.Warn("recovery_codes_count_failed", "user_id", userID, "error", err)
+		return nil
+	}
+	if remaining > 0 {
+		return nil
+	}
+	codes, err := s.generateRecoveryCodes(ctx, userID)
+	if err != nil {
+		// 不影响第二因素的启用，用户可稍后手动重新生成
+		slog.Warn("recovery_codes_generate_failed", "user_id", userID, "error", err)
+		return nil
+	}
+	return codes
+}
+
+// clearRecoveryCodesIfNoFactor 用户移除最后一个第二因素后清理恢复码
+func (s *TwoFactorService) clearRecoveryCodesIfNoFactor(ctx context.Context, userID int64) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return
+	}
+	hasFactor, err := s.hasConfiguredFactor(ctx, user)
+	if err != nil || hasFactor {
+		return
+	}
+	if err := s.recoveryRepo.DeleteAll(ctx, userID); err != nil {
+		slog.Warn("recovery_codes_delete_failed", "user_id", userID, "error", err)
+	}
+}
+
+func (s *TwoFactorService) generateRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	if err := s.recoveryRepo.ReplaceAll(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// VerifyRecoveryCode 校验并消耗一个恢复码（与 TOTP 共享失败次数限制）
+func (s *TwoFactorService) VerifyRecoveryCode(ctx context.Context, userID int64, code string) error {
+	attempts, err := s.totpCache.GetVerifyAttempts(ctx, userID)
+	if err == nil && attempts >= maxTotpAttempts {
+		return ErrTotpTooManyAttempts
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		_, _ = s.totpCache.IncrementVerifyAttempts(ctx, userID)
+		return ErrRecoveryCodeInvalid
+	}
+
+	ok, err := s.recoveryRepo.Consume(ctx, userID, hashRecoveryCode(normalized), time.Now())
+	if err != nil {
+		return fmt.Errorf("consume recovery code: %w", err)
+	}
+	if !ok {
+		_, _ = s.totpCache.IncrementVerifyAttempts(ctx, userID)
+		return ErrRecoveryCodeInvalid
+	}
+
+	_ = s.totpCache.ClearVerifyAttempts(ctx, userID)
+	slog.Info("recovery_code_used", "user_id", userID)
+	return nil
+}
+
+// CheckAdminPolicy 检查管理员是否满足强制 2FA 策略；不满足时返回 false
+func (s *TwoFactorService) CheckAdminPolicy(ctx context.Context, user *User) (bool, error) {
+	if user == nil || !user.IsAdmin() || !s.settingService.IsAdmin2FARequired(ctx) {
+		return true, nil
+	}
+	return s.HasSecondFactor(ctx, user)
+}
+
+// EnsureCanRequireAdmin2FA 开启"管理员强制 2FA"前确认操作者自身已配置第二因素，避免把自己锁在管理后台之外
+func (s *TwoFactorService) EnsureCanRequireAdmin2FA(ctx context.Context, adminID int64) error {
+	user, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	hasFactor, err := s.HasSecondFactor(ctx, user)
+	if err != nil {
+		return err
+	}
+	if !hasFactor {
+		return ErrAdmin2FANotSetup
+	}
+	return nil
+}
+
+// VerifyIdentity 敏感的 2FA 变更操作前验证用户身份：
+// 开启邮箱验证时校验邮箱验证码，否则校验登录密码
+func (s *TwoFactorService) VerifyIdentity(ctx context.Context, user *User, emailCode, password string) error {
+	return verifyUserIdentity(ctx, s.settingService, s.emailService, user, emailCode, password)
+}
+
+func verifyUserIdentity(ctx context.Context, settingService *SettingService, emailService *EmailService, user *User, emailCode, password string) error {
+	if settingService.IsEmailVerifyEnabled(ctx) {
+		if emailCode == "" {
+			return ErrVerifyCodeRequired
+		}
+		return emailService.VerifyCode(ctx, user.Email, emailCode)
+	}
+	if password == "" {
+		return ErrPasswordRequired
+	}
+	if !user.CheckPassword(password) {
+		return ErrPasswordIncorrect
+	}
+	return nil
+}
+
+// newRecoveryCode 生成形如 "abcde-fghjk" 的恢复码
+func newRecoveryCode() (string, error) {
+	// 拒绝采样，避免取模带来的字符分布偏差
+	limit := byte(256 - 256%len(recoveryCodeAlphabet))
+	chars := make([]byte, 0, recoveryCodeLength)
+	buf := make([]byte, recoveryCodeLength*2)
+	for len(chars) < recoveryCodeLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, v := range buf {
+			if v >= limit || len(chars) == recoveryCodeLength {
+				continue
+			}
+			chars = append(chars, recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)])
+		}
+	}
+	half := recoveryCodeLength / 2
+	return string(chars[:half]) + "-" + string(chars[half:]), nil
+}
+
+// normalizeRecoveryCode 去除空白与连字符并转小写，允许用户以任意格式输入
+func normalizeRecoveryCode(code string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(code) {
+		if r == '-' || r == ' ' || r == '\t' {
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,253 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/require"
+)
+
+type recoveryCodeRepoStub struct {
+	codes map[string]*time.Time // hash -> used_at
+}
+
+func (r *recoveryCodeRepoStub) ReplaceAll(_ context.Context, _ int64, codeHashes []string) error {
+	r.codes = make(map[string]*time.Time, len(codeHashes))
+	for _, h := range codeHashes {
+		r.codes[h] = nil
+	}
+	return nil
+}
+
+func (r *recoveryCodeRepoStub) Consume(_ context.Context, _ int64, codeHash string, at time.Time) (bool, error) {
+	usedAt, ok := r.codes[codeHash]
+	if !ok || usedAt != nil {
+		return false, nil
+	}
+	r.codes[codeHash] = &at
+	return true, nil
+}
+
+func (r *recoveryCodeRepoStub) CountRemaining(_ context.Context, _ int64) (int, error) {
+	n := 0
+	for _, usedAt := range r.codes {
+		if usedAt == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *recoveryCodeRepoStub) DeleteAll(_ context.Context, _ int64) error {
+	r.codes = nil
+	return nil
+}
+
+type webAuthnCredentialRepoStub struct {
+	credentials []WebAuthnCredential
+}
+
+func (r *webAuthnCredentialRepoStub) Create(_ context.Context, credential *WebAuthnCredential) error {
+	credential.ID = int64(len(r.credentials) + 1)
+	r.credentials = append(r.credentials, *credential)
+	return nil
+}
+
+func (r *webAuthnCredentialRepoStub) ListByUser(_ context.Context, userID int64) ([]WebAuthnCredential, error) {
+	out := []WebAuthnCredential{}
+	for _, c := range r.credentials {
+		if c.UserID == userID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (r *webAuthnCredentialRepoStub) CountByUser(ctx context.Context, userID int64) (int, error) {
+	list, _ := r.ListByUser(ctx, userID)
+	return len(list), nil
+}
+
+func (r *webAuthnCredentialRepoStub) UpdateAfterLogin(_ context.Context, _ string, _ webauthn.Credential, _ time.Time) error {
+	return nil
+}
+
+func (r *webAuthnCredentialRepoStub) Delete(_ context.Context, userID, id int64) error {
+	for i, c := range r.credentials {
+		if c.ID == id && c.UserID == userID {
+			r.credentials = append(r.credentials[:i], r.credentials[i+1:]...)
+			return nil
+		}
+	}
+	return ErrWebAuthnCredentialMissing
+}
+
+type totpCacheAttemptsStub struct {
+	attempts int
+}
+
+func (c *totpCacheAttemptsStub) GetSetupSession(context.Context, int64) (*TotpSetupSession, error) {
+	return nil, nil
+}
+func (c *totpCacheAttemptsStub) SetSetupSession(context.Context, int64, *TotpSetupSession, time.Duration) error {
+	return nil
+}
+func (c *totpCacheAttemptsStub) DeleteSetupSession(context.Context, int64) error { return nil }
+func (c *totpCacheAttemptsStub) GetLoginSession(context.Context, string) (*TotpLoginSession, error) {
+	return nil, nil
+}
+func (c *totpCacheAttemptsStub) SetLoginSession(context.Context, string, *TotpLoginSession, time.Duration) error {
+	return nil
+}
+func (c *totpCacheAttemptsStub) DeleteLoginSession(context.Context, string) error { return nil }
+func (c *totpCacheAttemptsStub) IncrementVerifyAttempts(context.Context, int64) (int, error) {
+	c.attempts++
+	return c.attempts, nil
+}
+func (c *totpCacheAttemptsStub) GetVerifyAttempts(context.Context, int64) (int, error) {
+	return c.attempts, nil
+}
+func (c *totpCacheAttemptsStub) ClearVerifyAttempts(context.Context, int64) error {
+	c.attempts = 0
+	return nil
+}
+
+func newTwoFactorServiceForTest(user *User, settings map[string]string) (*TwoFactorService, *recoveryCodeRepoStub, *webAuthnCredentialRepoStub, *totpCacheAttemptsStub) {
+	cfg := &config.Config{WebAuthn: config.WebAuthnConfig{Enabled: true}}
+	recovery := &recoveryCodeRepoStub{}
+	credentials := &webAuthnCredentialRepoStub{}
+	cache := &totpCacheAttemptsStub{}
+	settingService := NewSettingService(&settingRepoStub{values: settings}, cfg)
+	svc := NewTwoFactorService(recovery, credentials, &userRepoStub{user: user}, cache, settingService, nil, cfg)
+	return svc, recovery, credentials, cache
+}
+
+func TestTwoFactorService_RecoveryCodesAreSingleUse(t *testing.T) {
+	user := &User{ID: 1, Email: "user@test.com", TotpEnabled: true}
+	svc, _, _, _ := newTwoFactorServiceForTest(user, nil)
+	ctx := context.Background()
+
+	codes := svc.ensureRecoveryCodes(ctx, user.ID)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Nil(t, svc.ensureRecoveryCodes(ctx, user.ID), "existing unused codes must not be replaced")
+
+	// 允许用户输入大写、省略连字符
+	input := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	require.NoError(t, svc.VerifyRecoveryCode(ctx, user.ID, input))
+	require.ErrorIs(t, svc.VerifyRecoveryCode(ctx, user.ID, codes[0]), ErrRecoveryCodeInvalid)
+}
+
+func TestTwoFactorService_RecoveryCodeAttemptsAreLimited(t *testing.T) {
+	user := &User{ID: 1, Email: "user@test.com", TotpEnabled: true}
+	svc, _, _, cache := newTwoFactorServiceForTest(user, nil)
+	ctx := context.Background()
+
+	codes := svc.ensureRecoveryCodes(ctx, user.ID)
+	for i := 0; i < maxTotpAttempts; i++ {
+		require.ErrorIs(t, svc.VerifyRecoveryCode(ctx, user.ID, "aaaaa-aaaaa"), ErrRecoveryCodeInvalid)
+	}
+	require.Equal(t, maxTotpAttempts, cache.attempts)
+	require.ErrorIs(t, svc.VerifyRecoveryCode(ctx, user.ID, codes[0]), ErrTotpTooManyAttempts)
+}
+
+func TestTwoFactorService_LoginMethods(t *testing.T) {
+	ctx := context.Background()
+
+	user := &User{ID: 1, Email: "user@test.com"}
+	svc, _, credentials, _ := newTwoFactorServiceForTest(user, map[string]string{SettingKeyTotpEnabled: "true"})
+	methods, err := svc.LoginMethods(ctx, user)
+	require.NoError(t, err)
+	require.Empty(t, methods)
+
+	require.NoError(t, credentials.Create(ctx, &WebAuthnCredential{UserID: user.ID}))
+	svc.ensureRecoveryCodes(ctx, user.ID)
+	methods, err = svc.LoginMethods(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, []string{TwoFactorMethodWebAuthn, TwoFactorMethodRecoveryCode}, methods)
+
+	user.TotpEnabled = true
+	methods, err = svc.LoginMethods(ctx, user)
+	require.NoError(t, err)
+	require.Equal(t, []string{TwoFactorMethodTotp, TwoFactorMethodWebAuthn, TwoFactorMethodRecoveryCode}, methods)
+}
+
+func TestTwoFactorService_ClearRecoveryCodesAfterLastFactorRemoved(t *testing.T) {
+	ctx := context.Background()
+	user := &User{ID: 1, Email: "user@test.com"}
+	svc, recovery, credentials, _ := newTwoFactorServiceForTest(user, nil)
+
+	require.NoError(t, credentials.Create(ctx, &WebAuthnCredential{UserID: user.ID}))
+	svc.ensureRecoveryCodes(ctx, user.ID)
+
+	svc.clearRecoveryCodesIfNoFactor(ctx, user.ID)
+	require.Len(t, recovery.codes, recoveryCodeCount, "codes stay while a factor remains")
+
+	require.NoError(t, credentials.Delete(ctx, user.ID, 1))
+	svc.clearRecoveryCodesIfNoFactor(ctx, user.ID)
+	require.Empty(t, recovery.codes)
+}
+
+func TestTwoFactorService_AdminPolicy(t *testing.T) {
+	ctx := context.Background()
+	admin := &User{ID: 1, Email: "admin@test.com", Role: RoleAdmin}
+
+	svc, _, _, _ := newTwoFactorServiceForTest(admin, map[string]string{})
+	ok, err := svc.CheckAdminPolicy(ctx, admin)
+	require.NoError(t, err)
+	require.True(t, ok, "policy disabled")
+
+	svc, _, credentials, _ := newTwoFactorServiceForTest(admin, map[string]string{SettingKeyRequireAdmin2FA: "true"})
+	ok, err = svc.CheckAdminPolicy(ctx, admin)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.ErrorIs(t, svc.EnsureCanRequireAdmin2FA(ctx, admin.ID), ErrAdmin2FANotSetup)
+
+	ok, err = svc.CheckAdminPolicy(ctx, &User{ID: 2, Role: RoleUser})
+	require.NoError(t, err)
+	require.True(t, ok, "policy only applies to admins")
+
+	require.NoError(t, credentials.Create(ctx, &WebAuthnCredential{UserID: admin.ID}))
+	ok, err = svc.CheckAdminPolicy(ctx, admin)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, svc.EnsureCanRequireAdmin2FA(ctx, admin.ID))
+}
+
+func TestTwoFactorService_AdminPolicyIgnoresDisabledFactors(t *testing.T) {
+	ctx := context.Background()
+	admin := &User{ID: 1, Email: "admin@test.com", Role: RoleAdmin, TotpEnabled: true}
+
+	// TOTP 已绑定但全局关闭，视为未配置第二因素
+	svc, _, credentials, _ := newTwoFactorServiceForTest(admin, map[string]string{SettingKeyRequireAdmin2FA: "true"})
+	ok, err := svc.CheckAdminPolicy(ctx, admin)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.ErrorIs(t, svc.EnsureCanRequireAdmin2FA(ctx, admin.ID), ErrAdmin2FANotSetup)
+
+	// WebAuthn 凭证存在但 WebAuthn 未启用
+	require.NoError(t, credentials.Create(ctx, &WebAuthnCredential{UserID: admin.ID}))
+	svc.cfg.WebAuthn.Enabled = false
+	ok, err = svc.CheckAdminPolicy(ctx, admin)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	svc.cfg.WebAuthn.Enabled = true
+	ok, err = svc.CheckAdminPolicy(ctx, admin)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestWebAuthnUserHandleRoundTrip(t *testing.T) {
+	id, ok := userIDFromWebAuthnHandle(webAuthnUserHandle(42))
+	require.True(t, ok)
+	require.Equal(t, int64(42), id)
+
+	_, ok = userIDFromWebAuthnHandle([]byte("short"))
+	require.False(t, ok)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/fakeupstream"
+)
+
+// UpstreamDryRunRecorder 记录 dry run 期间网关本应发往上游的请求
+type UpstreamDryRunRecorder struct {
+	mu       sync.Mutex
+	requests []*CapturedUpstreamRequest
+}
+
+// CapturedUpstreamRequest 被拦截的上游请求（鉴权信息已脱敏）
+type CapturedUpstreamRequest struct {
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers"`
+	Body      json.RawMessage   `json:"body,omitempty"`
+	BodyText  string            `json:"body_text,omitempty"`
+	ProxyUsed bool              `json:"proxy_used"`
+}
+
+// WithUpstreamDryRun 返回开启上游 dry run 的 context 及其记录器
+func WithUpstreamDryRun(ctx context.Context) (context.Context, *UpstreamDryRunRecorder) {
+	rec := &UpstreamDryRunRecorder{}
+	return context.WithValue(ctx, ctxkey.UpstreamDryRun, rec), rec
+}
+
+func upstreamDryRunRecorderFrom(ctx context.Context) *UpstreamDryRunRecorder {
+	if ctx == nil {
+		return nil
+	}
+	rec, _ := ctx.Value(ctxkey.UpstreamDryRun).(*UpstreamDryRunRecorder)
+	return rec
+}
+
+// IsUpstreamDryRun 判断当前请求是否处于上游 dry run 模式
+func IsUpstreamDryRun(ctx context.Context) bool {
+	return upstreamDryRunRecorderFrom(ctx) != nil
+}
+
+// dryRunPlaceholderToken 账号未保存 access_token 时的占位凭证（捕获的请求中会被脱敏）
+const dryRunPlaceholderToken = "dry-run-access-token"
+
+// dryRunAccessToken dry run 期间不走 token 刷新：刷新会轮换 refresh_token 并写回账号与缓存，
+// 这里直接返回账号当前保存的 access_token。非 dry run 时返回 false。
+func dryRunAccessToken(ctx context.Context, account *Account) (string, bool) {
+	if account == nil || !IsUpstreamDryRun(ctx) {
+		return "", false
+	}
+	if token := strings.TrimSpace(account.GetCredential("access_token")); token != "" {
+		return token, true
+	}
+	return dryRunPlaceholderToken, true
+}
+
+// Requests 返回已记录的上游请求
+func (r *UpstreamDryRunRecorder) Requests() []*CapturedUpstreamRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*CapturedUpstreamRequest(nil), r.requests...)
+}
+
+func (r *UpstreamDryRunRecorder) record(req *CapturedUpstreamRequest) {
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.mu.Unlock()
+}
+
+// dryRunHTTPUpstream dry run 感知的 HTTPUpstream 装饰器：
+// context 中带有记录器时仅记录请求并返回 fakeupstream 构造的成功响应，否则透传给内层实现。
+type dryRunHTTPUpstream struct {
+	inner HTTPUpstream
+}
+
+// NewDryRunHTTPUpstream 为 HTTPUpstream 增加 dry run 拦截能力
+func NewDryRunHTTPUpstream(inner HTTPUpstream) HTTPUpstream {
+	return &dryRunHTTPUpstream{inner: inner}
+}
+
+func (u *dryRunHTTPUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	if rec := upstreamDryRunRecorderFrom(req.Context()); rec != nil {
+		return u.capture(rec, req, proxyURL)
+	}
+	return u.inner.Do(req, proxyURL, accountID, accountConcurrency)
+}
+
+func (u *dryRunHTTPUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	if rec := upstreamDryRunRecorderFrom(req.Context()); rec != nil {
+		return u.capture(rec, req, proxyURL)
+	}
+	return u.inner.DoWithTLS(req, proxyURL, accountID, accountConcurrency, enableTLSFingerprint)
+}
+
+func (u *dryRunHTTPUpstream) capture(rec *UpstreamDryRunRecorder, req *http.Request, proxyURL string) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+	}
+	rec.record(captureUpstreamRequest(req, body, proxyURL != ""))
+	return fakeupstream.Respond(req, body, fakeupstream.DefaultOptions()), nil
+}
+
+const redactedValue = "[REDACTED]"
+
+func captureUpstreamRequest(req *http.Request, body []byte, proxyUsed bool) *CapturedUpstreamRequest {
+	captured := &CapturedUpstreamRequest{
+		Method:    req.Method,
+		URL:       redactUpstreamURL(req.URL),
+		Headers:   make(map[string]string, len(req.Header)),
+		ProxyUsed: proxyUsed,
+	}
+	for k, values := range req.Header {
+		lower := strings.ToLower(k)
+		value := strings.Join(values, ", ")
+		if fakeupstream.IsSensitiveHeader(lower) {
+			value = redactCredential(value)
+		}
+		captured.Headers[lower] = value
+	}
+	if len(body) > 0 {
+		if json.Valid(body) {
+			captured.Body = append(json.RawMessage(nil), body...)
+		} else {
+			captured.BodyText = string(body)
+		}
+	}
+	return captured
+}
+
+// redactCredential 保留认证方案（如 Bearer），隐藏凭证本身
+func redactCredential(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok && strings.EqualFold(scheme, "bearer") {
+		return scheme + " " + redactedValue
+	}
+	return redactedValue
+}
+
+func redactUpstreamURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clone := *u
+	clone.User = nil
+	query := clone.Query()
+	if query.Has("key") {
+		query.Set("key", redactedValue)
+		clone.RawQuery = query.Encode()
+	}
+	return clone.String()
+}
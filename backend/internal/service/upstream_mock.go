@@ -0,0 +1,148 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/fakeupstream"
+)
+
+// mockHTTPUpstream 内置模拟上游与上游录制的 HTTPUpstream 装饰器：
+//   - mock_upstream.enabled 时，发往 *.mock.invalid 的请求由 fakeupstream.Mock 按 profile 响应，不访问网络
+//   - mock_upstream.record.enabled 时，真实上游的交互在响应体读完后写入录制目录，可作为 fixtures_dir 回放
+type mockHTTPUpstream struct {
+	inner  HTTPUpstream
+	mock   *fakeupstream.Mock
+	record config.UpstreamRecordConfig
+}
+
+// NewMockHTTPUpstream 按配置为 HTTPUpstream 增加模拟上游与录制能力；两者均未开启时直接返回 inner
+func NewMockHTTPUpstream(inner HTTPUpstream, cfg *config.Config) HTTPUpstream {
+	if cfg == nil || (!cfg.MockUpstream.Enabled && !cfg.MockUpstream.Record.Enabled) {
+		return inner
+	}
+	u := &mockHTTPUpstream{inner: inner, record: cfg.MockUpstream.Record}
+	if cfg.MockUpstream.Enabled {
+		u.mock = fakeupstream.NewMock(buildMockProfiles(cfg.MockUpstream.Profiles))
+		log.Printf("[MockUpstream] enabled: accounts with base_url https://<profile>.%s are served by the built-in mock", fakeupstream.MockHostSuffix)
+	}
+	if cfg.MockUpstream.Record.Enabled {
+		log.Printf("[MockUpstream] recording upstream exchanges to %s", cfg.MockUpstream.Record.Dir)
+	}
+	return u
+}
+
+func buildMockProfiles(profiles map[string]config.MockUpstreamProfile) map[string]fakeupstream.Profile {
+	out := make(map[string]fakeupstream.Profile, len(profiles))
+	for name, p := range profiles {
+		opts := fakeupstream.DefaultOptions()
+		if p.Text != "" {
+			opts.Text = p.Text
+		}
+		if p.InputTokens > 0 {
+			opts.InputTokens = p.InputTokens
+		}
+		if p.OutputTokens > 0 {
+			opts.OutputTokens = p.OutputTokens
+		}
+		opts.CacheReadTokens = p.CacheReadTokens
+		opts.Chunks = p.Chunks
+
+		profile := fakeupstream.Profile{
+			Options:          opts,
+			Latency:          time.Duration(p.LatencyMs) * time.Millisecond,
+			ChunkInterval:    time.Duration(p.ChunkIntervalMs) * time.Millisecond,
+			RateLimitRate:    p.RateLimitRate,
+			OverloadedRate:   p.OverloadedRate,
+			ServerErrorRate:  p.ServerErrorRate,
+			RetryAfter:       time.Duration(p.RetryAfterSeconds) * time.Second,
+			RateLimitHeaders: p.RateLimitHeaders,
+		}
+		if dir := strings.TrimSpace(p.FixturesDir); dir != "" {
+			fixtures, err := fakeupstream.LoadFixtures(dir)
+			if err != nil {
+				log.Printf("[MockUpstream] profile %s: load fixtures from %s failed: %v", name, dir, err)
+			} else {
+				profile.Fixtures = fixtures
+				log.Printf("[MockUpstream] profile %s: loaded %d fixtures from %s", name, len(fixtures), dir)
+			}
+		}
+		out[name] = profile
+	}
+	return out
+}
+
+func (u *mockHTTPUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	return u.do(req, func(r *http.Request) (*http.Response, error) {
+		return u.inner.Do(r, proxyURL, accountID, accountConcurrency)
+	})
+}
+
+func (u *mockHTTPUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	return u.do(req, func(r *http.Request) (*http.Response, error) {
+		return u.inner.DoWithTLS(r, proxyURL, accountID, accountConcurrency, enableTLSFingerprint)
+	})
+}
+
+func (u *mockHTTPUpstream) do(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if u.mock != nil && req.URL != nil {
+		if _, ok := fakeupstream.MockProfileName(req.URL.Hostname()); ok {
+			body, err := drainRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+			return u.mock.Do(req, body)
+		}
+	}
+	if !u.record.Enabled || fakeupstream.Detect(req) == fakeupstream.KindUnknown {
+		return next(req)
+	}
+
+	body, err := drainRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next(req)
+	if err != nil {
+		return resp, err
+	}
+	return fakeupstream.Record(u.record.Dir, req, body, resp, u.record.MaxBodyBytes, func(path string, err error) {
+		if err != nil {
+			log.Printf("[MockUpstream] record fixture failed: %v", err)
+		}
+	}), nil
+}
+
+// drainRequestBody 读出请求体并重置为可重复读取，供录制与模拟上游解析
+func drainRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	return body, nil
+}
+
+// isMockUpstreamBaseURL 模拟上游开启时，*.mock.invalid 的 base_url 跳过白名单与格式校验
+func isMockUpstreamBaseURL(cfg *config.Config, raw string) bool {
+	if cfg == nil || !cfg.MockUpstream.Enabled {
+		return false
+	}
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	_, ok := fakeupstream.MockProfileName(parsed.Hostname())
+	return ok
+}
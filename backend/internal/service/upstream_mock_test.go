@@ -0,0 +1,91 @@
+//go:build unit
+
+package service
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/fakeupstream"
+	"github.com/stretchr/testify/require"
+)
+
+type recordInnerUpstream struct {
+	calls int
+	body  string
+}
+
+func (u *recordInnerUpstream) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	u.calls++
+	return fakeupstream.NewResponse(http.StatusOK, http.Header{"Content-Type": []string{"application/json"}}, []byte(u.body)), nil
+}
+
+func (u *recordInnerUpstream) DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, enableTLSFingerprint bool) (*http.Response, error) {
+	return u.Do(req, proxyURL, accountID, accountConcurrency)
+}
+
+func TestNewMockHTTPUpstream_DisabledReturnsInner(t *testing.T) {
+	inner := &recordInnerUpstream{}
+	cfg := &config.Config{}
+	require.Same(t, inner, NewMockHTTPUpstream(inner, cfg).(*recordInnerUpstream))
+	require.False(t, isMockUpstreamBaseURL(cfg, "https://mock.invalid"))
+}
+
+func TestMockHTTPUpstream_ServesMockHosts(t *testing.T) {
+	inner := &recordInnerUpstream{body: `{"id":"real"}`}
+	cfg := &config.Config{MockUpstream: config.MockUpstreamConfig{
+		Enabled: true,
+		Profiles: map[string]config.MockUpstreamProfile{
+			"default": {Text: "mocked", OutputTokens: 3},
+			"flaky":   {RateLimitRate: 1, RetryAfterSeconds: 10},
+		},
+	}}
+	upstream := NewMockHTTPUpstream(inner, cfg)
+	require.True(t, isMockUpstreamBaseURL(cfg, " https://flaky.mock.invalid/ "))
+	require.False(t, isMockUpstreamBaseURL(cfg, "https://api.anthropic.com"))
+
+	req, err := http.NewRequest(http.MethodPost, "https://mock.invalid/v1/messages", strings.NewReader(`{"model":"claude-sonnet-4-5","messages":[]}`))
+	require.NoError(t, err)
+	resp, err := upstream.Do(req, "", 1, 1)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), "mocked")
+
+	req, err = http.NewRequest(http.MethodPost, "https://flaky.mock.invalid/v1/messages", strings.NewReader(`{"model":"claude-sonnet-4-5"}`))
+	require.NoError(t, err)
+	resp, err = upstream.DoWithTLS(req, "", 1, 1, true)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "10", resp.Header.Get("retry-after"))
+
+	require.Zero(t, inner.calls, "mock hosts must never reach the real upstream")
+}
+
+func TestMockHTTPUpstream_RecordsRealExchanges(t *testing.T) {
+	dir := t.TempDir()
+	inner := &recordInnerUpstream{body: `{"id":"resp_1","object":"response"}`}
+	cfg := &config.Config{MockUpstream: config.MockUpstreamConfig{
+		Record: config.UpstreamRecordConfig{Enabled: true, Dir: dir, MaxBodyBytes: 1 << 20},
+	}}
+	upstream := NewMockHTTPUpstream(inner, cfg)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/responses", strings.NewReader(`{"model":"gpt-5"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	resp, err := upstream.Do(req, "", 1, 1)
+	require.NoError(t, err)
+	_, _ = io.ReadAll(resp.Body)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, 1, inner.calls)
+
+	fixtures, err := fakeupstream.LoadFixtures(dir)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 1)
+	require.Equal(t, fakeupstream.KindOpenAIResponses, fixtures[0].Kind)
+	require.Equal(t, "gpt-5", fixtures[0].Model)
+	require.NotContains(t, fixtures[0].Request.Headers, "authorization")
+}
@@ -42,6 +42,8 @@ type UsageLog struct {
 	FirstTokenMs *int
 	UserAgent    *string
 	IPAddress    *string
+	// TraceID 请求所属的分布式追踪 trace id（未携带 trace 时为空）
+	TraceID *string
 
 	// 图片生成字段
 	ImageCount int
@@ -35,6 +35,8 @@ type UserRepository interface {
 
 	UpdateBalance(ctx context.Context, id int64, amount float64) error
 	DeductBalance(ctx context.Context, id int64, amount float64) error
+	// DeductBalanceIfSufficient 余额不足时不扣费并返回 ErrInsufficientBalance
+	DeductBalanceIfSufficient(ctx context.Context, id int64, amount float64) error
 	UpdateConcurrency(ctx context.Context, id int64, amount int) error
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	RemoveGroupFromAllowedGroups(ctx context.Context, groupID int64) (int64, error)
@@ -62,13 +64,15 @@ type ChangePasswordRequest struct {
 type UserService struct {
 	userRepo             UserRepository
 	authCacheInvalidator APIKeyAuthCacheInvalidator
+	sessionService       *SessionService
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(userRepo UserRepository, authCacheInvalidator APIKeyAuthCacheInvalidator) *UserService {
+func NewUserService(userRepo UserRepository, authCacheInvalidator APIKeyAuthCacheInvalidator, sessionService *SessionService) *UserService {
 	return &UserService{
 		userRepo:             userRepo,
 		authCacheInvalidator: authCacheInvalidator,
+		sessionService:       sessionService,
 	}
 }
 
@@ -154,6 +158,9 @@ func (s *UserService) ChangePassword(ctx context.Context, userID int64, req Chan
 		return fmt.Errorf("update user: %w", err)
 	}
 
+	// TokenVersion 变更已使所有 token 失效，同步吊销会话记录
+	s.sessionService.revokeAllBestEffort(ctx, userID, "", SessionRevokeReasonPasswordChanged)
+
 	return nil
 }
 
@@ -212,6 +219,9 @@ func (s *UserService) UpdateStatus(ctx context.Context, userID int64, status str
 	if s.authCacheInvalidator != nil {
 		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
 	}
+	if status == StatusDisabled {
+		s.sessionService.revokeAllBestEffort(ctx, userID, "", SessionRevokeReasonUserDisabled)
+	}
 
 	return nil
 }
@@ -19,10 +19,20 @@ type UserSubscription struct {
 	WeeklyUsageUSD  float64
 	MonthlyUsageUSD float64
 
+	// token/请求数限额计数（限额名称 -> 当前窗口累计值）
+	DailyUsageCounters   map[string]float64
+	WeeklyUsageCounters  map[string]float64
+	MonthlyUsageCounters map[string]float64
+
 	AssignedBy *int64
 	AssignedAt time.Time
 	Notes      string
 
+	// 自动续期：到期时按分组价格从余额扣费
+	AutoRenew bool
+	// 暂停时间（仅 paused 状态有值）
+	PausedAt *time.Time
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -35,6 +45,10 @@ func (s *UserSubscription) IsActive() bool {
 	return s.Status == SubscriptionStatusActive && time.Now().Before(s.ExpiresAt)
 }
 
+func (s *UserSubscription) IsPaused() bool {
+	return s.Status == SubscriptionStatusPaused
+}
+
 func (s *UserSubscription) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
 }
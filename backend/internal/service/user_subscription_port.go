@@ -30,6 +30,19 @@ type UserSubscriptionRepository interface {
 	ResetWeeklyUsage(ctx context.Context, id int64, newWindowStart time.Time) error
 	ResetMonthlyUsage(ctx context.Context, id int64, newWindowStart time.Time) error
 	IncrementUsage(ctx context.Context, id int64, costUSD float64) error
+	// IncrementUsageCounters 原子累加 token/请求数限额计数
+	IncrementUsageCounters(ctx context.Context, id int64, delta SubscriptionUsageCounters) error
 
 	BatchUpdateExpiredStatus(ctx context.Context) (int64, error)
+
+	// 续期、变更与暂停
+	SetAutoRenew(ctx context.Context, id int64, autoRenew bool) error
+	Pause(ctx context.Context, id int64, pausedAt time.Time) error
+	Resume(ctx context.Context, id int64, newExpiresAt time.Time) error
+	// RenewExpiry 仅当订阅仍为 active 且到期时间等于 prevExpiresAt 时顺延，否则返回 ErrSubscriptionChanged
+	RenewExpiry(ctx context.Context, id int64, prevExpiresAt, newExpiresAt time.Time) error
+	// MarkExpired 仅当订阅仍为 active 且到期时间等于 expiresAt 时标记为 expired，否则返回 ErrSubscriptionChanged
+	MarkExpired(ctx context.Context, id int64, expiresAt time.Time) error
+	ChangeGroup(ctx context.Context, id, fromGroupID, groupID int64, startsAt, expiresAt time.Time) error
+	ListAutoRenewDue(ctx context.Context, before time.Time, limit int) ([]UserSubscription, error)
 }
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrWebAuthnNotEnabled        = infraerrors.BadRequest("WEBAUTHN_NOT_ENABLED", "webauthn is not enabled")
+	ErrPasskeyLoginNotEnabled    = infraerrors.BadRequest("PASSKEY_LOGIN_NOT_ENABLED", "passwordless passkey login is not enabled")
+	ErrWebAuthnNotRegistered     = infraerrors.BadRequest("WEBAUTHN_NOT_REGISTERED", "no security key is registered for this account")
+	ErrWebAuthnCeremonyExpired   = infraerrors.BadRequest("WEBAUTHN_CEREMONY_EXPIRED", "webauthn challenge expired, please try again")
+	ErrWebAuthnVerifyFailed      = infraerrors.BadRequest("WEBAUTHN_VERIFY_FAILED", "security key verification failed")
+	ErrWebAuthnCredentialExists  = infraerrors.Conflict("WEBAUTHN_CREDENTIAL_EXISTS", "this security key is already registered")
+	ErrWebAuthnCredentialMissing = infraerrors.NotFound("WEBAUTHN_CREDENTIAL_NOT_FOUND", "security key not found")
+	ErrWebAuthnTooManyKeys       = infraerrors.BadRequest("WEBAUTHN_TOO_MANY_CREDENTIALS", "too many security keys registered")
+)
+
+const (
+	webAuthnCeremonyTTL           = 5 * time.Minute
+	maxWebAuthnCredentialsPerUser = 10
+	webAuthnCredentialNameMaxLen  = 100
+)
+
+// WebAuthnCredential 用户注册的 WebAuthn 凭证
+type WebAuthnCredential struct {
+	ID           int64
+	UserID       int64
+	CredentialID string // base64url(raw id)
+	Name         string
+	Discoverable bool
+	Credential   webauthn.Credential
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
+
+// WebAuthnCredentialRepository WebAuthn 凭证持久化
+type WebAuthnCredentialRepository interface {
+	Create(ctx context.Context, credential *WebAuthnCredential) error
+	ListByUser(ctx context.Context, userID int64) ([]WebAuthnCredential, error)
+	CountByUser(ctx context.Context, userID int64) (int, error)
+	// UpdateAfterLogin 登录成功后更新签名计数等认证器状态
+	UpdateAfterLogin(ctx context.Context, credentialID string, credential webauthn.Credential, usedAt time.Time) error
+	// Delete 删除用户的凭证，未找到时返回 ErrWebAuthnCredentialMissing
+	Delete(ctx context.Context, userID, id int64) error
+}
+
+// WebAuthnCache 保存注册/登录仪式的挑战数据（一次性）
+type WebAuthnCache interface {
+	SetCeremony(ctx context.Context, key string, data *webauthn.SessionData, ttl time.Duration) error
+	// TakeCeremony 读取并删除仪式数据，不存在时返回 (nil, nil)
+	TakeCeremony(ctx context.Context, key string) (*webauthn.SessionData, error)
+}
+
+// webAuthnUser 适配 webauthn.User
+type webAuthnUser struct {
+	user        *User
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte                         { return webAuthnUserHandle(u.user.ID) }
+func (u *webAuthnUser) WebAuthnName() string                       { return u.user.Email }
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if strings.TrimSpace(u.user.Username) != "" {
+		return u.user.Username
+	}
+	return u.user.Email
+}
+
+// webAuthnUserHandle 用户句柄：用户 ID 的 8 字节大端编码（不包含邮箱等个人信息）
+func webAuthnUserHandle(userID int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(userID))
+	return b
+}
+
+func userIDFromWebAuthnHandle(handle []byte) (int64, bool) {
+	if len(handle) != 8 {
+		return 0, false
+	}
+	id := int64(binary.BigEndian.Uint64(handle))
+	return id, id > 0
+}
+
+// WebAuthnService 处理 WebAuthn / Passkey 注册与认证
+type WebAuthnService struct {
+	cfg              *config.Config
+	webAuthn         *webauthn.WebAuthn
+	credentialRepo   WebAuthnCredentialRepository
+	cache            WebAuthnCache
+	userRepo         UserRepository
+	twoFactorService *TwoFactorService
+	sessionService   *SessionService
+}
+
+// NewWebAuthnService creates a new WebAuthnService
+func NewWebAuthnService(
+	cfg *config.Config,
+	credentialRepo WebAuthnCredentialRepository,
+	cache WebAuthnCache,
+	userRepo UserRepository,
+	twoFactorService *TwoFactorService,
+	sessionService *SessionService,
+) *WebAuthnService {
+	s := &WebAuthnService{
+		cfg:              cfg,
+		credentialRepo:   credentialRepo,
+		cache:            cache,
+		userRepo:         userRepo,
+		twoFactorService: twoFactorService,
+		sessionService:   sessionService,
+	}
+	if cfg != nil && cfg.WebAuthn.Enabled {
+		wa, err := webauthn.New(&webauthn.Config{
+			RPID:          cfg.WebAuthn.RPID,
+			RPDisplayName: cfg.WebAuthn.RPDisplayName,
+			RPOrigins:     cfg.WebAuthn.RPOrigins,
+			Timeouts: webauthn.TimeoutsConfig{
+				Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: webAuthnCeremonyTTL},
+				Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: webAuthnCeremonyTTL},
+			},
+		})
+		if err != nil {
+			slog.Error("webauthn_init_failed", "error", err)
+		} else {
+			s.webAuthn = wa
+		}
+	}
+	return s
+}
+
+// IsEnabled WebAuthn 是否可用
+func (s *WebAuthnService) IsEnabled() bool {
+	return s != nil && s.webAuthn != nil
+}
+
+// IsPasswordlessEnabled 是否允许 Passkey 无密码登录
+func (s *WebAuthnService) IsPasswordlessEnabled() bool {
+	return s.IsEnabled() && s.cfg.WebAuthn.AllowPasswordless
+}
+
+// ListCredentials 列出用户的凭证
+func (s *WebAuthnService) ListCredentials(ctx context.Context, userID int64) ([]WebAuthnCredential, error) {
+	return s.credentialRepo.ListByUser(ctx, userID)
+}
+
+// BeginRegistration 开始注册新的安全密钥（需验证身份）
+func (s *WebAuthnService) BeginRegistration(ctx context.Context, userID int64, emailCode, password string) (*protocol.CredentialCreation, error) {
+	if !s.IsEnabled() {
+		return nil, ErrWebAuthnNotEnabled
+	}
+
+	waUser, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(waUser.credentials) >= maxWebAuthnCredentialsPerUser {
+		return nil, ErrWebAuthnTooManyKeys
+	}
+	if err := s.twoFactorService.VerifyIdentity(ctx, waUser.user, emailCode, password); err != nil {
+		return nil, err
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(waUser,
+		webauthn.WithExclusions(webauthn.Credentials(waUser.credentials).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+		webauthn.WithExtensions(protocol.AuthenticationExtensions{"credProps": true}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("begin webauthn registration: %w", err)
+	}
+	if err := s.cache.SetCeremony(ctx, registrationCeremonyKey(userID), session, webAuthnCeremonyTTL); err != nil {
+		return nil, fmt.Errorf("store webauthn session: %w", err)
+	}
+	return creation, nil
+}
+
+// FinishRegistration 完成注册，返回新凭证；首次启用第二因素时同时返回恢复码
+func (s *WebAuthnService) FinishRegistration(ctx context.Context, userID int64, name string, body []byte) (*WebAuthnCredential, []string, error) {
+	if !s.IsEnabled() {
+		return nil, nil, ErrWebAuthnNotEnabled
+	}
+
+	session, err := s.cache.TakeCeremony(ctx, registrationCeremonyKey(userID))
+	if err != nil || session == nil {
+		return nil, nil, ErrWebAuthnCeremonyExpired
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(body)
+	if err != nil {
+		return nil, nil, ErrWebAuthnVerifyFailed.WithCause(err)
+	}
+
+	waUser, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	credential, err := s.webAuthn.CreateCredential(waUser, *session, parsed)
+	if err != nil {
+		return nil, nil, ErrWebAuthnVerifyFailed.WithCause(err)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Security Key"
+	}
+	if len([]rune(name)) > webAuthnCredentialNameMaxLen {
+		name = string([]rune(name)[:webAuthnCredentialNameMaxLen])
+	}
+
+	record := &WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(credential.ID),
+		Name:         name,
+		Discoverable: credPropsResidentKey(parsed.ClientExtensionResults),
+		Credential:   *credential,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.credentialRepo.Create(ctx, record); err != nil {
+		return nil, nil, err
+	}
+
+	// 2FA 配置变更后吊销其他设备上的登录会话
+	s.sessionService.revokeAllBestEffort(ctx, userID, CurrentSessionIDFromContext(ctx), SessionRevokeReasonWebAuthnChanged)
+
+	return record, s.twoFactorService.ensureRecoveryCodes(ctx, userID), nil
+}
+
+// DeleteCredential 删除安全密钥（需验证身份）
+func (s *WebAuthnService) DeleteCredential(ctx context.Context, userID, credentialID int64, emailCode, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if err := s.twoFactorService.VerifyIdentity(ctx, user, emailCode, password); err != nil {
+		return err
+	}
+	if err := s.credentialRepo.Delete(ctx, userID, credentialID); err != nil {
+		return err
+	}
+
+	s.sessionService.revokeAllBestEffort(ctx, userID, CurrentSessionIDFromContext(ctx), SessionRevokeReasonWebAuthnChanged)
+	s.twoFactorService.clearRecoveryCodesIfNoFactor(ctx, userID)
+	return nil
+}
+
+// BeginLogin 作为第二因素开始认证；loginToken 为密码验证后签发的 2FA 临时令牌
+func (s *WebAuthnService) BeginLogin(ctx context.Context, userID int64, loginToken string) (*protocol.CredentialAssertion, error) {
+	if !s.IsEnabled() {
+		return nil, ErrWebAuthnNotEnabled
+	}
+
+	waUser, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(waUser.credentials) == 0 {
+		return nil, ErrWebAuthnNotRegistered
+	}
+
+	assertion, session, err := s.webAuthn.BeginLogin(waUser)
+	if err != nil {
+		return nil, fmt.Errorf("begin webauthn login: %w", err)
+	}
+	if err := s.cache.SetCeremony(ctx, loginCeremonyKey(loginToken), session, webAuthnCeremonyTTL); err != nil {
+		return nil, fmt.Errorf("store webauthn session: %w", err)
+	}
+	return assertion, nil
+}
+
+// FinishLogin 校验第二因素断言
+func (s *WebAuthnService) FinishLogin(ctx context.Context, userID int64, loginToken string, body []byte) error {
+	if !s.IsEnabled() {
+		return ErrWebAuthnNotEnabled
+	}
+
+	session, err := s.cache.TakeCeremony(ctx, loginCeremonyKey(loginToken))
+	if err != nil || session == nil {
+		return ErrWebAuthnCeremonyExpired
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(body)
+	if err != nil {
+		return ErrWebAuthnVerifyFailed.WithCause(err)
+	}
+
+	waUser, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	credential, err := s.webAuthn.ValidateLogin(waUser, *session, parsed)
+	if err != nil {
+		return ErrWebAuthnVerifyFailed.WithCause(err)
+	}
+	return s.recordUsage(ctx, userID, credential)
+}
+
+// BeginPasskeyLogin 开始无密码登录，返回仪式 ID（完成时回传）与断言选项
+func (s *WebAuthnService) BeginPasskeyLogin(ctx context.Context) (string, *protocol.CredentialAssertion, error) {
+	if !s.IsPasswordlessEnabled() {
+		return "", nil, ErrPasskeyLoginNotEnabled
+	}
+
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("begin passkey login: %w", err)
+	}
+
+	ceremonyID, err := generateRandomToken(32)
+	if err != nil {
+		return "", nil, fmt.Errorf("generate ceremony id: %w", err)
+	}
+	if err := s.cache.SetCeremony(ctx, passkeyCeremonyKey(ceremonyID), session, webAuthnCeremonyTTL); err != nil {
+		return "", nil, fmt.Errorf("store webauthn session: %w", err)
+	}
+	return ceremonyID, assertion, nil
+}
+
+// FinishPasskeyLogin 完成无密码登录，返回通过验证的用户
+// Passkey 本身已包含持有因素与用户验证（生物识别/PIN），因此不再要求额外的 2FA
+func (s *WebAuthnService) FinishPasskeyLogin(ctx context.Context, ceremonyID string, body []byte) (*User, error) {
+	if !s.IsPasswordlessEnabled() {
+		return nil, ErrPasskeyLoginNotEnabled
+	}
+
+	session, err := s.cache.TakeCeremony(ctx, passkeyCeremonyKey(ceremonyID))
+	if err != nil || session == nil {
+		return nil, ErrWebAuthnCeremonyExpired
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(body)
+	if err != nil {
+		return nil, ErrWebAuthnVerifyFailed.WithCause(err)
+	}
+
+	var loaded *webAuthnUser
+	handler := func(_, userHandle []byte) (webauthn.User, error) {
+		userID, ok := userIDFromWebAuthnHandle(userHandle)
+		if !ok {
+			return nil, errors.New("invalid user handle")
+		}
+		u, err := s.loadUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		loaded = u
+		return u, nil
+	}
+
+	_, credential, err := s.webAuthn.ValidatePasskeyLogin(handler, *session, parsed)
+	if err != nil || loaded == nil {
+		return nil, ErrWebAuthnVerifyFailed.WithCause(err)
+	}
+	if !loaded.user.IsActive() {
+		return nil, ErrUserNotActive
+	}
+	if err := s.recordUsage(ctx, loaded.user.ID, credential); err != nil {
+		return nil, err
+	}
+	return loaded.user, nil
+}
+
+func (s *WebAuthnService) recordUsage(ctx context.Context, userID int64, credential *webauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		// 签名计数回退，可能是被克隆的认证器
+		slog.Warn("webauthn_clone_warning", "user_id", userID)
+		return ErrWebAuthnVerifyFailed
+	}
+	if err := s.credentialRepo.UpdateAfterLogin(ctx, base64.RawURLEncoding.EncodeToString(credential.ID), *credential, time.Now()); err != nil {
+		slog.Warn("webauthn_update_credential_failed", "user_id", userID, "error", err)
+	}
+	return nil
+}
+
+func (s *WebAuthnService) loadUser(ctx context.Context, userID int64) (*webAuthnUser, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	records, err := s.credentialRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list webauthn credentials: %w", err)
+	}
+	credentials := make([]webauthn.Credential, 0, len(records))
+	for i := range records {
+		credentials = append(credentials, records[i].Credential)
+	}
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// credPropsResidentKey 读取 credProps 扩展结果，判断是否为可发现凭证
+func credPropsResidentKey(results protocol.AuthenticationExtensionsClientOutputs) bool {
+	props, ok := results["credProps"].(map[string]any)
+	if !ok {
+		return false
+	}
+	rk, _ := props["rk"].(bool)
+	return rk
+}
+
+func registrationCeremonyKey(userID int64) string {
+	return fmt.Sprintf("reg:%d", userID)
+}
+
+func loginCeremonyKey(loginToken string) string {
+	return "login:" + loginToken
+}
+
+func passkeyCeremonyKey(ceremonyID string) string {
+	return "passkey:" + ceremonyID
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
@@ -73,8 +74,56 @@ func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpirySe
 }
 
 // ProvideSubscriptionExpiryService creates and starts SubscriptionExpiryService.
-func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository) *SubscriptionExpiryService {
-	svc := NewSubscriptionExpiryService(userSubRepo, time.Minute)
+func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository, subscriptionService *SubscriptionService, redisClient *redis.Client) *SubscriptionExpiryService {
+	svc := NewSubscriptionExpiryService(userSubRepo, subscriptionService, redisClient, time.Minute)
+	svc.Start()
+	return svc
+}
+
+// ProvideSubscriptionService creates SubscriptionService with renewal and plan change dependencies.
+func ProvideSubscriptionService(
+	groupRepo GroupRepository,
+	userSubRepo UserSubscriptionRepository,
+	billingCacheService *BillingCacheService,
+	entClient *dbent.Client,
+	userRepo UserRepository,
+	historyRepo SubscriptionHistoryRepository,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+) *SubscriptionService {
+	svc := NewSubscriptionService(groupRepo, userSubRepo, billingCacheService)
+	svc.SetBillingDependencies(entClient, userRepo, historyRepo, authCacheInvalidator)
+	return svc
+}
+
+// ProvideSessionService creates SessionService and starts expired session cleanup.
+func ProvideSessionService(repo UserSessionRepository, cache UserSessionCache, cfg *config.Config) *SessionService {
+	svc := NewSessionService(repo, cache, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideProxyPoolService creates ProxyPoolService and starts background health checks.
+func ProvideProxyPoolService(
+	poolRepo ProxyPoolRepository,
+	proxyRepo ProxyRepository,
+	latencyCache ProxyLatencyCache,
+	prober ProxyExitInfoProber,
+	cfg *config.Config,
+) *ProxyPoolService {
+	svc := NewProxyPoolService(poolRepo, proxyRepo, latencyCache, prober, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideSpendAnomalyService creates SpendAnomalyService and starts periodic detection.
+func ProvideSpendAnomalyService(
+	repo SpendAnomalyRepository,
+	apiKeyRepo APIKeyRepository,
+	userRepo UserRepository,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	cfg *config.Config,
+) *SpendAnomalyService {
+	svc := NewSpendAnomalyService(repo, apiKeyRepo, userRepo, authCacheInvalidator, cfg)
 	svc.Start()
 	return svc
 }
@@ -128,11 +177,15 @@ func ProvideRateLimitService(
 	timeoutCounterCache TimeoutCounterCache,
 	settingService *SettingService,
 	tokenCacheInvalidator TokenCacheInvalidator,
+	outcomeCache AccountOutcomeCache,
+	circuitBreaker *CircuitBreakerService,
 ) *RateLimitService {
 	svc := NewRateLimitService(accountRepo, usageRepo, cfg, geminiQuotaService, tempUnschedCache)
 	svc.SetTimeoutCounterCache(timeoutCounterCache)
 	svc.SetSettingService(settingService)
 	svc.SetTokenCacheInvalidator(tokenCacheInvalidator)
+	svc.SetAccountOutcomeCache(outcomeCache)
+	svc.SetCircuitBreaker(circuitBreaker)
 	return svc
 }
 
@@ -170,9 +223,13 @@ func ProvideOpsAlertEvaluatorService(
 	opsRepo OpsRepository,
 	emailService *EmailService,
 	redisClient *redis.Client,
+	spendAnomalyService *SpendAnomalyService,
+	capacityForecastService *CapacityForecastService,
 	cfg *config.Config,
 ) *OpsAlertEvaluatorService {
 	svc := NewOpsAlertEvaluatorService(opsService, opsRepo, emailService, redisClient, cfg)
+	svc.SetSpendAnomalyService(spendAnomalyService)
+	svc.SetCapacityForecastService(capacityForecastService)
 	svc.Start()
 	return svc
 }
@@ -202,6 +259,19 @@ func ProvideOpsScheduledReportService(
 	return svc
 }
 
+// ProvideClusterService creates ClusterService and starts the node heartbeat.
+func ProvideClusterService(
+	cache ClusterCache,
+	updateService *UpdateService,
+	opsService *OpsService,
+	buildInfo BuildInfo,
+	cfg *config.Config,
+) *ClusterService {
+	svc := NewClusterService(cache, updateService, opsService, buildInfo, cfg)
+	svc.Start()
+	return svc
+}
+
 // ProvideAPIKeyAuthCacheInvalidator 提供 API Key 认证缓存失效能力
 func ProvideAPIKeyAuthCacheInvalidator(apiKeyService *APIKeyService) APIKeyAuthCacheInvalidator {
 	// Start Pub/Sub subscriber for L1 cache invalidation across instances
@@ -219,6 +289,7 @@ var ProviderSet = wire.NewSet(
 	NewGroupService,
 	NewAccountService,
 	NewProxyService,
+	ProvideProxyPoolService,
 	NewRedeemService,
 	NewPromoService,
 	NewUsageService,
@@ -228,6 +299,11 @@ var ProviderSet = wire.NewSet(
 	NewBillingCacheService,
 	NewAdminService,
 	NewGatewayService,
+	NewMessageBatchService,
+	NewImageGenerationService,
+	NewContentPolicyService,
+	ProvideSpendAnomalyService,
+	NewCapacityForecastService,
 	NewOpenAIGatewayService,
 	NewOAuthService,
 	NewOpenAIOAuthService,
@@ -243,6 +319,8 @@ var ProviderSet = wire.NewSet(
 	NewClaudeTokenProvider,
 	NewAntigravityGatewayService,
 	ProvideRateLimitService,
+	NewAccountSelectionService,
+	NewCircuitBreakerService,
 	NewAccountUsageService,
 	NewAccountTestService,
 	NewSettingService,
@@ -255,12 +333,13 @@ var ProviderSet = wire.NewSet(
 	NewEmailService,
 	ProvideEmailQueueService,
 	NewTurnstileService,
-	NewSubscriptionService,
+	ProvideSubscriptionService,
 	ProvideConcurrencyService,
 	ProvideSchedulerSnapshotService,
 	NewIdentityService,
 	NewCRSSyncService,
 	ProvideUpdateService,
+	ProvideClusterService,
 	ProvideTokenRefreshService,
 	ProvideAccountExpiryService,
 	ProvideSubscriptionExpiryService,
@@ -272,4 +351,7 @@ var ProviderSet = wire.NewSet(
 	NewUserAttributeService,
 	NewUsageCache,
 	NewTotpService,
+	NewTwoFactorService,
+	NewWebAuthnService,
+	ProvideSessionService,
 )
@@ -2,7 +2,9 @@ package logredact
 
 import (
 	"encoding/json"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 // maxRedactDepth 限制递归深度以防止栈溢出
@@ -48,6 +50,47 @@ func RedactJSON(raw []byte, extraKeys ...string) string {
 	return string(encoded)
 }
 
+// excerptSensitiveKeys 请求/响应体摘录额外脱敏的字段
+var excerptSensitiveKeys = []string{"authorization", "api_key", "apikey", "x-api-key", "key", "secret", "token"}
+
+// inlineCredentialPatterns 非 JSON 文本中的凭据形式（Bearer token、sk- API Key、Google API Key/OAuth token）
+var inlineCredentialPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`),
+	regexp.MustCompile(`sk-[A-Za-z0-9_-]{8,}`),
+	regexp.MustCompile(`AIza[0-9A-Za-z_-]{20,}`),
+	regexp.MustCompile(`ya29\.[0-9A-Za-z._-]+`),
+}
+
+// Excerpt 返回写入日志的请求/响应体摘录：JSON 按敏感字段脱敏，非 JSON 文本遮蔽内嵌凭据，结果截断到 maxLen 字节（<=0 不截断）
+func Excerpt(raw []byte, maxLen int, extraKeys ...string) string {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" {
+		return ""
+	}
+	var out string
+	if json.Valid([]byte(trimmed)) {
+		out = RedactJSON([]byte(trimmed), append(extraKeys, excerptSensitiveKeys...)...)
+	} else {
+		out = trimmed
+	}
+	for _, re := range inlineCredentialPatterns {
+		out = re.ReplaceAllStringFunc(out, func(m string) string {
+			if sub := re.FindStringSubmatch(m); len(sub) > 1 {
+				return sub[1] + "***"
+			}
+			return "***"
+		})
+	}
+	if maxLen > 0 && len(out) > maxLen {
+		cut := maxLen
+		for cut > 0 && !utf8.RuneStart(out[cut]) {
+			cut--
+		}
+		out = out[:cut] + "...(truncated)"
+	}
+	return out
+}
+
 func buildKeySet(extraKeys []string) map[string]struct{} {
 	keys := make(map[string]struct{}, len(defaultSensitiveKeys)+len(extraKeys))
 	for k := range defaultSensitiveKeys {
@@ -0,0 +1,31 @@
+-- 045_add_user_sessions.sql
+-- 用户登录会话表：记录每次登录签发的 JWT 会话，支持列出与吊销
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    device_name VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_active_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    revoke_reason VARCHAR(50) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sessions_session_id
+    ON user_sessions(session_id);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_active
+    ON user_sessions(user_id, last_active_at DESC)
+    WHERE revoked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expires_at
+    ON user_sessions(expires_at);
+
+COMMENT ON TABLE user_sessions IS '用户登录会话（JWT sid 对应的服务端记录）';
+COMMENT ON COLUMN user_sessions.session_id IS 'JWT 中 sid 声明对应的随机会话 ID';
+COMMENT ON COLUMN user_sessions.expires_at IS '会话（刷新窗口）过期时间，每次刷新 token 时滑动延长';
+COMMENT ON COLUMN user_sessions.revoke_reason IS '吊销原因：logout/user_revoked/admin_force_logout/password_reset 等';
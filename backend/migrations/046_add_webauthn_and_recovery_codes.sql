@@ -0,0 +1,39 @@
+-- 046_add_webauthn_and_recovery_codes.sql
+-- WebAuthn/Passkey 凭证与双因素恢复码
+
+CREATE TABLE IF NOT EXISTS user_webauthn_credentials (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id VARCHAR(512) NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    credential JSONB NOT NULL,
+    discoverable BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_webauthn_credentials_credential_id
+    ON user_webauthn_credentials(credential_id);
+
+CREATE INDEX IF NOT EXISTS idx_user_webauthn_credentials_user_id
+    ON user_webauthn_credentials(user_id);
+
+COMMENT ON TABLE user_webauthn_credentials IS '用户 WebAuthn/Passkey 凭证';
+COMMENT ON COLUMN user_webauthn_credentials.credential_id IS '凭证 ID（base64url 编码）';
+COMMENT ON COLUMN user_webauthn_credentials.credential IS '凭证完整数据（公钥、签名计数、flags 等，JSON）';
+COMMENT ON COLUMN user_webauthn_credentials.discoverable IS '是否为可发现凭证（可用于无密码登录）';
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_recovery_codes_user_hash
+    ON user_recovery_codes(user_id, code_hash);
+
+COMMENT ON TABLE user_recovery_codes IS '双因素认证一次性恢复码';
+COMMENT ON COLUMN user_recovery_codes.code_hash IS '恢复码 SHA-256 哈希（hex），明文仅在生成时返回一次';
+COMMENT ON COLUMN user_recovery_codes.used_at IS '使用时间，非空表示已失效';
@@ -0,0 +1,3 @@
+-- Add account_selection_strategy field to groups table
+-- 空字符串表示默认策略（优先级 + fallback_selection_mode）
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS account_selection_strategy VARCHAR(50) NOT NULL DEFAULT '';
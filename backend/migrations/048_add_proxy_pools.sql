@@ -0,0 +1,37 @@
+-- 048_add_proxy_pools.sql
+-- 代理池：一组代理的命名集合，账户可绑定代理池替代单个代理
+
+CREATE TABLE IF NOT EXISTS proxy_pools (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_proxy_pools_status
+    ON proxy_pools(status)
+    WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS proxy_pool_members (
+    pool_id BIGINT NOT NULL REFERENCES proxy_pools(id) ON DELETE CASCADE,
+    proxy_id BIGINT NOT NULL REFERENCES proxies(id) ON DELETE CASCADE,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pool_id, proxy_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_proxy_pool_members_proxy_id
+    ON proxy_pool_members(proxy_id);
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS proxy_pool_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_proxy_pool_id
+    ON accounts(proxy_pool_id);
+
+COMMENT ON TABLE proxy_pools IS '代理池：账户绑定后按健康状态在成员间选择出口代理';
+COMMENT ON TABLE proxy_pool_members IS '代理池成员关系';
+COMMENT ON COLUMN proxy_pool_members.position IS '成员在池内的顺序（仅用于展示）';
+COMMENT ON COLUMN accounts.proxy_pool_id IS '绑定的代理池 ID（与 proxy_id 互斥）';
@@ -0,0 +1,28 @@
+-- 049_add_message_batches.sql
+-- Anthropic Message Batches：记录批次归属（API Key）与所在上游账号，结果按批量折扣计费
+
+CREATE TABLE IF NOT EXISTS message_batches (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id VARCHAR(128) NOT NULL,
+    api_key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL,
+    group_id BIGINT,
+    account_id BIGINT NOT NULL,
+    processing_status VARCHAR(20) NOT NULL DEFAULT 'in_progress',
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    results_billed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_message_batches_batch_id
+    ON message_batches(batch_id);
+
+CREATE INDEX IF NOT EXISTS idx_message_batches_api_key_id
+    ON message_batches(api_key_id, id DESC);
+
+COMMENT ON TABLE message_batches IS 'Anthropic Message Batches 归属记录';
+COMMENT ON COLUMN message_batches.batch_id IS '上游批次 ID（msgbatch_...）';
+COMMENT ON COLUMN message_batches.account_id IS '创建批次的上游账号，后续查询/取消/结果均固定使用该账号';
+COMMENT ON COLUMN message_batches.payload IS '最近一次从上游获取的批次对象';
+COMMENT ON COLUMN message_batches.results_billed_at IS '结果计费完成时间';
@@ -0,0 +1,3 @@
+-- Add image_models allowlist to groups table
+-- 空数组/NULL 表示不限制图片生成模型；元素支持末尾 * 通配符（如 "gpt-image-*"）
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS image_models JSONB;
@@ -0,0 +1,47 @@
+-- 051_add_content_policy.sql
+-- 内容策略：管理员定义的关键词/正则规则、命中记录，以及分组级命中动作
+
+CREATE TABLE IF NOT EXISTS content_policy_rules (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    match_type VARCHAR(20) NOT NULL,
+    pattern TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS content_policy_hits (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    api_key_id BIGINT NOT NULL DEFAULT 0,
+    group_id BIGINT,
+    rule_id BIGINT,
+    rule_name VARCHAR(100) NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    platform VARCHAR(50) NOT NULL DEFAULT '',
+    model VARCHAR(100) NOT NULL DEFAULT '',
+    excerpt TEXT NOT NULL DEFAULT '',
+    categories JSONB,
+    reviewed_at TIMESTAMPTZ,
+    reviewed_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_policy_hits_user_created
+    ON content_policy_hits(user_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_content_policy_hits_created
+    ON content_policy_hits(created_at);
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS content_policy_action VARCHAR(20) NOT NULL DEFAULT '';
+
+COMMENT ON TABLE content_policy_rules IS '内容策略规则：全局生效，命中后的动作由分组决定';
+COMMENT ON COLUMN content_policy_rules.match_type IS '匹配方式：keyword（不区分大小写子串）/ regex（RE2）';
+COMMENT ON TABLE content_policy_hits IS '内容策略命中记录，供管理员审查';
+COMMENT ON COLUMN content_policy_hits.source IS '命中来源：rule / moderation';
+COMMENT ON COLUMN content_policy_hits.action IS '实际执行的动作：block / log / strip';
+COMMENT ON COLUMN content_policy_hits.rule_name IS '命中时的规则名称（规则删除后仍保留）';
+COMMENT ON COLUMN groups.content_policy_action IS '内容策略命中动作：空=不检查，block / log / strip';
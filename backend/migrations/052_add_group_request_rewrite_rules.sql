@@ -0,0 +1,3 @@
+-- Add request_rewrite_rules to groups table
+-- 按顺序应用于 Claude/Responses/Gemini 请求的声明式改写规则（JSON 数组），NULL 表示不改写
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS request_rewrite_rules JSONB;
@@ -0,0 +1,6 @@
+-- Add model aliases and model access lists to groups table
+-- model_aliases: {"别名": "实际模型"}；allowed_models/denied_models: 模型模式数组（支持末尾 * 通配符）
+-- 均为 NULL 表示不限制；黑名单优先于白名单
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS model_aliases JSONB;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS allowed_models JSONB;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS denied_models JSONB;
@@ -0,0 +1,29 @@
+-- Subscription auto-renewal, plan changes and pause/resume
+-- groups.subscription_price: 每个 default_validity_days 周期的续期价格（NULL 表示不支持自助续期/变更）
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS subscription_price DECIMAL(20,8);
+
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS auto_renew BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS paused_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_user_subscriptions_auto_renew_expires
+    ON user_subscriptions (expires_at)
+    WHERE auto_renew = TRUE AND deleted_at IS NULL;
+
+-- 订阅变更历史：续期（成功/失败）、升降级、暂停/恢复
+CREATE TABLE IF NOT EXISTS subscription_history (
+    id                  BIGSERIAL PRIMARY KEY,
+    subscription_id     BIGINT NOT NULL,
+    user_id             BIGINT NOT NULL,
+    action              VARCHAR(20) NOT NULL,
+    from_group_id       BIGINT,
+    to_group_id         BIGINT,
+    amount              DECIMAL(20,8) NOT NULL DEFAULT 0,
+    credit              DECIMAL(20,8) NOT NULL DEFAULT 0,
+    previous_expires_at TIMESTAMPTZ,
+    new_expires_at      TIMESTAMPTZ,
+    note                TEXT NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_history_subscription ON subscription_history (subscription_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_subscription_history_user ON subscription_history (user_id, created_at DESC);
@@ -0,0 +1,8 @@
+-- Token / request-count subscription limits per window and model family
+-- groups.usage_limits: [{"name","window","metric","limit","models",...weights}]，NULL 表示仅按 USD 限额
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS usage_limits JSONB;
+
+-- 订阅窗口内的限额计数：{"限额名称": 累计值}，随 daily/weekly/monthly 窗口重置
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS daily_usage_counters JSONB;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS weekly_usage_counters JSONB;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS monthly_usage_counters JSONB;
@@ -0,0 +1,41 @@
+-- 056_add_spend_anomaly_flags.sql
+-- 消费异常检测：按用户/API Key 对比滚动基线发现异常消费、陌生客户端与模型组合突变，命中记录进入管理员审查队列
+
+CREATE TABLE IF NOT EXISTS spend_anomaly_flags (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    api_key_id BIGINT NOT NULL DEFAULT 0,
+    kind VARCHAR(30) NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    current_value DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    baseline_value DECIMAL(20, 10) NOT NULL DEFAULT 0,
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end TIMESTAMPTZ NOT NULL,
+    details JSONB,
+    action_state JSONB,
+    review_note TEXT NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMPTZ,
+    reviewed_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 同一对象同一类型同时只保留一条待审查记录（多实例并发检测时依赖此约束去重）
+CREATE UNIQUE INDEX IF NOT EXISTS uq_spend_anomaly_flags_pending
+    ON spend_anomaly_flags(user_id, api_key_id, kind)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_spend_anomaly_flags_status_created
+    ON spend_anomaly_flags(status, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_spend_anomaly_flags_user_created
+    ON spend_anomaly_flags(user_id, created_at);
+
+COMMENT ON TABLE spend_anomaly_flags IS '消费异常检测命中记录（管理员审查队列）';
+COMMENT ON COLUMN spend_anomaly_flags.api_key_id IS '命中的 API Key，0 表示用户级命中';
+COMMENT ON COLUMN spend_anomaly_flags.kind IS '异常类型：spend_spike / new_ip / new_user_agent / model_mix';
+COMMENT ON COLUMN spend_anomaly_flags.action IS '已执行的处置：alert / disable_key / lower_concurrency';
+COMMENT ON COLUMN spend_anomaly_flags.status IS '审查状态：pending / confirmed / dismissed';
+COMMENT ON COLUMN spend_anomaly_flags.current_value IS '当前窗口取值（消费为美元，新客户端为数量，模型组合为偏移度 0-1）';
+COMMENT ON COLUMN spend_anomaly_flags.baseline_value IS '基线取值（消费为基线小时均值）';
+COMMENT ON COLUMN spend_anomaly_flags.action_state IS '处置前状态（被停用的 Key、原并发数），驳回时用于恢复';
@@ -0,0 +1,11 @@
+-- 057_ops_alert_rule_expressions.sql
+-- 运维告警规则表达式：支持 聚合(指标{标签过滤}) by (分组) 比较 阈值 形式的规则，按分组序列独立触发/恢复
+-- 设置 expression 时 metric_type 为 'expression'，operator/threshold 同步为表达式中的比较部分（便于列表展示）
+-- 分组规则产生的告警事件在 dimensions.series_key 中记录所属序列
+
+ALTER TABLE ops_alert_rules ADD COLUMN IF NOT EXISTS expression TEXT;
+
+COMMENT ON COLUMN ops_alert_rules.expression IS '规则表达式，如 p95(ttft_ms{platform="anthropic"}) by (account_id) > 8000';
+
+CREATE INDEX IF NOT EXISTS idx_ops_alert_events_rule_series
+    ON ops_alert_events (rule_id, (dimensions->>'series_key'), fired_at DESC);
@@ -0,0 +1,12 @@
+-- 058_add_trace_id.sql
+-- 分布式追踪：使用记录与错误日志保存请求所属的 W3C trace id（32 位十六进制），便于从日志跳转到追踪后端
+-- 未启用追踪且客户端未携带 traceparent 时为空
+
+ALTER TABLE usage_logs ADD COLUMN IF NOT EXISTS trace_id VARCHAR(32);
+ALTER TABLE ops_error_logs ADD COLUMN IF NOT EXISTS trace_id VARCHAR(32);
+
+COMMENT ON COLUMN usage_logs.trace_id IS 'W3C trace id of the gateway request';
+COMMENT ON COLUMN ops_error_logs.trace_id IS 'W3C trace id of the gateway request';
+
+CREATE INDEX IF NOT EXISTS idx_usage_logs_trace_id ON usage_logs (trace_id) WHERE trace_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_ops_error_logs_trace_id ON ops_error_logs (trace_id) WHERE trace_id IS NOT NULL;
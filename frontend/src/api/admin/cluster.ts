@@ -0,0 +1,106 @@
+/**
+ * Cluster API endpoints for admin operations
+ * Node heartbeats, leader lock holders and rolling update/restart
+ */
+
+import { apiClient } from '../client'
+
+export type ClusterRolloutAction = 'update' | 'restart'
+
+export interface ClusterCommandResult {
+  id: string
+  action: ClusterRolloutAction
+  status: 'restarting' | 'up_to_date' | 'failed'
+  error?: string
+  at: string
+}
+
+export interface ClusterNode {
+  node_id: string
+  instance_id: string
+  hostname: string
+  version: string
+  build_type: string
+  config_hash: string
+  started_at: string
+  last_heartbeat: string
+  in_flight_requests: number
+  requests_total: number
+  requests_per_second: number
+  goroutines: number
+  heap_alloc_bytes: number
+  last_command?: ClusterCommandResult
+  online: boolean
+  self: boolean
+  uptime_seconds: number
+  heartbeat_age_seconds: number
+  held_locks: string[]
+}
+
+export interface ClusterLock {
+  key: string
+  holder_node_id?: string
+  holder_instance_id?: string
+}
+
+export interface ClusterRolloutStep {
+  node_id: string
+  status: 'pending' | 'running' | 'restarting' | 'done' | 'failed' | 'skipped'
+  from_version: string
+  to_version?: string
+  error?: string
+  started_at?: string
+  finished_at?: string
+}
+
+export interface ClusterRollout {
+  id: string
+  action: ClusterRolloutAction
+  status: 'running' | 'completed' | 'failed'
+  initiator_node_id: string
+  error?: string
+  started_at: string
+  finished_at?: string
+  steps: ClusterRolloutStep[]
+}
+
+export interface ClusterOverview {
+  self_node_id: string
+  nodes: ClusterNode[]
+  locks: ClusterLock[]
+  config_hash_consistent: boolean
+  version_consistent: boolean
+  rollout?: ClusterRollout
+}
+
+/**
+ * Get all registered nodes, leader lock holders and the latest rollout
+ */
+export async function getOverview(): Promise<ClusterOverview> {
+  const { data } = await apiClient.get<ClusterOverview>('/admin/cluster')
+  return data
+}
+
+/**
+ * Get the progress of the latest rollout
+ */
+export async function getRollout(): Promise<ClusterRollout | null> {
+  const { data } = await apiClient.get<ClusterRollout | null>('/admin/cluster/rollout')
+  return data
+}
+
+/**
+ * Start a cluster-wide update/restart, one node at a time
+ */
+export async function startRollout(action: ClusterRolloutAction): Promise<ClusterRollout> {
+  const { data } = await apiClient.post<ClusterRollout>('/admin/cluster/rollout', { action })
+  return data
+}
+
+export const clusterAPI = {
+  getOverview,
+  getRollout,
+  startRollout
+}
+
+export default clusterAPI
@@ -12,6 +12,7 @@ import redeemAPI from './redeem'
 import promoAPI from './promo'
 import settingsAPI from './settings'
 import systemAPI from './system'
+import clusterAPI from './cluster'
 import subscriptionsAPI from './subscriptions'
 import usageAPI from './usage'
 import geminiAPI from './gemini'
@@ -32,6 +33,7 @@ export const adminAPI = {
   promo: promoAPI,
   settings: settingsAPI,
   system: systemAPI,
+  cluster: clusterAPI,
   subscriptions: subscriptionsAPI,
   usage: usageAPI,
   gemini: geminiAPI,
@@ -50,6 +52,7 @@ export {
   promoAPI,
   settingsAPI,
   systemAPI,
+  clusterAPI,
   subscriptionsAPI,
   usageAPI,
   geminiAPI,
@@ -678,6 +678,13 @@ export type MetricType =
   | 'account_error_count'
   | 'account_error_ratio'
   | 'overload_account_count'
+  | 'spend_usd'
+  | 'max_user_spend_usd'
+  | 'max_api_key_spend_usd'
+  | 'spend_anomaly_pending_count'
+  | 'group_hours_to_exhaustion'
+  | 'group_recommended_accounts'
+  | 'expression'
 export type Operator = '>' | '>=' | '<' | '<=' | '==' | '!='
 
 export interface AlertRule {
@@ -694,11 +701,68 @@ export interface AlertRule {
   cooldown_minutes: number
   notify_email: boolean
   filters?: Record<string, any>
+  // e.g. p95(ttft_ms{platform="anthropic"}) by (account_id) > 8000; replaces metric_type/operator/threshold
+  expression?: string
   created_at?: string
   updated_at?: string
   last_triggered_at?: string | null
 }
 
+export interface AlertExpressionMatcher {
+  label: string
+  op: '=' | '!=' | '=~' | '!~'
+  value: string
+}
+
+export interface AlertExpression {
+  aggregation: string
+  metric: string
+  matchers?: AlertExpressionMatcher[]
+  group_by?: string[]
+  operator: Operator
+  threshold: number
+}
+
+export interface AlertSeriesPoint {
+  labels?: Record<string, string>
+  bucket_start: string
+  value: number
+}
+
+export interface AlertPreviewSeries {
+  series_key: string
+  labels?: Record<string, string>
+  points: AlertSeriesPoint[]
+  fired_count: number
+}
+
+export interface AlertPreviewEvent {
+  series_key: string
+  labels?: Record<string, string>
+  fired_at: string
+  resolved_at?: string | null
+  metric_value: number
+  peak_value: number
+}
+
+export interface AlertRulePreview {
+  expression: string
+  parsed: AlertExpression
+  start_time: string
+  end_time: string
+  step_minutes: number
+  series_total: number
+  series: AlertPreviewSeries[]
+  events: AlertPreviewEvent[]
+}
+
+export interface AlertExpressionRequest {
+  expression: string
+  window_minutes?: number
+  sustained_minutes?: number
+  cooldown_minutes?: number
+}
+
 export interface AlertEvent {
   id: number
   rule_id: number
@@ -817,6 +881,7 @@ export interface OpsErrorLog {
 
   client_request_id: string
   request_id: string
+  trace_id?: string
   message: string
 
   user_id?: number | null
@@ -966,6 +1031,7 @@ export type OpsErrorListQueryParams = {
   view?: OpsErrorListView
 
   q?: string
+  trace_id?: string
   status_codes?: string
   status_codes_other?: string
 }
@@ -1075,6 +1141,128 @@ export async function deleteAlertRule(id: number): Promise<void> {
   await apiClient.delete(`/admin/ops/alert-rules/${id}`)
 }
 
+export async function validateAlertExpression(
+  expression: string
+): Promise<{ expression: string; parsed: AlertExpression }> {
+  const { data } = await apiClient.post<{ expression: string; parsed: AlertExpression }>(
+    '/admin/ops/alert-rules/validate',
+    { expression }
+  )
+  return data
+}
+
+export async function previewAlertExpression(req: AlertExpressionRequest): Promise<AlertRulePreview> {
+  const { data } = await apiClient.post<AlertRulePreview>('/admin/ops/alert-rules/preview', req)
+  return data
+}
+
+export type OpsDryRunFormat = 'messages' | 'openai_responses' | 'gemini_v1beta'
+
+export interface OpsDryRunRequest {
+  account_ids: number[]
+  format: OpsDryRunFormat
+  /** Required for gemini_v1beta (model and streaming come from the URL) */
+  model?: string
+  stream?: boolean
+  headers?: Record<string, string>
+  body: Record<string, unknown>
+}
+
+export interface OpsCapturedUpstreamRequest {
+  method: string
+  url: string
+  headers: Record<string, string>
+  body?: unknown
+  body_text?: string
+  proxy_used: boolean
+}
+
+export interface OpsDryRunResult {
+  account_id: number
+  account_name: string
+  platform: string
+  account_type: string
+  upstream_requests: OpsCapturedUpstreamRequest[] | null
+  status_code: number
+  response_preview?: string
+  response_truncated: boolean
+  error?: string
+}
+
+export async function dryRunGatewayRequest(req: OpsDryRunRequest): Promise<OpsDryRunResult[]> {
+  const { data } = await apiClient.post<{ results: OpsDryRunResult[] }>('/admin/ops/dry-run', req)
+  return data.results || []
+}
+
+export type CapacityStatus = 'ok' | 'warning' | 'critical' | 'unbounded' | 'no_data'
+
+export interface CapacityQuotaWindow {
+  name: string
+  scope?: string
+  utilization: number
+  window_hours: number
+  resets_at?: string
+  used_requests: number
+  capacity_requests: number
+  remaining_requests: number
+  estimated: boolean
+  imputed?: boolean
+}
+
+export interface AccountCapacityForecast {
+  account_id: number
+  name: string
+  platform: string
+  type: string
+  unbounded: boolean
+  binding_window?: string
+  remaining_requests: number
+  sustainable_requests_per_hour: number
+  rate_limited_until?: string
+  windows: CapacityQuotaWindow[] | null
+  error?: string
+}
+
+export interface GroupCapacityForecast {
+  group_id: number
+  group_name: string
+  platform: string
+  status: CapacityStatus
+  account_count: number
+  bounded_accounts: number
+  unbounded_accounts: number
+  unknown_accounts: number
+  demand_per_hour: number
+  demand_last_hour: number
+  demand_average_per_hour: number
+  remaining_requests: number
+  sustainable_requests_per_hour: number
+  hours_to_exhaustion: number | null
+  exhausts_at?: string
+  recommended_accounts: number
+  active_scopes?: string[]
+  accounts: AccountCapacityForecast[]
+  generated_at: string
+}
+
+export interface CapacityForecastReport {
+  horizon_hours: number
+  generated_at: string
+  groups: GroupCapacityForecast[] | null
+}
+
+export async function getCapacityForecast(refresh = false): Promise<CapacityForecastReport> {
+  const { data } = await apiClient.get<CapacityForecastReport>('/admin/capacity-forecast', {
+    params: refresh ? { refresh: true } : undefined
+  })
+  return data
+}
+
+export async function getGroupCapacityForecast(groupId: number): Promise<GroupCapacityForecast> {
+  const { data } = await apiClient.get<GroupCapacityForecast>(`/admin/capacity-forecast/${groupId}`)
+  return data
+}
+
 export interface AlertEventsQuery {
   limit?: number
   status?: string
@@ -1193,10 +1381,15 @@ export const opsAPI = {
   createAlertRule,
   updateAlertRule,
   deleteAlertRule,
+  validateAlertExpression,
+  previewAlertExpression,
   listAlertEvents,
   getAlertEvent,
   updateAlertEventStatus,
   createAlertSilence,
+  dryRunGatewayRequest,
+  getCapacityForecast,
+  getGroupCapacityForecast,
   getEmailNotificationConfig,
   updateEmailNotificationConfig,
   getAlertRuntimeSettings,
@@ -70,12 +70,35 @@ export async function restartService(): Promise<{ message: string }> {
   return data
 }
 
+export interface LogLevelInfo {
+  level: string
+  format: string
+}
+
+/**
+ * Get the log level of the node serving the request
+ */
+export async function getLogLevel(): Promise<LogLevelInfo> {
+  const { data } = await apiClient.get<LogLevelInfo>('/admin/system/log-level')
+  return data
+}
+
+/**
+ * Change the log level at runtime (per node, not persisted)
+ */
+export async function setLogLevel(level: string): Promise<LogLevelInfo> {
+  const { data } = await apiClient.put<LogLevelInfo>('/admin/system/log-level', { level })
+  return data
+}
+
 export const systemAPI = {
   getVersion,
   checkUpdates,
   performUpdate,
   rollback,
-  restartService
+  restartService,
+  getLogLevel,
+  setLogLevel
 }
 
 export default systemAPI
@@ -2336,6 +2336,7 @@ export default {
         },
         loading: 'Loading…',
         requestId: 'Request ID',
+        traceId: 'Trace ID',
         time: 'Time',
         phase: 'Phase',
         status: 'Status',
@@ -2497,7 +2498,9 @@ export default {
         metricGroups: {
           system: 'System Metrics',
           group: 'Group-level Metrics (requires group_id)',
-          account: 'Account-level Metrics'
+          account: 'Account-level Metrics',
+          spend: 'Spend Metrics',
+          capacity: 'Capacity Forecast'
         },
         metrics: {
           successRate: 'Success Rate (%)',
@@ -2514,7 +2517,13 @@ export default {
           accountRateLimitedCount: 'Rate-limited Accounts',
           accountErrorCount: 'Error Accounts (excluding temporarily unschedulable)',
           accountErrorRatio: 'Error Account Ratio (%)',
-          overloadAccountCount: 'Overloaded Accounts'
+          overloadAccountCount: 'Overloaded Accounts',
+          spendUsd: 'Total Spend (USD)',
+          maxUserSpendUsd: 'Top User Spend (USD)',
+          maxApiKeySpendUsd: 'Top API Key Spend (USD)',
+          spendAnomalyPendingCount: 'Pending Spend Anomalies',
+          groupHoursToExhaustion: 'Hours to Capacity Exhaustion',
+          groupRecommendedAccounts: 'Recommended Additional Accounts'
         },
         metricDescriptions: {
           successRate: 'Percentage of successful requests in the window (0-100).',
@@ -2531,7 +2540,13 @@ export default {
           accountRateLimitedCount: 'Number of rate-limited accounts within the window.',
           accountErrorCount: 'Number of error accounts within the window (excluding temporarily unschedulable).',
           accountErrorRatio: 'Error account ratio within the window (0-100).',
-          overloadAccountCount: 'Number of overloaded accounts within the window.'
+          overloadAccountCount: 'Number of overloaded accounts within the window.',
+          spendUsd: 'Total billed spend within the window (USD, optional group_id).',
+          maxUserSpendUsd: 'Highest spend of a single user within the window (USD, optional group_id).',
+          maxApiKeySpendUsd: 'Highest spend of a single API key within the window (USD, optional group_id).',
+          spendAnomalyPendingCount: 'Number of spend anomaly flags waiting for review.',
+          groupHoursToExhaustion: 'Predicted hours until the account pool can no longer serve recent demand (optional group_id; worst group otherwise; equals the forecast horizon when no exhaustion is predicted).',
+          groupRecommendedAccounts: 'Accounts to add so sustainable capacity covers recent demand plus headroom (optional group_id; summed across groups otherwise).'
         },
         hints: {
           recommended: 'Recommended: operator {operator}, threshold {threshold}{unit}',
@@ -2752,6 +2767,50 @@ export default {
         errorAccounts: 'Errors {count}',
         loadFailed: 'Failed to load concurrency data'
       },
+      cluster: {
+        title: 'Cluster Nodes',
+        onlineCount: '{online}/{total} online',
+        self: 'this node',
+        heartbeatAge: 'heartbeat {seconds}s ago',
+        empty: 'No nodes registered',
+        loadFailed: 'Failed to load cluster nodes',
+        versionMismatch: 'Nodes are running different versions. ',
+        configMismatch: 'Nodes are running with different configuration (config hash differs).',
+        rollingRestart: 'Rolling restart',
+        rollingUpdate: 'Rolling update',
+        rolloutConfirm: 'Process all {count} online nodes one at a time? Each node waits for the previous one to come back; this node goes last.',
+        rolloutStarted: 'Rollout started',
+        rolloutFailed: 'Failed to start rollout',
+        rolloutTitle: 'Latest {action}',
+        actions: {
+          update: 'Rolling update',
+          restart: 'Rolling restart'
+        },
+        rolloutStatus: {
+          running: 'Running',
+          completed: 'Completed',
+          failed: 'Failed'
+        },
+        stepStatus: {
+          pending: 'Pending',
+          running: 'Running',
+          restarting: 'Restarting',
+          done: 'Done',
+          failed: 'Failed',
+          skipped: 'Skipped'
+        },
+        table: {
+          node: 'Node',
+          version: 'Version',
+          uptime: 'Uptime',
+          inFlight: 'In-flight',
+          rps: 'RPS',
+          goroutines: 'Goroutines',
+          heap: 'Heap',
+          configHash: 'Config',
+          locks: 'Leader locks'
+        }
+      },
       realtime: {
         title: 'Realtime',
         connected: 'Realtime connected',
@@ -2489,6 +2489,7 @@ export default {
         },
         loading: '加载中…',
         requestId: '请求 ID',
+        traceId: '追踪 ID',
         time: '时间',
         phase: '阶段',
         status: '状态码',
@@ -2650,7 +2651,9 @@ export default {
         metricGroups: {
           system: '系统指标',
           group: '分组级别指标（需 group_id）',
-          account: '账号级别指标'
+          account: '账号级别指标',
+          spend: '消费指标',
+          capacity: '容量预测'
         },
         metrics: {
           successRate: '成功率 (%)',
@@ -2667,7 +2670,13 @@ export default {
           accountRateLimitedCount: '限流账号数',
           accountErrorCount: '错误账号数（不含临时不可调度）',
           accountErrorRatio: '错误账号比例 (%)',
-          overloadAccountCount: '过载账号数'
+          overloadAccountCount: '过载账号数',
+          spendUsd: '总消费（美元）',
+          maxUserSpendUsd: '单用户最高消费（美元）',
+          maxApiKeySpendUsd: '单 API Key 最高消费（美元）',
+          spendAnomalyPendingCount: '待审查消费异常数',
+          groupHoursToExhaustion: '预测容量耗尽时间（小时）',
+          groupRecommendedAccounts: '建议扩容账号数'
         },
         metricDescriptions: {
           successRate: '统计窗口内成功请求占比（0~100）。',
@@ -2684,7 +2693,13 @@ export default {
           accountRateLimitedCount: '统计窗口内被限流的账号数量。',
           accountErrorCount: '统计窗口内产生错误的账号数量（不含临时不可调度）。',
           accountErrorRatio: '统计窗口内错误账号占比（0~100）。',
-          overloadAccountCount: '统计窗口内过载账号数量。'
+          overloadAccountCount: '统计窗口内过载账号数量。',
+          spendUsd: '统计窗口内的计费总消费（美元，可选 group_id）。',
+          maxUserSpendUsd: '统计窗口内单个用户的最高消费（美元，可选 group_id）。',
+          maxApiKeySpendUsd: '统计窗口内单个 API Key 的最高消费（美元，可选 group_id）。',
+          spendAnomalyPendingCount: '等待审查的消费异常记录数量。',
+          groupHoursToExhaustion: '按近期需求预测账号池无法满足请求的剩余小时数（可选 group_id，未指定时取最紧张的分组；预测期内不会耗尽时等于预测时长）。',
+          groupRecommendedAccounts: '使可持续容量覆盖近期需求及余量所需新增的账号数（可选 group_id，未指定时为所有分组之和）。'
         },
         hints: {
           recommended: '推荐：运算符 {operator}，阈值 {threshold}{unit}',
@@ -2905,6 +2920,50 @@ export default {
         errorAccounts: '异常 {count}',
         loadFailed: '加载并发数据失败'
       },
+      cluster: {
+        title: '集群节点',
+        onlineCount: '{online}/{total} 在线',
+        self: '当前节点',
+        heartbeatAge: '{seconds} 秒前心跳',
+        empty: '暂无已注册节点',
+        loadFailed: '加载集群节点失败',
+        versionMismatch: '各节点运行的版本不一致。',
+        configMismatch: '各节点配置不一致（配置摘要不同）。',
+        rollingRestart: '滚动重启',
+        rollingUpdate: '滚动更新',
+        rolloutConfirm: '将逐个处理全部 {count} 个在线节点：每个节点恢复后才处理下一个，当前节点最后处理。确定继续？',
+        rolloutStarted: '已开始滚动操作',
+        rolloutFailed: '启动滚动操作失败',
+        rolloutTitle: '最近一次{action}',
+        actions: {
+          update: '滚动更新',
+          restart: '滚动重启'
+        },
+        rolloutStatus: {
+          running: '进行中',
+          completed: '已完成',
+          failed: '失败'
+        },
+        stepStatus: {
+          pending: '等待中',
+          running: '进行中',
+          restarting: '重启中',
+          done: '完成',
+          failed: '失败',
+          skipped: '已跳过'
+        },
+        table: {
+          node: '节点',
+          version: '版本',
+          uptime: '运行时长',
+          inFlight: '进行中',
+          rps: 'RPS',
+          goroutines: '协程',
+          heap: '堆内存',
+          configHash: '配置',
+          locks: 'Leader 锁'
+        }
+      },
       realtime: {
         title: '实时信息',
         connected: '实时已连接',